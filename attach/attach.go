@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/output"
 	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
@@ -51,8 +52,9 @@ func Upload(endpoint string, identifyingKey string, timestamp string, dependenci
 	log.Debugf("Attempting to attach file with key: %s\n", identifyingKey)
 	var filesToUpload []UploadFiles
 
-	zipfile := getFilesForUpload(identifyingKey, timestamp, "zip", dependencies)
-	jsonfile := getFilesForUpload(identifyingKey, timestamp, "json", dependencies)
+	// the names include the encryption extension when -encrypt-to is used, so the encrypted files are what gets uploaded
+	zipfile := getFilesForUpload(identifyingKey, timestamp, output.ZipFileName(), dependencies)
+	jsonfile := getFilesForUpload(identifyingKey, timestamp, output.JSONFileName(), dependencies)
 
 	filesToUpload = append(filesToUpload, zipfile)
 	filesToUpload = append(filesToUpload, jsonfile)
@@ -82,13 +84,12 @@ func Upload(endpoint string, identifyingKey string, timestamp string, dependenci
 
 }
 
-func getFilesForUpload(identifyingKey string, timestamp string, filetype string, deps IAttachDeps) UploadFiles {
-	thisFileName := "nrdiag-output." + filetype
+func getFilesForUpload(identifyingKey string, timestamp string, thisFileName string, deps IAttachDeps) UploadFiles {
 	thisFile := UploadFiles{Path: config.Flags.OutputPath, Filename: thisFileName}
 	thisFile.Filesize = deps.GetFileSize(thisFile.Path + "/" + thisFile.Filename)
-	extension := filepath.Ext(thisFileName)
-	shortName := thisFileName[0 : len(thisFileName)-len(extension)]
-	thisFile.NewFilename = shortName + "-" + timestamp + extension
+	// the timestamp goes before every extension: nrdiag-output.zip.age becomes nrdiag-output-<timestamp>.zip.age
+	shortName, extension, _ := strings.Cut(thisFileName, ".")
+	thisFile.NewFilename = shortName + "-" + timestamp + "." + extension
 	log.Debug("Renamed file from", thisFileName, " to ", thisFile.NewFilename)
 	return thisFile
 }
@@ -125,6 +125,34 @@ func Test_uploadFilesToAccount(t *testing.T) {
 		})
 	}
 }
+func Test_getFilesForUpload(t *testing.T) {
+	outputPath := config.Flags.OutputPath
+	config.Flags.OutputPath = "/tmp"
+	defer func() { config.Flags.OutputPath = outputPath }()
+
+	tests := []struct {
+		filename    string
+		newFilename string
+	}{
+		{"nrdiag-output.zip", "nrdiag-output-timestamp.zip"},
+		{"nrdiag-output.json", "nrdiag-output-timestamp.json"},
+		{"nrdiag-output.zip.age", "nrdiag-output-timestamp.zip.age"},
+		{"nrdiag-output.json.gpg", "nrdiag-output-timestamp.json.gpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			mDeps := new(mocks.MAttachDeps)
+			mDeps.On("GetFileSize", "/tmp/"+tt.filename).Return(int64(42))
+
+			got := getFilesForUpload("key", "timestamp", tt.filename, mDeps)
+			want := UploadFiles{Path: "/tmp", Filename: tt.filename, NewFilename: tt.newFilename, Filesize: 42}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("getFilesForUpload() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
 func Test_uploadFile(t *testing.T) {
 	setup()
 	defer teardown()
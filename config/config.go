@@ -44,6 +44,9 @@ type userFlags struct {
 	Proxy              string
 	ProxyUser          string
 	ProxyPassword      string
+	ProxyAuth          string
+	ProxyPAC           string
+	CABundle           string
 	Tasks              string
 	ConfigFile         string
 	Override           string
@@ -56,6 +59,15 @@ type userFlags struct {
 	APIKey             string
 	Region             string
 	Script             string
+	TaskDir            string
+	PluginDir          string
+	Fix                bool
+	FixDryRun          bool
+	FixRollback        string
+	Verify             string
+	VerifyDigest       string
+	EncryptTo          string
+	EncryptJSON        bool
 	ScriptFlags        string
 	K8sNamespace       string
 	ACAgentsNamespace  string
@@ -70,7 +82,7 @@ type ConfigFlag struct {
 // MarshalJSON - custom JSON marshaling for this task, we'll strip out the passphrase to keep it only in memory, not on disk
 func (f userFlags) MarshalJSON() ([]byte, error) {
 	proxySpecified := false
-	if f.Proxy != "" || f.ProxyPassword != "" || f.ProxyUser != "" {
+	if f.Proxy != "" || f.ProxyPassword != "" || f.ProxyUser != "" || f.ProxyPAC != "" {
 		proxySpecified = true
 	}
 
@@ -99,6 +111,16 @@ func (f userFlags) MarshalJSON() ([]byte, error) {
 		ScriptFlags       string
 		K8sNamespace      string
 		ACAgentsNamespace string
+		ProxyAuth         string
+		CABundle          bool
+		TaskDir           bool
+		PluginDir         bool
+		Fix               bool
+		FixDryRun         bool
+		FixRollback       bool
+		Verify            bool
+		EncryptTo         bool
+		EncryptJSON       bool
 	}{
 		Verbose:           f.Verbose,
 		Quiet:             f.Quiet,
@@ -124,6 +146,16 @@ func (f userFlags) MarshalJSON() ([]byte, error) {
 		ScriptFlags:       f.ScriptFlags,
 		K8sNamespace:      f.K8sNamespace,
 		ACAgentsNamespace: f.ACAgentsNamespace,
+		ProxyAuth:         f.ProxyAuth,
+		CABundle:          boolifyFlag(f.CABundle),
+		TaskDir:           boolifyFlag(f.TaskDir),
+		PluginDir:         boolifyFlag(f.PluginDir),
+		Fix:               f.Fix,
+		FixDryRun:         f.FixDryRun,
+		FixRollback:       boolifyFlag(f.FixRollback),
+		Verify:            boolifyFlag(f.Verify),
+		EncryptTo:         boolifyFlag(f.EncryptTo),
+		EncryptJSON:       f.EncryptJSON,
 	})
 }
 
@@ -205,6 +237,12 @@ func ParseFlags() {
 	flag.StringVar(&Flags.ProxyUser, "proxy-user", defaultString, "Proxy username, if necessary")
 	flag.StringVar(&Flags.ProxyPassword, "proxy-pw", defaultString, "Proxy password, if necessary")
 
+	flag.StringVar(&Flags.ProxyAuth, "proxy-auth", defaultString, "Proxy authentication scheme to use with -proxy-user and -proxy-pw. Accepted values: basic, ntlm, negotiate (NTLM credentials sent to a Negotiate proxy, Kerberos is not used). Use DOMAIN\\user as -proxy-user for a domain account. (Default: basic)")
+
+	flag.StringVar(&Flags.ProxyPAC, "proxy-pac", defaultString, "URL or file path of a proxy auto-config (PAC) file whose FindProxyForURL picks the proxy for each endpoint when -proxy is not used. Only the first entry of its answer is used, without failover")
+
+	flag.StringVar(&Flags.CABundle, "ca-bundle", defaultString, "Path to a PEM file of additional CA certificates to trust for the Diagnostics CLI's own connections (uploads, version checks, script downloads), e.g. a corporate TLS inspection CA")
+
 	flag.StringVar(&Flags.Override, "o", defaultString, "alias for -override")
 	flag.StringVar(&Flags.Override, "override", defaultString, "Specify overrides for detected values. Format <Identifier>.<property>=<value> - example '-o Base/Config/Validate.agentLanguage=PHP'")
 
@@ -239,6 +277,24 @@ func ParseFlags() {
 
 	flag.BoolVar(&Flags.Run, "run", false, "Use with -script to run the script")
 
+	flag.StringVar(&Flags.TaskDir, "task-dir", defaultString, "Directory of declarative task definitions (*.yml) to register in addition to the built-in tasks")
+
+	flag.StringVar(&Flags.PluginDir, "plugin-dir", defaultString, "Directory of plugin executables that provide additional tasks. Every executable in it is run to describe its tasks, so only point this at trusted plugins")
+
+	flag.BoolVar(&Flags.Fix, "fix", false, "Offer to fix well-known problems found by the tasks. Every change is confirmed first, files are backed up and a rollback manifest is added to the zip")
+
+	flag.BoolVar(&Flags.FixDryRun, "fix-dry-run", false, "Print the changes -fix would make, without making them")
+
+	flag.StringVar(&Flags.FixRollback, "fix-rollback", defaultString, "Revert the changes recorded in a rollback manifest (nrdiag-fix-rollback.json) written by -fix")
+
+	flag.StringVar(&Flags.Verify, "verify", defaultString, "Check that the files in a nrdiag-output.zip match the checksums in its manifest.json. The manifest is inside the zip, so without -verify-digest this only detects accidental corruption, not edits made together with a regenerated manifest")
+
+	flag.StringVar(&Flags.VerifyDigest, "verify-digest", defaultString, "Use with -verify: the manifest SHA-256 printed when the zip was created, obtained separately from the zip, to also detect a replaced manifest")
+
+	flag.StringVar(&Flags.EncryptTo, "encrypt-to", defaultString, "Encrypt nrdiag-output.zip to the age recipients or PGP public key in this file as it is written. Decrypt it with 'nrdiag decrypt'")
+
+	flag.BoolVar(&Flags.EncryptJSON, "encrypt-json", false, "Use with -encrypt-to to also encrypt nrdiag-output.json")
+
 	//if first arg looks like it was build with `go build`, then we are testing against Haberdasher staging or localhost endpoint
 	if strings.Contains(os.Args[0], "newrelic-diagnostics-cli") {
 		flag.StringVar(&Flags.AttachmentEndpoint, "attachment-endpoint", defaultString, "The endpoint to send attachments to. (NR ONLY)")
@@ -315,6 +371,17 @@ func (f userFlags) UsagePayload() []ConfigFlag {
 		{Name: "script", Value: f.Script},
 		{Name: "k8sNamespace", Value: f.K8sNamespace},
 		{Name: "aCAgentsNamespace", Value: f.ACAgentsNamespace},
+		{Name: "proxyAuth", Value: f.ProxyAuth},
+		{Name: "proxyPAC", Value: boolifyFlag(f.ProxyPAC)},
+		{Name: "caBundle", Value: boolifyFlag(f.CABundle)},
+		{Name: "taskDir", Value: boolifyFlag(f.TaskDir)},
+		{Name: "pluginDir", Value: boolifyFlag(f.PluginDir)},
+		{Name: "fix", Value: f.Fix},
+		{Name: "fixDryRun", Value: f.FixDryRun},
+		{Name: "fixRollback", Value: boolifyFlag(f.FixRollback)},
+		{Name: "verify", Value: boolifyFlag(f.Verify)},
+		{Name: "encryptTo", Value: boolifyFlag(f.EncryptTo)},
+		{Name: "encryptJSON", Value: f.EncryptJSON},
 	}
 }
 
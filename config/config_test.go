@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,6 +23,9 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		Proxy              string
 		ProxyUser          string
 		ProxyPassword      string
+		ProxyAuth          string
+		ProxyPAC           string
+		CABundle           string
 		Tasks              string
 		ConfigFile         string
 		Override           string
@@ -33,6 +38,14 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		APIKey             string
 		Region             string
 		Script             string
+		TaskDir            string
+		PluginDir          string
+		Fix                bool
+		FixDryRun          bool
+		FixRollback        string
+		Verify             string
+		EncryptTo          string
+		EncryptJSON        bool
 		K8sNamespace       string
 		ACAgentsNamespace  string
 	}
@@ -50,6 +63,9 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		Proxy:              "string",
 		ProxyUser:          "string",
 		ProxyPassword:      "",
+		ProxyAuth:          "basic",
+		ProxyPAC:           "",
+		CABundle:           "string",
 		Tasks:              "string",
 		ConfigFile:         "string",
 		Override:           "",
@@ -62,6 +78,14 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		APIKey:             "string",
 		Region:             "string",
 		Script:             "string",
+		TaskDir:            "string",
+		PluginDir:          "",
+		Fix:                true,
+		FixDryRun:          false,
+		FixRollback:        "",
+		Verify:             "string",
+		EncryptTo:          "string",
+		EncryptJSON:        true,
 		K8sNamespace:       "string",
 		ACAgentsNamespace:  "string",
 	}
@@ -92,6 +116,17 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		{Name: "script", Value: "string"},
 		{Name: "k8sNamespace", Value: "string"},
 		{Name: "aCAgentsNamespace", Value: "string"},
+		{Name: "proxyAuth", Value: "basic"},
+		{Name: "proxyPAC", Value: false},
+		{Name: "caBundle", Value: true},
+		{Name: "taskDir", Value: true},
+		{Name: "pluginDir", Value: false},
+		{Name: "fix", Value: true},
+		{Name: "fixDryRun", Value: false},
+		{Name: "fixRollback", Value: false},
+		{Name: "verify", Value: true},
+		{Name: "encryptTo", Value: true},
+		{Name: "encryptJSON", Value: true},
 	}
 
 	tests := []struct {
@@ -121,6 +156,9 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 				Proxy:              tt.fields.Proxy,
 				ProxyUser:          tt.fields.ProxyUser,
 				ProxyPassword:      tt.fields.ProxyPassword,
+				ProxyAuth:          tt.fields.ProxyAuth,
+				ProxyPAC:           tt.fields.ProxyPAC,
+				CABundle:           tt.fields.CABundle,
 				Tasks:              tt.fields.Tasks,
 				ConfigFile:         tt.fields.ConfigFile,
 				Override:           tt.fields.Override,
@@ -133,6 +171,14 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 				APIKey:             tt.fields.APIKey,
 				Region:             tt.fields.Region,
 				Script:             tt.fields.Script,
+				TaskDir:            tt.fields.TaskDir,
+				PluginDir:          tt.fields.PluginDir,
+				Fix:                tt.fields.Fix,
+				FixDryRun:          tt.fields.FixDryRun,
+				FixRollback:        tt.fields.FixRollback,
+				Verify:             tt.fields.Verify,
+				EncryptTo:          tt.fields.EncryptTo,
+				EncryptJSON:        tt.fields.EncryptJSON,
 				K8sNamespace:       tt.fields.K8sNamespace,
 				ACAgentsNamespace:  tt.fields.ACAgentsNamespace,
 			}
@@ -308,3 +354,26 @@ func Test_parseRegionFlagAndEnv(t *testing.T) {
 		})
 	}
 }
+
+func Test_userFlags_MarshalJSON_paths(t *testing.T) {
+	f := userFlags{
+		CABundle:    "/etc/corp/ca.pem",
+		TaskDir:     "/opt/corp/tasks",
+		PluginDir:   "/opt/corp/plugins",
+		FixRollback: "/tmp/nrdiag-fix-rollback.json",
+		Verify:      "/tmp/nrdiag-output.zip",
+		EncryptTo:   "/home/support/key.asc",
+	}
+	marshaled, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(marshaled), "/") {
+		t.Errorf("MarshalJSON() = %s, want the path flags recorded as set, not their paths", marshaled)
+	}
+	for _, name := range []string{"CABundle", "TaskDir", "PluginDir", "FixRollback", "Verify", "EncryptTo"} {
+		if !strings.Contains(string(marshaled), `"`+name+`":true`) {
+			t.Errorf("MarshalJSON() = %s, want %s set to true", marshaled, name)
+		}
+	}
+}
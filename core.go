@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"sync"
+	"time"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	"github.com/newrelic/newrelic-diagnostics-cli/internal/haberdasher"
@@ -15,7 +16,11 @@ import (
 )
 
 func main() {
+	startTime := time.Now()
 	runID := generateRunID()
+	if len(os.Args) > 1 && os.Args[1] == "decrypt" {
+		os.Exit(processDecrypt(os.Args[2:]))
+	}
 	config.ParseFlags()
 	log.Debug("---------------------------------------------------------------------------------------------")
 	log.Debugf("Running nrdiag with version: %s and build timestamp %s\n", config.Version, config.BuildTimestamp)
@@ -31,6 +36,18 @@ func main() {
 
 	options, overrides := processOverrides()
 
+	if config.Flags.FixRollback != "" {
+		processFixRollback()
+	}
+
+	if config.Flags.Verify != "" {
+		processVerify()
+	}
+
+	if config.Flags.EncryptTo != "" || config.Flags.EncryptJSON {
+		processEncryption()
+	}
+
 	// Setup Haberdasher client
 	haberdasher.InitializeDefaultClient()
 	haberdasher.DefaultClient.SetRunID(runID)
@@ -94,6 +111,12 @@ func main() {
 
 	}
 
+	// Plugins are run to describe themselves when they are loaded, so external tasks are only loaded once nrdiag lists or runs tasks
+	if config.Flags.Help || !config.Flags.Version {
+		processTaskDir()
+		processPluginDir()
+	}
+
 	go processTasksToRun()
 
 	// if statements for doing stuff with args
@@ -140,9 +163,14 @@ func main() {
 		}
 
 		// block on wait group so program does not exit prematurely
-		log.Info(color.ColorString(color.White, "Creating nrdiag-output.zip"))
+		log.Info(color.ColorString(color.White, "Creating "+output.ZipFileName()))
 		wg.Wait()
 
+		// offer the fixes for the problems found, before the output file is written so the rollback manifest makes it into the zip
+		if config.Flags.Fix || config.Flags.FixDryRun {
+			processRemediations(outputResults, options, zipfile)
+		}
+
 		// creates the output file
 		output.WriteOutputFile(outputResults, scriptData)
 
@@ -158,8 +186,14 @@ func main() {
 		// copy the file list to the zip file last to ensure it's up to date
 		output.CopyFileListToZip(zipfile)
 
+		// the manifest describes every other entry so it has to be written after them
+		manifestDigest := output.WriteManifestToZip(zipfile, runID, startTime)
+
 		// ...and close it out
 		output.CloseZip(zipfile)
+		if manifestDigest != "" {
+			log.Infof("Manifest SHA-256: %s\nShare it separately from the zip file so '-verify <zip> -verify-digest %s' can detect edits to the zip.\n", manifestDigest, manifestDigest)
+		}
 
 		// upload any files (zip and json)
 		processUploads()
@@ -3,15 +3,13 @@ package main
 import (
 	"bufio"
 	"fmt"
-	"net"
-	"net/http"
 	"os"
-	"time"
 
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -51,12 +49,17 @@ func parseOverrides(overrides string) []override {
 	return sliceOverrides
 }
 
-func ProxyParseNSet() (set bool) {
-	//This sets the default
-	var DefaultDialer = &net.Dialer{Timeout: 1000 * time.Millisecond}
-	http.DefaultTransport = &http.Transport{Dial: DefaultDialer.Dial, Proxy: http.ProxyFromEnvironment}
-	return true
-
+// ProxyParseNSet builds the transport used for nrdiag's own HTTP traffic from the proxy and CA flags.
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY are read when each request is made.
+func ProxyParseNSet() error {
+	return httpHelper.ConfigureTransport(httpHelper.TransportConfig{
+		Proxy:         config.Flags.Proxy,
+		ProxyUser:     config.Flags.ProxyUser,
+		ProxyPassword: config.Flags.ProxyPassword,
+		ProxyAuth:     config.Flags.ProxyAuth,
+		ProxyPAC:      config.Flags.ProxyPAC,
+		CABundle:      config.Flags.CABundle,
+	})
 }
 
 // similar to tasks - This takes the input string as the query to the end users and waits for a response
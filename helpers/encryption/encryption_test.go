@@ -0,0 +1,158 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func TestAgeRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := writeFile(t, dir, "recipients.txt", "# support engineer\n"+identity.Recipient().String()+"\n")
+	privateKey := writeFile(t, dir, "key.txt", identity.String()+"\n")
+
+	encryptor, err := NewEncryptor(publicKey)
+	if err != nil {
+		t.Fatalf("NewEncryptor() error = %v", err)
+	}
+	if encryptor.Extension() != ".age" {
+		t.Errorf("Extension() = %q, want .age", encryptor.Extension())
+	}
+	ciphertext := encrypt(t, encryptor, "log_level: finest\n")
+	if bytes.Contains(ciphertext, []byte("log_level")) {
+		t.Fatalf("ciphertext contains the plaintext")
+	}
+
+	if got := decrypt(t, ciphertext, privateKey, nil); got != "log_level: finest\n" {
+		t.Errorf("Decrypt() = %q", got)
+	}
+
+	other, _ := age.GenerateX25519Identity()
+	otherKey := writeFile(t, dir, "other.txt", other.String()+"\n")
+	if _, err := Decrypt(bytes.NewReader(ciphertext), otherKey, nil); err == nil {
+		t.Errorf("Decrypt() with the wrong identity succeeded")
+	}
+}
+
+func TestPGPRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	entity, err := openpgp.NewEntity("Support", "", "support@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := writeFile(t, dir, "support.asc", armorKey(t, openpgp.PublicKeyType, func(w io.Writer) error { return entity.Serialize(w) }))
+	privateKey := writeFile(t, dir, "support-private.asc", armorKey(t, openpgp.PrivateKeyType, func(w io.Writer) error { return entity.SerializePrivate(w, nil) }))
+
+	encryptor, err := NewEncryptor(publicKey)
+	if err != nil {
+		t.Fatalf("NewEncryptor() error = %v", err)
+	}
+	if encryptor.Extension() != ".gpg" {
+		t.Errorf("Extension() = %q, want .gpg", encryptor.Extension())
+	}
+	ciphertext := encrypt(t, encryptor, "license_key: abc\n")
+	if got := decrypt(t, ciphertext, privateKey, nil); got != "license_key: abc\n" {
+		t.Errorf("Decrypt() = %q", got)
+	}
+}
+
+func TestPGPProtectedKey(t *testing.T) {
+	dir := t.TempDir()
+	entity, err := openpgp.NewEntity("Support", "", "support@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := writeFile(t, dir, "support.asc", armorKey(t, openpgp.PublicKeyType, func(w io.Writer) error { return entity.Serialize(w) }))
+	if err := entity.EncryptPrivateKeys([]byte("hunter2"), nil); err != nil {
+		t.Fatal(err)
+	}
+	privateKey := writeFile(t, dir, "support-private.asc", armorKey(t, openpgp.PrivateKeyType, func(w io.Writer) error { return entity.SerializePrivateWithoutSigning(w, nil) }))
+
+	encryptor, err := NewEncryptor(publicKey)
+	if err != nil {
+		t.Fatalf("NewEncryptor() error = %v", err)
+	}
+	ciphertext := encrypt(t, encryptor, "secret")
+
+	if _, err := Decrypt(bytes.NewReader(ciphertext), privateKey, nil); !errors.Is(err, ErrPassphrase) {
+		t.Errorf("Decrypt() without a passphrase error = %v, want ErrPassphrase", err)
+	}
+	if _, err := Decrypt(bytes.NewReader(ciphertext), privateKey, []byte("wrong")); !errors.Is(err, ErrPassphrase) {
+		t.Errorf("Decrypt() with the wrong passphrase error = %v, want ErrPassphrase", err)
+	}
+	if got := decrypt(t, ciphertext, privateKey, []byte("hunter2")); got != "secret" {
+		t.Errorf("Decrypt() = %q", got)
+	}
+}
+
+func TestNewEncryptor_invalidKeyFile(t *testing.T) {
+	keyFile := writeFile(t, t.TempDir(), "key.txt", "not a key\n")
+	if _, err := NewEncryptor(keyFile); err == nil || !strings.Contains(err.Error(), "does not contain age recipients or a PGP public key") {
+		t.Errorf("NewEncryptor() error = %v", err)
+	}
+	if _, err := NewEncryptor(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("NewEncryptor() with a missing file succeeded")
+	}
+}
+
+func encrypt(t *testing.T, encryptor *Encryptor, plaintext string) []byte {
+	t.Helper()
+	var ciphertext bytes.Buffer
+	writer, err := encryptor.Encrypt(&ciphertext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	io.WriteString(writer, plaintext)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return ciphertext.Bytes()
+}
+
+func decrypt(t *testing.T, ciphertext []byte, identityFile string, passphrase []byte) string {
+	t.Helper()
+	reader, err := Decrypt(bytes.NewReader(ciphertext), identityFile, passphrase)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading the plaintext error = %v", err)
+	}
+	return string(plaintext)
+}
+
+func armorKey(t *testing.T, blockType string, serialize func(io.Writer) error) string {
+	t.Helper()
+	var key bytes.Buffer
+	writer, err := armor.Encode(&key, blockType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := serialize(writer); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	return key.String()
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
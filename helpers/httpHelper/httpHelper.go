@@ -3,7 +3,6 @@ package httpHelper
 import (
 	"errors"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
 	"time"
@@ -76,26 +75,9 @@ func MakeHTTPRequest(wrapper RequestWrapper) (*http.Response, error) {
 		wrapper.TimeoutSeconds = defaultTimeoutSeconds
 	}
 
-	var transport http.RoundTripper
-
-	//All HTTP requests use the same http transport.
-	//However, when bypassing the detected system proxy, the request will be using its own unique http transport.
-	if wrapper.BypassProxy {
-		//These are the http.DefaultTransport values minus the Proxy
-		transport = &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-				DualStack: true,
-			}).DialContext,
-			MaxIdleConns:          100,
-			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		}
-	} else {
-		transport = http.DefaultTransport
-	}
+	//All HTTP requests share the transport built by ConfigureTransport.
+	//When bypassing the detected system proxy, the request uses its direct counterpart, which keeps the CA settings.
+	transport := Transport(wrapper.BypassProxy)
 
 	client := &http.Client{
 		Transport: transport,
@@ -0,0 +1,342 @@
+package httpHelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// pacTimeout bounds a single FindProxyForURL call, so a PAC file stuck in a loop or on a slow
+// DNS lookup cannot hang every request
+var pacTimeout = 5 * time.Second
+
+// pacScript evaluates the FindProxyForURL function of a proxy auto-config file. The runtime is
+// not safe for concurrent use, so calls are serialized.
+type pacScript struct {
+	mu      sync.Mutex
+	vm      *goja.Runtime
+	find    goja.Callable
+	resolve func(host string) (net.IP, error)
+	localIP func() net.IP
+	now     func() time.Time
+}
+
+func newPACScript(script string) (*pacScript, error) {
+	pac := &pacScript{
+		vm:      goja.New(),
+		resolve: lookupIPv4,
+		localIP: outboundIP,
+		now:     time.Now,
+	}
+	pac.defineHelpers()
+
+	done := time.AfterFunc(pacTimeout, func() { pac.vm.Interrupt("timed out") })
+	_, err := pac.vm.RunString(script)
+	done.Stop()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load PAC file: %w", err)
+	}
+	find, ok := goja.AssertFunction(pac.vm.Get("FindProxyForURL"))
+	if !ok {
+		return nil, errors.New("the PAC file does not define FindProxyForURL")
+	}
+	pac.find = find
+	return pac, nil
+}
+
+// proxyFor returns the proxy FindProxyForURL picks for target, or nil for DIRECT. Only the first
+// usable entry of the result is used; the failover entries after it are ignored.
+func (p *pacScript) proxyFor(target *url.URL) (*url.URL, error) {
+	result, err := p.findProxy(target)
+	if err != nil {
+		return nil, err
+	}
+	return parsePACResult(result)
+}
+
+func (p *pacScript) findProxy(target *url.URL) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.vm.ClearInterrupt()
+	done := time.AfterFunc(pacTimeout, func() { p.vm.Interrupt("timed out") })
+	defer done.Stop()
+	// like browsers, the script only gets the scheme and host of https URLs
+	pacURL := target.Scheme + "://" + target.Host + "/"
+	if target.Scheme == "http" {
+		pacURL = target.String()
+	}
+	result, err := p.find(goja.Undefined(), p.vm.ToValue(pacURL), p.vm.ToValue(target.Hostname()))
+	if err != nil {
+		return "", fmt.Errorf("FindProxyForURL failed for %s: %w", target.Host, err)
+	}
+	return result.String(), nil
+}
+
+// parsePACResult reads a FindProxyForURL result such as "PROXY proxy:8080; DIRECT"
+func parsePACResult(result string) (*url.URL, error) {
+	for _, entry := range strings.Split(result, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		scheme := ""
+		switch strings.ToUpper(fields[0]) {
+		case "DIRECT":
+			return nil, nil
+		case "PROXY", "HTTP":
+			scheme = "http"
+		case "HTTPS":
+			scheme = "https"
+		case "SOCKS", "SOCKS5":
+			scheme = "socks5"
+		default:
+			log.Debug("Skipping unsupported PAC entry", entry)
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid PAC entry %q", strings.TrimSpace(entry))
+		}
+		return parseProxyURL(scheme + "://" + fields[1])
+	}
+	return nil, fmt.Errorf("FindProxyForURL returned no usable entry: %q", result)
+}
+
+// defineHelpers adds the functions PAC files expect to find, as defined by Netscape and
+// implemented by browsers
+func (p *pacScript) defineHelpers() {
+	vm := p.vm
+	vm.Set("isPlainHostName", func(host string) bool { return !strings.Contains(host, ".") })
+	vm.Set("dnsDomainIs", func(host, domain string) bool {
+		return strings.HasSuffix(strings.ToLower(host), strings.ToLower(domain))
+	})
+	vm.Set("localHostOrDomainIs", func(host, hostdom string) bool {
+		host, hostdom = strings.ToLower(host), strings.ToLower(hostdom)
+		return host == hostdom || (!strings.Contains(host, ".") && strings.HasPrefix(hostdom, host+"."))
+	})
+	vm.Set("isResolvable", func(host string) bool {
+		_, err := p.resolve(host)
+		return err == nil
+	})
+	vm.Set("dnsResolve", func(host string) goja.Value {
+		ip, err := p.resolve(host)
+		if err != nil {
+			return goja.Null()
+		}
+		return vm.ToValue(ip.String())
+	})
+	vm.Set("myIpAddress", func() string { return p.localIP().String() })
+	vm.Set("isInNet", func(host, pattern, mask string) bool {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			var err error
+			if ip, err = p.resolve(host); err != nil {
+				return false
+			}
+		}
+		patternIP, maskIP := net.ParseIP(pattern).To4(), net.ParseIP(mask).To4()
+		if ip.To4() == nil || patternIP == nil || maskIP == nil {
+			return false
+		}
+		return ip.To4().Mask(net.IPMask(maskIP)).Equal(patternIP.Mask(net.IPMask(maskIP)))
+	})
+	vm.Set("convert_addr", func(addr string) int64 {
+		ip := net.ParseIP(addr).To4()
+		if ip == nil {
+			return 0
+		}
+		return int64(ip[0])<<24 | int64(ip[1])<<16 | int64(ip[2])<<8 | int64(ip[3])
+	})
+	vm.Set("dnsDomainLevels", func(host string) int { return strings.Count(host, ".") })
+	vm.Set("shExpMatch", shExpMatch)
+	vm.Set("weekdayRange", func(call goja.FunctionCall) goja.Value {
+		args, now := pacTimeArgs(call, p.now())
+		return vm.ToValue(weekdayRange(args, now))
+	})
+	vm.Set("timeRange", func(call goja.FunctionCall) goja.Value {
+		args, now := pacTimeArgs(call, p.now())
+		return vm.ToValue(timeRange(args, now))
+	})
+	vm.Set("dateRange", func(call goja.FunctionCall) goja.Value {
+		args, now := pacTimeArgs(call, p.now())
+		return vm.ToValue(dateRange(args, now))
+	})
+	vm.Set("alert", func(message string) { log.Debug("PAC file:", message) })
+}
+
+// shExpMatch matches str against a shell expression where * matches any run of characters and ?
+// a single one
+func shExpMatch(str, shexp string) bool {
+	pattern := regexp.QuoteMeta(shexp)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	matched, _ := regexp.MatchString("^"+pattern+"$", str)
+	return matched
+}
+
+// pacTimeArgs returns the arguments of a time function as strings, without the trailing "GMT",
+// and the time to compare them with
+func pacTimeArgs(call goja.FunctionCall, now time.Time) ([]string, time.Time) {
+	var args []string
+	for _, arg := range call.Arguments {
+		args = append(args, strings.ToUpper(arg.String()))
+	}
+	if len(args) > 0 && args[len(args)-1] == "GMT" {
+		return args[:len(args)-1], now.UTC()
+	}
+	return args, now
+}
+
+var pacWeekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+var pacMonths = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// inRange compares with wrap around, so FRI-MON or 22-6 work
+func inRange(value, start, end int) bool {
+	if start <= end {
+		return value >= start && value <= end
+	}
+	return value >= start || value <= end
+}
+
+func weekdayRange(args []string, now time.Time) bool {
+	if len(args) == 0 {
+		return false
+	}
+	start := indexOf(pacWeekdays, args[0])
+	end := start
+	if len(args) > 1 {
+		end = indexOf(pacWeekdays, args[1])
+	}
+	if start < 0 || end < 0 {
+		return false
+	}
+	return inRange(int(now.Weekday()), start, end)
+}
+
+func timeRange(args []string, now time.Time) bool {
+	values := make([]int, len(args))
+	for i, arg := range args {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			return false
+		}
+		values[i] = value
+	}
+	seconds := func(h, m, s int) int { return h*3600 + m*60 + s }
+	current := seconds(now.Hour(), now.Minute(), now.Second())
+	switch len(values) {
+	case 1:
+		return now.Hour() == values[0]
+	case 2:
+		// an hour range includes the whole end hour
+		return inRange(now.Hour(), values[0], values[1])
+	case 4:
+		return inRange(current, seconds(values[0], values[1], 0), seconds(values[2], values[3], 59))
+	case 6:
+		return inRange(current, seconds(values[0], values[1], values[2]), seconds(values[3], values[4], values[5]))
+	}
+	return false
+}
+
+// dateRange accepts days (1-31), month names and years (four digits), alone or as a start and
+// end made of the same fields, e.g. dateRange(1, "JUN", 15, "AUG")
+func dateRange(args []string, now time.Time) bool {
+	type date struct{ day, month, year int }
+	parse := func(fields []string) (date, []string, bool) {
+		d := date{-1, -1, -1}
+		var kinds []string
+		for _, field := range fields {
+			if month := indexOf(pacMonths, field); month >= 0 {
+				d.month, kinds = month, append(kinds, "month")
+				continue
+			}
+			value, err := strconv.Atoi(field)
+			if err != nil {
+				return d, nil, false
+			}
+			if value > 31 {
+				d.year, kinds = value, append(kinds, "year")
+			} else {
+				d.day, kinds = value, append(kinds, "day")
+			}
+		}
+		return d, kinds, true
+	}
+	// ordinal puts the fields that are set in year, month, day order so dates compare as numbers
+	ordinal := func(d, like date) int {
+		value := 0
+		if like.year >= 0 {
+			value = d.year
+		}
+		if like.month >= 0 {
+			value = value*12 + d.month
+		}
+		if like.day >= 0 {
+			value = value*32 + d.day
+		}
+		return value
+	}
+	today := date{now.Day(), int(now.Month()) - 1, now.Year()}
+
+	if len(args) == 0 || len(args) > 6 {
+		return false
+	}
+	if len(args) == 1 || len(args)%2 == 1 {
+		start, _, ok := parse(args)
+		return ok && ordinal(today, start) == ordinal(start, start)
+	}
+	start, startKinds, ok := parse(args[:len(args)/2])
+	end, endKinds, endOK := parse(args[len(args)/2:])
+	if !ok || !endOK || strings.Join(startKinds, ",") != strings.Join(endKinds, ",") {
+		return false
+	}
+	if start.year >= 0 {
+		// ranges with a year do not wrap around
+		current := ordinal(today, start)
+		return current >= ordinal(start, start) && current <= ordinal(end, start)
+	}
+	return inRange(ordinal(today, start), ordinal(start, start), ordinal(end, start))
+}
+
+func lookupIPv4(host string) (net.IP, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no IPv4 address for " + host)
+	}
+	return ips[0], nil
+}
+
+// outboundIP returns the address of the interface used to reach the internet. Dialing UDP does
+// not send any packet.
+func outboundIP() net.IP {
+	conn, err := net.Dial("udp4", "198.51.100.1:53")
+	if err != nil {
+		return net.IPv4(127, 0, 0, 1)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP
+}
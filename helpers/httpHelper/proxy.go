@@ -0,0 +1,220 @@
+package httpHelper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// proxySelector picks the proxy for a request. Environment variables are read on every call
+// because tasks such as Base/Config/ProxyDetect set HTTP_PROXY after start up.
+type proxySelector struct {
+	explicit *url.URL
+	pac      *pacScript
+	user     *url.Userinfo
+	getenv   func(string) string
+}
+
+func newProxySelector(cfg TransportConfig, rootCAs *x509.CertPool) (proxySelector, error) {
+	selector := proxySelector{getenv: os.Getenv}
+	if cfg.ProxyUser != "" {
+		selector.user = url.UserPassword(cfg.ProxyUser, cfg.ProxyPassword)
+	}
+
+	switch {
+	case cfg.Proxy != "":
+		proxy, err := parseProxyURL(cfg.Proxy)
+		if err != nil {
+			return selector, err
+		}
+		selector.explicit = proxy
+	case cfg.ProxyPAC != "":
+		script, err := readPAC(cfg.ProxyPAC, rootCAs)
+		if err != nil {
+			return selector, err
+		}
+		pac, err := newPACScript(script)
+		if err != nil {
+			return selector, err
+		}
+		log.Debug("Using FindProxyForURL from PAC file " + cfg.ProxyPAC)
+		selector.pac = pac
+	}
+	return selector, nil
+}
+
+func (p proxySelector) proxyForRequest(req *http.Request) (*url.URL, error) {
+	return p.proxyFor(req.URL)
+}
+
+// proxyFor returns the proxy to use for target, or nil to connect directly. A PAC file is
+// evaluated for each target and its DIRECT answers are final. Otherwise HTTPS requests use
+// HTTPS_PROXY and fall back to HTTP_PROXY, since that is where -proxy and
+// Base/Config/ProxyDetect put the proxy they found.
+func (p proxySelector) proxyFor(target *url.URL) (*url.URL, error) {
+	proxy := p.explicit
+	if proxy == nil && p.pac != nil {
+		var err error
+		proxy, err = p.pac.proxyFor(target)
+		if err != nil || proxy == nil {
+			return nil, err
+		}
+	}
+	if proxy == nil {
+		raw := ""
+		if target.Scheme == "https" {
+			raw = p.env("HTTPS_PROXY")
+		}
+		if raw == "" {
+			raw = p.env("HTTP_PROXY")
+		}
+		if raw == "" {
+			return nil, nil
+		}
+		var err error
+		proxy, err = parseProxyURL(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if bypassesProxy(target, p.env("NO_PROXY")) {
+		return nil, nil
+	}
+	if p.user != nil {
+		withUser := *proxy
+		withUser.User = p.user
+		proxy = &withUser
+	}
+	return proxy, nil
+}
+
+// env returns the upper case variable, falling back to the lower case spelling
+func (p proxySelector) env(key string) string {
+	if value := p.getenv(key); value != "" {
+		return value
+	}
+	return p.getenv(strings.ToLower(key))
+}
+
+// parseProxyURL accepts a proxy with or without a scheme, defaulting to http like net/http does
+func parseProxyURL(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	proxy, err := url.Parse(raw)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy address %q", raw)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+		return proxy, nil
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme)
+}
+
+// bypassesProxy reports whether target matches an entry in noProxy. Entries follow the
+// conventions of net/http: "*" matches everything, "example.com" matches the domain and its
+// subdomains, ".example.com" and "*.example.com" only match subdomains, IP addresses and CIDR
+// ranges match literal addresses, and any entry can be limited to a port with ":port".
+// Loopback addresses never go through a proxy.
+func bypassesProxy(target *url.URL, noProxy string) bool {
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		port = defaultPort(target.Scheme)
+	}
+	ip := net.ParseIP(host)
+	if host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryIP := net.ParseIP(strings.Trim(entryHost, "[]")); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost = strings.TrimPrefix(entryHost, "*")
+		if strings.HasPrefix(entryHost, ".") {
+			if strings.HasSuffix(host, entryHost) {
+				return true
+			}
+			continue
+		}
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultPort(scheme string) string {
+	if scheme == "http" {
+		return "80"
+	}
+	return "443"
+}
+
+// readPAC loads a PAC file from an http(s) URL, a file:// URL or a plain path. PAC files are
+// served from inside the network, so they are fetched without a proxy.
+func readPAC(location string, rootCAs *x509.CertPool) (string, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		client := &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}},
+			Timeout:   10 * time.Second,
+		}
+		resp, err := client.Get(location)
+		if err != nil {
+			return "", fmt.Errorf("unable to download PAC file: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", errors.New("unable to download PAC file: " + resp.Status)
+		}
+		script, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("unable to download PAC file: %w", err)
+		}
+		return string(script), nil
+	}
+
+	script, err := os.ReadFile(strings.TrimPrefix(location, "file://"))
+	if err != nil {
+		return "", fmt.Errorf("unable to read PAC file: %w", err)
+	}
+	return string(script), nil
+}
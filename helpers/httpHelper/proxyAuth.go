@@ -0,0 +1,181 @@
+package httpHelper
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const basicProxyAuth = "basic"
+
+// maxProxyAuthRounds bounds the CONNECT handshake. NTLM needs three requests, Negotiate two.
+const maxProxyAuthRounds = 4
+
+// ProxyAuthenticator answers the challenges of a proxy authentication scheme such as NTLM or
+// Negotiate. Authorization returns the Proxy-Authorization value for the next CONNECT request;
+// challenges holds the Proxy-Authenticate values of the previous 407 response and is empty on
+// the first attempt. Returning an empty value ends the handshake.
+type ProxyAuthenticator interface {
+	Authorization(proxy *url.URL, challenges []string) (string, error)
+}
+
+// ProxyAuthenticatorFactory creates a ProxyAuthenticator from the -proxy-user and -proxy-pw values
+type ProxyAuthenticatorFactory func(user, password string) (ProxyAuthenticator, error)
+
+var (
+	proxyAuthMu         sync.RWMutex
+	proxyAuthenticators = map[string]ProxyAuthenticatorFactory{
+		"ntlm":      newNTLMAuthenticatorFactory("NTLM"),
+		"negotiate": newNTLMAuthenticatorFactory("Negotiate"),
+	}
+)
+
+// RegisterProxyAuthenticator makes a proxy authentication scheme available to -proxy-auth. Basic,
+// NTLM and Negotiate are built in; other schemes are registered by builds that ship an
+// implementation.
+func RegisterProxyAuthenticator(scheme string, factory ProxyAuthenticatorFactory) {
+	proxyAuthMu.Lock()
+	defer proxyAuthMu.Unlock()
+	proxyAuthenticators[strings.ToLower(scheme)] = factory
+}
+
+// ProxyAuthSchemes lists the proxy authentication schemes accepted by -proxy-auth
+func ProxyAuthSchemes() []string {
+	proxyAuthMu.RLock()
+	defer proxyAuthMu.RUnlock()
+	schemes := []string{basicProxyAuth}
+	for scheme := range proxyAuthenticators {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes[1:])
+	return schemes
+}
+
+func newProxyAuthenticator(scheme, user, password string) (ProxyAuthenticator, error) {
+	proxyAuthMu.RLock()
+	factory, ok := proxyAuthenticators[scheme]
+	proxyAuthMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("proxy authentication scheme %q is not available, supported schemes: %s", scheme, strings.Join(ProxyAuthSchemes(), ", "))
+	}
+	return factory(user, password)
+}
+
+// tunnelDialer opens connections through a CONNECT tunnel, running the authentication
+// handshake on the tunnel's own connection
+type tunnelDialer struct {
+	proxyFor  func(*url.URL) (*url.URL, error)
+	auth      ProxyAuthenticator
+	dial      func(ctx context.Context, network, addr string) (net.Conn, error)
+	rootCAs   *x509.CertPool
+	handshake time.Duration
+}
+
+// DialContext connects to addr, through the proxy when one applies. The transport only hands
+// over host:port, so the target scheme used for proxy selection is inferred from the port.
+func (d tunnelDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	target := &url.URL{Scheme: "https", Host: addr}
+	if _, port, err := net.SplitHostPort(addr); err == nil && port == "80" {
+		target.Scheme = "http"
+	}
+	proxy, err := d.proxyFor(target)
+	if err != nil {
+		return nil, err
+	}
+	if proxy == nil {
+		return d.dial(ctx, network, addr)
+	}
+	if proxy.Scheme == "socks5" {
+		return nil, errors.New("proxy authentication schemes other than basic are not supported for SOCKS proxies")
+	}
+
+	proxyAddr := proxy.Host
+	if proxy.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxy.Hostname(), defaultPort(proxy.Scheme))
+	}
+	conn, err := d.dial(ctx, network, proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(d.handshake))
+	}
+	if proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxy.Hostname(), RootCAs: d.rootCAs})
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if err := d.connect(conn, proxy, addr); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (d tunnelDialer) connect(conn net.Conn, proxy *url.URL, addr string) error {
+	reader := bufio.NewReader(conn)
+	var challenges []string
+	for round := 0; round < maxProxyAuthRounds; round++ {
+		authorization, err := d.auth.Authorization(proxy, challenges)
+		if err != nil {
+			return fmt.Errorf("proxy authentication failed: %w", err)
+		}
+		if round > 0 && authorization == "" {
+			break
+		}
+
+		req := &http.Request{
+			Method: http.MethodConnect,
+			URL:    &url.URL{Opaque: addr},
+			Host:   addr,
+			Header: http.Header{},
+		}
+		if authorization != "" {
+			req.Header.Set("Proxy-Authorization", authorization)
+		}
+		if err := req.Write(conn); err != nil {
+			return err
+		}
+		resp, err := http.ReadResponse(reader, req)
+		if err != nil {
+			return err
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			if reader.Buffered() > 0 {
+				return errors.New("proxy sent unexpected data after CONNECT")
+			}
+			return nil
+		case http.StatusProxyAuthRequired:
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.Close {
+				return errors.New("proxy closed the connection during authentication")
+			}
+			challenges = resp.Header.Values("Proxy-Authenticate")
+		default:
+			resp.Body.Close()
+			return fmt.Errorf("proxy CONNECT to %s failed: %s", addr, resp.Status)
+		}
+	}
+	return errors.New("proxy authentication did not complete: " + http.StatusText(http.StatusProxyAuthRequired))
+}
@@ -0,0 +1,69 @@
+package httpHelper
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/go-ntlmssp"
+)
+
+// ntlmAuthenticator runs the NTLM handshake: a negotiate message, then an authenticate message
+// answering the proxy's challenge. Negotiate proxies are answered with the same NTLM tokens
+// under the Negotiate scheme, the fallback SPNEGO allows when no Kerberos ticket is used.
+type ntlmAuthenticator struct {
+	scheme       string
+	user         string
+	password     string
+	domain       string
+	domainNeeded bool
+}
+
+func newNTLMAuthenticatorFactory(scheme string) ProxyAuthenticatorFactory {
+	return func(user, password string) (ProxyAuthenticator, error) {
+		if user == "" || password == "" {
+			return nil, fmt.Errorf("%s proxy authentication requires -proxy-user and -proxy-pw", scheme)
+		}
+		// DOMAIN\user names carry the domain, user@domain names are sent as they are
+		ntlmUser, domain, domainNeeded := ntlmssp.GetDomain(user)
+		return ntlmAuthenticator{scheme: scheme, user: ntlmUser, password: password, domain: domain, domainNeeded: domainNeeded}, nil
+	}
+}
+
+func (a ntlmAuthenticator) Authorization(proxy *url.URL, challenges []string) (string, error) {
+	if len(challenges) == 0 {
+		negotiate, err := ntlmssp.NewNegotiateMessage(a.domain, "")
+		if err != nil {
+			return "", err
+		}
+		return a.scheme + " " + base64.StdEncoding.EncodeToString(negotiate), nil
+	}
+
+	offered := false
+	for _, challenge := range challenges {
+		scheme, token, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+		if !strings.EqualFold(scheme, a.scheme) {
+			continue
+		}
+		offered = true
+		if token == "" {
+			continue
+		}
+		challengeMessage, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token))
+		if err != nil {
+			return "", fmt.Errorf("invalid %s challenge: %w", a.scheme, err)
+		}
+		authenticate, err := ntlmssp.ProcessChallenge(challengeMessage, a.user, a.password, a.domainNeeded)
+		if err != nil {
+			return "", err
+		}
+		return a.scheme + " " + base64.StdEncoding.EncodeToString(authenticate), nil
+	}
+	if offered {
+		// the proxy started the handshake over, which is how it rejects the credentials
+		return "", errors.New("the proxy rejected the " + a.scheme + " credentials")
+	}
+	return "", fmt.Errorf("the proxy did not offer %s authentication, it asked for: %s", a.scheme, strings.Join(challenges, ", "))
+}
@@ -0,0 +1,195 @@
+package httpHelper
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+func TestNewTransport_ntlmProxyAuth(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "through the tunnel")
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	tests := []struct {
+		name      string
+		auth      string
+		offered   string
+		password  string
+		wantError string
+	}{
+		{name: "ntlm", auth: "ntlm", offered: "NTLM", password: "hunter2"},
+		{name: "negotiate", auth: "negotiate", offered: "Negotiate", password: "hunter2"},
+		{name: "wrong password", auth: "ntlm", offered: "NTLM", password: "wrong", wantError: "the proxy rejected the NTLM credentials"},
+		{name: "scheme not offered", auth: "negotiate", offered: "NTLM", password: "hunter2", wantError: "the proxy did not offer Negotiate authentication"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newNTLMProxy(t, tt.offered, `nrdiag`, "CORP", "hunter2")
+			defer proxy.Close()
+
+			transport, err := NewTransport(TransportConfig{Proxy: "http://" + proxy.Addr().String(), ProxyUser: `CORP\nrdiag`, ProxyPassword: tt.password, ProxyAuth: tt.auth})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := (&http.Client{Transport: transport}).Get("http://backend.test:" + backendURL.Port())
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request through the %s proxy failed: %v", tt.offered, err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != "through the tunnel" {
+				t.Errorf("unexpected response body %q", body)
+			}
+		})
+	}
+}
+
+func TestNewTransport_ntlmProxyAuthNeedsCredentials(t *testing.T) {
+	_, err := NewTransport(TransportConfig{Proxy: "http://proxy:3128", ProxyUser: "nrdiag", ProxyAuth: "ntlm"})
+	if err == nil || !strings.Contains(err.Error(), "-proxy-pw") {
+		t.Errorf("expected an error asking for -proxy-pw, got %v", err)
+	}
+}
+
+// newNTLMProxy answers CONNECT requests with 407 responses until the client completes an NTLMv2
+// handshake under the offered scheme, checking the response against the expected password
+func newNTLMProxy(t *testing.T, scheme, user, domain, password string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveNTLMProxy(conn, scheme, user, domain, password)
+		}
+	}()
+	return listener
+}
+
+func serveNTLMProxy(conn net.Conn, scheme, user, domain, password string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	serverChallenge := []byte("nrdiag42")
+	for {
+		req, err := http.ReadRequest(reader)
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		var message []byte
+		if token, ok := strings.CutPrefix(req.Header.Get("Proxy-Authorization"), scheme+" "); ok {
+			message, _ = base64.StdEncoding.DecodeString(token)
+		}
+		switch ntlmMessageType(message) {
+		case 1:
+			challenge := base64.StdEncoding.EncodeToString(ntlmChallenge(serverChallenge, domain))
+			io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: "+scheme+" "+challenge+"\r\nContent-Length: 0\r\n\r\n")
+		case 3:
+			if !validNTLMAuthenticate(message, serverChallenge, user, domain, password) {
+				io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: "+scheme+"\r\nContent-Length: 0\r\n\r\n")
+				continue
+			}
+			_, port, _ := net.SplitHostPort(req.Host)
+			upstream, err := net.Dial("tcp", "127.0.0.1:"+port)
+			if err != nil {
+				io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n")
+				return
+			}
+			defer upstream.Close()
+			io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+			go io.Copy(upstream, reader)
+			io.Copy(conn, upstream)
+			return
+		default:
+			io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: "+scheme+"\r\nContent-Length: 0\r\n\r\n")
+		}
+	}
+}
+
+func ntlmMessageType(message []byte) uint32 {
+	if len(message) < 12 || !bytes.HasPrefix(message, []byte("NTLMSSP\x00")) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(message[8:])
+}
+
+// ntlmChallenge builds a CHALLENGE message offering Unicode, NTLM and an empty target info
+func ntlmChallenge(serverChallenge []byte, target string) []byte {
+	const headerLen = 48
+	targetName := utf16le(target)
+	targetInfo := []byte{0, 0, 0, 0} // MsvAvEOL
+	message := make([]byte, headerLen)
+	copy(message, "NTLMSSP\x00")
+	binary.LittleEndian.PutUint32(message[8:], 2)
+	putNTLMField(message[12:], len(targetName), headerLen)
+	binary.LittleEndian.PutUint32(message[20:], 0x1|0x200|0x800000)
+	copy(message[24:32], serverChallenge)
+	putNTLMField(message[40:], len(targetInfo), headerLen+len(targetName))
+	return append(append(message, targetName...), targetInfo...)
+}
+
+// validNTLMAuthenticate checks the user, the domain and the NTLMv2 proof of an AUTHENTICATE message
+func validNTLMAuthenticate(message, serverChallenge []byte, user, domain, password string) bool {
+	field := func(offset int) []byte {
+		length := int(binary.LittleEndian.Uint16(message[offset:]))
+		start := int(binary.LittleEndian.Uint32(message[offset+4:]))
+		if start+length > len(message) {
+			return nil
+		}
+		return message[start : start+length]
+	}
+	if len(message) < 64 {
+		return false
+	}
+	ntResponse, target, userName := field(20), field(28), field(36)
+	if !bytes.Equal(userName, utf16le(user)) || !bytes.Equal(target, utf16le(domain)) || len(ntResponse) <= md5.Size {
+		return false
+	}
+	ntHash := md4.New()
+	ntHash.Write(utf16le(password))
+	v2Hash := hmac.New(md5.New, ntHash.Sum(nil))
+	v2Hash.Write(utf16le(strings.ToUpper(user) + domain))
+	proof := hmac.New(md5.New, v2Hash.Sum(nil))
+	proof.Write(serverChallenge)
+	proof.Write(ntResponse[md5.Size:])
+	return hmac.Equal(proof.Sum(nil), ntResponse[:md5.Size])
+}
+
+func putNTLMField(field []byte, length, offset int) {
+	binary.LittleEndian.PutUint16(field, uint16(length))
+	binary.LittleEndian.PutUint16(field[2:], uint16(length))
+	binary.LittleEndian.PutUint32(field[4:], uint32(offset))
+}
+
+func utf16le(s string) []byte {
+	var encoded []byte
+	for _, r := range utf16.Encode([]rune(s)) {
+		encoded = binary.LittleEndian.AppendUint16(encoded, r)
+	}
+	return encoded
+}
@@ -0,0 +1,166 @@
+package httpHelper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TransportConfig holds the settings used to build the transport for the Diagnostics CLI's own
+// HTTP traffic (uploads, version checks, script downloads and Haberdasher calls)
+type TransportConfig struct {
+	// Proxy is an explicit proxy URL (-proxy). When empty, the proxy comes from the PAC file or
+	// HTTPS_PROXY/HTTP_PROXY
+	Proxy string
+	// ProxyUser and ProxyPassword are handed to the proxy authentication scheme
+	ProxyUser     string
+	ProxyPassword string
+	// ProxyAuth is the proxy authentication scheme. Defaults to basic
+	ProxyAuth string
+	// ProxyPAC is a URL or file path of a proxy auto-config file
+	ProxyPAC string
+	// CABundle is a PEM file of CA certificates trusted in addition to the system pool
+	CABundle string
+	// BypassProxy builds a transport that always connects directly
+	BypassProxy bool
+}
+
+var (
+	transportMu      sync.RWMutex
+	proxiedTransport http.RoundTripper
+	directTransport  http.RoundTripper
+)
+
+// NewTransport builds an http.Transport from the supplied configuration
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+	rootCAs, err := loadCABundle(cfg.CABundle)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       &tls.Config{RootCAs: rootCAs},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if cfg.BypassProxy {
+		return transport, nil
+	}
+
+	selector, err := newProxySelector(cfg, rootCAs)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := strings.ToLower(cfg.ProxyAuth)
+	if scheme == "" || scheme == basicProxyAuth {
+		// Basic credentials ride along in the proxy URL, which net/http already knows how to send
+		transport.Proxy = selector.proxyForRequest
+		return transport, nil
+	}
+
+	authenticator, err := newProxyAuthenticator(scheme, cfg.ProxyUser, cfg.ProxyPassword)
+	if err != nil {
+		return nil, err
+	}
+	// Challenge/response schemes need every round of the handshake on the same connection,
+	// so the tunnel to the proxy is built by the dialer instead of net/http
+	tunnel := tunnelDialer{
+		proxyFor:  selector.proxyFor,
+		auth:      authenticator,
+		dial:      dialer.DialContext,
+		rootCAs:   rootCAs,
+		handshake: 10 * time.Second,
+	}
+	transport.DialContext = tunnel.DialContext
+	return transport, nil
+}
+
+// ConfigureTransport builds the shared transports used by MakeHTTPRequest and NewClient. The
+// proxied transport also replaces http.DefaultTransport so that any remaining users of
+// http.DefaultClient follow the same proxy and CA settings.
+func ConfigureTransport(cfg TransportConfig) error {
+	proxied, err := NewTransport(cfg)
+	if err != nil {
+		return err
+	}
+	cfg.BypassProxy = true
+	direct, err := NewTransport(cfg)
+	if err != nil {
+		return err
+	}
+
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	proxiedTransport = proxied
+	directTransport = direct
+	http.DefaultTransport = proxied
+	return nil
+}
+
+// Transport returns the shared transport, or the one that skips the proxy when bypassProxy is set
+func Transport(bypassProxy bool) http.RoundTripper {
+	transportMu.RLock()
+	proxied, direct := proxiedTransport, directTransport
+	transportMu.RUnlock()
+
+	if proxied == nil || direct == nil {
+		// ConfigureTransport has not run yet; an empty configuration cannot fail
+		_ = ConfigureTransport(TransportConfig{})
+		return Transport(bypassProxy)
+	}
+	if bypassProxy {
+		return direct
+	}
+	return proxied
+}
+
+// NewClient returns an http.Client that uses the shared transport. The transport is looked up
+// on every request, so clients created before ConfigureTransport still pick up its settings.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: sharedTransport{},
+		Timeout:   timeout,
+	}
+}
+
+type sharedTransport struct{}
+
+func (sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return Transport(false).RoundTrip(req)
+}
+
+// loadCABundle returns the system pool with the certificates from path added, or nil to use
+// the system pool as is when no bundle is configured
+func loadCABundle(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CA bundle %s: %w", path, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, errors.New("no PEM certificates found in CA bundle " + path)
+	}
+	return pool, nil
+}
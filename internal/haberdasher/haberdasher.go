@@ -9,6 +9,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 )
 
 const (
@@ -68,7 +70,7 @@ func newClientWithDefaults() *Client {
 	baseURL, _ := url.Parse(defaultBaseURL)
 
 	newClient := &Client{
-		httpClient: http.DefaultClient,
+		httpClient: httpHelper.NewClient(0),
 		BaseURL:    baseURL,
 		UserAgent:  defaultUserAgent,
 	}
@@ -0,0 +1,214 @@
+package output
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// ManifestName is where the bundle manifest is stored in nrdiag-output.zip
+const ManifestName = "nrdiag-output/manifest.json"
+
+const manifestVersion = 1
+
+// BundleManifest - describes every entry of nrdiag-output.zip and the run that produced it
+type BundleManifest struct {
+	ManifestVersion int
+	RunID           string
+	NRDiagVersion   string
+	Host            string
+	Configuration   interface{}
+	StartTime       time.Time
+	EndTime         time.Time
+	DurationSeconds float64
+	Entries         []ManifestEntry
+}
+
+// ManifestEntry - a file stored in nrdiag-output.zip
+type ManifestEntry struct {
+	Name       string     // name of the entry in the zip file
+	SourcePath string     // where the file was collected from, or the name the task gave a streamed file
+	Task       string     // identifier of the task that collected the file, empty for files nrdiag writes itself
+	Size       int64      // uncompressed size in bytes
+	SHA256     string     // hex encoded digest of the uncompressed content
+	ModTime    *time.Time `json:",omitempty"` // modification time of the source file, not set for streamed files
+	Streamed   bool
+	Truncated  bool
+	Redacted   bool
+}
+
+var manifestEntries struct {
+	sync.Mutex
+	entries []ManifestEntry
+}
+
+// manifestWriter hashes and counts what is written to a zip entry so it can be recorded in the manifest
+type manifestWriter struct {
+	writer io.Writer
+	hash   hash.Hash
+	size   int64
+}
+
+func newManifestWriter(writer io.Writer) *manifestWriter {
+	return &manifestWriter{writer: writer, hash: sha256.New()}
+}
+
+func (m *manifestWriter) Write(p []byte) (int, error) {
+	n, err := m.writer.Write(p)
+	m.hash.Write(p[:n])
+	m.size += int64(n)
+	return n, err
+}
+
+// record adds the entry, with the size and digest of what was written, to the manifest
+func (m *manifestWriter) record(entry ManifestEntry) {
+	entry.Size = m.size
+	entry.SHA256 = hex.EncodeToString(m.hash.Sum(nil))
+
+	manifestEntries.Lock()
+	defer manifestEntries.Unlock()
+	manifestEntries.entries = append(manifestEntries.entries, entry)
+}
+
+func modTime(info os.FileInfo) *time.Time {
+	t := info.ModTime().UTC()
+	return &t
+}
+
+// WriteManifestToZip - adds manifest.json, describing every entry written so far, to the zip file. It should be the last file added.
+// It returns the hex encoded SHA-256 of the manifest, or an empty string when it was not written. The manifest is not signed, so
+// the digest has to reach whoever verifies the zip by another channel than the zip itself for -verify to detect a replaced manifest.
+func WriteManifestToZip(zipfile *zip.Writer, runID string, startTime time.Time) string {
+	endTime := OutputNow()
+	host, err := os.Hostname()
+	if err != nil {
+		log.Debug("Unable to get the hostname for the manifest:", err)
+	}
+
+	manifestEntries.Lock()
+	entries := append([]ManifestEntry{}, manifestEntries.entries...)
+	manifestEntries.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	manifest := BundleManifest{
+		ManifestVersion: manifestVersion,
+		RunID:           runID,
+		NRDiagVersion:   config.Version,
+		Host:            host,
+		Configuration:   config.Flags,
+		StartTime:       startTime.UTC(),
+		EndTime:         endTime.UTC(),
+		DurationSeconds: endTime.Sub(startTime).Seconds(),
+		Entries:         entries,
+	}
+	content, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		log.Info("Unable to create the zip file manifest:", err)
+		return ""
+	}
+	writer, err := zipfile.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: endTime})
+	if err != nil {
+		log.Info("Unable to add the manifest to the zip file:", err)
+		return ""
+	}
+	if _, err := writer.Write(content); err != nil {
+		log.Info("Unable to add the manifest to the zip file:", err)
+		return ""
+	}
+	digest := sha256.Sum256(content)
+	return hex.EncodeToString(digest[:])
+}
+
+// VerifyZip - checks every entry of a nrdiag-output.zip against its manifest. It returns the problems found: entries that were modified, added or removed since the manifest was written.
+// The manifest itself is only checked when manifestDigest, the digest WriteManifestToZip returned, is given. Without it an edited zip with a
+// regenerated manifest passes, so only accidental corruption is detected.
+func VerifyZip(path string, manifestDigest string) ([]string, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+	manifestFile, ok := files[ManifestName]
+	if !ok {
+		return nil, errors.New(path + " does not contain " + ManifestName)
+	}
+	var manifest BundleManifest
+	if err := readZipJSON(manifestFile, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	var problems []string
+	if manifestDigest != "" {
+		_, digest, err := hashZipFile(manifestFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the manifest: %w", err)
+		}
+		if !strings.EqualFold(digest, strings.TrimSpace(manifestDigest)) {
+			problems = append(problems, ManifestName+": does not match the manifest digest, the manifest was replaced")
+		}
+	}
+	listed := map[string]bool{ManifestName: true}
+	for _, entry := range manifest.Entries {
+		listed[entry.Name] = true
+		file, ok := files[entry.Name]
+		if !ok {
+			problems = append(problems, entry.Name+": missing from the zip file")
+			continue
+		}
+		size, digest, err := hashZipFile(file)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: unable to read: %s", entry.Name, err))
+			continue
+		}
+		if size != entry.Size || digest != entry.SHA256 {
+			problems = append(problems, entry.Name+": content does not match the manifest")
+		}
+	}
+	for _, file := range reader.File {
+		if !listed[file.Name] {
+			problems = append(problems, file.Name+": not listed in the manifest")
+		}
+	}
+	return problems, nil
+}
+
+func readZipJSON(file *zip.File, v interface{}) error {
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return json.NewDecoder(content).Decode(v)
+}
+
+func hashZipFile(file *zip.File) (int64, string, error) {
+	content, err := file.Open()
+	if err != nil {
+		return 0, "", err
+	}
+	defer content.Close()
+	digest := sha256.New()
+	size, err := io.Copy(digest, content)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(digest.Sum(nil)), nil
+}
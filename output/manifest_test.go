@@ -0,0 +1,205 @@
+package output
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/scriptrunner"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func Test_WriteManifestToZip(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := config.Flags.OutputPath
+	config.Flags.OutputPath = dir
+	defer func() { config.Flags.OutputPath = outputPath }()
+	manifestEntries.entries = nil
+
+	source := filepath.Join(dir, "newrelic.yml")
+	os.WriteFile(source, []byte("log_level: info\n"), 0644)
+	stream := make(chan string)
+	go tasks.StreamBlob("line one", stream)
+
+	zipPath := filepath.Join(dir, "nrdiag-output.zip")
+	zipFile, _ := os.Create(zipPath)
+	writer := zip.NewWriter(zipFile)
+	copyFilesToZip(writer, []tasks.FileCopyEnvelope{
+		{Path: source, Identifier: "Base/Config/Collect"},
+		{Path: "report.txt", Identifier: "Corp/Proxy/Check", Stream: stream, Redacted: true},
+	})
+	manifestDigest := WriteManifestToZip(writer, "run-1", time.Now().Add(-time.Minute))
+	writer.Close()
+	zipFile.Close()
+
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var manifest BundleManifest
+	for _, file := range reader.File {
+		if file.Name == ManifestName {
+			readZipJSON(file, &manifest)
+		}
+	}
+	if manifest.RunID != "run-1" || manifest.ManifestVersion != manifestVersion || manifest.DurationSeconds < 60 || manifest.Configuration == nil {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+	if len(manifest.Entries) != 2 {
+		t.Fatalf("manifest entries = %+v", manifest.Entries)
+	}
+	collected := manifest.Entries[0]
+	want := ManifestEntry{
+		Name:       "nrdiag-output/Base/Config/newrelic.yml",
+		SourcePath: source,
+		Task:       "Base/Config/Collect",
+		Size:       16,
+		SHA256:     fmt.Sprintf("%x", sha256.Sum256([]byte("log_level: info\n"))),
+	}
+	if collected.ModTime == nil {
+		t.Errorf("collected file entry has no modification time")
+	}
+	collected.ModTime = nil
+	if !reflect.DeepEqual(collected, want) {
+		t.Errorf("collected file entry = %+v, want %+v", collected, want)
+	}
+	streamed := manifest.Entries[1]
+	if streamed.Name != "nrdiag-output/Corp/Proxy/report.txt" || !streamed.Streamed || !streamed.Redacted || streamed.Size != int64(len("line one\n")) || streamed.ModTime != nil {
+		t.Errorf("streamed entry = %+v", streamed)
+	}
+
+	problems, err := VerifyZip(zipPath, manifestDigest)
+	if err != nil || len(problems) != 0 {
+		t.Errorf("VerifyZip() = %v, %v, want no problems", problems, err)
+	}
+}
+
+func Test_CopyOutputToZip_manifestFlags(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := config.Flags.OutputPath
+	maxScriptOutputLen := MaxScriptOutputLen
+	config.Flags.OutputPath = dir
+	MaxScriptOutputLen = 4
+	defer func() {
+		config.Flags.OutputPath = outputPath
+		MaxScriptOutputLen = maxScriptOutputLen
+		outputJSONEnvelope = tasks.FileCopyEnvelope{}
+	}()
+	manifestEntries.entries = nil
+
+	results := []registration.TaskResult{{
+		Task:   registration.TasksForIdentifierString("Base/Env/CollectEnvVars")[0],
+		Result: tasks.Result{Status: tasks.Info, Payload: map[string]string{"NEW_RELIC_APP_NAME": "checkout"}, Redacted: true},
+	}}
+	WriteOutputFile(results, &scriptrunner.ScriptData{Name: "test", Output: []byte("more than four bytes")})
+
+	zipFile, _ := os.Create(filepath.Join(dir, "nrdiag-output.zip"))
+	writer := zip.NewWriter(zipFile)
+	CopyOutputToZip(writer)
+	writer.Close()
+	zipFile.Close()
+
+	manifestEntries.Lock()
+	defer manifestEntries.Unlock()
+	if len(manifestEntries.entries) != 1 {
+		t.Fatalf("manifest entries = %+v", manifestEntries.entries)
+	}
+	entry := manifestEntries.entries[0]
+	if entry.Name != "nrdiag-output/nrdiag-output.json" || !entry.Truncated || !entry.Redacted {
+		t.Errorf("nrdiag-output.json entry = %+v, want it truncated and redacted", entry)
+	}
+}
+
+func Test_VerifyZip_modified(t *testing.T) {
+	dir := t.TempDir()
+	manifest, _ := json.Marshal(BundleManifest{Entries: []ManifestEntry{
+		{Name: "nrdiag-output/a.txt", Size: 1, SHA256: "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"}, // "a"
+		{Name: "nrdiag-output/b.txt", Size: 1, SHA256: "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"}, // "b"
+		{Name: "nrdiag-output/c.txt", Size: 1, SHA256: "2e7d2c03a9507ae265ecf5b5356885a53393a2029d241394997265a1a25aefc6"}, // "c"
+	}})
+	zipPath := writeTestZip(t, dir, map[string]string{
+		ManifestName:            string(manifest),
+		"nrdiag-output/a.txt":   "a",
+		"nrdiag-output/b.txt":   "edited",
+		"nrdiag-output/new.txt": "added",
+	})
+
+	problems, err := VerifyZip(zipPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"nrdiag-output/b.txt: content does not match the manifest",
+		"nrdiag-output/c.txt: missing from the zip file",
+		"nrdiag-output/new.txt: not listed in the manifest",
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("VerifyZip() = %q, want %q", problems, want)
+	}
+
+	noManifest := writeTestZip(t, t.TempDir(), map[string]string{"nrdiag-output/a.txt": "a"})
+	if _, err := VerifyZip(noManifest, ""); err == nil || !strings.Contains(err.Error(), "does not contain") {
+		t.Errorf("VerifyZip() without a manifest error = %v", err)
+	}
+}
+
+func Test_VerifyZip_replacedManifest(t *testing.T) {
+	original, _ := json.Marshal(BundleManifest{Entries: []ManifestEntry{
+		{Name: "nrdiag-output/a.txt", Size: 1, SHA256: "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"}, // "a"
+	}})
+	originalDigest := fmt.Sprintf("%x", sha256.Sum256(original))
+	// the edited zip comes with a manifest regenerated for the new content
+	regenerated, _ := json.Marshal(BundleManifest{Entries: []ManifestEntry{
+		{Name: "nrdiag-output/a.txt", Size: 1, SHA256: "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"}, // "b"
+	}})
+	zipPath := writeTestZip(t, t.TempDir(), map[string]string{
+		ManifestName:          string(regenerated),
+		"nrdiag-output/a.txt": "b",
+	})
+
+	if problems, err := VerifyZip(zipPath, ""); err != nil || len(problems) != 0 {
+		t.Errorf("VerifyZip() without a digest = %v, %v, want the regenerated manifest to pass", problems, err)
+	}
+	problems, err := VerifyZip(zipPath, strings.ToUpper(originalDigest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ManifestName + ": does not match the manifest digest, the manifest was replaced"}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("VerifyZip() = %q, want %q", problems, want)
+	}
+}
+
+func writeTestZip(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(dir, "nrdiag-output.zip")
+	zipFile, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipFile.Close()
+	writer := zip.NewWriter(zipFile)
+	for _, name := range []string{ManifestName, "nrdiag-output/a.txt", "nrdiag-output/b.txt", "nrdiag-output/new.txt"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		entry, _ := writer.Create(name)
+		io.WriteString(entry, content)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
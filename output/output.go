@@ -114,10 +114,12 @@ func CopyScriptOutputsToZip(scriptData *scriptrunner.ScriptData, zipfile *zip.Wr
 		if err != nil {
 			return err
 		}
-		_, err = io.Copy(writer, file)
+		entryWriter := newManifestWriter(writer)
+		_, err = io.Copy(entryWriter, file)
 		if err != nil {
 			return err
 		}
+		entryWriter.record(ManifestEntry{Name: header.Name, SourcePath: filename, Task: "ScriptOutput", ModTime: modTime(info)})
 
 		addFileToFileList(tasks.FileCopyEnvelope{
 			Path:       filename,
@@ -130,6 +132,13 @@ func CopyScriptOutputsToZip(scriptData *scriptrunner.ScriptData, zipfile *zip.Wr
 
 // WriteOutputFile will output a JSON file with the results of the run
 func WriteOutputFile(data []registration.TaskResult, scriptResults *scriptrunner.ScriptData) {
+	outputJSONEnvelope = tasks.FileCopyEnvelope{}
+	if scriptResults != nil {
+		_, outputJSONEnvelope.Truncated = getTruncatedScriptOutputString(scriptResults.Output)
+	}
+	for _, result := range data {
+		outputJSONEnvelope.Redacted = outputJSONEnvelope.Redacted || result.Result.Redacted
+	}
 	outputJSON(getResultsJSON(data, scriptResults))
 }
 
@@ -209,7 +218,34 @@ func CopySingleFileToZip(zipfile *zip.Writer, filename string) {
 
 // CopyOutputToZip - takes the nrdiag-output.json and adds it to the zip file
 func CopyOutputToZip(zipfile *zip.Writer) {
-	CopySingleFileToZip(zipfile, "nrdiag-output.json")
+	filePath := filepath.Join(config.Flags.OutputPath, "nrdiag-output.json")
+	envelope := outputJSONEnvelope
+	envelope.Path = filePath
+	if encryptor == nil || !encryptJSON {
+		if _, err := os.Stat(filePath); err != nil {
+			log.Debug("Could not copy file to zip: ", err)
+			return
+		}
+		copyFilesToZip(zipfile, []tasks.FileCopyEnvelope{envelope})
+		return
+	}
+	// the json file on disk is encrypted, so the results are added from memory instead
+	header := zip.FileHeader{
+		Name:     "nrdiag-output/nrdiag-output.json",
+		Method:   zip.Deflate,
+		Modified: OutputNow(),
+	}
+	writer, err := zipfile.CreateHeader(&header)
+	if err != nil {
+		log.Info("Error writing results to zip file: ", err)
+		return
+	}
+	entryWriter := newManifestWriter(writer)
+	if _, err := io.WriteString(entryWriter, outputJSONContent); err != nil {
+		log.Info("Error writing results to zip file: ", err)
+	}
+	entryWriter.record(ManifestEntry{Name: header.Name, SourcePath: filePath, Truncated: envelope.Truncated, Redacted: envelope.Redacted})
+	addFileToFileList(tasks.FileCopyEnvelope{Path: filePath})
 }
 
 func CopyFileListToZip(zipfile *zip.Writer) {
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/encryption"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
 	"github.com/newrelic/newrelic-diagnostics-cli/registration"
@@ -94,15 +95,84 @@ func getResultsJSON(data []registration.TaskResult, scriptResults *scriptrunner.
 	return string(output)
 }
 
+// encryptor encrypts the output files when -encrypt-to is used
+var encryptor *encryption.Encryptor
+
+// encryptJSON is set when nrdiag-output.json is encrypted too
+var encryptJSON bool
+
+// outputJSONContent keeps the results written by outputJSON so they can be added to the zip file when nrdiag-output.json is encrypted
+var outputJSONContent string
+
+// outputJSONEnvelope describes nrdiag-output.json in the zip file manifest: truncated when the script output was cut, redacted when a task removed secrets from its payload
+var outputJSONEnvelope tasks.FileCopyEnvelope
+
+// zipClosers are closed, in order, by CloseZip after the zip writer: the encrypting writer, if any, and the zip file itself
+var zipClosers []io.Closer
+
+// SetEncryption - encrypts nrdiag-output.zip, and nrdiag-output.json when json is set, to the public keys in keyFile as they are written
+func SetEncryption(keyFile string, json bool) error {
+	e, err := encryption.NewEncryptor(keyFile)
+	if err != nil {
+		return err
+	}
+	encryptor = e
+	encryptJSON = json
+	return nil
+}
+
+// ZipFileName - the name of the zip file written to the output path, with the encryption extension when it is encrypted
+func ZipFileName() string {
+	if encryptor != nil {
+		return "nrdiag-output.zip" + encryptor.Extension()
+	}
+	return "nrdiag-output.zip"
+}
+
+// JSONFileName - the name of the json file written to the output path, with the encryption extension when it is encrypted
+func JSONFileName() string {
+	if encryptor != nil && encryptJSON {
+		return "nrdiag-output.json" + encryptor.Extension()
+	}
+	return "nrdiag-output.json"
+}
+
 func outputJSON(json string) {
-	jsonFile := filepath.Clean(config.Flags.OutputPath + "/nrdiag-output.json")
+	jsonFile := filepath.Clean(config.Flags.OutputPath + "/" + JSONFileName())
 	log.Debug("Creating json file:", jsonFile)
 	err := os.MkdirAll(config.Flags.OutputPath, 0777)
 	if err != nil {
 		log.Info("Error creating directory", err)
 		log.Info(permissionsError)
 	}
-	_ = os.WriteFile(jsonFile, []byte(json), 0644)
+	outputJSONContent = json
+	if encryptor == nil || !encryptJSON {
+		_ = os.WriteFile(jsonFile, []byte(json), 0644)
+		return
+	}
+	if err := writeEncrypted(jsonFile, []byte(json)); err != nil {
+		log.Info("Error writing encrypted json file", err)
+	}
+}
+
+// writeEncrypted - encrypts content straight into path, the plaintext is never written to disk
+func writeEncrypted(path string, content []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encrypted, err := encryptor.Encrypt(file)
+	if err != nil {
+		return err
+	}
+	if _, err := encrypted.Write(content); err != nil {
+		return err
+	}
+	if err := encrypted.Close(); err != nil {
+		return err
+	}
+	return file.Close()
 }
 
 func CreateZip() *zip.Writer {
@@ -111,14 +181,29 @@ func CreateZip() *zip.Writer {
 		log.Info("Error creating directory", err)
 		log.Info(permissionsError)
 	}
-	zipfile, err := os.Create(config.Flags.OutputPath + "/nrdiag-output.zip")
+	zipfile, err := os.Create(config.Flags.OutputPath + "/" + ZipFileName())
 	if err != nil {
 		log.Info("Error creating zip file", err)
 		log.Info(permissionsError)
 	}
 
+	var dst io.Writer = zipfile
+	zipClosers = nil
+	if err == nil {
+		zipClosers = []io.Closer{zipfile}
+		if encryptor != nil {
+			// the archive is encrypted as it is streamed to the file so the plaintext never touches the disk
+			encrypted, err := encryptor.Encrypt(zipfile)
+			if err != nil {
+				log.Fatalf("Unable to encrypt %s: %s\n", ZipFileName(), err.Error())
+			}
+			dst = encrypted
+			zipClosers = []io.Closer{encrypted, zipfile}
+		}
+	}
+
 	// Create a new zip archive.
-	w := zip.NewWriter(zipfile)
+	w := zip.NewWriter(dst)
 	return w
 }
 
@@ -129,6 +214,13 @@ func CloseZip(zipfile *zip.Writer) {
 	if zipErr != nil {
 		log.Info("error closing zip file: ", zipErr)
 	}
+	// the encrypting writer has to be closed before the file to flush the last of the ciphertext
+	for _, closer := range zipClosers {
+		if err := closer.Close(); err != nil {
+			log.Info("error closing zip file: ", err)
+		}
+	}
+	zipClosers = nil
 }
 
 func mapContains(set map[string]struct{}, item string) bool {
@@ -149,9 +241,18 @@ func copyFilesToZip(dst *zip.Writer, filesToZip []tasks.FileCopyEnvelope) {
 			}
 
 			writer, _ := dst.CreateHeader(&header)
+			entryWriter := newManifestWriter(writer)
 			for s := range envelope.Stream {
-				_, _ = io.WriteString(writer, s)
+				_, _ = io.WriteString(entryWriter, s)
 			}
+			entryWriter.record(ManifestEntry{
+				Name:       header.Name,
+				SourcePath: envelope.Path,
+				Task:       envelope.Identifier,
+				Streamed:   true,
+				Truncated:  envelope.Truncated,
+				Redacted:   envelope.Redacted,
+			})
 		} else {
 			log.Debug("adding " + envelope.Path + " to zip")
 			// Get file info from file
@@ -190,10 +291,19 @@ func copyFilesToZip(dst *zip.Writer, filesToZip []tasks.FileCopyEnvelope) {
 				continue
 			}
 
-			_, err = io.Copy(writer, fileHandle)
+			entryWriter := newManifestWriter(writer)
+			_, err = io.Copy(entryWriter, fileHandle)
 			if err != nil {
 				log.Info("Error writing file into zip: ", err)
 			}
+			entryWriter.record(ManifestEntry{
+				Name:       header.Name,
+				SourcePath: envelope.Path,
+				Task:       envelope.Identifier,
+				ModTime:    modTime(stat),
+				Truncated:  envelope.Truncated,
+				Redacted:   envelope.Redacted,
+			})
 		}
 	}
 }
@@ -305,9 +415,11 @@ func WriteFileToZip(path string, info os.FileInfo, zipfile *zip.Writer) error {
 		log.Info("Error writing results to zip file: ", ok)
 		return ok
 	}
-	_, ok = io.Copy(writer, file)
+	entryWriter := newManifestWriter(writer)
+	_, ok = io.Copy(entryWriter, file)
 	if ok != nil {
 		return ok
 	}
+	entryWriter.record(ManifestEntry{Name: header.Name, SourcePath: path, ModTime: modTime(info)})
 	return nil
 }
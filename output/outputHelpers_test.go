@@ -2,11 +2,17 @@ package output
 
 import (
 	"archive/zip"
+	"bytes"
 	"errors"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"filippo.io/age"
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/encryption"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
@@ -160,3 +166,69 @@ func Test_getTruncatedScriptOutputString(t *testing.T) {
 		})
 	}
 }
+
+func Test_CreateZip_encrypted(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := config.Flags.OutputPath
+	config.Flags.OutputPath = dir
+	defer func() {
+		config.Flags.OutputPath = outputPath
+		encryptor, encryptJSON = nil, false
+	}()
+	identity, _ := age.GenerateX25519Identity()
+	publicKey := filepath.Join(dir, "recipients.txt")
+	privateKey := filepath.Join(dir, "key.txt")
+	os.WriteFile(publicKey, []byte(identity.Recipient().String()), 0600)
+	os.WriteFile(privateKey, []byte(identity.String()), 0600)
+
+	if err := SetEncryption(publicKey, true); err != nil {
+		t.Fatalf("SetEncryption() error = %v", err)
+	}
+	if ZipFileName() != "nrdiag-output.zip.age" || JSONFileName() != "nrdiag-output.json.age" {
+		t.Errorf("file names = %s, %s", ZipFileName(), JSONFileName())
+	}
+
+	zipFile := CreateZip()
+	outputJSON(`{"RunDate":"today"}`)
+	CopyOutputToZip(zipFile)
+	CloseZip(zipFile)
+
+	for _, plaintext := range []string{"nrdiag-output.zip", "nrdiag-output.json"} {
+		if _, err := os.Stat(filepath.Join(dir, plaintext)); !os.IsNotExist(err) {
+			t.Errorf("%s was written in plaintext", plaintext)
+		}
+	}
+	if got := decryptFile(t, filepath.Join(dir, "nrdiag-output.json.age"), privateKey); string(got) != `{"RunDate":"today"}` {
+		t.Errorf("decrypted json = %q", got)
+	}
+	archive := decryptFile(t, filepath.Join(dir, "nrdiag-output.zip.age"), privateKey)
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("decrypted zip file is invalid: %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != "nrdiag-output/nrdiag-output.json" {
+		t.Fatalf("decrypted zip file entries = %v", reader.File)
+	}
+	content, _ := reader.File[0].Open()
+	if got, _ := io.ReadAll(content); string(got) != `{"RunDate":"today"}` {
+		t.Errorf("nrdiag-output.json in the zip file = %q", got)
+	}
+}
+
+func decryptFile(t *testing.T, path, identityFile string) []byte {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	plaintext, err := encryption.Decrypt(file, identityFile, nil)
+	if err != nil {
+		t.Fatalf("Decrypt(%s) error = %v", path, err)
+	}
+	content, err := io.ReadAll(plaintext)
+	if err != nil {
+		t.Fatalf("Decrypt(%s) error = %v", path, err)
+	}
+	return content
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"archive/zip"
 	"errors"
 	"flag"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -10,12 +12,17 @@ import (
 	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/encryption"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/output"
 	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
 	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/remediation"
 	"github.com/newrelic/newrelic-diagnostics-cli/scriptrunner"
 	"github.com/newrelic/newrelic-diagnostics-cli/suites"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/declarative"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/plugin"
 	"golang.org/x/exp/slices"
 )
 
@@ -43,6 +50,7 @@ func validateHTTPProxy(proxy string) (bool, error) {
 }
 
 // processHTTPProxy - Returns true if a proxy has been detected and set, otherwise false.
+// The transport for nrdiag's own requests is configured either way so that -ca-bundle and NO_PROXY apply.
 func processHTTPProxy() (bool, error) {
 
 	//Did the user -proxy <proxy> ?
@@ -56,14 +64,15 @@ func processHTTPProxy() (bool, error) {
 		}
 
 		//Check if there is a proxy user && password, then construct URL. Can you have a user with no pass?
-		if config.Flags.ProxyUser != "" && config.Flags.ProxyPassword != "" {
+		//Only basic credentials can be carried in the URL, other schemes are handled by the transport.
+		if config.Flags.ProxyUser != "" && config.Flags.ProxyPassword != "" && isBasicProxyAuth(config.Flags.ProxyAuth) {
 			splitURL := strings.Split(config.Flags.Proxy, "//")
 
 			//construct url
 			proxyURL := splitURL[0] + "//" + url.QueryEscape(config.Flags.ProxyUser) + ":" + url.QueryEscape(config.Flags.ProxyPassword) + "@" + splitURL[1]
 
 			os.Setenv("HTTP_PROXY", proxyURL)
-			log.Debug("Setting proxy from command line:", proxyURL)
+			log.Debug("Setting proxy from command line:", splitURL[0]+"//"+splitURL[1])
 		} else {
 			//No user and pass? Use the -proxy arg as is
 			os.Setenv("HTTP_PROXY", config.Flags.Proxy)
@@ -71,6 +80,8 @@ func processHTTPProxy() (bool, error) {
 		}
 
 	}
+
+	proxySet := false
 	envProxy := os.Getenv("HTTP_PROXY")
 	if envProxy != "" {
 		//Check the final env proxy.
@@ -80,19 +91,191 @@ func processHTTPProxy() (bool, error) {
 			log.Debug(envProxy)
 			return false, errors.New("proxy format should be: -proxy http://poxy_host:proxy_port")
 		}
+		log.Debug("Using proxy address from HTTP_PROXY environment variable:", envProxy)
+		proxySet = true
+	}
+	if httpsProxy := os.Getenv("HTTPS_PROXY"); httpsProxy != "" {
+		log.Debug("Using proxy address from HTTPS_PROXY environment variable:", httpsProxy)
+		proxySet = true
+	}
+	if config.Flags.ProxyPAC != "" {
+		log.Debug("Using proxy auto-config file:", config.Flags.ProxyPAC)
+		proxySet = true
+	}
 
-		if !ProxyParseNSet() {
-			return false, errors.New("error setting proxy: " + envProxy)
-		}
+	if err := ProxyParseNSet(); err != nil {
+		return false, errors.New("error setting proxy: " + err.Error())
+	}
 
-		log.Debug("Using proxy address from HTTP_PROXY environment variable:", envProxy)
+	return proxySet, nil
+}
+
+func isBasicProxyAuth(scheme string) bool {
+	return scheme == "" || strings.EqualFold(scheme, "basic")
+}
 
-		//ProxyParseNSet() passed
-		return true, nil
+// processTaskDir - registers the declarative task definitions from -task-dir so they can be selected like built-in tasks
+func processTaskDir() {
+	if config.Flags.TaskDir == "" {
+		return
+	}
+	for _, err := range declarative.RegisterDirWith(config.Flags.TaskDir, registerExternalTask) {
+		log.Info("Unable to load task definition: " + err.Error())
 	}
+}
 
-	//no proxy supplied via flag/env
-	return false, nil
+// processPluginDir - registers the tasks described by the plugin executables in -plugin-dir
+func processPluginDir() {
+	if config.Flags.PluginDir == "" {
+		return
+	}
+	for _, err := range plugin.RegisterDirWith(config.Flags.PluginDir, registerExternalTask) {
+		log.Info("Unable to load plugin: " + err.Error())
+	}
+}
+
+// processRemediations - offers the fixes the tasks found in -fix mode and records the applied changes in a rollback manifest in the output path and the zip file
+func processRemediations(results []registration.TaskResult, options tasks.Options, zipfile *zip.Writer) {
+	if config.Flags.FixDryRun {
+		log.Info(color.ColorString(color.White, "\nPlanned changes (dry run, nothing will be changed)\n-------------------------------------------------"))
+	} else {
+		log.Info(color.ColorString(color.White, "\nRemediations\n-------------------------------------------------"))
+	}
+	runner := remediation.Runner{
+		DryRun:  config.Flags.FixDryRun,
+		Options: options,
+		Confirm: tasks.PromptUser,
+	}
+	manifest := runner.Run(results)
+	if len(manifest.Applied) == 0 {
+		return
+	}
+
+	if err := os.MkdirAll(config.Flags.OutputPath, 0777); err != nil {
+		log.Info("Error creating directory", err)
+	}
+	if err := remediation.WriteManifest(manifest, filepath.Join(config.Flags.OutputPath, remediation.ManifestFileName)); err != nil {
+		log.Info("Unable to write the rollback manifest: " + err.Error())
+		return
+	}
+	output.CopySingleFileToZip(zipfile, remediation.ManifestFileName)
+	log.Infof("%d change(s) applied. To revert them run: %s -fix-rollback %s\n", len(manifest.Applied), os.Args[0], filepath.Join(config.Flags.OutputPath, remediation.ManifestFileName))
+}
+
+// processFixRollback - reverts the changes recorded in the -fix-rollback manifest
+func processFixRollback() {
+	errs := remediation.Rollback(config.Flags.FixRollback)
+	for _, err := range errs {
+		log.Info(color.ColorString(color.LightRed, "Unable to revert: "+err.Error()))
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+	log.Info("All changes were reverted.")
+	os.Exit(0)
+}
+
+// processVerify - checks the -verify zip file against its manifest and exits with a non-zero code if it was modified
+func processVerify() {
+	if config.Flags.VerifyDigest == "" {
+		log.Info(color.ColorString(color.Yellow, "No -verify-digest given: the manifest is stored in the zip file, so only accidental corruption is detected, not edits made together with a regenerated manifest."))
+	}
+	problems, err := output.VerifyZip(config.Flags.Verify, config.Flags.VerifyDigest)
+	if err != nil {
+		log.Info(color.ColorString(color.LightRed, "Unable to verify "+config.Flags.Verify+": "+err.Error()))
+		os.Exit(3)
+	}
+	for _, problem := range problems {
+		log.Info(color.ColorString(color.LightRed, problem))
+	}
+	if len(problems) > 0 {
+		log.Infof("%s does not match its manifest: %d problem(s) found\n", config.Flags.Verify, len(problems))
+		os.Exit(1)
+	}
+	log.Info(config.Flags.Verify + " matches its manifest.")
+	os.Exit(0)
+}
+
+// processEncryption - sets up the encryption of the output files for -encrypt-to, exiting before any task runs if the key file can't be used
+func processEncryption() {
+	if config.Flags.EncryptJSON && config.Flags.EncryptTo == "" {
+		log.Info("-encrypt-json is only used with -encrypt-to")
+		return
+	}
+	if err := output.SetEncryption(config.Flags.EncryptTo, config.Flags.EncryptJSON); err != nil {
+		log.Info(color.ColorString(color.LightRed, "Unable to use -encrypt-to: "+err.Error()))
+		os.Exit(3)
+	}
+	log.Debug("Encrypting output files to the keys in", config.Flags.EncryptTo)
+}
+
+// processDecrypt - runs 'nrdiag decrypt', which decrypts a file written with -encrypt-to, and returns the exit code
+func processDecrypt(args []string) int {
+	flags := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	identity := flags.String("identity", "", "File containing the age identities or PGP private key to decrypt with. The passphrase of a protected PGP key is read from "+encryption.PassphraseEnv)
+	outputFile := flags.String("o", "", "Where to write the decrypted file. Defaults to the name of the encrypted file without its .age or .gpg extension")
+	flags.Usage = func() {
+		log.Info("Usage: nrdiag decrypt -identity <key file> [-o <output file>] <encrypted file>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 3
+	}
+	if *identity == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return 3
+	}
+	input := flags.Arg(0)
+	out := *outputFile
+	if out == "" {
+		extension := filepath.Ext(input)
+		if extension != ".age" && extension != ".gpg" {
+			log.Info("Unable to name the decrypted file, use -o to choose where to write it")
+			return 3
+		}
+		out = strings.TrimSuffix(input, extension)
+	}
+	if err := decryptFile(input, out, *identity); err != nil {
+		log.Info(color.ColorString(color.LightRed, "Unable to decrypt "+input+": "+err.Error()))
+		return 1
+	}
+	log.Info("Decrypted " + input + " to " + out)
+	return 0
+}
+
+func decryptFile(input, out, identity string) error {
+	encrypted, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer encrypted.Close()
+	plaintext, err := encryption.Decrypt(encrypted, identity, []byte(os.Getenv(encryption.PassphraseEnv)))
+	if err != nil {
+		return err
+	}
+	decrypted, err := os.OpenFile(out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(decrypted, plaintext)
+	if closeErr := decrypted.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// the content can't be trusted when the integrity check at the end of the file fails
+		os.Remove(out)
+	}
+	return err
+}
+
+// registerExternalTask - registers a task loaded at runtime, refusing to replace a task that is already registered
+func registerExternalTask(t tasks.Task, runByDefault bool) {
+	identifier := t.Identifier().String()
+	if len(registration.TasksForIdentifierString(identifier)) > 0 {
+		log.Info("Skipping " + identifier + ": a task with this identifier is already registered")
+		return
+	}
+	registration.Register(t, runByDefault)
 }
 
 func processHelp() {
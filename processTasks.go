@@ -213,7 +213,7 @@ func processUploads() {
 	}
 
 	if config.Flags.APIKey != "" || config.Flags.AutoAttach {
-		question := "We've created nrdiag-output.zip and nrdiag-output.json\n" +
+		question := "We've created " + output.ZipFileName() + " and " + output.JSONFileName() + "\n" +
 			"Do you want to upload these to your New Relic account?"
 		if promptUser(question) {
 			checkAttachmentFlags(timestamp)
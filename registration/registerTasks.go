@@ -14,7 +14,9 @@ import (
 	containers "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/containers"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
 	logTasks "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/log"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/network"
 	browserAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/browser/agent"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/declarative"
 	dotnetCoreAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/agent"
 	dotnetCoreConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/config"
 	dotnetCoreCustInst "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/custominstrumentation"
@@ -32,11 +34,14 @@ import (
 	infraAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/agent"
 	infraConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/config"
 	infraEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/env"
+	infraFlex "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/flex"
 	infraLog "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/log"
+	infraLogging "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/logging"
 	javaAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/agent"
 	javaAppserver "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/appserver"
 	javaConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/config"
 	javaEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+	javaExtensions "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/extensions"
 	javaJvm "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/jvm"
 	javaLog "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/log"
 	k8sAgentControl "github.com/newrelic/newrelic-diagnostics-cli/tasks/k8s/agentcontrol"
@@ -54,6 +59,8 @@ import (
 	phpDaemon "github.com/newrelic/newrelic-diagnostics-cli/tasks/php/daemon"
 	phpEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/php/env"
 	phpLog "github.com/newrelic/newrelic-diagnostics-cli/tasks/php/log"
+	prometheusConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/prometheus/config"
+	prometheusTargets "github.com/newrelic/newrelic-diagnostics-cli/tasks/prometheus/targets"
 	pythonAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/python/agent"
 	pythonConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/python/config"
 	pythonEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/python/env"
@@ -64,6 +71,8 @@ import (
 	rubyEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/ruby/env"
 	rubyLog "github.com/newrelic/newrelic-diagnostics-cli/tasks/ruby/log"
 	rubyRequirements "github.com/newrelic/newrelic-diagnostics-cli/tasks/ruby/requirements"
+	serverlessLambda "github.com/newrelic/newrelic-diagnostics-cli/tasks/serverless/lambda"
+	syntheticsJobManager "github.com/newrelic/newrelic-diagnostics-cli/tasks/synthetics/jobmanager"
 	syntheticsMinion "github.com/newrelic/newrelic-diagnostics-cli/tasks/synthetics/minion"
 )
 
@@ -71,15 +80,18 @@ func init() {
 	env.RegisterWith(Register)
 	config.RegisterWith(Register)
 	collector.RegisterWith(Register)
+	network.RegisterWith(Register)
 	logTasks.RegisterWith(Register)
 	containers.RegisterWith(Register)
 	javaJvm.RegisterWith(Register)
 	phpDaemon.RegisterWith(Register)
 	syntheticsMinion.RegisterWith(Register)
+	syntheticsJobManager.RegisterWith(Register)
 	javaConfig.RegisterWith(Register)
 	javaAgent.RegisterWith(Register)
 	javaLog.RegisterWith(Register)
 	javaAppserver.RegisterWith(Register)
+	javaExtensions.RegisterWith(Register)
 	goAgent.RegisterWith(Register)
 	goLog.RegisterWith(Register)
 	nodeConfig.RegisterWith(Register)
@@ -100,6 +112,8 @@ func init() {
 	infraAgent.RegisterWith(Register)
 	infraLog.RegisterWith(Register)
 	infraEnv.RegisterWith(Register)
+	infraLogging.RegisterWith(Register)
+	infraFlex.RegisterWith(Register)
 	androidConfig.RegisterWith(Register)
 	androidAgent.RegisterWith(Register)
 	androidLog.RegisterWith(Register)
@@ -129,6 +143,10 @@ func init() {
 	k8sAgentControl.RegisterWith(Register)
 	flux.RegisterWith(Register)
 	K8sHelm.RegisterWith(Register)
+	serverlessLambda.RegisterWith(Register)
+	prometheusConfig.RegisterWith(Register)
+	prometheusTargets.RegisterWith(Register)
+	declarative.RegisterWith(Register)
 
 	//example stuff, doesn't need to "ship" because binary gets name after directory with `go build` cmd
 	if strings.Contains(os.Args[0], "newrelic-diagnostics-cli") {
@@ -7,10 +7,12 @@ import (
 	baseEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
 	infraEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/env"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks/java/jvm"
+	phpDaemon "github.com/newrelic/newrelic-diagnostics-cli/tasks/php/daemon"
 )
 
 func init() {
 	baseEnv.RegisterLinuxWith(Register)
 	infraEnv.RegisterLinuxWith(Register)
 	jvm.RegisterLinuxWith(Register)
+	phpDaemon.RegisterLinuxWith(Register)
 }
@@ -0,0 +1,135 @@
+package remediation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// ManifestFileName is the name of the rollback manifest written to the output path and the zip file
+const ManifestFileName = "nrdiag-fix-rollback.json"
+
+// Manifest - records every change made in -fix mode so it can be reverted with -fix-rollback
+type Manifest struct {
+	RunDate time.Time `json:"runDate"`
+	Applied []Applied `json:"applied"`
+}
+
+// Applied - a RemediationAction that was applied
+type Applied struct {
+	Task        string         `json:"task"`
+	Remediation string         `json:"remediation"`
+	Change      string         `json:"change"`
+	Rollback    tasks.Rollback `json:"rollback"`
+}
+
+// Runner - applies the remediations offered by task results
+type Runner struct {
+	DryRun  bool
+	Options tasks.Options
+	// Confirm is asked before every action, tasks.PromptUser in production
+	Confirm func(string, tasks.Options) bool
+}
+
+// Run - offers the remediations of every result, in task order, and applies the confirmed actions. In dry run mode only the planned changes are printed.
+func (r Runner) Run(results []registration.TaskResult) Manifest {
+	manifest := Manifest{RunDate: time.Now().UTC()}
+
+	sorted := make([]registration.TaskResult, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Task.Identifier().String() < sorted[j].Task.Identifier().String()
+	})
+
+	offered := 0
+	for _, result := range sorted {
+		identifier := result.Task.Identifier().String()
+		for _, remediation := range result.Result.Remediations {
+			offered++
+			log.Info(color.ColorString(color.White, fmt.Sprintf("\n%s: %s", identifier, remediation.Description)))
+			for _, action := range remediation.Actions {
+				applied, ok := r.runAction(action)
+				if !ok {
+					continue
+				}
+				applied.Task = identifier
+				applied.Remediation = remediation.Description
+				manifest.Applied = append(manifest.Applied, applied)
+			}
+		}
+	}
+	if offered == 0 {
+		log.Info("No remediations were offered for the problems found.")
+	}
+	return manifest
+}
+
+func (r Runner) runAction(action tasks.RemediationAction) (Applied, bool) {
+	plan, err := action.Plan()
+	if errors.Is(err, tasks.ErrNothingToChange) {
+		log.Info("Nothing to change, skipping.")
+		return Applied{}, false
+	}
+	if err != nil {
+		log.Info("Unable to plan this change, skipping:", err)
+		return Applied{}, false
+	}
+	log.Info(plan)
+	if r.DryRun {
+		return Applied{}, false
+	}
+	if !r.Confirm("Apply this change?", r.Options) {
+		log.Info("Skipped.")
+		return Applied{}, false
+	}
+	rollback, err := action.Apply()
+	if err != nil {
+		log.Info(color.ColorString(color.LightRed, "Unable to apply this change: "+err.Error()))
+		return Applied{}, false
+	}
+	if rollback.Backup != "" {
+		log.Info("Applied. The original was backed up to " + rollback.Backup)
+	} else {
+		log.Info("Applied.")
+	}
+	return Applied{Change: plan, Rollback: rollback}, true
+}
+
+// WriteManifest - writes the manifest as JSON to path
+func WriteManifest(manifest Manifest, path string) error {
+	content, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// Rollback - reverts the changes recorded in the manifest at path, newest first. It returns an error for every change that could not be reverted.
+func Rollback(path string) []error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return []error{fmt.Errorf("invalid rollback manifest %s: %w", path, err)}
+	}
+
+	var errs []error
+	for i := len(manifest.Applied) - 1; i >= 0; i-- {
+		applied := manifest.Applied[i]
+		log.Infof("Reverting %s: %s\n", applied.Task, applied.Change)
+		if err := applied.Rollback.Revert(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", applied.Task, err))
+		}
+	}
+	return errs
+}
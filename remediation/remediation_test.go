@@ -0,0 +1,157 @@
+package remediation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+type stubTask struct {
+	identifier string
+}
+
+func (s stubTask) Identifier() tasks.Identifier { return tasks.IdentifierFromString(s.identifier) }
+func (s stubTask) Explain() string              { return "" }
+func (s stubTask) Dependencies() []string       { return nil }
+func (s stubTask) Execute(tasks.Options, map[string]tasks.Result) tasks.Result {
+	return tasks.Result{}
+}
+
+type stubAction struct {
+	name     string
+	planErr  error
+	applyErr error
+	applied  *[]string
+}
+
+func (s stubAction) Plan() (string, error) {
+	return "change " + s.name, s.planErr
+}
+
+func (s stubAction) Apply() (tasks.Rollback, error) {
+	if s.applyErr != nil {
+		return tasks.Rollback{}, s.applyErr
+	}
+	*s.applied = append(*s.applied, s.name)
+	return tasks.Rollback{Type: tasks.RollbackNone, Path: s.name}, nil
+}
+
+func taskResult(identifier string, actions ...tasks.RemediationAction) registration.TaskResult {
+	return registration.TaskResult{
+		Task: stubTask{identifier: identifier},
+		Result: tasks.Result{
+			Status:       tasks.Failure,
+			Remediations: []tasks.Remediation{{Description: "fix " + identifier, Actions: actions}},
+		},
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	var applied, asked []string
+	results := []registration.TaskResult{
+		taskResult("PHP/Daemon/Running", stubAction{name: "restart", applied: &applied}),
+		taskResult("Base/Config/LogLevel",
+			stubAction{name: "log level", applied: &applied},
+			stubAction{name: "declined", applied: &applied},
+			stubAction{name: "already fixed", planErr: tasks.ErrNothingToChange, applied: &applied},
+			stubAction{name: "broken", applyErr: errors.New("permission denied"), applied: &applied},
+		),
+		{Task: stubTask{identifier: "Base/Env/HostInfo"}, Result: tasks.Result{Status: tasks.Info}},
+	}
+	runner := Runner{
+		Confirm: func(msg string, options tasks.Options) bool {
+			asked = append(asked, msg)
+			return len(asked) != 2 // decline the second action
+		},
+	}
+
+	manifest := runner.Run(results)
+
+	if !reflect.DeepEqual(applied, []string{"log level", "restart"}) {
+		t.Errorf("applied %v, want the confirmed actions in task order", applied)
+	}
+	if len(asked) != 4 {
+		t.Errorf("asked %d times, want once for every action with a change to make", len(asked))
+	}
+	if len(manifest.Applied) != 2 {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	want := Applied{
+		Task:        "Base/Config/LogLevel",
+		Remediation: "fix Base/Config/LogLevel",
+		Change:      "change log level",
+		Rollback:    tasks.Rollback{Type: tasks.RollbackNone, Path: "log level"},
+	}
+	if !reflect.DeepEqual(manifest.Applied[0], want) {
+		t.Errorf("manifest.Applied[0] = %+v, want %+v", manifest.Applied[0], want)
+	}
+}
+
+func TestRunner_Run_dryRun(t *testing.T) {
+	var applied []string
+	runner := Runner{
+		DryRun: true,
+		Confirm: func(string, tasks.Options) bool {
+			t.Error("a dry run should not ask for confirmation")
+			return true
+		},
+	}
+	manifest := runner.Run([]registration.TaskResult{taskResult("PHP/Daemon/Running", stubAction{name: "restart", applied: &applied})})
+
+	if len(applied) != 0 || len(manifest.Applied) != 0 {
+		t.Errorf("dry run applied %v, manifest %+v", applied, manifest)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "newrelic.yml")
+	os.WriteFile(config, []byte("log_level: info\n"), 0644)
+
+	edit := tasks.FileEdit{
+		Path: config,
+		Edit: func(content []byte) ([]byte, error) {
+			return []byte(strings.Replace(string(content), "info", "finest", 1)), nil
+		},
+	}
+	runner := Runner{Confirm: func(string, tasks.Options) bool { return true }}
+	results := []registration.TaskResult{taskResult("Base/Config/LogLevel", edit)}
+	manifestPath := filepath.Join(dir, ManifestFileName)
+	if err := WriteManifest(runner.Run(results), manifestPath); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(config); string(content) != "log_level: finest\n" {
+		t.Fatalf("the edit was not applied: %q", content)
+	}
+
+	if errs := Rollback(manifestPath); len(errs) != 0 {
+		t.Fatalf("Rollback() errors = %v", errs)
+	}
+	if content, _ := os.ReadFile(config); string(content) != "log_level: info\n" {
+		t.Errorf("Rollback() left %q", content)
+	}
+}
+
+func TestRollback_errors(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, ManifestFileName)
+	os.WriteFile(manifestPath, []byte(`{"applied":[
+		{"task":"PHP/Daemon/Running","rollback":{"type":"none"}},
+		{"task":"Base/Config/LogLevel","rollback":{"type":"restore","path":"`+filepath.ToSlash(filepath.Join(dir, "newrelic.yml"))+`","backup":"missing"}}
+	]}`), 0644)
+
+	errs := Rollback(manifestPath)
+	if len(errs) != 2 || !strings.HasPrefix(errs[0].Error(), "Base/Config/LogLevel") || !strings.Contains(errs[1].Error(), "cannot be reverted") {
+		t.Errorf("Rollback() errors = %v, want both changes reported, newest first", errs)
+	}
+
+	if errs := Rollback(filepath.Join(dir, "missing.json")); len(errs) != 1 {
+		t.Errorf("Rollback() of a missing manifest errors = %v", errs)
+	}
+}
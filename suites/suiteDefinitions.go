@@ -10,6 +10,16 @@ var suiteDefinitions = []Suite{
 			"Java/*",
 		},
 	},
+	{
+		Identifier:  "java:jcmd",
+		DisplayName: "Java Agent (jcmd)",
+		Description: "Java Agent installation with jcmd introspection of the running JVMs",
+		Tasks: []string{
+			"Base/*",
+			"Java/*",
+			"Java/JVM/Introspect",
+		},
+	},
 	{
 		Identifier:  "infra",
 		DisplayName: "Infrastructure Agent",
@@ -29,6 +39,16 @@ var suiteDefinitions = []Suite{
 			"Infra/Agent/Debug",
 		},
 	},
+	{
+		Identifier:  "infra:flex",
+		DisplayName: "Infrastructure Agent (Flex dry-run)",
+		Description: "Infrastructure Agent installation with a dry-run of the commands and requests of Flex integrations",
+		Tasks: []string{
+			"Base/*",
+			"Infra/*",
+			"Infra/Flex/DryRun",
+		},
+	},
 	{
 		Identifier:  "dotnet",
 		DisplayName: ".NET Agent",
@@ -108,7 +128,20 @@ var suiteDefinitions = []Suite{
 		Tasks: []string{
 			"Base/Env/HostInfo",
 			"Base/Env/SELinux",
-			"Synthetics/*",
+			"Synthetics/Minion/*",
+		},
+	},
+	{
+		Identifier:  "sjm",
+		DisplayName: "Synthetics Job Manager",
+		Description: "Gather information about Synthetics Job Managers on Docker, Podman and Kubernetes",
+		Tasks: []string{
+			"Base/Env/HostInfo",
+			"Base/Env/SELinux",
+			"Synthetics/JobManager/Detect",
+			"Synthetics/JobManager/ConfigValidate",
+			"Synthetics/JobManager/HordeConnect",
+			"Synthetics/JobManager/CollectLogs",
 		},
 	},
 	{
@@ -136,6 +169,36 @@ var suiteDefinitions = []Suite{
 			"K8s/*",
 		},
 	},
+	{
+		Identifier:  "lambda",
+		DisplayName: "AWS Lambda",
+		Description: "To diagnose a Lambda function offline, run './nrdiag -suites lambda -o Serverless/Lambda/Detect.path=PATH-TO-FUNCTION-DIR-OR-ZIP'",
+		Tasks: []string{
+			"Serverless/*",
+		},
+	},
+	{
+		Identifier:  "prometheus",
+		DisplayName: "Prometheus",
+		Description: "Prometheus remote_write and nri-prometheus integrations",
+		Tasks: []string{
+			"Prometheus/*",
+		},
+	},
+	{
+		Identifier:  "network",
+		DisplayName: "Network",
+		Description: "DNS, TCP, TLS and HTTP checks of the New Relic endpoints of the detected regions, and of the CA bundles and proxy settings of the agents",
+		Tasks: []string{
+			"Base/Network/Endpoints",
+			"Base/Network/DNS",
+			"Base/Network/TCP",
+			"Base/Network/TLS",
+			"Base/Network/HTTP",
+			"Base/Network/TLSInspect",
+			"Base/Config/ProxyReconcile",
+		},
+	},
 	{
 		Identifier:  "all",
 		DisplayName: "All New Relic Products",
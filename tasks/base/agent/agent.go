@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"os"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/suites"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
@@ -12,4 +14,10 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(BaseAgentEOL{
 		suiteManager: suites.DefaultSuiteManager,
 	}, true)
+	registrationFunc(BaseAgentConflicts{
+		suiteManager:  suites.DefaultSuiteManager,
+		getProcArgs:   tasks.GetProcArgs,
+		getProcessEnv: tasks.GetProcessEnvVars,
+		fileReader:    os.ReadFile,
+	}, true)
 }
@@ -0,0 +1,357 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/suites"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+	nodeEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/node/env"
+)
+
+// BaseAgentConflicts - looks for other APM agents and profilers next to the New Relic agents
+type BaseAgentConflicts struct {
+	suiteManager  *suites.SuiteManager
+	getProcArgs   func(string) []tasks.JavaProcArgs
+	getProcessEnv func(int32) (tasks.EnvironmentVariables, error)
+	fileReader    func(string) ([]byte, error)
+}
+
+// AgentConflict - another agent instrumenting the same application as a New Relic agent
+type AgentConflict struct {
+	Language string
+	Vendor   string
+	// Source is where the other agent was found: the command line or the environment of a process, or a dependency file
+	Source string
+	// Active is set when the other agent is loaded next to the New Relic agent, otherwise it is only installed
+	Active bool
+}
+
+// the node options that load a module before the application
+var nodePreloadFlags = []string{"-r", "--require", "--import", "--loader", "--experimental-loader"}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseAgentConflicts) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Agent/Conflicts")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseAgentConflicts) Explain() string {
+	return "Detect other APM agents and profilers instrumenting the same applications as the New Relic agents"
+}
+
+// Dependencies - like Base/Agent/EOL, only the dependency inventories of the languages of the selected suites are collected
+func (p BaseAgentConflicts) Dependencies() []string {
+	var dependencies []string
+	if p.isLanguageSelected("node") {
+		dependencies = append(dependencies, "Node/Env/Dependencies")
+	}
+	if p.isLanguageSelected("python") {
+		dependencies = append(dependencies, "Python/Env/Dependencies")
+	}
+	if p.isLanguageSelected("ruby") {
+		dependencies = append(dependencies, "Ruby/Config/Collect")
+	}
+	return dependencies
+}
+
+func (p BaseAgentConflicts) isLanguageSelected(suite string) bool {
+	if p.suiteManager == nil || len(p.suiteManager.SelectedSuites) == 0 {
+		return true
+	}
+	for _, selected := range p.suiteManager.SelectedSuites {
+		if selected.Identifier == "all" || selected.Identifier == suite || strings.HasPrefix(selected.Identifier, suite+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute - The core work within each task
+func (p BaseAgentConflicts) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var conflicts []AgentConflict
+	newRelicFound := false
+	// every check reports whether it found a New Relic agent, conflicts are only looked for next to one
+	collect := func(found []AgentConflict, hasNewRelic bool) {
+		conflicts = append(conflicts, found...)
+		newRelicFound = newRelicFound || hasNewRelic
+	}
+	if p.isLanguageSelected("java") {
+		collect(p.getJavaConflicts())
+	}
+	if p.isLanguageSelected("node") {
+		collect(p.getNodeConflicts(upstream["Node/Env/Dependencies"]))
+	}
+	if p.isLanguageSelected("python") {
+		collect(getPythonConflicts(upstream["Python/Env/Dependencies"]))
+	}
+	if p.isLanguageSelected("ruby") {
+		collect(p.getRubyConflicts(upstream["Ruby/Config/Collect"]))
+	}
+	if p.isLanguageSelected("dotnet") || p.isLanguageSelected("dotnetcore") {
+		collect(p.getDotNetConflicts())
+	}
+
+	if !newRelicFound {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic agents detected. This task did not run",
+		}
+	}
+	if len(conflicts) == 0 {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "No other APM agents or profilers were found next to the New Relic agents",
+		}
+	}
+
+	var active, installed []string
+	for _, conflict := range conflicts {
+		line := fmt.Sprintf("%s: %s agent in %s", conflict.Language, conflict.Vendor, conflict.Source)
+		if conflict.Active {
+			active = append(active, line)
+		} else {
+			installed = append(installed, line)
+		}
+	}
+	var summary []string
+	if len(active) > 0 {
+		summary = append(summary, "The following agents are loaded next to the New Relic agent, two agents instrumenting the same code cause missing or duplicated data:\n"+strings.Join(active, "\n"))
+	}
+	if len(installed) > 0 {
+		summary = append(summary, "The following agents are installed next to the New Relic agent, make sure only one of them is enabled:\n"+strings.Join(installed, "\n"))
+	}
+	status := tasks.Warning
+	if len(active) > 0 {
+		status = tasks.Failure
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		URL:     "https://docs.newrelic.com/docs/apm/new-relic-apm/troubleshooting/troubleshooting-other-monitoring-software/",
+		Payload: conflicts,
+	}
+}
+
+// getJavaConflicts - a JVM loads every -javaagent and -agentpath, only the ones next to newrelic.jar conflict
+func (p BaseAgentConflicts) getJavaConflicts() ([]AgentConflict, bool) {
+	var conflicts []AgentConflict
+	hasNewRelic := false
+	for _, proc := range p.getProcArgs("java") {
+		var newRelicAgents int
+		var others []AgentConflict
+		for _, arg := range proc.Args {
+			var agent string
+			switch {
+			case strings.HasPrefix(arg, "-javaagent:"):
+				agent = strings.TrimPrefix(arg, "-javaagent:")
+			case strings.HasPrefix(arg, "-agentpath:"):
+				agent = strings.TrimPrefix(arg, "-agentpath:")
+			default:
+				continue
+			}
+			if strings.Contains(strings.ToLower(filepath.Base(agent)), "newrelic") {
+				newRelicAgents++
+				if newRelicAgents > 1 {
+					others = append(others, AgentConflict{Language: "Java", Vendor: "a second New Relic", Source: fmt.Sprintf("process %d (%s)", proc.ProcID, agent), Active: true})
+				}
+				continue
+			}
+			if vendor := compatibilityVars.JavaConflictingAgentVendor(agent); vendor != "" {
+				others = append(others, AgentConflict{Language: "Java", Vendor: vendor, Source: fmt.Sprintf("process %d (%s)", proc.ProcID, agent), Active: true})
+			}
+		}
+		if newRelicAgents > 0 {
+			hasNewRelic = true
+			conflicts = append(conflicts, others...)
+		}
+	}
+	return conflicts, hasNewRelic
+}
+
+// getNodeConflicts - modules preloaded with the options of a node process, on its command line or in its NODE_OPTIONS, are active,
+// the other ones are only installed
+func (p BaseAgentConflicts) getNodeConflicts(dependencies tasks.Result) ([]AgentConflict, bool) {
+	var conflicts []AgentConflict
+	preloadedNewRelic := false
+	for _, proc := range p.getProcArgs("node") {
+		fromArgs := getNodePreloadedModules(proc.Args)
+		fromEnv := getNodePreloadedModules(strings.Fields(p.processEnv(proc.ProcID)["NODE_OPTIONS"]))
+		if !tasks.ContainsString(fromArgs, "newrelic") && !tasks.ContainsString(fromEnv, "newrelic") {
+			continue
+		}
+		preloadedNewRelic = true
+		sources := []struct {
+			name    string
+			modules []string
+		}{
+			{fmt.Sprintf("process %d", proc.ProcID), fromArgs},
+			{fmt.Sprintf("NODE_OPTIONS of process %d", proc.ProcID), fromEnv},
+		}
+		for _, source := range sources {
+			for _, module := range source.modules {
+				if vendor := compatibilityVars.NodeConflictingModules[module]; vendor != "" {
+					conflicts = append(conflicts, AgentConflict{Language: "Node", Vendor: vendor, Source: fmt.Sprintf("%s (%s)", source.name, module), Active: true})
+				}
+			}
+		}
+	}
+
+	modules, ok := dependencies.Payload.([]nodeEnv.NodeModuleVersion)
+	if !ok {
+		return conflicts, preloadedNewRelic
+	}
+	hasNewRelic := false
+	for _, module := range modules {
+		hasNewRelic = hasNewRelic || module.Module == "newrelic"
+	}
+	if !hasNewRelic {
+		return conflicts, preloadedNewRelic
+	}
+	for _, module := range modules {
+		if vendor := compatibilityVars.NodeConflictingModules[module.Module]; vendor != "" && !hasActiveConflict(conflicts, "Node", vendor) {
+			conflicts = append(conflicts, AgentConflict{Language: "Node", Vendor: vendor, Source: "node_modules (" + module.Module + "@" + module.Version + ")"})
+		}
+	}
+	return conflicts, true
+}
+
+// getNodePreloadedModules - returns the package names of -r dd-trace/init, --require=newrelic and --import newrelic/esm-loader.mjs
+func getNodePreloadedModules(args []string) []string {
+	var modules []string
+	for i, arg := range args {
+		var specifier string
+		for _, flag := range nodePreloadFlags {
+			if arg == flag && i+1 < len(args) {
+				specifier = args[i+1]
+			} else if strings.HasPrefix(arg, flag+"=") {
+				specifier = strings.TrimPrefix(arg, flag+"=")
+			}
+		}
+		if specifier == "" || strings.HasPrefix(specifier, ".") || strings.HasPrefix(specifier, "/") {
+			continue
+		}
+		parts := strings.Split(specifier, "/")
+		module := parts[0]
+		if strings.HasPrefix(module, "@") && len(parts) > 1 {
+			module += "/" + parts[1]
+		}
+		modules = append(modules, module)
+	}
+	return modules
+}
+
+// getPythonConflicts - the payload of Python/Env/Dependencies lists the installed distributions as name==version
+func getPythonConflicts(dependencies tasks.Result) ([]AgentConflict, bool) {
+	packages, ok := dependencies.Payload.([]string)
+	if !ok {
+		return nil, false
+	}
+	hasNewRelic := false
+	for _, dependency := range packages {
+		name, _, _ := strings.Cut(dependency, "==")
+		hasNewRelic = hasNewRelic || tasks.NormalizePythonPackageName(name) == "newrelic"
+	}
+	if !hasNewRelic {
+		return nil, false
+	}
+	var conflicts []AgentConflict
+	for _, dependency := range packages {
+		name, _, _ := strings.Cut(dependency, "==")
+		if vendor := compatibilityVars.PythonConflictingPackages[tasks.NormalizePythonPackageName(name)]; vendor != "" && !hasActiveConflict(conflicts, "Python", vendor) {
+			conflicts = append(conflicts, AgentConflict{Language: "Python", Vendor: vendor, Source: "installed packages (" + dependency + ")"})
+		}
+	}
+	return conflicts, true
+}
+
+// getRubyConflicts - Bundler requires every gem of the Gemfile, so a gem next to newrelic_rpm is active
+func (p BaseAgentConflicts) getRubyConflicts(gemfiles tasks.Result) ([]AgentConflict, bool) {
+	paths, ok := gemfiles.Payload.([]string)
+	if !ok {
+		return nil, false
+	}
+	var conflicts []AgentConflict
+	newRelicFound := false
+	for _, path := range paths {
+		if filepath.Base(path) != "Gemfile.lock" {
+			continue
+		}
+		content, err := p.fileReader(path)
+		if err != nil {
+			log.Debug("Unable to read", path, ":", err)
+			continue
+		}
+		lockfile, err := tasks.ParseLockfile(path, content)
+		if err != nil {
+			log.Debug("Unable to parse", path, ":", err)
+			continue
+		}
+		hasNewRelic := false
+		for _, gem := range lockfile.Dependencies {
+			hasNewRelic = hasNewRelic || gem.Name == "newrelic_rpm"
+		}
+		if !hasNewRelic {
+			continue
+		}
+		newRelicFound = true
+		for _, gem := range lockfile.Dependencies {
+			if vendor := compatibilityVars.RubyConflictingGems[gem.Name]; vendor != "" {
+				conflicts = append(conflicts, AgentConflict{Language: "Ruby", Vendor: vendor, Source: fmt.Sprintf("%s (%s %s)", path, gem.Name, gem.Version), Active: gem.Direct})
+			}
+		}
+	}
+	return conflicts, newRelicFound
+}
+
+// getDotNetConflicts - a process loads a single profiler, the one of COR_PROFILER or CORECLR_PROFILER in its environment.
+// The environment of other processes can only be read on Linux.
+func (p BaseAgentConflicts) getDotNetConflicts() ([]AgentConflict, bool) {
+	var conflicts []AgentConflict
+	hasNewRelic := false
+	for _, proc := range p.getProcArgs("dotnet") {
+		envVars := p.processEnv(proc.ProcID)
+		if envVars["CORECLR_NEWRELIC_HOME"] == "" && envVars["NEWRELIC_HOME"] == "" && envVars["NEWRELIC_INSTALL_PATH"] == "" {
+			continue
+		}
+		hasNewRelic = true
+		for _, prefix := range []string{"COR", "CORECLR"} {
+			clsid := strings.ToUpper(strings.TrimSpace(envVars[prefix+"_PROFILER"]))
+			if clsid == "" || tasks.ContainsString(compatibilityVars.DotNetNewRelicProfilers, clsid) {
+				continue
+			}
+			vendor := compatibilityVars.DotNetConflictingProfilers[clsid]
+			if vendor == "" {
+				vendor = "an unknown"
+			}
+			source := fmt.Sprintf("process %d (%s_PROFILER=%s", proc.ProcID, prefix, clsid)
+			if path := envVars[prefix+"_PROFILER_PATH"]; path != "" {
+				source += " " + path
+			}
+			conflicts = append(conflicts, AgentConflict{Language: ".NET", Vendor: vendor, Source: source + ")", Active: true})
+		}
+	}
+	return conflicts, hasNewRelic
+}
+
+// processEnv - the environment variables of a process, empty when they can't be read
+func (p BaseAgentConflicts) processEnv(pid int32) map[string]string {
+	envVars, err := p.getProcessEnv(pid)
+	if err != nil {
+		log.Debug("Unable to read the environment of process", pid, ":", err)
+		return nil
+	}
+	return envVars.All
+}
+
+func hasActiveConflict(conflicts []AgentConflict, language string, vendor string) bool {
+	for _, conflict := range conflicts {
+		if conflict.Language == language && conflict.Vendor == vendor && conflict.Active {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/suites"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	nodeEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/node/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Base/Agent/Conflicts", func() {
+	var (
+		p          BaseAgentConflicts
+		procArgs   map[string][]tasks.JavaProcArgs
+		processEnv map[int32]map[string]string
+		upstream   map[string]tasks.Result
+	)
+
+	BeforeEach(func() {
+		procArgs = map[string][]tasks.JavaProcArgs{}
+		processEnv = map[int32]map[string]string{}
+		upstream = map[string]tasks.Result{}
+		p = BaseAgentConflicts{
+			suiteManager: &suites.SuiteManager{},
+			getProcArgs:  func(name string) []tasks.JavaProcArgs { return procArgs[name] },
+			getProcessEnv: func(pid int32) (tasks.EnvironmentVariables, error) {
+				return tasks.EnvironmentVariables{All: processEnv[pid]}, nil
+			},
+			fileReader: os.ReadFile,
+		}
+	})
+
+	Describe("Dependencies()", func() {
+		It("Should depend on every inventory without a selected suite", func() {
+			Expect(p.Dependencies()).To(Equal([]string{"Node/Env/Dependencies", "Python/Env/Dependencies", "Ruby/Config/Collect"}))
+		})
+
+		It("Should only depend on the inventories of the selected suites", func() {
+			p.suiteManager = &suites.SuiteManager{SelectedSuites: []suites.Suite{{Identifier: "java:jcmd"}}}
+			Expect(p.Dependencies()).To(BeEmpty())
+		})
+	})
+
+	Describe("Execute()", func() {
+		It("Should succeed without other agents", func() {
+			procArgs["java"] = []tasks.JavaProcArgs{{ProcID: 10, Args: []string{"java", "-javaagent:/opt/newrelic/newrelic.jar", "-jar", "app.jar"}}}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+
+		It("Should fail for another -javaagent next to newrelic.jar", func() {
+			procArgs["java"] = []tasks.JavaProcArgs{
+				{ProcID: 10, Args: []string{"java", "-javaagent:/opt/appdynamics/javaagent.jar", "-javaagent:/opt/newrelic/newrelic.jar", "-jar", "app.jar"}},
+				// other agents of JVMs without the New Relic agent are not conflicts
+				{ProcID: 11, Args: []string{"java", "-javaagent:/opt/dd/dd-java-agent.jar", "-jar", "other.jar"}},
+			}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Payload).To(Equal([]AgentConflict{{Language: "Java", Vendor: "AppDynamics", Source: "process 10 (/opt/appdynamics/javaagent.jar)", Active: true}}))
+		})
+
+		It("Should fail for modules preloaded next to newrelic and warn for the installed ones", func() {
+			procArgs["node"] = []tasks.JavaProcArgs{
+				{ProcID: 20, Args: []string{"node", "-r", "newrelic", "--require=dd-trace/init", "server.js"}},
+				{ProcID: 21, Args: []string{"node", "worker.js"}},
+				// the modules of a process without the New Relic agent are not conflicts
+				{ProcID: 22, Args: []string{"node", "-r", "elastic-apm-node/start", "other.js"}},
+			}
+			processEnv[21] = map[string]string{"NODE_OPTIONS": "--import newrelic/esm-loader.mjs --import @opentelemetry/auto-instrumentations-node/register"}
+			upstream["Node/Env/Dependencies"] = tasks.Result{Status: tasks.Info, Payload: []nodeEnv.NodeModuleVersion{
+				{Module: "newrelic", Version: "12.5.0"},
+				{Module: "dd-trace", Version: "5.21.0"},
+				{Module: "elastic-apm-node", Version: "4.7.3"},
+			}}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(Equal("The following agents are loaded next to the New Relic agent, two agents instrumenting the same code cause missing or duplicated data:\n" +
+				"Node: Datadog agent in process 20 (dd-trace)\n" +
+				"Node: OpenTelemetry agent in NODE_OPTIONS of process 21 (@opentelemetry/auto-instrumentations-node)\n" +
+				"The following agents are installed next to the New Relic agent, make sure only one of them is enabled:\n" +
+				"Node: Elastic APM agent in node_modules (elastic-apm-node@4.7.3)"))
+		})
+
+		It("Should warn for Python packages installed next to newrelic", func() {
+			upstream["Python/Env/Dependencies"] = tasks.Result{Status: tasks.Success, Payload: []string{"newrelic==9.13.0", "ddtrace==2.11.1", "flask==3.0.3"}}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Payload).To(Equal([]AgentConflict{{Language: "Python", Vendor: "Datadog", Source: "installed packages (ddtrace==2.11.1)"}}))
+		})
+
+		It("Should fail for gems required by the Gemfile next to newrelic_rpm", func() {
+			upstream["Ruby/Config/Collect"] = tasks.Result{Status: tasks.Success, Payload: []string{"fixtures/Gemfile.lock"}}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Payload).To(Equal([]AgentConflict{
+				{Language: "Ruby", Vendor: "Datadog", Source: "fixtures/Gemfile.lock (datadog 2.3.0)", Active: true},
+				{Language: "Ruby", Vendor: "Skylight", Source: "fixtures/Gemfile.lock (skylight 6.0.4)"},
+			}))
+		})
+
+		It("Should fail when CORECLR_PROFILER of a process is set to another profiler", func() {
+			procArgs["dotnet"] = []tasks.JavaProcArgs{
+				{ProcID: 30, Args: []string{"dotnet", "app.dll"}},
+				// the profiler of a process without the New Relic agent is not a conflict
+				{ProcID: 31, Args: []string{"dotnet", "other.dll"}},
+			}
+			processEnv[30] = map[string]string{
+				"CORECLR_NEWRELIC_HOME": "/usr/local/newrelic-dotnet-agent",
+				"CORECLR_PROFILER":      "{846f5f1c-f9ae-4b07-969e-05c26bc060d8}",
+				"CORECLR_PROFILER_PATH": "/opt/datadog/Datadog.Trace.ClrProfiler.Native.so",
+				"COR_PROFILER":          "{71DA0A04-7777-4EC6-9643-7D28B46A8A41}",
+			}
+			processEnv[31] = map[string]string{"CORECLR_PROFILER": "{846f5f1c-f9ae-4b07-969e-05c26bc060d8}"}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Payload).To(Equal([]AgentConflict{{Language: ".NET", Vendor: "Datadog", Source: "process 30 (CORECLR_PROFILER={846F5F1C-F9AE-4B07-969E-05C26BC060D8} /opt/datadog/Datadog.Trace.ClrProfiler.Native.so)", Active: true}}))
+		})
+
+		It("Should only check the languages of the selected suites", func() {
+			p.suiteManager = &suites.SuiteManager{SelectedSuites: []suites.Suite{{Identifier: "python"}}}
+			procArgs["java"] = []tasks.JavaProcArgs{{ProcID: 10, Args: []string{"java", "-javaagent:/opt/newrelic/newrelic.jar", "-javaagent:/opt/dd/dd-java-agent.jar"}}}
+			upstream["Python/Env/Dependencies"] = tasks.Result{Status: tasks.Success, Payload: []string{"newrelic==9.13.0", "flask==3.0.3"}}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+
+		It("Should return None when no New Relic agent was detected", func() {
+			procArgs["java"] = []tasks.JavaProcArgs{{ProcID: 11, Args: []string{"java", "-javaagent:/opt/dd/dd-java-agent.jar", "-jar", "other.jar"}}}
+			upstream["Python/Env/Dependencies"] = tasks.Result{Status: tasks.Success, Payload: []string{"ddtrace==2.11.1"}}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Payload).To(BeNil())
+		})
+	})
+})
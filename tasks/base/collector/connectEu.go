@@ -12,7 +12,7 @@ import (
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
-// BaseCollectorConnectEU - This task connects to collector.newrelic.com and reports the status
+// BaseCollectorConnectEU - This task connects to the EU region collector and reports the status
 type BaseCollectorConnectEU struct {
 	upstream   map[string]tasks.Result
 	httpGetter requestFunc
@@ -40,8 +40,6 @@ func (p BaseCollectorConnectEU) Dependencies() []string {
 func (p BaseCollectorConnectEU) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	p.upstream = upstream
 
-	url := "https://collector.eu.newrelic.com/status/mongrel"
-
 	// Was the task not explicitly provided on -t ?
 	if !config.Flags.IsForcedTask(p.Identifier().String()) {
 		result := p.prepareEarlyResult()
@@ -54,7 +52,7 @@ func (p BaseCollectorConnectEU) Execute(op tasks.Options, upstream map[string]ta
 	// Make request
 	wrapper := httpHelper.RequestWrapper{
 		Method:         "GET",
-		URL:            url,
+		URL:            euCollector.URL(),
 		TimeoutSeconds: 30,
 	}
 	resp, err := p.httpGetter(wrapper)
@@ -99,7 +97,7 @@ func (p BaseCollectorConnectEU) prepareCollectorErrorResult(e error) tasks.Resul
 		return result
 	}
 	result.Status = tasks.Failure
-	result.Summary = "There was an error connecting to collector.eu.newrelic.com (EU Region)"
+	result.Summary = "There was an error connecting to " + euCollector.Host + " (EU Region)"
 	result.Summary += "\nPlease check network and proxy settings and try again or see -help for more options."
 	result.Summary += "\nError = " + e.Error()
 	result.URL = "https://docs.newrelic.com/docs/apm/new-relic-apm/getting-started/networks"
@@ -127,12 +125,12 @@ func (p BaseCollectorConnectEU) prepareResult(body, statusCode string) tasks.Res
 	if statusCode == "404" && body == "{}" {
 		log.Debug("Successfully connected")
 		result.Status = tasks.Success
-		result.Summary = "Successfully connected to collector.eu.newrelic.com (EU Region)"
+		result.Summary = "Successfully connected to " + euCollector.Host + " (EU Region)"
 	} else {
-		log.Debug("Unsuccessful response received from collector.eu.newrelic.com.")
+		log.Debug("Unsuccessful response received from " + euCollector.Host + ".")
 		log.Debug("Body:", body)
 		result.Status = tasks.Warning
-		result.Summary = "The connection to collector.eu.newrelic.com (EU Region) was not successful."
+		result.Summary = "The connection to " + euCollector.Host + " (EU Region) was not successful."
 		result.Summary += "\nPlease check network and proxy settings and try again or see -help for more options."
 		result.Summary += "\nResponse Body: " + body
 		result.URL = "https://docs.newrelic.com/docs/apm/new-relic-apm/getting-started/networks"
@@ -7,6 +7,13 @@ import (
 	"net/http"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/network"
+)
+
+// the collectors of the regions, from the Base/Network endpoint catalog
+var (
+	usCollector, _ = network.CatalogEndpoint("us01", "collector")
+	euCollector, _ = network.CatalogEndpoint("eu01", "collector")
 )
 
 type requestFunc func(wrapper httpHelper.RequestWrapper) (*http.Response, error)
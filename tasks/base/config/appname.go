@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/logger"
@@ -115,9 +118,10 @@ func (t BaseConfigAppName) Execute(options tasks.Options, upstream map[string]ta
 
 	if len(appNameInfosFromConfig) == 0 {
 		return tasks.Result{
-			Status:  tasks.Warning,
-			Summary: "No New Relic app names were found. Please ensure an app name is set in your New Relic agent configuration file or as a New Relic environment variable (NEW_RELIC_APP_NAME). Ignore this warning if you are troubleshooting for a non APM Agent.",
-			URL:     "https://docs.newrelic.com/docs/agents/manage-apm-agents/app-naming/name-your-application",
+			Status:       tasks.Warning,
+			Summary:      "No New Relic app names were found. Please ensure an app name is set in your New Relic agent configuration file or as a New Relic environment variable (NEW_RELIC_APP_NAME). Ignore this warning if you are troubleshooting for a non APM Agent.",
+			URL:          "https://docs.newrelic.com/docs/agents/manage-apm-agents/app-naming/name-your-application",
+			Remediations: missingAppNameRemediations(configElements),
 		}
 	}
 
@@ -247,3 +251,92 @@ func getAppNameFromSysProps(upstream map[string]tasks.Result) string {
 	}
 	return ""
 }
+
+var (
+	yamlCommonSectionRgx = regexp.MustCompile(`(?m)^common:.*$`)
+	yamlIndentRgx        = regexp.MustCompile(`(?m)^([ \t]+)\S`)
+	iniNewRelicSection   = regexp.MustCompile(`(?m)^\[newrelic\][ \t]*\r?$`)
+	appNameSettingRgx    = regexp.MustCompile(`(?m)^\s*(app_name|newrelic\.appname)\s*[:=]`)
+)
+
+// missingAppNameRemediations - offers to add an app name, the host name, to every config file we know how to edit.
+// The agent reads it from the config file the same way it would read NEW_RELIC_APP_NAME, which cannot be set for an application from here.
+func missingAppNameRemediations(configElements []ValidateElement) []tasks.Remediation {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return nil
+	}
+	var remediations []tasks.Remediation
+	for _, configElement := range configElements {
+		configFile := filepath.Join(configElement.Config.FilePath, configElement.Config.FileName)
+		content, err := os.ReadFile(configFile)
+		if err != nil {
+			continue
+		}
+		if _, err := insertAppName(content, configFile, hostname); err != nil {
+			logger.Debug("Not offering to add an app name to", configFile+":", err)
+			continue
+		}
+		remediations = append(remediations, tasks.Remediation{
+			Description: fmt.Sprintf("Add the app name %q to %s. Restart the application for it to take effect.", hostname, configFile),
+			Actions: []tasks.RemediationAction{
+				tasks.FileEdit{
+					Path: configFile,
+					Edit: func(content []byte) ([]byte, error) {
+						return insertAppName(content, configFile, hostname)
+					},
+				},
+			},
+		})
+	}
+	return remediations
+}
+
+// insertAppName - adds an app name setting to the section the agent reads it from: common in newrelic.yml, [newrelic] in newrelic.ini
+func insertAppName(content []byte, configFile string, appName string) ([]byte, error) {
+	if appNameSettingRgx.Match(content) {
+		// an empty setting is better fixed by hand than by adding a duplicate key
+		return nil, fmt.Errorf("the file already has an app name setting")
+	}
+	var section []int
+	var setting string
+	switch filepath.Ext(configFile) {
+	case ".yml", ".yaml":
+		section = yamlCommonSectionRgx.FindIndex(content)
+		indent := "  "
+		if section != nil {
+			if match := yamlIndentRgx.FindSubmatch(content[section[1]:]); match != nil {
+				indent = string(match[1])
+			}
+		}
+		setting = fmt.Sprintf("%sapp_name: %s", indent, appName)
+	case ".ini":
+		section = iniNewRelicSection.FindIndex(content)
+		if strings.Contains(string(content), "newrelic.license") {
+			setting = fmt.Sprintf("newrelic.appname = %q", appName) // PHP
+		} else {
+			setting = "app_name = " + appName // Python
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config file type %q", filepath.Ext(configFile))
+	}
+	if section == nil {
+		return nil, fmt.Errorf("unable to find the section for the app name")
+	}
+
+	newline := "\n"
+	if strings.HasSuffix(string(content[section[0]:section[1]]), "\r") {
+		newline = "\r\n"
+	}
+	edited := append([]byte{}, content[:section[1]]...)
+	if section[1] == len(content) {
+		edited = append(edited, newline...)
+	} else {
+		edited = append(edited, content[section[1]]) // the newline ending the section line
+	}
+	edited = append(edited, setting+newline...)
+	if section[1] < len(content) {
+		edited = append(edited, content[section[1]+1:]...)
+	}
+	return edited, nil
+}
@@ -343,4 +343,43 @@ var _ = Describe("Base/Config/AppName", func() {
 			})
 		})
 	})
+
+	Describe("insertAppName()", func() {
+		It("Should add app_name to the common section of a newrelic.yml", func() {
+			content := "common: &default_settings\n    license_key: abc\n\nproduction:\n    <<: *default_settings\n"
+			edited, err := insertAppName([]byte(content), "/app/newrelic/newrelic.yml", "web01")
+			Expect(err).To(BeNil())
+			Expect(string(edited)).To(Equal("common: &default_settings\n    app_name: web01\n    license_key: abc\n\nproduction:\n    <<: *default_settings\n"))
+		})
+
+		It("Should keep Windows line endings", func() {
+			edited, err := insertAppName([]byte("common: &default_settings\r\n  license_key: abc\r\n"), "newrelic.yml", "web01")
+			Expect(err).To(BeNil())
+			Expect(string(edited)).To(Equal("common: &default_settings\r\n  app_name: web01\r\n  license_key: abc\r\n"))
+		})
+
+		It("Should add app_name to the [newrelic] section of a Python newrelic.ini", func() {
+			edited, err := insertAppName([]byte("[newrelic]\nlicense_key = abc\n"), "newrelic.ini", "web01")
+			Expect(err).To(BeNil())
+			Expect(string(edited)).To(Equal("[newrelic]\napp_name = web01\nlicense_key = abc\n"))
+		})
+
+		It("Should add newrelic.appname to a PHP newrelic.ini", func() {
+			edited, err := insertAppName([]byte("[newrelic]\nnewrelic.license = \"abc\""), "newrelic.ini", "web01")
+			Expect(err).To(BeNil())
+			Expect(string(edited)).To(Equal("[newrelic]\nnewrelic.appname = \"web01\"\nnewrelic.license = \"abc\""))
+		})
+
+		It("Should not add a second app name setting", func() {
+			_, err := insertAppName([]byte("common: &default_settings\n  app_name:\n"), "newrelic.yml", "web01")
+			Expect(err).To(MatchError("the file already has an app name setting"))
+		})
+
+		It("Should not edit files without the expected section", func() {
+			_, err := insertAppName([]byte("license_key: abc\n"), "newrelic.yml", "web01")
+			Expect(err).To(HaveOccurred())
+			_, err = insertAppName([]byte("{}"), "newrelic.js", "web01")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net"
 	"strconv"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
@@ -15,6 +16,12 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(BaseConfigCollect{}, true)
 	registrationFunc(BaseConfigLogLevel{}, false)
 	registrationFunc(BaseConfigProxyDetect{}, true)
+	registrationFunc(BaseConfigProxyReconcile{
+		getProcArgs:   tasks.GetProcArgs,
+		getProcessEnv: tasks.GetProcessEnvVars,
+		lookupHost:    net.LookupHost,
+		dial:          net.DialTimeout,
+	}, false)
 	registrationFunc(BaseConfigLicenseKey{}, true)
 	registrationFunc(BaseConfigValidateLicenseKey{
 		validateAgainstAccount: validateAgainstAccount,
@@ -1,10 +1,18 @@
 package config
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
+// logLevelInfoRgx matches a log_level setting of info in a newrelic.yml or newrelic.ini file
+var logLevelInfoRgx = regexp.MustCompile(`(?m)^(\s*log_level\s*[:=]\s*["']?)info(["']?[ \t]*(?:#.*)?\r?)$`)
+
 // BaseConfigLogLevel - This struct defined the sample plugin which can be used as a starting point
 type BaseConfigLogLevel struct { // This defines the task itself and should be named according to the standard CategorySubcategoryTaskname in camelcase
 }
@@ -70,6 +78,9 @@ func (p BaseConfigLogLevel) Execute(options tasks.Options, upstream map[string]t
 					result.Status = tasks.Warning //Setting a task's status to Warning for things that aren't necessarily bad, but might concern us
 					result.Summary = "Log level is info, you may want to consider updating the log level to finest before uploading logs to support"
 					result.URL = "https://docs.newrelic.com/docs/agents/manage-apm-agents/configuration/configure-agent"
+					if remediation, ok := debugLogLevelRemediation(validation.Config); ok {
+						result.Remediations = append(result.Remediations, remediation)
+					}
 				default:
 					result.Status = tasks.Error // User error if something went wrong, but we're not sure if it was our fault or theirs (this isn't the best example)
 					result.Summary = "We couldn't figure out the log level."
@@ -80,3 +91,47 @@ func (p BaseConfigLogLevel) Execute(options tasks.Options, upstream map[string]t
 	return result
 	// Additional functions defined within the task should be added below the standard methods to keep code consistent
 }
+
+// debugLogLevelRemediation - offers to raise an info log_level to the agent's most verbose level. The previous level can be restored with -fix-rollback once the logs have been collected.
+func debugLogLevelRemediation(config ConfigElement) (tasks.Remediation, bool) {
+	configFile := filepath.Join(config.FilePath, config.FileName)
+	debugLevel := debugLogLevelFor(configFile)
+	if debugLevel == "" {
+		return tasks.Remediation{}, false
+	}
+	content, err := os.ReadFile(configFile)
+	if err != nil || !logLevelInfoRgx.Match(content) {
+		return tasks.Remediation{}, false
+	}
+	return tasks.Remediation{
+		Description: "Set log_level to " + debugLevel + " in " + configFile + " to collect detailed agent logs. Restart the application for it to take effect.",
+		Actions: []tasks.RemediationAction{
+			tasks.FileEdit{
+				Path: configFile,
+				Edit: func(content []byte) ([]byte, error) {
+					if !logLevelInfoRgx.Match(content) {
+						return nil, errors.New("log_level is no longer set to info")
+					}
+					return logLevelInfoRgx.ReplaceAll(content, []byte("${1}"+debugLevel+"${2}")), nil
+				},
+			},
+		},
+	}, true
+}
+
+// debugLogLevelFor - returns the most verbose log level of the agent a config file belongs to, or an empty string when the agent is not known.
+// The Java agent keeps its newrelic.yml next to newrelic.jar and logs at finest; Ruby and Python use debug.
+func debugLogLevelFor(configFile string) string {
+	switch filepath.Ext(configFile) {
+	case ".yml", ".yaml":
+		if tasks.FileExists(filepath.Join(filepath.Dir(configFile), "newrelic.jar")) {
+			return "finest"
+		}
+		if tasks.FileExists(filepath.Join(filepath.Dir(configFile), "..", "Gemfile")) {
+			return "debug"
+		}
+	case ".ini":
+		return "debug"
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Base/Config/LogLevel", func() {
+	Describe("debugLogLevelRemediation()", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+		})
+
+		It("Should raise the Java agent log level to finest", func() {
+			os.WriteFile(filepath.Join(dir, "newrelic.jar"), []byte("jar"), 0644)
+			os.WriteFile(filepath.Join(dir, "newrelic.yml"), []byte("common: &default_settings\n  log_level: info # default\n"), 0644)
+
+			remediation, ok := debugLogLevelRemediation(ConfigElement{FileName: "newrelic.yml", FilePath: dir + string(os.PathSeparator)})
+			Expect(ok).To(BeTrue())
+			_, err := remediation.Actions[0].Apply()
+			Expect(err).To(BeNil())
+			content, _ := os.ReadFile(filepath.Join(dir, "newrelic.yml"))
+			Expect(string(content)).To(Equal("common: &default_settings\n  log_level: finest # default\n"))
+		})
+
+		It("Should raise the Python agent log level to debug", func() {
+			os.WriteFile(filepath.Join(dir, "newrelic.ini"), []byte("[newrelic]\nlog_level = info\n"), 0644)
+
+			remediation, ok := debugLogLevelRemediation(ConfigElement{FileName: "newrelic.ini", FilePath: dir})
+			Expect(ok).To(BeTrue())
+			plan, err := remediation.Actions[0].Plan()
+			Expect(err).To(BeNil())
+			Expect(plan).To(ContainSubstring("-log_level = info\n+log_level = debug\n"))
+		})
+
+		It("Should not offer a change when the agent is unknown", func() {
+			os.WriteFile(filepath.Join(dir, "newrelic.yml"), []byte("log_level: info\n"), 0644)
+
+			_, ok := debugLogLevelRemediation(ConfigElement{FileName: "newrelic.yml", FilePath: dir})
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Should not offer a change when the file cannot be read", func() {
+			_, ok := debugLogLevelRemediation(ConfigElement{FileName: "newrelic.ini", FilePath: dir})
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
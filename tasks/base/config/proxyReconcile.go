@@ -0,0 +1,579 @@
+package config
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// BaseConfigProxyReconcile - compares the proxy settings of every agent, their environment and the system
+type BaseConfigProxyReconcile struct {
+	getProcArgs   func(string) []tasks.JavaProcArgs
+	getProcessEnv func(int32) (tasks.EnvironmentVariables, error)
+	lookupHost    func(string) ([]string, error)
+	dial          func(network, address string, timeout time.Duration) (net.Conn, error)
+}
+
+// ProxySource - a proxy setting found in a config file, an environment or the arguments of a process
+type ProxySource struct {
+	Agent string
+	// Source is where the setting is, e.g. the config file, "environment of process 123" or "shell environment"
+	Source string
+	// Setting is the name of the key, variable or system property
+	Setting string
+	// Value has the password of proxy URLs redacted
+	Value string
+	PID   int32
+	// proxy is the URL of the setting with its password, used for CONNECT
+	proxy string
+}
+
+// AgentProxy - the proxy an agent uses once the precedence of its settings is applied
+type AgentProxy struct {
+	Agent string
+	PID   int32
+	// Proxy is empty when the agent connects directly, its password is redacted
+	Proxy  string
+	Source string
+	// NoProxyEntries are the entries of NO_PROXY of the agent that exclude New Relic domains
+	NoProxyEntries []string
+	Notes          []string
+	proxyURL       *url.URL
+	systemProxy    bool
+}
+
+// ProxyConnect - the response of a proxy to a CONNECT to the collector
+type ProxyConnect struct {
+	Proxy      string
+	Target     string
+	StatusCode int
+	Error      string
+}
+
+// ProxyReconciliation - the payload of Base/Config/ProxyReconcile
+type ProxyReconciliation struct {
+	Sources  []ProxySource
+	Agents   []AgentProxy
+	Connects []ProxyConnect
+	Hints    []string
+}
+
+// agentProxyRule - where an agent reads its proxy from, by precedence
+type agentProxyRule struct {
+	agent        string
+	processNames []string
+	// configKind is the kind of config file of the agent, see configFileKind
+	configKind string
+	envPrefix  string
+	// systemProxyVars are the variables of the system proxy the agent uses when it has no proxy setting, in order
+	systemProxyVars []string
+}
+
+// the agents reading each kind of config file
+var configKindAgents = map[string]string{
+	"newrelic.yml":       "Java, Ruby",
+	"newrelic.js":        "Node",
+	"python":             "Python",
+	"php":                "PHP",
+	"newrelic-infra.yml": "Infrastructure",
+	"dotnet":             ".NET",
+}
+
+var agentProxyRules = []agentProxyRule{
+	// the Java agent ignores HTTPS_PROXY and -Dhttps.proxyHost, only its own settings are used
+	{agent: "Java", processNames: []string{"java"}, configKind: "newrelic.yml", envPrefix: "NEW_RELIC_PROXY_"},
+	// Net::HTTP reads http_proxy and no_proxy when the agent has no proxy setting
+	{agent: "Ruby", processNames: []string{"ruby"}, configKind: "newrelic.yml", envPrefix: "NEW_RELIC_PROXY_", systemProxyVars: []string{"http_proxy", "HTTP_PROXY"}},
+	{agent: "Node", processNames: []string{"node"}, configKind: "newrelic.js", envPrefix: "NEW_RELIC_PROXY_"},
+	{agent: "Python", processNames: []string{"python", "python3"}, configKind: "python", envPrefix: "NEW_RELIC_PROXY_"},
+	{agent: "PHP", processNames: []string{"newrelic-daemon"}, configKind: "php"},
+	// ignore_system_proxy: true makes the infrastructure agent ignore HTTPS_PROXY and HTTP_PROXY
+	{agent: "Infrastructure", processNames: []string{"newrelic-infra"}, configKind: "newrelic-infra.yml", envPrefix: "NRIA_PROXY", systemProxyVars: []string{"HTTPS_PROXY", "HTTP_PROXY"}},
+	{agent: ".NET", configKind: "dotnet"},
+}
+
+var (
+	systemProxyVariables  = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"}
+	noProxyVariables      = []string{"NO_PROXY", "no_proxy"}
+	pacVariables          = []string{"PAC_URL", "AUTO_PROXY", "auto_proxy"}
+	javaProxySysProps     = []string{"-Dhttps.proxyHost", "-Dhttps.proxyPort", "-Dhttp.proxyHost", "-Dhttp.nonProxyHosts", "-Djava.net.useSystemProxies"}
+	newRelicProxySysProps = []string{"-Dnewrelic.config.proxy_host", "-Dnewrelic.config.proxy_port", "-Dnewrelic.config.proxy_user", "-Dnewrelic.config.proxy_password", "-Dnewrelic.config.proxy_scheme"}
+	// the hosts of the collector NO_PROXY is checked against
+	newRelicProxyCheckHosts = []string{"collector.newrelic.com", "collector.eu01.nr-data.net"}
+	collectorConnectTargets = map[string]string{
+		"us01": "collector.newrelic.com:443",
+		"eu01": "collector.eu01.nr-data.net:443",
+	}
+)
+
+const proxyConnectTimeout = 10 * time.Second
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseConfigProxyReconcile) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/ProxyReconcile")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseConfigProxyReconcile) Explain() string {
+	return "Reconcile the proxy settings of the agents, their environment and the system, and check the proxies accept CONNECT to the collector"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseConfigProxyReconcile) Dependencies() []string {
+	return []string{
+		"Base/Config/Validate",
+		"Base/Env/CollectEnvVars",
+		"Base/Config/RegionDetect",
+	}
+}
+
+// Execute - The core work within each task
+func (p BaseConfigProxyReconcile) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	reconciliation := ProxyReconciliation{}
+	shellEnv, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	validations, _ := upstream["Base/Config/Validate"].Payload.([]ValidateElement)
+
+	configSources := map[string][]ProxySource{}
+	configIgnoresSystemProxy := false
+	for _, validation := range validations {
+		kind := configFileKind(validation)
+		sources := configProxySources(validation, kind, options)
+		configSources[kind] = append(configSources[kind], sources...)
+		reconciliation.Sources = append(reconciliation.Sources, sources...)
+		if kind == "newrelic-infra.yml" {
+			for _, blob := range validation.ParsedResult.FindKey("ignore_system_proxy") {
+				configIgnoresSystemProxy = configIgnoresSystemProxy || strings.EqualFold(blob.Value(), "true")
+			}
+		}
+	}
+	reconciliation.Sources = append(reconciliation.Sources, envProxySources(shellEnv, "shell environment", 0)...)
+
+	for _, rule := range agentProxyRules {
+		found := false
+		for _, name := range rule.processNames {
+			for _, proc := range p.getProcArgs(name) {
+				found = true
+				env := shellEnv
+				source := "shell environment"
+				if processEnv, err := p.getProcessEnv(proc.ProcID); err == nil {
+					env = processEnv.All
+					source = fmt.Sprintf("environment of process %d", proc.ProcID)
+					reconciliation.Sources = append(reconciliation.Sources, envProxySources(env, source, proc.ProcID)...)
+				} else {
+					log.Debug("Unable to read the environment of process", proc.ProcID, ":", err)
+				}
+				sysProps := procSysProps(proc.Args)
+				for _, key := range append(newRelicProxySysProps, javaProxySysProps...) {
+					if value, ok := sysProps[key]; ok {
+						if strings.Contains(key, "password") {
+							value = "_REDACTED_"
+						}
+						reconciliation.Sources = append(reconciliation.Sources, ProxySource{Agent: rule.agent, Source: fmt.Sprintf("arguments of process %d", proc.ProcID), Setting: key, Value: redactProxyValue(value), PID: proc.ProcID})
+					}
+				}
+				agent := resolveAgentProxy(rule, proc.ProcID, env, source, sysProps, configSources[rule.configKind], configIgnoresSystemProxy)
+				reconciliation.Agents = append(reconciliation.Agents, agent)
+			}
+		}
+		// an agent with a config file but no running process is reported with the shell environment
+		if !found && len(configSources[rule.configKind]) > 0 {
+			reconciliation.Agents = append(reconciliation.Agents, resolveAgentProxy(rule, 0, shellEnv, "shell environment", nil, configSources[rule.configKind], configIgnoresSystemProxy))
+		}
+	}
+
+	reconciliation.Hints = p.pacHints(shellEnv, reconciliation.Sources)
+	if len(reconciliation.Sources) == 0 && len(reconciliation.Hints) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No proxy setting found for the agents, their environment or the system",
+		}
+	}
+
+	target := collectorConnectTargets["us01"]
+	if regions, ok := upstream["Base/Config/RegionDetect"].Payload.([]string); ok && len(regions) > 0 && collectorConnectTargets[regions[0]] != "" {
+		target = collectorConnectTargets[regions[0]]
+	}
+	connected := map[string]bool{}
+	for _, agent := range reconciliation.Agents {
+		if agent.proxyURL == nil || connected[agent.Proxy] {
+			continue
+		}
+		connected[agent.Proxy] = true
+		reconciliation.Connects = append(reconciliation.Connects, p.connect(agent.proxyURL, target))
+	}
+
+	return summarizeReconciliation(reconciliation)
+}
+
+func summarizeReconciliation(reconciliation ProxyReconciliation) tasks.Result {
+	var failures, warnings, lines []string
+	direct, proxied := []string{}, []string{}
+	for _, agent := range reconciliation.Agents {
+		name := agentName(agent)
+		if agent.Proxy == "" {
+			direct = append(direct, name)
+			lines = append(lines, fmt.Sprintf("%s connects directly", name))
+		} else {
+			proxied = append(proxied, name)
+			lines = append(lines, fmt.Sprintf("%s uses the proxy %s from %s", name, agent.Proxy, agent.Source))
+		}
+		if len(agent.NoProxyEntries) > 0 {
+			message := fmt.Sprintf("%s: NO_PROXY excludes New Relic domains (%s)", name, strings.Join(agent.NoProxyEntries, ", "))
+			if agent.systemProxy {
+				failures = append(failures, message+", the agent connects directly instead of through "+agent.Proxy)
+			} else {
+				warnings = append(warnings, message+", clients of the host honoring NO_PROXY connect to New Relic directly")
+			}
+		}
+		for _, note := range agent.Notes {
+			warnings = append(warnings, name+": "+note)
+		}
+	}
+	if len(direct) > 0 && len(proxied) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s connect directly while %s use a proxy, if direct connections are blocked the agents without a proxy can't reach New Relic", strings.Join(direct, ", "), strings.Join(proxied, ", ")))
+	}
+	for _, connect := range reconciliation.Connects {
+		switch {
+		case connect.Error != "":
+			failures = append(failures, fmt.Sprintf("Unable to CONNECT to %s through %s: %s", connect.Target, connect.Proxy, connect.Error))
+		case connect.StatusCode == http.StatusProxyAuthRequired:
+			failures = append(failures, fmt.Sprintf("The proxy %s requires authentication to CONNECT to %s (407), check the proxy user and password", connect.Proxy, connect.Target))
+		case connect.StatusCode != http.StatusOK:
+			failures = append(failures, fmt.Sprintf("The proxy %s refused to CONNECT to %s with %d, allow New Relic domains on the proxy", connect.Proxy, connect.Target, connect.StatusCode))
+		default:
+			lines = append(lines, fmt.Sprintf("The proxy %s accepts CONNECT to %s", connect.Proxy, connect.Target))
+		}
+	}
+	warnings = append(warnings, reconciliation.Hints...)
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(append(failures, warnings...), lines...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/#proxies",
+			Payload: reconciliation,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(append(warnings, lines...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/#proxies",
+			Payload: reconciliation,
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No agent was found to reconcile the proxy settings of")
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(lines, "\n"),
+		Payload: reconciliation,
+	}
+}
+
+func agentName(agent AgentProxy) string {
+	if agent.PID == 0 {
+		return agent.Agent + " agent"
+	}
+	return fmt.Sprintf("%s agent (pid %d)", agent.Agent, agent.PID)
+}
+
+// configFileKind - the agent a config file belongs to, PHP and Python both use newrelic.ini
+func configFileKind(validation ValidateElement) string {
+	name := strings.ToLower(validation.Config.FileName)
+	switch {
+	case name == "newrelic-infra.yml":
+		return "newrelic-infra.yml"
+	case strings.HasSuffix(name, ".yml"):
+		return "newrelic.yml"
+	case strings.HasPrefix(name, "newrelic.") && (strings.HasSuffix(name, ".js") || strings.HasSuffix(name, ".cjs")):
+		return "newrelic.js"
+	case strings.HasSuffix(name, ".ini"):
+		for _, blob := range validation.ParsedResult.FindKey("newrelic.license") {
+			if blob.Value() != "" {
+				return "php"
+			}
+		}
+		if len(validation.ParsedResult.FindKey(phpProxyKeys.proxyHost)) > 0 {
+			return "php"
+		}
+		return "python"
+	case strings.HasSuffix(name, ".config"):
+		return "dotnet"
+	}
+	return ""
+}
+
+// configProxySources - the proxy settings of a config file, with the keys of the agent the file belongs to
+func configProxySources(validation ValidateElement, kind string, options tasks.Options) []ProxySource {
+	var proxyConfig ProxyConfig
+	switch kind {
+	case "newrelic.yml":
+		proxyConfig = findProxyValuesFromYmlFile(validation, options)
+	case "newrelic-infra.yml", "newrelic.js":
+		proxyConfig, _ = findProxyValuesFromConfigFile(infraOrNodeProxyKeys, validation)
+		if proxyConfig.proxyURL == "" && kind == "newrelic.js" {
+			proxyConfig, _ = findProxyValuesFromConfigFile(standardProxyKeys, validation)
+		}
+	case "python":
+		proxyConfig, _ = findProxyValuesFromConfigFile(standardProxyKeys, validation)
+	case "php":
+		proxyConfig, _ = findProxyValuesFromConfigFile(phpProxyKeys, validation)
+	case "dotnet":
+		proxyConfig, _ = findProxyValuesFromConfigFile(dotnetProxyKeys, validation)
+	}
+	if proxyConfig.proxyHost == "" && proxyConfig.proxyURL == "" {
+		return nil
+	}
+	proxy := setProxyURL(proxyConfig)
+	return []ProxySource{{
+		Agent:   configKindAgents[kind],
+		Source:  filepath.Join(validation.Config.FilePath, validation.Config.FileName),
+		Setting: "proxy",
+		Value:   redactProxyValue(proxy),
+		proxy:   proxy,
+	}}
+}
+
+// envProxySources - the proxy variables of an environment
+func envProxySources(env map[string]string, source string, pid int32) []ProxySource {
+	var sources []ProxySource
+	var names []string
+	for _, name := range append(append(append(append([]string{}, proxyEnvVarsKeys...), systemProxyVariables...), noProxyVariables...), pacVariables...) {
+		if _, ok := env[name]; ok && !tasks.ContainsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := env[name]
+		if strings.Contains(strings.ToLower(name), "pass") {
+			value = "_REDACTED_"
+		}
+		sources = append(sources, ProxySource{Source: source, Setting: name, Value: redactProxyValue(value), PID: pid})
+	}
+	return sources
+}
+
+// resolveAgentProxy - applies the precedence of the agent: system properties, environment, config file, then the system proxy
+func resolveAgentProxy(rule agentProxyRule, pid int32, env map[string]string, envSource string, sysProps map[string]string, configSources []ProxySource, ignoreSystemProxy bool) AgentProxy {
+	agent := AgentProxy{Agent: rule.agent, PID: pid}
+	setProxy := func(value string, source string) {
+		proxyURL, err := parseProxyURL(value)
+		if err != nil {
+			agent.Notes = append(agent.Notes, fmt.Sprintf("the proxy %s of %s is not a valid URL: %s", redactProxyValue(value), source, err))
+			return
+		}
+		agent.proxyURL = proxyURL
+		agent.Proxy = proxyURL.Redacted()
+		agent.Source = source
+	}
+
+	if rule.agent == "Java" && sysProps[newRelicProxySysProps[0]] != "" {
+		setProxy(setProxyURL(ProxyConfig{
+			proxyHost:     sysProps["-Dnewrelic.config.proxy_host"],
+			proxyPort:     sysProps["-Dnewrelic.config.proxy_port"],
+			proxyUser:     sysProps["-Dnewrelic.config.proxy_user"],
+			proxyPassword: sysProps["-Dnewrelic.config.proxy_password"],
+			proxyScheme:   sysProps["-Dnewrelic.config.proxy_scheme"],
+		}), "system properties")
+	}
+	if agent.proxyURL == nil && rule.envPrefix != "" {
+		if value, setting := envAgentProxy(rule.envPrefix, env); value != "" {
+			setProxy(value, setting+" in the "+envSource)
+		}
+	}
+	if agent.proxyURL == nil && len(configSources) > 0 {
+		setProxy(configSources[0].proxy, configSources[0].Source)
+	}
+	if agent.proxyURL == nil && len(rule.systemProxyVars) > 0 && !(rule.agent == "Infrastructure" && (ignoreSystemProxy || strings.EqualFold(env["NRIA_IGNORE_SYSTEM_PROXY"], "true"))) {
+		for _, name := range rule.systemProxyVars {
+			if env[name] != "" {
+				setProxy(env[name], name+" in the "+envSource)
+				agent.systemProxy = true
+				break
+			}
+		}
+	}
+
+	// the system proxy variables and the JVM proxy properties are not used by the agents without systemProxyVars
+	if agent.proxyURL == nil {
+		for _, name := range systemProxyVariables {
+			if env[name] != "" && !tasks.ContainsString(rule.systemProxyVars, name) {
+				agent.Notes = append(agent.Notes, fmt.Sprintf("%s is set in the %s but the %s agent doesn't use it, set the proxy in the agent config", name, envSource, rule.agent))
+				break
+			}
+		}
+		if rule.agent == "Java" && sysProps["-Dhttps.proxyHost"] != "" {
+			agent.Notes = append(agent.Notes, "-Dhttps.proxyHost is set but the Java agent doesn't use the JVM proxy properties, set proxy_host in newrelic.yml or -Dnewrelic.config.proxy_host")
+		}
+	}
+	if agent.proxyURL != nil {
+		for _, name := range noProxyVariables {
+			agent.NoProxyEntries = append(agent.NoProxyEntries, noProxyExclusions(env[name])...)
+		}
+		agent.NoProxyEntries = tasks.DedupeStringSlice(agent.NoProxyEntries)
+	}
+	return agent
+}
+
+// envAgentProxy - the NEW_RELIC_PROXY_* or NRIA_PROXY variables of the agent as a proxy URL
+func envAgentProxy(prefix string, env map[string]string) (string, string) {
+	if prefix == "NRIA_PROXY" {
+		return env["NRIA_PROXY"], "NRIA_PROXY"
+	}
+	if env[prefix+"URL"] != "" {
+		return env[prefix+"URL"], prefix + "URL"
+	}
+	if env[prefix+"HOST"] == "" {
+		return "", ""
+	}
+	password := env[prefix+"PASS"]
+	if password == "" {
+		password = env[prefix+"PASSWORD"]
+	}
+	return setProxyURL(ProxyConfig{
+		proxyHost:     env[prefix+"HOST"],
+		proxyPort:     env[prefix+"PORT"],
+		proxyUser:     env[prefix+"USER"],
+		proxyPassword: password,
+		proxyScheme:   env[prefix+"SCHEME"],
+	}), prefix + "HOST"
+}
+
+// noProxyExclusions - the entries of NO_PROXY matching the hosts of the collector
+func noProxyExclusions(noProxy string) []string {
+	var entries []string
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			entries = append(entries, entry)
+			continue
+		}
+		domain := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(entry), "*"), ".")
+		if host, _, err := net.SplitHostPort(domain); err == nil {
+			domain = host
+		}
+		for _, host := range newRelicProxyCheckHosts {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				entries = append(entries, entry)
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// pacHints - the agents don't support PAC files or WPAD, the proxy they return must be configured explicitly
+func (p BaseConfigProxyReconcile) pacHints(shellEnv map[string]string, sources []ProxySource) []string {
+	var hints []string
+	for _, name := range pacVariables {
+		if shellEnv[name] != "" {
+			hints = append(hints, fmt.Sprintf("%s is set to %s: the agents don't read PAC files, configure the proxy it returns for New Relic domains in the agent settings", name, shellEnv[name]))
+		}
+	}
+	for _, source := range sources {
+		if source.Setting == "-Djava.net.useSystemProxies" && strings.EqualFold(source.Value, "true") {
+			hints = append(hints, fmt.Sprintf("-Djava.net.useSystemProxies=true is set for process %d: the Java agent doesn't use the system proxies, set proxy_host in newrelic.yml", source.PID))
+		}
+	}
+	if addresses, err := p.lookupHost("wpad"); err == nil && len(addresses) > 0 {
+		hints = append(hints, fmt.Sprintf("The network publishes a WPAD host (wpad resolves to %s): browsers may use a proxy the agents don't know about, configure it in the agent settings if direct connections are blocked", strings.Join(addresses, ", ")))
+	}
+	return hints
+}
+
+// connect - sends a CONNECT for the target to the proxy, over TLS for https proxies
+func (p BaseConfigProxyReconcile) connect(proxyURL *url.URL, target string) ProxyConnect {
+	connect := ProxyConnect{Proxy: proxyURL.Redacted(), Target: target}
+	address := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		address = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	conn, err := p.dial("tcp", address, proxyConnectTimeout)
+	if err != nil {
+		connect.Error = err.Error()
+		return connect
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(proxyConnectTimeout))
+	if proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+	}
+
+	request := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", target, target)
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		request += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	if _, err := conn.Write([]byte(request + "\r\n")); err != nil {
+		connect.Error = err.Error()
+		return connect
+	}
+	response, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	if err != nil {
+		connect.Error = err.Error()
+		return connect
+	}
+	response.Body.Close()
+	connect.StatusCode = response.StatusCode
+	return connect
+}
+
+func parseProxyURL(value string) (*url.URL, error) {
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Hostname() == "" {
+		return nil, fmt.Errorf("no host in %s", proxyURL.Redacted())
+	}
+	return proxyURL, nil
+}
+
+// redactProxyValue - redacts the password of a proxy URL, other values are returned as is
+func redactProxyValue(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	if proxyURL, err := parseProxyURL(value); err == nil {
+		return proxyURL.Redacted()
+	}
+	return "_REDACTED_"
+}
+
+// procSysProps - the -Dkey=value arguments of a process
+func procSysProps(args []string) map[string]string {
+	sysProps := map[string]string{}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-D") {
+			continue
+		}
+		key, value, _ := strings.Cut(arg, "=")
+		sysProps[key] = value
+	}
+	return sysProps
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// newStandInProxy - a local proxy answering CONNECT with 200 for the credentials user:secret, 407 otherwise
+func newStandInProxy(t *testing.T) (string, *[]string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	var targets []string
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			request, err := http.ReadRequest(bufio.NewReader(conn))
+			if err == nil {
+				targets = append(targets, request.Method+" "+request.Host)
+				if request.Header.Get("Proxy-Authorization") == "Basic dXNlcjpzZWNyZXQ=" {
+					conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+				} else {
+					conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n"))
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String(), &targets
+}
+
+func newProxyReconcile(procs map[string][]tasks.JavaProcArgs, procEnv map[int32]map[string]string) BaseConfigProxyReconcile {
+	return BaseConfigProxyReconcile{
+		getProcArgs: func(name string) []tasks.JavaProcArgs { return procs[name] },
+		getProcessEnv: func(pid int32) (tasks.EnvironmentVariables, error) {
+			if env, ok := procEnv[pid]; ok {
+				return tasks.EnvironmentVariables{All: env, PID: pid, Scope: tasks.Process}, nil
+			}
+			return tasks.EnvironmentVariables{}, errors.New("permission denied")
+		},
+		lookupHost: func(string) ([]string, error) { return nil, errors.New("no such host") },
+		dial:       net.DialTimeout,
+	}
+}
+
+func TestBaseConfigProxyReconcile_Execute(t *testing.T) {
+	proxy, targets := newStandInProxy(t)
+	proxyHost, proxyPort, _ := net.SplitHostPort(proxy)
+
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedProxy := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name      string
+		procs     map[string][]tasks.JavaProcArgs
+		procEnv   map[int32]map[string]string
+		upstream  map[string]tasks.Result
+		lookup    []string
+		status    tasks.Status
+		summaries []string
+	}{
+		{
+			name:   "should return None without proxy settings",
+			status: tasks.None,
+		},
+		{
+			name: "should use the proxy of the system properties of the Java agent",
+			procs: map[string][]tasks.JavaProcArgs{"java": {{ProcID: 10, Args: []string{
+				"java", "-Dnewrelic.config.proxy_host=" + proxyHost, "-Dnewrelic.config.proxy_port=" + proxyPort,
+				"-Dnewrelic.config.proxy_user=user", "-Dnewrelic.config.proxy_password=secret", "-jar", "app.jar",
+			}}}},
+			procEnv:   map[int32]map[string]string{10: {}},
+			status:    tasks.Success,
+			summaries: []string{"Java agent (pid 10) uses the proxy http://user:xxxxx@" + proxy + " from system properties", "accepts CONNECT to collector.newrelic.com:443"},
+		},
+		{
+			name:    "should report the system proxy the Java agent ignores",
+			procs:   map[string][]tasks.JavaProcArgs{"java": {{ProcID: 10, Args: []string{"java", "-Dhttps.proxyHost=" + proxyHost, "-jar", "app.jar"}}}},
+			procEnv: map[int32]map[string]string{10: {"HTTPS_PROXY": "http://" + proxy}},
+			status:  tasks.Warning,
+			summaries: []string{
+				"HTTPS_PROXY is set in the environment of process 10 but the Java agent doesn't use it",
+				"-Dhttps.proxyHost is set but the Java agent doesn't use the JVM proxy properties",
+				"Java agent (pid 10) connects directly",
+			},
+		},
+		{
+			name:      "should fail when NO_PROXY excludes New Relic for the system proxy of the infrastructure agent",
+			procs:     map[string][]tasks.JavaProcArgs{"newrelic-infra": {{ProcID: 20}}},
+			procEnv:   map[int32]map[string]string{20: {"HTTPS_PROXY": "http://user:secret@" + proxy, "NO_PROXY": "localhost,.newrelic.com"}},
+			status:    tasks.Failure,
+			summaries: []string{"Infrastructure agent (pid 20): NO_PROXY excludes New Relic domains (.newrelic.com)", "from HTTPS_PROXY in the environment of process 20"},
+		},
+		{
+			name:      "should not use the system proxy when the infrastructure agent ignores it",
+			procs:     map[string][]tasks.JavaProcArgs{"newrelic-infra": {{ProcID: 20}}},
+			procEnv:   map[int32]map[string]string{20: {"HTTPS_PROXY": "http://" + proxy, "NRIA_IGNORE_SYSTEM_PROXY": "true"}},
+			status:    tasks.Success,
+			summaries: []string{"Infrastructure agent (pid 20) connects directly"},
+		},
+		{
+			name: "should fail when the proxy of the config file rejects the credentials",
+			upstream: map[string]tasks.Result{
+				"Base/Config/Validate": {Payload: []ValidateElement{{
+					Config:       ConfigElement{FileName: "newrelic.js", FilePath: "/app/"},
+					ParsedResult: tasks.ValidateBlob{Children: []tasks.ValidateBlob{{Key: "proxy", RawValue: "http://user:wrong@" + proxy}}},
+				}}},
+				"Base/Config/RegionDetect": {Payload: []string{"eu01"}},
+			},
+			status:    tasks.Failure,
+			summaries: []string{"Node agent uses the proxy http://user:xxxxx@" + proxy + " from /app/newrelic.js", "requires authentication to CONNECT to collector.eu01.nr-data.net:443 (407)"},
+		},
+		{
+			name:    "should fail when the proxy is not reachable and report the agents connecting directly",
+			procs:   map[string][]tasks.JavaProcArgs{"node": {{ProcID: 30}}, "python3": {{ProcID: 31}}},
+			procEnv: map[int32]map[string]string{30: {"NEW_RELIC_PROXY_URL": "http://" + closedProxy}, 31: {}},
+			status:  tasks.Failure,
+			summaries: []string{
+				"Unable to CONNECT to collector.newrelic.com:443 through http://" + closedProxy,
+				"Python agent (pid 31) connect directly while Node agent (pid 30) use a proxy",
+			},
+		},
+		{
+			name:      "should report PAC and WPAD hints",
+			upstream:  map[string]tasks.Result{"Base/Env/CollectEnvVars": {Payload: map[string]string{"PAC_URL": "http://wpad/proxy.pac"}}},
+			lookup:    []string{"10.0.0.5"},
+			status:    tasks.Warning,
+			summaries: []string{"PAC_URL is set to http://wpad/proxy.pac", "wpad resolves to 10.0.0.5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newProxyReconcile(tt.procs, tt.procEnv)
+			if tt.lookup != nil {
+				p.lookupHost = func(string) ([]string, error) { return tt.lookup, nil }
+			}
+			upstream := tt.upstream
+			if upstream == nil {
+				upstream = map[string]tasks.Result{}
+			}
+			result := p.Execute(tasks.Options{Options: map[string]string{}}, upstream)
+			if result.Status != tt.status {
+				t.Errorf("Execute() status = %v, want %v: %s", result.Status, tt.status, result.Summary)
+			}
+			for _, summary := range tt.summaries {
+				if !strings.Contains(result.Summary, summary) {
+					t.Errorf("Execute() summary = %q, want it to contain %q", result.Summary, summary)
+				}
+			}
+			if strings.Contains(result.Summary, "secret") || strings.Contains(fmt.Sprint(result.Payload), "secret") {
+				t.Errorf("Execute() = %q, the proxy password was not redacted", result.Summary)
+			}
+		})
+	}
+	if len(*targets) == 0 || (*targets)[0] != "CONNECT collector.newrelic.com:443" {
+		t.Errorf("stand-in proxy received %v, want CONNECT requests to the collector", *targets)
+	}
+}
+
+func Test_noProxyExclusions(t *testing.T) {
+	tests := []struct {
+		noProxy string
+		want    []string
+	}{
+		{noProxy: "", want: nil},
+		{noProxy: "localhost,127.0.0.1,.internal", want: nil},
+		{noProxy: "*", want: []string{"*"}},
+		{noProxy: "newrelic.com", want: []string{"newrelic.com"}},
+		{noProxy: "*.nr-data.net, example.com", want: []string{"*.nr-data.net"}},
+		{noProxy: "collector.newrelic.com:443", want: []string{"collector.newrelic.com:443"}},
+		{noProxy: "notnewrelic.com", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.noProxy, func(t *testing.T) {
+			got := noProxyExclusions(tt.noProxy)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("noProxyExclusions(%q) = %v, want %v", tt.noProxy, got, tt.want)
+			}
+		})
+	}
+}
@@ -70,6 +70,11 @@ func detectRegions(licenseKeyToSources map[string][]string) []string {
 	return detectedRegions
 }
 
+// RegionFromLicenseKey - returns the region (us01, eu01, ...) a license key belongs to, defaults to us01 for legacy keys
+func RegionFromLicenseKey(licenseKey string) string {
+	return parseRegion(licenseKey)
+}
+
 func parseRegion(licenseKey string) string {
 	parsedRegion := defaultRegion
 
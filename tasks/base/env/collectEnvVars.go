@@ -39,6 +39,7 @@ func (t BaseEnvCollectEnvVars) Execute(options tasks.Options, upstream map[strin
 	filteredEnvVars := envVars.WithDefaultFilter()
 
 	result.Payload = filteredEnvVars
+	result.Redacted = len(filteredEnvVars) < len(envVars.All)
 	result.Status = tasks.Info
 	result.Summary = "Gathered Environment variables of current shell."
 
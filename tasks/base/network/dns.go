@@ -0,0 +1,102 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// BaseNetworkDNS - resolves the hosts of the New Relic endpoints
+type BaseNetworkDNS struct {
+	lookupIP func(host string) ([]net.IP, error)
+}
+
+// DNSResolution - the addresses a host resolves to
+type DNSResolution struct {
+	Host     string
+	IPv4     []string
+	IPv6     []string
+	Duration time.Duration
+	Error    string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseNetworkDNS) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Network/DNS")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseNetworkDNS) Explain() string {
+	return "Resolve the hosts of the New Relic endpoints"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseNetworkDNS) Dependencies() []string {
+	return []string{"Base/Network/Endpoints"}
+}
+
+// Execute - The core work within each task
+func (p BaseNetworkDNS) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	endpoints, ok := upstreamEndpoints(upstream)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic endpoint to resolve",
+		}
+	}
+
+	resolutions := map[string]DNSResolution{}
+	var hosts, failures, lines []string
+	for _, endpoint := range endpoints {
+		if _, done := resolutions[endpoint.Host]; done {
+			continue
+		}
+		hosts = append(hosts, endpoint.Host)
+		resolution := p.resolve(endpoint.Host)
+		resolutions[endpoint.Host] = resolution
+		if resolution.Error != "" {
+			failures = append(failures, fmt.Sprintf("Unable to resolve %s: %s", endpoint.Host, resolution.Error))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s resolves to %s in %s", endpoint.Host, strings.Join(append(resolution.IPv4, resolution.IPv6...), ", "), resolution.Duration.Round(time.Millisecond)))
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(failures, lines...), "\n") + "\nCheck the DNS servers of the host (/etc/resolv.conf) and that they resolve public names, or the agents must connect through a proxy.",
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks",
+			Payload: resolutions,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("Resolved %d New Relic host(s):\n%s", len(hosts), strings.Join(lines, "\n")),
+		Payload: resolutions,
+	}
+}
+
+func (p BaseNetworkDNS) resolve(host string) DNSResolution {
+	resolution := DNSResolution{Host: host}
+	start := time.Now()
+	ips, err := p.lookupIP(host)
+	resolution.Duration = time.Since(start)
+	if err != nil {
+		resolution.Error = err.Error()
+		return resolution
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			resolution.IPv4 = append(resolution.IPv4, ip.String())
+		} else {
+			resolution.IPv6 = append(resolution.IPv6, ip.String())
+		}
+	}
+	if len(ips) == 0 {
+		resolution.Error = "no address returned"
+	}
+	return resolution
+}
@@ -0,0 +1,215 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// BaseNetworkEndpoints - selects the New Relic endpoints checked by the other Base/Network tasks
+type BaseNetworkEndpoints struct {
+}
+
+// Endpoint - the endpoint of a New Relic product in a region
+type Endpoint struct {
+	Product string
+	Region  string
+	Host    string
+	Port    int
+	// Path is requested by Base/Network/HTTP, any HTTP response means the endpoint is reachable
+	Path string
+}
+
+// Address - the host:port of the endpoint
+func (e Endpoint) Address() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// URL - the URL requested by Base/Network/HTTP, the port is left out when it is the https default
+func (e Endpoint) URL() string {
+	if e.Port == 443 {
+		return "https://" + e.Host + e.Path
+	}
+	return "https://" + e.Address() + e.Path
+}
+
+// String - the product, region and address of the endpoint used in the summaries
+func (e Endpoint) String() string {
+	if e.Region == "" {
+		return fmt.Sprintf("%s (%s)", e.Address(), e.Product)
+	}
+	return fmt.Sprintf("%s (%s, %s)", e.Address(), e.Product, e.Region)
+}
+
+// EndpointCatalog - the endpoints of every New Relic product by region, see https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks
+var EndpointCatalog = map[string][]Endpoint{
+	"us01": {
+		{Product: "collector", Host: "collector.newrelic.com", Port: 443, Path: "/status/mongrel"},
+		{Product: "infra-api", Host: "infra-api.newrelic.com", Port: 443, Path: "/"},
+		{Product: "identity-api", Host: "identity-api.newrelic.com", Port: 443, Path: "/"},
+		{Product: "infrastructure-command-api", Host: "infrastructure-command-api.newrelic.com", Port: 443, Path: "/"},
+		{Product: "log-api", Host: "log-api.newrelic.com", Port: 443, Path: "/log/v1"},
+		{Product: "metric-api", Host: "metric-api.newrelic.com", Port: 443, Path: "/metric/v1"},
+		{Product: "trace-api", Host: "trace-api.newrelic.com", Port: 443, Path: "/trace/v1"},
+		{Product: "otlp", Host: "otlp.nr-data.net", Port: 443, Path: "/v1/traces"},
+		{Product: "bam", Host: "bam.nr-data.net", Port: 443, Path: "/"},
+		{Product: "synthetics", Host: "synthetics-horde.nr-data.net", Port: 443, Path: "/"},
+	},
+	"eu01": {
+		{Product: "collector", Host: "collector.eu01.nr-data.net", Port: 443, Path: "/status/mongrel"},
+		{Product: "infra-api", Host: "infra-api.eu.newrelic.com", Port: 443, Path: "/"},
+		{Product: "identity-api", Host: "identity-api.eu.newrelic.com", Port: 443, Path: "/"},
+		{Product: "infrastructure-command-api", Host: "infrastructure-command-api.eu.newrelic.com", Port: 443, Path: "/"},
+		{Product: "log-api", Host: "log-api.eu.newrelic.com", Port: 443, Path: "/log/v1"},
+		{Product: "metric-api", Host: "metric-api.eu.newrelic.com", Port: 443, Path: "/metric/v1"},
+		{Product: "trace-api", Host: "trace-api.eu.newrelic.com", Port: 443, Path: "/trace/v1"},
+		{Product: "otlp", Host: "otlp.eu01.nr-data.net", Port: 443, Path: "/v1/traces"},
+		{Product: "bam", Host: "bam.eu01.nr-data.net", Port: 443, Path: "/"},
+		{Product: "synthetics", Host: "synthetics-horde.eu01.nr-data.net", Port: 443, Path: "/"},
+	},
+}
+
+// CatalogRegions - the regions of the catalog, sorted
+func CatalogRegions() []string {
+	regions := make([]string, 0, len(EndpointCatalog))
+	for region := range EndpointCatalog {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// CatalogEndpoint - the endpoint of a product in a region of the catalog
+func CatalogEndpoint(region, product string) (Endpoint, bool) {
+	for _, endpoint := range EndpointCatalog[region] {
+		if endpoint.Product == product {
+			endpoint.Region = region
+			return endpoint, true
+		}
+	}
+	return Endpoint{}, false
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseNetworkEndpoints) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Network/Endpoints")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseNetworkEndpoints) Explain() string {
+	explain := "List the New Relic endpoints of the detected regions to check the network path to"
+	if config.Flags.ShowOverrideHelp {
+		explain += fmt.Sprintf("\n%37s %s", " ", "Override: regions => comma separated regions to check instead of the detected ones (e.g. us01,eu01)")
+		explain += fmt.Sprintf("\n%37s %s", " ", "Override: products => comma separated products to check (collector, infra-api, identity-api, infrastructure-command-api, log-api, metric-api, trace-api, otlp, bam, synthetics)")
+		explain += fmt.Sprintf("\n%37s %s", " ", "Override: endpoints => comma separated host:port to check instead of the catalog (e.g. collector.newrelic.com:443)")
+	}
+	return explain
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseNetworkEndpoints) Dependencies() []string {
+	return []string{"Base/Config/RegionDetect"}
+}
+
+// Execute - The core work within each task
+func (p BaseNetworkEndpoints) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if options.Options["endpoints"] != "" {
+		endpoints, err := parseEndpoints(options.Options["endpoints"])
+		if err != nil {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: "Unable to parse the endpoints override: " + err.Error(),
+			}
+		}
+		return endpointsResult(endpoints)
+	}
+
+	regions := splitOption(options.Options["regions"])
+	if len(regions) == 0 {
+		regions, _ = upstream["Base/Config/RegionDetect"].Payload.([]string)
+	}
+	// without a license key every region is checked
+	if len(regions) == 0 {
+		regions = CatalogRegions()
+	}
+	sort.Strings(regions)
+
+	products := splitOption(options.Options["products"])
+	var endpoints []Endpoint
+	var unknown []string
+	for _, region := range regions {
+		catalog, found := EndpointCatalog[region]
+		if !found {
+			unknown = append(unknown, region)
+			continue
+		}
+		for _, endpoint := range catalog {
+			if len(products) > 0 && !tasks.ContainsString(products, endpoint.Product) {
+				continue
+			}
+			endpoint.Region = region
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: fmt.Sprintf("No New Relic endpoint matches the regions %s and the products %s", strings.Join(regions, ", "), strings.Join(products, ", ")),
+		}
+	}
+	result := endpointsResult(endpoints)
+	if len(unknown) > 0 {
+		result.Summary += "\nUnknown regions skipped: " + strings.Join(unknown, ", ")
+	}
+	return result
+}
+
+func endpointsResult(endpoints []Endpoint) tasks.Result {
+	var lines []string
+	for _, endpoint := range endpoints {
+		lines = append(lines, endpoint.String())
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: fmt.Sprintf("%d New Relic endpoint(s) to check:\n%s", len(endpoints), strings.Join(lines, "\n")),
+		Payload: endpoints,
+	}
+}
+
+// parseEndpoints - the endpoints of the override are checked with the / path
+func parseEndpoints(value string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	for _, address := range splitOption(value) {
+		host, portString, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.Atoi(portString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in %s", address)
+		}
+		endpoints = append(endpoints, Endpoint{Product: "custom", Host: host, Port: port, Path: "/"})
+	}
+	return endpoints, nil
+}
+
+func splitOption(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// upstreamEndpoints - the endpoints selected by Base/Network/Endpoints
+func upstreamEndpoints(upstream map[string]tasks.Result) ([]Endpoint, bool) {
+	endpoints, ok := upstream["Base/Network/Endpoints"].Payload.([]Endpoint)
+	return endpoints, ok && len(endpoints) > 0
+}
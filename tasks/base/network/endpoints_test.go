@@ -0,0 +1,94 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func TestBaseNetworkEndpoints_Execute(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   map[string]string
+		upstream  map[string]tasks.Result
+		status    tasks.Status
+		addresses []string
+	}{
+		{
+			name:     "should select the endpoints of the detected region",
+			upstream: map[string]tasks.Result{"Base/Config/RegionDetect": {Status: tasks.Info, Payload: []string{"eu01"}}},
+			status:   tasks.Info,
+			addresses: []string{
+				"collector.eu01.nr-data.net:443", "infra-api.eu.newrelic.com:443", "identity-api.eu.newrelic.com:443", "infrastructure-command-api.eu.newrelic.com:443",
+				"log-api.eu.newrelic.com:443", "metric-api.eu.newrelic.com:443", "trace-api.eu.newrelic.com:443", "otlp.eu01.nr-data.net:443", "bam.eu01.nr-data.net:443", "synthetics-horde.eu01.nr-data.net:443",
+			},
+		},
+		{
+			name:      "should select the products of every region when no region is detected",
+			options:   map[string]string{"products": "collector,otlp"},
+			upstream:  map[string]tasks.Result{"Base/Config/RegionDetect": {Status: tasks.None}},
+			status:    tasks.Info,
+			addresses: []string{"otlp.eu01.nr-data.net:443", "collector.eu01.nr-data.net:443", "collector.newrelic.com:443", "otlp.nr-data.net:443"},
+		},
+		{
+			name:      "should use the endpoints override",
+			options:   map[string]string{"endpoints": "127.0.0.1:8443, [::1]:443"},
+			status:    tasks.Info,
+			addresses: []string{"127.0.0.1:8443", "[::1]:443"},
+		},
+		{
+			name:    "should return an error for an invalid endpoints override",
+			options: map[string]string{"endpoints": "collector.newrelic.com"},
+			status:  tasks.Error,
+		},
+		{
+			name:    "should return an error for an unknown region",
+			options: map[string]string{"regions": "ap01"},
+			status:  tasks.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := BaseNetworkEndpoints{}.Execute(tasks.Options{Options: tt.options}, tt.upstream)
+			if result.Status != tt.status {
+				t.Fatalf("Execute() status = %v, want %v: %s", result.Status, tt.status, result.Summary)
+			}
+			if tt.addresses == nil {
+				return
+			}
+			endpoints := result.Payload.([]Endpoint)
+			var addresses []string
+			for _, endpoint := range endpoints {
+				addresses = append(addresses, endpoint.Address())
+			}
+			if !sameElements(addresses, tt.addresses) {
+				t.Errorf("Execute() addresses = %v, want %v", addresses, tt.addresses)
+			}
+		})
+	}
+}
+
+func TestCatalogEndpoint(t *testing.T) {
+	endpoint, found := CatalogEndpoint("eu01", "collector")
+	if !found || endpoint.Region != "eu01" || endpoint.URL() != "https://collector.eu01.nr-data.net/status/mongrel" {
+		t.Errorf("CatalogEndpoint(eu01, collector) = %+v, %v", endpoint, found)
+	}
+	if _, found := CatalogEndpoint("ap01", "collector"); found {
+		t.Error("CatalogEndpoint(ap01, collector) found an endpoint for an unknown region")
+	}
+	if custom := (Endpoint{Host: "127.0.0.1", Port: 8443, Path: "/"}); custom.URL() != "https://127.0.0.1:8443/" {
+		t.Errorf("URL() = %s, want the port of a custom endpoint", custom.URL())
+	}
+}
+
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		if !tasks.ContainsString(b, v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// BaseNetworkHTTP - checks that the New Relic endpoints answer HTTP requests through the detected proxy
+type BaseNetworkHTTP struct {
+	httpGetter tasks.HTTPRequestFunc
+}
+
+// HTTPReachability - the response of an endpoint to a request
+type HTTPReachability struct {
+	Endpoint   Endpoint
+	URL        string
+	Proxy      string
+	StatusCode int
+	Duration   time.Duration
+	Error      string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseNetworkHTTP) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Network/HTTP")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseNetworkHTTP) Explain() string {
+	return "Check that the New Relic endpoints answer HTTP requests through the detected proxy"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseNetworkHTTP) Dependencies() []string {
+	return []string{
+		"Base/Network/Endpoints",
+		"Base/Config/ProxyDetect", //we are not using the payload of this task, but it sets the proxy used by the requests
+	}
+}
+
+// Execute - The core work within each task
+func (p BaseNetworkHTTP) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	endpoints, ok := upstreamEndpoints(upstream)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic endpoint to request",
+		}
+	}
+
+	var reachabilities []HTTPReachability
+	var failures, lines []string
+	for _, endpoint := range endpoints {
+		reachability := p.request(endpoint)
+		reachabilities = append(reachabilities, reachability)
+		via := ""
+		if reachability.Proxy != "" {
+			via = " through " + reachability.Proxy
+		}
+		if reachability.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: unable to request %s%s: %s", endpoint, reachability.URL, via, reachability.Error))
+			continue
+		}
+		// any status code comes from the endpoint or the proxy, a 407 means the proxy refused the credentials
+		if reachability.StatusCode == http.StatusProxyAuthRequired {
+			failures = append(failures, fmt.Sprintf("%s: the proxy%s requires authentication (407)", endpoint, via))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: answered %d%s in %s", endpoint, reachability.StatusCode, via, reachability.Duration.Round(time.Millisecond)))
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(failures, lines...), "\n") + "\nPlease check network and proxy settings and try again or see -help for more options.",
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks",
+			Payload: reachabilities,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(lines, "\n"),
+		Payload: reachabilities,
+	}
+}
+
+func (p BaseNetworkHTTP) request(endpoint Endpoint) HTTPReachability {
+	reachability := HTTPReachability{Endpoint: endpoint, URL: endpoint.URL()}
+	request, err := http.NewRequest("GET", reachability.URL, nil)
+	if err == nil {
+		if proxyURL, err := http.ProxyFromEnvironment(request); err == nil && proxyURL != nil {
+			reachability.Proxy = proxyURL.Redacted()
+		}
+	}
+
+	start := time.Now()
+	resp, err := p.httpGetter(httpHelper.RequestWrapper{
+		Method:         "GET",
+		URL:            reachability.URL,
+		TimeoutSeconds: 30,
+	})
+	reachability.Duration = time.Since(start)
+	if err != nil {
+		reachability.Error = err.Error()
+		return reachability
+	}
+	defer resp.Body.Close()
+	reachability.StatusCode = resp.StatusCode
+	return reachability
+}
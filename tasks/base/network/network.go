@@ -0,0 +1,31 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Base/Network/*")
+
+	registrationFunc(BaseNetworkEndpoints{}, false)
+	registrationFunc(BaseNetworkDNS{lookupIP: net.LookupIP}, false)
+	registrationFunc(BaseNetworkTCP{dial: net.DialTimeout}, false)
+	registrationFunc(BaseNetworkTLS{dial: net.DialTimeout, tlsClient: tls.Client}, false)
+	registrationFunc(BaseNetworkHTTP{httpGetter: httpHelper.MakeHTTPRequest}, false)
+	registrationFunc(BaseNetworkTLSInspect{
+		getProcArgs: tasks.GetProcArgs,
+		fileReader:  os.ReadFile,
+		readDir:     os.ReadDir,
+		systemRoots: x509.SystemCertPool,
+		now:         time.Now,
+	}, false)
+}
@@ -0,0 +1,172 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// newStandIn - a local TLS server standing in for a New Relic endpoint
+func newStandIn(t *testing.T) (*httptest.Server, Endpoint) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	return server, Endpoint{Product: "collector", Region: "us01", Host: serverURL.Hostname(), Port: port, Path: "/status/mongrel"}
+}
+
+// runProbes - runs the Base/Network tasks in order against the endpoints
+func runProbes(t *testing.T, endpoints []Endpoint, roots *x509.CertPool, client *http.Client) map[string]tasks.Result {
+	upstream := map[string]tasks.Result{
+		"Base/Network/Endpoints": {Status: tasks.Info, Payload: endpoints},
+	}
+	upstream["Base/Network/DNS"] = BaseNetworkDNS{lookupIP: net.LookupIP}.Execute(tasks.Options{}, upstream)
+	upstream["Base/Network/TCP"] = BaseNetworkTCP{dial: net.DialTimeout}.Execute(tasks.Options{}, upstream)
+	upstream["Base/Network/TLS"] = BaseNetworkTLS{dial: net.DialTimeout, tlsClient: tls.Client, rootCAs: roots}.Execute(tasks.Options{}, upstream)
+	httpGetter := func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+		return client.Get(wrapper.URL)
+	}
+	upstream["Base/Network/HTTP"] = BaseNetworkHTTP{httpGetter: httpGetter}.Execute(tasks.Options{}, upstream)
+	return upstream
+}
+
+func TestBaseNetwork_standIn(t *testing.T) {
+	server, endpoint := newStandIn(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	results := runProbes(t, []Endpoint{endpoint}, roots, server.Client())
+	for _, identifier := range []string{"Base/Network/DNS", "Base/Network/TCP", "Base/Network/TLS", "Base/Network/HTTP"} {
+		if results[identifier].Status != tasks.Success {
+			t.Errorf("%s status = %v, want Success: %s", identifier, results[identifier].Status, results[identifier].Summary)
+		}
+	}
+
+	handshakes := results["Base/Network/TLS"].Payload.([]TLSHandshake)
+	if len(handshakes) != 1 {
+		t.Fatalf("TLS handshakes = %d, want 1", len(handshakes))
+	}
+	handshake := handshakes[0]
+	if handshake.Version != "TLS 1.3" || handshake.CipherSuite == "" || !handshake.Verified {
+		t.Errorf("TLS handshake = %+v, want a verified TLS 1.3 handshake", handshake)
+	}
+	if handshake.ServerName != "" {
+		t.Errorf("TLS ServerName = %q, want no SNI for an IP address", handshake.ServerName)
+	}
+	if len(handshake.Chain) == 0 || len(handshake.Certificates) != len(handshake.Chain) {
+		t.Errorf("TLS chain = %v, want the presented certificates", handshake.Chain)
+	}
+
+	reachabilities := results["Base/Network/HTTP"].Payload.([]HTTPReachability)
+	if reachabilities[0].StatusCode != http.StatusNotFound {
+		t.Errorf("HTTP status code = %d, want 404", reachabilities[0].StatusCode)
+	}
+}
+
+func TestBaseNetwork_untrustedChain(t *testing.T) {
+	server, endpoint := newStandIn(t)
+
+	// the system roots don't trust the certificate of the stand-in, like a TLS-intercepting proxy
+	results := runProbes(t, []Endpoint{endpoint}, x509.NewCertPool(), server.Client())
+	result := results["Base/Network/TLS"]
+	if result.Status != tasks.Failure {
+		t.Fatalf("TLS status = %v, want Failure: %s", result.Status, result.Summary)
+	}
+	if !strings.Contains(result.Summary, "is not trusted") {
+		t.Errorf("TLS summary = %q, want the certificate reported as not trusted", result.Summary)
+	}
+	if handshake := result.Payload.([]TLSHandshake)[0]; handshake.Verified || len(handshake.Chain) == 0 {
+		t.Errorf("TLS handshake = %+v, want the unverified chain recorded", handshake)
+	}
+}
+
+func TestBaseNetwork_closedPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	endpoint := Endpoint{Product: "log-api", Region: "us01", Host: "127.0.0.1", Port: port, Path: "/log/v1"}
+
+	results := runProbes(t, []Endpoint{endpoint}, nil, http.DefaultClient)
+	if results["Base/Network/TCP"].Status != tasks.Failure {
+		t.Errorf("TCP status = %v, want Failure: %s", results["Base/Network/TCP"].Status, results["Base/Network/TCP"].Summary)
+	}
+	if results["Base/Network/TLS"].Status != tasks.Warning {
+		t.Errorf("TLS status = %v, want Warning: %s", results["Base/Network/TLS"].Status, results["Base/Network/TLS"].Summary)
+	}
+	if results["Base/Network/HTTP"].Status != tasks.Failure {
+		t.Errorf("HTTP status = %v, want Failure: %s", results["Base/Network/HTTP"].Status, results["Base/Network/HTTP"].Summary)
+	}
+}
+
+func TestBaseNetworkTCP_proxyConfigured(t *testing.T) {
+	upstream := map[string]tasks.Result{
+		"Base/Network/Endpoints":  {Payload: []Endpoint{{Product: "collector", Host: "collector.newrelic.com", Port: 443}}},
+		"Base/Network/DNS":        {Payload: map[string]DNSResolution{"collector.newrelic.com": {Host: "collector.newrelic.com", IPv4: []string{"162.247.241.2"}}}},
+		"Base/Config/ProxyDetect": {Status: tasks.Success},
+	}
+	p := BaseNetworkTCP{dial: func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}}
+	result := p.Execute(tasks.Options{}, upstream)
+	if result.Status != tasks.Warning || !strings.Contains(result.Summary, "A proxy is configured") {
+		t.Errorf("Execute() = %v %q, want a Warning about the proxy", result.Status, result.Summary)
+	}
+}
+
+func TestBaseNetworkTCP_ipv6Fallback(t *testing.T) {
+	upstream := map[string]tasks.Result{
+		"Base/Network/Endpoints": {Payload: []Endpoint{{Product: "collector", Host: "collector.newrelic.com", Port: 443}}},
+		"Base/Network/DNS": {Payload: map[string]DNSResolution{"collector.newrelic.com": {
+			Host: "collector.newrelic.com",
+			IPv4: []string{"162.247.241.2"},
+			IPv6: []string{"2a04:4e42::2"},
+		}}},
+	}
+	p := BaseNetworkTCP{dial: func(network, address string, timeout time.Duration) (net.Conn, error) {
+		if network == "tcp6" {
+			return nil, errors.New("connect: network is unreachable")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}}
+	result := p.Execute(tasks.Options{}, upstream)
+	if result.Status != tasks.Warning || !strings.Contains(result.Summary, "not over IPv6") {
+		t.Errorf("Execute() = %v %q, want a Warning about the IPv6 fallback", result.Status, result.Summary)
+	}
+}
+
+func TestBaseNetworkDNS_unresolved(t *testing.T) {
+	upstream := map[string]tasks.Result{
+		"Base/Network/Endpoints": {Payload: []Endpoint{
+			{Product: "collector", Host: "collector.newrelic.com", Port: 443},
+			{Product: "collector", Host: "collector.newrelic.com", Port: 443},
+		}},
+	}
+	lookups := 0
+	p := BaseNetworkDNS{lookupIP: func(host string) ([]net.IP, error) {
+		lookups++
+		return nil, errors.New("no such host")
+	}}
+	result := p.Execute(tasks.Options{}, upstream)
+	if result.Status != tasks.Failure || lookups != 1 {
+		t.Errorf("Execute() = %v after %d lookups, want a Failure after 1 lookup", result.Status, lookups)
+	}
+}
@@ -0,0 +1,159 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// BaseNetworkTCP - times the TCP connection to the New Relic endpoints over IPv4 and IPv6
+type BaseNetworkTCP struct {
+	dial func(network, address string, timeout time.Duration) (net.Conn, error)
+}
+
+// TCPConnection - the TCP connections to an endpoint, one per address family it resolves to
+type TCPConnection struct {
+	Endpoint Endpoint
+	IPv4     *TCPAttempt
+	IPv6     *TCPAttempt
+}
+
+// TCPAttempt - a TCP connection to one address of an endpoint
+type TCPAttempt struct {
+	Address  string
+	Duration time.Duration
+	Error    string
+}
+
+// Connected - at least one address family reached the endpoint
+func (c TCPConnection) Connected() bool {
+	return (c.IPv4 != nil && c.IPv4.Error == "") || (c.IPv6 != nil && c.IPv6.Error == "")
+}
+
+const tcpTimeout = 10 * time.Second
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseNetworkTCP) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Network/TCP")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseNetworkTCP) Explain() string {
+	return "Time direct TCP connections to the New Relic endpoints over IPv4 and IPv6"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseNetworkTCP) Dependencies() []string {
+	return []string{
+		"Base/Network/Endpoints",
+		"Base/Network/DNS",
+		"Base/Config/ProxyDetect",
+	}
+}
+
+// Execute - The core work within each task
+func (p BaseNetworkTCP) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	endpoints, ok := upstreamEndpoints(upstream)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic endpoint to connect to",
+		}
+	}
+	resolutions, _ := upstream["Base/Network/DNS"].Payload.(map[string]DNSResolution)
+
+	var connections []TCPConnection
+	var failures, warnings, lines []string
+	for _, endpoint := range endpoints {
+		resolution, found := resolutions[endpoint.Host]
+		if !found || resolution.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: not connected, the host was not resolved", endpoint))
+			connections = append(connections, TCPConnection{Endpoint: endpoint})
+			continue
+		}
+		connection := TCPConnection{Endpoint: endpoint}
+		if len(resolution.IPv4) > 0 {
+			connection.IPv4 = p.connect("tcp4", resolution.IPv4[0], endpoint.Port)
+		}
+		if len(resolution.IPv6) > 0 {
+			connection.IPv6 = p.connect("tcp6", resolution.IPv6[0], endpoint.Port)
+		}
+		connections = append(connections, connection)
+
+		switch {
+		case !connection.Connected():
+			failures = append(failures, fmt.Sprintf("%s: unable to connect: %s", endpoint, attemptErrors(connection)))
+		case connection.IPv6 != nil && connection.IPv6.Error != "":
+			// clients without Happy Eyeballs wait for the IPv6 connection to time out before trying IPv4
+			warnings = append(warnings, fmt.Sprintf("%s: connected over IPv4 in %s but not over IPv6 (%s). Clients that try IPv6 first can wait for its timeout on every connection: fix the IPv6 route or prefer IPv4 (e.g. -Djava.net.preferIPv4Stack=true, precedence ::ffff:0:0/96 100 in /etc/gai.conf)", endpoint, connection.IPv4.Duration.Round(time.Millisecond), connection.IPv6.Error))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: connected in %s", endpoint, fastestAttempt(connection).Round(time.Millisecond)))
+		}
+	}
+
+	if len(failures) > 0 {
+		status := tasks.Failure
+		summary := strings.Join(append(append(failures, warnings...), lines...), "\n")
+		// the agents connect through the proxy, the direct connections are expected to be blocked
+		if proxyStatus := upstream["Base/Config/ProxyDetect"].Status; proxyStatus == tasks.Success || proxyStatus == tasks.Warning {
+			status = tasks.Warning
+			summary += "\nA proxy is configured: direct connections may be blocked on purpose, see Base/Network/HTTP for the connections through the proxy."
+		}
+		return tasks.Result{
+			Status:  status,
+			Summary: summary,
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks",
+			Payload: connections,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(append(warnings, lines...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks",
+			Payload: connections,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(lines, "\n"),
+		Payload: connections,
+	}
+}
+
+func (p BaseNetworkTCP) connect(network string, ip string, port int) *TCPAttempt {
+	attempt := &TCPAttempt{Address: net.JoinHostPort(ip, strconv.Itoa(port))}
+	start := time.Now()
+	conn, err := p.dial(network, attempt.Address, tcpTimeout)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	conn.Close()
+	return attempt
+}
+
+func attemptErrors(connection TCPConnection) string {
+	var errors []string
+	for _, attempt := range []*TCPAttempt{connection.IPv4, connection.IPv6} {
+		if attempt != nil {
+			errors = append(errors, attempt.Error)
+		}
+	}
+	return strings.Join(errors, "; ")
+}
+
+func fastestAttempt(connection TCPConnection) time.Duration {
+	var fastest time.Duration
+	for _, attempt := range []*TCPAttempt{connection.IPv4, connection.IPv6} {
+		if attempt != nil && attempt.Error == "" && (fastest == 0 || attempt.Duration < fastest) {
+			fastest = attempt.Duration
+		}
+	}
+	return fastest
+}
@@ -0,0 +1,199 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// BaseNetworkTLS - records the TLS handshake with the New Relic endpoints
+type BaseNetworkTLS struct {
+	dial      func(network, address string, timeout time.Duration) (net.Conn, error)
+	tlsClient func(conn net.Conn, config *tls.Config) *tls.Conn
+	// rootCAs verifies the presented chains, the system roots when nil
+	rootCAs *x509.CertPool
+}
+
+// TLSHandshake - the negotiated parameters and the certificate chain presented by an endpoint
+type TLSHandshake struct {
+	Endpoint Endpoint
+	Address  string
+	// ServerName is the SNI sent, empty when the host is an IP address
+	ServerName  string
+	Version     string
+	CipherSuite string
+	Chain       []CertificateSummary
+	Verified    bool
+	VerifyError string
+	Duration    time.Duration
+	Error       string
+	// Certificates is the presented chain, the leaf first
+	Certificates []*x509.Certificate `json:"-"`
+}
+
+// CertificateSummary - the fields of a presented certificate relevant to trust issues
+type CertificateSummary struct {
+	Subject   string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	DNSNames  []string
+}
+
+const tlsTimeout = 10 * time.Second
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseNetworkTLS) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Network/TLS")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseNetworkTLS) Explain() string {
+	return "Record the TLS version, cipher suite and certificate chain negotiated with the New Relic endpoints"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseNetworkTLS) Dependencies() []string {
+	return []string{"Base/Network/TCP"}
+}
+
+// Execute - The core work within each task
+func (p BaseNetworkTLS) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	connections, ok := upstream["Base/Network/TCP"].Payload.([]TCPConnection)
+	if !ok || len(connections) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No TCP connection to New Relic endpoints to check the TLS handshake of",
+		}
+	}
+
+	var handshakes []TLSHandshake
+	var failures, warnings, lines []string
+	for _, connection := range connections {
+		address := connectedAddress(connection)
+		if address == "" {
+			warnings = append(warnings, fmt.Sprintf("%s: TLS not checked, the endpoint is not reachable over TCP", connection.Endpoint))
+			continue
+		}
+		handshake := p.handshake(connection.Endpoint, address)
+		handshakes = append(handshakes, handshake)
+		switch {
+		case handshake.Error != "":
+			failures = append(failures, fmt.Sprintf("%s: TLS handshake failed: %s", connection.Endpoint, handshake.Error))
+		case !handshake.Verified:
+			failures = append(failures, fmt.Sprintf("%s: the certificate presented by %s is not trusted: %s. A TLS-intercepting proxy or firewall may be in the path", connection.Endpoint, handshake.Chain[0].Issuer, handshake.VerifyError))
+		case handshake.Version == tls.VersionName(tls.VersionTLS10) || handshake.Version == tls.VersionName(tls.VersionTLS11):
+			warnings = append(warnings, fmt.Sprintf("%s: negotiated %s, New Relic requires TLS 1.2 or later", connection.Endpoint, handshake.Version))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s %s in %s, certificate issued by %s", connection.Endpoint, handshake.Version, handshake.CipherSuite, handshake.Duration.Round(time.Millisecond), handshake.Chain[0].Issuer))
+		}
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(append(failures, warnings...), lines...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#tls",
+			Payload: handshakes,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(append(warnings, lines...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#tls",
+			Payload: handshakes,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(lines, "\n"),
+		Payload: handshakes,
+	}
+}
+
+// connectedAddress - the IPv4 address is preferred when both families connected
+func connectedAddress(connection TCPConnection) string {
+	for _, attempt := range []*TCPAttempt{connection.IPv4, connection.IPv6} {
+		if attempt != nil && attempt.Error == "" {
+			return attempt.Address
+		}
+	}
+	return ""
+}
+
+func (p BaseNetworkTLS) handshake(endpoint Endpoint, address string) TLSHandshake {
+	handshake := TLSHandshake{Endpoint: endpoint, Address: address}
+	if net.ParseIP(endpoint.Host) == nil {
+		handshake.ServerName = endpoint.Host
+	}
+
+	start := time.Now()
+	conn, err := p.dial("tcp", address, tlsTimeout)
+	if err != nil {
+		handshake.Error = err.Error()
+		return handshake
+	}
+	defer conn.Close()
+	conn.SetDeadline(start.Add(tlsTimeout))
+	// the chain is verified below to record it even when it is not trusted
+	tlsConn := p.tlsClient(conn, &tls.Config{ServerName: handshake.ServerName, InsecureSkipVerify: true})
+	err = tlsConn.Handshake()
+	handshake.Duration = time.Since(start)
+	if err != nil {
+		handshake.Error = err.Error()
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			handshake.Error += ". The TCP connection succeeded but the handshake timed out: packets as large as the certificate chain may be dropped, check the MTU of the path (ping -M do -s 1472) and MSS clamping on VPNs and firewalls"
+		}
+		return handshake
+	}
+
+	state := tlsConn.ConnectionState()
+	handshake.Version = tls.VersionName(state.Version)
+	handshake.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	handshake.Certificates = state.PeerCertificates
+	for _, certificate := range state.PeerCertificates {
+		handshake.Chain = append(handshake.Chain, summarizeCertificate(certificate))
+	}
+	if len(state.PeerCertificates) == 0 {
+		handshake.Error = "no certificate presented"
+		return handshake
+	}
+	if err := VerifyChain(state.PeerCertificates, endpoint.Host, p.rootCAs); err != nil {
+		handshake.VerifyError = err.Error()
+		return handshake
+	}
+	handshake.Verified = true
+	return handshake
+}
+
+// VerifyChain - verifies a presented chain, the leaf first, for the host against the roots, the system roots when nil
+func VerifyChain(certificates []*x509.Certificate, host string, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err := certificates[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+func summarizeCertificate(certificate *x509.Certificate) CertificateSummary {
+	return CertificateSummary{
+		Subject:   certificate.Subject.String(),
+		Issuer:    certificate.Issuer.String(),
+		NotBefore: certificate.NotBefore,
+		NotAfter:  certificate.NotAfter,
+		DNSNames:  certificate.DNSNames,
+	}
+}
@@ -0,0 +1,371 @@
+package network
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// BaseNetworkTLSInspect - checks the certificate chains presented by the New Relic endpoints against the CA bundles and truststores of the agents
+type BaseNetworkTLSInspect struct {
+	getProcArgs func(string) []tasks.JavaProcArgs
+	fileReader  func(string) ([]byte, error)
+	readDir     func(string) ([]os.DirEntry, error)
+	systemRoots func() (*x509.CertPool, error)
+	now         func() time.Time
+}
+
+// CATrustSource - a CA bundle or truststore an agent validates the certificates of New Relic with
+type CATrustSource struct {
+	Agent string
+	// Setting is where the bundle is configured, e.g. ca_bundle_path in /app/config/newrelic.yml
+	Setting string
+	Path    string
+	// Additive bundles are trusted along with the default roots of the agent, the others replace them
+	Additive     bool
+	Certificates int
+	Expired      []string
+	ExpiringSoon []string
+	Error        string
+	certificates []*x509.Certificate
+}
+
+// EndpointTrust - who issued the chain presented by an endpoint and the sources that don't validate it
+type EndpointTrust struct {
+	Endpoint Endpoint
+	Chain    []CertificateSummary
+	// Issuer is the issuer of the last certificate of the chain
+	Issuer      string
+	Intercepted bool
+	// Rejected has the reason each source doesn't validate the chain by its Setting
+	Rejected map[string]string
+}
+
+// TLSInspection - the payload of Base/Network/TLSInspect
+type TLSInspection struct {
+	Endpoints []EndpointTrust
+	Sources   []CATrustSource
+}
+
+// a certificate expiring within this duration is reported
+const expiryWarningPeriod = 30 * 24 * time.Hour
+
+// the organizations of the public CAs issuing the certificates of the New Relic endpoints and of the CDNs in front of them
+var publicIssuerOrganizations = []string{
+	"DigiCert",
+	"Let's Encrypt",
+	"Internet Security Research Group",
+	"Amazon",
+	"GlobalSign",
+	"Sectigo",
+	"COMODO",
+	"Google Trust Services",
+	"Entrust",
+	"GoDaddy",
+	"Starfield",
+	"IdenTrust",
+	"Baltimore",
+}
+
+// the settings of a CA bundle in the config files of the agents
+var configCASettings = []struct {
+	key      string
+	agent    string
+	additive bool
+	dir      bool
+}{
+	{key: "ca_bundle_path", agent: "APM agent"},
+	{key: "ca_bundle_file", agent: "Infrastructure agent", additive: true},
+	{key: "ca_bundle_dir", agent: "Infrastructure agent", additive: true, dir: true},
+	{key: "newrelic.daemon.ssl_ca_bundle", agent: "PHP daemon"},
+	{key: "newrelic.daemon.ssl_ca_path", agent: "PHP daemon", dir: true},
+}
+
+// the environment variables of a CA bundle, they take precedence over the config files
+var envCASettings = []struct {
+	name     string
+	agent    string
+	additive bool
+	dir      bool
+}{
+	{name: "NEW_RELIC_CA_BUNDLE_PATH", agent: "APM agent"},
+	{name: "NODE_EXTRA_CA_CERTS", agent: "Node agent", additive: true},
+	{name: "NRIA_CA_BUNDLE_FILE", agent: "Infrastructure agent", additive: true},
+	{name: "NRIA_CA_BUNDLE_DIR", agent: "Infrastructure agent", additive: true, dir: true},
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseNetworkTLSInspect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Network/TLSInspect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseNetworkTLSInspect) Explain() string {
+	return "Detect TLS-intercepting proxies and check that the CA bundles and truststores of the agents validate the certificates presented for New Relic endpoints"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseNetworkTLSInspect) Dependencies() []string {
+	return []string{
+		"Base/Network/TLS",
+		"Base/Config/Validate",
+		"Base/Env/CollectEnvVars",
+	}
+}
+
+// Execute - The core work within each task
+func (p BaseNetworkTLSInspect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	handshakes, _ := upstream["Base/Network/TLS"].Payload.([]TLSHandshake)
+	sources := p.findSources(upstream)
+	for i := range sources {
+		p.loadSource(&sources[i])
+	}
+
+	var presented []TLSHandshake
+	for _, handshake := range handshakes {
+		if len(handshake.Certificates) > 0 {
+			presented = append(presented, handshake)
+		}
+	}
+	if len(presented) == 0 && len(sources) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No certificate chain of New Relic endpoints or CA bundle of agents to inspect",
+		}
+	}
+
+	inspection := TLSInspection{Sources: sources}
+	var failures, warnings, lines []string
+	for _, source := range sources {
+		switch {
+		case source.Error == errPKCS12Truststore.Error():
+			warnings = append(warnings, fmt.Sprintf("%s: %s is not checked, %s", source.Agent, source.Setting, source.Error))
+		case source.Error != "":
+			failures = append(failures, fmt.Sprintf("%s: unable to load %s: %s", source.Agent, source.Setting, source.Error))
+		}
+		if len(source.Expired) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: %s has expired certificates: %s", source.Agent, source.Setting, strings.Join(source.Expired, "; ")))
+		}
+		if len(source.ExpiringSoon) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: %s has certificates expiring within 30 days: %s", source.Agent, source.Setting, strings.Join(source.ExpiringSoon, "; ")))
+		}
+	}
+
+	for _, handshake := range presented {
+		trust := p.inspectChain(handshake, sources)
+		inspection.Endpoints = append(inspection.Endpoints, trust)
+		if trust.Intercepted {
+			if len(sources) == 0 {
+				failures = append(failures, fmt.Sprintf("%s: the certificate is issued by %s, not by a public CA: a TLS-intercepting proxy is in the path and no agent is configured to trust its CA. Add the CA of the proxy with ca_bundle_path (Java, Ruby, Python), NODE_EXTRA_CA_CERTS (Node), ca_bundle_file (Infrastructure) or newrelic.daemon.ssl_ca_bundle (PHP)", handshake.Endpoint, trust.Issuer))
+			} else {
+				lines = append(lines, fmt.Sprintf("%s: the certificate is issued by %s, not by a public CA: a TLS-intercepting proxy is in the path", handshake.Endpoint, trust.Issuer))
+			}
+		}
+		for _, setting := range sortedKeys(trust.Rejected) {
+			failures = append(failures, fmt.Sprintf("%s: %s doesn't validate the certificate issued by %s: %s", handshake.Endpoint, setting, trust.Issuer, trust.Rejected[setting]))
+		}
+		if !trust.Intercepted && len(trust.Rejected) == 0 {
+			lines = append(lines, fmt.Sprintf("%s: the certificate is issued by the public CA %s", handshake.Endpoint, trust.Issuer))
+		}
+	}
+	for _, source := range sources {
+		if source.Error == "" {
+			lines = append(lines, fmt.Sprintf("%s: %s has %d certificate(s)", source.Agent, source.Setting, source.Certificates))
+		}
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(append(failures, warnings...), lines...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#tls",
+			Payload: inspection,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(append(warnings, lines...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#tls",
+			Payload: inspection,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(lines, "\n"),
+		Payload: inspection,
+	}
+}
+
+// findSources - the CA bundles of the config files, of the environment and the truststores of the running JVMs
+func (p BaseNetworkTLSInspect) findSources(upstream map[string]tasks.Result) []CATrustSource {
+	var sources []CATrustSource
+	seen := map[string]bool{}
+	add := func(source CATrustSource) {
+		if source.Path == "" || seen[source.Setting] {
+			return
+		}
+		seen[source.Setting] = true
+		sources = append(sources, source)
+	}
+
+	envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	for _, setting := range envCASettings {
+		add(CATrustSource{Agent: setting.agent, Setting: setting.name, Path: envVars[setting.name], Additive: setting.additive})
+	}
+
+	validations, _ := upstream["Base/Config/Validate"].Payload.([]config.ValidateElement)
+	for _, validation := range validations {
+		file := filepath.Join(validation.Config.FilePath, validation.Config.FileName)
+		for _, setting := range configCASettings {
+			for _, blob := range validation.ParsedResult.FindKey(setting.key) {
+				agent := setting.agent
+				if setting.key == "ca_bundle_path" {
+					agent = configFileAgent(validation.Config.FileName)
+				}
+				add(CATrustSource{
+					Agent:    agent,
+					Setting:  fmt.Sprintf("%s %s in %s", setting.key, tasks.TrimQuotes(blob.Value()), file),
+					Path:     tasks.TrimQuotes(blob.Value()),
+					Additive: setting.additive,
+				})
+			}
+		}
+	}
+
+	for _, proc := range p.getProcArgs("java") {
+		for _, arg := range proc.Args {
+			for _, property := range []string{"-Djavax.net.ssl.trustStore=", "-Dnewrelic.config.ca_bundle_path="} {
+				if !strings.HasPrefix(arg, property) {
+					continue
+				}
+				path := strings.TrimPrefix(arg, property)
+				add(CATrustSource{
+					Agent:   fmt.Sprintf("Java agent (pid %d)", proc.ProcID),
+					Setting: fmt.Sprintf("%s%s of process %d", property, path, proc.ProcID),
+					Path:    path,
+				})
+			}
+		}
+	}
+	return sources
+}
+
+func configFileAgent(fileName string) string {
+	switch filepath.Ext(fileName) {
+	case ".yml", ".yaml":
+		return "Java or Ruby agent"
+	case ".ini":
+		return "Python agent"
+	}
+	return "APM agent"
+}
+
+// loadSource - reads the certificates of a PEM bundle, a directory of PEM files or a Java truststore
+func (p BaseNetworkTLSInspect) loadSource(source *CATrustSource) {
+	var paths []string
+	if entries, err := p.readDir(source.Path); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				paths = append(paths, filepath.Join(source.Path, entry.Name()))
+			}
+		}
+	} else {
+		paths = []string{source.Path}
+	}
+
+	for _, path := range paths {
+		content, err := p.fileReader(path)
+		if err != nil {
+			source.Error = err.Error()
+			return
+		}
+		certificates, err := parsePEMCertificates(content)
+		if err != nil && len(paths) == 1 {
+			certificates, err = parseJavaTruststore(content)
+		}
+		if err != nil {
+			// the files of a CA directory that are not certificates are skipped
+			if len(paths) > 1 {
+				log.Debug("Skipping", path, ":", err)
+				continue
+			}
+			source.Error = err.Error()
+			return
+		}
+		source.certificates = append(source.certificates, certificates...)
+	}
+	if len(source.certificates) == 0 {
+		source.Error = "no certificate found"
+		return
+	}
+	source.Certificates = len(source.certificates)
+
+	now := p.now()
+	for _, certificate := range source.certificates {
+		description := fmt.Sprintf("%s (%s)", certificate.Subject.CommonName, certificate.NotAfter.Format("2006-01-02"))
+		if now.After(certificate.NotAfter) {
+			source.Expired = append(source.Expired, description)
+		} else if now.Add(expiryWarningPeriod).After(certificate.NotAfter) {
+			source.ExpiringSoon = append(source.ExpiringSoon, description)
+		}
+	}
+}
+
+// inspectChain - the chain is intercepted when its last certificate is not issued by a public CA
+func (p BaseNetworkTLSInspect) inspectChain(handshake TLSHandshake, sources []CATrustSource) EndpointTrust {
+	last := handshake.Certificates[len(handshake.Certificates)-1]
+	trust := EndpointTrust{
+		Endpoint:    handshake.Endpoint,
+		Chain:       handshake.Chain,
+		Issuer:      last.Issuer.String(),
+		Intercepted: !isPublicIssuer(last),
+		Rejected:    map[string]string{},
+	}
+	for _, source := range sources {
+		if source.Error != "" {
+			continue
+		}
+		roots := x509.NewCertPool()
+		if source.Additive {
+			if systemRoots, err := p.systemRoots(); err == nil {
+				roots = systemRoots.Clone()
+			}
+		}
+		for _, certificate := range source.certificates {
+			roots.AddCert(certificate)
+		}
+		if err := VerifyChain(handshake.Certificates, handshake.Endpoint.Host, roots); err != nil {
+			trust.Rejected[source.Setting] = err.Error()
+		}
+	}
+	return trust
+}
+
+func isPublicIssuer(certificate *x509.Certificate) bool {
+	for _, organization := range certificate.Issuer.Organization {
+		for _, public := range publicIssuerOrganizations {
+			if strings.Contains(organization, public) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
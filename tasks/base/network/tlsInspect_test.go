@@ -0,0 +1,256 @@
+package network
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+var testNow = time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, organization string, notAfter time.Time) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: organization + " Root CA", Organization: []string{organization}},
+		NotBefore:             testNow.AddDate(-1, 0, 0),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+	return testCA{certificate: certificate, key: key}
+}
+
+// issue - a leaf certificate of the host signed by the CA, valid at the current time for Verify
+func (ca testCA) issue(t *testing.T, host string) *x509.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+	return certificate
+}
+
+func writePEM(t *testing.T, dir string, name string, certificates ...*x509.Certificate) string {
+	var content []byte
+	for _, certificate := range certificates {
+		content = append(content, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})...)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeJKS - a version 2 JKS keystore with a trusted certificate entry per certificate, the digest is not computed
+func writeJKS(t *testing.T, dir string, certificates ...*x509.Certificate) string {
+	var content bytes.Buffer
+	write := func(value interface{}) { binary.Write(&content, binary.BigEndian, value) }
+	writeUTF := func(value string) {
+		write(uint16(len(value)))
+		content.WriteString(value)
+	}
+	write(uint32(jksMagic))
+	write(uint32(2))
+	write(uint32(len(certificates)))
+	for _, certificate := range certificates {
+		write(uint32(2))
+		writeUTF(certificate.Subject.CommonName)
+		write(testNow.UnixMilli())
+		writeUTF("X.509")
+		write(uint32(len(certificate.Raw)))
+		content.Write(certificate.Raw)
+	}
+	content.Write(make([]byte, 20))
+	path := filepath.Join(dir, "truststore.jks")
+	if err := os.WriteFile(path, content.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTLSInspect(procArgs []tasks.JavaProcArgs) BaseNetworkTLSInspect {
+	return BaseNetworkTLSInspect{
+		getProcArgs: func(string) []tasks.JavaProcArgs { return procArgs },
+		fileReader:  os.ReadFile,
+		readDir:     os.ReadDir,
+		systemRoots: func() (*x509.CertPool, error) { return x509.NewCertPool(), nil },
+		now:         func() time.Time { return testNow },
+	}
+}
+
+func presentedChain(certificates ...*x509.Certificate) map[string]tasks.Result {
+	return map[string]tasks.Result{
+		"Base/Network/TLS": {Payload: []TLSHandshake{{
+			Endpoint:     Endpoint{Product: "collector", Region: "us01", Host: "collector.newrelic.com", Port: 443},
+			Certificates: certificates,
+		}}},
+	}
+}
+
+func TestBaseNetworkTLSInspect_Execute(t *testing.T) {
+	dir := t.TempDir()
+	publicCA := newTestCA(t, "DigiCert Inc", testNow.AddDate(5, 0, 0))
+	proxyCA := newTestCA(t, "Corp Proxy", testNow.AddDate(5, 0, 0))
+	expiringCA := newTestCA(t, "Old Corp", testNow.AddDate(0, 0, 10))
+	publicLeaf := publicCA.issue(t, "collector.newrelic.com")
+	interceptedLeaf := proxyCA.issue(t, "collector.newrelic.com")
+
+	proxyBundle := writePEM(t, dir, "proxy.pem", proxyCA.certificate)
+	publicBundle := writePEM(t, dir, "public.pem", publicCA.certificate)
+	expiringBundle := writePEM(t, dir, "expiring.pem", proxyCA.certificate, expiringCA.certificate)
+	truststore := writeJKS(t, dir, publicCA.certificate, proxyCA.certificate)
+	pkcs12 := filepath.Join(dir, "truststore.p12")
+	os.WriteFile(pkcs12, []byte{0x30, 0x82, 0x01, 0x00}, 0600)
+
+	tests := []struct {
+		name      string
+		upstream  map[string]tasks.Result
+		procArgs  []tasks.JavaProcArgs
+		status    tasks.Status
+		summaries []string
+	}{
+		{
+			name:      "should accept a chain of a public CA without CA bundles",
+			upstream:  presentedChain(publicLeaf),
+			status:    tasks.Success,
+			summaries: []string{"issued by the public CA"},
+		},
+		{
+			name:      "should detect the interception when no agent trusts the proxy CA",
+			upstream:  presentedChain(interceptedLeaf),
+			status:    tasks.Failure,
+			summaries: []string{"TLS-intercepting proxy", "O=Corp Proxy", "NODE_EXTRA_CA_CERTS"},
+		},
+		{
+			name: "should validate the intercepted chain with the extra CA of Node",
+			upstream: withEnv(presentedChain(interceptedLeaf), map[string]string{
+				"NODE_EXTRA_CA_CERTS": proxyBundle,
+			}),
+			status:    tasks.Success,
+			summaries: []string{"TLS-intercepting proxy", "NODE_EXTRA_CA_CERTS has 1 certificate(s)"},
+		},
+		{
+			name: "should report the config bundle that doesn't validate the intercepted chain",
+			upstream: withConfig(presentedChain(interceptedLeaf), "newrelic.yml", map[string]string{
+				"ca_bundle_path": publicBundle,
+			}),
+			status:    tasks.Failure,
+			summaries: []string{"ca_bundle_path " + publicBundle + " in newrelic.yml doesn't validate"},
+		},
+		{
+			name: "should report certificates of bundles expiring soon",
+			upstream: withConfig(presentedChain(interceptedLeaf), "newrelic-infra.yml", map[string]string{
+				"ca_bundle_file": expiringBundle,
+			}),
+			status:    tasks.Warning,
+			summaries: []string{"expiring within 30 days: Old Corp Root CA"},
+		},
+		{
+			name:      "should validate the chain with the truststore of the JVM",
+			upstream:  presentedChain(interceptedLeaf),
+			procArgs:  []tasks.JavaProcArgs{{ProcID: 42, Args: []string{"java", "-Djavax.net.ssl.trustStore=" + truststore, "-jar", "app.jar"}}},
+			status:    tasks.Success,
+			summaries: []string{"-Djavax.net.ssl.trustStore=" + truststore + " of process 42 has 2 certificate(s)"},
+		},
+		{
+			name:      "should not check PKCS12 truststores",
+			upstream:  presentedChain(publicLeaf),
+			procArgs:  []tasks.JavaProcArgs{{ProcID: 42, Args: []string{"java", "-Djavax.net.ssl.trustStore=" + pkcs12}}},
+			status:    tasks.Warning,
+			summaries: []string{"PKCS12 truststores are not supported"},
+		},
+		{
+			name: "should fail for a missing bundle",
+			upstream: withEnv(presentedChain(publicLeaf), map[string]string{
+				"NEW_RELIC_CA_BUNDLE_PATH": filepath.Join(dir, "missing.pem"),
+			}),
+			status:    tasks.Failure,
+			summaries: []string{"unable to load NEW_RELIC_CA_BUNDLE_PATH"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := newTLSInspect(tt.procArgs).Execute(tasks.Options{}, tt.upstream)
+			if result.Status != tt.status {
+				t.Errorf("Execute() status = %v, want %v: %s", result.Status, tt.status, result.Summary)
+			}
+			for _, summary := range tt.summaries {
+				if !strings.Contains(result.Summary, summary) {
+					t.Errorf("Execute() summary = %q, want it to contain %q", result.Summary, summary)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJavaTruststore(t *testing.T) {
+	ca := newTestCA(t, "Corp Proxy", testNow.AddDate(1, 0, 0))
+	content, _ := os.ReadFile(writeJKS(t, t.TempDir(), ca.certificate))
+	certificates, err := parseJavaTruststore(content)
+	if err != nil || len(certificates) != 1 || !certificates[0].Equal(ca.certificate) {
+		t.Errorf("parseJavaTruststore() = %v, %v, want the certificate of the CA", certificates, err)
+	}
+	if _, err := parseJavaTruststore(content[:30]); err == nil {
+		t.Errorf("parseJavaTruststore() of a truncated keystore returned no error")
+	}
+	if _, err := parseJavaTruststore([]byte("not a truststore")); err == nil || err == errPKCS12Truststore {
+		t.Errorf("parseJavaTruststore() of text = %v, want an error", err)
+	}
+}
+
+func withEnv(upstream map[string]tasks.Result, envVars map[string]string) map[string]tasks.Result {
+	upstream["Base/Env/CollectEnvVars"] = tasks.Result{Status: tasks.Info, Payload: envVars}
+	return upstream
+}
+
+func withConfig(upstream map[string]tasks.Result, fileName string, values map[string]string) map[string]tasks.Result {
+	blob := tasks.ValidateBlob{}
+	for key, value := range values {
+		blob.Children = append(blob.Children, tasks.ValidateBlob{Key: key, RawValue: value})
+	}
+	upstream["Base/Config/Validate"] = tasks.Result{Status: tasks.Success, Payload: []config.ValidateElement{{
+		Config:       config.ConfigElement{FileName: fileName},
+		ParsedResult: blob,
+	}}}
+	return upstream
+}
@@ -0,0 +1,149 @@
+package network
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// the magic numbers of the Java keystores
+const (
+	jksMagic   = 0xFEEDFEED
+	jceksMagic = 0xCECECECE
+)
+
+var errPKCS12Truststore = errors.New("PKCS12 truststores are not supported, convert it to JKS with keytool -importkeystore to check it")
+
+// parsePEMCertificates - the certificates of a PEM bundle, the blocks that are not certificates are skipped
+func parsePEMCertificates(content []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return certificates, nil
+}
+
+// parseJavaTruststore - the trusted certificates and the certificate chains of the key entries of a JKS or JCEKS keystore.
+// The password is only needed to check the integrity of the keystore, which is not done.
+func parseJavaTruststore(content []byte) ([]*x509.Certificate, error) {
+	reader := bytes.NewReader(content)
+	var header struct {
+		Magic   uint32
+		Version uint32
+		Count   uint32
+	}
+	if err := binary.Read(reader, binary.BigEndian, &header); err != nil || (header.Magic != jksMagic && header.Magic != jceksMagic) {
+		// a PKCS12 file is a DER sequence
+		if len(content) > 0 && content[0] == 0x30 {
+			return nil, errPKCS12Truststore
+		}
+		return nil, errors.New("not a PEM bundle or a Java truststore")
+	}
+	if header.Version != 1 && header.Version != 2 {
+		return nil, fmt.Errorf("unsupported keystore version %d", header.Version)
+	}
+
+	var certificates []*x509.Certificate
+	readCertificate := func() error {
+		if header.Version == 2 {
+			// the certificate type, always X.509
+			if _, err := readJavaUTF(reader); err != nil {
+				return err
+			}
+		}
+		der, err := readJavaBytes(reader)
+		if err != nil {
+			return err
+		}
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			return err
+		}
+		certificates = append(certificates, certificate)
+		return nil
+	}
+
+	for i := uint32(0); i < header.Count; i++ {
+		var tag uint32
+		if err := binary.Read(reader, binary.BigEndian, &tag); err != nil {
+			return nil, err
+		}
+		alias, err := readJavaUTF(reader)
+		if err != nil {
+			return nil, err
+		}
+		var timestamp int64
+		if err := binary.Read(reader, binary.BigEndian, &timestamp); err != nil {
+			return nil, err
+		}
+		switch tag {
+		case 1:
+			if _, err := readJavaBytes(reader); err != nil {
+				return nil, err
+			}
+			var chainLength uint32
+			if err := binary.Read(reader, binary.BigEndian, &chainLength); err != nil {
+				return nil, err
+			}
+			for j := uint32(0); j < chainLength; j++ {
+				if err := readCertificate(); err != nil {
+					return nil, fmt.Errorf("entry %s: %w", alias, err)
+				}
+			}
+		case 2:
+			if err := readCertificate(); err != nil {
+				return nil, fmt.Errorf("entry %s: %w", alias, err)
+			}
+		default:
+			// the secret keys of JCEKS are serialized Java objects, the entries after them can't be read
+			return certificates, nil
+		}
+	}
+	return certificates, nil
+}
+
+func readJavaUTF(reader *bytes.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func readJavaBytes(reader *bytes.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if int64(length) > int64(reader.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -11,4 +12,7 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 
 	registrationFunc(BrowserAgentDetect{}, true)
 	registrationFunc(BrowserAgentGetSource{}, true)
+	registrationFunc(BrowserAgentInspect{
+		httpGetter: httpHelper.MakeHTTPRequest,
+	}, true)
 }
@@ -0,0 +1,424 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// BrowserAgentInspect - inspects the loader and the NREUM configuration of the pages of the provided URL
+type BrowserAgentInspect struct {
+	httpGetter tasks.HTTPRequestFunc
+}
+
+// BrowserPageInspection - the Browser agent found in one page
+type BrowserPageInspection struct {
+	URL           string
+	LoaderType    string
+	LoaderVersion string
+	ApplicationID string
+	LicenseKey    string
+	Beacon        string
+	Loaders       int
+	Failures      []string
+	Warnings      []string
+}
+
+// htmlScript - a script element and its position in the page
+type htmlScript struct {
+	Attributes string
+	Content    string
+	Start      int
+}
+
+const (
+	defaultBeacon     = "bam.nr-data.net"
+	browserAgentHost  = "js-agent.newrelic.com"
+	maxInspectedPages = 10
+)
+
+var (
+	scriptRegex       = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script>`)
+	headEndRegex      = regexp.MustCompile(`(?i)</head>`)
+	linkRegex         = regexp.MustCompile(`(?i)<a\s[^>]*href\s*=\s*["']([^"'#]+)`)
+	scriptTypeRegex   = regexp.MustCompile(`(?i)\btype\s*=\s*["']?([^"'\s>]+)`)
+	scriptSrcRegex    = regexp.MustCompile(`(?i)\bsrc\s*=\s*["']?([^"'\s>]+)`)
+	scriptNonceRegex  = regexp.MustCompile(`(?i)\bnonce\s*=`)
+	loaderMarkerRegex = regexp.MustCompile(`NREUM\.loader_config|nr-loader-(?:spa|full|rum)|[^"\w]agent:"js-agent\.newrelic\.com`)
+	nreumBlobRegex    = regexp.MustCompile(`NREUM\.(info|loader_config)\s*=\s*\{([^{}]*)\}`)
+	blobEntryRegex    = regexp.MustCompile(`["']?(\w+)["']?\s*:\s*(?:"([^"]*)"|'([^']*)'|([^,}]+))`)
+	loaderFileRegex   = regexp.MustCompile(`nr-loader-(spa|full|rum)-([0-9]+(?:\.[0-9]+)*)(?:\.min)?\.js`)
+	applicationIDRgx  = regexp.MustCompile(`^[0-9]+$`)
+	browserKeyRegex   = regexp.MustCompile(`^(NRJS-[0-9a-f]{19}|[0-9a-f]{10})$`)
+	ingestKeyRegex    = regexp.MustCompile(`^[0-9a-fA-F]{36}NRAL$|^[0-9a-fA-F]{40}$`)
+
+	// the loader files by the loader type shown in the Browser UI
+	loaderTypes = map[string]string{"spa": "SPA", "full": "Pro", "rum": "Lite"}
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t BrowserAgentInspect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Browser/Agent/Inspect")
+}
+
+// Explain - Returns the help text for each individual task
+func (t BrowserAgentInspect) Explain() string {
+	explain := "Inspect the New Relic Browser loader, its configuration and the Content-Security-Policy of the provided URL"
+	if config.Flags.ShowOverrideHelp {
+		explain += fmt.Sprintf("\n%37s %s", " ", "Override: pages => semicolon separated paths of the same site to inspect as well (e.g. /cart;/checkout)")
+		explain += fmt.Sprintf("\n%37s %s", " ", "Override: crawl => number of same site links of the page to inspect as well (defaults to 0)")
+	}
+	return explain
+}
+
+// Dependencies - Returns the dependencies for ech task.
+func (t BrowserAgentInspect) Dependencies() []string {
+	return []string{
+		"Browser/Agent/GetSource",
+	}
+}
+
+// Execute - The core work within each task
+func (t BrowserAgentInspect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	sourceStatus := upstream["Browser/Agent/GetSource"].Status
+	if sourceStatus != tasks.Success && sourceStatus != tasks.Warning {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "This task did not run because the previous task 'Browser/Agent/GetSource' either did not run or was not successful.",
+		}
+	}
+	source, ok := upstream["Browser/Agent/GetSource"].Payload.(BrowserAgentSourcePayload)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+	base, err := url.Parse(source.URL)
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: fmt.Sprintf("Unable to parse the URL %s: %s", source.URL, err.Error()),
+		}
+	}
+
+	pages := []string{base.String()}
+	for _, page := range strings.Split(options.Options["pages"], ";") {
+		if resolved := resolveSameOrigin(base, strings.TrimSpace(page)); resolved != "" && !tasks.ContainsString(pages, resolved) {
+			pages = append(pages, resolved)
+		}
+	}
+	crawl, _ := strconv.Atoi(options.Options["crawl"])
+
+	var inspections []BrowserPageInspection
+	for i := 0; i < len(pages) && i < maxInspectedPages; i++ {
+		inspection, body := t.inspectPage(pages[i])
+		inspections = append(inspections, inspection)
+		if i > 0 || crawl <= 0 {
+			continue
+		}
+		for _, link := range getSameOriginLinks(base, body) {
+			if crawl == 0 {
+				break
+			}
+			if !tasks.ContainsString(pages, link) {
+				pages = append(pages, link)
+				crawl--
+			}
+		}
+	}
+
+	var failures, warnings []string
+	for _, inspection := range inspections {
+		for _, failure := range inspection.Failures {
+			failures = append(failures, inspection.URL+": "+failure)
+		}
+		for _, warning := range inspection.Warnings {
+			warnings = append(warnings, inspection.URL+": "+warning)
+		}
+	}
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(failures, warnings...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/browser/new-relic-browser/troubleshooting/troubleshooting-browser-monitoring-installation/",
+			Payload: inspections,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(warnings, "\n"),
+			URL:     "https://docs.newrelic.com/docs/browser/browser-monitoring/installation/install-browser-monitoring-agent/",
+			Payload: inspections,
+		}
+	}
+	var summaries []string
+	for _, inspection := range inspections {
+		summaries = append(summaries, fmt.Sprintf("%s: %s loader %s for application %s", inspection.URL, inspection.LoaderType, inspection.LoaderVersion, inspection.ApplicationID))
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: inspections,
+	}
+}
+
+// inspectPage - fetches a page and returns its inspection and body
+func (t BrowserAgentInspect) inspectPage(pageURL string) (BrowserPageInspection, string) {
+	inspection := BrowserPageInspection{URL: pageURL}
+	resp, err := t.httpGetter(httpHelper.RequestWrapper{
+		Method: "GET",
+		URL:    pageURL,
+	})
+	if err != nil {
+		inspection.Failures = append(inspection.Failures, "unable to fetch the page: "+err.Error())
+		return inspection, ""
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		inspection.Failures = append(inspection.Failures, "unable to read the page: "+err.Error())
+		return inspection, ""
+	}
+	page := string(body)
+
+	scripts := getScripts(page)
+	var loaders []htmlScript
+	for _, script := range scripts {
+		if isLoaderScript(script) {
+			loaders = append(loaders, script)
+		}
+	}
+	inspection.Loaders = len(loaders)
+	if len(loaders) == 0 {
+		inspection.Failures = append(inspection.Failures, "the Browser agent loader was not found")
+		return inspection, page
+	}
+	if len(loaders) > 1 {
+		inspection.Failures = append(inspection.Failures, fmt.Sprintf("%d Browser agent loaders were found, only one loader must be on the page or the data is reported twice or not at all", len(loaders)))
+	}
+	inspection.LoaderType, inspection.LoaderVersion = getLoaderTypeAndVersion(loaders[0])
+
+	info := map[string]string{}
+	applicationIDs := map[string]bool{}
+	for _, script := range scripts {
+		for _, blob := range parseNREUMBlobs(script.Content) {
+			if blob["applicationID"] != "" {
+				applicationIDs[blob["applicationID"]] = true
+			}
+			for key, value := range blob {
+				if _, found := info[key]; !found {
+					info[key] = value
+				}
+			}
+		}
+	}
+	inspection.ApplicationID = info["applicationID"]
+	inspection.LicenseKey = info["licenseKey"]
+	inspection.Beacon = info["beacon"]
+	if inspection.Beacon == "" {
+		inspection.Beacon = defaultBeacon
+	}
+	if len(applicationIDs) > 1 {
+		ids := make([]string, 0, len(applicationIDs))
+		for id := range applicationIDs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		inspection.Failures = append(inspection.Failures, "the NREUM configuration of the page sets more than one applicationID: "+strings.Join(ids, ", "))
+	}
+	inspection.Failures = append(inspection.Failures, validateNREUMInfo(info)...)
+	inspection.Warnings = append(inspection.Warnings, checkLoaderPosition(page, scripts, loaders[0])...)
+
+	beacons := []string{inspection.Beacon}
+	if errorBeacon := info["errorBeacon"]; errorBeacon != "" && errorBeacon != inspection.Beacon {
+		beacons = append(beacons, errorBeacon)
+	}
+	inspection.Failures = append(inspection.Failures, checkContentSecurityPolicy(resp.Header.Get("Content-Security-Policy"), beacons, loaders[0])...)
+	return inspection, page
+}
+
+func getScripts(page string) []htmlScript {
+	var scripts []htmlScript
+	for _, match := range scriptRegex.FindAllStringSubmatchIndex(page, -1) {
+		scripts = append(scripts, htmlScript{
+			Attributes: page[match[2]:match[3]],
+			Content:    page[match[4]:match[5]],
+			Start:      match[0],
+		})
+	}
+	return scripts
+}
+
+// isLoaderScript - the loader is either the copy/paste or APM injected snippet, or a script loaded from js-agent.newrelic.com
+func isLoaderScript(script htmlScript) bool {
+	if src := scriptSrcRegex.FindStringSubmatch(script.Attributes); src != nil {
+		return strings.Contains(src[1], browserAgentHost) || strings.Contains(src[1], "nr-loader-")
+	}
+	return loaderMarkerRegex.MatchString(script.Content)
+}
+
+// getLoaderTypeAndVersion - the current loaders name their file nr-loader-<type>-<version>, the older ones are detected as Browser/Agent/Detect does
+func getLoaderTypeAndVersion(loader htmlScript) (string, string) {
+	if match := loaderFileRegex.FindStringSubmatch(loader.Attributes + loader.Content); match != nil {
+		return loaderTypes[match[1]], match[2]
+	}
+	scripts := []string{loader.Content}
+	return getBrowserLoader(scripts), getAgentVersion(scripts)
+}
+
+// parseNREUMBlobs - the NREUM.info and NREUM.loader_config objects of a script, keys may or may not be quoted
+func parseNREUMBlobs(content string) []map[string]string {
+	var blobs []map[string]string
+	for _, match := range nreumBlobRegex.FindAllStringSubmatch(content, -1) {
+		blob := map[string]string{}
+		for _, entry := range blobEntryRegex.FindAllStringSubmatch(match[2], -1) {
+			blob[entry[1]] = strings.TrimSpace(entry[2] + entry[3] + entry[4])
+		}
+		blobs = append(blobs, blob)
+	}
+	return blobs
+}
+
+func validateNREUMInfo(info map[string]string) []string {
+	var failures []string
+	applicationID := info["applicationID"]
+	switch {
+	case applicationID == "":
+		failures = append(failures, "NREUM.info has no applicationID")
+	case !applicationIDRgx.MatchString(applicationID):
+		failures = append(failures, fmt.Sprintf("the applicationID %s of NREUM.info is not the numeric ID of a Browser application", applicationID))
+	}
+	licenseKey := info["licenseKey"]
+	switch {
+	case licenseKey == "":
+		failures = append(failures, "NREUM.info has no licenseKey")
+	case ingestKeyRegex.MatchString(licenseKey):
+		failures = append(failures, "the licenseKey of NREUM.info is an ingest license key, the Browser agent requires the browser key of the account (NRJS-...)")
+	case !browserKeyRegex.MatchString(licenseKey):
+		failures = append(failures, fmt.Sprintf("the licenseKey %s of NREUM.info is not a browser key", licenseKey))
+	}
+	return failures
+}
+
+// checkLoaderPosition - the loader must run before the other scripts to wrap the APIs of the browser they use
+func checkLoaderPosition(page string, scripts []htmlScript, loader htmlScript) []string {
+	headEnd := headEndRegex.FindStringIndex(page)
+	if headEnd == nil || loader.Start > headEnd[0] {
+		return []string{"the Browser agent loader is not in the <head> of the page, it must be placed as close to the top of the <head> as possible"}
+	}
+	var before []string
+	for _, script := range scripts {
+		if script.Start >= loader.Start {
+			break
+		}
+		if scriptType := scriptTypeRegex.FindStringSubmatch(script.Attributes); scriptType != nil && !strings.Contains(strings.ToLower(scriptType[1]), "javascript") && scriptType[1] != "module" {
+			continue
+		}
+		if src := scriptSrcRegex.FindStringSubmatch(script.Attributes); src != nil {
+			before = append(before, src[1])
+			continue
+		}
+		before = append(before, "an inline script")
+	}
+	if len(before) == 0 {
+		return nil
+	}
+	return []string{"these scripts run before the Browser agent loader and are not instrumented: " + strings.Join(before, ", ")}
+}
+
+// checkContentSecurityPolicy - the loader must be allowed to run, to load its code from js-agent.newrelic.com and to send data to the beacons
+func checkContentSecurityPolicy(policy string, beacons []string, loader htmlScript) []string {
+	if policy == "" {
+		return nil
+	}
+	directives := map[string][]string{}
+	for _, directive := range strings.Split(policy, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) > 0 {
+			directives[strings.ToLower(fields[0])] = fields[1:]
+		}
+	}
+	sourcesOf := func(name string) ([]string, bool) {
+		if sources, found := directives[name]; found {
+			return sources, true
+		}
+		sources, found := directives["default-src"]
+		return sources, found
+	}
+
+	var failures []string
+	if sources, found := sourcesOf("connect-src"); found {
+		for _, beacon := range beacons {
+			if !cspAllowsHost(sources, beacon) {
+				failures = append(failures, fmt.Sprintf("the Content-Security-Policy header blocks the connections of the Browser agent to %s, add https://%s to connect-src", beacon, beacon))
+			}
+		}
+	}
+	if sources, found := sourcesOf("script-src"); found {
+		inline := tasks.ContainsString(sources, "'unsafe-inline'")
+		for _, source := range sources {
+			// a hash can't be checked against the snippet, it is trusted
+			if strings.HasPrefix(source, "'sha") || (strings.HasPrefix(source, "'nonce-") && scriptNonceRegex.MatchString(loader.Attributes)) {
+				inline = true
+			}
+		}
+		if !inline && !scriptSrcRegex.MatchString(loader.Attributes) {
+			failures = append(failures, "the Content-Security-Policy header blocks the inline Browser agent loader, add a nonce to the loader script or 'unsafe-inline' to script-src")
+		}
+		if !tasks.ContainsString(sources, "'strict-dynamic'") && !cspAllowsHost(sources, browserAgentHost) {
+			failures = append(failures, fmt.Sprintf("the Content-Security-Policy header blocks the Browser agent code from %s, add https://%s to script-src", browserAgentHost, browserAgentHost))
+		}
+	}
+	return failures
+}
+
+func cspAllowsHost(sources []string, host string) bool {
+	for _, source := range sources {
+		source = strings.TrimPrefix(strings.TrimPrefix(source, "https://"), "http://")
+		source = strings.TrimSuffix(source, "/")
+		if source == "*" || source == "https:" || source == host {
+			return true
+		}
+		if strings.HasPrefix(source, "*.") && strings.HasSuffix(host, source[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSameOrigin - returns the absolute URL of a link, or an empty string for the links to other sites
+func resolveSameOrigin(base *url.URL, link string) string {
+	if link == "" {
+		return ""
+	}
+	parsed, err := base.Parse(link)
+	if err != nil || parsed.Scheme != base.Scheme || parsed.Host != base.Host {
+		return ""
+	}
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+func getSameOriginLinks(base *url.URL, page string) []string {
+	var links []string
+	for _, match := range linkRegex.FindAllStringSubmatch(page, -1) {
+		if link := resolveSameOrigin(base, match[1]); link != "" && !tasks.ContainsString(links, link) {
+			links = append(links, link)
+		}
+	}
+	if len(links) > 0 {
+		log.Debug("Found", len(links), "links of the same site")
+	}
+	return links
+}
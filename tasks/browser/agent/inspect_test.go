@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	spaLoader = `<script type="text/javascript">;window.NREUM||(NREUM={});NREUM.init={distributed_tracing:{enabled:true},privacy:{cookies_enabled:true}};
+NREUM.loader_config={accountID:"1234567",trustKey:"1234567",agentID:"601234567",licenseKey:"NRJS-0123456789abcdef012",applicationID:"601234567"};
+NREUM.info={beacon:"bam.nr-data.net",errorBeacon:"bam.nr-data.net",licenseKey:"NRJS-0123456789abcdef012",applicationID:"601234567",sa:1};
+/*! For license information please see nr-loader-spa-1.250.0.min.js.LICENSE.txt */
+(()=>{"use strict";var e={}})();</script>`
+	injectedLoader = `<script type="text/javascript">window.NREUM||(NREUM={});NREUM.info={"beacon":"bam.nr-data.net","licenseKey":"0123456789012345678901234567890123456789","applicationID":"12345,67890","transactionName":"abc","agent":""}</script>
+<script type="text/javascript">window.NREUM||(NREUM={}),__nr_require=function(){};NREUM.loader_config={};/*! nr-loader-full-1.230.0.min.js */</script>`
+
+	goodPage = `<!DOCTYPE html><html><head><meta charset="utf-8">` + spaLoader + `
+<script type="application/ld+json">{"@type":"Organization"}</script>
+<script src="/static/app.js"></script></head>
+<body><a href="/cart">Cart</a><a href="https://example.com/">Other site</a><a href="/about#team">About</a></body></html>`
+	latePage       = `<!DOCTYPE html><html><head><script src="/static/vendor.js"></script>` + spaLoader + `</head><body></body></html>`
+	twoLoadersPage = `<!DOCTYPE html><html><head>` + spaLoader + injectedLoader + `</head><body></body></html>`
+	noLoaderPage   = `<!DOCTYPE html><html><head><script src="/static/app.js"></script></head><body></body></html>`
+)
+
+var _ = Describe("Browser/Agent/Inspect", func() {
+	var (
+		p        BrowserAgentInspect
+		server   *httptest.Server
+		csp      string
+		options  tasks.Options
+		upstream map[string]tasks.Result
+		result   tasks.Result
+	)
+
+	BeforeEach(func() {
+		csp = ""
+		options = tasks.Options{Options: map[string]string{}}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if csp != "" {
+				w.Header().Set("Content-Security-Policy", csp)
+			}
+			switch r.URL.Path {
+			case "/":
+				w.Write([]byte(goodPage))
+			case "/late":
+				w.Write([]byte(latePage))
+			case "/cart":
+				w.Write([]byte(twoLoadersPage))
+			case "/about":
+				w.Write([]byte(noLoaderPage))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		p = BrowserAgentInspect{
+			httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				return http.Get(wrapper.URL)
+			},
+		}
+		upstream = map[string]tasks.Result{
+			"Browser/Agent/GetSource": {Status: tasks.Success, Payload: BrowserAgentSourcePayload{URL: server.URL + "/"}},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(options, upstream)
+	})
+
+	Context("When Browser/Agent/GetSource did not find the loader", func() {
+		BeforeEach(func() {
+			upstream["Browser/Agent/GetSource"] = tasks.Result{Status: tasks.Failure}
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("When the page has one SPA loader at the top of the head", func() {
+		It("Should return the loader and its configuration", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload).To(Equal([]BrowserPageInspection{{
+				URL:           server.URL + "/",
+				LoaderType:    "SPA",
+				LoaderVersion: "1.250.0",
+				ApplicationID: "601234567",
+				LicenseKey:    "NRJS-0123456789abcdef012",
+				Beacon:        "bam.nr-data.net",
+				Loaders:       1,
+			}}))
+		})
+	})
+
+	Context("When other scripts run before the loader", func() {
+		BeforeEach(func() {
+			upstream["Browser/Agent/GetSource"] = tasks.Result{Status: tasks.Success, Payload: BrowserAgentSourcePayload{URL: server.URL + "/late"}}
+		})
+		It("Should return a Warning with the scripts", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(Equal(server.URL + "/late: these scripts run before the Browser agent loader and are not instrumented: /static/vendor.js"))
+		})
+	})
+
+	Context("When the Content-Security-Policy blocks the agent", func() {
+		BeforeEach(func() {
+			csp = "default-src 'self'; script-src 'self' 'unsafe-inline' https://*.newrelic.com; connect-src 'self' https://bam.eu01.nr-data.net"
+		})
+		It("Should return a Failure for the beacon", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(Equal(server.URL + "/: the Content-Security-Policy header blocks the connections of the Browser agent to bam.nr-data.net, add https://bam.nr-data.net to connect-src"))
+		})
+	})
+
+	Context("When the Content-Security-Policy does not allow inline scripts", func() {
+		BeforeEach(func() {
+			csp = "default-src 'self' https://*.nr-data.net"
+		})
+		It("Should return a Failure for the loader and its code", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("blocks the inline Browser agent loader"))
+			Expect(result.Summary).To(ContainSubstring("blocks the Browser agent code from js-agent.newrelic.com"))
+		})
+	})
+
+	Context("When other pages of the site are crawled", func() {
+		BeforeEach(func() {
+			options.Options["crawl"] = "5"
+		})
+		It("Should inspect the links of the same site", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			inspections := result.Payload.([]BrowserPageInspection)
+			Expect(inspections).To(HaveLen(3))
+
+			cart := inspections[1]
+			Expect(cart.URL).To(Equal(server.URL + "/cart"))
+			Expect(cart.Loaders).To(Equal(2))
+			Expect(cart.Failures).To(Equal([]string{
+				"2 Browser agent loaders were found, only one loader must be on the page or the data is reported twice or not at all",
+				"the NREUM configuration of the page sets more than one applicationID: 12345,67890, 601234567",
+			}))
+
+			about := inspections[2]
+			Expect(about.URL).To(Equal(server.URL + "/about"))
+			Expect(about.Failures).To(Equal([]string{"the Browser agent loader was not found"}))
+		})
+	})
+
+	Describe("validateNREUMInfo()", func() {
+		It("Should reject the ingest license key and a list of application IDs", func() {
+			Expect(validateNREUMInfo(map[string]string{"applicationID": "12345,67890", "licenseKey": "0123456789012345678901234567890123456789"})).To(Equal([]string{
+				"the applicationID 12345,67890 of NREUM.info is not the numeric ID of a Browser application",
+				"the licenseKey of NREUM.info is an ingest license key, the Browser agent requires the browser key of the account (NRJS-...)",
+			}))
+		})
+		It("Should accept the older browser keys", func() {
+			Expect(validateNREUMInfo(map[string]string{"applicationID": "12345", "licenseKey": "0123abcdef"})).To(BeEmpty())
+		})
+	})
+
+	Describe("getLoaderTypeAndVersion()", func() {
+		It("Should detect the loader of the injected snippet", func() {
+			scripts := getScripts(injectedLoader)
+			Expect(isLoaderScript(scripts[0])).To(BeFalse())
+			Expect(isLoaderScript(scripts[1])).To(BeTrue())
+			loaderType, version := getLoaderTypeAndVersion(scripts[1])
+			Expect(loaderType).To(Equal("Pro"))
+			Expect(version).To(Equal("1.230.0"))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package compatibilityVars
+
+import (
+	"sort"
+	"strings"
+)
+
+// Other APM agents instrumenting the same code as the New Relic agents. The keys are matched against
+// the command line, module, package or gem names, the values are the vendors displayed to the user.
+
+// Substrings of the -javaagent and -agentpath values of other Java agents
+var JavaConflictingAgents = map[string]string{
+	"dd-java-agent":           "Datadog",
+	"elastic-apm-agent":       "Elastic APM",
+	"opentelemetry-javaagent": "OpenTelemetry",
+	"appdynamics":             "AppDynamics",
+	"appserveragent":          "AppDynamics",
+	"oneagent":                "Dynatrace",
+	"dynatrace":               "Dynatrace",
+	"instana":                 "Instana",
+	"skywalking-agent":        "Apache SkyWalking",
+	"glowroot":                "Glowroot",
+	"pinpoint-bootstrap":      "Pinpoint",
+	"stackify-java-apm":       "Stackify",
+	"scouter.agent":           "Scouter",
+}
+
+// JavaConflictingAgentVendor - returns the vendor of a -javaagent or -agentpath value, or an empty string for an agent not in
+// JavaConflictingAgents. The substrings are checked in a fixed order so an agent always gets the same vendor.
+func JavaConflictingAgentVendor(agent string) string {
+	agent = strings.ToLower(agent)
+	patterns := make([]string, 0, len(JavaConflictingAgents))
+	for pattern := range JavaConflictingAgents {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if strings.Contains(agent, pattern) {
+			return JavaConflictingAgents[pattern]
+		}
+	}
+	return ""
+}
+
+// Node modules that instrument the application when they are required
+var NodeConflictingModules = map[string]string{
+	"dd-trace":         "Datadog",
+	"elastic-apm-node": "Elastic APM",
+	"@opentelemetry/auto-instrumentations-node": "OpenTelemetry",
+	"@opentelemetry/sdk-node":                   "OpenTelemetry",
+	"appdynamics":                               "AppDynamics",
+	"@instana/collector":                        "Instana",
+	"@dynatrace/oneagent":                       "Dynatrace",
+	"@splunk/otel":                              "Splunk",
+	"stackify-node-apm":                         "Stackify",
+}
+
+// Python distributions, with normalized names, that instrument the application
+var PythonConflictingPackages = map[string]string{
+	"ddtrace":                       "Datadog",
+	"elastic-apm":                   "Elastic APM",
+	"opentelemetry-instrumentation": "OpenTelemetry",
+	"opentelemetry-distro":          "OpenTelemetry",
+	"appdynamics":                   "AppDynamics",
+	"instana":                       "Instana",
+	"scout-apm":                     "Scout APM",
+	"splunk-opentelemetry":          "Splunk",
+	"stackify-python-apm":           "Stackify",
+}
+
+// Gems that instrument the application when Bundler requires them
+var RubyConflictingGems = map[string]string{
+	"ddtrace":                           "Datadog",
+	"datadog":                           "Datadog",
+	"elastic-apm":                       "Elastic APM",
+	"opentelemetry-instrumentation-all": "OpenTelemetry",
+	"scout_apm":                         "Scout APM",
+	"skylight":                          "Skylight",
+	"appsignal":                         "AppSignal",
+	"instana":                           "Instana",
+	"stackify-api-ruby":                 "Stackify",
+}
+
+// CLSIDs of the .NET profilers of the New Relic agents, for .NET Framework and .NET Core
+var DotNetNewRelicProfilers = []string{
+	"{71DA0A04-7777-4EC6-9643-7D28B46A8A41}",
+	"{36032161-FFC0-4B61-B559-F6C5D41BAE5A}",
+}
+
+// CLSIDs, in upper case, of the .NET profilers of other agents
+var DotNetConflictingProfilers = map[string]string{
+	"{846F5F1C-F9AE-4B07-969E-05C26BC060D8}": "Datadog",
+	"{FA65FE15-F085-4681-9B20-95E04F6C03CC}": "Elastic APM",
+	"{918728DD-259F-4A6A-AC2B-B85E1B658318}": "OpenTelemetry",
+	"{B7038F67-52FC-4DA2-AB02-969B3C1EDA03}": "Dynatrace",
+}
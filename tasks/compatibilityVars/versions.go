@@ -104,3 +104,152 @@ var DotnetCoreSupportedVersions = map[string][]string{
 }
 
 //https://docs.newrelic.com/docs/agents/net-agent/getting-started/net-agent-compatibility-requirements-net-core#net-version
+
+// https://layers.newrelic-external.com/
+// New Relic Lambda layer names (without the ARM64 suffix) as keys and the Lambda runtimes each layer supports as values.
+// The extension-only layer can be used with any runtime. The layers of the runtimes in LambdaDeprecatedRuntimes are not listed.
+var LambdaLayerRuntimes = map[string][]string{
+	"NewRelicNodeJS18X":       {"nodejs18.x"},
+	"NewRelicNodeJS20X":       {"nodejs20.x"},
+	"NewRelicNodeJS22X":       {"nodejs22.x"},
+	"NewRelicPython39":        {"python3.9"},
+	"NewRelicPython310":       {"python3.10"},
+	"NewRelicPython311":       {"python3.11"},
+	"NewRelicPython312":       {"python3.12"},
+	"NewRelicPython313":       {"python3.13"},
+	"NewRelicJava8":           {"java8.al2"},
+	"NewRelicJava11":          {"java11"},
+	"NewRelicJava17":          {"java17"},
+	"NewRelicJava21":          {"java21"},
+	"NewRelicRuby32":          {"ruby3.2"},
+	"NewRelicRuby33":          {"ruby3.3"},
+	"NewRelicDotnet":          {"dotnet8"},
+	"NewRelicLambdaExtension": {"*"},
+}
+
+// Lambda runtimes deprecated by AWS, New Relic no longer publishes layers for them
+var LambdaDeprecatedRuntimes = []string{
+	"nodejs12.x",
+	"nodejs14.x",
+	"nodejs16.x",
+	"python3.6",
+	"python3.7",
+	"python3.8",
+	"java8",
+	"ruby2.7",
+	"dotnetcore3.1",
+	"dotnet6",
+	"go1.x",
+}
+
+// https://docs.newrelic.com/docs/infrastructure/host-integrations/installation/update-infrastructure-host-integration-package/
+// On-host integration binaries as keys and the integration versions supported by current infrastructure agents as values.
+// Older versions use the v3 definition files or bundled libraries that are no longer supported.
+var InfraIntegrationSupportedVersions = map[string][]string{
+	"nri-apache":        {"1.6.0+"},
+	"nri-cassandra":     {"2.9.0+"},
+	"nri-consul":        {"2.3.0+"},
+	"nri-couchbase":     {"2.3.0+"},
+	"nri-elasticsearch": {"4.3.0+"},
+	"nri-f5":            {"2.3.0+"},
+	"nri-haproxy":       {"2.2.0+"},
+	"nri-jmx":           {"2.6.0+"},
+	"nri-kafka":         {"2.13.0+"},
+	"nri-memcached":     {"2.2.0+"},
+	"nri-mongodb":       {"2.6.0+"},
+	"nri-mssql":         {"2.5.0+"},
+	"nri-mysql":         {"1.7.0+"},
+	"nri-nagios":        {"2.7.0+"},
+	"nri-nginx":         {"3.1.0+"},
+	"nri-oracledb":      {"2.5.0+"},
+	"nri-postgresql":    {"2.8.0+"},
+	"nri-rabbitmq":      {"2.5.0+"},
+	"nri-redis":         {"1.7.0+"},
+	"nri-snmp":          {"1.5.0+"},
+	"nri-varnish":       {"2.2.0+"},
+}
+
+// https://docs.newrelic.com/docs/apm/agents/nodejs-agent/getting-started/compatibility-requirements-nodejs-agent/
+// Web framework modules as keys and the module versions instrumented by the Node agent as values.
+var NodeFrameworkModules = map[string][]string{
+	"express":    {"4.6.0+"},
+	"hapi":       {"16.0.0+"},
+	"@hapi/hapi": {"20.1.2+"},
+	"restify":    {"5.0.0+"},
+	"connect":    {"2.0.0+"},
+	"koa":        {"2.0.0+"},
+	"fastify":    {"2.0.0+"},
+}
+
+// Modules known to cause instrumentation issues and inconsistent data with the Node agent as keys, and the affected module versions as values.
+var NodeIncompatibleModules = map[string][]string{
+	"mongoose":               {"0+"},
+	"typescript":             {"0+"},
+	"@types/node":            {"0+"},
+	"@babel/core":            {"0+"},
+	"@babel/node":            {"0+"},
+	"@babel/generator":       {"0+"},
+	"webpack":                {"0+"},
+	"graphql-server-express": {"0+"},
+	"apollo-server":          {"0+"},
+	"graphql":                {"0+"},
+	"webpack-node-externals": {"0+"},
+}
+
+// Optional modules that add data collected by the Node agent
+var NodeOptionalModules = []string{"@newrelic/native-metrics"}
+
+// Client side modules that are monitored by the Browser agent instead of the Node agent
+var NodeFrontendModules = []string{"react", "react-dom", "angular", "@angular/core", "@angular/common"}
+
+// https://docs.newrelic.com/docs/apm/agents/ruby-agent/troubleshooting/incompatible-gems/
+// Gems as keys and the gem versions that are incompatible with every Ruby agent version as values.
+var RubyIncompatibleGems = map[string][]string{
+	"db-charmer":            {"0+"},
+	"escape_utils":          {"0+"},
+	"right_http_connection": {"0+"},
+	"ar-octopus":            {"0+"},
+}
+
+// https://docs.newrelic.com/docs/apm/agents/ruby-agent/getting-started/ruby-agent-requirements-supported-frameworks/
+// Frameworks as keys, then the framework version (major.minor or major) and the Ruby agent versions that support it.
+var RubyFrameworkAgentSupportability = map[string]map[string][]string{
+	"rails": {
+		"8.0": {"9.16.0+"},
+		"7.2": {"9.12.0+"},
+		"7.1": {"9.6.0+"},
+		"7.0": {"8.2.0+"},
+		"6.1": {"6.15.0+"},
+		"6.0": {"6.7.0.359+"},
+		"5.2": {"5.2.0.345+"},
+		"5.1": {"4.1.0.333+"},
+		"5.0": {"3.15.0.314+"},
+		"4.2": {"3.9.9.275-8.16.0"},
+	},
+	"puma": {
+		"6": {"8.16.0+"},
+		"5": {"6.15.0+"},
+		"4": {"3.9.9.275+"},
+		"3": {"3.9.9.275+"},
+	},
+	"unicorn": {
+		"6": {"6.7.0.359+"},
+		"5": {"3.9.9.275+"},
+	},
+	"passenger": {
+		"6": {"6.7.0.359+"},
+		"5": {"3.9.9.275+"},
+	},
+	"sidekiq": {
+		"7": {"8.16.0+"},
+		"6": {"6.7.0.359+"},
+		"5": {"4.6.0.338+"},
+	},
+	"resque": {
+		"2": {"5.4.0.347+"},
+		"1": {"3.9.9.275+"},
+	},
+}
+
+// The values of NEW_RELIC_DISPATCHER understood by the Ruby agent
+var RubyAgentDispatchers = []string{"passenger", "puma", "unicorn", "thin", "webrick", "falcon", "rainbows", "litespeed", "fastcgi", "sidekiq", "resque", "delayed_job"}
@@ -0,0 +1,122 @@
+package declarative
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed definitions/*.yml
+var embeddedDefinitions embed.FS
+
+// RegisterWith - will register the task definitions embedded in the binary
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering declarative tasks")
+	definitions, err := fs.Sub(embeddedDefinitions, "definitions")
+	if err != nil {
+		log.Info("Unable to read the embedded task definitions:", err)
+		return
+	}
+	for _, err := range registerFS(definitions, registrationFunc) {
+		log.Info("Invalid embedded task definition:", err)
+	}
+}
+
+// RegisterDirWith - will register the task definitions (*.yml and *.yaml files) found in dir,
+// returning an error for every file or definition that could not be used
+func RegisterDirWith(dir string, registrationFunc func(tasks.Task, bool)) []error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return []error{err}
+	}
+	if !info.IsDir() {
+		return []error{errors.New(dir + " is not a directory")}
+	}
+	log.Debug("Registering declarative tasks from", dir)
+	return registerFS(os.DirFS(dir), registrationFunc)
+}
+
+func registerFS(fsys fs.FS, registrationFunc func(tasks.Task, bool)) []error {
+	var errs []error
+	names, err := fs.Glob(fsys, "*.y*ml")
+	if err != nil {
+		return []error{err}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if ext := path.Ext(name); ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		definitions, err := Parse(content)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		for _, definition := range definitions {
+			if !supportsOS(definition.OS) {
+				log.Debugf("Skipping %s, it does not run on %s\n", definition.Identifier, runtime.GOOS)
+				continue
+			}
+			task, err := Compile(definition)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			registrationFunc(task, definition.RunByDefault)
+		}
+	}
+	return errs
+}
+
+// Parse reads the task definitions in a YAML file. A file can hold several definitions
+// separated by '---'.
+func Parse(content []byte) ([]Definition, error) {
+	var definitions []Definition
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	for {
+		var definition Definition
+		err := decoder.Decode(&definition)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(definitions) == 0 {
+		return nil, errors.New("no task definitions found")
+	}
+	return definitions, nil
+}
+
+func supportsOS(systems []string) bool {
+	if len(systems) == 0 {
+		return true
+	}
+	for _, system := range systems {
+		if strings.EqualFold(system, runtime.GOOS) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,364 @@
+package declarative
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+func mustCompile(t *testing.T, content string) DeclarativeTask {
+	t.Helper()
+	definitions, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	task, err := Compile(definitions[0])
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return task
+}
+
+func Test_Parse(t *testing.T) {
+	definitions, err := Parse([]byte(`
+identifier: Custom/Check/One
+explain: first
+checks:
+  - env: {name: NEW_RELIC_APP_NAME}
+---
+identifier: Custom/Check/Two
+explain: second
+checks:
+  - files: {patterns: ['newrelic\.yml']}
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(definitions) != 2 || definitions[1].Identifier != "Custom/Check/Two" {
+		t.Errorf("Parse() = %+v, want two definitions", definitions)
+	}
+
+	if _, err := Parse([]byte("identifier: Custom/Check/One\nexplian: typo\n")); err == nil {
+		t.Errorf("Parse() expected an error for an unknown field")
+	}
+	if _, err := Parse([]byte("")); err == nil {
+		t.Errorf("Parse() expected an error for an empty file")
+	}
+}
+
+func Test_Compile(t *testing.T) {
+	valid := Check{Env: &EnvSource{Name: "NEW_RELIC_APP_NAME"}}
+	tests := []struct {
+		name       string
+		definition Definition
+		wantErr    string
+	}{
+		{name: "valid", definition: Definition{Identifier: "Custom/Check/Valid", Explain: "x", Checks: []Check{valid}}},
+		{name: "bad identifier", definition: Definition{Identifier: "Custom/Check", Explain: "x", Checks: []Check{valid}}, wantErr: "Category/Subcategory/Name"},
+		{name: "missing explain", definition: Definition{Identifier: "Custom/Check/X", Checks: []Check{valid}}, wantErr: "explain"},
+		{name: "no checks", definition: Definition{Identifier: "Custom/Check/X", Explain: "x"}, wantErr: "at least one check"},
+		{
+			name:       "two sources",
+			definition: Definition{Identifier: "Custom/Check/X", Explain: "x", Checks: []Check{{Env: valid.Env, Command: &CommandSource{Name: "java"}}}},
+			wantErr:    "exactly one",
+		},
+		{
+			name:       "bad regex",
+			definition: Definition{Identifier: "Custom/Check/X", Explain: "x", Checks: []Check{{Env: valid.Env, Matches: "("}}},
+			wantErr:    "invalid matches regex",
+		},
+		{
+			name:       "bad version",
+			definition: Definition{Identifier: "Custom/Check/X", Explain: "x", Checks: []Check{{Env: valid.Env, Version: []string{"1.0+", "one"}}}},
+			wantErr:    "invalid version requirement",
+		},
+		{
+			name:       "bad status",
+			definition: Definition{Identifier: "Custom/Check/X", Explain: "x", Checks: []Check{{Env: valid.Env, Status: "panic"}}},
+			wantErr:    "unknown status",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.definition)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Compile() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_embeddedDefinitions(t *testing.T) {
+	definitions, _ := fs.Sub(embeddedDefinitions, "definitions")
+	var registered []string
+	errs := registerFS(definitions, func(task tasks.Task, runByDefault bool) {
+		registered = append(registered, task.Identifier().String())
+		if runByDefault {
+			t.Errorf("%s: embedded definitions should be opt-in", task.Identifier())
+		}
+	})
+	if len(errs) > 0 {
+		t.Errorf("embedded definitions failed to compile: %v", errs)
+	}
+	if !tasks.ContainsString(registered, "Base/Config/AuditLog") {
+		t.Errorf("expected Base/Config/AuditLog to be registered, got %v", registered)
+	}
+}
+
+func Test_RegisterDirWith(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "good.yaml"), []byte("identifier: Custom/Check/Good\nexplain: x\nrunByDefault: true\nchecks:\n  - env: {name: X}\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "bad.yml"), []byte("identifier: Custom/Check/Bad\nexplain: x\nchecks: []\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a definition"), 0600)
+
+	registered := map[string]bool{}
+	errs := RegisterDirWith(dir, func(task tasks.Task, runByDefault bool) {
+		registered[task.Identifier().String()] = runByDefault
+	})
+	if !reflect.DeepEqual(registered, map[string]bool{"Custom/Check/Good": true}) {
+		t.Errorf("RegisterDirWith() registered %v", registered)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "bad.yml") {
+		t.Errorf("RegisterDirWith() errors = %v, want one for bad.yml", errs)
+	}
+
+	if errs := RegisterDirWith(filepath.Join(dir, "missing"), nil); len(errs) != 1 {
+		t.Errorf("RegisterDirWith() expected an error for a missing directory")
+	}
+}
+
+func Test_DeclarativeTask_Dependencies(t *testing.T) {
+	task := mustCompile(t, `
+identifier: Custom/Check/Deps
+explain: x
+dependencies: [Java/Env/Process]
+requires: [Java/Env/Process, Java/Agent/Version]
+checks:
+  - config: {keys: [log_level]}
+  - env: {name: JAVA_HOME}
+  - config: {keys: [app_name]}
+`)
+	want := []string{"Java/Env/Process", "Java/Agent/Version", "Base/Config/Validate", "Base/Env/CollectEnvVars"}
+	if got := task.Dependencies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies() = %v, want %v", got, want)
+	}
+}
+
+func validateResult() tasks.Result {
+	blob := tasks.ValidateBlob{
+		Children: []tasks.ValidateBlob{
+			{Key: "common", Path: "", Children: []tasks.ValidateBlob{
+				{Key: "log_level", Path: "/common", RawValue: "finest"},
+				{Key: "app_name", Path: "/common", RawValue: "My App"},
+			}},
+			{Key: "production", Path: "", Children: []tasks.ValidateBlob{
+				{Key: "log_level", Path: "/production", RawValue: "info"},
+			}},
+		},
+	}
+	return tasks.Result{
+		Status: tasks.Success,
+		Payload: []baseConfig.ValidateElement{
+			{Config: baseConfig.ConfigElement{FileName: "newrelic.yml", FilePath: "/app/"}, ParsedResult: blob},
+		},
+	}
+}
+
+func Test_DeclarativeTask_Execute(t *testing.T) {
+	upstream := map[string]tasks.Result{
+		"Base/Config/Validate":    validateResult(),
+		"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{"NEW_RELIC_APP_NAME": "env-app"}},
+	}
+
+	tests := []struct {
+		name        string
+		definition  string
+		upstream    map[string]tasks.Result
+		setup       func(*DeclarativeTask)
+		wantStatus  tasks.Status
+		wantSummary string
+		wantFiles   int
+	}{
+		{
+			name: "config key failing a notMatches assertion",
+			definition: `
+identifier: Custom/Check/LogLevel
+explain: x
+url: https://docs.newrelic.com
+checks:
+  - name: Log level
+    config: {keys: [log_level]}
+    notMatches: '(?i)^finest$'
+    status: warning
+    summary: "Verbose logging found: {{values}}"
+`,
+			wantStatus:  tasks.Warning,
+			wantSummary: "Verbose logging found: finest",
+		},
+		{
+			name: "config key limited by path",
+			definition: `
+identifier: Custom/Check/LogLevel
+explain: x
+checks:
+  - config: {keys: [production/log_level]}
+    equals: INFO
+    success: Production logs at info
+`,
+			wantStatus:  tasks.Success,
+			wantSummary: "Production logs at info",
+		},
+		{
+			name: "missing config key fails unless optional",
+			definition: `
+identifier: Custom/Check/Missing
+explain: x
+checks:
+  - config: {keys: [license_key]}
+`,
+			wantStatus:  tasks.Failure,
+			wantSummary: "No config setting license_key found.",
+		},
+		{
+			name: "optional checks that find nothing are skipped",
+			definition: `
+identifier: Custom/Check/Missing
+explain: x
+checks:
+  - config: {keys: [license_key]}
+    optional: true
+`,
+			wantStatus:  tasks.None,
+			wantSummary: "None of the checks for Custom/Check/Missing applied to this system.",
+		},
+		{
+			name: "env var",
+			definition: `
+identifier: Custom/Check/Env
+explain: x
+checks:
+  - env: {name: NEW_RELIC_APP_NAME}
+    matches: '^env-'
+`,
+			wantStatus:  tasks.Success,
+			wantSummary: "environment variable NEW_RELIC_APP_NAME: passed.",
+		},
+		{
+			name: "command output version",
+			definition: `
+identifier: Custom/Check/JavaVersion
+explain: x
+checks:
+  - name: Java version
+    command: {name: java, args: [-version], regex: 'version "([^"]+)"'}
+    version: ["11+"]
+`,
+			setup: func(task *DeclarativeTask) {
+				task.executeCommand = func(name string, arg ...string) ([]byte, error) {
+					return []byte("openjdk version \"1.8.0_292\"\nOpenJDK Runtime Environment"), nil
+				}
+			},
+			wantStatus:  tasks.Failure,
+			wantSummary: "Java version: version 1.8.0 is not in 11+",
+		},
+		{
+			name: "command not installed",
+			definition: `
+identifier: Custom/Check/JavaVersion
+explain: x
+checks:
+  - command: {name: java, args: [-version]}
+    optional: true
+`,
+			setup: func(task *DeclarativeTask) {
+				task.executeCommand = func(name string, arg ...string) ([]byte, error) {
+					return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+				}
+			},
+			wantStatus:  tasks.None,
+			wantSummary: "None of the checks for Custom/Check/JavaVersion applied to this system.",
+		},
+		{
+			name: "command failing without output",
+			definition: `
+identifier: Custom/Check/Broken
+explain: x
+checks:
+  - command: {name: kubectl, args: [version]}
+`,
+			setup: func(task *DeclarativeTask) {
+				task.executeCommand = func(name string, arg ...string) ([]byte, error) {
+					return nil, errors.New("signal: killed")
+				}
+			},
+			wantStatus:  tasks.Error,
+			wantSummary: "Unable to evaluate command kubectl version: signal: killed",
+		},
+		{
+			name: "files are collected and the worst check wins",
+			definition: `
+identifier: Custom/Check/Files
+explain: x
+checks:
+  - files: {patterns: ['newrelic_agent\.log'], paths: [/var/log], collect: true}
+    success: Found agent logs
+  - env: {name: NEW_RELIC_LICENSE_KEY}
+    status: info
+    summary: License key is not set in the environment
+`,
+			setup: func(task *DeclarativeTask) {
+				task.findFiles = func(patterns []string, paths []string) []string {
+					return []string{"/var/log/newrelic_agent.log", "/var/log/newrelic_agent.log.1"}
+				}
+			},
+			wantStatus:  tasks.Info,
+			wantSummary: "License key is not set in the environment",
+			wantFiles:   2,
+		},
+		{
+			name: "requires a successful upstream task",
+			definition: `
+identifier: Custom/Check/Java
+explain: x
+requires: [Java/Env/Process]
+checks:
+  - env: {name: JAVA_HOME}
+`,
+			wantStatus:  tasks.None,
+			wantSummary: "Java/Env/Process did not succeed; this task did not run.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := mustCompile(t, tt.definition)
+			task.getWorkingDirs = func() []string { return []string{"."} }
+			if tt.setup != nil {
+				tt.setup(&task)
+			}
+			result := task.Execute(tasks.Options{}, upstream)
+			if result.Status != tt.wantStatus {
+				t.Errorf("Execute() status = %v, want %v (%s)", result.Status, tt.wantStatus, result.Summary)
+			}
+			if result.Summary != tt.wantSummary {
+				t.Errorf("Execute() summary = %q, want %q", result.Summary, tt.wantSummary)
+			}
+			if len(result.FilesToCopy) != tt.wantFiles {
+				t.Errorf("Execute() collected %d files, want %d", len(result.FilesToCopy), tt.wantFiles)
+			}
+		})
+	}
+}
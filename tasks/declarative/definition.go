@@ -0,0 +1,224 @@
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	configValidateTask = "Base/Config/Validate"
+	collectEnvVarsTask = "Base/Env/CollectEnvVars"
+)
+
+// Definition is a task described in YAML. It compiles to a tasks.Task whose result is the
+// worst outcome among its checks.
+type Definition struct {
+	Identifier   string   `yaml:"identifier"`
+	Explain      string   `yaml:"explain"`
+	URL          string   `yaml:"url"`
+	RunByDefault bool     `yaml:"runByDefault"`
+	OS           []string `yaml:"os"`           // GOOS values the task registers on; empty means every platform
+	Dependencies []string `yaml:"dependencies"` // extra dependencies, on top of the ones the checks need
+	Requires     []string `yaml:"requires"`     // dependencies that must succeed, otherwise the task returns None
+	Checks       []Check  `yaml:"checks"`
+}
+
+// Check is a single step of a declarative task. It takes values from exactly one source
+// (files, config, env or command) and every value has to pass the assertions.
+type Check struct {
+	Name    string         `yaml:"name"`
+	Files   *FilesSource   `yaml:"files"`
+	Config  *ConfigSource  `yaml:"config"`
+	Env     *EnvSource     `yaml:"env"`
+	Command *CommandSource `yaml:"command"`
+
+	Matches    string   `yaml:"matches"`    // regex every value must match
+	NotMatches string   `yaml:"notMatches"` // regex no value may match
+	Equals     string   `yaml:"equals"`     // exact value, compared case insensitively
+	Version    []string `yaml:"version"`    // requirements in tasks.VersionIsCompatible format, e.g. "1.2+" or "4.0-7.4"
+
+	Optional bool   `yaml:"optional"` // a source without values skips the check instead of failing it
+	Status   string `yaml:"status"`   // status when the check fails: info, warning, failure (default) or error
+	Summary  string `yaml:"summary"`  // summary when the check fails, {{values}} is replaced by the offending values
+	Success  string `yaml:"success"`  // summary when the check passes
+}
+
+// FilesSource finds files with tasks.FindFiles; the values are the paths that were found
+type FilesSource struct {
+	Patterns []string `yaml:"patterns"` // regexes matched against file names
+	Paths    []string `yaml:"paths"`    // directories to walk, defaults to the working directories
+	Collect  bool     `yaml:"collect"`  // add the files to nrdiag-output.zip
+}
+
+// ConfigSource reads keys from the config files parsed by Base/Config/Validate with ValidateBlob.FindKey
+type ConfigSource struct {
+	Keys []string `yaml:"keys"` // key names; a name containing '/' is matched against the key path as well
+	File string   `yaml:"file"` // optional regex the config file name has to match
+}
+
+// EnvSource reads an environment variable collected by Base/Env/CollectEnvVars
+type EnvSource struct {
+	Name string `yaml:"name"`
+}
+
+// CommandSource runs a command; the values are the regex matches in its output
+type CommandSource struct {
+	Name  string   `yaml:"name"`
+	Args  []string `yaml:"args"`
+	Regex string   `yaml:"regex"` // the first capture group, or the whole match, of every match becomes a value. Defaults to the trimmed output
+}
+
+type compiledCheck struct {
+	Check
+	failStatus tasks.Status
+	matches    *regexp.Regexp
+	notMatches *regexp.Regexp
+	fileName   *regexp.Regexp
+	output     *regexp.Regexp
+}
+
+var failStatuses = map[string]tasks.Status{
+	"":        tasks.Failure,
+	"info":    tasks.Info,
+	"warning": tasks.Warning,
+	"failure": tasks.Failure,
+	"error":   tasks.Error,
+}
+
+// Compile validates a definition and turns it into a task
+func Compile(definition Definition) (DeclarativeTask, error) {
+	task := DeclarativeTask{
+		definition:          definition,
+		findFiles:           tasks.FindFiles,
+		getWorkingDirs:      tasks.GetWorkingDirectories,
+		executeCommand:      tasks.CmdExecutor,
+		versionIsCompatible: tasks.VersionIsCompatible,
+	}
+
+	if strings.Count(definition.Identifier, "/") != 2 || strings.Contains(definition.Identifier, "//") ||
+		strings.HasPrefix(definition.Identifier, "/") || strings.HasSuffix(definition.Identifier, "/") {
+		return task, fmt.Errorf("identifier %q must have the form Category/Subcategory/Name", definition.Identifier)
+	}
+	if strings.TrimSpace(definition.Explain) == "" {
+		return task, fmt.Errorf("%s: explain is required", definition.Identifier)
+	}
+	if len(definition.Checks) == 0 {
+		return task, fmt.Errorf("%s: at least one check is required", definition.Identifier)
+	}
+
+	for i, check := range definition.Checks {
+		compiled, err := compileCheck(check)
+		if err != nil {
+			name := check.Name
+			if name == "" {
+				name = fmt.Sprintf("check %d", i+1)
+			}
+			return task, fmt.Errorf("%s: %s: %w", definition.Identifier, name, err)
+		}
+		task.checks = append(task.checks, compiled)
+	}
+	return task, nil
+}
+
+func compileCheck(check Check) (compiledCheck, error) {
+	compiled := compiledCheck{Check: check}
+
+	sources := 0
+	for _, set := range []bool{check.Files != nil, check.Config != nil, check.Env != nil, check.Command != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return compiled, errors.New("exactly one of files, config, env or command is required")
+	}
+
+	status, ok := failStatuses[strings.ToLower(check.Status)]
+	if !ok {
+		return compiled, fmt.Errorf("unknown status %q", check.Status)
+	}
+	compiled.failStatus = status
+
+	var err error
+	switch {
+	case check.Files != nil:
+		if len(check.Files.Patterns) == 0 {
+			return compiled, errors.New("files needs at least one pattern")
+		}
+		for _, pattern := range check.Files.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return compiled, fmt.Errorf("invalid file pattern: %w", err)
+			}
+		}
+	case check.Config != nil:
+		if len(check.Config.Keys) == 0 {
+			return compiled, errors.New("config needs at least one key")
+		}
+		if compiled.fileName, err = compileOptional(check.Config.File); err != nil {
+			return compiled, fmt.Errorf("invalid config file regex: %w", err)
+		}
+	case check.Env != nil:
+		if check.Env.Name == "" {
+			return compiled, errors.New("env needs a name")
+		}
+	case check.Command != nil:
+		if check.Command.Name == "" {
+			return compiled, errors.New("command needs a name")
+		}
+		if compiled.output, err = compileOptional(check.Command.Regex); err != nil {
+			return compiled, fmt.Errorf("invalid command regex: %w", err)
+		}
+	}
+
+	if compiled.matches, err = compileOptional(check.Matches); err != nil {
+		return compiled, fmt.Errorf("invalid matches regex: %w", err)
+	}
+	if compiled.notMatches, err = compileOptional(check.NotMatches); err != nil {
+		return compiled, fmt.Errorf("invalid notMatches regex: %w", err)
+	}
+	for _, requirement := range check.Version {
+		// a valid requirement accepts or rejects a version without an error
+		if _, err := tasks.VersionIsCompatible("1.0", []string{requirement}); err != nil {
+			return compiled, fmt.Errorf("invalid version requirement %q: %w", requirement, err)
+		}
+	}
+	return compiled, nil
+}
+
+func compileOptional(expression string) (*regexp.Regexp, error) {
+	if expression == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expression)
+}
+
+// dependencies returns the tasks a check reads from
+func (c compiledCheck) dependencies() []string {
+	switch {
+	case c.Config != nil:
+		return []string{configValidateTask}
+	case c.Env != nil:
+		return []string{collectEnvVarsTask}
+	}
+	return nil
+}
+
+// label names the check in summaries
+func (c compiledCheck) label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	switch {
+	case c.Files != nil:
+		return "files matching " + strings.Join(c.Files.Patterns, ", ")
+	case c.Config != nil:
+		return "config setting " + strings.Join(c.Config.Keys, ", ")
+	case c.Env != nil:
+		return "environment variable " + c.Env.Name
+	}
+	return "command " + strings.TrimSpace(c.Command.Name+" "+strings.Join(c.Command.Args, " "))
+}
@@ -0,0 +1,307 @@
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// DeclarativeTask - a task compiled from a YAML Definition
+type DeclarativeTask struct {
+	definition          Definition
+	checks              []compiledCheck
+	findFiles           func([]string, []string) []string
+	getWorkingDirs      tasks.GetWorkingDirectoriesFunc
+	executeCommand      tasks.CmdExecFunc
+	versionIsCompatible tasks.VersionIsCompatibleFunc
+}
+
+// CheckResult - the outcome of one check of a declarative task
+type CheckResult struct {
+	Check   string
+	Status  tasks.Status
+	Summary string
+	Values  []string
+}
+
+var versionRegex = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p DeclarativeTask) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString(p.definition.Identifier)
+}
+
+// Explain - Returns the help text for each individual task
+func (p DeclarativeTask) Explain() string {
+	return p.definition.Explain
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p DeclarativeTask) Dependencies() []string {
+	candidates := append([]string{}, p.definition.Dependencies...)
+	candidates = append(candidates, p.definition.Requires...)
+	for _, check := range p.checks {
+		candidates = append(candidates, check.dependencies()...)
+	}
+	dependencies := []string{}
+	for _, dependency := range candidates {
+		if !tasks.ContainsString(dependencies, dependency) {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
+}
+
+// Execute - The core work within each task
+func (p DeclarativeTask) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	for _, required := range p.definition.Requires {
+		status := upstream[required].Status
+		if status != tasks.Success && status != tasks.Warning && status != tasks.Info {
+			return tasks.Result{
+				Status:  tasks.None,
+				Summary: required + " did not succeed; this task did not run.",
+			}
+		}
+	}
+
+	var (
+		results     []CheckResult
+		filesToCopy []tasks.FileCopyEnvelope
+	)
+	for _, check := range p.checks {
+		result, files := p.runCheck(check, upstream)
+		results = append(results, result)
+		filesToCopy = append(filesToCopy, files...)
+	}
+
+	result := tasks.Result{
+		Status:      tasks.None,
+		FilesToCopy: filesToCopy,
+		Payload:     results,
+	}
+	var passed, problems []string
+	for _, checkResult := range results {
+		if severity(checkResult.Status) > severity(result.Status) {
+			result.Status = checkResult.Status
+		}
+		switch checkResult.Status {
+		case tasks.Success:
+			passed = append(passed, checkResult.Summary)
+		case tasks.None:
+		default:
+			problems = append(problems, checkResult.Summary)
+		}
+	}
+
+	switch {
+	case result.Status == tasks.None:
+		result.Summary = "None of the checks for " + p.definition.Identifier + " applied to this system."
+	case len(problems) > 0:
+		result.Summary = strings.Join(problems, "\n")
+		result.URL = p.definition.URL
+	default:
+		result.Summary = strings.Join(passed, "\n")
+	}
+	return result
+}
+
+// severity orders statuses so that the worst check decides the task status
+func severity(status tasks.Status) int {
+	switch status {
+	case tasks.Success:
+		return 1
+	case tasks.Info:
+		return 2
+	case tasks.Warning:
+		return 3
+	case tasks.Failure:
+		return 4
+	case tasks.Error:
+		return 5
+	}
+	return 0
+}
+
+func (p DeclarativeTask) runCheck(check compiledCheck, upstream map[string]tasks.Result) (CheckResult, []tasks.FileCopyEnvelope) {
+	result := CheckResult{Check: check.label()}
+
+	values, files, err := p.gatherValues(check, upstream)
+	if err != nil {
+		result.Status = tasks.Error
+		result.Summary = fmt.Sprintf("Unable to evaluate %s: %s", check.label(), err.Error())
+		return result, nil
+	}
+	result.Values = values
+
+	if len(values) == 0 {
+		if check.Optional {
+			result.Status = tasks.None
+			result.Summary = "Skipped " + check.label() + ": nothing found."
+			return result, files
+		}
+		result.Status = check.failStatus
+		result.Summary = check.failSummary("No "+check.label()+" found.", nil)
+		return result, files
+	}
+
+	var failed []string
+	var reasons []string
+	for _, value := range values {
+		if reason := p.assert(check, value); reason != "" {
+			failed = append(failed, value)
+			reasons = append(reasons, reason)
+		}
+	}
+	if len(failed) > 0 {
+		result.Status = check.failStatus
+		result.Summary = check.failSummary(check.label()+": "+strings.Join(reasons, "; "), failed)
+		return result, files
+	}
+
+	result.Status = tasks.Success
+	result.Summary = check.Success
+	if result.Summary == "" {
+		result.Summary = check.label() + ": passed."
+	}
+	return result, files
+}
+
+// assert returns why value fails the check's assertions, or an empty string when it passes
+func (p DeclarativeTask) assert(check compiledCheck, value string) string {
+	if check.Equals != "" && !strings.EqualFold(value, check.Equals) {
+		return fmt.Sprintf("%q is not %q", value, check.Equals)
+	}
+	if check.matches != nil && !check.matches.MatchString(value) {
+		return fmt.Sprintf("%q does not match %s", value, check.Matches)
+	}
+	if check.notMatches != nil && check.notMatches.MatchString(value) {
+		return fmt.Sprintf("%q matches %s", value, check.NotMatches)
+	}
+	if len(check.Version) > 0 {
+		version := versionRegex.FindString(value)
+		if version == "" {
+			return fmt.Sprintf("%q is not a version", value)
+		}
+		compatible, err := p.versionIsCompatible(version, check.Version)
+		if err != nil {
+			return fmt.Sprintf("unable to compare version %s: %s", version, err.Error())
+		}
+		if !compatible {
+			return fmt.Sprintf("version %s is not in %s", version, strings.Join(check.Version, ", "))
+		}
+	}
+	return ""
+}
+
+func (c compiledCheck) failSummary(fallback string, values []string) string {
+	if c.Summary == "" {
+		return fallback
+	}
+	return strings.ReplaceAll(c.Summary, "{{values}}", strings.Join(values, ", "))
+}
+
+func (p DeclarativeTask) gatherValues(check compiledCheck, upstream map[string]tasks.Result) ([]string, []tasks.FileCopyEnvelope, error) {
+	switch {
+	case check.Files != nil:
+		paths := check.Files.Paths
+		if len(paths) == 0 {
+			paths = p.getWorkingDirs()
+		}
+		found := p.findFiles(check.Files.Patterns, paths)
+		var files []tasks.FileCopyEnvelope
+		if check.Files.Collect {
+			files = tasks.StringsToFileCopyEnvelopes(found)
+		}
+		return found, files, nil
+
+	case check.Config != nil:
+		values, err := configValues(check, upstream[configValidateTask])
+		return values, nil, err
+
+	case check.Env != nil:
+		envVars, ok := upstream[collectEnvVarsTask].Payload.(map[string]string)
+		if !ok {
+			if upstream[collectEnvVarsTask].Status == tasks.None {
+				return nil, nil, nil
+			}
+			return nil, nil, errors.New(tasks.AssertionErrorSummary)
+		}
+		if value, found := envVars[check.Env.Name]; found {
+			return []string{value}, nil, nil
+		}
+		return nil, nil, nil
+
+	default:
+		values, err := p.commandValues(check)
+		return values, nil, err
+	}
+}
+
+func configValues(check compiledCheck, validate tasks.Result) ([]string, error) {
+	if validate.Status == tasks.None || validate.Payload == nil {
+		return nil, nil
+	}
+	elements, ok := validate.Payload.([]baseConfig.ValidateElement)
+	if !ok {
+		return nil, errors.New(tasks.AssertionErrorSummary)
+	}
+
+	var values []string
+	for _, element := range elements {
+		if check.fileName != nil && !check.fileName.MatchString(element.Config.FileName) {
+			continue
+		}
+		for _, key := range check.Config.Keys {
+			searchKey := key
+			if index := strings.LastIndex(key, "/"); index >= 0 {
+				searchKey = key[index+1:]
+			}
+			for _, blob := range element.ParsedResult.FindKey(searchKey) {
+				if searchKey != key && !strings.Contains(strings.ToLower(blob.PathAndKey()), strings.ToLower(key)) {
+					continue
+				}
+				values = append(values, blob.Value())
+			}
+		}
+	}
+	return values, nil
+}
+
+func (p DeclarativeTask) commandValues(check compiledCheck) ([]string, error) {
+	output, err := p.executeCommand(check.Command.Name, check.Command.Args...)
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			// the command is not installed, which usually means the product is not either
+			log.Debug("Declarative task command not available:", err)
+			return nil, nil
+		}
+		if len(output) == 0 {
+			return nil, err
+		}
+		// a non-zero exit code still leaves output worth checking
+		log.Debug("Declarative task command exited with", err)
+	}
+
+	if check.output == nil {
+		if trimmed := strings.TrimSpace(string(output)); trimmed != "" {
+			return []string{trimmed}, nil
+		}
+		return nil, nil
+	}
+	var values []string
+	for _, match := range check.output.FindAllStringSubmatch(string(output), -1) {
+		if len(match) > 1 {
+			values = append(values, match[1])
+		} else {
+			values = append(values, match[0])
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,508 @@
+package custominstrumentation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// AssemblyMetadata - the assembly name and the types and methods defined in a .NET DLL
+type AssemblyMetadata struct {
+	Name string
+	// Types has full type names as keys, nested types are separated from their enclosing type with +
+	Types map[string][]string
+}
+
+var (
+	errNotManagedAssembly = errors.New("file is not a .NET assembly")
+	errInvalidMetadata    = errors.New("invalid CLI metadata")
+)
+
+// These are the metadata table numbers from ECMA-335 II.22 used to read types and methods
+const (
+	tableModule                 = 0x00
+	tableTypeRef                = 0x01
+	tableTypeDef                = 0x02
+	tableFieldPtr               = 0x03
+	tableField                  = 0x04
+	tableMethodPtr              = 0x05
+	tableMethodDef              = 0x06
+	tableParamPtr               = 0x07
+	tableParam                  = 0x08
+	tableInterfaceImpl          = 0x09
+	tableMemberRef              = 0x0A
+	tableConstant               = 0x0B
+	tableCustomAttribute        = 0x0C
+	tableFieldMarshal           = 0x0D
+	tableDeclSecurity           = 0x0E
+	tableClassLayout            = 0x0F
+	tableFieldLayout            = 0x10
+	tableStandAloneSig          = 0x11
+	tableEventMap               = 0x12
+	tableEventPtr               = 0x13
+	tableEvent                  = 0x14
+	tablePropertyMap            = 0x15
+	tablePropertyPtr            = 0x16
+	tableProperty               = 0x17
+	tableMethodSemantics        = 0x18
+	tableMethodImpl             = 0x19
+	tableModuleRef              = 0x1A
+	tableTypeSpec               = 0x1B
+	tableImplMap                = 0x1C
+	tableFieldRVA               = 0x1D
+	tableEncLog                 = 0x1E
+	tableEncMap                 = 0x1F
+	tableAssembly               = 0x20
+	tableAssemblyProcessor      = 0x21
+	tableAssemblyOS             = 0x22
+	tableAssemblyRef            = 0x23
+	tableAssemblyRefProcessor   = 0x24
+	tableAssemblyRefOS          = 0x25
+	tableFile                   = 0x26
+	tableExportedType           = 0x27
+	tableManifestResource       = 0x28
+	tableNestedClass            = 0x29
+	tableGenericParam           = 0x2A
+	tableMethodSpec             = 0x2B
+	tableGenericParamConstraint = 0x2C
+	tableCount                  = 0x2D
+)
+
+// column kinds of the metadata tables, fixed sizes are the number of bytes
+type columnKind struct {
+	size   int
+	heap   byte
+	tables []int
+}
+
+const (
+	heapString = 0x01
+	heapGUID   = 0x02
+	heapBlob   = 0x04
+)
+
+func fixed(size int) columnKind           { return columnKind{size: size} }
+func heapIndex(heap byte) columnKind      { return columnKind{heap: heap} }
+func tableIndex(table int) columnKind     { return columnKind{tables: []int{table}} }
+func codedIndex(tables ...int) columnKind { return columnKind{tables: tables} }
+
+var (
+	str  = heapIndex(heapString)
+	guid = heapIndex(heapGUID)
+	blob = heapIndex(heapBlob)
+
+	typeDefOrRef        = codedIndex(tableTypeDef, tableTypeRef, tableTypeSpec)
+	hasConstant         = codedIndex(tableField, tableParam, tableProperty)
+	hasCustomAttribute  = codedIndex(tableMethodDef, tableField, tableTypeRef, tableTypeDef, tableParam, tableInterfaceImpl, tableMemberRef, tableModule, tableDeclSecurity, tableProperty, tableEvent, tableStandAloneSig, tableModuleRef, tableTypeSpec, tableAssembly, tableAssemblyRef, tableFile, tableExportedType, tableManifestResource, tableGenericParam, tableGenericParamConstraint, tableMethodSpec)
+	hasFieldMarshal     = codedIndex(tableField, tableParam)
+	hasDeclSecurity     = codedIndex(tableTypeDef, tableMethodDef, tableAssembly)
+	memberRefParent     = codedIndex(tableTypeDef, tableTypeRef, tableModuleRef, tableMethodDef, tableTypeSpec)
+	hasSemantics        = codedIndex(tableEvent, tableProperty)
+	methodDefOrRef      = codedIndex(tableMethodDef, tableMemberRef)
+	memberForwarded     = codedIndex(tableField, tableMethodDef)
+	implementation      = codedIndex(tableFile, tableAssemblyRef, tableExportedType)
+	customAttributeType = codedIndex(-1, -1, tableMethodDef, tableMemberRef, -1)
+	resolutionScope     = codedIndex(tableModule, tableModuleRef, tableAssemblyRef, tableTypeRef)
+	typeOrMethodDef     = codedIndex(tableTypeDef, tableMethodDef)
+)
+
+// tableSchemas - the columns of every table in ECMA-335 II.22, needed to find where each table starts
+var tableSchemas = [tableCount][]columnKind{
+	tableModule:                 {fixed(2), str, guid, guid, guid},
+	tableTypeRef:                {resolutionScope, str, str},
+	tableTypeDef:                {fixed(4), str, str, typeDefOrRef, tableIndex(tableField), tableIndex(tableMethodDef)},
+	tableFieldPtr:               {tableIndex(tableField)},
+	tableField:                  {fixed(2), str, blob},
+	tableMethodPtr:              {tableIndex(tableMethodDef)},
+	tableMethodDef:              {fixed(4), fixed(2), fixed(2), str, blob, tableIndex(tableParam)},
+	tableParamPtr:               {tableIndex(tableParam)},
+	tableParam:                  {fixed(2), fixed(2), str},
+	tableInterfaceImpl:          {tableIndex(tableTypeDef), typeDefOrRef},
+	tableMemberRef:              {memberRefParent, str, blob},
+	tableConstant:               {fixed(2), hasConstant, blob},
+	tableCustomAttribute:        {hasCustomAttribute, customAttributeType, blob},
+	tableFieldMarshal:           {hasFieldMarshal, blob},
+	tableDeclSecurity:           {fixed(2), hasDeclSecurity, blob},
+	tableClassLayout:            {fixed(2), fixed(4), tableIndex(tableTypeDef)},
+	tableFieldLayout:            {fixed(4), tableIndex(tableField)},
+	tableStandAloneSig:          {blob},
+	tableEventMap:               {tableIndex(tableTypeDef), tableIndex(tableEvent)},
+	tableEventPtr:               {tableIndex(tableEvent)},
+	tableEvent:                  {fixed(2), str, typeDefOrRef},
+	tablePropertyMap:            {tableIndex(tableTypeDef), tableIndex(tableProperty)},
+	tablePropertyPtr:            {tableIndex(tableProperty)},
+	tableProperty:               {fixed(2), str, blob},
+	tableMethodSemantics:        {fixed(2), tableIndex(tableMethodDef), hasSemantics},
+	tableMethodImpl:             {tableIndex(tableTypeDef), methodDefOrRef, methodDefOrRef},
+	tableModuleRef:              {str},
+	tableTypeSpec:               {blob},
+	tableImplMap:                {fixed(2), memberForwarded, str, tableIndex(tableModuleRef)},
+	tableFieldRVA:               {fixed(4), tableIndex(tableField)},
+	tableEncLog:                 {fixed(4), fixed(4)},
+	tableEncMap:                 {fixed(4)},
+	tableAssembly:               {fixed(4), fixed(2), fixed(2), fixed(2), fixed(2), fixed(4), blob, str, str},
+	tableAssemblyProcessor:      {fixed(4)},
+	tableAssemblyOS:             {fixed(4), fixed(4), fixed(4)},
+	tableAssemblyRef:            {fixed(2), fixed(2), fixed(2), fixed(2), fixed(4), blob, str, str, blob},
+	tableAssemblyRefProcessor:   {fixed(4), tableIndex(tableAssemblyRef)},
+	tableAssemblyRefOS:          {fixed(4), fixed(4), fixed(4), tableIndex(tableAssemblyRef)},
+	tableFile:                   {fixed(4), str, blob},
+	tableExportedType:           {fixed(4), fixed(4), str, str, implementation},
+	tableManifestResource:       {fixed(4), fixed(4), str, implementation},
+	tableNestedClass:            {tableIndex(tableTypeDef), tableIndex(tableTypeDef)},
+	tableGenericParam:           {fixed(2), fixed(2), typeOrMethodDef, str},
+	tableMethodSpec:             {methodDefOrRef, blob},
+	tableGenericParamConstraint: {tableIndex(tableGenericParam), typeDefOrRef},
+}
+
+// metadataTables - the #~ stream split into rows, with the heaps needed to resolve names
+type metadataTables struct {
+	rows       [tableCount]uint32
+	offsets    [tableCount]int
+	rowSizes   [tableCount]int
+	heapSizes  byte
+	data       []byte
+	stringHeap []byte
+	// err is the first out of range read, cell returns 0 after recording it so corrupt row numbers can't panic
+	err error
+}
+
+// peImage - the sections of a PE file, parsed by hand because debug/pe rejects the OS specific machine
+// types of ReadyToRun images
+type peImage struct {
+	content     []byte
+	directories []byte
+	sections    []peSection
+}
+
+type peSection struct {
+	virtualAddress uint32
+	virtualSize    uint32
+	rawSize        uint32
+	rawOffset      uint32
+}
+
+// comDescriptorEntry - the data directory of the CLI header
+const comDescriptorEntry = 14
+
+// readAssemblyMetadata - reads the assembly name, types and methods from the CLI metadata of a PE file
+func readAssemblyMetadata(content []byte) (AssemblyMetadata, error) {
+	image, err := parsePEImage(content)
+	if err != nil {
+		return AssemblyMetadata{}, err
+	}
+
+	cliHeader, err := image.readDataDirectory(comDescriptorEntry)
+	if err != nil {
+		return AssemblyMetadata{}, err
+	}
+	// the CLI header holds the metadata directory after its size and runtime version
+	if len(cliHeader) < 16 {
+		return AssemblyMetadata{}, errNotManagedAssembly
+	}
+	metadataRVA := binary.LittleEndian.Uint32(cliHeader[8:12])
+	metadataSize := binary.LittleEndian.Uint32(cliHeader[12:16])
+	metadata, err := image.readRVA(metadataRVA, metadataSize)
+	if err != nil {
+		return AssemblyMetadata{}, err
+	}
+
+	tables, err := parseMetadataRoot(metadata)
+	if err != nil {
+		return AssemblyMetadata{}, err
+	}
+	return tables.assemblyMetadata()
+}
+
+func parsePEImage(content []byte) (*peImage, error) {
+	if len(content) < 0x40 || string(content[:2]) != "MZ" {
+		return nil, errNotManagedAssembly
+	}
+	peOffset := int(binary.LittleEndian.Uint32(content[0x3C:]))
+	if peOffset+24 > len(content) || string(content[peOffset:peOffset+4]) != "PE\x00\x00" {
+		return nil, errNotManagedAssembly
+	}
+	coffHeader := content[peOffset+4 : peOffset+24]
+	sectionCount := int(binary.LittleEndian.Uint16(coffHeader[2:]))
+	optionalHeaderSize := int(binary.LittleEndian.Uint16(coffHeader[16:]))
+	optionalHeaderOffset := peOffset + 24
+	if optionalHeaderOffset+optionalHeaderSize > len(content) || optionalHeaderSize < 2 {
+		return nil, errNotManagedAssembly
+	}
+	optionalHeader := content[optionalHeaderOffset : optionalHeaderOffset+optionalHeaderSize]
+
+	// data directories follow the fixed part of the PE32 and PE32+ optional headers
+	directoriesOffset := 0
+	switch binary.LittleEndian.Uint16(optionalHeader) {
+	case 0x10b:
+		directoriesOffset = 96
+	case 0x20b:
+		directoriesOffset = 112
+	default:
+		return nil, errNotManagedAssembly
+	}
+	if directoriesOffset > len(optionalHeader) {
+		return nil, errNotManagedAssembly
+	}
+	image := &peImage{
+		content:     content,
+		directories: optionalHeader[directoriesOffset:],
+	}
+
+	sectionsOffset := optionalHeaderOffset + optionalHeaderSize
+	if sectionsOffset+sectionCount*40 > len(content) {
+		return nil, errNotManagedAssembly
+	}
+	for i := 0; i < sectionCount; i++ {
+		header := content[sectionsOffset+i*40:]
+		image.sections = append(image.sections, peSection{
+			virtualSize:    binary.LittleEndian.Uint32(header[8:]),
+			virtualAddress: binary.LittleEndian.Uint32(header[12:]),
+			rawSize:        binary.LittleEndian.Uint32(header[16:]),
+			rawOffset:      binary.LittleEndian.Uint32(header[20:]),
+		})
+	}
+	return image, nil
+}
+
+func (image *peImage) readDataDirectory(entry int) ([]byte, error) {
+	if (entry+1)*8 > len(image.directories) {
+		return nil, errNotManagedAssembly
+	}
+	rva := binary.LittleEndian.Uint32(image.directories[entry*8:])
+	size := binary.LittleEndian.Uint32(image.directories[entry*8+4:])
+	if rva == 0 {
+		return nil, errNotManagedAssembly
+	}
+	return image.readRVA(rva, size)
+}
+
+func (image *peImage) readRVA(rva uint32, size uint32) ([]byte, error) {
+	for _, section := range image.sections {
+		sectionSize := section.virtualSize
+		if section.rawSize > sectionSize {
+			sectionSize = section.rawSize
+		}
+		if rva < section.virtualAddress || uint64(rva)+uint64(size) > uint64(section.virtualAddress)+uint64(sectionSize) {
+			continue
+		}
+		start := uint64(section.rawOffset) + uint64(rva-section.virtualAddress)
+		if start+uint64(size) > uint64(len(image.content)) {
+			break
+		}
+		return image.content[start : start+uint64(size)], nil
+	}
+	return nil, fmt.Errorf("%w: RVA 0x%x is outside of the sections", errInvalidMetadata, rva)
+}
+
+// parseMetadataRoot - finds the #~ (or uncompressed #-) and #Strings streams of the metadata root, ECMA-335 II.24.2
+func parseMetadataRoot(metadata []byte) (*metadataTables, error) {
+	if len(metadata) < 16 || binary.LittleEndian.Uint32(metadata) != 0x424A5342 {
+		return nil, errNotManagedAssembly
+	}
+	versionLength := int(binary.LittleEndian.Uint32(metadata[12:16]))
+	position := 16 + versionLength + 2
+	if position+2 > len(metadata) {
+		return nil, errInvalidMetadata
+	}
+	streamCount := int(binary.LittleEndian.Uint16(metadata[position:]))
+	position += 2
+
+	streams := map[string][]byte{}
+	for i := 0; i < streamCount; i++ {
+		if position+8 > len(metadata) {
+			return nil, errInvalidMetadata
+		}
+		offset := int(binary.LittleEndian.Uint32(metadata[position:]))
+		size := int(binary.LittleEndian.Uint32(metadata[position+4:]))
+		position += 8
+		nameEnd := bytes.IndexByte(metadata[position:], 0)
+		if nameEnd == -1 || offset+size > len(metadata) {
+			return nil, errInvalidMetadata
+		}
+		name := string(metadata[position : position+nameEnd])
+		// stream names are padded to 4 bytes
+		position += (nameEnd + 4) &^ 3
+		streams[name] = metadata[offset : offset+size]
+	}
+
+	tableStream, ok := streams["#~"]
+	if !ok {
+		tableStream, ok = streams["#-"]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: no metadata table stream", errInvalidMetadata)
+	}
+	return parseTableStream(tableStream, streams["#Strings"])
+}
+
+func parseTableStream(stream []byte, stringHeap []byte) (*metadataTables, error) {
+	if len(stream) < 24 {
+		return nil, errInvalidMetadata
+	}
+	tables := &metadataTables{
+		heapSizes:  stream[6],
+		data:       stream,
+		stringHeap: stringHeap,
+	}
+	valid := binary.LittleEndian.Uint64(stream[8:16])
+	if valid>>tableCount != 0 {
+		return nil, fmt.Errorf("%w: unknown metadata tables", errInvalidMetadata)
+	}
+
+	position := 24
+	for table := 0; table < tableCount; table++ {
+		if valid&(1<<table) == 0 {
+			continue
+		}
+		if position+4 > len(stream) {
+			return nil, errInvalidMetadata
+		}
+		tables.rows[table] = binary.LittleEndian.Uint32(stream[position:])
+		position += 4
+	}
+	// streams with edit and continue data have an extra 4 bytes after the row counts
+	if tables.heapSizes&0x40 != 0 {
+		position += 4
+	}
+
+	for table := 0; table < tableCount; table++ {
+		for _, column := range tableSchemas[table] {
+			tables.rowSizes[table] += tables.columnSize(column)
+		}
+		tables.offsets[table] = position
+		position += tables.rowSizes[table] * int(tables.rows[table])
+	}
+	if position > len(stream) {
+		return nil, fmt.Errorf("%w: tables are larger than the table stream", errInvalidMetadata)
+	}
+	return tables, nil
+}
+
+func (t *metadataTables) columnSize(column columnKind) int {
+	switch {
+	case column.heap != 0:
+		if t.heapSizes&column.heap != 0 {
+			return 4
+		}
+		return 2
+	case column.tables != nil:
+		// coded indexes use the low bits as a tag for the referenced table, II.24.2.6
+		tagBits := 0
+		if len(column.tables) > 1 {
+			for 1<<tagBits < len(column.tables) {
+				tagBits++
+			}
+		}
+		var maxRows uint32
+		for _, table := range column.tables {
+			if table >= 0 && t.rows[table] > maxRows {
+				maxRows = t.rows[table]
+			}
+		}
+		if maxRows < 1<<(16-tagBits) {
+			return 2
+		}
+		return 4
+	}
+	return column.size
+}
+
+// cell - reads a column of a row, rows are 1-based like metadata tokens. Row numbers come from the file
+// itself, so a row that does not exist is recorded in t.err and read as 0
+func (t *metadataTables) cell(table int, row uint32, column int) uint32 {
+	if row < 1 || row > t.rows[table] {
+		t.invalid(fmt.Errorf("%w: row %d of table 0x%02x out of range", errInvalidMetadata, row, table))
+		return 0
+	}
+	position := t.offsets[table] + int(row-1)*t.rowSizes[table]
+	for _, previous := range tableSchemas[table][:column] {
+		position += t.columnSize(previous)
+	}
+	size := t.columnSize(tableSchemas[table][column])
+	if position+size > len(t.data) {
+		t.invalid(fmt.Errorf("%w: row %d of table 0x%02x is outside of the table stream", errInvalidMetadata, row, table))
+		return 0
+	}
+	if size == 4 {
+		return binary.LittleEndian.Uint32(t.data[position:])
+	}
+	return uint32(binary.LittleEndian.Uint16(t.data[position:]))
+}
+
+func (t *metadataTables) invalid(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *metadataTables) stringAt(offset uint32) string {
+	if int(offset) >= len(t.stringHeap) {
+		return ""
+	}
+	end := bytes.IndexByte(t.stringHeap[offset:], 0)
+	if end == -1 {
+		return string(t.stringHeap[offset:])
+	}
+	return string(t.stringHeap[offset : offset+uint32(end)])
+}
+
+// methodRow - resolves a MethodList index through the MethodPtr table of uncompressed streams
+func (t *metadataTables) methodRow(listIndex uint32) uint32 {
+	if t.rows[tableMethodPtr] == 0 {
+		return listIndex
+	}
+	return t.cell(tableMethodPtr, listIndex, 0)
+}
+
+func (t *metadataTables) assemblyMetadata() (AssemblyMetadata, error) {
+	if t.rows[tableAssembly] == 0 {
+		return AssemblyMetadata{}, fmt.Errorf("%w: no assembly manifest", errNotManagedAssembly)
+	}
+	assembly := AssemblyMetadata{
+		Name:  t.stringAt(t.cell(tableAssembly, 1, 7)),
+		Types: map[string][]string{},
+	}
+
+	enclosing := map[uint32]uint32{}
+	for row := uint32(1); row <= t.rows[tableNestedClass]; row++ {
+		enclosing[t.cell(tableNestedClass, row, 0)] = t.cell(tableNestedClass, row, 1)
+	}
+	var typeName func(row uint32, depth int) string
+	typeName = func(row uint32, depth int) string {
+		name := t.stringAt(t.cell(tableTypeDef, row, 1))
+		if parent, ok := enclosing[row]; ok && depth < 16 {
+			return typeName(parent, depth+1) + "+" + name
+		}
+		if namespace := t.stringAt(t.cell(tableTypeDef, row, 2)); namespace != "" {
+			return namespace + "." + name
+		}
+		return name
+	}
+
+	methodCount := t.rows[tableMethodDef]
+	if t.rows[tableMethodPtr] > 0 {
+		methodCount = t.rows[tableMethodPtr]
+	}
+	for row := uint32(1); row <= t.rows[tableTypeDef]; row++ {
+		first := t.cell(tableTypeDef, row, 5)
+		last := methodCount + 1
+		if row < t.rows[tableTypeDef] {
+			last = t.cell(tableTypeDef, row+1, 5)
+		}
+		var methods []string
+		for listIndex := first; listIndex < last && listIndex <= methodCount; listIndex++ {
+			method := t.methodRow(listIndex)
+			if method == 0 || method > t.rows[tableMethodDef] {
+				return AssemblyMetadata{}, fmt.Errorf("%w: method row %d out of range", errInvalidMetadata, method)
+			}
+			methods = append(methods, t.stringAt(t.cell(tableMethodDef, method, 3)))
+		}
+		assembly.Types[typeName(row, 0)] = methods
+	}
+	if t.err != nil {
+		return AssemblyMetadata{}, t.err
+	}
+	return assembly, nil
+}
@@ -0,0 +1,84 @@
+package custominstrumentation
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("readAssemblyMetadata()", func() {
+	It("Should read the assembly name, types and methods of a DLL", func() {
+		content, err := os.ReadFile("fixtures/app/MyApp.Orders.dll")
+		Expect(err).To(BeNil())
+		assembly, err := readAssemblyMetadata(content)
+		Expect(err).To(BeNil())
+		Expect(assembly.Name).To(Equal("MyApp.Orders"))
+		Expect(assembly.Types).To(HaveKeyWithValue("MyApp.Orders.OrderService", []string{"PlaceOrder", "CancelOrder", ".ctor"}))
+		Expect(assembly.Types).To(HaveKeyWithValue("MyApp.Orders.OrderService+Validator", []string{"Validate", ".ctor"}))
+		Expect(assembly.Types).To(HaveKeyWithValue("MyApp.Orders.Repository", []string{"Load", ".ctor"}))
+	})
+
+	It("Should reject files that are not PE files", func() {
+		content, _ := os.ReadFile("fixtures/extensions/orders.xml")
+		_, err := readAssemblyMetadata(content)
+		Expect(err).To(Equal(errNotManagedAssembly))
+	})
+
+	It("Should reject PE files without CLI metadata", func() {
+		content, _ := os.ReadFile("fixtures/app/MyApp.Orders.dll")
+		native := append([]byte{}, content...)
+		image, err := parsePEImage(native)
+		Expect(err).To(BeNil())
+		// clear the CLI header data directory
+		for i := range image.directories[comDescriptorEntry*8 : comDescriptorEntry*8+8] {
+			image.directories[comDescriptorEntry*8+i] = 0
+		}
+		_, err = readAssemblyMetadata(native)
+		Expect(err).To(Equal(errNotManagedAssembly))
+	})
+
+	It("Should reject truncated metadata", func() {
+		content, _ := os.ReadFile("fixtures/app/MyApp.Orders.dll")
+		_, err := readAssemblyMetadata(content[:1024])
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Should reject corrupt row numbers instead of panicking", func() {
+		content, _ := os.ReadFile("fixtures/app/MyApp.Orders.dll")
+		corrupt := append([]byte{}, content...)
+		tables := metadataTablesOf(corrupt)
+		Expect(tables.rows[tableNestedClass]).ToNot(BeZero())
+		// point the enclosing class of the first nested type at row 0, which does not exist
+		enclosingClass := tables.offsets[tableNestedClass] + tables.columnSize(tableSchemas[tableNestedClass][0])
+		Expect(tables.columnSize(tableSchemas[tableNestedClass][1])).To(Equal(2))
+		binary.LittleEndian.PutUint16(tables.data[enclosingClass:], 0)
+
+		_, err := readAssemblyMetadata(corrupt)
+		Expect(errors.Is(err, errInvalidMetadata)).To(BeTrue())
+	})
+
+	It("Should reject rows outside of the table stream", func() {
+		content, _ := os.ReadFile("fixtures/app/MyApp.Orders.dll")
+		tables := metadataTablesOf(append([]byte{}, content...))
+		tables.data = tables.data[:tables.offsets[tableTypeDef]+tables.rowSizes[tableTypeDef]]
+
+		Expect(tables.cell(tableTypeDef, 2, 1)).To(BeZero())
+		Expect(errors.Is(tables.err, errInvalidMetadata)).To(BeTrue())
+	})
+})
+
+// metadataTablesOf - parses the metadata tables of a DLL, the tables' data shares memory with content
+func metadataTablesOf(content []byte) *metadataTables {
+	image, err := parsePEImage(content)
+	Expect(err).To(BeNil())
+	cliHeader, err := image.readDataDirectory(comDescriptorEntry)
+	Expect(err).To(BeNil())
+	metadata, err := image.readRVA(binary.LittleEndian.Uint32(cliHeader[8:12]), binary.LittleEndian.Uint32(cliHeader[12:16]))
+	Expect(err).To(BeNil())
+	tables, err := parseMetadataRoot(metadata)
+	Expect(err).To(BeNil())
+	return tables
+}
@@ -1,6 +1,8 @@
 package custominstrumentation
 
 import (
+	"os"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -10,4 +12,7 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering DotNetCore/CustomInstrumentation/*")
 
 	registrationFunc(DotNetCoreCustomInstrumentationCollect{}, true)
+	registrationFunc(DotNetCoreCustomInstrumentationValidate{
+		fileReader: os.ReadFile,
+	}, true)
 }
@@ -0,0 +1,24 @@
+package custominstrumentation
+
+import (
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDotNetCoreCustomInstrumentation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DotNetCore/CustomInstrumentation/* test suite")
+}
+
+func TestRegisterWithCount(t *testing.T) {
+	registeredTasks := []tasks.Task{}
+	RegisterWith(func(task tasks.Task, runByDefault bool) {
+		registeredTasks = append(registeredTasks, task)
+	})
+	if len(registeredTasks) != 2 {
+		t.Errorf("RegisterWith() registered %d tasks, want 2", len(registeredTasks))
+	}
+}
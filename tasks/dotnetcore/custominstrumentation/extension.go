@@ -0,0 +1,232 @@
+package custominstrumentation
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const extensionNamespace = "urn:newrelic-extension"
+
+// elementSchema - an element of the agent's extension.xsd, with its allowed children and attributes
+type elementSchema struct {
+	children   []string
+	attributes []string
+	required   []string
+	// attribute values that must be integers, with their inclusive bounds
+	integers map[string][2]int
+}
+
+// extensionSchema - the elements of extension.xsd shipped with the .NET agent
+var extensionSchema = map[string]elementSchema{
+	"extension": {
+		children: []string{"instrumentation"},
+	},
+	"instrumentation": {
+		children: []string{"tracerFactory"},
+	},
+	"tracerFactory": {
+		children:   []string{"match"},
+		attributes: []string{"name", "metricName", "level", "transactionNamingPriority"},
+		integers: map[string][2]int{
+			"level":                     {0, 7},
+			"transactionNamingPriority": {1, 7},
+		},
+	},
+	"match": {
+		children:   []string{"exactMethodMatcher"},
+		attributes: []string{"assemblyName", "className", "minVersion", "maxVersion"},
+		required:   []string{"assemblyName", "className"},
+	},
+	"exactMethodMatcher": {
+		attributes: []string{"methodName", "parameters", "minVersion", "maxVersion"},
+		required:   []string{"methodName"},
+	},
+}
+
+// InstrumentationMatch - a method matched by an exactMethodMatcher of an extension file
+type InstrumentationMatch struct {
+	File         string
+	Line         int
+	AssemblyName string
+	ClassName    string
+	MethodName   string
+	Parameters   string
+}
+
+type xmlElement struct {
+	name       xml.Name
+	attributes map[string]string
+	children   []*xmlElement
+	line       int
+}
+
+// parseXMLElements - reads a document into a tree of elements so the line of each element can be reported
+func parseXMLElements(content []byte) (*xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var root *xmlElement
+	var stack []*xmlElement
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch typedToken := token.(type) {
+		case xml.StartElement:
+			line, _ := decoder.InputPos()
+			element := &xmlElement{name: typedToken.Name, attributes: map[string]string{}, line: line}
+			for _, attribute := range typedToken.Attr {
+				// namespace declarations and xsi attributes are not part of the extension schema
+				if attribute.Name.Space == "xmlns" || attribute.Name.Local == "xmlns" || attribute.Name.Space == "http://www.w3.org/2001/XMLSchema-instance" {
+					continue
+				}
+				element.attributes[attribute.Name.Local] = attribute.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, element)
+			} else {
+				root = element
+			}
+			stack = append(stack, element)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+// validateExtension - checks an extension file against extensionSchema and returns its method matchers
+func validateExtension(path string, content []byte) ([]InstrumentationMatch, []string) {
+	root, err := parseXMLElements(content)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("%s: is not valid XML: %s", path, err.Error())}
+	}
+	if root.name.Local != "extension" || root.name.Space != extensionNamespace {
+		return nil, []string{fmt.Sprintf("%s: root element must be <extension xmlns=%q>", path, extensionNamespace)}
+	}
+
+	var failures []string
+	var matches []InstrumentationMatch
+	var walk func(element *xmlElement, parent *xmlElement)
+	walk = func(element *xmlElement, parent *xmlElement) {
+		location := fmt.Sprintf("%s:%d", path, element.line)
+		schema, known := extensionSchema[element.name.Local]
+		if !known || element.name.Space != extensionNamespace {
+			failures = append(failures, fmt.Sprintf("%s: <%s> is not an element of the extension schema", location, element.name.Local))
+			return
+		}
+		failures = append(failures, validateAttributes(location, element, schema)...)
+
+		switch element.name.Local {
+		case "tracerFactory", "match":
+			if len(element.children) == 0 {
+				failures = append(failures, fmt.Sprintf("%s: <%s> must contain at least one <%s>", location, element.name.Local, schema.children[0]))
+			}
+		case "exactMethodMatcher":
+			match := InstrumentationMatch{
+				File:         path,
+				Line:         element.line,
+				AssemblyName: parent.attributes["assemblyName"],
+				ClassName:    parent.attributes["className"],
+				MethodName:   element.attributes["methodName"],
+				Parameters:   element.attributes["parameters"],
+			}
+			failures = append(failures, validateWildcards(location, match)...)
+			matches = append(matches, match)
+		}
+
+		for _, child := range element.children {
+			if !tasks.ContainsString(schema.children, child.name.Local) {
+				failures = append(failures, fmt.Sprintf("%s:%d: <%s> is not allowed in <%s>", path, child.line, child.name.Local, element.name.Local))
+				continue
+			}
+			walk(child, element)
+		}
+	}
+	walk(root, nil)
+
+	if len(root.children) > 1 {
+		failures = append(failures, fmt.Sprintf("%s: <extension> may only contain one <instrumentation>", path))
+	}
+	return matches, failures
+}
+
+func validateAttributes(location string, element *xmlElement, schema elementSchema) []string {
+	var failures []string
+	for _, name := range schema.required {
+		if strings.TrimSpace(element.attributes[name]) == "" {
+			failures = append(failures, fmt.Sprintf("%s: <%s> is missing the required %s attribute", location, element.name.Local, name))
+		}
+	}
+	for _, name := range sortedAttributeNames(element) {
+		if !tasks.ContainsString(schema.attributes, name) {
+			failures = append(failures, fmt.Sprintf("%s: %s is not an attribute of <%s>", location, name, element.name.Local))
+			continue
+		}
+		bounds, isInteger := schema.integers[name]
+		if !isInteger {
+			continue
+		}
+		value, err := strconv.Atoi(element.attributes[name])
+		if err != nil || value < bounds[0] || value > bounds[1] {
+			failures = append(failures, fmt.Sprintf("%s: %s must be a number from %d to %d", location, name, bounds[0], bounds[1]))
+		}
+	}
+	return failures
+}
+
+// validateWildcards - the .NET agent matches assemblies, classes and methods by exact name only
+func validateWildcards(location string, match InstrumentationMatch) []string {
+	var failures []string
+	values := [][2]string{
+		{"assemblyName", match.AssemblyName},
+		{"className", match.ClassName},
+		{"methodName", match.MethodName},
+	}
+	for _, value := range values {
+		if strings.ContainsAny(value[1], "*?") {
+			failures = append(failures, fmt.Sprintf("%s: %s %q uses a wildcard, the .NET agent only matches exact names", location, value[0], value[1]))
+		}
+	}
+	return failures
+}
+
+// findDuplicateMatches - a matcher without parameters instruments every overload, so it overlaps any matcher of the same method
+func findDuplicateMatches(matches []InstrumentationMatch) []string {
+	var failures []string
+	seen := map[string][]InstrumentationMatch{}
+	for _, match := range matches {
+		key := strings.Join([]string{match.AssemblyName, match.ClassName, match.MethodName}, "|")
+		for _, previous := range seen[key] {
+			if previous.Parameters == match.Parameters || previous.Parameters == "" || match.Parameters == "" {
+				failures = append(failures, fmt.Sprintf("%s:%d: %s.%s in %s is already matched at %s:%d", match.File, match.Line, match.ClassName, match.MethodName, match.AssemblyName, previous.File, previous.Line))
+				break
+			}
+		}
+		seen[key] = append(seen[key], match)
+	}
+	return failures
+}
+
+func sortedAttributeNames(element *xmlElement) []string {
+	names := make([]string, 0, len(element.attributes))
+	for name := range element.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
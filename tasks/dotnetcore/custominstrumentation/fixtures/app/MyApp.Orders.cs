@@ -0,0 +1,18 @@
+// Source of MyApp.Orders.dll, built with: dotnet build -c Release (net8.0, DebugType none)
+namespace MyApp.Orders
+{
+    public class OrderService
+    {
+        public int PlaceOrder(string sku, int quantity) { return quantity; }
+        public void CancelOrder(int id) { }
+        public class Validator
+        {
+            public bool Validate() { return true; }
+        }
+    }
+
+    public class Repository
+    {
+        public string Load(int id) { return id.ToString(); }
+    }
+}
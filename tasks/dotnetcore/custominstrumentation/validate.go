@@ -0,0 +1,172 @@
+package custominstrumentation
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/env"
+)
+
+// DotNetCoreCustomInstrumentationValidate - validates the custom instrumentation files against the extension schema and the deployed assemblies
+type DotNetCoreCustomInstrumentationValidate struct {
+	fileReader func(string) ([]byte, error)
+}
+
+const customInstrumentationDocsURL = "https://docs.newrelic.com/docs/apm/agents/net-agent/custom-instrumentation/create-transactions-xml-net/"
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p DotNetCoreCustomInstrumentationValidate) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("DotNetCore/CustomInstrumentation/Validate")
+}
+
+// Explain - Returns the help text
+func (p DotNetCoreCustomInstrumentationValidate) Explain() string {
+	return "Validate New Relic .NET Core agent custom instrumentation file(s) against the extension schema and deployed assemblies"
+}
+
+// Dependencies - Returns the dependencies for ech task.
+func (p DotNetCoreCustomInstrumentationValidate) Dependencies() []string {
+	return []string{
+		"DotNetCore/CustomInstrumentation/Collect",
+		"DotNetCore/Env/Process",
+	}
+}
+
+// Execute - The core work within each task
+func (p DotNetCoreCustomInstrumentationValidate) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["DotNetCore/CustomInstrumentation/Collect"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Custom Instrumentation files not found, not validating them.",
+		}
+	}
+	elements, ok := upstream["DotNetCore/CustomInstrumentation/Collect"].Payload.([]CustomInstrumentationElement)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var failures, warnings []string
+	var matches []InstrumentationMatch
+	for _, element := range elements {
+		path := filepath.Join(element.FilePath, element.FileName)
+		content, err := p.fileReader(path)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: unable to read file: %s", path, err.Error()))
+			continue
+		}
+		fileMatches, fileFailures := validateExtension(path, content)
+		matches = append(matches, fileMatches...)
+		failures = append(failures, fileFailures...)
+	}
+	failures = append(failures, findDuplicateMatches(matches)...)
+
+	// the assemblies are only checked when the running application could be found
+	if processes, ok := upstream["DotNetCore/Env/Process"].Payload.([]env.ProcessArgs); ok && upstream["DotNetCore/Env/Process"].Status == tasks.Success {
+		assemblyFailures, assemblyWarnings := p.checkAssemblies(matches, getAssemblyDirs(processes))
+		failures = append(failures, assemblyFailures...)
+		warnings = append(warnings, assemblyWarnings...)
+	} else {
+		logger.Debug("No running dotnet processes found, skipping the deployed assembly checks")
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Invalid custom instrumentation found:\n" + strings.Join(append(failures, warnings...), "\n"),
+			URL:     customInstrumentationDocsURL,
+			Payload: matches,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "Some custom instrumentation could not be checked against the deployed assemblies:\n" + strings.Join(warnings, "\n"),
+			URL:     customInstrumentationDocsURL,
+			Payload: matches,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("Successfully validated %d custom instrumentation method matcher(s)", len(matches)),
+		Payload: matches,
+	}
+}
+
+// getAssemblyDirs - the application DLLs are deployed next to the dll passed to dotnet, or in its working directory
+func getAssemblyDirs(processes []env.ProcessArgs) []string {
+	var dirs []string
+	for _, process := range processes {
+		for _, arg := range strings.Fields(process.CmdLine) {
+			if !strings.HasSuffix(strings.ToLower(arg), ".dll") {
+				continue
+			}
+			if !filepath.IsAbs(arg) && process.Cwd != "" {
+				arg = filepath.Join(process.Cwd, arg)
+			}
+			if dir := filepath.Dir(arg); !tasks.ContainsString(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+		if process.Cwd != "" && !tasks.ContainsString(dirs, filepath.Clean(process.Cwd)) {
+			dirs = append(dirs, filepath.Clean(process.Cwd))
+		}
+	}
+	return dirs
+}
+
+// checkAssemblies - a missing class or method fails, a missing assembly warns because it may come from a shared framework
+func (p DotNetCoreCustomInstrumentationValidate) checkAssemblies(matches []InstrumentationMatch, dirs []string) ([]string, []string) {
+	var failures, warnings []string
+	assemblies := map[string]*AssemblyMetadata{}
+	for _, match := range matches {
+		if match.AssemblyName == "" || match.ClassName == "" || match.MethodName == "" {
+			continue
+		}
+		assembly, checked := assemblies[match.AssemblyName]
+		if !checked {
+			assembly = p.findAssembly(match.AssemblyName, dirs)
+			assemblies[match.AssemblyName] = assembly
+			if assembly == nil {
+				warnings = append(warnings, fmt.Sprintf("%s:%d: assembly %s was not found in %s", match.File, match.Line, match.AssemblyName, strings.Join(dirs, ", ")))
+			}
+		}
+		if assembly == nil {
+			continue
+		}
+
+		methods, ok := assembly.Types[match.ClassName]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("%s:%d: class %s was not found in assembly %s", match.File, match.Line, match.ClassName, match.AssemblyName))
+			continue
+		}
+		if !tasks.ContainsString(methods, match.MethodName) {
+			failures = append(failures, fmt.Sprintf("%s:%d: method %s was not found in class %s", match.File, match.Line, match.MethodName, match.ClassName))
+		}
+	}
+	return failures, warnings
+}
+
+func (p DotNetCoreCustomInstrumentationValidate) findAssembly(assemblyName string, dirs []string) *AssemblyMetadata {
+	for _, dir := range dirs {
+		path := filepath.Join(dir, assemblyName+".dll")
+		content, err := p.fileReader(path)
+		if err != nil {
+			continue
+		}
+		assembly, err := readAssemblyMetadata(content)
+		if err != nil {
+			logger.Debug("Unable to read the metadata of", path, ":", err)
+			continue
+		}
+		if assembly.Name == assemblyName {
+			return &assembly
+		}
+	}
+	return nil
+}
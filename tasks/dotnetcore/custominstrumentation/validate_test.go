@@ -0,0 +1,112 @@
+package custominstrumentation
+
+import (
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DotNetCore/CustomInstrumentation/Validate", func() {
+	p := DotNetCoreCustomInstrumentationValidate{fileReader: os.ReadFile}
+
+	collected := func(fileNames ...string) tasks.Result {
+		var elements []CustomInstrumentationElement
+		for _, fileName := range fileNames {
+			elements = append(elements, CustomInstrumentationElement{FileName: fileName, FilePath: "fixtures/extensions/"})
+		}
+		return tasks.Result{Status: tasks.Success, Payload: elements}
+	}
+	process := tasks.Result{
+		Status:  tasks.Success,
+		Payload: []env.ProcessArgs{{Pid: 1, CmdLine: "dotnet MyApp.Orders.dll", Cwd: "fixtures/app"}},
+	}
+
+	Describe("Dependencies()", func() {
+		It("Should return the dependencies", func() {
+			Expect(p.Dependencies()).To(Equal([]string{"DotNetCore/CustomInstrumentation/Collect", "DotNetCore/Env/Process"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		It("Should return None when no files were collected", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"DotNetCore/CustomInstrumentation/Collect": {Status: tasks.None},
+			})
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		It("Should return an assertion error for an unexpected payload", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"DotNetCore/CustomInstrumentation/Collect": {Status: tasks.Success, Payload: "unexpected"},
+			})
+			Expect(result).To(Equal(tasks.Result{Status: tasks.Error, Summary: tasks.AssertionErrorSummary}))
+		})
+
+		It("Should succeed when every matcher is found in the deployed assemblies", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"DotNetCore/CustomInstrumentation/Collect": collected("orders.xml"),
+				"DotNetCore/Env/Process":                   process,
+			})
+			Expect(result.Summary).To(Equal("Successfully validated 4 custom instrumentation method matcher(s)"))
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+
+		It("Should report schema, wildcard, duplicate and assembly errors", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"DotNetCore/CustomInstrumentation/Collect": collected("orders.xml", "invalid.xml"),
+				"DotNetCore/Env/Process":                   process,
+			})
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.URL).To(Equal(customInstrumentationDocsURL))
+			for _, expected := range []string{
+				"fixtures/extensions/invalid.xml:4: level must be a number from 0 to 7",
+				`fixtures/extensions/invalid.xml:6: className "MyApp.Orders.*" uses a wildcard, the .NET agent only matches exact names`,
+				`fixtures/extensions/invalid.xml:6: methodName "Get*" uses a wildcard, the .NET agent only matches exact names`,
+				"fixtures/extensions/invalid.xml:8: <match> is missing the required assemblyName attribute",
+				"fixtures/extensions/invalid.xml:12: class MyApp.Orders.Invoices was not found in assembly MyApp.Orders",
+				"fixtures/extensions/invalid.xml:15: MyApp.Orders.OrderService.PlaceOrder in MyApp.Orders is already matched at fixtures/extensions/orders.xml:6",
+				"fixtures/extensions/invalid.xml:16: method Ship was not found in class MyApp.Orders.OrderService",
+				"fixtures/extensions/invalid.xml:17: <methodMatcher> is not allowed in <match>",
+				"fixtures/extensions/invalid.xml:20: assembly MyApp.Payments was not found in fixtures/app",
+			} {
+				Expect(result.Summary).To(ContainSubstring(expected))
+			}
+		})
+
+		It("Should warn when an assembly is not deployed with the application", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"DotNetCore/CustomInstrumentation/Collect": collected("orders.xml"),
+				"DotNetCore/Env/Process": {
+					Status:  tasks.Success,
+					Payload: []env.ProcessArgs{{Pid: 1, CmdLine: "dotnet /srv/app/MyApp.Orders.dll", Cwd: "/srv"}},
+				},
+			})
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("assembly MyApp.Orders was not found in /srv/app, /srv"))
+		})
+
+		It("Should only validate the schema when no dotnet process is running", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"DotNetCore/CustomInstrumentation/Collect": collected("orders.xml"),
+				"DotNetCore/Env/Process":                   {Status: tasks.None},
+			})
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Describe("validateExtension()", func() {
+		It("Should reject files that are not extension files", func() {
+			_, failures := validateExtension("other.xml", []byte(`<configuration xmlns="urn:newrelic-config"/>`))
+			Expect(failures).To(Equal([]string{`other.xml: root element must be <extension xmlns="urn:newrelic-extension">`}))
+		})
+
+		It("Should reject malformed XML", func() {
+			_, failures := validateExtension("broken.xml", []byte(`<extension xmlns="urn:newrelic-extension"><instrumentation>`))
+			Expect(failures).To(HaveLen(1))
+			Expect(failures[0]).To(HavePrefix("broken.xml: is not valid XML"))
+		})
+	})
+})
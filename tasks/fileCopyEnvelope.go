@@ -15,6 +15,8 @@ type FileCopyEnvelope struct {
 	instance   int
 	Stream     chan string
 	Identifier string
+	Truncated  bool // set when only part of the file is copied
+	Redacted   bool // set when secrets were removed from the copied content
 }
 
 // MarshalJSON - custom JSON marshaling for this task, we'll strip out the passphrase to keep it only in memory, not on disk
@@ -0,0 +1,8 @@
+'use strict'
+/*
+ * require('express') in a comment is not a module
+ */
+require('newrelic')
+const express = require('express')
+
+express().listen(3000)
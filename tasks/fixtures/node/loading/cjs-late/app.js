@@ -0,0 +1,6 @@
+'use strict'
+// the agent is loaded after express, which is then not instrumented
+const express = require('express')
+const newrelic = require('newrelic')
+
+express().listen(3000)
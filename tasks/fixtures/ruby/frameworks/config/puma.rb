@@ -0,0 +1,8 @@
+workers ENV.fetch("WEB_CONCURRENCY") { 2 }
+threads 5, 5
+
+preload_app!
+
+before_fork do
+  NewRelic::Agent.manual_start
+end
@@ -8,7 +8,7 @@ import (
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
-	"golang.org/x/exp/maps"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/network"
 )
 
 // InfraAgentConnect - This struct tests the connector to Infrastructure
@@ -46,18 +46,6 @@ func (p InfraAgentConnect) Dependencies() []string {
 
 // Execute - The core work within each task
 func (p InfraAgentConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
-	domains := map[string]string{
-		"us01": ".newrelic.com",
-		"eu01": ".eu.newrelic.com",
-	}
-	endpoints := []string{
-		"infra-api",
-		"identity-api",
-		"infrastructure-command-api",
-		"log-api",
-		"metric-api",
-	}
-
 	var result tasks.Result
 	var requestResults map[string]RequestResult
 	var requestURLs []string
@@ -71,12 +59,9 @@ func (p InfraAgentConnect) Execute(options tasks.Options, upstream map[string]ta
 	regions, ok := upstream["Base/Config/RegionDetect"].Payload.([]string)
 
 	if (!ok) || len(regions) == 0 {
-		requestURLs = buildRequestURLs(endpoints, maps.Values(domains)...)
-	} else {
-		for _, region := range regions {
-			requestURLs = append(requestURLs, buildRequestURLs(endpoints, domains[region])...)
-		}
+		regions = network.CatalogRegions()
 	}
+	requestURLs = buildRequestURLs(regions...)
 
 	requestResults = makeRequests(requestURLs, p.httpGetter)
 	summary, status := validateResponses(requestResults)
@@ -89,12 +74,22 @@ func (p InfraAgentConnect) Execute(options tasks.Options, upstream map[string]ta
 	}
 }
 
-func buildRequestURLs(endpoints []string, domains ...string) []string {
+// the products the infrastructure agent sends to, from the Base/Network endpoint catalog
+var infraProducts = []string{
+	"infra-api",
+	"identity-api",
+	"infrastructure-command-api",
+	"log-api",
+	"metric-api",
+}
+
+func buildRequestURLs(regions ...string) []string {
 	var urls []string
-	for _, domain := range domains {
-		for _, endpoint := range endpoints {
-			url := "https://" + endpoint + domain
-			urls = append(urls, url)
+	for _, region := range regions {
+		for _, product := range infraProducts {
+			if endpoint, found := network.CatalogEndpoint(region, product); found {
+				urls = append(urls, "https://"+endpoint.Host)
+			}
 		}
 	}
 	return urls
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"runtime"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
@@ -20,6 +21,12 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		runtimeOS: runtime.GOOS,
 	}, true)
 	registrationFunc(InfraConfigIntegrationsValidateJson{}, true)
+	registrationFunc(InfraConfigIntegrationsInventory{
+		runtimeOS:  runtime.GOOS,
+		cmdExec:    cmdExecWithTimeout,
+		globber:    filepath.Glob,
+		fileReader: os.ReadFile,
+	}, true)
 	registrationFunc(InfraConfigValidateJMX{
 		mCmdExecutor:             tasks.MultiCmdExecutor,
 		getJMXProcessCmdlineArgs: getJMXProcessCmdlineArgs,
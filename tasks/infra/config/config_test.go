@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -26,7 +27,7 @@ func TestRegisterWithCount(t *testing.T) {
 		registrationFunc func(tasks.Task, bool)
 	}
 
-	expectedRegisteredTaskCount := 7
+	expectedRegisteredTaskCount := 8
 
 	tests := []struct {
 		name      string
@@ -65,6 +66,7 @@ func TestRegisterWith(t *testing.T) {
 		InfraConfigIntegrationsValidate{fileReader: os.Open},
 		InfraConfigIntegrationsMatch{runtimeOS: runtime.GOOS},
 		InfraConfigIntegrationsValidateJson{},
+		InfraConfigIntegrationsInventory{runtimeOS: runtime.GOOS, cmdExec: cmdExecWithTimeout, globber: filepath.Glob, fileReader: os.ReadFile},
 		InfraConfigValidateJMX{mCmdExecutor: tasks.MultiCmdExecutor, getJMXProcessCmdlineArgs: getJMXProcessCmdlineArgs},
 	}
 
@@ -0,0 +1,354 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+	"gopkg.in/yaml.v3"
+)
+
+// InfraConfigIntegrationsInventory - lists the installed on-host integrations and maps them to their integrations.d configs
+type InfraConfigIntegrationsInventory struct {
+	runtimeOS  string
+	cmdExec    tasks.CmdExecFunc
+	globber    func(string) ([]string, error)
+	fileReader func(string) ([]byte, error)
+}
+
+// InstalledIntegration - an nri-* binary found in the integrations directories
+type InstalledIntegration struct {
+	Name    string
+	Path    string
+	Version string
+	// VersionSource is the package manager the version was read from, or -show_version when it came from the binary
+	VersionSource string
+}
+
+// IntegrationsInventory - This struct represents the payload for the task
+type IntegrationsInventory struct {
+	Installed []InstalledIntegration
+	// Configs has the integration names as keys and the integrations.d files that run them as values
+	Configs map[string][]string
+}
+
+// These are the directories the infrastructure agent looks for integration binaries in
+var (
+	integrationBinDirsLinux = []string{
+		"/var/db/newrelic-infra/newrelic-integrations/bin/",
+		"/var/db/newrelic-infra/custom-integrations/bin/",
+	}
+	integrationBinDirsWindows = []string{
+		"C:\\Program Files\\New Relic\\newrelic-infra\\newrelic-integrations\\",
+		"C:\\Program Files\\New Relic\\newrelic-infra\\newrelic-integrations\\bin\\",
+		"C:\\Program Files\\New Relic\\newrelic-infra\\custom-integrations\\",
+	}
+	// integrations shipped with the infrastructure agent that do not need a config file to be installed
+	bundledIntegrations = []string{"nri-docker", "nri-flex", "nri-winservices"}
+	// -show_version prints "New Relic <name> integration Version: 1.10.0, Platform: linux/amd64, ..."
+	binaryVersionRegex = regexp.MustCompile(`(?i)integration version:\s*v?(\d+(?:\.\d+)+)`)
+)
+
+// showVersionTimeout - an integration that hangs on -show_version must not block the run
+const showVersionTimeout = 10 * time.Second
+
+const integrationsInventoryDocsURL = "https://docs.newrelic.com/docs/infrastructure/host-integrations/installation/install-infrastructure-host-integrations/"
+
+// integrationsConfigFile - the v4 (integrations) and v3 (integration_name) formats of integrations.d files
+type integrationsConfigFile struct {
+	IntegrationName string `yaml:"integration_name"`
+	Integrations    []struct {
+		Name string    `yaml:"name"`
+		Exec yaml.Node `yaml:"exec"`
+	} `yaml:"integrations"`
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraConfigIntegrationsInventory) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Config/IntegrationsInventory")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraConfigIntegrationsInventory) Explain() string {
+	return "List installed New Relic Infrastructure on-host integrations and check them against their configuration files"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraConfigIntegrationsInventory) Dependencies() []string {
+	return []string{
+		"Infra/Config/Agent",
+		"Infra/Config/IntegrationsValidate",
+	}
+}
+
+// Execute - The core work within each task
+func (p InfraConfigIntegrationsInventory) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic Infrastructure agent detected. This task did not run",
+		}
+	}
+
+	// integrations.d may be empty, installed binaries are still listed
+	var validatedYamls []config.ValidateElement
+	if upstream["Infra/Config/IntegrationsValidate"].Status == tasks.Success {
+		var ok bool
+		validatedYamls, ok = upstream["Infra/Config/IntegrationsValidate"].Payload.([]config.ValidateElement)
+		if !ok {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: tasks.AssertionErrorSummary,
+			}
+		}
+	}
+
+	inventory := IntegrationsInventory{
+		Installed: p.getInstalledIntegrations(),
+		Configs:   p.getConfiguredIntegrations(validatedYamls),
+	}
+	if len(inventory.Installed) == 0 && len(inventory.Configs) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No on-host integrations installed or configured.",
+		}
+	}
+
+	failures, warnings := checkInventory(inventory)
+	summary := fmt.Sprintf("Found %d installed on-host integration(s) and %d configured integration(s)", len(inventory.Installed), len(inventory.Configs))
+	issues := append(failures, warnings...)
+	if len(issues) > 0 {
+		summary += ":\n" + strings.Join(issues, "\n")
+	}
+
+	result := tasks.Result{
+		Status:  tasks.Success,
+		Summary: summary,
+		Payload: inventory,
+	}
+	if len(failures) > 0 {
+		result.Status = tasks.Failure
+		result.URL = integrationsInventoryDocsURL
+	} else if len(warnings) > 0 {
+		result.Status = tasks.Warning
+		result.URL = integrationsInventoryDocsURL
+	}
+	return result
+}
+
+// getInstalledIntegrations - finds the nri-* binaries and reads their versions from dpkg/rpm, falling back to -show_version.
+// Custom integrations are never run since they are arbitrary executables that may not support -show_version
+func (p InfraConfigIntegrationsInventory) getInstalledIntegrations() []InstalledIntegration {
+	binDirs := integrationBinDirsLinux
+	if p.runtimeOS == "windows" {
+		binDirs = integrationBinDirsWindows
+	}
+
+	packageVersions, packageManager := p.getPackageVersions()
+	var installed []InstalledIntegration
+	found := map[string]bool{}
+	for _, dir := range binDirs {
+		paths, err := p.globber(dir + "nri-*")
+		if err != nil {
+			log.Debug("Unable to list integration binaries in", dir, ":", err)
+			continue
+		}
+		for _, path := range paths {
+			name := strings.TrimSuffix(filepath.Base(path), ".exe")
+			if found[name] {
+				continue
+			}
+			found[name] = true
+			integration := InstalledIntegration{Name: name, Path: path}
+			if version, ok := packageVersions[name]; ok {
+				integration.Version = version
+				integration.VersionSource = packageManager
+			} else if !isCustomIntegrationDir(dir) {
+				if version := p.getBinaryVersion(path); version != "" {
+					integration.Version = version
+					integration.VersionSource = "-show_version"
+				}
+			}
+			installed = append(installed, integration)
+		}
+	}
+	sort.Slice(installed, func(i, j int) bool {
+		return installed[i].Name < installed[j].Name
+	})
+	return installed
+}
+
+func (p InfraConfigIntegrationsInventory) getPackageVersions() (map[string]string, string) {
+	if p.runtimeOS != "linux" {
+		return nil, ""
+	}
+	packageQueries := []struct {
+		manager string
+		args    []string
+	}{
+		{"dpkg", []string{"dpkg-query", "-W", "-f", "${Package} ${Version}\n", "nri-*"}},
+		{"rpm", []string{"rpm", "-qa", "--queryformat", "%{NAME} %{VERSION}\n", "nri-*"}},
+	}
+	for _, query := range packageQueries {
+		output, err := p.cmdExec(query.args[0], query.args[1:]...)
+		if err != nil {
+			log.Debug("Unable to query", query.manager, "for integration packages:", err)
+			continue
+		}
+		versions := parsePackageVersions(string(output))
+		if len(versions) > 0 {
+			return versions, query.manager
+		}
+	}
+	return nil, ""
+}
+
+// parsePackageVersions - reads "name version" lines, dropping the debian revision and epoch of the version
+func parsePackageVersions(output string) map[string]string {
+	versions := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "nri-") {
+			continue
+		}
+		version := fields[1]
+		if _, withoutEpoch, found := strings.Cut(version, ":"); found {
+			version = withoutEpoch
+		}
+		version, _, _ = strings.Cut(version, "-")
+		versions[fields[0]] = version
+	}
+	return versions
+}
+
+func isCustomIntegrationDir(dir string) bool {
+	return strings.Contains(filepath.ToSlash(dir), "custom-integrations")
+}
+
+func (p InfraConfigIntegrationsInventory) getBinaryVersion(path string) string {
+	output, err := p.cmdExec(path, "-show_version")
+	if err != nil {
+		log.Debug("Unable to get version of", path, ":", err)
+		return ""
+	}
+	match := binaryVersionRegex.FindStringSubmatch(string(output))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// getConfiguredIntegrations - maps integrations.d files to the binaries they run. Definition files have neither
+// integrations nor integration_name so they are skipped.
+func (p InfraConfigIntegrationsInventory) getConfiguredIntegrations(validatedYamls []config.ValidateElement) map[string][]string {
+	configs := map[string][]string{}
+	for _, validatedYaml := range validatedYamls {
+		path := validatedYaml.Config.FilePath + validatedYaml.Config.FileName
+		content, err := p.fileReader(path)
+		if err != nil {
+			log.Debug("Unable to read", path, ":", err)
+			continue
+		}
+		var configFile integrationsConfigFile
+		if err := yaml.Unmarshal(content, &configFile); err != nil {
+			continue
+		}
+		for _, name := range getIntegrationNames(configFile) {
+			if !tasks.ContainsString(configs[name], path) {
+				configs[name] = append(configs[name], path)
+			}
+		}
+	}
+	return configs
+}
+
+func getIntegrationNames(configFile integrationsConfigFile) []string {
+	// v3 configs name the integration after its definition file, e.g. com.newrelic.mysql runs nri-mysql
+	if configFile.IntegrationName != "" {
+		return []string{"nri-" + strings.TrimPrefix(configFile.IntegrationName, "com.newrelic.")}
+	}
+
+	var names []string
+	for _, integration := range configFile.Integrations {
+		// exec may be a path or a list with the path first
+		var exec string
+		switch integration.Exec.Kind {
+		case yaml.ScalarNode:
+			exec = strings.Fields(integration.Exec.Value + " ")[0]
+		case yaml.SequenceNode:
+			if len(integration.Exec.Content) > 0 {
+				exec = integration.Exec.Content[0].Value
+			}
+		}
+		if exec != "" {
+			exec = strings.TrimSuffix(filepath.Base(filepath.ToSlash(exec)), ".exe")
+			if strings.HasPrefix(exec, "nri-") {
+				names = append(names, exec)
+			}
+			// custom executables are not installed from packages
+			continue
+		}
+		if integration.Name != "" {
+			names = append(names, integration.Name)
+		}
+	}
+	return names
+}
+
+// checkInventory - configs running uninstalled integrations are failures, binaries without configs and outdated
+// versions are warnings
+func checkInventory(inventory IntegrationsInventory) ([]string, []string) {
+	var failures, warnings []string
+	installed := map[string]bool{}
+	for _, integration := range inventory.Installed {
+		installed[integration.Name] = true
+		if _, configured := inventory.Configs[integration.Name]; !configured && !tasks.ContainsString(bundledIntegrations, integration.Name) {
+			warnings = append(warnings, fmt.Sprintf("%s is installed at %s but no integrations.d file configures it", integration.Name, integration.Path))
+		}
+		requirements, ok := compatibilityVars.InfraIntegrationSupportedVersions[integration.Name]
+		if !ok || integration.Version == "" {
+			continue
+		}
+		isSupported, err := tasks.VersionIsCompatible(integration.Version, requirements)
+		if err != nil {
+			log.Debug("Unable to compare", integration.Name, "version:", err)
+			continue
+		}
+		if !isSupported {
+			warnings = append(warnings, fmt.Sprintf("%s %s is older than the minimum supported version %s", integration.Name, integration.Version, strings.Join(requirements, ", ")))
+		}
+	}
+
+	names := make([]string, 0, len(inventory.Configs))
+	for name := range inventory.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !installed[name] && !tasks.ContainsString(bundledIntegrations, name) {
+			failures = append(failures, fmt.Sprintf("%s is configured in %s but is not installed", name, strings.Join(inventory.Configs[name], ", ")))
+		}
+	}
+	return failures, warnings
+}
+
+// cmdExecWithTimeout - runs commands like tasks.CmdExecutor, killing them after showVersionTimeout
+func cmdExecWithTimeout(name string, arg ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), showVersionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, name, arg...).CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("timed out after %s", showVersionTimeout)
+	}
+	return output, err
+}
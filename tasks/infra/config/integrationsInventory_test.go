@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Config/IntegrationsInventory", func() {
+	var p InfraConfigIntegrationsInventory
+
+	binDir := "/var/db/newrelic-infra/newrelic-integrations/bin/"
+	configDir := "/etc/newrelic-infra/integrations.d/"
+
+	validated := func(fileNames ...string) tasks.Result {
+		var elements []config.ValidateElement
+		for _, fileName := range fileNames {
+			elements = append(elements, config.ValidateElement{
+				Config: config.ConfigElement{FileName: fileName, FilePath: configDir},
+			})
+		}
+		return tasks.Result{Status: tasks.Success, Payload: elements}
+	}
+
+	BeforeEach(func() {
+		binaries := []string{binDir + "nri-mysql", binDir + "nri-redis", binDir + "nri-flex"}
+		files := map[string]string{
+			configDir + "mysql-config.yml": "integrations:\n  - name: nri-mysql\n    env:\n      HOSTNAME: localhost\n",
+			configDir + "redis-config.yml": "integration_name: com.newrelic.redis\ninstances:\n  - name: redis\n",
+			configDir + "flex-config.yml":  "integrations:\n  - name: nri-flex\n    config:\n      name: example\n",
+		}
+		p = InfraConfigIntegrationsInventory{
+			runtimeOS: "linux",
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				if name == "dpkg-query" {
+					return []byte("nri-mysql 1.10.5\nnri-redis 1:1.8.0-1\n"), nil
+				}
+				return nil, errors.New("command not found")
+			},
+			globber: func(pattern string) ([]string, error) {
+				if pattern == binDir+"nri-*" {
+					return binaries, nil
+				}
+				return nil, nil
+			},
+			fileReader: func(path string) ([]byte, error) {
+				content, ok := files[path]
+				if !ok {
+					return nil, errors.New("no such file")
+				}
+				return []byte(content), nil
+			},
+		}
+	})
+
+	Describe("Identifier()", func() {
+		It("Should return the identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Infra", Subcategory: "Config", Name: "IntegrationsInventory"}))
+		})
+	})
+
+	Describe("Dependencies()", func() {
+		It("Should return the dependencies", func() {
+			Expect(p.Dependencies()).To(Equal([]string{"Infra/Config/Agent", "Infra/Config/IntegrationsValidate"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		Context("When the infrastructure agent was not found", func() {
+			It("Should return a None result", func() {
+				result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+					"Infra/Config/Agent": {Status: tasks.Failure},
+				})
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the integrations upstream has an unexpected payload", func() {
+			It("Should return an assertion error", func() {
+				result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+					"Infra/Config/Agent":                {Status: tasks.Success},
+					"Infra/Config/IntegrationsValidate": {Status: tasks.Success, Payload: "unexpected"},
+				})
+				Expect(result).To(Equal(tasks.Result{Status: tasks.Error, Summary: tasks.AssertionErrorSummary}))
+			})
+		})
+
+		Context("When every config has its integration installed", func() {
+			It("Should return a Success result with the inventory", func() {
+				result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+					"Infra/Config/Agent":                {Status: tasks.Success},
+					"Infra/Config/IntegrationsValidate": validated("mysql-config.yml", "redis-config.yml", "flex-config.yml"),
+				})
+				Expect(result.Status).To(Equal(tasks.Success))
+				inventory, ok := result.Payload.(IntegrationsInventory)
+				Expect(ok).To(BeTrue())
+				Expect(inventory.Installed).To(ConsistOf(
+					InstalledIntegration{Name: "nri-flex", Path: binDir + "nri-flex"},
+					InstalledIntegration{Name: "nri-mysql", Path: binDir + "nri-mysql", Version: "1.10.5", VersionSource: "dpkg"},
+					InstalledIntegration{Name: "nri-redis", Path: binDir + "nri-redis", Version: "1.8.0", VersionSource: "dpkg"},
+				))
+				Expect(inventory.Configs).To(HaveKeyWithValue("nri-redis", []string{configDir + "redis-config.yml"}))
+			})
+		})
+
+		Context("When configs, binaries and versions do not line up", func() {
+			It("Should return a Failure listing every issue", func() {
+				p.cmdExec = func(name string, arg ...string) ([]byte, error) {
+					switch name {
+					case "rpm":
+						return []byte("nri-mysql 1.5.0\n"), nil
+					case binDir + "nri-redis":
+						Expect(arg).To(Equal([]string{"-show_version"}))
+						return []byte("New Relic redis integration Version: 1.9.1, Platform: linux/amd64, GoVersion: go1.21.1\n"), nil
+					}
+					return nil, errors.New("command not found")
+				}
+				p.fileReader = func(path string) ([]byte, error) {
+					return []byte("integrations:\n  - name: nri-mysql\n  - name: postgres\n    exec: /var/db/newrelic-infra/newrelic-integrations/bin/nri-postgresql\n"), nil
+				}
+				result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+					"Infra/Config/Agent":                {Status: tasks.Success},
+					"Infra/Config/IntegrationsValidate": validated("config.yml"),
+				})
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.URL).To(Equal(integrationsInventoryDocsURL))
+				Expect(result.Summary).To(ContainSubstring("nri-postgresql is configured in " + configDir + "config.yml but is not installed"))
+				Expect(result.Summary).To(ContainSubstring("nri-redis is installed at " + binDir + "nri-redis but no integrations.d file configures it"))
+				Expect(result.Summary).To(ContainSubstring("nri-mysql 1.5.0 is older than the minimum supported version 1.7.0+"))
+				Expect(result.Summary).ToNot(ContainSubstring("nri-flex"))
+			})
+		})
+
+		Context("When there is no package manager", func() {
+			It("Should read the versions with -show_version without running custom integrations", func() {
+				windowsDir := "C:\\Program Files\\New Relic\\newrelic-infra\\newrelic-integrations\\bin\\"
+				customDir := "C:\\Program Files\\New Relic\\newrelic-infra\\custom-integrations\\"
+				p.runtimeOS = "windows"
+				p.globber = func(pattern string) ([]string, error) {
+					switch pattern {
+					case windowsDir + "nri-*":
+						return []string{windowsDir + "nri-mssql.exe"}, nil
+					case customDir + "nri-*":
+						return []string{customDir + "nri-custom.exe"}, nil
+					}
+					return nil, nil
+				}
+				var commands [][]string
+				p.cmdExec = func(name string, arg ...string) ([]byte, error) {
+					commands = append(commands, append([]string{name}, arg...))
+					return []byte("New Relic nri-mssql integration Version: 2.12.0, Platform: windows/amd64, GoVersion: go1.21.1, GitCommit: abc, BuildDate: 2024-01-01\n"), nil
+				}
+				installed := p.getInstalledIntegrations()
+				Expect(commands).To(Equal([][]string{{windowsDir + "nri-mssql.exe", "-show_version"}}))
+				Expect(installed).To(HaveLen(2))
+				for _, integration := range installed {
+					if integration.Path == customDir+"nri-custom.exe" {
+						Expect(integration.Version).To(BeEmpty())
+					} else {
+						Expect(integration.Path).To(Equal(windowsDir + "nri-mssql.exe"))
+						Expect(integration.Version).To(Equal("2.12.0"))
+						Expect(integration.VersionSource).To(Equal("-show_version"))
+					}
+				}
+			})
+		})
+
+		Context("When integrations.d has no validated files", func() {
+			It("Should warn about the unconfigured binaries", func() {
+				result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+					"Infra/Config/Agent":                {Status: tasks.Success},
+					"Infra/Config/IntegrationsValidate": {Status: tasks.None},
+				})
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(strings.Count(result.Summary, "no integrations.d file configures it")).To(Equal(2))
+			})
+		})
+
+		Context("When nothing is installed or configured", func() {
+			It("Should return a None result", func() {
+				p.globber = func(string) ([]string, error) { return nil, nil }
+				result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+					"Infra/Config/Agent":                {Status: tasks.Success},
+					"Infra/Config/IntegrationsValidate": {Status: tasks.None},
+				})
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+	})
+
+	Describe("parsePackageVersions()", func() {
+		It("Should drop epochs and package revisions", func() {
+			Expect(parsePackageVersions("nri-mysql 1.10.5\nnri-kafka 2:3.4.1-1.el8\nnewrelic-infra 1.40.0\n\n")).To(Equal(map[string]string{
+				"nri-mysql": "1.10.5",
+				"nri-kafka": "3.4.1",
+			}))
+		})
+	})
+})
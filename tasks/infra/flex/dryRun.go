@@ -0,0 +1,395 @@
+package flex
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// InfraFlexDryRun - runs the commands and requests of the Flex configurations and shows the samples they produce
+type InfraFlexDryRun struct {
+	commandRunner func(command string, shell string, timeout time.Duration) ([]byte, error)
+	httpGetter    tasks.HTTPRequestFunc
+}
+
+// FlexDryRunResult - the samples produced by a single api of a Flex configuration
+type FlexDryRunResult struct {
+	Config      string
+	API         string
+	SampleCount int
+	Samples     []Sample
+	Notes       []string
+	Error       string
+}
+
+const (
+	defaultDryRunTimeout = 10 * time.Second
+	// maxLookupExpansions - number of requests or commands run for each api when lookups resolve to several values
+	maxLookupExpansions = 10
+	maxShownSamples     = 5
+	maxOutputBytes      = 10 * 1024 * 1024
+)
+
+var errOutputTooLarge = errors.New("output is larger than 10MB")
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraFlexDryRun) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Flex/DryRun")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraFlexDryRun) Explain() string {
+	return "Run the commands and requests of Flex integration configurations and show the samples they produce. Commands run with the permissions of nrdiag, they are not sandboxed"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraFlexDryRun) Dependencies() []string {
+	return []string{
+		"Infra/Flex/Validate",
+	}
+}
+
+// Execute - The core work within each task
+func (p InfraFlexDryRun) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Flex/Validate"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No valid Flex integration configurations found. Dry-run not executed.",
+		}
+	}
+	integrations, ok := upstream["Infra/Flex/Validate"].Payload.([]FlexIntegration)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	question := "The Flex dry-run will run the following commands and requests on this host:\n" +
+		strings.Join(getActions(integrations), "\n") +
+		"\nThe commands are not sandboxed: they run with the permissions of nrdiag and can change this host, only their working directory is an empty temporary one and only PATH is set.\nContinue?"
+	if !tasks.PromptUser(question, options) {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Flex dry-run was declined by the user.",
+		}
+	}
+
+	var results []FlexDryRunResult
+	for _, integration := range integrations {
+		results = append(results, p.runConfig(integration.Config)...)
+	}
+
+	var summary []string
+	var failed bool
+	for _, result := range results {
+		label := result.Config + "/" + result.API
+		if result.Error != "" {
+			failed = true
+			summary = append(summary, label+": "+result.Error)
+			continue
+		}
+		line := fmt.Sprintf("%s: %d sample(s)", label, result.SampleCount)
+		if len(result.Samples) > 0 {
+			firstSample, _ := json.Marshal(result.Samples[0])
+			line += " " + string(firstSample)
+		}
+		for _, note := range result.Notes {
+			line += "\n\t" + note
+		}
+		summary = append(summary, line)
+	}
+
+	if failed {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Flex dry-run found errors:\n" + strings.Join(summary, "\n"),
+			URL:     flexDocsURL,
+			Payload: results,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: "Flex dry-run results:\n" + strings.Join(summary, "\n"),
+		Payload: results,
+	}
+}
+
+func getActions(integrations []FlexIntegration) []string {
+	var actions []string
+	for _, integration := range integrations {
+		for _, api := range integration.Config.APIs {
+			for _, command := range api.Commands {
+				actions = append(actions, "run: "+command.Run)
+			}
+			if api.URL != "" {
+				actions = append(actions, "request: "+getRequestURL(api, integration.Config.Global))
+			}
+		}
+	}
+	return actions
+}
+
+// runConfig - runs the apis in order, lookups stored by an api are available to the following ones
+func (p InfraFlexDryRun) runConfig(flexConfig FlexConfig) []FlexDryRunResult {
+	var results []FlexDryRunResult
+	lookupStores := map[string][]string{}
+	var previousSamples []Sample
+
+	for _, api := range flexConfig.APIs {
+		result := FlexDryRunResult{Config: flexConfig.Name, API: api.Name}
+		if api.File != "" || api.Database != "" {
+			result.Notes = append(result.Notes, "file and database apis are not run by the dry-run")
+			results = append(results, result)
+			continue
+		}
+
+		var samples []Sample
+		var err error
+		if api.URL != "" {
+			samples, err = p.runRequests(api, flexConfig, lookupStores, previousSamples, &result)
+		} else {
+			samples, err = p.runCommands(api, flexConfig, lookupStores, previousSamples, &result)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		samples = transformSamples(samples, api)
+		for store, key := range api.StoreLookups {
+			lookupStores[store] = getSampleValues(samples, key)
+		}
+		previousSamples = samples
+
+		result.SampleCount = len(samples)
+		if len(samples) > maxShownSamples {
+			samples = samples[:maxShownSamples]
+		}
+		result.Samples = samples
+		results = append(results, result)
+	}
+	return results
+}
+
+func (p InfraFlexDryRun) runCommands(api FlexAPI, flexConfig FlexConfig, lookupStores map[string][]string, previousSamples []Sample, result *FlexDryRunResult) ([]Sample, error) {
+	var samples []Sample
+	for _, command := range api.Commands {
+		if command.RegexMatch {
+			result.Notes = append(result.Notes, "regex_match output is not split by the dry-run")
+		}
+		timeout := getTimeout(command.Timeout, api.Timeout, flexConfig.Global.Timeout)
+		for _, run := range resolveReferences(command.Run, flexConfig, lookupStores, previousSamples) {
+			output, err := p.commandRunner(run, command.Shell, timeout)
+			if err != nil {
+				return nil, fmt.Errorf("command %q failed: %s", run, err.Error())
+			}
+			samples = append(samples, samplesFromCommand(string(output), command)...)
+		}
+	}
+	return samples, nil
+}
+
+func (p InfraFlexDryRun) runRequests(api FlexAPI, flexConfig FlexConfig, lookupStores map[string][]string, previousSamples []Sample, result *FlexDryRunResult) ([]Sample, error) {
+	headers := map[string]string{}
+	for name, value := range flexConfig.Global.Headers {
+		headers[name] = value
+	}
+	for name, value := range api.Headers {
+		headers[name] = resolveReferences(value, flexConfig, lookupStores, previousSamples)[0]
+	}
+	if flexConfig.Global.User != "" {
+		credentials := flexConfig.Global.User + ":" + flexConfig.Global.Pass
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	method := strings.ToUpper(api.Method)
+	if method == "" {
+		method = "GET"
+	}
+	timeout := getTimeout(0, api.Timeout, flexConfig.Global.Timeout)
+
+	var samples []Sample
+	for _, requestURL := range resolveReferences(getRequestURL(api, flexConfig.Global), flexConfig, lookupStores, previousSamples) {
+		wrapper := httpHelper.RequestWrapper{
+			Method:         method,
+			URL:            requestURL,
+			Headers:        headers,
+			TimeoutSeconds: int16(timeout.Seconds()),
+		}
+		if api.Payload != "" {
+			payload := resolveReferences(api.Payload, flexConfig, lookupStores, previousSamples)[0]
+			wrapper.Payload = strings.NewReader(payload)
+			wrapper.Length = int64(len(payload))
+		}
+
+		resp, err := p.httpGetter(wrapper)
+		if err != nil {
+			return nil, fmt.Errorf("request to %s failed: %s", requestURL, err.Error())
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxOutputBytes))
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read response of %s: %s", requestURL, err.Error())
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("request to %s returned status code %d", requestURL, resp.StatusCode)
+		}
+
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, fmt.Errorf("response of %s is not JSON: %s", requestURL, err.Error())
+		}
+		if api.JQ == "" {
+			samples = append(samples, samplesFromJSON(data)...)
+			continue
+		}
+		values, err := applyJQ(api.JQ, data)
+		if err != nil {
+			if !tasks.ContainsString(result.Notes, err.Error()) {
+				result.Notes = append(result.Notes, err.Error())
+			}
+			samples = append(samples, samplesFromJSON(data)...)
+			continue
+		}
+		for _, value := range values {
+			samples = append(samples, samplesFromJSON(value)...)
+		}
+	}
+	return samples, nil
+}
+
+// resolveReferences - replaces ${var:}, ${env:} and ${lookup:} references, a value is returned for each lookup value
+func resolveReferences(value string, flexConfig FlexConfig, lookupStores map[string][]string, previousSamples []Sample) []string {
+	resolved := []string{value}
+	for _, match := range referenceRegex.FindAllStringSubmatch(value, -1) {
+		reference, kind, key := match[0], match[1], match[2]
+
+		var replacements []string
+		switch {
+		case kind == "var":
+			replacements = []string{flexConfig.VariableStore[key]}
+		case kind == "env":
+			replacements = []string{os.Getenv(key)}
+		case strings.HasPrefix(kind, "lookup."):
+			replacements = lookupStores[strings.TrimPrefix(kind, "lookup.")]
+		default:
+			replacements = getSampleValues(previousSamples, key)
+		}
+		if len(replacements) == 0 {
+			replacements = []string{""}
+		}
+
+		var expanded []string
+		for _, partial := range resolved {
+			for _, replacement := range replacements {
+				if len(expanded) < maxLookupExpansions {
+					expanded = append(expanded, strings.ReplaceAll(partial, reference, replacement))
+				}
+			}
+		}
+		resolved = expanded
+	}
+	return resolved
+}
+
+func getSampleValues(samples []Sample, key string) []string {
+	var values []string
+	for _, sample := range samples {
+		if value, ok := sample[key]; ok {
+			formatted := fmt.Sprint(value)
+			if !tasks.ContainsString(values, formatted) {
+				values = append(values, formatted)
+			}
+		}
+	}
+	return values
+}
+
+func getTimeout(timeouts ...int) time.Duration {
+	for _, timeout := range timeouts {
+		if timeout > 0 {
+			return time.Duration(timeout) * time.Second
+		}
+	}
+	return defaultDryRunTimeout
+}
+
+// runFlexCommand - runs a Flex command with a timeout, in an empty temporary directory and with only PATH set. This keeps the
+// command from reading the environment of nrdiag or writing next to it by accident, it is not a sandbox: the command has the
+// same permissions as nrdiag.
+func runFlexCommand(command string, shell string, timeout time.Duration) ([]byte, error) {
+	workDir, err := os.MkdirTemp("", "nrdiag-flex")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	shellArgs := []string{"/bin/sh", "-c"}
+	if runtime.GOOS == "windows" {
+		shellArgs = []string{"cmd", "/C"}
+	}
+	if shell != "" {
+		shellArgs = append(strings.Fields(shell), "-c")
+		if runtime.GOOS == "windows" {
+			shellArgs = append(strings.Fields(shell), "-Command")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, shellArgs[0], append(shellArgs[1:], command)...)
+	cmd.Dir = workDir
+	// commands started in the background by the shell would otherwise keep the output open past the timeout
+	cmd.WaitDelay = time.Second
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	if runtime.GOOS == "windows" {
+		cmd.Env = append(cmd.Env, "SystemRoot="+os.Getenv("SystemRoot"))
+	}
+	stdout := &cappedBuffer{limit: maxOutputBytes, cancel: cancel}
+	cmd.Stdout = stdout
+
+	err = cmd.Run()
+	if stdout.exceeded {
+		return nil, errOutputTooLarge
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		log.Debug("Flex dry-run command failed", command, err)
+		return nil, err
+	}
+	return stdout.buffer.Bytes(), nil
+}
+
+// cappedBuffer keeps the output of a command up to limit bytes and kills the command, through the cancel of its context, once
+// it writes more. The buffer is not embedded so io.Copy can't bypass Write with bytes.Buffer.ReadFrom.
+type cappedBuffer struct {
+	buffer   bytes.Buffer
+	limit    int
+	exceeded bool
+	cancel   context.CancelFunc
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.buffer.Len()+len(p) > b.limit {
+		b.exceeded = true
+		b.cancel()
+		return 0, errOutputTooLarge
+	}
+	return b.buffer.Write(p)
+}
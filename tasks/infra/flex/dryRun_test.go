@@ -0,0 +1,174 @@
+package flex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Flex/DryRun", func() {
+	var p InfraFlexDryRun
+	var api *httptest.Server
+	var commands []string
+	options := tasks.Options{Options: map[string]string{"YesToAll": "true"}}
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Api-Key") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"data":[{"id":1,"status":"open","internal":"x","customer":{"name":"ada"}},{"id":2,"status":"closed","internal":"y"}]}`)
+		})
+		mux.HandleFunc("/orders/1", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"id":1,"total":12.5}`)
+		})
+		mux.HandleFunc("/orders/2", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"id":2,"total":3}`)
+		})
+		api = httptest.NewServer(mux)
+
+		commands = nil
+		p = InfraFlexDryRun{
+			commandRunner: func(command string, shell string, timeout time.Duration) ([]byte, error) {
+				commands = append(commands, command)
+				switch command {
+				case "df -P /":
+					return []byte("Filesystem 1024-blocks Used Available Capacity Mounted on\n/dev/sda1 1000 400 600 40% /\n"), nil
+				case "stat -f /":
+					return []byte("File: \"/\"\nType: ext2/ext3\n"), nil
+				}
+				return nil, errors.New("exit status 127")
+			},
+			httpGetter: tasks.HTTPRequester,
+		}
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	upstreamFor := func(flexConfig FlexConfig) map[string]tasks.Result {
+		return map[string]tasks.Result{
+			"Infra/Flex/Validate": {Status: tasks.Success, Payload: []FlexIntegration{{Path: "flex.yml", Config: flexConfig}}},
+		}
+	}
+
+	Describe("Execute()", func() {
+		Context("When the Flex configurations did not validate", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{"Infra/Flex/Validate": {Status: tasks.Failure}}
+				Expect(p.Execute(options, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the upstream payload has an unexpected type", func() {
+			It("Should return an Error result", func() {
+				upstream := map[string]tasks.Result{"Infra/Flex/Validate": {Status: tasks.Success, Payload: "flex.yml"}}
+				Expect(p.Execute(options, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Error,
+					Summary: tasks.AssertionErrorSummary,
+				}))
+			})
+		})
+
+		Context("When commands use variables and lookups", func() {
+			It("Should return the samples of each api", func() {
+				flexConfig := FlexConfig{
+					Name:          "linuxDisk",
+					VariableStore: map[string]string{"mount": "/"},
+					APIs: []FlexAPI{
+						{
+							Name:         "diskFree",
+							Commands:     []FlexCommand{{Run: "df -P ${var:mount}", Split: "horizontal", SetHeader: []string{"filesystem", "blocks", "used", "available", "capacity", "mounted"}, RowStart: 1}},
+							StoreLookups: map[string]string{"mounts": "mounted"},
+						},
+						{Name: "mountDetail", EventType: "FlexMountSample", Commands: []FlexCommand{{Run: "stat -f ${lookup.mounts:mounted}"}}},
+					},
+				}
+				result := p.Execute(options, upstreamFor(flexConfig))
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(commands).To(Equal([]string{"df -P /", "stat -f /"}))
+
+				results := result.Payload.([]FlexDryRunResult)
+				Expect(results[0].Samples).To(Equal([]Sample{{
+					"filesystem": "/dev/sda1", "blocks": 1000.0, "used": 400.0, "available": 600.0, "capacity": "40%", "mounted": "/", "event_type": "diskFreeSample",
+				}}))
+				Expect(results[1].Samples).To(Equal([]Sample{{"File": `"/"`, "Type": "ext2/ext3", "event_type": "FlexMountSample"}}))
+			})
+		})
+
+		Context("When apis call a local HTTP endpoint", func() {
+			It("Should apply jq, key filters and lookups to the responses", func() {
+				flexConfig := FlexConfig{
+					Name:   "ordersAPI",
+					Global: FlexGlobal{BaseURL: api.URL, Headers: map[string]string{"X-Api-Key": "secret"}},
+					APIs: []FlexAPI{
+						{Name: "orders", URL: "/orders", JQ: ".data[]", RemoveKeys: []string{"internal"}, StoreLookups: map[string]string{"ids": "id"}},
+						{Name: "orderTotals", URL: "/orders/${lookup.ids:id}", CustomAttributes: map[string]string{"team": "checkout"}},
+					},
+				}
+				result := p.Execute(options, upstreamFor(flexConfig))
+				Expect(result.Status).To(Equal(tasks.Info))
+
+				results := result.Payload.([]FlexDryRunResult)
+				Expect(results[0].Samples).To(Equal([]Sample{
+					{"id": 1.0, "status": "open", "customer.name": "ada", "event_type": "ordersSample"},
+					{"id": 2.0, "status": "closed", "event_type": "ordersSample"},
+				}))
+				Expect(results[1].SampleCount).To(Equal(2))
+				Expect(results[1].Samples[1]).To(Equal(Sample{"id": 2.0, "total": 3.0, "team": "checkout", "event_type": "orderTotalsSample"}))
+			})
+		})
+
+		Context("When a command or request fails", func() {
+			It("Should return a Failure result", func() {
+				flexConfig := FlexConfig{
+					Name: "broken",
+					APIs: []FlexAPI{
+						{Name: "missing", Commands: []FlexCommand{{Run: "not-a-command"}}},
+						{Name: "unauthorized", URL: api.URL + "/orders"},
+					},
+				}
+				result := p.Execute(options, upstreamFor(flexConfig))
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring(`broken/missing: command "not-a-command" failed: exit status 127`))
+				Expect(result.Summary).To(ContainSubstring("broken/unauthorized: request to " + api.URL + "/orders returned status code 401"))
+			})
+		})
+	})
+
+	Describe("runFlexCommand()", func() {
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("uses a POSIX shell")
+			}
+		})
+
+		It("Should run in an empty directory without the caller environment", func() {
+			output, err := runFlexCommand("ls -A; echo ${HOME:-unset}", "", time.Second)
+			Expect(err).To(BeNil())
+			Expect(string(output)).To(Equal("unset\n"))
+		})
+
+		It("Should stop commands that exceed the timeout", func() {
+			_, err := runFlexCommand("sleep 5", "", 100*time.Millisecond)
+			Expect(err).To(MatchError("timed out after 100ms"))
+		})
+
+		It("Should stop commands once their output exceeds the limit", func() {
+			start := time.Now()
+			_, err := runFlexCommand("yes flex", "", 30*time.Second)
+			Expect(err).To(Equal(errOutputTooLarge))
+			Expect(time.Since(start)).To(BeNumerically("<", 20*time.Second))
+		})
+	})
+})
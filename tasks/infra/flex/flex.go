@@ -0,0 +1,170 @@
+package flex
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"gopkg.in/yaml.v3"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Infra/Flex/*")
+
+	registrationFunc(InfraFlexValidate{
+		fileReader: os.ReadFile,
+	}, true)
+	// DryRun runs the commands and requests of the Flex configs, so it only runs when requested by name
+	registrationFunc(InfraFlexDryRun{
+		commandRunner: runFlexCommand,
+		httpGetter:    tasks.HTTPRequester,
+	}, false)
+}
+
+const (
+	flexIntegrationName = "nri-flex"
+	flexDocsURL         = "https://github.com/newrelic/nri-flex/blob/master/docs/basics/configure.md"
+	redacted            = "_REDACTED_"
+)
+
+// FlexIntegration - an nri-flex entry of an integrations.d file
+type FlexIntegration struct {
+	Path   string
+	Config FlexConfig
+}
+
+// FlexConfig - the Flex configuration, inline under config or loaded from config_template_path
+type FlexConfig struct {
+	Name          string            `yaml:"name"`
+	Global        FlexGlobal        `yaml:"global"`
+	VariableStore map[string]string `yaml:"variable_store"`
+	LookupFile    string            `yaml:"lookup_file"`
+	APIs          []FlexAPI         `yaml:"apis"`
+}
+
+// FlexGlobal - settings shared by every api of a Flex configuration
+type FlexGlobal struct {
+	BaseURL string            `yaml:"base_url"`
+	User    string            `yaml:"user"`
+	Pass    string            `yaml:"pass"`
+	Headers map[string]string `yaml:"headers"`
+	Timeout int               `yaml:"timeout"`
+}
+
+// FlexAPI - a single data source of a Flex configuration and the processing applied to its output
+type FlexAPI struct {
+	Name             string            `yaml:"name"`
+	EventType        string            `yaml:"event_type"`
+	URL              string            `yaml:"url"`
+	Method           string            `yaml:"method"`
+	Headers          map[string]string `yaml:"headers"`
+	Payload          string            `yaml:"payload"`
+	Commands         []FlexCommand     `yaml:"commands"`
+	File             string            `yaml:"file"`
+	Database         string            `yaml:"database"`
+	DBConn           string            `yaml:"db_conn"`
+	JQ               string            `yaml:"jq"`
+	StoreLookups     map[string]string `yaml:"store_lookups"`
+	LookupFile       string            `yaml:"lookup_file"`
+	RenameKeys       map[string]string `yaml:"rename_keys"`
+	RemoveKeys       []string          `yaml:"remove_keys"`
+	KeepKeys         []string          `yaml:"keep_keys"`
+	CustomAttributes map[string]string `yaml:"custom_attributes"`
+	Timeout          int               `yaml:"timeout"`
+}
+
+// FlexCommand - a command of an api and how its output is split into samples
+type FlexCommand struct {
+	Run        string   `yaml:"run"`
+	Shell      string   `yaml:"shell"`
+	Split      string   `yaml:"split"`
+	SplitBy    string   `yaml:"split_by"`
+	RegexMatch bool     `yaml:"regex_match"`
+	RowStart   int      `yaml:"row_start"`
+	RowHeader  int      `yaml:"row_header"`
+	SetHeader  []string `yaml:"set_header"`
+	LineLimit  int      `yaml:"line_limit"`
+	Timeout    int      `yaml:"timeout"`
+}
+
+// onHostIntegrationsConfig - the integrations.d format of the infrastructure agent
+type onHostIntegrationsConfig struct {
+	Integrations []struct {
+		Name               string    `yaml:"name"`
+		Config             yaml.Node `yaml:"config"`
+		ConfigTemplatePath string    `yaml:"config_template_path"`
+	} `yaml:"integrations"`
+}
+
+var errNoFlexConfig = errors.New("nri-flex entry has neither config nor config_template_path")
+
+// MarshalJSON - keeps the credentials of the Flex global settings out of nrdiag-output.json
+func (g FlexGlobal) MarshalJSON() ([]byte, error) {
+	type alias FlexGlobal
+	redactedGlobal := alias(g)
+	if redactedGlobal.Pass != "" {
+		redactedGlobal.Pass = redacted
+	}
+	redactedGlobal.Headers = redactHeaders(g.Headers)
+	return json.Marshal(redactedGlobal)
+}
+
+// MarshalJSON - keeps request headers and database connection strings out of nrdiag-output.json
+func (a FlexAPI) MarshalJSON() ([]byte, error) {
+	type alias FlexAPI
+	redactedAPI := alias(a)
+	if redactedAPI.DBConn != "" {
+		redactedAPI.DBConn = redacted
+	}
+	redactedAPI.Headers = redactHeaders(a.Headers)
+	return json.Marshal(redactedAPI)
+}
+
+func redactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redactedHeaders := map[string]string{}
+	for name := range headers {
+		redactedHeaders[name] = redacted
+	}
+	return redactedHeaders
+}
+
+// parseFlexIntegrations - returns the nri-flex entries of an integrations.d file, loading config_template_path files with fileReader
+func parseFlexIntegrations(path string, content []byte, fileReader func(string) ([]byte, error)) ([]FlexIntegration, error) {
+	var onHost onHostIntegrationsConfig
+	if err := yaml.Unmarshal(content, &onHost); err != nil {
+		return nil, err
+	}
+
+	var integrations []FlexIntegration
+	for _, integration := range onHost.Integrations {
+		if integration.Name != flexIntegrationName {
+			continue
+		}
+		flexIntegration := FlexIntegration{Path: path}
+		switch {
+		case integration.Config.Kind != 0:
+			if err := integration.Config.Decode(&flexIntegration.Config); err != nil {
+				return integrations, err
+			}
+		case integration.ConfigTemplatePath != "":
+			flexIntegration.Path = integration.ConfigTemplatePath
+			templateContent, err := fileReader(integration.ConfigTemplatePath)
+			if err != nil {
+				return integrations, err
+			}
+			if err := yaml.Unmarshal(templateContent, &flexIntegration.Config); err != nil {
+				return integrations, err
+			}
+		default:
+			return integrations, errNoFlexConfig
+		}
+		integrations = append(integrations, flexIntegration)
+	}
+	return integrations, nil
+}
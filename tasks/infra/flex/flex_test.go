@@ -0,0 +1,64 @@
+package flex
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestInfraFlex(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Infra/Flex/* test suite")
+}
+
+func TestRegisterWithCount(t *testing.T) {
+	registeredTasks := []tasks.Task{}
+	RegisterWith(func(task tasks.Task, runByDefault bool) {
+		registeredTasks = append(registeredTasks, task)
+	})
+	if len(registeredTasks) != 2 {
+		t.Errorf("RegisterWith() registered %d tasks, want 2", len(registeredTasks))
+	}
+}
+
+var _ = Describe("parseFlexIntegrations()", func() {
+	It("Should only return nri-flex entries", func() {
+		content, _ := os.ReadFile("fixtures/flex-disk.yml")
+		integrations, err := parseFlexIntegrations("fixtures/flex-disk.yml", content, os.ReadFile)
+		Expect(err).To(BeNil())
+		Expect(integrations).To(HaveLen(1))
+		Expect(integrations[0].Config.Name).To(Equal("linuxDisk"))
+		Expect(integrations[0].Config.APIs[0].Commands[0].SetHeader).To(HaveLen(6))
+		Expect(integrations[0].Config.APIs[0].StoreLookups).To(Equal(map[string]string{"mounts": "mounted"}))
+	})
+
+	It("Should load configurations from config_template_path", func() {
+		content, _ := os.ReadFile("fixtures/flex-template.yml")
+		integrations, err := parseFlexIntegrations("fixtures/flex-template.yml", content, os.ReadFile)
+		Expect(err).To(BeNil())
+		Expect(integrations[0].Path).To(Equal("fixtures/flex-api-template.yml"))
+		Expect(integrations[0].Config.Global.BaseURL).To(Equal("http://localhost:8080"))
+	})
+
+	It("Should fail on entries without a configuration", func() {
+		_, err := parseFlexIntegrations("flex.yml", []byte("integrations:\n  - name: nri-flex\n"), os.ReadFile)
+		Expect(err).To(Equal(errNoFlexConfig))
+	})
+})
+
+var _ = Describe("MarshalJSON()", func() {
+	It("Should redact credentials, headers and connection strings", func() {
+		flexConfig := FlexConfig{
+			Global: FlexGlobal{User: "admin", Pass: "secret", Headers: map[string]string{"X-Api-Key": "secret"}},
+			APIs:   []FlexAPI{{Database: "postgres", DBConn: "user=admin password=secret", Headers: map[string]string{"Authorization": "secret"}}},
+		}
+		output, err := json.Marshal(flexConfig)
+		Expect(err).To(BeNil())
+		Expect(string(output)).NotTo(ContainSubstring("secret"))
+		Expect(string(output)).To(ContainSubstring("X-Api-Key"))
+	})
+})
@@ -0,0 +1,173 @@
+package flex
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// errJQUnsupported - the expression is valid for Flex but uses jq features the dry-run does not evaluate
+var errJQUnsupported = errors.New("jq expression uses features not evaluated by the dry-run")
+
+// validateJQ - checks the expression is not empty, its strings are terminated and its brackets are balanced. Flex
+// evaluates jq with gojq, full semantic validation is left to it.
+func validateJQ(expression string) error {
+	if strings.TrimSpace(expression) == "" {
+		return errors.New("jq expression is empty")
+	}
+
+	var stack []rune
+	pairs := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	inString := false
+	escaped := false
+	for _, char := range expression {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case char == '\\':
+				escaped = true
+			case char == '"':
+				inString = false
+			}
+			continue
+		}
+		switch char {
+		case '"':
+			inString = true
+		case '(', '[', '{':
+			stack = append(stack, char)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[char] {
+				return fmt.Errorf("jq expression %q has an unexpected %q", expression, char)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if inString {
+		return fmt.Errorf("jq expression %q has an unterminated string", expression)
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("jq expression %q has an unclosed %q", expression, stack[len(stack)-1])
+	}
+	trimmed := strings.TrimSpace(expression)
+	if strings.HasPrefix(trimmed, "|") || strings.HasSuffix(trimmed, "|") {
+		return fmt.Errorf("jq expression %q has an empty pipe", expression)
+	}
+	return nil
+}
+
+// applyJQ - evaluates the path subset of jq used by most Flex configs: pipes of ., .key, .["key"], .[n] and .[]
+func applyJQ(expression string, input interface{}) ([]interface{}, error) {
+	values := []interface{}{input}
+	for _, filter := range strings.Split(expression, "|") {
+		steps, err := parseJQPath(strings.TrimSpace(filter))
+		if err != nil {
+			return nil, err
+		}
+		for _, step := range steps {
+			var next []interface{}
+			for _, value := range values {
+				next = append(next, step(value)...)
+			}
+			values = next
+		}
+	}
+	return values, nil
+}
+
+type jqStep func(interface{}) []interface{}
+
+func parseJQPath(filter string) ([]jqStep, error) {
+	if !strings.HasPrefix(filter, ".") {
+		return nil, errJQUnsupported
+	}
+	var steps []jqStep
+	rest := filter[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "[]"):
+			steps = append(steps, iterateStep)
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, errJQUnsupported
+			}
+			index := rest[1:end]
+			if key, err := strconv.Unquote(index); err == nil {
+				steps = append(steps, keyStep(key))
+			} else if position, err := strconv.Atoi(index); err == nil {
+				steps = append(steps, indexStep(position))
+			} else {
+				return nil, errJQUnsupported
+			}
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if !isJQIdentifier(key) {
+				return nil, errJQUnsupported
+			}
+			steps = append(steps, keyStep(key))
+			rest = rest[end:]
+		}
+	}
+	return steps, nil
+}
+
+func isJQIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, char := range key {
+		isLetter := char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+		if !isLetter && (i == 0 || char < '0' || char > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func iterateStep(value interface{}) []interface{} {
+	switch typedValue := value.(type) {
+	case []interface{}:
+		return typedValue
+	case map[string]interface{}:
+		var values []interface{}
+		for _, key := range sortedKeys(typedValue) {
+			values = append(values, typedValue[key])
+		}
+		return values
+	}
+	return nil
+}
+
+func keyStep(key string) jqStep {
+	return func(value interface{}) []interface{} {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []interface{}{nil}
+		}
+		return []interface{}{object[key]}
+	}
+}
+
+func indexStep(position int) jqStep {
+	return func(value interface{}) []interface{} {
+		array, ok := value.([]interface{})
+		if position < 0 && ok {
+			position += len(array)
+		}
+		if !ok || position < 0 || position >= len(array) {
+			return []interface{}{nil}
+		}
+		return []interface{}{array[position]}
+	}
+}
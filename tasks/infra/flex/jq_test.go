@@ -0,0 +1,39 @@
+package flex
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("validateJQ()", func() {
+	DescribeTable("Should validate the structure of jq expressions",
+		func(expression string, valid bool) {
+			Expect(validateJQ(expression) == nil).To(Equal(valid))
+		},
+		Entry("path", ".data[].items", true),
+		Entry("pipe with function", `.[] | select(.name == "a)") | {id: .id}`, true),
+		Entry("empty", "  ", false),
+		Entry("unbalanced", ".data[", false),
+		Entry("mismatched", ".data(]", false),
+		Entry("unterminated string", `.["name]`, false),
+		Entry("trailing pipe", ".data |", false),
+	)
+})
+
+var _ = Describe("applyJQ()", func() {
+	var data interface{}
+	_ = json.Unmarshal([]byte(`{"data":{"items":[{"id":1},{"id":2}],"total":2}}`), &data)
+
+	It("Should evaluate path expressions", func() {
+		Expect(applyJQ(".data.items[].id", data)).To(Equal([]interface{}{1.0, 2.0}))
+		Expect(applyJQ(`.data | .["total"]`, data)).To(Equal([]interface{}{2.0}))
+		Expect(applyJQ(".data.items[-1]", data)).To(Equal([]interface{}{map[string]interface{}{"id": 2.0}}))
+	})
+
+	It("Should report expressions it does not evaluate", func() {
+		_, err := applyJQ(".data.items | length", data)
+		Expect(err).To(Equal(errJQUnsupported))
+	})
+})
@@ -0,0 +1,192 @@
+package flex
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sample - a single event Flex sends to New Relic
+type Sample map[string]interface{}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// samplesFromJSON - Flex creates a sample per element of top level arrays and a single sample for objects, nested
+// objects and arrays are flattened with . separated keys
+func samplesFromJSON(data interface{}) []Sample {
+	elements, ok := data.([]interface{})
+	if !ok {
+		elements = []interface{}{data}
+	}
+
+	var samples []Sample
+	for _, element := range elements {
+		sample := Sample{}
+		if _, isObject := element.(map[string]interface{}); isObject {
+			flatten("", element, sample)
+		} else {
+			flatten("value", element, sample)
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func flatten(prefix string, value interface{}, sample Sample) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range typedValue {
+			flatten(join(key), nested, sample)
+		}
+	case []interface{}:
+		for i, nested := range typedValue {
+			flatten(join(strconv.Itoa(i)), nested, sample)
+		}
+	default:
+		sample[prefix] = typedValue
+	}
+}
+
+// samplesFromCommand - splits command output the way Flex does, JSON output is handled like an url response
+func samplesFromCommand(output string, command FlexCommand) []Sample {
+	var data interface{}
+	if err := json.Unmarshal([]byte(output), &data); err == nil {
+		return samplesFromJSON(data)
+	}
+
+	lines := strings.Split(strings.TrimRight(output, "\r\n"), "\n")
+	if command.LineLimit > 0 && len(lines) > command.LineLimit {
+		lines = lines[:command.LineLimit]
+	}
+	if command.Split == "horizontal" {
+		return splitHorizontal(lines, command)
+	}
+	return splitVertical(lines, command)
+}
+
+func splitHorizontal(lines []string, command FlexCommand) []Sample {
+	splitBy := regexp.MustCompile(`\s+`)
+	if command.SplitBy != "" {
+		splitBy = regexp.MustCompile(command.SplitBy)
+	}
+
+	header := command.SetHeader
+	rowStart := command.RowStart
+	if len(header) == 0 {
+		if command.RowHeader >= len(lines) {
+			return nil
+		}
+		header = splitBy.Split(strings.TrimSpace(lines[command.RowHeader]), -1)
+		if rowStart <= command.RowHeader {
+			rowStart = command.RowHeader + 1
+		}
+	}
+
+	var samples []Sample
+	for i := rowStart; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		sample := Sample{}
+		for j, value := range splitBy.Split(line, -1) {
+			if j < len(header) {
+				sample[header[j]] = convertValue(value)
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func splitVertical(lines []string, command FlexCommand) []Sample {
+	splitBy := regexp.MustCompile(`:`)
+	if command.SplitBy != "" {
+		splitBy = regexp.MustCompile(command.SplitBy)
+	}
+
+	sample := Sample{}
+	for _, line := range lines {
+		parts := splitBy.Split(line, 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+		sample[strings.TrimSpace(parts[0])] = convertValue(parts[1])
+	}
+	if len(sample) == 0 {
+		return nil
+	}
+	return []Sample{sample}
+}
+
+func convertValue(value string) interface{} {
+	value = strings.TrimSpace(value)
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+	return value
+}
+
+// transformSamples - applies rename_keys, remove_keys, keep_keys, custom_attributes and the event type of an api
+func transformSamples(samples []Sample, api FlexAPI) []Sample {
+	eventType := api.EventType
+	if eventType == "" {
+		eventType = api.Name + "Sample"
+	}
+
+	for _, sample := range samples {
+		for _, pattern := range sortedKeys(api.RenameKeys) {
+			renameRegex := regexp.MustCompile(pattern)
+			for _, key := range sortedKeys(sample) {
+				if renamed := renameRegex.ReplaceAllString(key, api.RenameKeys[pattern]); renamed != key {
+					sample[renamed] = sample[key]
+					delete(sample, key)
+				}
+			}
+		}
+		for _, pattern := range api.RemoveKeys {
+			removeRegex := regexp.MustCompile(pattern)
+			for key := range sample {
+				if removeRegex.MatchString(key) {
+					delete(sample, key)
+				}
+			}
+		}
+		if len(api.KeepKeys) > 0 {
+			for key := range sample {
+				if !matchesAny(api.KeepKeys, key) {
+					delete(sample, key)
+				}
+			}
+		}
+		for key, value := range api.CustomAttributes {
+			sample[key] = value
+		}
+		sample["event_type"] = eventType
+	}
+	return samples
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if regexp.MustCompile(pattern).MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+package flex
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// InfraFlexValidate - validates the nri-flex configurations found in integrations.d
+type InfraFlexValidate struct {
+	fileReader func(string) ([]byte, error)
+}
+
+var (
+	// ${var:name}, ${lookup:key} and ${lookup.store:key} are replaced by Flex before running each api
+	referenceRegex = regexp.MustCompile(`\$\{(var|env|lookup(?:\.[\w-]+)?):([^}]*)\}`)
+	eventTypeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_:]*$`)
+	httpMethods    = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"}
+	splitModes     = []string{"horizontal", "vertical"}
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraFlexValidate) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Flex/Validate")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraFlexValidate) Explain() string {
+	return "Validate New Relic Infrastructure Flex integration configurations"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraFlexValidate) Dependencies() []string {
+	return []string{
+		"Infra/Config/IntegrationsValidate",
+	}
+}
+
+// Execute - The core work within each task
+func (p InfraFlexValidate) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Config/IntegrationsValidate"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No valid on-host integration configuration files found. Task not executed.",
+		}
+	}
+	validatedYamls, ok := upstream["Infra/Config/IntegrationsValidate"].Payload.([]config.ValidateElement)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var integrations []FlexIntegration
+	var failures []string
+	for _, validatedYaml := range validatedYamls {
+		path := validatedYaml.Config.FilePath + validatedYaml.Config.FileName
+		content, err := p.fileReader(path)
+		if err != nil || !bytes.Contains(content, []byte(flexIntegrationName)) {
+			continue
+		}
+		fileIntegrations, err := parseFlexIntegrations(path, content, p.fileReader)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", path, err.Error()))
+		}
+		integrations = append(integrations, fileIntegrations...)
+	}
+
+	if len(integrations) == 0 && len(failures) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Flex integration configurations found.",
+		}
+	}
+	for _, integration := range integrations {
+		failures = append(failures, validateFlexConfig(integration)...)
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Invalid Flex integration configuration found:\n" + strings.Join(failures, "\n"),
+			URL:     flexDocsURL,
+			Payload: integrations,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("Successfully validated %d Flex integration configuration(s)", len(integrations)),
+		Payload: integrations,
+	}
+}
+
+// validateFlexConfig - checks every api of a Flex configuration, lookups must be stored by an earlier api to be used
+func validateFlexConfig(integration FlexIntegration) []string {
+	flexConfig := integration.Config
+	label := integration.Path
+	if flexConfig.Name == "" {
+		return []string{label + ": config name is required"}
+	}
+	label += ": " + flexConfig.Name
+	if len(flexConfig.APIs) == 0 {
+		return []string{label + ": no apis defined"}
+	}
+
+	var failures []string
+	storedLookups := map[string]bool{}
+	for i, api := range flexConfig.APIs {
+		apiLabel := fmt.Sprintf("%s: apis[%d]", label, i)
+		if api.Name != "" {
+			apiLabel = label + ": " + api.Name
+		}
+		for _, failure := range validateAPI(api, flexConfig, storedLookups, i > 0) {
+			failures = append(failures, apiLabel+": "+failure)
+		}
+		for store := range api.StoreLookups {
+			storedLookups[store] = true
+		}
+	}
+	return failures
+}
+
+func validateAPI(api FlexAPI, flexConfig FlexConfig, storedLookups map[string]bool, hasPreviousAPI bool) []string {
+	var failures []string
+	if api.Name == "" {
+		failures = append(failures, "name is required")
+	}
+	if api.EventType != "" && !eventTypeRegex.MatchString(api.EventType) {
+		failures = append(failures, fmt.Sprintf("event_type %q may only contain letters, numbers, _ and :", api.EventType))
+	}
+
+	sources := getSources(api)
+	if len(sources) == 0 {
+		failures = append(failures, "no data source defined, set one of commands, url, file or database")
+	}
+	if len(sources) > 1 {
+		failures = append(failures, "more than one data source defined: "+strings.Join(sources, ", "))
+	}
+
+	if api.URL != "" {
+		failures = append(failures, validateURL(getRequestURL(api, flexConfig.Global))...)
+	}
+	if api.Method != "" && !tasks.ContainsString(httpMethods, strings.ToUpper(api.Method)) {
+		failures = append(failures, fmt.Sprintf("method %q is not one of %s", api.Method, strings.Join(httpMethods, ", ")))
+	}
+	if api.Database != "" && api.DBConn == "" {
+		failures = append(failures, "db_conn is required for database apis")
+	}
+	for i, command := range api.Commands {
+		for _, failure := range validateCommand(command) {
+			failures = append(failures, fmt.Sprintf("commands[%d]: %s", i, failure))
+		}
+	}
+	if api.JQ != "" {
+		if err := validateJQ(api.JQ); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	keyPatterns := sortedKeys(api.RenameKeys)
+	keyPatterns = append(keyPatterns, api.RemoveKeys...)
+	keyPatterns = append(keyPatterns, api.KeepKeys...)
+	for _, pattern := range keyPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			failures = append(failures, fmt.Sprintf("key pattern %q is not a valid regular expression", pattern))
+		}
+	}
+
+	hasLookupFile := api.LookupFile != "" || flexConfig.LookupFile != ""
+	for _, value := range getReferencedValues(api) {
+		failures = append(failures, validateReferences(value, flexConfig, storedLookups, hasLookupFile || hasPreviousAPI)...)
+	}
+	return failures
+}
+
+func getSources(api FlexAPI) []string {
+	var sources []string
+	if len(api.Commands) > 0 {
+		sources = append(sources, "commands")
+	}
+	if api.URL != "" {
+		sources = append(sources, "url")
+	}
+	if api.File != "" {
+		sources = append(sources, "file")
+	}
+	if api.Database != "" {
+		sources = append(sources, "database")
+	}
+	return sources
+}
+
+func validateCommand(command FlexCommand) []string {
+	var failures []string
+	if strings.TrimSpace(command.Run) == "" {
+		failures = append(failures, "run is required")
+	}
+	if command.Split != "" && !tasks.ContainsString(splitModes, command.Split) {
+		failures = append(failures, fmt.Sprintf("split %q is not one of %s", command.Split, strings.Join(splitModes, ", ")))
+	}
+	if command.SplitBy != "" {
+		if _, err := regexp.Compile(command.SplitBy); err != nil {
+			failures = append(failures, fmt.Sprintf("split_by %q is not a valid regular expression", command.SplitBy))
+		}
+	}
+	if len(command.SetHeader) > 0 && command.Split != "horizontal" {
+		failures = append(failures, "set_header is only used with split: horizontal")
+	}
+	if command.RowStart < 0 || command.RowHeader < 0 || command.LineLimit < 0 {
+		failures = append(failures, "row_start, row_header and line_limit must be positive numbers")
+	}
+	return failures
+}
+
+// getRequestURL - Flex prefixes urls without a scheme with global.base_url
+func getRequestURL(api FlexAPI, global FlexGlobal) string {
+	if strings.Contains(api.URL, "://") || global.BaseURL == "" {
+		return api.URL
+	}
+	return global.BaseURL + api.URL
+}
+
+func validateURL(requestURL string) []string {
+	// references are only resolved at runtime, replace them so the rest of the url can be parsed
+	parsedURL, err := url.Parse(referenceRegex.ReplaceAllString(requestURL, "reference"))
+	if err != nil {
+		return []string{fmt.Sprintf("url %q is not valid: %s", requestURL, err.Error())}
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return []string{fmt.Sprintf("url %q must start with http:// or https://, or global.base_url must be set", requestURL)}
+	}
+	if parsedURL.Host == "" {
+		return []string{fmt.Sprintf("url %q has no host", requestURL)}
+	}
+	return nil
+}
+
+func getReferencedValues(api FlexAPI) []string {
+	values := []string{api.URL, api.Payload}
+	for _, name := range sortedKeys(api.Headers) {
+		values = append(values, api.Headers[name])
+	}
+	for _, command := range api.Commands {
+		values = append(values, command.Run)
+	}
+	return values
+}
+
+func validateReferences(value string, flexConfig FlexConfig, storedLookups map[string]bool, lookupsAvailable bool) []string {
+	var failures []string
+	if strings.Count(value, "${") != len(referenceRegex.FindAllString(value, -1)) {
+		failures = append(failures, fmt.Sprintf("%q has an invalid reference, use ${var:name}, ${lookup:key} or ${lookup.store:key}", value))
+	}
+	for _, match := range referenceRegex.FindAllStringSubmatch(value, -1) {
+		kind, key := match[1], match[2]
+		switch {
+		case kind == "var":
+			if _, ok := flexConfig.VariableStore[key]; !ok {
+				failures = append(failures, fmt.Sprintf("variable %s is not defined in variable_store", key))
+			}
+		case strings.HasPrefix(kind, "lookup."):
+			store := strings.TrimPrefix(kind, "lookup.")
+			if !storedLookups[store] {
+				failures = append(failures, fmt.Sprintf("lookup store %s is not created by the store_lookups of an earlier api", store))
+			}
+		case kind == "lookup":
+			if !lookupsAvailable {
+				failures = append(failures, fmt.Sprintf("lookup %s has no lookup_file or earlier api to read from", key))
+			}
+		}
+	}
+	return failures
+}
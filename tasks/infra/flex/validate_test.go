@@ -0,0 +1,107 @@
+package flex
+
+import (
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Flex/Validate", func() {
+	p := InfraFlexValidate{fileReader: os.ReadFile}
+
+	upstreamFor := func(fileNames ...string) map[string]tasks.Result {
+		var validatedYamls []config.ValidateElement
+		for _, fileName := range fileNames {
+			validatedYamls = append(validatedYamls, config.ValidateElement{Config: config.ConfigElement{FilePath: "fixtures/", FileName: fileName}})
+		}
+		return map[string]tasks.Result{"Infra/Config/IntegrationsValidate": {Status: tasks.Success, Payload: validatedYamls}}
+	}
+
+	Describe("Execute()", func() {
+		Context("When integrations were not validated", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{"Infra/Config/IntegrationsValidate": {Status: tasks.Failure}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the upstream payload has an unexpected type", func() {
+			It("Should return an Error result", func() {
+				upstream := map[string]tasks.Result{"Infra/Config/IntegrationsValidate": {Status: tasks.Success, Payload: "flex.yml"}}
+				Expect(p.Execute(tasks.Options{}, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Error,
+					Summary: tasks.AssertionErrorSummary,
+				}))
+			})
+		})
+
+		Context("When no Flex integration is configured", func() {
+			It("Should return a None result", func() {
+				Expect(p.Execute(tasks.Options{}, upstreamFor("flex-api-template.yml")).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the Flex configurations are valid", func() {
+			It("Should return a Success result with the parsed configurations", func() {
+				result := p.Execute(tasks.Options{}, upstreamFor("flex-disk.yml", "flex-template.yml"))
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.Summary).To(Equal("Successfully validated 2 Flex integration configuration(s)"))
+				Expect(result.Payload).To(HaveLen(2))
+			})
+		})
+	})
+
+	Describe("validateFlexConfig()", func() {
+		It("Should flag invalid sources, urls, commands, jq and references", func() {
+			integration := FlexIntegration{Path: "flex.yml", Config: FlexConfig{
+				Name:          "broken",
+				VariableStore: map[string]string{"host": "localhost"},
+				APIs: []FlexAPI{
+					{Name: "status", URL: "localhost:8080/status", Method: "FETCH", JQ: ".data[] | select(.up"},
+					{Name: "both", URL: "http://${var:host}:8080/${lookup.ids:id}", Commands: []FlexCommand{{Run: "echo ${var:port}", Split: "diagonal", SplitBy: "(", SetHeader: []string{"a"}}}},
+					{EventType: "Flex Sample"},
+				},
+			}}
+			Expect(validateFlexConfig(integration)).To(Equal([]string{
+				`flex.yml: broken: status: url "localhost:8080/status" must start with http:// or https://, or global.base_url must be set`,
+				`flex.yml: broken: status: method "FETCH" is not one of GET, POST, PUT, PATCH, DELETE, HEAD`,
+				`flex.yml: broken: status: jq expression ".data[] | select(.up" has an unclosed '('`,
+				"flex.yml: broken: both: more than one data source defined: commands, url",
+				`flex.yml: broken: both: commands[0]: split "diagonal" is not one of horizontal, vertical`,
+				`flex.yml: broken: both: commands[0]: split_by "(" is not a valid regular expression`,
+				"flex.yml: broken: both: commands[0]: set_header is only used with split: horizontal",
+				"flex.yml: broken: both: lookup store ids is not created by the store_lookups of an earlier api",
+				"flex.yml: broken: both: variable port is not defined in variable_store",
+				"flex.yml: broken: apis[2]: name is required",
+				`flex.yml: broken: apis[2]: event_type "Flex Sample" may only contain letters, numbers, _ and :`,
+				"flex.yml: broken: apis[2]: no data source defined, set one of commands, url, file or database",
+			}))
+		})
+
+		It("Should accept relative urls with a base_url and lookups from earlier apis", func() {
+			integration := FlexIntegration{Path: "flex.yml", Config: FlexConfig{
+				Name:   "orders",
+				Global: FlexGlobal{BaseURL: "https://api.example.com"},
+				APIs: []FlexAPI{
+					{Name: "list", URL: "/orders", StoreLookups: map[string]string{"ids": "id"}},
+					{Name: "detail", URL: "/orders/${lookup.ids:id}", RenameKeys: map[string]string{"^order_": ""}},
+					{Name: "items", URL: "/items/${lookup:id}"},
+				},
+			}}
+			Expect(validateFlexConfig(integration)).To(BeEmpty())
+		})
+
+		It("Should flag references that are not closed", func() {
+			integration := FlexIntegration{Path: "flex.yml", Config: FlexConfig{
+				Name: "echo",
+				APIs: []FlexAPI{{Name: "echo", Commands: []FlexCommand{{Run: "echo ${var:name"}}}},
+			}}
+			Expect(validateFlexConfig(integration)).To(Equal([]string{
+				`flex.yml: echo: echo: "echo ${var:name" has an invalid reference, use ${var:name}, ${lookup:key} or ${lookup.store:key}`,
+			}))
+		})
+	})
+})
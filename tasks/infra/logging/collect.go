@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	c "github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"gopkg.in/yaml.v3"
+)
+
+// InfraLoggingCollect - finds and parses the log forwarding configuration files of the infrastructure agent
+type InfraLoggingCollect struct {
+	fileFinder func([]string, []string) []string
+	fileReader func(string) ([]byte, error)
+}
+
+// LoggingConfigFile - a parsed logging.d configuration file
+type LoggingConfigFile struct {
+	Path    string
+	Entries []LogEntry `yaml:"logs"`
+}
+
+// LogEntry - a single log source forwarded by the infrastructure agent
+type LogEntry struct {
+	Name       string                 `yaml:"name"`
+	File       string                 `yaml:"file"`
+	Systemd    string                 `yaml:"systemd"`
+	Syslog     *SyslogInput           `yaml:"syslog"`
+	TCP        *TCPInput              `yaml:"tcp"`
+	Winlog     *WinlogInput           `yaml:"winlog"`
+	Winevtlog  *WinlogInput           `yaml:"winevtlog"`
+	Fluentbit  *FluentbitInput        `yaml:"fluentbit"`
+	Attributes map[string]interface{} `yaml:"attributes"`
+	Pattern    string                 `yaml:"pattern"`
+	MaxLineKb  int                    `yaml:"max_line_kb"`
+}
+
+// SyslogInput - syslog listener settings of a log entry
+type SyslogInput struct {
+	URI             string `yaml:"uri"`
+	Parser          string `yaml:"parser"`
+	UnixPermissions string `yaml:"unix_permissions"`
+}
+
+// TCPInput - tcp listener settings of a log entry
+type TCPInput struct {
+	URI       string `yaml:"uri"`
+	Format    string `yaml:"format"`
+	Separator string `yaml:"separator"`
+}
+
+// WinlogInput - Windows event log settings of a log entry
+type WinlogInput struct {
+	Channel         string   `yaml:"channel"`
+	CollectEventIDs []string `yaml:"collect-eventids"`
+	ExcludeEventIDs []string `yaml:"exclude-eventids"`
+}
+
+// FluentbitInput - external Fluent Bit configuration referenced by a log entry
+type FluentbitInput struct {
+	ConfigFile  string `yaml:"config_file"`
+	ParsersFile string `yaml:"parsers_file"`
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraLoggingCollect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Logging/Collect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraLoggingCollect) Explain() string {
+	return "Collect New Relic Infrastructure log forwarding configuration files"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraLoggingCollect) Dependencies() []string {
+	return []string{
+		"Infra/Config/Agent",
+	}
+}
+
+// Execute - The core work within each task
+func (p InfraLoggingCollect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Infra Agent detected. Task not executed.",
+		}
+	}
+
+	configFiles := p.fileFinder([]string{".+[.]y(a)?ml$"}, getLoggingDirs(runtime.GOOS))
+	if len(configFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No log forwarding configuration files found in logging.d",
+		}
+	}
+
+	var loggingConfigs []LoggingConfigFile
+	var parseErrors []string
+	var filesToCopy []tasks.FileCopyEnvelope
+	for _, file := range configFiles {
+		question := fmt.Sprintf("We've found a file that may contain secure information: %s\n", file) +
+			"Include this file in nrdiag-output.zip?"
+		if tasks.PromptUser(question, options) {
+			if !c.Flags.Quiet {
+				log.Info("Adding file to Diagnostics CLI zip file: ", file)
+			}
+			filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{Path: file, Identifier: p.Identifier().String()})
+		}
+
+		content, err := p.fileReader(file)
+		if err != nil {
+			parseErrors = append(parseErrors, file+": "+err.Error())
+			continue
+		}
+		loggingConfig := LoggingConfigFile{Path: file}
+		if err := yaml.Unmarshal(content, &loggingConfig); err != nil {
+			parseErrors = append(parseErrors, file+": "+err.Error())
+			continue
+		}
+		loggingConfigs = append(loggingConfigs, loggingConfig)
+	}
+
+	if len(parseErrors) > 0 {
+		return tasks.Result{
+			Status:      tasks.Failure,
+			Summary:     "Unable to parse the following log forwarding configuration files, the agent will not forward the logs they define:\n" + strings.Join(parseErrors, "\n"),
+			URL:         loggingDocsURL,
+			Payload:     loggingConfigs,
+			FilesToCopy: filesToCopy,
+		}
+	}
+
+	var entries int
+	for _, loggingConfig := range loggingConfigs {
+		entries += len(loggingConfig.Entries)
+	}
+	return tasks.Result{
+		Status:      tasks.Success,
+		Summary:     fmt.Sprintf("%d log forwarding configuration file(s) found with %d log source(s)", len(loggingConfigs), entries),
+		Payload:     loggingConfigs,
+		FilesToCopy: filesToCopy,
+	}
+}
+
+func getLoggingDirs(goos string) []string {
+	if goos == "windows" {
+		return []string{`C:\Program Files\New Relic\newrelic-infra\logging.d\`}
+	}
+	return []string{"/etc/newrelic-infra/logging.d/"}
+}
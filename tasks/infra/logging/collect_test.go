@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Logging/Collect", func() {
+	var p InfraLoggingCollect
+	options := tasks.Options{Options: map[string]string{"YesToAll": "true"}}
+	agentDetected := map[string]tasks.Result{"Infra/Config/Agent": {Status: tasks.Success}}
+
+	Describe("Execute()", func() {
+		Context("When the infrastructure agent is not detected", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{"Infra/Config/Agent": {Status: tasks.None}}
+				Expect(p.Execute(options, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When logging.d is empty", func() {
+			It("Should return a None result", func() {
+				p = InfraLoggingCollect{
+					fileFinder: func([]string, []string) []string { return []string{} },
+					fileReader: os.ReadFile,
+				}
+				Expect(p.Execute(options, agentDetected).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When logging.d contains a valid file", func() {
+			It("Should return a Success result with the parsed entries", func() {
+				p = InfraLoggingCollect{
+					fileFinder: func([]string, []string) []string { return []string{"fixtures/logging.d/file.yml"} },
+					fileReader: os.ReadFile,
+				}
+				result := p.Execute(options, agentDetected)
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.Summary).To(Equal("1 log forwarding configuration file(s) found with 3 log source(s)"))
+				Expect(result.FilesToCopy).To(HaveLen(1))
+
+				loggingConfigs := result.Payload.([]LoggingConfigFile)
+				Expect(loggingConfigs[0].Entries[0].File).To(Equal("/var/log/nginx/access.log"))
+				Expect(loggingConfigs[0].Entries[0].Attributes).To(HaveKeyWithValue("logtype", "nginx"))
+				Expect(loggingConfigs[0].Entries[2].Syslog).To(Equal(&SyslogInput{URI: "tcp://0.0.0.0:5140", Parser: "rfc5424"}))
+			})
+		})
+
+		Context("When a logging.d file is not valid yaml", func() {
+			It("Should return a Failure result", func() {
+				p = InfraLoggingCollect{
+					fileFinder: func([]string, []string) []string {
+						return []string{"fixtures/logging.d/file.yml", "fixtures/logging.d/invalid.yml"}
+					},
+					fileReader: os.ReadFile,
+				}
+				result := p.Execute(options, agentDetected)
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("fixtures/logging.d/invalid.yml: yaml:"))
+				Expect(result.Payload).To(HaveLen(1))
+				Expect(result.URL).To(Equal(loggingDocsURL))
+			})
+		})
+	})
+})
@@ -0,0 +1,169 @@
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// InfraLoggingFluentBit - collects the Fluent Bit configuration generated by the agent and the Fluent Bit output from the agent logs
+type InfraLoggingFluentBit struct {
+	globber    func(string) ([]string, error)
+	fileOpener func(string) (*os.File, error)
+}
+
+// FluentBitStatus - the generated Fluent Bit files and the problems Fluent Bit reported in the agent logs
+type FluentBitStatus struct {
+	GeneratedConfigs []string
+	LogLines         int
+	ErrorLines       []string
+}
+
+// maxFluentBitErrors - number of Fluent Bit errors included in the summary, the full output is in the collected file
+const maxFluentBitErrors = 5
+
+var (
+	// the agent writes the generated config to temporary files named nr_fb_config, nr_fb_parsers and nr_fb_lua_filter
+	generatedConfigPatterns = []string{"nr_fb_config*", "nr_fb_parsers*", "nr_fb_lua_filter*"}
+	fluentBitLineRegex      = regexp.MustCompile(`(?i)fluent-?bit`)
+	fluentBitErrorRegex     = regexp.MustCompile(`(?i)level=error|\[\s*error\]|\[\s*warn\]`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraLoggingFluentBit) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Logging/FluentBit")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraLoggingFluentBit) Explain() string {
+	return "Collect the Fluent Bit configuration generated by the New Relic Infrastructure agent and its log output"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraLoggingFluentBit) Dependencies() []string {
+	return []string{
+		"Infra/Logging/Collect",
+		"Infra/Log/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (p InfraLoggingFluentBit) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if !upstream["Infra/Logging/Collect"].HasPayload() {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No log forwarding configuration found. Task not executed.",
+		}
+	}
+
+	status := FluentBitStatus{
+		GeneratedConfigs: p.findGeneratedConfigs(getTempDir(runtime.GOOS)),
+	}
+	filesToCopy := tasks.StringsToFileCopyEnvelopes(status.GeneratedConfigs)
+
+	var logFiles []string
+	if upstream["Infra/Log/Collect"].HasPayload() {
+		if payload, ok := upstream["Infra/Log/Collect"].Payload.([]string); ok {
+			logFiles = payload
+		}
+	}
+	var fluentBitOutput []string
+	for _, logFile := range logFiles {
+		lines, err := p.getFluentBitLines(logFile)
+		if err != nil {
+			log.Debug("Unable to read agent log", logFile, err)
+			continue
+		}
+		fluentBitOutput = append(fluentBitOutput, lines...)
+	}
+	status.LogLines = len(fluentBitOutput)
+	for _, line := range fluentBitOutput {
+		if fluentBitErrorRegex.MatchString(line) {
+			status.ErrorLines = append(status.ErrorLines, line)
+		}
+	}
+	if len(fluentBitOutput) > 0 {
+		stream := make(chan string)
+		go tasks.StreamBlob(strings.Join(fluentBitOutput, "\n"), stream)
+		filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{Path: "fluent-bit-agent-output.txt", Stream: stream})
+	}
+
+	if len(status.GeneratedConfigs) == 0 {
+		return tasks.Result{
+			Status:      tasks.Warning,
+			Summary:     "Log forwarding is configured but no Fluent Bit configuration generated by the agent was found. Check the agent is running and the fluent-bit package is installed.",
+			URL:         loggingDocsURL,
+			Payload:     status,
+			FilesToCopy: filesToCopy,
+		}
+	}
+	if len(status.ErrorLines) > 0 {
+		errorLines := status.ErrorLines
+		if len(errorLines) > maxFluentBitErrors {
+			errorLines = errorLines[len(errorLines)-maxFluentBitErrors:]
+		}
+		return tasks.Result{
+			Status:      tasks.Warning,
+			Summary:     fmt.Sprintf("Fluent Bit reported %d errors or warnings in the agent log, the latest are:\n%s", len(status.ErrorLines), strings.Join(errorLines, "\n")),
+			URL:         loggingDocsURL,
+			Payload:     status,
+			FilesToCopy: filesToCopy,
+		}
+	}
+	return tasks.Result{
+		Status:      tasks.Success,
+		Summary:     fmt.Sprintf("Found %d Fluent Bit configuration file(s) generated by the agent.", len(status.GeneratedConfigs)),
+		Payload:     status,
+		FilesToCopy: filesToCopy,
+	}
+}
+
+// getTempDir - the temporary directory of the agent service, which does not share the environment nrdiag runs with
+func getTempDir(goos string) string {
+	if goos == "windows" {
+		return `C:\Windows\Temp`
+	}
+	return "/tmp"
+}
+
+func (p InfraLoggingFluentBit) findGeneratedConfigs(tempDir string) []string {
+	var generatedConfigs []string
+	for _, pattern := range generatedConfigPatterns {
+		matches, err := p.globber(filepath.Join(tempDir, pattern))
+		if err != nil {
+			log.Debug("Unable to search for generated Fluent Bit files", err)
+			continue
+		}
+		generatedConfigs = append(generatedConfigs, matches...)
+	}
+	return generatedConfigs
+}
+
+func (p InfraLoggingFluentBit) getFluentBitLines(logFile string) ([]string, error) {
+	file, err := p.fileOpener(logFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return filterFluentBitLines(file)
+}
+
+func filterFluentBitLines(reader io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); fluentBitLineRegex.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Logging/FluentBit", func() {
+	var p InfraLoggingFluentBit
+
+	loggingConfigured := map[string]tasks.Result{
+		"Infra/Logging/Collect": {Status: tasks.Success, Payload: []LoggingConfigFile{{Path: "logging.d/app.yml"}}},
+		"Infra/Log/Collect":     {Status: tasks.Success, Payload: []string{"fixtures/newrelic-infra.log"}},
+	}
+
+	Describe("Execute()", func() {
+		Context("When there is no log forwarding configuration", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{"Infra/Logging/Collect": {Status: tasks.None}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the agent has not generated a Fluent Bit configuration", func() {
+			It("Should return a Warning result", func() {
+				p = InfraLoggingFluentBit{
+					globber:    func(string) ([]string, error) { return nil, nil },
+					fileOpener: os.Open,
+				}
+				result := p.Execute(tasks.Options{}, loggingConfigured)
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("no Fluent Bit configuration generated by the agent was found"))
+			})
+		})
+
+		Context("When Fluent Bit reported errors in the agent log", func() {
+			It("Should return a Warning result and collect the Fluent Bit output", func() {
+				p = InfraLoggingFluentBit{
+					globber: func(pattern string) ([]string, error) {
+						if filepath.Base(pattern) == "nr_fb_config*" {
+							return []string{"/tmp/nr_fb_config012345"}, nil
+						}
+						return nil, nil
+					},
+					fileOpener: os.Open,
+				}
+				result := p.Execute(tasks.Options{}, loggingConfigured)
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("read error, check permissions: /var/log/secure"))
+
+				status := result.Payload.(FluentBitStatus)
+				Expect(status.GeneratedConfigs).To(Equal([]string{"/tmp/nr_fb_config012345"}))
+				Expect(status.LogLines).To(Equal(3))
+				Expect(status.ErrorLines).To(HaveLen(1))
+
+				Expect(result.FilesToCopy).To(HaveLen(2))
+				Expect(result.FilesToCopy[0].Path).To(Equal("/tmp/nr_fb_config012345"))
+				var streamed string
+				for line := range result.FilesToCopy[1].Stream {
+					streamed += line
+				}
+				Expect(streamed).To(ContainSubstring("version=2.2.0"))
+				Expect(streamed).NotTo(ContainSubstring("Agent connected"))
+			})
+		})
+	})
+})
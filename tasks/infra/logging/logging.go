@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Infra/Logging/*")
+
+	registrationFunc(InfraLoggingCollect{
+		fileFinder: tasks.FindFiles,
+		fileReader: os.ReadFile,
+	}, true)
+	registrationFunc(InfraLoggingValidate{
+		runtimeOS:       runtime.GOOS,
+		globber:         filepath.Glob,
+		agentUserGetter: getAgentUser,
+		readableChecker: checkReadableBy,
+	}, true)
+	registrationFunc(InfraLoggingFluentBit{
+		globber:    filepath.Glob,
+		fileOpener: os.Open,
+	}, true)
+}
+
+// getAgentUser - returns the user the infrastructure agent process runs as, empty when it is not running
+func getAgentUser() string {
+	processes, err := tasks.FindProcessByName("newrelic-infra")
+	if err != nil {
+		log.Debug("Unable to list processes", err)
+		return ""
+	}
+	for i := range processes {
+		username, err := processes[i].Username()
+		if err == nil && username != "" {
+			return username
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package logging
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// checkReadableBy - checks the file mode grants read access to the user the agent runs as. When the agent user is
+// unknown or root, the file is opened with the permissions nrdiag runs with instead.
+func checkReadableBy(path string, username string) error {
+	if username == "" || username == "root" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	agentUser, err := user.Lookup(username)
+	if err != nil {
+		return err
+	}
+
+	mode := info.Mode().Perm()
+	if strconv.FormatUint(uint64(stat.Uid), 10) == agentUser.Uid {
+		if mode&0400 != 0 {
+			return nil
+		}
+		return errors.New("the file owner has no read permission")
+	}
+	groupIDs, err := agentUser.GroupIds()
+	if err != nil {
+		return err
+	}
+	for _, groupID := range groupIDs {
+		if strconv.FormatUint(uint64(stat.Gid), 10) == groupID {
+			if mode&0040 != 0 {
+				return nil
+			}
+			return errors.New("user " + username + " is in the file group but the group has no read permission")
+		}
+	}
+	if mode&0004 != 0 {
+		return nil
+	}
+	return errors.New("user " + username + " is not the owner nor in the group of the file and others have no read permission")
+}
@@ -0,0 +1,29 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package logging
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("checkReadableBy()", func() {
+	It("Should open the file when the agent user is unknown", func() {
+		Expect(checkReadableBy("fixtures/newrelic-infra.log", "")).To(BeNil())
+		Expect(checkReadableBy("fixtures/missing.log", "")).NotTo(BeNil())
+	})
+
+	It("Should check the file mode against the owner", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "app.log")
+		Expect(os.WriteFile(path, []byte("log"), 0200)).To(Succeed())
+		currentUser := os.Getenv("USER")
+		if currentUser == "" || currentUser == "root" {
+			Skip("requires a non root user")
+		}
+		Expect(checkReadableBy(path, currentUser)).To(MatchError("the file owner has no read permission"))
+	})
+})
@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package logging
+
+import "os"
+
+// checkReadableBy - the agent runs as LocalSystem on Windows, so opening the file is enough to validate access
+func checkReadableBy(path string, username string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
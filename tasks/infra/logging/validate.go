@@ -0,0 +1,285 @@
+package logging
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// InfraLoggingValidate - validates the log sources defined in logging.d
+type InfraLoggingValidate struct {
+	runtimeOS       string
+	globber         func(string) ([]string, error)
+	agentUserGetter func() string
+	readableChecker func(path string, username string) error
+}
+
+const (
+	loggingDocsURL          = "https://docs.newrelic.com/docs/logs/forward-logs/forward-your-logs-using-infrastructure-agent/"
+	maxAttributeNameLength  = 255
+	maxAttributeValueLength = 4096
+)
+
+var (
+	syslogSchemes = []string{"tcp", "udp", "unix_tcp", "unix_udp"}
+	syslogParsers = []string{"rfc5424", "rfc3164", "rfc3164-local"}
+	tcpFormats    = []string{"json", "none"}
+	// Fluent Bit uses Onigmo for the grep filter, RE2 cannot compile lookarounds or backreferences
+	onigmoOnlyRegex = regexp.MustCompile(`\(\?<?[=!]|\\[1-9]`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraLoggingValidate) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Logging/Validate")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraLoggingValidate) Explain() string {
+	return "Validate New Relic Infrastructure log forwarding sources, attributes and patterns"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraLoggingValidate) Dependencies() []string {
+	return []string{
+		"Infra/Logging/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (p InfraLoggingValidate) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if !upstream["Infra/Logging/Collect"].HasPayload() {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No log forwarding configuration found. Task not executed.",
+		}
+	}
+	loggingConfigs, ok := upstream["Infra/Logging/Collect"].Payload.([]LoggingConfigFile)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	agentUser := p.agentUserGetter()
+	var failures, warnings []string
+	names := map[string]string{}
+	for _, loggingConfig := range loggingConfigs {
+		if len(loggingConfig.Entries) == 0 {
+			warnings = append(warnings, loggingConfig.Path+": no entries found under logs")
+			continue
+		}
+		for i, entry := range loggingConfig.Entries {
+			label := fmt.Sprintf("%s: logs[%d]", loggingConfig.Path, i)
+			if entry.Name != "" {
+				label = fmt.Sprintf("%s: %s", loggingConfig.Path, entry.Name)
+				if otherFile, ok := names[entry.Name]; ok {
+					warnings = append(warnings, fmt.Sprintf("%s: name is also used in %s", label, otherFile))
+				}
+				names[entry.Name] = loggingConfig.Path
+			} else {
+				failures = append(failures, label+": name is required")
+			}
+
+			entryFailures, entryWarnings := p.validateEntry(entry, agentUser)
+			for _, failure := range entryFailures {
+				failures = append(failures, label+": "+failure)
+			}
+			for _, warning := range entryWarnings {
+				warnings = append(warnings, label+": "+warning)
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Invalid log forwarding configuration found:\n" + strings.Join(append(failures, warnings...), "\n"),
+			URL:     loggingDocsURL,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "Potential issues found in log forwarding configuration:\n" + strings.Join(warnings, "\n"),
+			URL:     loggingDocsURL,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: "Log forwarding configuration is valid.",
+	}
+}
+
+func (p InfraLoggingValidate) validateEntry(entry LogEntry, agentUser string) (failures []string, warnings []string) {
+	sources := getSources(entry)
+	if len(sources) == 0 {
+		return []string{"no log source defined, set one of file, systemd, syslog, tcp, winlog, winevtlog or fluentbit"}, nil
+	}
+	if len(sources) > 1 {
+		failures = append(failures, "more than one log source defined: "+strings.Join(sources, ", "))
+	}
+
+	if entry.File != "" {
+		failures = append(failures, p.validateFile(entry.File, agentUser)...)
+	}
+	if entry.Systemd != "" && p.runtimeOS != "linux" {
+		failures = append(failures, "systemd sources are only supported on Linux")
+	}
+	if entry.Syslog != nil {
+		failures = append(failures, validateListener("syslog", entry.Syslog.URI, syslogSchemes)...)
+		if entry.Syslog.Parser != "" && !tasks.ContainsString(syslogParsers, entry.Syslog.Parser) {
+			failures = append(failures, fmt.Sprintf("syslog parser %q is not one of %s", entry.Syslog.Parser, strings.Join(syslogParsers, ", ")))
+		}
+	}
+	if entry.TCP != nil {
+		failures = append(failures, validateListener("tcp", entry.TCP.URI, []string{"tcp"})...)
+		if entry.TCP.Format != "" && !tasks.ContainsString(tcpFormats, entry.TCP.Format) {
+			failures = append(failures, fmt.Sprintf("tcp format %q is not one of %s", entry.TCP.Format, strings.Join(tcpFormats, ", ")))
+		}
+	}
+	winlogSources := []struct {
+		name  string
+		input *WinlogInput
+	}{{"winlog", entry.Winlog}, {"winevtlog", entry.Winevtlog}}
+	for _, source := range winlogSources {
+		if source.input == nil {
+			continue
+		}
+		if p.runtimeOS != "windows" {
+			failures = append(failures, source.name+" sources are only supported on Windows")
+		}
+		if source.input.Channel == "" {
+			failures = append(failures, source.name+" channel is required")
+		}
+	}
+	if entry.Fluentbit != nil {
+		if entry.Fluentbit.ConfigFile == "" {
+			failures = append(failures, "fluentbit config_file is required")
+		} else {
+			failures = append(failures, p.validateFile(entry.Fluentbit.ConfigFile, agentUser)...)
+		}
+		if entry.Fluentbit.ParsersFile != "" {
+			failures = append(failures, p.validateFile(entry.Fluentbit.ParsersFile, agentUser)...)
+		}
+	}
+
+	failures = append(failures, validateAttributes(entry.Attributes)...)
+	if entry.Pattern != "" {
+		if err := validatePattern(entry.Pattern); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if entry.MaxLineKb < 0 {
+		failures = append(failures, "max_line_kb must be a positive number")
+	}
+	return failures, warnings
+}
+
+func getSources(entry LogEntry) []string {
+	var sources []string
+	if entry.File != "" {
+		sources = append(sources, "file")
+	}
+	if entry.Systemd != "" {
+		sources = append(sources, "systemd")
+	}
+	if entry.Syslog != nil {
+		sources = append(sources, "syslog")
+	}
+	if entry.TCP != nil {
+		sources = append(sources, "tcp")
+	}
+	if entry.Winlog != nil {
+		sources = append(sources, "winlog")
+	}
+	if entry.Winevtlog != nil {
+		sources = append(sources, "winevtlog")
+	}
+	if entry.Fluentbit != nil {
+		sources = append(sources, "fluentbit")
+	}
+	return sources
+}
+
+// validateFile - checks a file, or the files matching a wildcard path, exist and can be read by the agent
+func (p InfraLoggingValidate) validateFile(path string, agentUser string) []string {
+	matches, err := p.globber(path)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid file path %s: %s", path, err.Error())}
+	}
+	if len(matches) == 0 {
+		return []string{fmt.Sprintf("no file matches %s", path)}
+	}
+
+	var failures []string
+	for _, match := range matches {
+		if err := p.readableChecker(match, agentUser); err != nil {
+			failures = append(failures, fmt.Sprintf("%s cannot be read by the agent: %s", match, err.Error()))
+		}
+	}
+	return failures
+}
+
+// validateListener - checks uris as scheme://host:port or unix_tcp:///path/to/socket, the underscores in unix
+// schemes are not valid for url.Parse
+func validateListener(source string, uri string, schemes []string) []string {
+	if uri == "" {
+		return []string{source + " uri is required"}
+	}
+	scheme, address, found := strings.Cut(uri, "://")
+	if !found || !tasks.ContainsString(schemes, scheme) {
+		return []string{fmt.Sprintf("%s uri %q must use one of the schemes %s", source, uri, strings.Join(schemes, ", "))}
+	}
+	if strings.HasPrefix(scheme, "unix_") {
+		if !strings.HasPrefix(address, "/") {
+			return []string{fmt.Sprintf("%s uri %q has no absolute socket path", source, uri)}
+		}
+		return nil
+	}
+	if _, port, err := net.SplitHostPort(address); err != nil || port == "" {
+		return []string{fmt.Sprintf("%s uri %q has no port", source, uri)}
+	}
+	return nil
+}
+
+func validateAttributes(attributes map[string]interface{}) []string {
+	var keys []string
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var failures []string
+	for _, key := range keys {
+		value := attributes[key]
+		if len(key) > maxAttributeNameLength {
+			failures = append(failures, fmt.Sprintf("attribute name %.32s... is longer than %d characters", key, maxAttributeNameLength))
+		}
+		switch typedValue := value.(type) {
+		case string:
+			if len(typedValue) > maxAttributeValueLength {
+				failures = append(failures, fmt.Sprintf("value of attribute %s is longer than %d characters", key, maxAttributeValueLength))
+			}
+		case int, float64, bool:
+		default:
+			failures = append(failures, fmt.Sprintf("value of attribute %s must be a string, number or boolean", key))
+		}
+	}
+	return failures
+}
+
+// validatePattern - compiles the pattern when RE2 supports its syntax, Onigmo-only constructs are left to Fluent Bit
+func validatePattern(pattern string) error {
+	if onigmoOnlyRegex.MatchString(pattern) {
+		return nil
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("pattern %q is not a valid regular expression: %s", pattern, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Logging/Validate", func() {
+	var p InfraLoggingValidate
+
+	upstreamFor := func(entries ...LogEntry) map[string]tasks.Result {
+		return map[string]tasks.Result{
+			"Infra/Logging/Collect": {
+				Status:  tasks.Success,
+				Payload: []LoggingConfigFile{{Path: "logging.d/app.yml", Entries: entries}},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		p = InfraLoggingValidate{
+			runtimeOS: "linux",
+			globber: func(pattern string) ([]string, error) {
+				switch pattern {
+				case "/var/log/app/*.log":
+					return []string{"/var/log/app/a.log", "/var/log/app/b.log"}, nil
+				case "/var/log/missing.log":
+					return nil, nil
+				}
+				return []string{pattern}, nil
+			},
+			agentUserGetter: func() string { return "nri-agent" },
+			readableChecker: func(path string, username string) error {
+				if path == "/var/log/app/b.log" {
+					return errors.New("user " + username + " is not the owner nor in the group of the file and others have no read permission")
+				}
+				return nil
+			},
+		}
+	})
+
+	Describe("Execute()", func() {
+		Context("When there is no log forwarding configuration", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{"Infra/Logging/Collect": {Status: tasks.None}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the upstream payload has an unexpected type", func() {
+			It("Should return an Error result", func() {
+				upstream := map[string]tasks.Result{"Infra/Logging/Collect": {Status: tasks.Success, Payload: "logging.d"}}
+				Expect(p.Execute(tasks.Options{}, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Error,
+					Summary: tasks.AssertionErrorSummary,
+				}))
+			})
+		})
+
+		Context("When all entries are valid", func() {
+			It("Should return a Success result", func() {
+				upstream := upstreamFor(
+					LogEntry{Name: "app", File: "/var/log/app.log", Attributes: map[string]interface{}{"logtype": "app", "retries": 3}},
+					LogEntry{Name: "tcp", TCP: &TCPInput{URI: "tcp://0.0.0.0:1234", Format: "json"}},
+					LogEntry{Name: "syslog", Syslog: &SyslogInput{URI: "unix_udp:///var/run/syslog.sock", Parser: "rfc3164"}},
+				)
+				Expect(p.Execute(tasks.Options{}, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Success,
+					Summary: "Log forwarding configuration is valid.",
+				}))
+			})
+		})
+
+		Context("When entries reference unreadable files or use invalid settings", func() {
+			It("Should return a Failure result listing each problem", func() {
+				upstream := upstreamFor(
+					LogEntry{Name: "app", File: "/var/log/app/*.log"},
+					LogEntry{Name: "missing", File: "/var/log/missing.log"},
+					LogEntry{Name: "events", Winlog: &WinlogInput{}},
+					LogEntry{File: "/var/log/other.log", Systemd: "sshd"},
+				)
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.URL).To(Equal(loggingDocsURL))
+				Expect(strings.Split(result.Summary, "\n")[1:]).To(Equal([]string{
+					"logging.d/app.yml: app: /var/log/app/b.log cannot be read by the agent: user nri-agent is not the owner nor in the group of the file and others have no read permission",
+					"logging.d/app.yml: missing: no file matches /var/log/missing.log",
+					"logging.d/app.yml: events: winlog sources are only supported on Windows",
+					"logging.d/app.yml: events: winlog channel is required",
+					"logging.d/app.yml: logs[3]: name is required",
+					"logging.d/app.yml: logs[3]: more than one log source defined: file, systemd",
+				}))
+			})
+		})
+
+		Context("When the same name is used twice", func() {
+			It("Should return a Warning result", func() {
+				upstream := upstreamFor(
+					LogEntry{Name: "app", File: "/var/log/app.log"},
+					LogEntry{Name: "app", Systemd: "app"},
+				)
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("logging.d/app.yml: app: name is also used in logging.d/app.yml"))
+			})
+		})
+	})
+
+	Describe("validateEntry()", func() {
+		It("Should flag an entry without a source", func() {
+			failures, _ := p.validateEntry(LogEntry{Name: "empty"}, "root")
+			Expect(failures).To(Equal([]string{"no log source defined, set one of file, systemd, syslog, tcp, winlog, winevtlog or fluentbit"}))
+		})
+
+		It("Should validate listener uris, parsers and formats", func() {
+			failures, _ := p.validateEntry(LogEntry{Name: "syslog", Syslog: &SyslogInput{URI: "http://0.0.0.0:5140", Parser: "json"}}, "root")
+			Expect(failures).To(Equal([]string{
+				`syslog uri "http://0.0.0.0:5140" must use one of the schemes tcp, udp, unix_tcp, unix_udp`,
+				`syslog parser "json" is not one of rfc5424, rfc3164, rfc3164-local`,
+			}))
+
+			failures, _ = p.validateEntry(LogEntry{Name: "tcp", TCP: &TCPInput{URI: "tcp://0.0.0.0", Format: "xml"}}, "root")
+			Expect(failures).To(Equal([]string{
+				`tcp uri "tcp://0.0.0.0" has no port`,
+				`tcp format "xml" is not one of json, none`,
+			}))
+		})
+
+		It("Should require the config file of external Fluent Bit configurations", func() {
+			failures, _ := p.validateEntry(LogEntry{Name: "fb", Fluentbit: &FluentbitInput{ParsersFile: "/var/log/missing.log"}}, "root")
+			Expect(failures).To(Equal([]string{"fluentbit config_file is required", "no file matches /var/log/missing.log"}))
+		})
+
+		It("Should flag invalid attributes and patterns", func() {
+			entry := LogEntry{
+				Name:       "app",
+				Systemd:    "app",
+				Attributes: map[string]interface{}{"tags": []interface{}{"a", "b"}, "note": strings.Repeat("x", maxAttributeValueLength+1)},
+				Pattern:    "ERROR(",
+			}
+			failures, _ := p.validateEntry(entry, "root")
+			Expect(failures).To(Equal([]string{
+				"value of attribute note is longer than 4096 characters",
+				"value of attribute tags must be a string, number or boolean",
+				"pattern \"ERROR(\" is not a valid regular expression: error parsing regexp: missing closing ): `ERROR(`",
+			}))
+		})
+	})
+
+	Describe("validatePattern()", func() {
+		It("Should leave Onigmo lookarounds to Fluent Bit", func() {
+			Expect(validatePattern(`^(?!DEBUG).*`)).To(BeNil())
+			Expect(validatePattern(`(\w+) \1`)).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,296 @@
+package extensions
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+)
+
+// JavaExtensionsClassPath - scans the classpath of the running Java applications
+type JavaExtensionsClassPath struct {
+	globber    func(string) ([]string, error)
+	zipOpener  func(string) (*zip.ReadCloser, error)
+	walkDir    func(string, fs.WalkDirFunc) error
+	fileReader func(string) ([]byte, error)
+}
+
+// AppClassPath - the classes an application with a Java agent can load
+type AppClassPath struct {
+	Pid     int32
+	Entries []string
+	// Classes holds the internal names (com/example/MyClass) of every class, it is left out of the output because of its size
+	Classes map[string]bool `json:"-"`
+	// TraceClasses are the classes referencing the @Trace annotation of the newrelic-api
+	TraceClasses []string
+	APIJars      []APIJar
+}
+
+// APIJar - a newrelic-api jar found on the classpath
+type APIJar struct {
+	Path    string
+	Version string
+}
+
+var (
+	// the descriptor the compiler writes into the constant pool of classes annotated with @Trace
+	traceDescriptor = []byte("Lcom/newrelic/api/agent/Trace;")
+	apiJarRegex     = regexp.MustCompile(`newrelic-api-(\d+\.\d+\.\d+)\.jar$`)
+	// the class directories of Spring Boot jars, war files and multi-release jars
+	classPrefixRegex = regexp.MustCompile(`^(BOOT-INF/classes/|WEB-INF/classes/|META-INF/versions/\d+/)`)
+	// libraries packaged inside Spring Boot jars and war files
+	nestedJarRegex = regexp.MustCompile(`^(BOOT-INF|WEB-INF)/lib/[^/]+\.jar$`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaExtensionsClassPath) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Extensions/ClassPath")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaExtensionsClassPath) Explain() string {
+	return "Scan the classpath of Java applications running the New Relic Java agent"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaExtensionsClassPath) Dependencies() []string {
+	return []string{
+		"Java/Env/Process",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaExtensionsClassPath) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Java/Env/Process"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No running Java agent was found. This task did not run",
+		}
+	}
+	processes, ok := upstream["Java/Env/Process"].Payload.([]env.ProcIdAndArgs)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var classPaths []AppClassPath
+	for i := range processes {
+		classPath := p.scanClassPath(&processes[i])
+		if len(classPath.Classes) == 0 {
+			log.Debug("No classes found on the classpath of process", classPath.Pid)
+			continue
+		}
+		classPaths = append(classPaths, classPath)
+	}
+	if len(classPaths) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Unable to find the classpath of the running Java applications",
+		}
+	}
+
+	var summaries []string
+	for _, classPath := range classPaths {
+		summaries = append(summaries, fmt.Sprintf("process %d: %d classes in %d classpath entries", classPath.Pid, len(classPath.Classes), len(classPath.Entries)))
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: "Scanned the classpath of the running Java applications:\n" + strings.Join(summaries, "\n"),
+		Payload: classPaths,
+	}
+}
+
+func (p JavaExtensionsClassPath) scanClassPath(process *env.ProcIdAndArgs) AppClassPath {
+	classPath := AppClassPath{Pid: process.Proc.Pid, Classes: map[string]bool{}}
+	for _, entry := range p.getClassPathEntries(process) {
+		classPath.Entries = append(classPath.Entries, entry)
+		lowerEntry := strings.ToLower(entry)
+		if strings.HasSuffix(lowerEntry, ".jar") || strings.HasSuffix(lowerEntry, ".war") || strings.HasSuffix(lowerEntry, ".zip") {
+			p.scanJar(entry, &classPath)
+			continue
+		}
+		p.scanDir(entry, &classPath)
+	}
+	sort.Strings(classPath.TraceClasses)
+	return classPath
+}
+
+// getClassPathEntries - reads -cp, -classpath and --class-path, or the jar run with -jar and the Class-Path of its manifest, falling back to CLASSPATH
+func (p JavaExtensionsClassPath) getClassPathEntries(process *env.ProcIdAndArgs) []string {
+	var rawEntries []string
+	args := process.CmdLineArgs
+	for i, arg := range args {
+		var value string
+		switch {
+		case (arg == "-cp" || arg == "-classpath" || arg == "--class-path") && i+1 < len(args):
+			value = args[i+1]
+		case strings.HasPrefix(arg, "--class-path="):
+			value = strings.TrimPrefix(arg, "--class-path=")
+		case arg == "-jar" && i+1 < len(args):
+			jar := p.resolve(process, args[i+1])
+			rawEntries = append(rawEntries, jar)
+			rawEntries = append(rawEntries, p.getManifestClassPath(jar)...)
+			// the arguments after the jar belong to the application
+			return p.expandEntries(process, rawEntries)
+		}
+		if value != "" {
+			rawEntries = append(rawEntries, filepath.SplitList(value)...)
+		}
+	}
+	if len(rawEntries) == 0 && process.EnvVars["CLASSPATH"] != "" {
+		rawEntries = filepath.SplitList(process.EnvVars["CLASSPATH"])
+	}
+	return p.expandEntries(process, rawEntries)
+}
+
+// expandEntries - resolves relative entries against the working directory of the process and expands dir/* to the jars of dir
+func (p JavaExtensionsClassPath) expandEntries(process *env.ProcIdAndArgs, rawEntries []string) []string {
+	var entries []string
+	for _, entry := range rawEntries {
+		entry = p.resolve(process, entry)
+		if filepath.Base(entry) != "*" {
+			entries = append(entries, entry)
+			continue
+		}
+		for _, pattern := range []string{"*.jar", "*.JAR"} {
+			matches, err := p.globber(filepath.Join(filepath.Dir(entry), pattern))
+			if err != nil {
+				log.Debug("Unable to expand classpath entry", entry, ":", err)
+			}
+			entries = append(entries, matches...)
+		}
+	}
+	return entries
+}
+
+func (p JavaExtensionsClassPath) resolve(process *env.ProcIdAndArgs, entry string) string {
+	entry = strings.Trim(entry, `"'`)
+	if !filepath.IsAbs(entry) && process.Cwd != "" {
+		entry = filepath.Join(process.Cwd, entry)
+	}
+	return entry
+}
+
+// getManifestClassPath - the Class-Path attribute lists jars relative to the jar that declares it
+func (p JavaExtensionsClassPath) getManifestClassPath(jar string) []string {
+	reader, err := p.zipOpener(jar)
+	if err != nil {
+		log.Debug("Unable to open", jar, ":", err)
+		return nil
+	}
+	defer reader.Close()
+	manifest, err := readManifest(&reader.Reader)
+	if err != nil {
+		return nil
+	}
+	var entries []string
+	for _, entry := range strings.Fields(manifest["Class-Path"]) {
+		if !filepath.IsAbs(entry) {
+			entry = filepath.Join(filepath.Dir(jar), filepath.FromSlash(entry))
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (p JavaExtensionsClassPath) scanJar(path string, classPath *AppClassPath) {
+	reader, err := p.zipOpener(path)
+	if err != nil {
+		log.Debug("Unable to open", path, ":", err)
+		return
+	}
+	defer reader.Close()
+	scanZip(path, &reader.Reader, classPath, true)
+}
+
+func scanZip(path string, reader *zip.Reader, classPath *AppClassPath, scanNested bool) {
+	isAPIJar := false
+	for _, file := range reader.File {
+		switch {
+		case strings.HasSuffix(file.Name, ".class"):
+			name := strings.TrimSuffix(classPrefixRegex.ReplaceAllString(file.Name, ""), ".class")
+			classPath.Classes[name] = true
+			if name == "com/newrelic/api/agent/Trace" {
+				isAPIJar = true
+			}
+			if content, err := readZipFile(file); err == nil {
+				classPath.addTraceClass(name, content)
+			}
+		case scanNested && nestedJarRegex.MatchString(file.Name):
+			content, err := readZipFile(file)
+			if err != nil {
+				log.Debug("Unable to read", file.Name, "in", path, ":", err)
+				continue
+			}
+			nested, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+			if err != nil {
+				log.Debug("Unable to open", file.Name, "in", path, ":", err)
+				continue
+			}
+			scanZip(path+"!/"+file.Name, nested, classPath, false)
+		}
+	}
+	if isAPIJar {
+		classPath.APIJars = append(classPath.APIJars, APIJar{Path: path, Version: getAPIVersion(path, reader)})
+	}
+}
+
+// getAPIVersion - the version is in the file name of the jar published to Maven Central, shaded copies keep it in the manifest
+func getAPIVersion(path string, reader *zip.Reader) string {
+	if match := apiJarRegex.FindStringSubmatch(path); match != nil {
+		return match[1]
+	}
+	manifest, err := readManifest(reader)
+	if err != nil {
+		return ""
+	}
+	return manifest["Implementation-Version"]
+}
+
+// addTraceClass - a class only references the descriptor of an annotation it uses, the newrelic-api and agent classes are not the application's
+func (c *AppClassPath) addTraceClass(name string, content []byte) {
+	if bytes.Contains(content, traceDescriptor) && !strings.HasPrefix(name, "com/newrelic/") {
+		c.TraceClasses = append(c.TraceClasses, name)
+	}
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func (p JavaExtensionsClassPath) scanDir(dir string, classPath *AppClassPath) {
+	err := p.walkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".class") {
+			return nil
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(relative), ".class")
+		classPath.Classes[name] = true
+		if content, err := p.fileReader(path); err == nil {
+			classPath.addTraceClass(name, content)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Debug("Unable to scan", dir, ":", err)
+	}
+}
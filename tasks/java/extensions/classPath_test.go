@@ -0,0 +1,126 @@
+package extensions
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Java/Extensions/ClassPath", func() {
+	p := JavaExtensionsClassPath{
+		globber:    filepath.Glob,
+		zipOpener:  zip.OpenReader,
+		walkDir:    filepath.WalkDir,
+		fileReader: os.ReadFile,
+	}
+	var dir string
+
+	nestedJar := func(entries map[string]string) string {
+		var buffer bytes.Buffer
+		writer := zip.NewWriter(&buffer)
+		for name, content := range entries {
+			entry, err := writer.Create(name)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = entry.Write([]byte(content))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(writer.Close()).To(Succeed())
+		return buffer.String()
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "lib"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "classes", "com", "example"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "classes", "com", "example", "Traced.class"), []byte("cafebabe Lcom/newrelic/api/agent/Trace; "), 0644)).To(Succeed())
+		writeJar(filepath.Join(dir, "lib", "newrelic-api-8.10.0.jar"), map[string]string{
+			"com/newrelic/api/agent/Trace.class":    "cafebabe Lcom/newrelic/api/agent/Trace;",
+			"com/newrelic/api/agent/NewRelic.class": "cafebabe",
+		})
+		writeJar(filepath.Join(dir, "lib", "orders.jar"), map[string]string{
+			"com/example/orders/OrderProcessor.class": "cafebabe",
+		})
+		writeJar(filepath.Join(dir, "app.jar"), map[string]string{
+			manifestPath:                             "Manifest-Version: 1.0\nClass-Path: lib/orders.jar\n",
+			"BOOT-INF/classes/com/example/App.class": "cafebabe Lcom/newrelic/api/agent/Trace;",
+			"BOOT-INF/lib/shaded-api.jar": nestedJar(map[string]string{
+				manifestPath:                         "Manifest-Version: 1.0\nImplementation-Version: 8.12.0\n",
+				"com/newrelic/api/agent/Trace.class": "cafebabe",
+			}),
+		})
+	})
+
+	Describe("Execute()", func() {
+		It("Should not run without a running Java agent", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Failure},
+			})
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		It("Should scan the jars and directories of -cp", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Success, Payload: []env.ProcIdAndArgs{{
+					Cwd:         dir,
+					CmdLineArgs: []string{"java", "-javaagent:newrelic.jar", "-cp", "classes" + string(filepath.ListSeparator) + "lib/*", "com.example.Main"},
+				}}},
+			})
+			Expect(result.Status).To(Equal(tasks.Info))
+			classPaths := result.Payload.([]AppClassPath)
+			Expect(classPaths).To(HaveLen(1))
+			Expect(classPaths[0].Classes).To(HaveKey("com/example/Traced"))
+			Expect(classPaths[0].Classes).To(HaveKey("com/example/orders/OrderProcessor"))
+			Expect(classPaths[0].TraceClasses).To(Equal([]string{"com/example/Traced"}))
+			Expect(classPaths[0].APIJars).To(Equal([]APIJar{{Path: filepath.Join(dir, "lib", "newrelic-api-8.10.0.jar"), Version: "8.10.0"}}))
+		})
+
+		It("Should scan the jar run with -jar, its nested jars and its manifest Class-Path", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Success, Payload: []env.ProcIdAndArgs{{
+					Cwd:         dir,
+					CmdLineArgs: []string{"java", "-javaagent:newrelic.jar", "-jar", "app.jar", "-cp", "ignored"},
+				}}},
+			})
+			Expect(result.Status).To(Equal(tasks.Info))
+			classPath := result.Payload.([]AppClassPath)[0]
+			Expect(classPath.Entries).To(Equal([]string{filepath.Join(dir, "app.jar"), filepath.Join(dir, "lib", "orders.jar")}))
+			Expect(classPath.Classes).To(HaveKey("com/example/App"))
+			Expect(classPath.Classes).To(HaveKey("com/example/orders/OrderProcessor"))
+			Expect(classPath.TraceClasses).To(Equal([]string{"com/example/App"}))
+			Expect(classPath.APIJars).To(Equal([]APIJar{{Path: filepath.Join(dir, "app.jar") + "!/BOOT-INF/lib/shaded-api.jar", Version: "8.12.0"}}))
+		})
+
+		It("Should fall back to the CLASSPATH environment variable", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Success, Payload: []env.ProcIdAndArgs{{
+					CmdLineArgs: []string{"java", "-javaagent:newrelic.jar", "com.example.Main"},
+					EnvVars:     map[string]string{"CLASSPATH": filepath.Join(dir, "classes")},
+				}}},
+			})
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload.([]AppClassPath)[0].Entries).To(Equal([]string{filepath.Join(dir, "classes")}))
+		})
+
+		It("Should return None when no classes are found", func() {
+			result := JavaExtensionsClassPath{
+				globber:    filepath.Glob,
+				zipOpener:  zip.OpenReader,
+				walkDir:    func(string, fs.WalkDirFunc) error { return fs.ErrNotExist },
+				fileReader: os.ReadFile,
+			}.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Success, Payload: []env.ProcIdAndArgs{{
+					CmdLineArgs: []string{"java", "-javaagent:newrelic.jar", "-cp", "/missing", "com.example.Main"},
+				}}},
+			})
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(Equal("Unable to find the classpath of the running Java applications"))
+		})
+	})
+})
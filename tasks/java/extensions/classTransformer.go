@@ -0,0 +1,131 @@
+package extensions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// JavaExtensionsClassTransformer - flags class_transformer settings referencing classes the application no longer has
+type JavaExtensionsClassTransformer struct {
+}
+
+var (
+	// class_transformer settings whose values are class names, excludes also accepts regular expressions
+	classTransformerClassSettings = []string{"excludes", "trace_annotation_class_name"}
+	classNameRegex                = regexp.MustCompile(`^[\w$]+([./][\w$]+)+$`)
+	// the classes of the JVM are not on the classpath of the application
+	jdkClassPrefixes = []string{"java/", "javax/", "jdk/", "sun/", "com/sun/"}
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaExtensionsClassTransformer) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Extensions/ClassTransformer")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaExtensionsClassTransformer) Explain() string {
+	return "Check that the classes named in the class_transformer settings of newrelic.yml are on the application classpath"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaExtensionsClassTransformer) Dependencies() []string {
+	return []string{
+		"Java/Config/Agent",
+		"Java/Extensions/ClassPath",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaExtensionsClassTransformer) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Java/Extensions/ClassPath"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The classpath of the running Java applications was not scanned. This task did not run",
+		}
+	}
+	classPaths, ok := upstream["Java/Extensions/ClassPath"].Payload.([]AppClassPath)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+	configs, ok := upstream["Java/Config/Agent"].Payload.([]config.ValidateElement)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var warnings []string
+	settingsFound := false
+	for _, configElement := range configs {
+		file := configElement.Config.FilePath + configElement.Config.FileName
+		classes := getClassTransformerClasses(configElement.ParsedResult)
+		for _, setting := range classTransformerClassSettings {
+			settingsFound = settingsFound || len(classes[setting]) > 0
+			for _, className := range classes[setting] {
+				for _, classPath := range classPaths {
+					if !classPath.Classes[className] {
+						warnings = append(warnings, fmt.Sprintf("%s: class_transformer %s references %s, which is not on the classpath of process %d", file, setting, strings.ReplaceAll(className, "/", "."), classPath.Pid))
+					}
+				}
+			}
+		}
+	}
+
+	if !settingsFound {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No class_transformer settings referencing classes were found in newrelic.yml",
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "The following class_transformer settings no longer apply to the application and can be removed:\n" + strings.Join(warnings, "\n"),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/java-agent/configuration/java-agent-configuration-config-file/#class-transformer",
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: "Every class referenced by the class_transformer settings is on the application classpath",
+	}
+}
+
+// getClassTransformerClasses - returns the internal names of the classes of each class_transformer setting, regular expressions are skipped
+func getClassTransformerClasses(parsedConfig tasks.ValidateBlob) map[string][]string {
+	classes := map[string][]string{}
+	for _, classTransformer := range parsedConfig.FindKey("class_transformer") {
+		for _, child := range classTransformer.Children {
+			if !tasks.ContainsString(classTransformerClassSettings, child.Key) {
+				continue
+			}
+			for _, value := range strings.FieldsFunc(child.Value(), func(r rune) bool { return r == ',' || r == ' ' }) {
+				if !classNameRegex.MatchString(value) {
+					continue
+				}
+				className := strings.ReplaceAll(value, ".", "/")
+				if isJDKClass(className) || tasks.ContainsString(classes[child.Key], className) {
+					continue
+				}
+				classes[child.Key] = append(classes[child.Key], className)
+			}
+		}
+	}
+	return classes
+}
+
+func isJDKClass(className string) bool {
+	for _, prefix := range jdkClassPrefixes {
+		if strings.HasPrefix(className, prefix) {
+			return true
+		}
+	}
+	return false
+}
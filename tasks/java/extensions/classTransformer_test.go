@@ -0,0 +1,61 @@
+package extensions
+
+import (
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Java/Extensions/ClassTransformer", func() {
+	p := JavaExtensionsClassTransformer{}
+
+	agentConfig := func(yml string) []config.ValidateElement {
+		parsed, err := config.ParseYaml(strings.NewReader(yml))
+		Expect(err).ToNot(HaveOccurred())
+		return []config.ValidateElement{{
+			Config:       config.ConfigElement{FileName: "newrelic.yml", FilePath: "/opt/newrelic/"},
+			ParsedResult: parsed,
+		}}
+	}
+	classPath := tasks.Result{Status: tasks.Info, Payload: []AppClassPath{{
+		Pid:     42,
+		Classes: map[string]bool{"com/example/orders/OrderProcessor": true, "com/example/Traced": true},
+	}}}
+
+	It("Should not run without the classpath", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Extensions/ClassPath": {Status: tasks.None},
+		})
+		Expect(result.Status).To(Equal(tasks.None))
+	})
+
+	It("Should return None without class_transformer settings", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Config/Agent":         {Status: tasks.Success, Payload: agentConfig("common:\n  app_name: orders\n")},
+			"Java/Extensions/ClassPath": classPath,
+		})
+		Expect(result.Status).To(Equal(tasks.None))
+	})
+
+	It("Should succeed when the referenced classes are on the classpath", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Config/Agent":         {Status: tasks.Success, Payload: agentConfig("common:\n  class_transformer:\n    excludes: com.example.orders.OrderProcessor, ^com/example/gen/.*, java.lang.Thread\n")},
+			"Java/Extensions/ClassPath": classPath,
+		})
+		Expect(result.Status).To(Equal(tasks.Success))
+	})
+
+	It("Should warn about classes missing from the classpath", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Config/Agent":         {Status: tasks.Success, Payload: agentConfig("common:\n  class_transformer:\n    excludes: com.example.orders.OrderProcessor,com.example.Removed\n    trace_annotation_class_name: com.example.CustomTrace\n")},
+			"Java/Extensions/ClassPath": classPath,
+		})
+		Expect(result.Status).To(Equal(tasks.Warning))
+		Expect(result.Summary).To(Equal("The following class_transformer settings no longer apply to the application and can be removed:\n" +
+			"/opt/newrelic/newrelic.yml: class_transformer excludes references com.example.Removed, which is not on the classpath of process 42\n" +
+			"/opt/newrelic/newrelic.yml: class_transformer trace_annotation_class_name references com.example.CustomTrace, which is not on the classpath of process 42"))
+	})
+})
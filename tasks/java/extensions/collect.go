@@ -0,0 +1,116 @@
+package extensions
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+)
+
+// JavaExtensionsCollect - finds the extensions directory of the running Java agents
+type JavaExtensionsCollect struct {
+	globber func(string) ([]string, error)
+}
+
+// ExtensionsDir - the custom instrumentation files and extension jars loaded by a Java agent
+type ExtensionsDir struct {
+	Dir      string
+	XMLFiles []string
+	Jars     []string
+}
+
+const extensionsDirProperty = "-Dnewrelic.config.extensions.dir="
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaExtensionsCollect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Extensions/Collect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaExtensionsCollect) Explain() string {
+	return "Collect the custom instrumentation files and extension jars of the New Relic Java agent"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaExtensionsCollect) Dependencies() []string {
+	return []string{
+		"Java/Env/Process",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaExtensionsCollect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Java/Env/Process"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No running Java agent was found. This task did not run",
+		}
+	}
+	processes, ok := upstream["Java/Env/Process"].Payload.([]env.ProcIdAndArgs)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var extensionsDirs []ExtensionsDir
+	var searched []string
+	var filesToCopy []tasks.FileCopyEnvelope
+	for i := range processes {
+		dir := getExtensionsDir(&processes[i])
+		if tasks.ContainsString(searched, dir) {
+			continue
+		}
+		searched = append(searched, dir)
+
+		extensionsDir := ExtensionsDir{Dir: dir}
+		for _, pattern := range []string{"*.xml", "*.jar"} {
+			matches, err := p.globber(filepath.Join(dir, pattern))
+			if err != nil {
+				log.Debug("Unable to search", dir, ":", err)
+				continue
+			}
+			if pattern == "*.xml" {
+				extensionsDir.XMLFiles = matches
+			} else {
+				extensionsDir.Jars = matches
+			}
+		}
+		if len(extensionsDir.XMLFiles) == 0 && len(extensionsDir.Jars) == 0 {
+			continue
+		}
+		extensionsDirs = append(extensionsDirs, extensionsDir)
+		filesToCopy = append(filesToCopy, tasks.StringsToFileCopyEnvelopes(extensionsDir.XMLFiles)...)
+	}
+
+	if len(extensionsDirs) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Java agent extensions were found in " + strings.Join(searched, ", "),
+		}
+	}
+	return tasks.Result{
+		Status:      tasks.Success,
+		Summary:     fmt.Sprintf("Found Java agent extensions in %d director(ies)", len(extensionsDirs)),
+		Payload:     extensionsDirs,
+		FilesToCopy: filesToCopy,
+	}
+}
+
+// getExtensionsDir - the agent loads the extensions directory next to newrelic.jar unless the newrelic.config.extensions.dir property is set
+func getExtensionsDir(process *env.ProcIdAndArgs) string {
+	dir := filepath.Join(filepath.Dir(process.JarPath), "extensions")
+	for _, arg := range process.CmdLineArgs {
+		if strings.HasPrefix(arg, extensionsDirProperty) {
+			dir = strings.Trim(strings.TrimPrefix(arg, extensionsDirProperty), `"'`)
+		}
+	}
+	if !filepath.IsAbs(dir) && process.Cwd != "" {
+		dir = filepath.Join(process.Cwd, dir)
+	}
+	return filepath.Clean(dir)
+}
@@ -0,0 +1,88 @@
+package extensions
+
+import (
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Java/Extensions/Collect", func() {
+	var p JavaExtensionsCollect
+
+	BeforeEach(func() {
+		p = JavaExtensionsCollect{
+			globber: func(pattern string) ([]string, error) {
+				switch pattern {
+				case filepath.Join("/opt/newrelic/extensions", "*.xml"):
+					return []string{"/opt/newrelic/extensions/orders.xml"}, nil
+				case filepath.Join("/opt/newrelic/extensions", "*.jar"):
+					return []string{"/opt/newrelic/extensions/orders.jar"}, nil
+				}
+				return nil, nil
+			},
+		}
+	})
+
+	Describe("Dependencies()", func() {
+		It("Should return the dependencies", func() {
+			Expect(p.Dependencies()).To(Equal([]string{"Java/Env/Process"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		It("Should not run without a running Java agent", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Failure},
+			})
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		It("Should return an assertion error for an unexpected payload", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Success, Payload: "unexpected"},
+			})
+			Expect(result).To(Equal(tasks.Result{Status: tasks.Error, Summary: tasks.AssertionErrorSummary}))
+		})
+
+		It("Should collect the extensions next to the agent jar of each process once", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Success, Payload: []env.ProcIdAndArgs{
+					{JarPath: "/opt/newrelic/newrelic.jar", Cwd: "/srv/app"},
+					{JarPath: "newrelic.jar", Cwd: "/opt/newrelic"},
+				}},
+			})
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload).To(Equal([]ExtensionsDir{{
+				Dir:      "/opt/newrelic/extensions",
+				XMLFiles: []string{"/opt/newrelic/extensions/orders.xml"},
+				Jars:     []string{"/opt/newrelic/extensions/orders.jar"},
+			}}))
+			Expect(result.FilesToCopy).To(HaveLen(1))
+			Expect(result.FilesToCopy[0].Path).To(Equal("/opt/newrelic/extensions/orders.xml"))
+		})
+
+		It("Should return None when the extensions directory is empty", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Success, Payload: []env.ProcIdAndArgs{
+					{JarPath: "/srv/app/newrelic/newrelic.jar"},
+				}},
+			})
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(Equal("No Java agent extensions were found in /srv/app/newrelic/extensions"))
+		})
+	})
+
+	Describe("getExtensionsDir()", func() {
+		It("Should honor the extensions dir system property", func() {
+			process := env.ProcIdAndArgs{
+				JarPath:     "/opt/newrelic/newrelic.jar",
+				Cwd:         "/srv/app",
+				CmdLineArgs: []string{"java", "-javaagent:/opt/newrelic/newrelic.jar", "-Dnewrelic.config.extensions.dir=custom/extensions", "-jar", "app.jar"},
+			}
+			Expect(getExtensionsDir(&process)).To(Equal("/srv/app/custom/extensions"))
+		})
+	})
+})
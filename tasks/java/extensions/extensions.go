@@ -0,0 +1,33 @@
+package extensions
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Java/Extensions/*")
+
+	registrationFunc(JavaExtensionsCollect{
+		globber: filepath.Glob,
+	}, true)
+	registrationFunc(JavaExtensionsValidateXML{
+		fileReader: os.ReadFile,
+	}, true)
+	registrationFunc(JavaExtensionsValidateJars{
+		zipOpener: zip.OpenReader,
+	}, true)
+	registrationFunc(JavaExtensionsClassPath{
+		globber:    filepath.Glob,
+		zipOpener:  zip.OpenReader,
+		walkDir:    filepath.WalkDir,
+		fileReader: os.ReadFile,
+	}, true)
+	registrationFunc(JavaExtensionsClassTransformer{}, true)
+	registrationFunc(JavaExtensionsTraceAPI{}, true)
+}
@@ -0,0 +1,41 @@
+package extensions
+
+import (
+	"archive/zip"
+	"os"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestJavaExtensions(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Java/Extensions/* test suite")
+}
+
+func TestRegisterWithCount(t *testing.T) {
+	registeredTasks := []tasks.Task{}
+	RegisterWith(func(task tasks.Task, runByDefault bool) {
+		registeredTasks = append(registeredTasks, task)
+	})
+	if len(registeredTasks) != 6 {
+		t.Errorf("RegisterWith() registered %d tasks, want 6", len(registeredTasks))
+	}
+}
+
+// writeJar - writes a jar with the given entries for the tests that read jars
+func writeJar(path string, entries map[string]string) {
+	file, err := os.Create(path)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for name, content := range entries {
+		entry, err := writer.Create(name)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = entry.Write([]byte(content))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(writer.Close()).To(Succeed())
+}
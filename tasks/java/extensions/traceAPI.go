@@ -0,0 +1,107 @@
+package extensions
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// JavaExtensionsTraceAPI - reports the @Trace usage of the applications and compares their newrelic-api with the agent
+type JavaExtensionsTraceAPI struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaExtensionsTraceAPI) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Extensions/TraceAPI")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaExtensionsTraceAPI) Explain() string {
+	return "Check @Trace annotation usage and the newrelic-api version of Java applications against the Java agent version"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaExtensionsTraceAPI) Dependencies() []string {
+	return []string{
+		"Java/Extensions/ClassPath",
+		"Java/Agent/Version",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaExtensionsTraceAPI) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Java/Extensions/ClassPath"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The classpath of the running Java applications was not scanned. This task did not run",
+		}
+	}
+	classPaths, ok := upstream["Java/Extensions/ClassPath"].Payload.([]AppClassPath)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+	agentVersion, _ := upstream["Java/Agent/Version"].Payload.(string)
+
+	var warnings, summaries []string
+	for _, classPath := range classPaths {
+		if len(classPath.TraceClasses) == 0 && len(classPath.APIJars) == 0 {
+			continue
+		}
+		summaries = append(summaries, fmt.Sprintf("process %d: %d class(es) use @Trace", classPath.Pid, len(classPath.TraceClasses)))
+
+		var versions []string
+		for _, apiJar := range classPath.APIJars {
+			summaries = append(summaries, fmt.Sprintf("process %d: newrelic-api %s in %s", classPath.Pid, apiJar.Version, apiJar.Path))
+			if apiJar.Version != "" && !tasks.ContainsString(versions, apiJar.Version) {
+				versions = append(versions, apiJar.Version)
+			}
+			if warning := compareAPIVersion(apiJar, agentVersion); warning != "" {
+				warnings = append(warnings, fmt.Sprintf("process %d: %s", classPath.Pid, warning))
+			}
+		}
+		if len(versions) > 1 {
+			warnings = append(warnings, fmt.Sprintf("process %d: the classpath has several newrelic-api versions (%s), the first one loaded wins", classPath.Pid, strings.Join(versions, ", ")))
+		}
+	}
+
+	if len(summaries) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The running Java applications do not use the New Relic Java agent API",
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(append(warnings, summaries...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/java-agent/api-guides/guide-using-java-agent-api/",
+			Payload: classPaths,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: classPaths,
+	}
+}
+
+// compareAPIVersion - the agent implements the API calls it shipped with, the ones of a newer newrelic-api are no-ops
+func compareAPIVersion(apiJar APIJar, agentVersion string) string {
+	if apiJar.Version == "" || agentVersion == "" {
+		return ""
+	}
+	isNewer, err := tasks.VersionIsCompatible(apiJar.Version, []string{agentVersion + "+"})
+	if err != nil {
+		log.Debug("Unable to compare the newrelic-api version", apiJar.Version, "with the agent version", agentVersion, ":", err)
+		return ""
+	}
+	if isNewer && apiJar.Version != agentVersion {
+		return fmt.Sprintf("newrelic-api %s in %s is newer than the Java agent %s, the API calls added since %s do nothing. Please upgrade the agent to %s or later", apiJar.Version, apiJar.Path, agentVersion, agentVersion, apiJar.Version)
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package extensions
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Java/Extensions/TraceAPI", func() {
+	p := JavaExtensionsTraceAPI{}
+
+	upstream := func(agentVersion string, classPaths ...AppClassPath) map[string]tasks.Result {
+		return map[string]tasks.Result{
+			"Java/Extensions/ClassPath": {Status: tasks.Info, Payload: classPaths},
+			"Java/Agent/Version":        {Status: tasks.Info, Payload: agentVersion},
+		}
+	}
+
+	It("Should not run without the classpath", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Extensions/ClassPath": {Status: tasks.None},
+		})
+		Expect(result.Status).To(Equal(tasks.None))
+	})
+
+	It("Should return None when the API is not used", func() {
+		result := p.Execute(tasks.Options{}, upstream("8.10.0", AppClassPath{Pid: 42}))
+		Expect(result.Status).To(Equal(tasks.None))
+	})
+
+	It("Should report the API usage", func() {
+		result := p.Execute(tasks.Options{}, upstream("8.10.0", AppClassPath{
+			Pid:          42,
+			TraceClasses: []string{"com/example/Traced"},
+			APIJars:      []APIJar{{Path: "/srv/app/lib/newrelic-api-8.9.0.jar", Version: "8.9.0"}},
+		}))
+		Expect(result.Status).To(Equal(tasks.Info))
+		Expect(result.Summary).To(Equal("process 42: 1 class(es) use @Trace\nprocess 42: newrelic-api 8.9.0 in /srv/app/lib/newrelic-api-8.9.0.jar"))
+	})
+
+	It("Should warn about an API newer than the agent and several API versions", func() {
+		result := p.Execute(tasks.Options{}, upstream("8.10.0", AppClassPath{
+			Pid: 42,
+			APIJars: []APIJar{
+				{Path: "/srv/app/lib/newrelic-api-8.10.0.jar", Version: "8.10.0"},
+				{Path: "/srv/app/lib/newrelic-api-8.12.0.jar", Version: "8.12.0"},
+			},
+		}))
+		Expect(result.Status).To(Equal(tasks.Warning))
+		Expect(result.Summary).To(ContainSubstring("process 42: newrelic-api 8.12.0 in /srv/app/lib/newrelic-api-8.12.0.jar is newer than the Java agent 8.10.0"))
+		Expect(result.Summary).To(ContainSubstring("process 42: the classpath has several newrelic-api versions (8.10.0, 8.12.0)"))
+	})
+})
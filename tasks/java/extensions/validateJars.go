@@ -0,0 +1,165 @@
+package extensions
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// JavaExtensionsValidateJars - checks that the extension jars have the manifest the agent needs to load them
+type JavaExtensionsValidateJars struct {
+	zipOpener func(string) (*zip.ReadCloser, error)
+}
+
+// ExtensionJar - the manifest attributes the agent reads from an extension jar
+type ExtensionJar struct {
+	Path    string
+	Title   string
+	Version string
+	// Weave is set for instrumentation modules built with the weaver, the other jars are loaded as legacy extensions
+	Weave bool
+}
+
+const manifestPath = "META-INF/MANIFEST.MF"
+
+// the agent refuses to load an extension jar that does not name and version itself
+var requiredManifestAttributes = []string{"Implementation-Title", "Implementation-Version"}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaExtensionsValidateJars) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Extensions/ValidateJars")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaExtensionsValidateJars) Explain() string {
+	return "Check the manifest of New Relic Java agent extension jars"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaExtensionsValidateJars) Dependencies() []string {
+	return []string{
+		"Java/Extensions/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaExtensionsValidateJars) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Java/Extensions/Collect"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Java agent extensions were found. This task did not run",
+		}
+	}
+	extensionsDirs, ok := upstream["Java/Extensions/Collect"].Payload.([]ExtensionsDir)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var failures []string
+	var jars []ExtensionJar
+	for _, extensionsDir := range extensionsDirs {
+		for _, path := range extensionsDir.Jars {
+			jar, failure := p.readExtensionJar(path)
+			if failure != "" {
+				failures = append(failures, failure)
+				continue
+			}
+			jars = append(jars, jar)
+		}
+	}
+
+	if len(jars) == 0 && len(failures) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No extension jars were found in the extensions directory",
+		}
+	}
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "The Java agent will not load the following extension jar(s):\n" + strings.Join(failures, "\n"),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/java-agent/custom-instrumentation/java-instrumentation-xml/#extensions",
+			Payload: jars,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("All %d extension jar(s) have a valid manifest", len(jars)),
+		Payload: jars,
+	}
+}
+
+func (p JavaExtensionsValidateJars) readExtensionJar(path string) (ExtensionJar, string) {
+	reader, err := p.zipOpener(path)
+	if err != nil {
+		return ExtensionJar{}, fmt.Sprintf("%s: is not a valid jar: %s", path, err.Error())
+	}
+	defer reader.Close()
+
+	manifest, err := readManifest(&reader.Reader)
+	if err != nil {
+		return ExtensionJar{}, fmt.Sprintf("%s: %s", path, err.Error())
+	}
+	var missing []string
+	for _, attribute := range requiredManifestAttributes {
+		if manifest[attribute] == "" {
+			missing = append(missing, attribute)
+		}
+	}
+	if len(missing) > 0 {
+		return ExtensionJar{}, fmt.Sprintf("%s: %s is missing %s", path, manifestPath, strings.Join(missing, ", "))
+	}
+	_, weave := manifest["Weave-Classes"]
+	return ExtensionJar{
+		Path:    path,
+		Title:   manifest["Implementation-Title"],
+		Version: manifest["Implementation-Version"],
+		Weave:   weave,
+	}, ""
+}
+
+// readManifest - reads the main attributes of the manifest of a jar
+func readManifest(reader *zip.Reader) (map[string]string, error) {
+	file, err := reader.Open(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s was not found", manifestPath)
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %s", manifestPath, err.Error())
+	}
+	return parseManifest(content), nil
+}
+
+// parseManifest - lines longer than 72 bytes continue on the next line after a single space, a blank line ends the main section
+func parseManifest(content []byte) map[string]string {
+	attributes := map[string]string{}
+	var last string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, " ") && last != "" {
+			attributes[last] += line[1:]
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		last = strings.TrimSpace(name)
+		attributes[last] = strings.TrimSpace(value)
+	}
+	return attributes
+}
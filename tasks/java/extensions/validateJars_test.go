@@ -0,0 +1,66 @@
+package extensions
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Java/Extensions/ValidateJars", func() {
+	p := JavaExtensionsValidateJars{zipOpener: zip.OpenReader}
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		writeJar(filepath.Join(dir, "weave.jar"), map[string]string{
+			manifestPath: "Manifest-Version: 1.0\r\nImplementation-Title: com.example.orders-instrume\r\n ntation\r\nImplementation-Version: 1.2\r\nWeave-Classes: com.example.Orders\r\n\r\nName: com/example/\r\nSealed: true\r\n",
+		})
+		writeJar(filepath.Join(dir, "unversioned.jar"), map[string]string{
+			manifestPath: "Manifest-Version: 1.0\nImplementation-Title: orders\n",
+		})
+		writeJar(filepath.Join(dir, "nomanifest.jar"), map[string]string{
+			"com/example/Orders.class": "",
+		})
+		Expect(os.WriteFile(filepath.Join(dir, "broken.jar"), []byte("not a jar"), 0644)).To(Succeed())
+	})
+
+	It("Should not run without extensions", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Extensions/Collect": {Status: tasks.None},
+		})
+		Expect(result.Status).To(Equal(tasks.None))
+	})
+
+	It("Should read the manifest of valid jars", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Extensions/Collect": {Status: tasks.Success, Payload: []ExtensionsDir{{Dir: dir, Jars: []string{filepath.Join(dir, "weave.jar")}}}},
+		})
+		Expect(result.Status).To(Equal(tasks.Success))
+		Expect(result.Payload).To(Equal([]ExtensionJar{{
+			Path:    filepath.Join(dir, "weave.jar"),
+			Title:   "com.example.orders-instrumentation",
+			Version: "1.2",
+			Weave:   true,
+		}}))
+	})
+
+	It("Should fail for the jars the agent cannot load", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Extensions/Collect": {Status: tasks.Success, Payload: []ExtensionsDir{{Dir: dir, Jars: []string{
+				filepath.Join(dir, "weave.jar"),
+				filepath.Join(dir, "unversioned.jar"),
+				filepath.Join(dir, "nomanifest.jar"),
+				filepath.Join(dir, "broken.jar"),
+			}}}},
+		})
+		Expect(result.Status).To(Equal(tasks.Failure))
+		Expect(result.Summary).To(ContainSubstring(filepath.Join(dir, "unversioned.jar") + ": META-INF/MANIFEST.MF is missing Implementation-Version"))
+		Expect(result.Summary).To(ContainSubstring(filepath.Join(dir, "nomanifest.jar") + ": META-INF/MANIFEST.MF was not found"))
+		Expect(result.Summary).To(ContainSubstring(filepath.Join(dir, "broken.jar") + ": is not a valid jar"))
+		Expect(result.Payload).To(HaveLen(1))
+	})
+})
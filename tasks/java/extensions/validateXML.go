@@ -0,0 +1,331 @@
+package extensions
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// JavaExtensionsValidateXML - validates the custom instrumentation files against the agent's extension schema
+type JavaExtensionsValidateXML struct {
+	fileReader func(string) ([]byte, error)
+}
+
+// Pointcut - the methods instrumented by a pointcut of a custom instrumentation file
+type Pointcut struct {
+	File          string
+	Line          int
+	ClassName     string
+	InterfaceName string
+	Methods       []string
+}
+
+const customInstrumentationDocsURL = "https://docs.newrelic.com/docs/apm/agents/java-agent/custom-instrumentation/java-instrumentation-xml/"
+
+// the agent accepts the namespace of the published schema and the one of its examples
+var extensionNamespaces = []string{"https://newrelic.com/docs/java/xsd/v1.0", "newrelic-extension"}
+
+// elementSchema - an element of extension-2.0.xsd, with its allowed children and attributes
+type elementSchema struct {
+	children   []string
+	attributes []string
+	required   []string
+	booleans   []string
+	// attributes that only accept a set of values
+	enums map[string][]string
+	// elements that only contain text
+	text bool
+}
+
+// extensionSchema - the elements of extension-2.0.xsd shipped with the Java agent
+var extensionSchema = map[string]elementSchema{
+	"extension": {
+		children:   []string{"instrumentation"},
+		attributes: []string{"name", "version", "enabled"},
+		required:   []string{"name"},
+		booleans:   []string{"enabled"},
+	},
+	"instrumentation": {
+		children:   []string{"pointcut"},
+		attributes: []string{"metricPrefix"},
+	},
+	"pointcut": {
+		children:   []string{"nameTransaction", "className", "interfaceName", "methodAnnotation", "method", "traceLambda", "traceByReturnType"},
+		attributes: []string{"transactionStartPoint", "metricNameFormat", "excludeFromTransactionTrace", "ignoreTransaction", "transactionType", "leaf"},
+		booleans:   []string{"transactionStartPoint", "excludeFromTransactionTrace", "ignoreTransaction", "leaf"},
+		enums:      map[string][]string{"transactionType": {"web", "background"}},
+	},
+	"nameTransaction":   {},
+	"className":         {attributes: []string{"includeSubclasses"}, booleans: []string{"includeSubclasses"}, text: true},
+	"interfaceName":     {text: true},
+	"methodAnnotation":  {text: true},
+	"traceLambda":       {attributes: []string{"pattern", "includeNonstatic"}, booleans: []string{"includeNonstatic"}},
+	"traceByReturnType": {text: true},
+	"method": {
+		children: []string{"name", "parameters", "returnType"},
+	},
+	"name":       {text: true},
+	"returnType": {text: true},
+	"parameters": {
+		children: []string{"type"},
+	},
+	"type": {attributes: []string{"attributeName"}, text: true},
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaExtensionsValidateXML) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Extensions/ValidateXML")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaExtensionsValidateXML) Explain() string {
+	return "Validate New Relic Java agent custom instrumentation file(s) against the extension schema"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaExtensionsValidateXML) Dependencies() []string {
+	return []string{
+		"Java/Extensions/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaExtensionsValidateXML) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Java/Extensions/Collect"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Java agent extensions were found. This task did not run",
+		}
+	}
+	extensionsDirs, ok := upstream["Java/Extensions/Collect"].Payload.([]ExtensionsDir)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var failures []string
+	var pointcuts []Pointcut
+	var files int
+	for _, extensionsDir := range extensionsDirs {
+		for _, path := range extensionsDir.XMLFiles {
+			files++
+			content, err := p.fileReader(path)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: unable to read file: %s", path, err.Error()))
+				continue
+			}
+			filePointcuts, fileFailures := validateExtension(path, content)
+			pointcuts = append(pointcuts, filePointcuts...)
+			failures = append(failures, fileFailures...)
+		}
+	}
+
+	if files == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No custom instrumentation files were found in the extensions directory",
+		}
+	}
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Invalid custom instrumentation found, the Java agent skips invalid extension files:\n" + strings.Join(failures, "\n"),
+			URL:     customInstrumentationDocsURL,
+			Payload: pointcuts,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("Successfully validated %d custom instrumentation pointcut(s) in %d file(s)", len(pointcuts), files),
+		Payload: pointcuts,
+	}
+}
+
+type xmlElement struct {
+	name       xml.Name
+	attributes map[string]string
+	children   []*xmlElement
+	text       string
+	line       int
+}
+
+// parseXMLElements - reads a document into a tree of elements so the line of each element can be reported
+func parseXMLElements(content []byte) (*xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var root *xmlElement
+	var stack []*xmlElement
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch typedToken := token.(type) {
+		case xml.StartElement:
+			line, _ := decoder.InputPos()
+			element := &xmlElement{name: typedToken.Name, attributes: map[string]string{}, line: line}
+			for _, attribute := range typedToken.Attr {
+				// namespace declarations and xsi attributes are not part of the extension schema
+				if attribute.Name.Space == "xmlns" || attribute.Name.Local == "xmlns" || attribute.Name.Space == "http://www.w3.org/2001/XMLSchema-instance" {
+					continue
+				}
+				element.attributes[attribute.Name.Local] = attribute.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, element)
+			} else {
+				root = element
+			}
+			stack = append(stack, element)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(typedToken)
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+// validateExtension - checks an extension file against extensionSchema and returns its pointcuts
+func validateExtension(path string, content []byte) ([]Pointcut, []string) {
+	root, err := parseXMLElements(content)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("%s: is not valid XML: %s", path, err.Error())}
+	}
+	if root.name.Local != "extension" || !tasks.ContainsString(extensionNamespaces, root.name.Space) {
+		return nil, []string{fmt.Sprintf("%s: root element must be <extension xmlns=%q>", path, extensionNamespaces[0])}
+	}
+
+	var failures []string
+	var pointcuts []Pointcut
+	var walk func(element *xmlElement)
+	walk = func(element *xmlElement) {
+		location := fmt.Sprintf("%s:%d", path, element.line)
+		schema := extensionSchema[element.name.Local]
+		failures = append(failures, validateAttributes(location, element, schema)...)
+		if schema.text && strings.TrimSpace(element.text) == "" {
+			failures = append(failures, fmt.Sprintf("%s: <%s> must not be empty", location, element.name.Local))
+		}
+
+		switch element.name.Local {
+		case "pointcut":
+			pointcut, pointcutFailures := validatePointcut(location, element)
+			pointcut.File, pointcut.Line = path, element.line
+			pointcuts = append(pointcuts, pointcut)
+			failures = append(failures, pointcutFailures...)
+		case "method":
+			if len(childrenNamed(element, "name")) != 1 {
+				failures = append(failures, fmt.Sprintf("%s: <method> must contain exactly one <name>", location))
+			}
+		}
+
+		for _, child := range element.children {
+			if !tasks.ContainsString(schema.children, child.name.Local) || child.name.Space != root.name.Space {
+				failures = append(failures, fmt.Sprintf("%s:%d: <%s> is not allowed in <%s>", path, child.line, child.name.Local, element.name.Local))
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(root)
+	return pointcuts, failures
+}
+
+// validatePointcut - a pointcut matches classes by one of className, interfaceName or methodAnnotation
+func validatePointcut(location string, element *xmlElement) (Pointcut, []string) {
+	var pointcut Pointcut
+	var failures []string
+	var matchers []string
+	for _, matcher := range []string{"className", "interfaceName", "methodAnnotation", "traceLambda", "traceByReturnType"} {
+		if count := len(childrenNamed(element, matcher)); count > 0 {
+			matchers = append(matchers, matcher)
+			if count > 1 {
+				failures = append(failures, fmt.Sprintf("%s: <pointcut> may only contain one <%s>", location, matcher))
+			}
+		}
+	}
+	if len(matchers) == 0 {
+		failures = append(failures, fmt.Sprintf("%s: <pointcut> must contain a <className>, <interfaceName> or <methodAnnotation>", location))
+	}
+	if tasks.ContainsString(matchers, "className") && tasks.ContainsString(matchers, "interfaceName") {
+		failures = append(failures, fmt.Sprintf("%s: <pointcut> may not contain both <className> and <interfaceName>", location))
+	}
+
+	for _, child := range element.children {
+		value := strings.TrimSpace(child.text)
+		switch child.name.Local {
+		case "className":
+			pointcut.ClassName = value
+		case "interfaceName":
+			pointcut.InterfaceName = value
+		case "method":
+			for _, name := range childrenNamed(child, "name") {
+				pointcut.Methods = append(pointcut.Methods, strings.TrimSpace(name.text))
+			}
+		}
+	}
+	if (pointcut.ClassName != "" || pointcut.InterfaceName != "") && len(pointcut.Methods) == 0 {
+		failures = append(failures, fmt.Sprintf("%s: <pointcut> must contain at least one <method> for its class", location))
+	}
+	// the class matchers take fully qualified names, the agent does not expand wildcards
+	for _, name := range []string{pointcut.ClassName, pointcut.InterfaceName} {
+		if strings.ContainsAny(name, "*?/") {
+			failures = append(failures, fmt.Sprintf("%s: %q must be a fully qualified class name such as com.example.MyClass", location, name))
+		}
+	}
+	return pointcut, failures
+}
+
+func validateAttributes(location string, element *xmlElement, schema elementSchema) []string {
+	var failures []string
+	for _, name := range schema.required {
+		if strings.TrimSpace(element.attributes[name]) == "" {
+			failures = append(failures, fmt.Sprintf("%s: <%s> is missing the required %s attribute", location, element.name.Local, name))
+		}
+	}
+	names := make([]string, 0, len(element.attributes))
+	for name := range element.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := element.attributes[name]
+		if !tasks.ContainsString(schema.attributes, name) {
+			failures = append(failures, fmt.Sprintf("%s: %s is not an attribute of <%s>", location, name, element.name.Local))
+			continue
+		}
+		if tasks.ContainsString(schema.booleans, name) && value != "true" && value != "false" {
+			failures = append(failures, fmt.Sprintf("%s: %s must be true or false", location, name))
+		}
+		if values, isEnum := schema.enums[name]; isEnum && !tasks.ContainsString(values, value) {
+			failures = append(failures, fmt.Sprintf("%s: %s must be one of %s", location, name, strings.Join(values, ", ")))
+		}
+	}
+	return failures
+}
+
+func childrenNamed(element *xmlElement, name string) []*xmlElement {
+	var children []*xmlElement
+	for _, child := range element.children {
+		if child.name.Local == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
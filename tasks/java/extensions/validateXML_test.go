@@ -0,0 +1,69 @@
+package extensions
+
+import (
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Java/Extensions/ValidateXML", func() {
+	p := JavaExtensionsValidateXML{fileReader: os.ReadFile}
+
+	collected := func(files ...string) map[string]tasks.Result {
+		return map[string]tasks.Result{
+			"Java/Extensions/Collect": {Status: tasks.Success, Payload: []ExtensionsDir{{Dir: "fixtures", XMLFiles: files}}},
+		}
+	}
+
+	Describe("Execute()", func() {
+		It("Should not run without extensions", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Extensions/Collect": {Status: tasks.None},
+			})
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		It("Should return the pointcuts of valid files", func() {
+			result := p.Execute(tasks.Options{}, collected("fixtures/custom-instrumentation.xml"))
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("Successfully validated 2 custom instrumentation pointcut(s) in 1 file(s)"))
+			Expect(result.Payload).To(Equal([]Pointcut{
+				{File: "fixtures/custom-instrumentation.xml", Line: 7, ClassName: "com.example.orders.OrderProcessor", Methods: []string{"process"}},
+				{File: "fixtures/custom-instrumentation.xml", Line: 16, InterfaceName: "com.example.orders.OrderListener", Methods: []string{"onOrder"}},
+			}))
+		})
+
+		It("Should report every schema violation with its line", func() {
+			result := p.Execute(tasks.Options{}, collected("fixtures/invalid-instrumentation.xml", "fixtures/missing.xml"))
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.URL).To(Equal(customInstrumentationDocsURL))
+			for _, failure := range []string{
+				"fixtures/invalid-instrumentation.xml:2: <extension> is missing the required name attribute",
+				"fixtures/invalid-instrumentation.xml:4: transactionStartPoint must be true or false",
+				"fixtures/invalid-instrumentation.xml:4: transactionType must be one of web, background",
+				`fixtures/invalid-instrumentation.xml:4: "com.example.orders.*" must be a fully qualified class name such as com.example.MyClass`,
+				"fixtures/invalid-instrumentation.xml:6: <method> must contain exactly one <name>",
+				"fixtures/invalid-instrumentation.xml:9: <metricName> is not allowed in <pointcut>",
+				"fixtures/invalid-instrumentation.xml:11: <pointcut> must contain a <className>, <interfaceName> or <methodAnnotation>",
+				"fixtures/missing.xml: unable to read file",
+			} {
+				Expect(result.Summary).To(ContainSubstring(failure))
+			}
+		})
+	})
+
+	Describe("validateExtension()", func() {
+		It("Should reject documents without the extension namespace", func() {
+			_, failures := validateExtension("ext.xml", []byte(`<extension name="x"/>`))
+			Expect(failures).To(Equal([]string{`ext.xml: root element must be <extension xmlns="https://newrelic.com/docs/java/xsd/v1.0">`}))
+		})
+
+		It("Should reject malformed XML", func() {
+			_, failures := validateExtension("ext.xml", []byte(`<extension`))
+			Expect(failures).To(HaveLen(1))
+			Expect(failures[0]).To(HavePrefix("ext.xml: is not valid XML"))
+		})
+	})
+})
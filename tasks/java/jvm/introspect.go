@@ -0,0 +1,393 @@
+package jvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+)
+
+// JavaJVMIntrospect - attaches to the JVMs running the Java agent with jcmd to read their effective settings
+type JavaJVMIntrospect struct {
+	jcmdRunner  func(jcmd string, pid int32, command string) ([]byte, error)
+	lookPath    func(string) (string, error)
+	fileExists  func(string) bool
+	runtimeGOOS string
+}
+
+// JVMIntrospection - the live state of a JVM read with jcmd
+type JVMIntrospection struct {
+	Pid  int32
+	Jcmd string
+	// JVMArgs are the arguments the JVM was started with, after JDK_JAVA_OPTIONS and JAVA_TOOL_OPTIONS were applied
+	JVMArgs           []string
+	Flags             []string
+	SystemProperties  map[string]string
+	AgentLoaded       bool
+	AgentThreads      []string
+	ConflictingAgents []string
+	// StuckThreads are the agent threads that look stuck, their thread dump is added to the zip file
+	StuckThreads []string
+	Error        string `json:",omitempty"`
+}
+
+// JavaThread - a thread of a Thread.print thread dump
+type JavaThread struct {
+	Name   string
+	State  string
+	Frames []string
+}
+
+const (
+	agentThreadPrefix = "New Relic"
+	jcmdTimeout       = 30 * time.Second
+)
+
+var (
+	// the system properties that describe the JVM, the newrelic.* ones are added to them
+	introspectedSystemProperties = []string{"java.version", "java.vendor", "java.vm.name", "java.vm.version", "java.home", "user.name"}
+	// newrelic.* system properties holding secrets are redacted
+	secretPropertyKeywords = []string{"license_key", "password", "api_key", "insert_key"}
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaJVMIntrospect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/JVM/Introspect")
+}
+
+// Explain - Returns the help text for this task
+func (p JavaJVMIntrospect) Explain() string {
+	return "Use jcmd to confirm the New Relic Java agent is loaded in running JVMs, detect other APM agents and collect a thread dump when the agent looks stuck"
+}
+
+// Dependencies - Returns the dependencies for this task.
+func (p JavaJVMIntrospect) Dependencies() []string {
+	return []string{
+		"Java/Env/Process",
+	}
+}
+
+// Execute - attaches to each JVM found by Java/Env/Process
+func (p JavaJVMIntrospect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Java/Env/Process"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No running Java agent was found. This task did not run",
+		}
+	}
+	processes, ok := upstream["Java/Env/Process"].Payload.([]env.ProcIdAndArgs)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var introspections []JVMIntrospection
+	var filesToCopy []tasks.FileCopyEnvelope
+	var failures, warnings, infos, errs []string
+	for i := range processes {
+		jcmd := p.findJcmd(&processes[i])
+		if jcmd == "" {
+			errs = append(errs, fmt.Sprintf("process %d: jcmd was not found next to the java executable, in JAVA_HOME or in PATH", processes[i].Proc.Pid))
+			continue
+		}
+		introspection, threadDump := p.introspect(jcmd, processes[i].Proc.Pid)
+		introspections = append(introspections, introspection)
+		if introspection.Error != "" {
+			errs = append(errs, fmt.Sprintf("process %d: %s", introspection.Pid, introspection.Error))
+			continue
+		}
+
+		if !introspection.AgentLoaded {
+			failures = append(failures, fmt.Sprintf("process %d: the JVM has no %s threads, the Java agent did not start. Check newrelic_agent.log for errors during premain", introspection.Pid, agentThreadPrefix))
+		}
+		if len(introspection.ConflictingAgents) > 0 {
+			failures = append(failures, fmt.Sprintf("process %d: other agents are attached to the JVM: %s. The New Relic Java agent is not compatible with other APM agents", introspection.Pid, strings.Join(introspection.ConflictingAgents, ", ")))
+		}
+		if len(introspection.StuckThreads) > 0 {
+			warnings = append(warnings, fmt.Sprintf("process %d: the following agent thread(s) look stuck, a thread dump was added to the zip file: %s", introspection.Pid, strings.Join(introspection.StuckThreads, ", ")))
+			stream := make(chan string)
+			go tasks.StreamBlob(threadDump, stream)
+			filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{Path: fmt.Sprintf("threadDump-%d.txt", introspection.Pid), Stream: stream})
+		}
+		if introspection.AgentLoaded {
+			infos = append(infos, fmt.Sprintf("process %d: the Java agent is loaded (%d agent threads) on %s %s", introspection.Pid, len(introspection.AgentThreads), introspection.SystemProperties["java.vendor"], introspection.SystemProperties["java.version"]))
+		}
+	}
+
+	if len(failures) == 0 && len(warnings) == 0 && len(infos) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Unable to introspect the running JVMs with jcmd, jcmd must come from a JDK and run as the user of the JVM:\n" + strings.Join(errs, "\n"),
+			Payload: introspections,
+		}
+	}
+	summary := strings.Join(append(append(append(failures, warnings...), infos...), errs...), "\n")
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:      tasks.Failure,
+			Summary:     summary,
+			URL:         "https://docs.newrelic.com/docs/apm/agents/java-agent/troubleshooting/no-data-appears-java/",
+			Payload:     introspections,
+			FilesToCopy: filesToCopy,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:      tasks.Warning,
+			Summary:     summary,
+			URL:         "https://docs.newrelic.com/docs/apm/agents/java-agent/troubleshooting/generate-thread-dump-java/",
+			Payload:     introspections,
+			FilesToCopy: filesToCopy,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: summary,
+		Payload: introspections,
+	}
+}
+
+// findJcmd - prefers the jcmd of the JDK running the application, a JDK 8 JRE lives in the jre directory of the JDK
+func (p JavaJVMIntrospect) findJcmd(process *env.ProcIdAndArgs) string {
+	binary := "jcmd"
+	if p.runtimeGOOS == "windows" {
+		binary += ".exe"
+	}
+	var candidates []string
+	if len(process.CmdLineArgs) > 0 && filepath.IsAbs(process.CmdLineArgs[0]) {
+		javaBin := filepath.Dir(process.CmdLineArgs[0])
+		candidates = append(candidates,
+			filepath.Join(javaBin, binary),
+			filepath.Join(filepath.Dir(filepath.Dir(javaBin)), "bin", binary),
+		)
+	}
+	if javaHome := process.EnvVars["JAVA_HOME"]; javaHome != "" {
+		candidates = append(candidates, filepath.Join(javaHome, "bin", binary))
+	}
+	for _, candidate := range candidates {
+		if p.fileExists(candidate) {
+			return candidate
+		}
+	}
+	jcmd, err := p.lookPath(binary)
+	if err != nil {
+		log.Debug("jcmd is not in PATH:", err)
+		return ""
+	}
+	return jcmd
+}
+
+// introspect - returns what jcmd reports about a JVM and its thread dump
+func (p JavaJVMIntrospect) introspect(jcmd string, pid int32) (JVMIntrospection, string) {
+	introspection := JVMIntrospection{Pid: pid, Jcmd: jcmd}
+
+	commandLine, err := p.jcmdRunner(jcmd, pid, "VM.command_line")
+	if err != nil {
+		introspection.Error = jcmdError("VM.command_line", commandLine, err)
+		return introspection, ""
+	}
+	introspection.JVMArgs = parseJVMArgs(string(commandLine))
+	introspection.ConflictingAgents = findConflictingAgents(introspection.JVMArgs)
+
+	if flags, err := p.jcmdRunner(jcmd, pid, "VM.flags"); err == nil {
+		introspection.Flags = parseJcmdFlags(string(flags))
+	} else {
+		log.Debug(jcmdError("VM.flags", flags, err))
+	}
+	if properties, err := p.jcmdRunner(jcmd, pid, "VM.system_properties"); err == nil {
+		introspection.SystemProperties = filterSystemProperties(parseSystemProperties(string(properties)))
+	} else {
+		log.Debug(jcmdError("VM.system_properties", properties, err))
+	}
+
+	threadDump, err := p.jcmdRunner(jcmd, pid, "Thread.print")
+	if err != nil {
+		introspection.Error = jcmdError("Thread.print", threadDump, err)
+		return introspection, ""
+	}
+	threads := parseThreadDump(string(threadDump))
+	for _, thread := range threads {
+		if strings.HasPrefix(thread.Name, agentThreadPrefix) {
+			introspection.AgentThreads = append(introspection.AgentThreads, thread.Name)
+		}
+	}
+	introspection.AgentLoaded = len(introspection.AgentThreads) > 0
+	introspection.StuckThreads = findStuckAgentThreads(threads, string(threadDump))
+	return introspection, string(threadDump)
+}
+
+func jcmdError(command string, output []byte, err error) string {
+	message := fmt.Sprintf("jcmd %s failed: %s", command, err.Error())
+	if trimmed := strings.TrimSpace(string(output)); trimmed != "" {
+		message += ": " + trimmed
+	}
+	return message
+}
+
+// runJcmd - a JVM that is not responding would block jcmd forever
+func runJcmd(jcmd string, pid int32, command string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jcmdTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, jcmd, strconv.Itoa(int(pid)), command).CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("timed out after %s", jcmdTimeout)
+	}
+	return output, err
+}
+
+// parseJVMArgs - reads the jvm_args line of VM.command_line
+func parseJVMArgs(output string) []string {
+	for _, line := range strings.Split(output, "\n") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), "jvm_args:"); found {
+			return strings.Fields(value)
+		}
+	}
+	return nil
+}
+
+// parseJcmdFlags - VM.flags prints the flags on a single line after the pid
+func parseJcmdFlags(output string) []string {
+	var flags []string
+	for _, field := range strings.Fields(output) {
+		if strings.HasPrefix(field, "-") {
+			flags = append(flags, field)
+		}
+	}
+	return flags
+}
+
+// parseSystemProperties - VM.system_properties prints the properties in the properties file format
+func parseSystemProperties(output string) map[string]string {
+	properties := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		// keys and values escape the characters of the properties format
+		properties[strings.ReplaceAll(key, `\`, "")] = strings.NewReplacer(`\:`, ":", `\=`, "=", `\\`, `\`).Replace(value)
+	}
+	return properties
+}
+
+// filterSystemProperties - keeps the properties describing the JVM and the agent, the secrets of the agent are redacted
+func filterSystemProperties(properties map[string]string) map[string]string {
+	filtered := map[string]string{}
+	for key, value := range properties {
+		switch {
+		case tasks.ContainsString(introspectedSystemProperties, key):
+			filtered[key] = value
+		case strings.HasPrefix(key, "newrelic."):
+			filtered[key] = value
+			for _, keyword := range secretPropertyKeywords {
+				if strings.Contains(key, keyword) {
+					filtered[key] = "_REDACTED_"
+				}
+			}
+		}
+	}
+	return filtered
+}
+
+// findConflictingAgents - returns the other APM agents and the extra New Relic agents of the JVM
+func findConflictingAgents(jvmArgs []string) []string {
+	var conflicts []string
+	newRelicAgents := 0
+	for _, arg := range jvmArgs {
+		var agent string
+		switch {
+		case strings.HasPrefix(arg, "-javaagent:"):
+			agent = strings.TrimPrefix(arg, "-javaagent:")
+		case strings.HasPrefix(arg, "-agentpath:"):
+			agent = strings.TrimPrefix(arg, "-agentpath:")
+		default:
+			continue
+		}
+		lowerAgent := strings.ToLower(agent)
+		if strings.Contains(filepath.Base(lowerAgent), "newrelic") {
+			newRelicAgents++
+			if newRelicAgents > 1 {
+				conflicts = append(conflicts, "a second New Relic agent ("+agent+")")
+			}
+			continue
+		}
+		if vendor := compatibilityVars.JavaConflictingAgentVendor(agent); vendor != "" {
+			conflicts = append(conflicts, vendor+" ("+agent+")")
+		}
+	}
+	return conflicts
+}
+
+// parseThreadDump - the threads of Thread.print start with their quoted name and are separated by blank lines
+func parseThreadDump(output string) []JavaThread {
+	var threads []JavaThread
+	var current *JavaThread
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, `"`):
+			end := strings.Index(line[1:], `"`)
+			if end == -1 {
+				current = nil
+				continue
+			}
+			threads = append(threads, JavaThread{Name: line[1 : end+1]})
+			current = &threads[len(threads)-1]
+		case current == nil:
+			continue
+		case strings.TrimSpace(line) == "":
+			current = nil
+		case strings.Contains(line, "java.lang.Thread.State:"):
+			state := strings.TrimSpace(strings.SplitN(line, "java.lang.Thread.State:", 2)[1])
+			current.State = strings.Fields(state)[0]
+		case strings.HasPrefix(strings.TrimSpace(line), "at "):
+			current.Frames = append(current.Frames, strings.TrimPrefix(strings.TrimSpace(line), "at "))
+		}
+	}
+	return threads
+}
+
+// findStuckAgentThreads - an agent thread looks stuck when it is blocked or deadlocked, or when the application is still waiting on the agent premain
+func findStuckAgentThreads(threads []JavaThread, threadDump string) []string {
+	stuck := map[string]bool{}
+	for _, thread := range threads {
+		if strings.HasPrefix(thread.Name, agentThreadPrefix) && thread.State == "BLOCKED" {
+			stuck[thread.Name] = true
+		}
+		for _, frame := range thread.Frames {
+			if strings.HasPrefix(frame, "com.newrelic.") && strings.Contains(frame, ".premain(") {
+				stuck[thread.Name] = true
+			}
+		}
+	}
+	// the deadlock report follows the threads and names the threads of each deadlock
+	if _, deadlocks, found := strings.Cut(threadDump, "Found one Java-level deadlock:"); found {
+		for _, thread := range threads {
+			if strings.Contains(deadlocks, `"`+thread.Name+`"`) && strings.HasPrefix(thread.Name, agentThreadPrefix) {
+				stuck[thread.Name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(stuck))
+	for name := range stuck {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
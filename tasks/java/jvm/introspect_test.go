@@ -0,0 +1,148 @@
+package jvm
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+var _ = Describe("Java/JVM/Introspect", func() {
+	var (
+		p         JavaJVMIntrospect
+		outputs   map[string]string
+		processes []env.ProcIdAndArgs
+	)
+
+	threadDump, err := os.ReadFile("fixtures/jcmd-thread-print.txt")
+	if err != nil {
+		panic(err)
+	}
+
+	BeforeEach(func() {
+		outputs = map[string]string{
+			"VM.command_line":      "4242:\nVM Arguments:\njvm_args: -javaagent:/opt/newrelic/newrelic.jar -Xmx512m\njava_command: com.example.orders.Main\n",
+			"VM.flags":             "4242:\n-XX:CICompilerCount=4 -XX:MaxHeapSize=536870912 -XX:+UseG1GC\n",
+			"VM.system_properties": "4242:\n#Sun Oct 18 13:40:01 UTC 2026\njava.vendor=Eclipse Adoptium\njava.version=17.0.8\njava.home=/opt/java/openjdk\nnewrelic.config.app_name=orders\nnewrelic.config.license_key=abc123\nsun.boot.library.path=/opt/java/openjdk/lib\n",
+			"Thread.print":         strings.Replace(string(threadDump), "BLOCKED (on object monitor)", "TIMED_WAITING (parking)", 1),
+		}
+		p = JavaJVMIntrospect{
+			jcmdRunner: func(jcmd string, pid int32, command string) ([]byte, error) {
+				Expect(jcmd).To(Equal("/opt/java/openjdk/bin/jcmd"))
+				Expect(pid).To(Equal(int32(4242)))
+				return []byte(outputs[command]), nil
+			},
+			lookPath:    func(string) (string, error) { return "", errors.New("not found") },
+			fileExists:  func(path string) bool { return path == "/opt/java/openjdk/bin/jcmd" },
+			runtimeGOOS: "linux",
+		}
+		processes = []env.ProcIdAndArgs{{
+			Proc:        process.Process{Pid: 4242},
+			CmdLineArgs: []string{"/opt/java/openjdk/bin/java", "-javaagent:/opt/newrelic/newrelic.jar", "com.example.orders.Main"},
+		}}
+	})
+
+	execute := func() tasks.Result {
+		return p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Java/Env/Process": {Status: tasks.Success, Payload: processes},
+		})
+	}
+
+	Describe("Execute()", func() {
+		It("Should not run without a running Java agent", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Failure},
+			})
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		It("Should confirm the agent is loaded", func() {
+			result := execute()
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("process 4242: the Java agent is loaded (2 agent threads) on Eclipse Adoptium 17.0.8"))
+			introspections := result.Payload.([]JVMIntrospection)
+			Expect(introspections[0].Flags).To(Equal([]string{"-XX:CICompilerCount=4", "-XX:MaxHeapSize=536870912", "-XX:+UseG1GC"}))
+			Expect(introspections[0].SystemProperties).To(Equal(map[string]string{
+				"java.vendor":                 "Eclipse Adoptium",
+				"java.version":                "17.0.8",
+				"java.home":                   "/opt/java/openjdk",
+				"newrelic.config.app_name":    "orders",
+				"newrelic.config.license_key": "_REDACTED_",
+			}))
+			Expect(result.FilesToCopy).To(BeEmpty())
+		})
+
+		It("Should fail when the JVM has no agent threads", func() {
+			outputs["Thread.print"] = "4242:\n\n\"main\" #1 prio=5\n   java.lang.Thread.State: RUNNABLE\n"
+			result := execute()
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("process 4242: the JVM has no New Relic threads, the Java agent did not start"))
+		})
+
+		It("Should fail when other agents are attached", func() {
+			outputs["VM.command_line"] = "jvm_args: -javaagent:/opt/newrelic/newrelic.jar -javaagent:/opt/dd/dd-java-agent.jar -javaagent:/tmp/newrelic.jar\n"
+			result := execute()
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Payload.([]JVMIntrospection)[0].ConflictingAgents).To(Equal([]string{"Datadog (/opt/dd/dd-java-agent.jar)", "a second New Relic agent (/tmp/newrelic.jar)"}))
+		})
+
+		It("Should collect the thread dump when an agent thread is blocked", func() {
+			outputs["Thread.print"] = string(threadDump)
+			result := execute()
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(HavePrefix("process 4242: the following agent thread(s) look stuck, a thread dump was added to the zip file: New Relic Harvest Service"))
+			Expect(result.FilesToCopy).To(HaveLen(1))
+			Expect(result.FilesToCopy[0].Path).To(Equal("threadDump-4242.txt"))
+			var streamed strings.Builder
+			for line := range result.FilesToCopy[0].Stream {
+				streamed.WriteString(line)
+			}
+			Expect(streamed.String()).To(Equal(string(threadDump)))
+		})
+
+		It("Should return None when jcmd is not available", func() {
+			p.fileExists = func(string) bool { return false }
+			result := execute()
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(ContainSubstring("process 4242: jcmd was not found next to the java executable, in JAVA_HOME or in PATH"))
+		})
+
+		It("Should return None when jcmd cannot attach", func() {
+			p.jcmdRunner = func(string, int32, string) ([]byte, error) {
+				return []byte("com.sun.tools.attach.AttachNotSupportedException: Unable to open socket file"), errors.New("exit status 1")
+			}
+			result := execute()
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(ContainSubstring("process 4242: jcmd VM.command_line failed: exit status 1: com.sun.tools.attach.AttachNotSupportedException: Unable to open socket file"))
+		})
+	})
+
+	Describe("findJcmd()", func() {
+		It("Should find the jcmd of the JDK of a JDK 8 JRE", func() {
+			p.fileExists = func(path string) bool { return path == "/usr/lib/jvm/java-8/bin/jcmd" }
+			process := env.ProcIdAndArgs{CmdLineArgs: []string{"/usr/lib/jvm/java-8/jre/bin/java"}}
+			Expect(p.findJcmd(&process)).To(Equal("/usr/lib/jvm/java-8/bin/jcmd"))
+		})
+
+		It("Should fall back to PATH", func() {
+			p.fileExists = func(string) bool { return false }
+			p.lookPath = func(string) (string, error) { return "/usr/bin/jcmd", nil }
+			process := env.ProcIdAndArgs{CmdLineArgs: []string{"java"}}
+			Expect(p.findJcmd(&process)).To(Equal("/usr/bin/jcmd"))
+		})
+	})
+
+	Describe("findStuckAgentThreads()", func() {
+		It("Should report agent threads in a deadlock and an application waiting on premain", func() {
+			dump := "\"main\" #1\n   java.lang.Thread.State: WAITING\n\tat com.newrelic.bootstrap.BootstrapAgent.premain(BootstrapAgent.java:140)\n\n" +
+				"\"New Relic Retransformer\" #30\n   java.lang.Thread.State: WAITING\n\n" +
+				"Found one Java-level deadlock:\n=============================\n\"New Relic Retransformer\":\n  waiting to lock monitor 0x00007f3c\n"
+			Expect(findStuckAgentThreads(parseThreadDump(dump), dump)).To(Equal([]string{"New Relic Retransformer", "main"}))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package jvm
 
 import (
+	"os/exec"
 	"runtime"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
@@ -17,5 +18,11 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		runtimeGOOS:       runtime.GOOS,
 		getCmdLineArgs:    getCmdLineArgs,
 	}, true)
-
+	// Introspect attaches jcmd to the running JVMs, so it only runs when requested by name
+	registrationFunc(JavaJVMIntrospect{
+		jcmdRunner:  runJcmd,
+		lookPath:    exec.LookPath,
+		fileExists:  tasks.FileExists,
+		runtimeGOOS: runtime.GOOS,
+	}, false)
 }
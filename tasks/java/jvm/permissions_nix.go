@@ -118,6 +118,7 @@ func (p JavaJVMPermissions) Execute(options tasks.Options, upstream map[string]t
 	warningCount := 0
 	var failureSummary, warningSummary string
 	payloadResult := []*JavaAgentPermissions{}
+	var remediations []tasks.Remediation
 
 	for _, process := range javaAgentProcs { //though we expect to find one single process running the new relic agent, it is not un-heard of users running multiple agents in different processes
 		var j JavaAgentPermissions
@@ -137,6 +138,9 @@ func (p JavaJVMPermissions) Execute(options tasks.Options, upstream map[string]t
 		if anyPermissionDenied {
 			failureCount++
 			failureSummary += fmt.Sprintf("The process for the for PID %d did not meet the New Relic Java Agent permissions requirements. Errors found:\n\n%s", process.Proc.Pid, buildErrMsg(&j))
+			if remediation, ok := permissionsRemediation(&process.Proc, process.JarPath, &j); ok {
+				remediations = append(remediations, remediation)
+			}
 		} else if anyPermissionUndetermined {
 			warningCount++
 			warningSummary += fmt.Sprintf(tasks.ThisProgramFullName+" ran into some unexpected errors and was unable to verify that your application meets the Java agent permissions requirements for the following reasons:\n%s", buildErrMsg(&j))
@@ -145,10 +149,11 @@ func (p JavaJVMPermissions) Execute(options tasks.Options, upstream map[string]t
 
 	if failureCount > 0 {
 		return tasks.Result{
-			Status:  tasks.Failure,
-			Summary: failureSummary + warningSummary,
-			Payload: payloadResult,
-			URL:     "https://docs.newrelic.com/docs/agents/java-agent/troubleshooting/determine-permissions-requirements-java",
+			Status:       tasks.Failure,
+			Summary:      failureSummary + warningSummary,
+			Payload:      payloadResult,
+			URL:          "https://docs.newrelic.com/docs/agents/java-agent/troubleshooting/determine-permissions-requirements-java",
+			Remediations: remediations,
 		}
 	}
 
@@ -257,7 +262,7 @@ func determineTmpDirPermissions(proc process.Process, upstream map[string]tasks.
 
 /* need write/execute access to temp dir */
 func canCreateFilesInTempDir(proc process.Process, tempDir string) (err error) {
-	procOwnerUID, procOwnerGID, fileOwnerUID, fileOwnerGID, err := getUIDsGIDs(proc, tempDir)
+	procOwnerUID, procOwnerGID, fileOwnerUID, fileOwnerGID, err := getUIDsGIDs(&proc, tempDir)
 
 	if err != nil {
 		return err
@@ -352,7 +357,7 @@ func canCreateAgentLog(proc process.Process, logFilePath, jarPath string) (err e
 		}
 	}
 
-	procOwnerUID, procOwnerGID, fileOwnerUID, fileOwnerGID, err := getUIDsGIDs(proc, logDir)
+	procOwnerUID, procOwnerGID, fileOwnerUID, fileOwnerGID, err := getUIDsGIDs(&proc, logDir)
 	if err != nil {
 		return fmt.Errorf("%s: %w for %s", err.Error(), errPermissionsCannotBeDetermined, logDir)
 	}
@@ -390,7 +395,7 @@ func canReadAgentJar(proc process.Process, jarLoc string) (err error) {
 	if _, errJarNotExist := os.Stat(jarLoc); os.IsNotExist(errJarNotExist) {
 		return fmt.Errorf(`agent JAR does not exist for PID %d. This location is at %s: %w`, proc.Pid, jarLoc, errJarNotExist)
 	}
-	procOwnerUID, procOwnerGID, fileOwnerUID, fileOwnerGID, err := getUIDsGIDs(proc, jarLoc)
+	procOwnerUID, procOwnerGID, fileOwnerUID, fileOwnerGID, err := getUIDsGIDs(&proc, jarLoc)
 	if err != nil {
 		return err
 	}
@@ -445,7 +450,49 @@ func determineJarPermissions(proc process.Process, jarLoc string, j *JavaAgentPe
 	j.AgentJarCanRead.Value = jarLoc
 }
 
-func getUIDsGIDs(proc process.Process, fileOrDirPath string) (string, string, string, string, error) {
+/* offers to grant the process owner the permissions it was denied: read on the agent JAR and write/execute on the log directory. The temp directory is shared by the whole host so it is left alone */
+func permissionsRemediation(proc *process.Process, jarPath string, j *JavaAgentPermissions) (tasks.Remediation, bool) {
+	var actions []tasks.RemediationAction
+	if j.AgentJarCanRead.SuccessLevel == denied {
+		if chmod, err := permissionsFix(proc, j.AgentJarCanRead.Value, 04); err == nil {
+			actions = append(actions, chmod)
+		}
+	}
+	if j.LogCanCreate.SuccessLevel == denied {
+		logDir := filepath.Dir(j.LogCanCreate.Value)
+		if _, err := os.Stat(logDir); os.IsNotExist(err) {
+			logDir = filepath.Dir(jarPath) //same fallback as canCreateAgentLog
+		}
+		chmod, err := permissionsFix(proc, logDir, 03)
+		//write access to a directory is only granted to its owner or group, never to everyone
+		if err == nil && chmod.Add&07 == 0 {
+			actions = append(actions, chmod)
+		}
+	}
+	if len(actions) == 0 {
+		return tasks.Remediation{}, false
+	}
+	return tasks.Remediation{
+		Description: fmt.Sprintf("Grant the owner of the process for PID %d the permissions the New Relic Java agent needs", proc.Pid),
+		Actions:     actions,
+	}, true
+}
+
+/* returns a Chmod adding bits for the process owner as the file owner, the file group or everyone else, matching how the permissions were checked */
+func permissionsFix(proc *process.Process, fileOrDirPath string, bits os.FileMode) (tasks.Chmod, error) {
+	procOwnerUID, procOwnerGID, fileOwnerUID, fileOwnerGID, err := getUIDsGIDs(proc, fileOrDirPath)
+	if err != nil {
+		return tasks.Chmod{}, err
+	}
+	if procOwnerUID == fileOwnerUID {
+		bits <<= 6
+	} else if procOwnerGID == fileOwnerGID {
+		bits <<= 3
+	}
+	return tasks.Chmod{Path: fileOrDirPath, Add: bits}, nil
+}
+
+func getUIDsGIDs(proc *process.Process, fileOrDirPath string) (string, string, string, string, error) {
 	procOwner, _ := proc.Username()
 	procOwnerUser, err := user.Lookup(procOwner)
 	if err != nil {
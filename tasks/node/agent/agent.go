@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"os"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -10,4 +12,11 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Node/Agent/*")
 
 	registrationFunc(NodeAgentVersion{}, true)
+	registrationFunc(NodeAgentLoading{
+		getProcArgs:   tasks.GetProcArgs,
+		getProcessEnv: tasks.GetProcessEnvVars,
+		getenv:        os.Getenv,
+		getwd:         os.Getwd,
+		fileReader:    os.ReadFile,
+	}, true)
 }
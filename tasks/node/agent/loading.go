@@ -0,0 +1,332 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// NodeAgentLoading - checks that the agent is loaded before the modules of the application
+type NodeAgentLoading struct {
+	getProcArgs   func(string) []tasks.JavaProcArgs
+	getProcessEnv func(int32) (tasks.EnvironmentVariables, error)
+	getenv        func(string) string
+	getwd         func() (string, error)
+	fileReader    func(string) ([]byte, error)
+}
+
+// NodeAppLoading - how one way of starting the application loads the agent
+type NodeAppLoading struct {
+	// Source is "process <pid>", "scripts.start" or "main" of package.json
+	Source string
+	Entry  string
+	ESM    bool
+	// Preloaded are the specifiers of the -r, --require, --import, --loader and --experimental-loader options, NODE_OPTIONS included
+	Preloaded []string
+	// FirstModule is the first module required or imported by the entry file
+	FirstModule string
+	Problem     string
+}
+
+type nodePackageJSON struct {
+	Type    string            `json:"type"`
+	Main    string            `json:"main"`
+	Scripts map[string]string `json:"scripts"`
+}
+
+const nodeESMLoader = "newrelic/esm-loader.mjs"
+
+var (
+	nodeRequireFlags = []string{"-r", "--require"}
+	nodeLoaderFlags  = []string{"--import", "--loader", "--experimental-loader"}
+	// the options of node that take a value in the next argument and are not preloads
+	nodeValueFlags = []string{"-e", "--eval", "-p", "--print", "--input-type", "--env-file", "--title", "-C", "--conditions"}
+
+	nodeRequireRegex = regexp.MustCompile(`\brequire\(\s*['"]([^'"]+)['"]\s*\)`)
+	nodeImportRegex  = regexp.MustCompile(`^\s*import\s+(?:[^'"]*\s+from\s+)?['"]([^'"]+)['"]`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of this task
+func (t NodeAgentLoading) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Node/Agent/Loading")
+}
+
+// Explain - Returns the help text for this task
+func (t NodeAgentLoading) Explain() string {
+	return "Check that the New Relic Node agent is loaded before any other module, including ES module applications"
+}
+
+// Dependencies - Returns the dependencies for this task.
+func (t NodeAgentLoading) Dependencies() []string {
+	return []string{
+		"Node/Agent/Version",
+	}
+}
+
+// Execute - The core work within this task
+func (t NodeAgentLoading) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Node/Agent/Version"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The newrelic module was not found. This task did not run.",
+		}
+	}
+	appDir, err := t.getwd()
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the working directory: " + err.Error(),
+		}
+	}
+	loadings := t.getAppLoadings(appDir)
+	if len(loadings) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Unable to find how the application is started: no node process runs a file of " + appDir + " and its package.json has no start script or main file. This task did not run.",
+		}
+	}
+
+	var problems, successes []string
+	for i := range loadings {
+		t.checkAppLoading(&loadings[i])
+		if loadings[i].Problem != "" {
+			problems = append(problems, loadings[i].Problem)
+			continue
+		}
+		successes = append(successes, fmt.Sprintf("%s: the agent is loaded before the modules of %s", loadings[i].Source, loadings[i].Entry))
+	}
+	if len(problems) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(problems, "\n"),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/nodejs-agent/installation-configuration/install-nodejs-agent/",
+			Payload: loadings,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(successes, "\n"),
+		Payload: loadings,
+	}
+}
+
+// getAppLoadings - the running node processes of the application with their own NODE_OPTIONS, or how package.json starts it
+// when none is running. The start script is expected to run from a shell like the one nrdiag runs in, so it gets our NODE_OPTIONS.
+func (t NodeAgentLoading) getAppLoadings(appDir string) []NodeAppLoading {
+	packageJSON, hasPackageJSON := t.readPackageJSON(appDir)
+	var loadings []NodeAppLoading
+	for _, proc := range t.getProcArgs("node") {
+		if len(proc.Args) < 2 {
+			continue
+		}
+		preloaded, entry := parseNodeArgs(proc.Args[1:])
+		if entry == "" {
+			continue
+		}
+		if !filepath.IsAbs(entry) {
+			entry = filepath.Join(appDir, entry)
+		}
+		// node processes of other applications and tools are not checked
+		if !strings.HasPrefix(entry, appDir+string(filepath.Separator)) {
+			continue
+		}
+		loadings = append(loadings, NodeAppLoading{
+			Source:    fmt.Sprintf("process %d", proc.ProcID),
+			Entry:     entry,
+			ESM:       packageJSON.Type == "module",
+			Preloaded: append(parseNodePreloads(t.processNodeOptions(proc.ProcID)), preloaded...),
+		})
+	}
+	if len(loadings) > 0 || !hasPackageJSON {
+		return loadings
+	}
+
+	nodeOptions := strings.Fields(t.getenv("NODE_OPTIONS"))
+	startArgs := strings.Fields(packageJSON.Scripts["start"])
+	if len(startArgs) > 1 && filepath.Base(startArgs[0]) == "node" {
+		preloaded, entry := parseNodeArgs(startArgs[1:])
+		if entry != "" {
+			return []NodeAppLoading{{
+				Source:    "scripts.start",
+				Entry:     filepath.Join(appDir, entry),
+				ESM:       packageJSON.Type == "module",
+				Preloaded: append(parseNodePreloads(nodeOptions), preloaded...),
+			}}
+		}
+	}
+	main := packageJSON.Main
+	if main == "" {
+		main = "index.js"
+	}
+	return []NodeAppLoading{{
+		Source:    "main",
+		Entry:     filepath.Join(appDir, main),
+		ESM:       packageJSON.Type == "module",
+		Preloaded: parseNodePreloads(nodeOptions),
+	}}
+}
+
+// processNodeOptions - the NODE_OPTIONS a node process was started with
+func (t NodeAgentLoading) processNodeOptions(pid int32) []string {
+	envVars, err := t.getProcessEnv(pid)
+	if err != nil {
+		log.Debug("Unable to read the environment of process", pid, ":", err)
+		return nil
+	}
+	return strings.Fields(envVars.All["NODE_OPTIONS"])
+}
+
+// readPackageJSON - the type of package.json decides whether the .js files of the application are ES modules
+func (t NodeAgentLoading) readPackageJSON(appDir string) (nodePackageJSON, bool) {
+	var packageJSON nodePackageJSON
+	content, err := t.fileReader(filepath.Join(appDir, "package.json"))
+	if err != nil {
+		log.Debug("Unable to read package.json:", err)
+		return packageJSON, false
+	}
+	if err := json.Unmarshal(content, &packageJSON); err != nil {
+		log.Debug("Unable to parse package.json:", err)
+		return packageJSON, false
+	}
+	return packageJSON, true
+}
+
+// parseNodeArgs - returns the preloaded specifiers and the script of the arguments of node
+func parseNodeArgs(args []string) ([]string, string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return parseNodePreloads(args[:i]), arg
+		}
+		if arg == "--" {
+			if i+1 < len(args) {
+				return parseNodePreloads(args[:i]), args[i+1]
+			}
+			break
+		}
+		if tasks.ContainsString(nodeRequireFlags, arg) || tasks.ContainsString(nodeLoaderFlags, arg) || tasks.ContainsString(nodeValueFlags, arg) {
+			i++
+		}
+	}
+	return parseNodePreloads(args), ""
+}
+
+// parseNodePreloads - the specifiers of -r newrelic, --require=newrelic and --import newrelic/esm-loader.mjs
+func parseNodePreloads(args []string) []string {
+	var specifiers []string
+	for i, arg := range args {
+		for _, flag := range append(nodeRequireFlags, nodeLoaderFlags...) {
+			if arg == flag && i+1 < len(args) {
+				specifiers = append(specifiers, flag+" "+args[i+1])
+			} else if strings.HasPrefix(arg, flag+"=") {
+				specifiers = append(specifiers, flag+" "+strings.TrimPrefix(arg, flag+"="))
+			}
+		}
+	}
+	return specifiers
+}
+
+func isPreloaded(preloaded []string, flags []string, specifier string) bool {
+	for _, flag := range flags {
+		if tasks.ContainsString(preloaded, flag+" "+specifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAppLoading - sets the problem and its fix when the agent is not loaded first
+func (t NodeAgentLoading) checkAppLoading(loading *NodeAppLoading) {
+	entry := t.resolveEntry(loading.Entry)
+	if entry == "" {
+		loading.Problem = fmt.Sprintf("%s: unable to read the entry file %s", loading.Source, loading.Entry)
+		return
+	}
+	loading.Entry = entry
+	switch filepath.Ext(entry) {
+	case ".mjs":
+		loading.ESM = true
+	case ".cjs":
+		loading.ESM = false
+	}
+	firstModule, newRelicLine := t.scanEntry(entry)
+	loading.FirstModule = firstModule
+
+	requiresAgent := isPreloaded(loading.Preloaded, nodeRequireFlags, "newrelic")
+	startCommand := "node -r newrelic " + entry
+	if loading.ESM {
+		startCommand = fmt.Sprintf("node --import %s -r newrelic %s (use --experimental-loader instead of --import before Node.js 20.6)", nodeESMLoader, entry)
+		if !isPreloaded(loading.Preloaded, nodeLoaderFlags, nodeESMLoader) {
+			loading.Problem = fmt.Sprintf("%s: %s is an ES module but the New Relic ESM loader is not registered, the modules it imports are not instrumented. Start it with: %s", loading.Source, entry, startCommand)
+			return
+		}
+	}
+	if requiresAgent || firstModule == "newrelic" {
+		return
+	}
+	if newRelicLine > 0 {
+		loading.Problem = fmt.Sprintf("%s: newrelic is loaded on line %d of %s after %s, the modules loaded before it are not instrumented. Move require('newrelic') to the first line or start it with: %s", loading.Source, newRelicLine, entry, firstModule, startCommand)
+		return
+	}
+	loading.Problem = fmt.Sprintf("%s: newrelic is not loaded by %s or by the options of node. Add require('newrelic') as the first line of %s, add -r newrelic to NODE_OPTIONS or start it with: %s", loading.Source, entry, entry, startCommand)
+}
+
+// resolveEntry - node resolves an entry without extension as .js or as the index.js of a directory
+func (t NodeAgentLoading) resolveEntry(entry string) string {
+	for _, candidate := range []string{entry, entry + ".js", filepath.Join(entry, "index.js")} {
+		if _, err := t.fileReader(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// scanEntry - returns the first module loaded by the file and the line that loads newrelic, 0 when it doesn't
+func (t NodeAgentLoading) scanEntry(path string) (string, int) {
+	content, err := t.fileReader(path)
+	if err != nil {
+		log.Debug("Unable to read", path, ":", err)
+		return "", 0
+	}
+	var firstModule string
+	inComment := false
+	lineNumber := 0
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if inComment {
+			inComment = !strings.Contains(line, "*/")
+			continue
+		}
+		if strings.HasPrefix(line, "/*") {
+			inComment = !strings.Contains(line, "*/")
+			continue
+		}
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+		var module string
+		if match := nodeImportRegex.FindStringSubmatch(line); match != nil {
+			module = match[1]
+		} else if match := nodeRequireRegex.FindStringSubmatch(line); match != nil {
+			module = match[1]
+		}
+		if module == "" {
+			continue
+		}
+		if firstModule == "" {
+			firstModule = module
+		}
+		if module == "newrelic" {
+			return firstModule, lineNumber
+		}
+	}
+	return firstModule, 0
+}
@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Node/Agent/Loading", func() {
+	var (
+		p           NodeAgentLoading
+		appDir      string
+		processes   []tasks.JavaProcArgs
+		processEnv  map[int32]map[string]string
+		nodeOptions string
+		upstream    map[string]tasks.Result
+		result      tasks.Result
+	)
+
+	useApp := func(name string) {
+		dir, err := filepath.Abs(filepath.Join("../../fixtures/node/loading", name))
+		Expect(err).ToNot(HaveOccurred())
+		appDir = dir
+	}
+
+	BeforeEach(func() {
+		processes = nil
+		processEnv = map[int32]map[string]string{}
+		nodeOptions = ""
+		upstream = map[string]tasks.Result{
+			"Node/Agent/Version": {Status: tasks.Info, Payload: "11.10.0"},
+		}
+		p = NodeAgentLoading{
+			getProcArgs: func(string) []tasks.JavaProcArgs { return processes },
+			getProcessEnv: func(pid int32) (tasks.EnvironmentVariables, error) {
+				return tasks.EnvironmentVariables{All: processEnv[pid]}, nil
+			},
+			getenv: func(key string) string {
+				if key == "NODE_OPTIONS" {
+					return nodeOptions
+				}
+				return ""
+			},
+			getwd:      func() (string, error) { return appDir, nil },
+			fileReader: os.ReadFile,
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("When the newrelic module is not installed", func() {
+		BeforeEach(func() {
+			upstream["Node/Agent/Version"] = tasks.Result{Status: tasks.Warning}
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("When the main file requires newrelic first", func() {
+		BeforeEach(func() {
+			useApp("cjs-first")
+		})
+		It("Should return a Success result", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("main: the agent is loaded before the modules of " + filepath.Join(appDir, "server.js")))
+		})
+	})
+
+	Context("When the start script runs a file that requires newrelic after express", func() {
+		BeforeEach(func() {
+			useApp("cjs-late")
+		})
+		It("Should return a Failure with the line to move", func() {
+			entry := filepath.Join(appDir, "app.js")
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(Equal("scripts.start: newrelic is loaded on line 4 of " + entry + " after express, the modules loaded before it are not instrumented. Move require('newrelic') to the first line or start it with: node -r newrelic " + entry))
+		})
+	})
+
+	Context("When the main file does not load newrelic", func() {
+		BeforeEach(func() {
+			useApp("cjs-missing")
+		})
+		It("Should return a Failure", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("main: newrelic is not loaded by " + filepath.Join(appDir, "index.js")))
+		})
+		It("Should accept -r newrelic in NODE_OPTIONS", func() {
+			nodeOptions = "--max-old-space-size=512 -r newrelic"
+			Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Context("When an ES module application does not register the ESM loader", func() {
+		BeforeEach(func() {
+			useApp("esm")
+		})
+		It("Should return a Failure with the command line to use", func() {
+			entry := filepath.Join(appDir, "index.js")
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(Equal("scripts.start: " + entry + " is an ES module but the New Relic ESM loader is not registered, the modules it imports are not instrumented. Start it with: node --import newrelic/esm-loader.mjs -r newrelic " + entry + " (use --experimental-loader instead of --import before Node.js 20.6)"))
+			Expect(result.Payload.([]NodeAppLoading)[0].Preloaded).To(Equal([]string{"-r newrelic"}))
+		})
+	})
+
+	Context("When an ES module application registers the ESM loader", func() {
+		BeforeEach(func() {
+			useApp("esm-loader")
+		})
+		It("Should return a Success result", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Context("When node processes of the application are running", func() {
+		BeforeEach(func() {
+			useApp("esm-loader")
+			processes = []tasks.JavaProcArgs{
+				{ProcID: 10, Args: []string{"node", "/usr/lib/node_modules/npm/bin/npm-cli.js", "start"}},
+				{ProcID: 11, Args: []string{"/usr/bin/node", "--experimental-loader=newrelic/esm-loader.mjs", "--require", "newrelic", filepath.Join(appDir, "src/index.js")}},
+				{ProcID: 12, Args: []string{"node", "-r", "newrelic", "src/index"}},
+			}
+		})
+		It("Should check the command line of the processes instead of package.json", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			loadings := result.Payload.([]NodeAppLoading)
+			Expect(loadings).To(HaveLen(2))
+			Expect(loadings[0].Source).To(Equal("process 11"))
+			Expect(loadings[0].Problem).To(BeEmpty())
+			Expect(loadings[1].Source).To(Equal("process 12"))
+			Expect(loadings[1].Entry).To(Equal(filepath.Join(appDir, "src/index.js")))
+			Expect(loadings[1].Problem).To(ContainSubstring("is an ES module but the New Relic ESM loader is not registered"))
+		})
+		It("Should read NODE_OPTIONS from the environment of each process", func() {
+			processEnv[12] = map[string]string{"NODE_OPTIONS": "--import newrelic/esm-loader.mjs"}
+			nodeOptions = "-r dd-trace/init"
+			loadings := p.Execute(tasks.Options{}, upstream).Payload.([]NodeAppLoading)
+			Expect(loadings[0].Preloaded).To(Equal([]string{"--experimental-loader newrelic/esm-loader.mjs", "--require newrelic"}))
+			Expect(loadings[1].Preloaded).To(Equal([]string{"--import newrelic/esm-loader.mjs", "-r newrelic"}))
+			Expect(loadings[1].Problem).To(BeEmpty())
+		})
+	})
+
+	Describe("parseNodeArgs()", func() {
+		It("Should skip the values of the options", func() {
+			preloaded, entry := parseNodeArgs([]string{"--env-file", ".env", "--import=newrelic/esm-loader.mjs", "-r", "newrelic", "server.mjs", "--port", "80"})
+			Expect(preloaded).To(Equal([]string{"--import newrelic/esm-loader.mjs", "-r newrelic"}))
+			Expect(entry).To(Equal("server.mjs"))
+		})
+	})
+})
@@ -2,14 +2,19 @@ package env
 
 import (
 	"bufio"
+	"encoding/json"
+	"path/filepath"
 	"regexp"
 	"strings"
 
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
 type NodeEnvDependencies struct {
-	cmdExec tasks.CmdExecFunc
+	cmdExec    tasks.CmdExecFunc
+	getwd      func() (string, error)
+	fileReader func(string) ([]byte, error)
 }
 
 type NodeModuleVersion struct {
@@ -34,13 +39,6 @@ func (p NodeEnvDependencies) Dependencies() []string {
 
 func (p NodeEnvDependencies) Execute(option tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 
-	if upstream["Node/Env/NpmVersion"].Status != tasks.Info {
-		return tasks.Result{
-			Status:  tasks.None,
-			Summary: "NPM is not installed. This task did not run",
-		}
-	}
-
 	if upstream["Node/Config/Agent"].Status != tasks.Success {
 		return tasks.Result{
 			Status:  tasks.None,
@@ -48,6 +46,11 @@ func (p NodeEnvDependencies) Execute(option tasks.Options, upstream map[string]t
 		}
 	}
 
+	// without npm the resolved versions are read from the lockfile of the application
+	if upstream["Node/Env/NpmVersion"].Status != tasks.Info {
+		return p.getLockfileDependencies()
+	}
+
 	modulesList, npmErr := p.getModulesListStr()
 	// create a channel to stream modulesList and zip file with tasks.FileCopyEnvelope
 	stream := make(chan string)
@@ -84,6 +87,82 @@ func (p NodeEnvDependencies) Execute(option tasks.Options, upstream map[string]t
 	}
 }
 
+func (p NodeEnvDependencies) getLockfileDependencies() tasks.Result {
+	dir, err := p.getwd()
+	if err != nil {
+		log.Debug("Unable to read the working directory:", err)
+	}
+	lockfiles := tasks.ReadLockfiles(dir, "node", p.fileReader)
+	if err != nil || len(lockfiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "NPM is not installed and no package-lock.json, yarn.lock or pnpm-lock.yaml was found. This task did not run",
+		}
+	}
+
+	lockfile := lockfiles[0]
+	modulesVersions := getLockfileModulesVersions(lockfile, p.getDeclaredModules(dir))
+	if len(modulesVersions) < 1 {
+		return tasks.Result{
+			Status:      tasks.Error,
+			Summary:     "We failed to find any dependencies in " + lockfile.Path + ", but have included it in nrdiag-output.zip.",
+			FilesToCopy: []tasks.FileCopyEnvelope{{Path: lockfile.Path}},
+		}
+	}
+	return tasks.Result{
+		Status:      tasks.Info,
+		Summary:     "We have successfully retrieved a list of dependencies from " + lockfile.Path,
+		Payload:     modulesVersions,
+		FilesToCopy: []tasks.FileCopyEnvelope{{Path: lockfile.Path}},
+	}
+}
+
+// getDeclaredModules - the dependencies of the package.json next to the lockfile, which npm ls --depth=0 would list
+func (p NodeEnvDependencies) getDeclaredModules(dir string) []string {
+	content, err := p.fileReader(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var packageJSON struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(content, &packageJSON); err != nil {
+		log.Debug("Unable to parse package.json:", err)
+		return nil
+	}
+	var declared []string
+	for _, group := range []map[string]string{packageJSON.Dependencies, packageJSON.DevDependencies, packageJSON.OptionalDependencies} {
+		for name := range group {
+			declared = append(declared, name)
+		}
+	}
+	return declared
+}
+
+// getLockfileModulesVersions - the top level modules of the lockfile, or the ones of package.json when the lockfile does not record them
+func getLockfileModulesVersions(lockfile tasks.Lockfile, declared []string) []NodeModuleVersion {
+	hasDirect := false
+	for _, dependency := range lockfile.Dependencies {
+		hasDirect = hasDirect || dependency.Direct
+	}
+
+	var modulesVersions []NodeModuleVersion
+	var found []string
+	for _, dependency := range lockfile.Dependencies {
+		if hasDirect && !dependency.Direct || !hasDirect && len(declared) > 0 && !tasks.ContainsString(declared, dependency.Name) {
+			continue
+		}
+		if tasks.ContainsString(found, dependency.Name) {
+			continue
+		}
+		found = append(found, dependency.Name)
+		modulesVersions = append(modulesVersions, NodeModuleVersion{Module: dependency.Name, Version: dependency.Version})
+	}
+	return modulesVersions
+}
+
 func (p NodeEnvDependencies) getModulesListStr() (string, error) {
 	cmdOutput, cmdError := p.cmdExec("npm", "ls", "--parseable=true", "--long=true", "--depth=0")
 	modulesList := string(cmdOutput)
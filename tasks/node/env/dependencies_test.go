@@ -66,7 +66,7 @@ var _ = Describe("Node/Env/Dependencies", func() {
 			})
 		})
 
-		Context("When NPM is not installed", func() {
+		Context("When NPM is not installed and there is no lockfile", func() {
 			BeforeEach(func() {
 				options = tasks.Options{}
 				upstream = map[string]tasks.Result{
@@ -77,12 +77,50 @@ var _ = Describe("Node/Env/Dependencies", func() {
 						Status: tasks.Success,
 					},
 				}
+				p.getwd = func() (string, error) { return "/app", nil }
+				p.fileReader = func(string) ([]byte, error) {
+					return nil, errors.New("no such file")
+				}
 			})
 			It("Should return a None result status", func() {
 				Expect(result.Status).To(Equal(tasks.None))
 			})
 			It("Should return a None result summary", func() {
-				Expect(result.Summary).To(Equal("NPM is not installed. This task did not run"))
+				Expect(result.Summary).To(Equal("NPM is not installed and no package-lock.json, yarn.lock or pnpm-lock.yaml was found. This task did not run"))
+			})
+		})
+		Context("When NPM is not installed and the application has a lockfile", func() {
+			BeforeEach(func() {
+				options = tasks.Options{}
+				upstream = map[string]tasks.Result{
+					"Node/Env/NpmVersion": {
+						Status: tasks.None,
+					},
+					"Node/Config/Agent": {
+						Status: tasks.Success,
+					},
+				}
+				files := map[string]string{
+					"/app/package.json": `{"name": "app", "dependencies": {"express": "^4.16.4", "mongoose": "^5.4.0"}}`,
+					"/app/yarn.lock":    "debug@2.6.9:\n  version \"2.6.9\"\n\nexpress@^4.16.4:\n  version \"4.16.4\"\n\nmongoose@^5.4.0:\n  version \"5.4.0\"\n",
+				}
+				p.getwd = func() (string, error) { return "/app", nil }
+				p.fileReader = func(path string) ([]byte, error) {
+					content, ok := files[path]
+					if !ok {
+						return nil, errors.New("no such file")
+					}
+					return []byte(content), nil
+				}
+			})
+			It("Should return an Info result with the declared modules resolved by the lockfile", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Summary).To(Equal("We have successfully retrieved a list of dependencies from /app/yarn.lock"))
+				Expect(result.Payload).To(Equal([]NodeModuleVersion{
+					{Module: "express", Version: "4.16.4"},
+					{Module: "mongoose", Version: "5.4.0"},
+				}))
+				Expect(result.FilesToCopy).To(Equal([]tasks.FileCopyEnvelope{{Path: "/app/yarn.lock"}}))
 			})
 		})
 		Context("When getModulesList gets an error", func() {
@@ -197,6 +235,23 @@ var _ = Describe("Node/Env/Dependencies", func() {
 
 		})
 	})
+	Describe("getLockfileModulesVersions()", func() {
+		lockfile := tasks.Lockfile{
+			Path: "/app/package-lock.json",
+			Dependencies: []tasks.LockfileDependency{
+				{Name: "debug", Version: "2.6.9"},
+				{Name: "debug", Version: "4.3.4"},
+				{Name: "express", Version: "4.18.2"},
+			},
+		}
+		It("Should use the modules declared by package.json", func() {
+			Expect(getLockfileModulesVersions(lockfile, []string{"debug"})).To(Equal([]NodeModuleVersion{{Module: "debug", Version: "2.6.9"}}))
+		})
+		It("Should prefer the modules the lockfile records as direct", func() {
+			direct := tasks.Lockfile{Dependencies: append([]tasks.LockfileDependency{{Name: "koa", Version: "2.14.2", Direct: true}}, lockfile.Dependencies...)}
+			Expect(getLockfileModulesVersions(direct, []string{"debug"})).To(Equal([]NodeModuleVersion{{Module: "koa", Version: "2.14.2"}}))
+		})
+	})
 	Describe("getNodeModulesVersions()", func() {
 		var (
 			//input
@@ -19,7 +19,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		cmdExec: tasks.CmdExecutor}, true)
 
 	registrationFunc(NodeEnvDependencies{
-		cmdExec: tasks.CmdExecutor,
+		cmdExec:    tasks.CmdExecutor,
+		getwd:      os.Getwd,
+		fileReader: os.ReadFile,
 	}, true)
 
 	registrationFunc(NodeEnvNpmPackage{
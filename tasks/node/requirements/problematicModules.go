@@ -6,16 +6,10 @@ import (
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
 	dependencies "github.com/newrelic/newrelic-diagnostics-cli/tasks/node/env"
 )
 
-var modules = map[string][]string{
-	"supported":   {"express", "hapi", "restify", "connect", "koa"},
-	"unsupported": {"mongoose", "typescript", "@types/node", "@babel/core", "@babel/node", "@babel/generator", "webpack", "graphql-server-express", "apollo-server", "graphql", "webpack-node-externals"},
-	"optional":    {"@newrelic/native-metrics"},
-	"frontend":    {"react", "react-dom", "angular", "@angular/core", "@angular/common"},
-}
-
 type NodeRequirementsProblematicModules struct {
 }
 
@@ -78,24 +72,28 @@ func initializeTaskDependencies(upstream map[string]tasks.Result) []dependencies
 func (p NodeRequirementsProblematicModules) isUsingSupportedFramework(foundDependencies []dependencies.NodeModuleVersion) bool {
 
 	for _, dependency := range foundDependencies {
-		moduleName := dependency.Module
-		for _, val := range modules["supported"] {
-			if val == moduleName {
-				return true
-			}
+		if requirements, ok := compatibilityVars.NodeFrameworkModules[dependency.Module]; ok && isModuleVersionIn(dependency, requirements) {
+			return true
 		}
 	}
 	return false
 }
 
+func checkForUnsupportedFrameworkVersions(foundDependencies []dependencies.NodeModuleVersion) []string {
+	var unsupportedVersions []string
+	for _, dependency := range foundDependencies {
+		if requirements, ok := compatibilityVars.NodeFrameworkModules[dependency.Module]; ok && !isModuleVersionIn(dependency, requirements) {
+			unsupportedVersions = append(unsupportedVersions, fmt.Sprintf("%s %s (supported: %s)", dependency.Module, dependency.Version, strings.Join(requirements, ", ")))
+		}
+	}
+	return unsupportedVersions
+}
+
 func checkForFrontendFrameworks(foundNodeDependencies []dependencies.NodeModuleVersion) []string {
 	var frontendFrameworks []string
 	for _, dependency := range foundNodeDependencies {
-		moduleName := dependency.Module
-		for _, val := range modules["frontend"] {
-			if val == moduleName {
-				frontendFrameworks = append(frontendFrameworks, val)
-			}
+		if tasks.ContainsString(compatibilityVars.NodeFrontendModules, dependency.Module) {
+			frontendFrameworks = append(frontendFrameworks, dependency.Module)
 		}
 	}
 	return frontendFrameworks
@@ -103,11 +101,8 @@ func checkForFrontendFrameworks(foundNodeDependencies []dependencies.NodeModuleV
 
 func isUsingNativeMetricsModule(foundDependencies []dependencies.NodeModuleVersion) bool {
 	for _, dependency := range foundDependencies {
-		moduleName := dependency.Module
-		for _, val := range modules["optional"] {
-			if val == moduleName {
-				return true
-			}
+		if tasks.ContainsString(compatibilityVars.NodeOptionalModules, dependency.Module) {
+			return true
 		}
 	}
 	return false
@@ -116,16 +111,27 @@ func isUsingNativeMetricsModule(foundDependencies []dependencies.NodeModuleVersi
 func checkForConflictiveModules(foundDependencies []dependencies.NodeModuleVersion) []string {
 	var conflictiveModules []string
 	for _, dependency := range foundDependencies {
-		moduleName := dependency.Module
-		for _, val := range modules["unsupported"] {
-			if val == moduleName {
-				conflictiveModules = append(conflictiveModules, val)
-			}
+		if requirements, ok := compatibilityVars.NodeIncompatibleModules[dependency.Module]; ok && isModuleVersionIn(dependency, requirements) {
+			conflictiveModules = append(conflictiveModules, dependency.Module)
 		}
 	}
 	return conflictiveModules
 }
 
+// isModuleVersionIn - a version that cannot be compared is given the benefit of the doubt
+func isModuleVersionIn(dependency dependencies.NodeModuleVersion, requirements []string) bool {
+	version := tasks.LockfileVersionNumber(dependency.Version)
+	if version == "" {
+		return true
+	}
+	isIn, err := tasks.VersionIsCompatible(version, requirements)
+	if err != nil {
+		log.Debug("Unable to compare the version of", dependency.Module, ":", err)
+		return true
+	}
+	return isIn
+}
+
 func (p NodeRequirementsProblematicModules) checkForMissingDataIssues(foundDependencies []dependencies.NodeModuleVersion) string {
 
 	var warningSummary string
@@ -134,6 +140,11 @@ func (p NodeRequirementsProblematicModules) checkForMissingDataIssues(foundDepen
 		warningSummary += "- You are not using a supported framework by the Node Agent. In order to get monitoring data, you'll have to apply manual instrumentation using our APIs. For more information: https://docs.newrelic.com/docs/agents/nodejs-agent/supported-features/nodejs-custom-instrumentation\n"
 	}
 
+	unsupportedFrameworkVersions := checkForUnsupportedFrameworkVersions(foundDependencies)
+	if len(unsupportedFrameworkVersions) > 0 {
+		warningSummary += fmt.Sprintf("- The following framework version(s) are not instrumented by the Node Agent: %s. Please upgrade them to a supported version.\n", strings.Join(unsupportedFrameworkVersions, ", "))
+	}
+
 	foundFrontendFrameworks := checkForFrontendFrameworks(foundDependencies)
 	if len(foundFrontendFrameworks) > 0 {
 		warningSummary += fmt.Sprintf("- We noticed that you are using: %s. If you are looking to monitor a client side app, beware that the Node Agent only monitors server side frameworks. To get metrics for front-end libraries/frameworks use the Browser Agent instead: https://docs.newrelic.com/docs/browser/new-relic-browser/getting-started/compatibility-requirements-new-relic-browser\n", strings.Join(foundFrontendFrameworks, ", "))
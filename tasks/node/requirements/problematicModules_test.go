@@ -136,6 +136,31 @@ var _ = Describe("Node/Requirements/ProblematicModules", func() {
 				Expect(result.Summary).To(Equal("- You are not using a supported framework by the Node Agent. In order to get monitoring data, you'll have to apply manual instrumentation using our APIs. For more information: https://docs.newrelic.com/docs/agents/nodejs-agent/supported-features/nodejs-custom-instrumentation\n- We have detected the following unsupported module(s) in your application: @babel/node, @babel/core. This may cause instrumentation issues and inconsistency of data for the Node Agent.\n- Keep in mind that if you are looking for additional Node.js runtime level statistics, you'll need to install our optional module: @newrelic/native-metrics. For more information: https://docs.newrelic.com/docs/agents/nodejs-agent/supported-features/nodejs-vm-measurements\n"))
 			})
 		})
+		Context("when the framework version is older than the versions instrumented by the Node Agent", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Env/Dependencies": {
+						Status: tasks.Info,
+						Payload: []dependencies.NodeModuleVersion{
+							{
+								Module:  "express",
+								Version: "3.21.2",
+							},
+							{
+								Module:  "@newrelic/native-metrics",
+								Version: "10.0.0",
+							},
+						},
+					},
+				}
+			})
+			It("should return an expected Warning Status", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+			})
+			It("should return an expected summary", func() {
+				Expect(result.Summary).To(Equal("- You are not using a supported framework by the Node Agent. In order to get monitoring data, you'll have to apply manual instrumentation using our APIs. For more information: https://docs.newrelic.com/docs/agents/nodejs-agent/supported-features/nodejs-custom-instrumentation\n- The following framework version(s) are not instrumented by the Node Agent: express 3.21.2 (supported: 4.6.0+). Please upgrade them to a supported version.\n"))
+			})
+		})
 
 	})
 })
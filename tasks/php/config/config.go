@@ -10,4 +10,5 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Node/Config/*")
 
 	registrationFunc(PHPConfigAgent{}, true)
+	registrationFunc(PHPConfigSAPIs{}, true)
 }
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/php/env"
+)
+
+// PHPConfigSAPIs - compares the New Relic configuration loaded by each PHP SAPI
+type PHPConfigSAPIs struct {
+}
+
+// the settings that send the data of every SAPI to the same application
+var sharedSAPIDirectives = []string{"newrelic.license", "newrelic.appname"}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PHPConfigSAPIs) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("PHP/Config/SAPIs")
+}
+
+// Explain - Returns the help text for each individual task
+func (p PHPConfigSAPIs) Explain() string {
+	return "Check that every PHP SAPI loads the New Relic extension with the same license key and application name"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PHPConfigSAPIs) Dependencies() []string {
+	return []string{
+		"PHP/Env/SAPIConfig",
+	}
+}
+
+// Execute - The core work within each task
+func (p PHPConfigSAPIs) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["PHP/Env/SAPIConfig"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The configuration of the PHP SAPIs was not collected. This task did not run",
+		}
+	}
+	sapis, ok := upstream["PHP/Env/SAPIConfig"].Payload.([]env.SAPIConfig)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+	if len(sapis) < 2 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Only one PHP SAPI was found, there is no configuration to compare",
+		}
+	}
+
+	failures := getMissingExtensions(sapis)
+	warnings := getDisagreements(sapis)
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(failures, warnings...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/php-agent/configuration/php-agent-configuration/#ini-location",
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(warnings, "\n"),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/php-agent/configuration/php-agent-configuration/",
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("The %d PHP SAPIs load the New Relic extension with the same license key and application name", len(sapis)),
+	}
+}
+
+// getMissingExtensions - a newrelic.ini loaded by one SAPI and missing from the scan dir of a web SAPI is the most common cause of no data
+func getMissingExtensions(sapis []env.SAPIConfig) []string {
+	var loadedBy *env.SAPIConfig
+	for i := range sapis {
+		if sapis[i].ExtensionLoaded {
+			loadedBy = &sapis[i]
+			break
+		}
+	}
+	if loadedBy == nil {
+		var scanDirs []string
+		for _, sapi := range sapis {
+			scanDirs = append(scanDirs, fmt.Sprintf("%s: %s", sapi.SAPI, sapi.ScanDir))
+		}
+		return []string{"None of the PHP SAPIs load the New Relic extension, newrelic.ini must be in the scan dir of each SAPI:\n" + strings.Join(scanDirs, "\n")}
+	}
+	var failures []string
+	for _, sapi := range sapis {
+		if sapi.ExtensionLoaded || sapi.SAPI == env.SAPICLI {
+			continue
+		}
+		failure := fmt.Sprintf("The %s SAPI loads the New Relic extension but the %s %s SAPI does not.", loadedBy.SAPI, sapi.SAPI, sapi.Version)
+		if loadedBy.NewRelicIni != "" && sapi.ScanDir != "" {
+			failure += fmt.Sprintf(" Copy or link %s into %s and restart the web server.", loadedBy.NewRelicIni, sapi.ScanDir)
+		}
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+// getDisagreements - the pools of php-fpm override the ini settings of the SAPI
+func getDisagreements(sapis []env.SAPIConfig) []string {
+	var warnings []string
+	for _, directive := range sharedSAPIDirectives {
+		valueSources := map[string][]string{}
+		for _, sapi := range sapis {
+			if !sapi.ExtensionLoaded {
+				continue
+			}
+			value := sapi.Directives[directive]
+			valueSources[value] = append(valueSources[value], sapi.SAPI)
+			for _, pool := range sapi.Pools {
+				if poolValue, found := pool.Directives[directive]; found {
+					valueSources[poolValue] = append(valueSources[poolValue], fmt.Sprintf("%s pool %s", sapi.SAPI, pool.Name))
+				}
+			}
+		}
+		if len(valueSources) < 2 {
+			continue
+		}
+		values := make([]string, 0, len(valueSources))
+		for value := range valueSources {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		var lines []string
+		for _, value := range values {
+			label := value
+			if label == "" {
+				label = "(not set)"
+			}
+			lines = append(lines, fmt.Sprintf("\t%s: %s", label, strings.Join(valueSources[value], ", ")))
+		}
+		warnings = append(warnings, fmt.Sprintf("The PHP SAPIs set different values for %s, their data goes to different accounts or applications:\n%s", directive, strings.Join(lines, "\n")))
+	}
+	return warnings
+}
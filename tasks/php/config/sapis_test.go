@@ -0,0 +1,68 @@
+package config
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/php/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PHP/Config/SAPIs", func() {
+	var p PHPConfigSAPIs
+	var cli, fpm env.SAPIConfig
+
+	BeforeEach(func() {
+		cli = env.SAPIConfig{
+			SAPI:            env.SAPICLI,
+			Version:         "8.2",
+			NewRelicIni:     "/etc/php/8.2/cli/conf.d/20-newrelic.ini",
+			ExtensionLoaded: true,
+			Directives:      map[string]string{"newrelic.license": "****NRAL", "newrelic.appname": "Orders"},
+		}
+		fpm = env.SAPIConfig{
+			SAPI:            env.SAPIFPM,
+			Version:         "8.2",
+			ScanDir:         "/etc/php/8.2/fpm/conf.d",
+			ExtensionLoaded: true,
+			Directives:      map[string]string{"newrelic.license": "****NRAL", "newrelic.appname": "Orders"},
+		}
+	})
+
+	execute := func(sapis ...env.SAPIConfig) tasks.Result {
+		return p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"PHP/Env/SAPIConfig": {Status: tasks.Info, Payload: sapis},
+		})
+	}
+
+	It("Should not run without the SAPI configuration", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"PHP/Env/SAPIConfig": {Status: tasks.None},
+		})
+		Expect(result.Status).To(Equal(tasks.None))
+	})
+
+	It("Should succeed when the SAPIs agree", func() {
+		Expect(execute(cli, fpm).Status).To(Equal(tasks.Success))
+	})
+
+	It("Should fail when newrelic.ini is loaded by the cli but not by fpm", func() {
+		fpm.ExtensionLoaded = false
+		fpm.Directives = map[string]string{}
+		result := execute(cli, fpm)
+		Expect(result.Status).To(Equal(tasks.Failure))
+		Expect(result.Summary).To(Equal("The cli SAPI loads the New Relic extension but the fpm 8.2 SAPI does not. Copy or link /etc/php/8.2/cli/conf.d/20-newrelic.ini into /etc/php/8.2/fpm/conf.d and restart the web server."))
+	})
+
+	It("Should warn when the SAPIs and pools disagree on the license key and application name", func() {
+		fpm.Directives["newrelic.appname"] = "Orders FPM"
+		fpm.Pools = []env.FPMPool{{Name: "www", Directives: map[string]string{"newrelic.license": "****ABCD"}}}
+		result := execute(cli, fpm)
+		Expect(result.Status).To(Equal(tasks.Warning))
+		Expect(result.Summary).To(Equal("The PHP SAPIs set different values for newrelic.license, their data goes to different accounts or applications:\n" +
+			"\t****ABCD: fpm pool www\n" +
+			"\t****NRAL: cli, fpm\n" +
+			"The PHP SAPIs set different values for newrelic.appname, their data goes to different accounts or applications:\n" +
+			"\tOrders: cli\n" +
+			"\tOrders FPM: fpm"))
+	})
+})
@@ -0,0 +1,22 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterLinuxWith - will register any plugins in this package
+func RegisterLinuxWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering PHP/Daemon/*_linux")
+
+	// the sockets of the daemon are read from /proc/net
+	registrationFunc(PHPDaemonSocket{
+		processFinder: tasks.FindProcessByName,
+		fileReader:    os.ReadFile,
+		globber:       filepath.Glob,
+		readLink:      os.Readlink,
+	}, true)
+}
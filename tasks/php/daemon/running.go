@@ -68,6 +68,15 @@ func (p PHPDaemonRunning) Execute(options tasks.Options, upstream map[string]tas
 				Summary: fmt.Sprintf("%s Please make sure the daemons were started as outlined in the following document:", baseFailureSummaryMsg),
 				Payload: daemonInfo,
 				URL:     "https://docs.newrelic.com/docs/agents/php-agent/advanced-installation/starting-php-daemon-advanced",
+				// in agent mode the daemon is started by the agent, so only a manually started daemon can be restarted on its own
+				Remediations: []tasks.Remediation{
+					{
+						Description: "Restart the newrelic-daemon service",
+						Actions: []tasks.RemediationAction{
+							tasks.Command{Name: "service", Args: []string{"newrelic-daemon", "restart"}},
+						},
+					},
+				},
 			}
 		}
 
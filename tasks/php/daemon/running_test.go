@@ -112,6 +112,10 @@ var _ = Describe("PHP/Daemon/Running", func() {
 				It("should return a result summary with the number of daemon process", func() {
 					Expect(result.Summary).To(Equal("There is incorrect number of newrelic-daemon processes running - (0). Please restart your web server to start up the daemons."))
 				})
+
+				It("should not offer to restart a daemon started by the agent", func() {
+					Expect(result.Remediations).To(BeEmpty())
+				})
 			})
 
 			Context("Zero daemon processes found, manual start mode", func() {
@@ -135,6 +139,13 @@ var _ = Describe("PHP/Daemon/Running", func() {
 					Expect(result.URL).To(Equal("https://docs.newrelic.com/docs/agents/php-agent/advanced-installation/starting-php-daemon-advanced"))
 				})
 
+				It("should offer to restart the daemon service", func() {
+					Expect(result.Remediations).To(HaveLen(1))
+					Expect(result.Remediations[0].Actions).To(Equal([]tasks.RemediationAction{
+						tasks.Command{Name: "service", Args: []string{"newrelic-daemon", "restart"}},
+					}))
+				})
+
 			})
 
 			Context("One daemon process found, manual start mode", func() {
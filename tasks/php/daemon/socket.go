@@ -0,0 +1,262 @@
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/php/env"
+)
+
+// PHPDaemonSocket - compares the newrelic.daemon.address of each SAPI with the sockets the daemon listens on
+type PHPDaemonSocket struct {
+	processFinder processFinderFunc
+	fileReader    func(string) ([]byte, error)
+	globber       func(string) ([]string, error)
+	readLink      func(string) (string, error)
+}
+
+// DaemonListener - a socket in the listening state
+type DaemonListener struct {
+	// Address is the path of a unix socket, @name for an abstract unix socket, or the port of a tcp socket
+	Address string
+	Inode   string
+}
+
+const (
+	// the default of newrelic.daemon.address on Linux
+	defaultDaemonAddress = "@newrelic"
+	// the __SO_ACCEPTCON flag of /proc/net/unix, set for listening unix sockets
+	unixAcceptFlag = 0x10000
+	tcpListenState = "0A"
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PHPDaemonSocket) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("PHP/Daemon/Socket")
+}
+
+// Explain - Returns the help text for each individual task
+func (p PHPDaemonSocket) Explain() string {
+	return "Check that the newrelic-daemon listens on the newrelic.daemon.address of each PHP SAPI"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PHPDaemonSocket) Dependencies() []string {
+	return []string{
+		"PHP/Daemon/Running",
+		"PHP/Env/SAPIConfig",
+	}
+}
+
+// Execute - The core work within each task
+func (p PHPDaemonSocket) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["PHP/Daemon/Running"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The newrelic-daemon is not running. This task did not run",
+		}
+	}
+	if upstream["PHP/Env/SAPIConfig"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The configuration of the PHP SAPIs was not collected. This task did not run",
+		}
+	}
+	sapis, ok := upstream["PHP/Env/SAPIConfig"].Payload.([]env.SAPIConfig)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	listeners := p.getListeners()
+	daemonListeners := p.getDaemonListeners(listeners)
+	// without access to the file descriptors of the daemon, any process listening on the address is accepted
+	checked := daemonListeners
+	if len(checked) == 0 {
+		log.Debug("Unable to read the sockets of the newrelic-daemon, checking every listening socket")
+		checked = listeners
+	}
+
+	var failures, successes []string
+	for _, sapi := range sapis {
+		if !sapi.ExtensionLoaded {
+			continue
+		}
+		address := getDaemonAddress(sapi)
+		listenAddress, local := normalizeDaemonAddress(address)
+		if !local {
+			successes = append(successes, fmt.Sprintf("%s: newrelic.daemon.address %s is a remote daemon and was not checked", sapi.SAPI, address))
+			continue
+		}
+		if isListening(checked, listenAddress) {
+			successes = append(successes, fmt.Sprintf("%s: the newrelic-daemon listens on %s", sapi.SAPI, address))
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s: newrelic.daemon.address is %s but the newrelic-daemon is not listening on it", sapi.SAPI, address))
+	}
+
+	if len(failures) == 0 && len(successes) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No PHP SAPI loads the New Relic extension. This task did not run",
+		}
+	}
+	if len(failures) > 0 {
+		var addresses []string
+		for _, listener := range daemonListeners {
+			addresses = append(addresses, listener.Address)
+		}
+		summary := strings.Join(failures, "\n")
+		if len(addresses) > 0 {
+			summary += "\nThe newrelic-daemon is listening on: " + strings.Join(addresses, ", ")
+		}
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: summary + "\nThe agent and the daemon must use the same newrelic.daemon.address, restart the web server after changing it.",
+			URL:     "https://docs.newrelic.com/docs/apm/agents/php-agent/configuration/php-agent-configuration/#inivar-daemon-address",
+			Payload: daemonListeners,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(successes, "\n"),
+		Payload: daemonListeners,
+	}
+}
+
+// getDaemonAddress - newrelic.daemon.port is the name of the setting before agent 9.2
+func getDaemonAddress(sapi env.SAPIConfig) string {
+	for _, directive := range []string{"newrelic.daemon.address", "newrelic.daemon.port"} {
+		if address := sapi.Directives[directive]; address != "" {
+			return address
+		}
+	}
+	return defaultDaemonAddress
+}
+
+// normalizeDaemonAddress - returns the address as listed by getListeners, and false for the daemons of other hosts
+func normalizeDaemonAddress(address string) (string, bool) {
+	if strings.HasPrefix(address, "/") || strings.HasPrefix(address, "@") {
+		return address, true
+	}
+	if _, err := strconv.Atoi(address); err == nil {
+		return address, true
+	}
+	host, port, found := strings.Cut(address, ":")
+	if index := strings.LastIndex(address, "]:"); index != -1 {
+		host, port, found = address[1:index], address[index+2:], true
+	}
+	if !found {
+		return address, true
+	}
+	switch host {
+	case "localhost", "127.0.0.1", "::1", "0.0.0.0", "":
+		return port, true
+	}
+	return address, false
+}
+
+func isListening(listeners []DaemonListener, address string) bool {
+	for _, listener := range listeners {
+		if listener.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// getListeners - reads the listening unix and tcp sockets of the host
+func (p PHPDaemonSocket) getListeners() []DaemonListener {
+	var listeners []DaemonListener
+	if content, err := p.fileReader("/proc/net/unix"); err == nil {
+		listeners = append(listeners, parseUnixListeners(string(content))...)
+	} else {
+		log.Debug("Unable to read /proc/net/unix:", err)
+	}
+	for _, file := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		if content, err := p.fileReader(file); err == nil {
+			listeners = append(listeners, parseTCPListeners(string(content))...)
+		} else {
+			log.Debug("Unable to read", file, ":", err)
+		}
+	}
+	return listeners
+}
+
+// getDaemonListeners - the sockets of the daemon are the ones whose inode is open by a newrelic-daemon process
+func (p PHPDaemonSocket) getDaemonListeners(listeners []DaemonListener) []DaemonListener {
+	processes, err := p.processFinder("newrelic-daemon")
+	if err != nil {
+		log.Debug("Unable to find the newrelic-daemon processes:", err)
+		return nil
+	}
+	inodes := map[string]bool{}
+	for i := range processes {
+		fds, _ := p.globber(filepath.Join("/proc", strconv.Itoa(int(processes[i].Pid)), "fd", "*"))
+		for _, fd := range fds {
+			target, err := p.readLink(fd)
+			if err != nil {
+				continue
+			}
+			if inode, found := strings.CutPrefix(target, "socket:["); found {
+				inodes[strings.TrimSuffix(inode, "]")] = true
+			}
+		}
+	}
+	var daemonListeners []DaemonListener
+	for _, listener := range listeners {
+		if inodes[listener.Inode] {
+			daemonListeners = append(daemonListeners, listener)
+		}
+	}
+	sort.Slice(daemonListeners, func(i, j int) bool { return daemonListeners[i].Address < daemonListeners[j].Address })
+	return daemonListeners
+}
+
+// parseUnixListeners - the columns are Num RefCount Protocol Flags Type St Inode Path
+func parseUnixListeners(content string) []DaemonListener {
+	var listeners []DaemonListener
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[0] == "Num" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&unixAcceptFlag == 0 {
+			continue
+		}
+		listeners = append(listeners, DaemonListener{Address: fields[7], Inode: fields[6]})
+	}
+	return listeners
+}
+
+// parseTCPListeners - the columns are sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+func parseTCPListeners(content string) []DaemonListener {
+	var listeners []DaemonListener
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListenState {
+			continue
+		}
+		_, hexPort, found := strings.Cut(fields[1], ":")
+		if !found {
+			continue
+		}
+		port, err := strconv.ParseUint(hexPort, 16, 16)
+		if err != nil {
+			continue
+		}
+		listeners = append(listeners, DaemonListener{Address: strconv.FormatUint(port, 10), Inode: fields[9]})
+	}
+	return listeners
+}
@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/php/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	procNetUnix = `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 21801 @newrelic
+0000000000000000: 00000002 00000000 00010000 0001 01 19422 /run/php/php8.2-fpm.sock
+0000000000000000: 00000003 00000000 00000000 0001 03 21950 @newrelic
+`
+	procNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 18810 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:7A6B 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 22010 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:7A6B 0100007F:D2F0 01 00000000:00000000 00:00000000 00000000     0        0 22100 1 0000000000000000 20 4 30 10 -1
+`
+)
+
+var _ = Describe("PHP/Daemon/Socket", func() {
+	var p PHPDaemonSocket
+	var sapis []env.SAPIConfig
+
+	BeforeEach(func() {
+		p = PHPDaemonSocket{
+			processFinder: func(string) ([]process.Process, error) { return []process.Process{{Pid: 900}}, nil },
+			fileReader: func(path string) ([]byte, error) {
+				switch path {
+				case "/proc/net/unix":
+					return []byte(procNetUnix), nil
+				case "/proc/net/tcp":
+					return []byte(procNetTCP), nil
+				}
+				return nil, errors.New("no such file or directory")
+			},
+			globber: func(pattern string) ([]string, error) {
+				Expect(pattern).To(Equal("/proc/900/fd/*"))
+				return []string{"/proc/900/fd/0", "/proc/900/fd/3", "/proc/900/fd/4"}, nil
+			},
+			readLink: func(path string) (string, error) {
+				return map[string]string{"/proc/900/fd/0": "/dev/null", "/proc/900/fd/3": "socket:[21801]", "/proc/900/fd/4": "socket:[22010]"}[path], nil
+			},
+		}
+		sapis = []env.SAPIConfig{
+			{SAPI: env.SAPICLI, ExtensionLoaded: true, Directives: map[string]string{"newrelic.daemon.address": "@newrelic"}},
+			{SAPI: env.SAPIFPM, ExtensionLoaded: true, Directives: map[string]string{"newrelic.daemon.address": "127.0.0.1:31339"}},
+		}
+	})
+
+	execute := func() tasks.Result {
+		return p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"PHP/Daemon/Running": {Status: tasks.Success},
+			"PHP/Env/SAPIConfig": {Status: tasks.Info, Payload: sapis},
+		})
+	}
+
+	It("Should not run when the daemon is not running", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"PHP/Daemon/Running": {Status: tasks.Failure},
+		})
+		Expect(result.Status).To(Equal(tasks.None))
+	})
+
+	It("Should succeed when the daemon listens on the address of every SAPI", func() {
+		result := execute()
+		Expect(result.Status).To(Equal(tasks.Success))
+		Expect(result.Payload).To(Equal([]DaemonListener{{Address: "31339", Inode: "22010"}, {Address: "@newrelic", Inode: "21801"}}))
+	})
+
+	It("Should fail when a SAPI uses an address the daemon is not listening on", func() {
+		sapis = append(sapis, env.SAPIConfig{SAPI: env.SAPIApache, ExtensionLoaded: true, Directives: map[string]string{}})
+		sapis[0].Directives["newrelic.daemon.address"] = "/tmp/.newrelic.sock"
+		result := execute()
+		Expect(result.Status).To(Equal(tasks.Failure))
+		Expect(result.Summary).To(Equal("cli: newrelic.daemon.address is /tmp/.newrelic.sock but the newrelic-daemon is not listening on it\n" +
+			"The newrelic-daemon is listening on: 31339, @newrelic\n" +
+			"The agent and the daemon must use the same newrelic.daemon.address, restart the web server after changing it."))
+	})
+
+	It("Should not check the sockets of other processes when the daemon sockets are readable", func() {
+		sapis[0].Directives["newrelic.daemon.address"] = "/run/php/php8.2-fpm.sock"
+		Expect(execute().Status).To(Equal(tasks.Failure))
+	})
+
+	It("Should check every listening socket when the daemon sockets are not readable", func() {
+		p.readLink = func(string) (string, error) { return "", errors.New("permission denied") }
+		sapis[1].Directives["newrelic.daemon.address"] = "80"
+		Expect(execute().Status).To(Equal(tasks.Success))
+	})
+
+	It("Should not check a remote daemon", func() {
+		sapis[1].Directives["newrelic.daemon.address"] = "newrelic-daemon.internal:31339"
+		result := execute()
+		Expect(result.Status).To(Equal(tasks.Success))
+		Expect(result.Summary).To(ContainSubstring("fpm: newrelic.daemon.address newrelic-daemon.internal:31339 is a remote daemon and was not checked"))
+	})
+})
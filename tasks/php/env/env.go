@@ -1,6 +1,9 @@
 package env
 
 import (
+	"os"
+	"path/filepath"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -11,4 +14,11 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(PHPEnvPHPinfoCLI{
 		cmdExec: tasks.CmdExecutor,
 	}, true)
+	registrationFunc(PHPEnvSAPIConfig{
+		cmdExec:    tasks.CmdExecutor,
+		globber:    filepath.Glob,
+		fileReader: os.ReadFile,
+		fileExists: tasks.FileExists,
+		etcDir:     "/etc",
+	}, true)
 }
@@ -0,0 +1,351 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// PHPEnvSAPIConfig - builds the ini load order and the New Relic settings of each PHP SAPI
+type PHPEnvSAPIConfig struct {
+	cmdExec    tasks.CmdExecFunc
+	globber    func(string) ([]string, error)
+	fileReader func(string) ([]byte, error)
+	fileExists func(string) bool
+	// etcDir is where the distributions install the PHP configuration, /etc outside of tests
+	etcDir string
+}
+
+// SAPIConfig - the configuration a PHP SAPI loads
+type SAPIConfig struct {
+	SAPI    string
+	Version string
+	// Source is the command the configuration was read from, or "ini files" when the files were parsed
+	Source     string
+	LoadedFile string
+	ScanDir    string
+	// IniFiles are in the order PHP loads them, the settings of the last file win
+	IniFiles    []string
+	NewRelicIni string
+	// ExtensionLoaded is set when the SAPI loads newrelic.so
+	ExtensionLoaded bool
+	// Directives are the newrelic.* settings, newrelic.license is masked
+	Directives map[string]string
+	Pools      []FPMPool
+}
+
+// FPMPool - the New Relic settings a php-fpm pool overrides with php_value and php_admin_value
+type FPMPool struct {
+	Name       string
+	File       string
+	Directives map[string]string
+}
+
+// SAPIs
+const (
+	SAPICLI    = "cli"
+	SAPIFPM    = "fpm"
+	SAPIApache = "apache2handler"
+)
+
+var (
+	phpinfoVersionRegex   = regexp.MustCompile(`^PHP Version => (\d+\.\d+)`)
+	debianVersionDirRegex = regexp.MustCompile(`/php/(\d+\.\d+)/`)
+	// php_value[newrelic.appname] = "My App" and php_admin_flag[newrelic.enabled] = on
+	poolDirectiveRegex     = regexp.MustCompile(`^php(?:_admin)?_(?:value|flag)\[(newrelic\.[\w.]+)\]\s*=\s*(.*)$`)
+	newRelicExtensionRegex = regexp.MustCompile(`^(?:zend_)?extension\s*=\s*["']?(?:[^"']*/)?newrelic(?:\.so)?["']?\s*$`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PHPEnvSAPIConfig) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("PHP/Env/SAPIConfig")
+}
+
+// Explain - Returns the help text for each individual task
+func (p PHPEnvSAPIConfig) Explain() string {
+	return "Collect the ini load order and New Relic settings of the PHP cli, fpm and apache2handler SAPIs"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PHPEnvSAPIConfig) Dependencies() []string {
+	return []string{
+		"PHP/Config/Agent",
+		"PHP/Env/PHPinfoCLI",
+	}
+}
+
+// Execute - The core work within each task
+func (p PHPEnvSAPIConfig) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["PHP/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "PHP Agent was not detected on this host. Skipping SAPI configuration check.",
+		}
+	}
+
+	var sapis []SAPIConfig
+	if phpinfo, ok := upstream["PHP/Env/PHPinfoCLI"].Payload.(string); ok && upstream["PHP/Env/PHPinfoCLI"].Status == tasks.Success {
+		sapis = append(sapis, parsePHPInfo(SAPICLI, "php -i", phpinfo))
+	}
+	sapis = append(sapis, p.getFPMConfigs()...)
+	sapis = append(sapis, p.getApacheConfigs()...)
+
+	if len(sapis) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Unable to read the configuration of any PHP SAPI",
+		}
+	}
+
+	var summary []string
+	for _, sapi := range sapis {
+		loaded := "does not load the New Relic extension"
+		if sapi.ExtensionLoaded {
+			loaded = "loads the New Relic extension"
+			if sapi.NewRelicIni != "" {
+				loaded += " from " + sapi.NewRelicIni
+			}
+		}
+		summary = append(summary, fmt.Sprintf("%s %s (%s): %d ini file(s), %s", sapi.SAPI, sapi.Version, sapi.Source, len(sapi.IniFiles), loaded))
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: strings.Join(summary, "\n"),
+		Payload: sapis,
+	}
+}
+
+// getFPMConfigs - Debian installs a php-fpm binary and configuration per PHP version, the other distributions a single php-fpm
+func (p PHPEnvSAPIConfig) getFPMConfigs() []SAPIConfig {
+	var sapis []SAPIConfig
+	versionDirs, _ := p.globber(filepath.Join(p.etcDir, "php", "*", "fpm"))
+	for _, versionDir := range versionDirs {
+		version := filepath.Base(filepath.Dir(versionDir))
+		sapi, ok := p.runFPMInfo("php-fpm" + version)
+		if !ok {
+			log.Debug("php-fpm", version, "is not installed, skipping", versionDir)
+			continue
+		}
+		sapi.Pools = p.getFPMPools(filepath.Join(versionDir, "php-fpm.conf"))
+		sapis = append(sapis, sapi)
+	}
+	if len(sapis) > 0 {
+		return sapis
+	}
+	sapi, ok := p.runFPMInfo("php-fpm")
+	if !ok {
+		return nil
+	}
+	sapi.Pools = p.getFPMPools(filepath.Join(p.etcDir, "php-fpm.conf"))
+	return []SAPIConfig{sapi}
+}
+
+func (p PHPEnvSAPIConfig) runFPMInfo(binary string) (SAPIConfig, bool) {
+	output, err := p.cmdExec(binary, "-i")
+	if err != nil {
+		return SAPIConfig{}, false
+	}
+	return parsePHPInfo(SAPIFPM, binary+" -i", string(output)), true
+}
+
+// getApacheConfigs - mod_php cannot be run from the command line, its ini files are parsed for each enabled php module
+func (p PHPEnvSAPIConfig) getApacheConfigs() []SAPIConfig {
+	var sapis []SAPIConfig
+	iniFiles, _ := p.globber(filepath.Join(p.etcDir, "php", "*", "apache2", "php.ini"))
+	for _, iniFile := range iniFiles {
+		sapiDir := filepath.Dir(iniFile)
+		version := filepath.Base(filepath.Dir(sapiDir))
+		if !p.fileExists(filepath.Join(p.etcDir, "apache2", "mods-enabled", "php"+version+".load")) {
+			continue
+		}
+		scanDir := filepath.Join(sapiDir, "conf.d")
+		scanned, _ := p.globber(filepath.Join(scanDir, "*.ini"))
+		sort.Strings(scanned)
+		sapis = append(sapis, p.parseIniFiles(SAPIApache, version, iniFile, scanDir, scanned))
+	}
+	return sapis
+}
+
+// parseIniFiles - reads the files in the order PHP loads them
+func (p PHPEnvSAPIConfig) parseIniFiles(sapiName string, version string, loadedFile string, scanDir string, scanned []string) SAPIConfig {
+	sapi := SAPIConfig{
+		SAPI:       sapiName,
+		Version:    version,
+		Source:     "ini files",
+		LoadedFile: loadedFile,
+		ScanDir:    scanDir,
+		IniFiles:   append([]string{loadedFile}, scanned...),
+		Directives: map[string]string{},
+	}
+	for _, iniFile := range sapi.IniFiles {
+		content, err := p.fileReader(iniFile)
+		if err != nil {
+			log.Debug("Unable to read", iniFile, ":", err)
+			continue
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(content)))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") {
+				continue
+			}
+			if newRelicExtensionRegex.MatchString(line) {
+				sapi.ExtensionLoaded = true
+				sapi.NewRelicIni = iniFile
+				continue
+			}
+			key, value, found := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !found || !strings.HasPrefix(key, "newrelic.") {
+				continue
+			}
+			sapi.Directives[key] = maskDirective(key, trimIniValue(value))
+		}
+	}
+	if !sapi.ExtensionLoaded {
+		// the directives of an extension that is not loaded are ignored by PHP
+		sapi.Directives = map[string]string{}
+	}
+	return sapi
+}
+
+// getFPMPools - reads the pools included by php-fpm.conf
+func (p PHPEnvSAPIConfig) getFPMPools(mainConfig string) []FPMPool {
+	content, err := p.fileReader(mainConfig)
+	if err != nil {
+		log.Debug("Unable to read", mainConfig, ":", err)
+		return nil
+	}
+	var poolFiles []string
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !found || strings.TrimSpace(key) != "include" {
+			continue
+		}
+		pattern := trimIniValue(value)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(mainConfig), pattern)
+		}
+		matches, _ := p.globber(pattern)
+		poolFiles = append(poolFiles, matches...)
+	}
+	sort.Strings(poolFiles)
+
+	var pools []FPMPool
+	for _, poolFile := range append([]string{mainConfig}, poolFiles...) {
+		content, err := p.fileReader(poolFile)
+		if err != nil {
+			log.Debug("Unable to read", poolFile, ":", err)
+			continue
+		}
+		pools = append(pools, parseFPMPools(poolFile, string(content))...)
+	}
+	return pools
+}
+
+func parseFPMPools(file string, content string) []FPMPool {
+	var pools []FPMPool
+	var current *FPMPool
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.Trim(line, "[]")
+			if name == "global" {
+				current = nil
+				continue
+			}
+			pools = append(pools, FPMPool{Name: name, File: file, Directives: map[string]string{}})
+			current = &pools[len(pools)-1]
+		case current != nil:
+			if match := poolDirectiveRegex.FindStringSubmatch(line); match != nil {
+				current.Directives[match[1]] = maskDirective(match[1], trimIniValue(match[2]))
+			}
+		}
+	}
+	return pools
+}
+
+// parsePHPInfo - reads the text output of php -i, the settings are printed as "directive => local value => master value"
+func parsePHPInfo(sapiName string, source string, phpinfo string) SAPIConfig {
+	sapi := SAPIConfig{SAPI: sapiName, Source: source, Directives: map[string]string{}}
+	lines := strings.Split(strings.ReplaceAll(phpinfo, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if match := phpinfoVersionRegex.FindStringSubmatch(line); match != nil {
+			sapi.Version = match[1]
+			continue
+		}
+		parts := strings.Split(line, " => ")
+		if len(parts) < 2 {
+			continue
+		}
+		key, value := parts[0], strings.TrimSpace(parts[1])
+		switch {
+		case key == "Loaded Configuration File" && value != "(none)":
+			sapi.LoadedFile = value
+			sapi.IniFiles = append([]string{value}, sapi.IniFiles...)
+		case key == "Scan this dir for additional .ini files" && value != "(none)":
+			sapi.ScanDir = value
+		case key == "Additional .ini files parsed" && value != "(none)":
+			// the list continues on the following lines while they end with a comma
+			files := []string{value}
+			for strings.HasSuffix(strings.TrimSpace(lines[i]), ",") && i+1 < len(lines) {
+				i++
+				files = append(files, strings.TrimSpace(lines[i]))
+			}
+			for _, file := range files {
+				if file = strings.TrimSuffix(strings.TrimSpace(file), ","); file != "" {
+					sapi.IniFiles = append(sapi.IniFiles, file)
+				}
+			}
+		case strings.HasPrefix(key, "newrelic."):
+			// directives are only listed when the extension is loaded
+			sapi.ExtensionLoaded = true
+			sapi.Directives[key] = maskDirective(key, value)
+		}
+	}
+	if sapi.Version == "" {
+		if match := debianVersionDirRegex.FindStringSubmatch(sapi.LoadedFile); match != nil {
+			sapi.Version = match[1]
+		}
+	}
+	for _, file := range sapi.IniFiles {
+		if strings.Contains(filepath.Base(file), "newrelic") {
+			sapi.NewRelicIni = file
+		}
+	}
+	return sapi
+}
+
+func trimIniValue(value string) string {
+	value = strings.TrimSpace(value)
+	for _, quote := range []string{`"`, `'`} {
+		if strings.HasPrefix(value, quote) {
+			// a comment may follow the closing quote
+			if end := strings.Index(value[1:], quote); end != -1 {
+				return value[1 : end+1]
+			}
+		}
+	}
+	value, _, _ = strings.Cut(value, ";")
+	return strings.TrimSpace(value)
+}
+
+// maskDirective - the license keys of the SAPIs are compared without writing them to the output
+func maskDirective(key string, value string) string {
+	if key != "newrelic.license" || len(value) <= 4 {
+		return value
+	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
@@ -0,0 +1,117 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// fixtureGlobber - the pool includes of the fixtures are absolute paths under /etc
+func fixtureGlobber(pattern string) ([]string, error) {
+	if strings.HasPrefix(pattern, "/etc/") {
+		pattern = filepath.Join("fixtures", pattern)
+	}
+	return filepath.Glob(pattern)
+}
+
+func fixturePHPInfo(t *testing.T, name string) string {
+	content, err := os.ReadFile(filepath.Join("fixtures", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestPHPEnvSAPIConfig_Execute(t *testing.T) {
+	fpmInfo := fixturePHPInfo(t, "phpinfo-fpm.txt")
+	p := PHPEnvSAPIConfig{
+		cmdExec: func(name string, arg ...string) ([]byte, error) {
+			if name == "php-fpm8.2" {
+				return []byte(fpmInfo), nil
+			}
+			return nil, errors.New("executable file not found in $PATH")
+		},
+		globber:    fixtureGlobber,
+		fileReader: os.ReadFile,
+		fileExists: tasks.FileExists,
+		etcDir:     filepath.Join("fixtures", "etc"),
+	}
+	result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+		"PHP/Config/Agent":   {Status: tasks.Success},
+		"PHP/Env/PHPinfoCLI": {Status: tasks.Success, Payload: fixturePHPInfo(t, "phpinfo-cli.txt")},
+	})
+	if result.Status != tasks.Info {
+		t.Fatalf("Execute() status = %v, want Info: %s", result.Status, result.Summary)
+	}
+	sapis := result.Payload.([]SAPIConfig)
+	if len(sapis) != 3 {
+		t.Fatalf("Execute() returned %d SAPIs, want 3", len(sapis))
+	}
+
+	cli := sapis[0]
+	wantCLIFiles := []string{"/etc/php/8.2/cli/php.ini", "/etc/php/8.2/cli/conf.d/10-opcache.ini", "/etc/php/8.2/cli/conf.d/10-pdo.ini", "/etc/php/8.2/cli/conf.d/20-newrelic.ini"}
+	if cli.SAPI != SAPICLI || cli.Version != "8.2" || !cli.ExtensionLoaded || cli.NewRelicIni != "/etc/php/8.2/cli/conf.d/20-newrelic.ini" || !reflect.DeepEqual(cli.IniFiles, wantCLIFiles) {
+		t.Errorf("cli SAPI = %+v", cli)
+	}
+	wantCLIDirectives := map[string]string{
+		"newrelic.appname":        "Orders",
+		"newrelic.daemon.address": "@newrelic",
+		"newrelic.enabled":        "enabled",
+		"newrelic.license":        "************************************NRAL",
+	}
+	if !reflect.DeepEqual(cli.Directives, wantCLIDirectives) {
+		t.Errorf("cli directives = %v, want %v", cli.Directives, wantCLIDirectives)
+	}
+
+	fpm := sapis[1]
+	if fpm.SAPI != SAPIFPM || fpm.Source != "php-fpm8.2 -i" || fpm.ExtensionLoaded || fpm.ScanDir != "/etc/php/8.2/fpm/conf.d" {
+		t.Errorf("fpm SAPI = %+v", fpm)
+	}
+	wantPools := []FPMPool{
+		{Name: "www", File: "fixtures/etc/php/8.2/fpm/pool.d/www.conf", Directives: map[string]string{
+			"newrelic.appname": "Orders Web",
+			"newrelic.license": "************************************NRAL",
+		}},
+		{Name: "admin", File: "fixtures/etc/php/8.2/fpm/pool.d/www.conf", Directives: map[string]string{
+			"newrelic.enabled": "off",
+		}},
+	}
+	if !reflect.DeepEqual(fpm.Pools, wantPools) {
+		t.Errorf("fpm pools = %+v, want %+v", fpm.Pools, wantPools)
+	}
+
+	// the apache2 configuration of PHP 7.4 is not used without its module
+	apache := sapis[2]
+	if apache.SAPI != SAPIApache || apache.Version != "8.2" || !apache.ExtensionLoaded || apache.NewRelicIni != filepath.Join("fixtures", "etc", "php", "8.2", "apache2", "conf.d", "20-newrelic.ini") {
+		t.Errorf("apache2handler SAPI = %+v", apache)
+	}
+	wantApacheDirectives := map[string]string{
+		"newrelic.appname":        "Orders Apache",
+		"newrelic.daemon.address": "/tmp/.newrelic.sock",
+		"newrelic.license":        "************************************NRAL",
+	}
+	if !reflect.DeepEqual(apache.Directives, wantApacheDirectives) {
+		t.Errorf("apache2handler directives = %v, want %v", apache.Directives, wantApacheDirectives)
+	}
+}
+
+func TestPHPEnvSAPIConfig_ExecuteWithoutAgent(t *testing.T) {
+	result := PHPEnvSAPIConfig{}.Execute(tasks.Options{}, map[string]tasks.Result{
+		"PHP/Config/Agent": {Status: tasks.None},
+	})
+	if result.Status != tasks.None {
+		t.Errorf("Execute() status = %v, want None", result.Status)
+	}
+}
+
+func Test_parsePHPInfo(t *testing.T) {
+	sapi := parsePHPInfo(SAPICLI, "php -i", "Loaded Configuration File => (none)\r\nScan this dir for additional .ini files => (none)\r\nAdditional .ini files parsed => (none)\r\n")
+	if sapi.LoadedFile != "" || len(sapi.IniFiles) != 0 || sapi.ExtensionLoaded {
+		t.Errorf("parsePHPInfo() = %+v, want no ini files", sapi)
+	}
+}
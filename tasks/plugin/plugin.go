@@ -0,0 +1,176 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// ProtocolVersion is sent with every request so plugins can reject versions they do not understand
+const ProtocolVersion = 1
+
+const (
+	describeTimeout       = 10 * time.Second
+	defaultExecuteTimeout = 120 * time.Second
+)
+
+// Request is written as JSON to the plugin's stdin
+type Request struct {
+	Command         string                     `json:"command"` // "describe" or "execute"
+	ProtocolVersion int                        `json:"protocolVersion"`
+	Identifier      string                     `json:"identifier,omitempty"`
+	Options         map[string]string          `json:"options,omitempty"`
+	Upstream        map[string]json.RawMessage `json:"upstream,omitempty"`
+}
+
+// Description is the plugin's answer to a describe request
+type Description struct {
+	Tasks []TaskDescription `json:"tasks"`
+}
+
+// TaskDescription describes one task provided by a plugin
+type TaskDescription struct {
+	Identifier     string   `json:"identifier"`
+	Explain        string   `json:"explain"`
+	Dependencies   []string `json:"dependencies"`
+	RunByDefault   bool     `json:"runByDefault"`
+	TimeoutSeconds int      `json:"timeoutSeconds"`
+}
+
+// Response is the plugin's answer to an execute request
+type Response struct {
+	Status      string          `json:"status"` // None, Success, Warning, Failure, Error or Info
+	Summary     string          `json:"summary"`
+	URL         string          `json:"url"`
+	FilesToCopy []File          `json:"filesToCopy"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// File is a file to add to nrdiag-output.zip: either an existing file (Path) or content
+// generated by the plugin (Name and Content)
+type File struct {
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// runFunc runs a plugin executable with input on stdin and returns its stdout
+type runFunc func(ctx context.Context, path string, input []byte) ([]byte, error)
+
+// RegisterDirWith - discovers the executables in dir, asks each one to describe its tasks and
+// registers them. It returns an error for every plugin that could not be used.
+func RegisterDirWith(dir string, registrationFunc func(tasks.Task, bool)) []error {
+	return registerDir(dir, registrationFunc, runPlugin)
+}
+
+func registerDir(dir string, registrationFunc func(tasks.Task, bool), run runFunc) []error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return []error{err}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var errs []error
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil || !isExecutable(info) {
+			continue
+		}
+		log.Debug("Registering plugin tasks from", path)
+
+		descriptions, err := describe(path, run)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name(), err))
+			continue
+		}
+		for _, description := range descriptions {
+			registrationFunc(PluginTask{path: path, description: description, run: run}, description.RunByDefault)
+		}
+	}
+	return errs
+}
+
+func describe(path string, run runFunc) ([]TaskDescription, error) {
+	input, err := json.Marshal(Request{Command: "describe", ProtocolVersion: ProtocolVersion})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+	defer cancel()
+	output, err := run(ctx, path, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var description Description
+	if err := json.Unmarshal(output, &description); err != nil {
+		return nil, fmt.Errorf("invalid describe response: %w", err)
+	}
+	if len(description.Tasks) == 0 {
+		return nil, errors.New("plugin does not describe any tasks")
+	}
+	for _, task := range description.Tasks {
+		identifier := tasks.IdentifierFromString(task.Identifier)
+		if strings.Count(task.Identifier, "/") != 2 || identifier.Category == "" || identifier.Subcategory == "" || identifier.Name == "" {
+			return nil, fmt.Errorf("task identifier %q must have the form Category/Subcategory/Name", task.Identifier)
+		}
+		if strings.TrimSpace(task.Explain) == "" {
+			return nil, fmt.Errorf("%s: explain is required", task.Identifier)
+		}
+	}
+	return description.Tasks, nil
+}
+
+// runPlugin executes the plugin with input on stdin. Plugins write their response to stdout;
+// stderr is only logged.
+func runPlugin(ctx context.Context, path string, input []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if stderr.Len() > 0 {
+		log.Debugf("Plugin %s stderr: %s\n", filepath.Base(path), stderr.String())
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, errors.New("plugin timed out")
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s", err, message)
+	}
+	return stdout.Bytes(), nil
+}
+
+// isExecutable reports whether a directory entry can be run as a plugin
+func isExecutable(info os.FileInfo) bool {
+	if info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(info.Name())) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
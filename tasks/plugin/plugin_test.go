@@ -0,0 +1,222 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// TestMain lets the test binary act as a plugin when GO_WANT_PLUGIN_HELPER is set, so runPlugin
+// can be exercised against a real subprocess
+func TestMain(m *testing.M) {
+	if os.Getenv("GO_WANT_PLUGIN_HELPER") == "1" {
+		helperPlugin()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func helperPlugin() {
+	var request Request
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		fmt.Fprintln(os.Stderr, "bad request:", err)
+		os.Exit(2)
+	}
+	switch request.Command {
+	case "describe":
+		fmt.Print(`{"tasks":[{"identifier":"Corp/Proxy/Check","explain":"Checks the corporate proxy","dependencies":["Base/Config/ProxyDetect"]}]}`)
+	case "execute":
+		fmt.Printf(`{"status":"Success","summary":"proxy option was %s"}`, request.Options["proxy"])
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command", request.Command)
+		os.Exit(3)
+	}
+	os.Exit(0)
+}
+
+func Test_runPlugin(t *testing.T) {
+	t.Setenv("GO_WANT_PLUGIN_HELPER", "1")
+	executable, err := os.Executable()
+	if err != nil {
+		t.Skip("test binary path unavailable:", err)
+	}
+
+	descriptions, err := describe(executable, runPlugin)
+	if err != nil {
+		t.Fatalf("describe() error = %v", err)
+	}
+	if len(descriptions) != 1 || descriptions[0].Identifier != "Corp/Proxy/Check" {
+		t.Fatalf("describe() = %+v", descriptions)
+	}
+
+	task := PluginTask{path: executable, description: descriptions[0], run: runPlugin}
+	result := task.Execute(tasks.Options{Options: map[string]string{"proxy": "squid"}}, nil)
+	if result.Status != tasks.Success || result.Summary != "proxy option was squid" {
+		t.Errorf("Execute() = %+v", result)
+	}
+
+	_, err = runPlugin(context.Background(), executable, []byte(`{"command":"bogus"}`))
+	if err == nil || !strings.Contains(err.Error(), "unknown command bogus") {
+		t.Errorf("runPlugin() error = %v, want the plugin's stderr", err)
+	}
+}
+
+func Test_registerDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits are not used on Windows")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "corp-checks"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "broken"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a plugin"), 0644)
+	os.Mkdir(filepath.Join(dir, "lib"), 0755)
+
+	var ran []string
+	run := func(ctx context.Context, path string, input []byte) ([]byte, error) {
+		ran = append(ran, filepath.Base(path))
+		if filepath.Base(path) == "broken" {
+			return []byte(`{"tasks":[{"identifier":"NoSlashes","explain":"x"}]}`), nil
+		}
+		return []byte(`{"tasks":[
+			{"identifier":"Corp/Deploy/Layout","explain":"Checks the deploy layout","runByDefault":true},
+			{"identifier":"Corp/Proxy/Check","explain":"Checks the corporate proxy"}
+		]}`), nil
+	}
+
+	registered := map[string]bool{}
+	errs := registerDir(dir, func(task tasks.Task, runByDefault bool) {
+		registered[task.Identifier().String()] = runByDefault
+	}, run)
+
+	if !reflect.DeepEqual(ran, []string{"broken", "corp-checks"}) {
+		t.Errorf("registerDir() ran %v, want only the executables", ran)
+	}
+	want := map[string]bool{"Corp/Deploy/Layout": true, "Corp/Proxy/Check": false}
+	if !reflect.DeepEqual(registered, want) {
+		t.Errorf("registerDir() registered %v, want %v", registered, want)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken") {
+		t.Errorf("registerDir() errors = %v, want one for the broken plugin", errs)
+	}
+}
+
+func Test_describe(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		err     error
+		wantErr string
+	}{
+		{name: "not json", output: "hello", wantErr: "invalid describe response"},
+		{name: "no tasks", output: `{"tasks":[]}`, wantErr: "does not describe any tasks"},
+		{name: "bad identifier", output: `{"tasks":[{"identifier":"Corp//Check","explain":"x"}]}`, wantErr: "Category/Subcategory/Name"},
+		{name: "missing explain", output: `{"tasks":[{"identifier":"Corp/Proxy/Check"}]}`, wantErr: "explain is required"},
+		{name: "plugin fails", err: errors.New("exit status 1"), wantErr: "exit status 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func(ctx context.Context, path string, input []byte) ([]byte, error) {
+				return []byte(tt.output), tt.err
+			}
+			_, err := describe("plugin", run)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("describe() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_PluginTask_Execute(t *testing.T) {
+	var request Request
+	task := PluginTask{
+		path: "/plugins/corp-checks",
+		description: TaskDescription{
+			Identifier:   "Corp/Proxy/Check",
+			Explain:      "Checks the corporate proxy",
+			Dependencies: []string{"Base/Config/ProxyDetect"},
+		},
+		run: func(ctx context.Context, path string, input []byte) ([]byte, error) {
+			if err := json.Unmarshal(input, &request); err != nil {
+				t.Fatalf("invalid request: %v", err)
+			}
+			return []byte(`{
+				"status": "warning",
+				"summary": "The corporate proxy is bypassed",
+				"url": "https://wiki.corp/proxy",
+				"filesToCopy": [{"path": "/etc/corp/proxy.conf"}, {"name": "report.txt", "content": "line one\nline two"}],
+				"payload": {"proxy": "squid.corp:3128"}
+			}`), nil
+		},
+	}
+
+	upstream := map[string]tasks.Result{
+		"Base/Config/ProxyDetect": {Status: tasks.Success, Summary: "found a proxy"},
+		"Base/Env/HostInfo":       {Status: tasks.Info, Summary: "not a dependency"},
+	}
+	result := task.Execute(tasks.Options{Options: map[string]string{"region": "eu"}}, upstream)
+
+	if request.Command != "execute" || request.Identifier != "Corp/Proxy/Check" || request.ProtocolVersion != ProtocolVersion {
+		t.Errorf("unexpected request %+v", request)
+	}
+	if request.Options["region"] != "eu" {
+		t.Errorf("request options = %v", request.Options)
+	}
+	if len(request.Upstream) != 1 || !strings.Contains(string(request.Upstream["Base/Config/ProxyDetect"]), `"Status":"Success"`) {
+		t.Errorf("request upstream = %s", request.Upstream)
+	}
+
+	if result.Status != tasks.Warning || result.Summary != "The corporate proxy is bypassed" || result.URL != "https://wiki.corp/proxy" {
+		t.Errorf("Execute() = %+v", result)
+	}
+	if payload, _ := json.Marshal(result.Payload); string(payload) != `{"proxy":"squid.corp:3128"}` {
+		t.Errorf("Execute() payload = %s", payload)
+	}
+	if len(result.FilesToCopy) != 2 || result.FilesToCopy[0].Path != "/etc/corp/proxy.conf" || result.FilesToCopy[1].Path != "report.txt" {
+		t.Fatalf("Execute() files = %+v", result.FilesToCopy)
+	}
+	var streamed strings.Builder
+	for line := range result.FilesToCopy[1].Stream {
+		io.WriteString(&streamed, line)
+	}
+	if streamed.String() != "line one\nline two\n" {
+		t.Errorf("streamed content = %q", streamed.String())
+	}
+}
+
+func Test_PluginTask_Execute_errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		err         error
+		wantSummary string
+	}{
+		{name: "plugin fails", err: errors.New("plugin timed out"), wantSummary: "Plugin corp-checks failed: plugin timed out"},
+		{name: "invalid json", output: "{", wantSummary: "Plugin corp-checks returned an invalid response"},
+		{name: "unknown status", output: `{"status":"great"}`, wantSummary: `Plugin corp-checks returned an unknown status "great"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := PluginTask{
+				path:        "/plugins/corp-checks",
+				description: TaskDescription{Identifier: "Corp/Proxy/Check", Explain: "x"},
+				run: func(ctx context.Context, path string, input []byte) ([]byte, error) {
+					return []byte(tt.output), tt.err
+				},
+			}
+			result := task.Execute(tasks.Options{}, nil)
+			if result.Status != tasks.Error || !strings.HasPrefix(result.Summary, tt.wantSummary) {
+				t.Errorf("Execute() = %+v, want an Error starting with %q", result, tt.wantSummary)
+			}
+		})
+	}
+}
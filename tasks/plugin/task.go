@@ -0,0 +1,127 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+var statuses = map[string]tasks.Status{
+	"none":    tasks.None,
+	"success": tasks.Success,
+	"warning": tasks.Warning,
+	"failure": tasks.Failure,
+	"error":   tasks.Error,
+	"info":    tasks.Info,
+}
+
+// PluginTask - a task provided by an external plugin executable
+type PluginTask struct {
+	path        string
+	description TaskDescription
+	run         runFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PluginTask) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString(p.description.Identifier)
+}
+
+// Explain - Returns the help text for each individual task
+func (p PluginTask) Explain() string {
+	return p.description.Explain + " (plugin: " + filepath.Base(p.path) + ")"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PluginTask) Dependencies() []string {
+	return p.description.Dependencies
+}
+
+// Execute - The core work within each task
+func (p PluginTask) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	request := Request{
+		Command:         "execute",
+		ProtocolVersion: ProtocolVersion,
+		Identifier:      p.description.Identifier,
+		Options:         options.Options,
+		Upstream:        map[string]json.RawMessage{},
+	}
+	for _, dependency := range p.description.Dependencies {
+		result, ok := upstream[dependency]
+		if !ok {
+			continue
+		}
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			// some payloads cannot be marshaled, the status and summary are still useful
+			log.Debug("Unable to marshal", dependency, "payload for plugin:", err)
+			result.Payload = nil
+			encoded, _ = json.Marshal(result)
+		}
+		request.Upstream[dependency] = encoded
+	}
+	input, err := json.Marshal(request)
+	if err != nil {
+		return pluginError("Unable to build the plugin request: " + err.Error())
+	}
+
+	timeout := defaultExecuteTimeout
+	if p.description.TimeoutSeconds > 0 {
+		timeout = time.Duration(p.description.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	output, err := p.run(ctx, p.path, input)
+	if err != nil {
+		return pluginError(fmt.Sprintf("Plugin %s failed: %s", filepath.Base(p.path), err.Error()))
+	}
+	return p.parseResponse(output)
+}
+
+func (p PluginTask) parseResponse(output []byte) tasks.Result {
+	var response Response
+	if err := json.Unmarshal(output, &response); err != nil {
+		return pluginError(fmt.Sprintf("Plugin %s returned an invalid response: %s", filepath.Base(p.path), err.Error()))
+	}
+	status, ok := statuses[strings.ToLower(response.Status)]
+	if !ok {
+		return pluginError(fmt.Sprintf("Plugin %s returned an unknown status %q", filepath.Base(p.path), response.Status))
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: response.Summary,
+		URL:     response.URL,
+	}
+	if len(response.Payload) > 0 && string(response.Payload) != "null" {
+		result.Payload = response.Payload
+	}
+
+	for _, file := range response.FilesToCopy {
+		switch {
+		case file.Path != "":
+			result.FilesToCopy = append(result.FilesToCopy, tasks.FileCopyEnvelope{Path: file.Path})
+		case file.Name != "":
+			stream := make(chan string)
+			go tasks.StreamBlob(file.Content, stream)
+			result.FilesToCopy = append(result.FilesToCopy, tasks.FileCopyEnvelope{Path: filepath.Base(file.Name), Stream: stream})
+		default:
+			log.Debug("Plugin", filepath.Base(p.path), "returned a file without a path or name")
+		}
+	}
+	return result
+}
+
+func pluginError(summary string) tasks.Result {
+	return tasks.Result{
+		Status:  tasks.Error,
+		Summary: summary,
+	}
+}
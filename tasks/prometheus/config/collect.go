@@ -0,0 +1,267 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	c "github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"gopkg.in/yaml.v3"
+)
+
+// PrometheusConfigCollect - finds and parses prometheus.yml and nri-prometheus configuration files
+type PrometheusConfigCollect struct {
+	fileFinder func([]string, []string) []string
+	fileReader func(string) ([]byte, error)
+}
+
+// Types of configuration files collected by this task
+const (
+	PrometheusServer = "prometheus"
+	NriPrometheus    = "nri-prometheus"
+)
+
+// PrometheusConfigFile - a parsed prometheus.yml or nri-prometheus configuration file
+type PrometheusConfigFile struct {
+	Path          string
+	Type          string
+	Server        PrometheusServerConfig
+	NriPrometheus NriPrometheusConfig
+}
+
+// PrometheusServerConfig - the parts of prometheus.yml relevant to sending data to New Relic
+type PrometheusServerConfig struct {
+	RemoteWrite   []RemoteWriteConfig `yaml:"remote_write"`
+	ScrapeConfigs []ScrapeConfig      `yaml:"scrape_configs"`
+}
+
+// RemoteWriteConfig - a remote_write entry of prometheus.yml
+type RemoteWriteConfig struct {
+	URL             string              `yaml:"url"`
+	Name            string              `yaml:"name"`
+	BearerToken     string              `yaml:"bearer_token"`
+	BearerTokenFile string              `yaml:"bearer_token_file"`
+	Authorization   AuthorizationConfig `yaml:"authorization"`
+}
+
+// AuthorizationConfig - the authorization block of a remote_write entry
+type AuthorizationConfig struct {
+	Type            string `yaml:"type"`
+	Credentials     string `yaml:"credentials"`
+	CredentialsFile string `yaml:"credentials_file"`
+}
+
+// ScrapeConfig - a scrape_configs entry of prometheus.yml
+type ScrapeConfig struct {
+	JobName       string         `yaml:"job_name"`
+	MetricsPath   string         `yaml:"metrics_path"`
+	Scheme        string         `yaml:"scheme"`
+	StaticConfigs []StaticConfig `yaml:"static_configs"`
+}
+
+// StaticConfig - a static_configs entry of a scrape config
+type StaticConfig struct {
+	Targets []string `yaml:"targets"`
+}
+
+// NriPrometheusConfig - the settings of the nri-prometheus integration
+type NriPrometheusConfig struct {
+	ClusterName        string                `yaml:"cluster_name"`
+	LicenseKey         string                `yaml:"license_key"`
+	Targets            []NriPrometheusTarget `yaml:"targets"`
+	ScrapeEnabledLabel string                `yaml:"scrape_enabled_label"`
+	Verbose            bool                  `yaml:"verbose"`
+	InsecureSkipVerify bool                  `yaml:"insecure_skip_verify"`
+}
+
+// NriPrometheusTarget - a static target of nri-prometheus
+type NriPrometheusTarget struct {
+	Description string   `yaml:"description"`
+	URLs        []string `yaml:"urls"`
+}
+
+// onHostIntegrationsConfig - integrations.d format used to run nri-prometheus under the infrastructure agent
+type onHostIntegrationsConfig struct {
+	Integrations []struct {
+		Name   string    `yaml:"name"`
+		Config yaml.Node `yaml:"config"`
+	} `yaml:"integrations"`
+}
+
+const redacted = "_REDACTED_"
+
+var errNotPrometheusConfig = errors.New("file is not a Prometheus or nri-prometheus configuration")
+
+// MarshalJSON - keeps the credentials used to authenticate against New Relic out of nrdiag-output.json
+func (r RemoteWriteConfig) MarshalJSON() ([]byte, error) {
+	type alias RemoteWriteConfig
+	redactedConfig := alias(r)
+	if redactedConfig.BearerToken != "" {
+		redactedConfig.BearerToken = redacted
+	}
+	if redactedConfig.Authorization.Credentials != "" {
+		redactedConfig.Authorization.Credentials = redacted
+	}
+	redactedConfig.URL = redactURL(r.URL)
+	return json.Marshal(redactedConfig)
+}
+
+// MarshalJSON - keeps the license key out of nrdiag-output.json
+func (n NriPrometheusConfig) MarshalJSON() ([]byte, error) {
+	type alias NriPrometheusConfig
+	redactedConfig := alias(n)
+	if redactedConfig.LicenseKey != "" {
+		redactedConfig.LicenseKey = redacted
+	}
+	return json.Marshal(redactedConfig)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PrometheusConfigCollect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Prometheus/Config/Collect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p PrometheusConfigCollect) Explain() string {
+	return "Collect Prometheus and nri-prometheus configuration files"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PrometheusConfigCollect) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (p PrometheusConfigCollect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	searchPaths := getSearchPaths(runtime.GOOS)
+	if customPath := options.Options["path"]; customPath != "" {
+		searchPaths = []string{customPath}
+	}
+	patterns := []string{`^prometheus\.ya?ml$`, `^nri-prometheus.*\.ya?ml$`}
+
+	foundFiles := p.fileFinder(patterns, searchPaths)
+	if len(foundFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Prometheus or nri-prometheus configuration files found.",
+		}
+	}
+
+	var configFiles []PrometheusConfigFile
+	var parseErrors []string
+	var filesToCopy []tasks.FileCopyEnvelope
+	for _, file := range foundFiles {
+		content, err := p.fileReader(file)
+		if err != nil {
+			parseErrors = append(parseErrors, file+": "+err.Error())
+			continue
+		}
+		configFile, err := parseConfigFile(file, content)
+		if err == errNotPrometheusConfig {
+			log.Debug(file, "is not a Prometheus configuration file")
+			continue
+		}
+		if err != nil {
+			parseErrors = append(parseErrors, file+": "+err.Error())
+			continue
+		}
+		configFiles = append(configFiles, configFile)
+
+		question := fmt.Sprintf("We've found a file that may contain secure information: %s\n", file) +
+			"Include this file in nrdiag-output.zip?"
+		if tasks.PromptUser(question, options) {
+			if !c.Flags.Quiet {
+				log.Info("Adding file to Diagnostics CLI zip file: ", file)
+			}
+			filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{Path: file, Identifier: p.Identifier().String()})
+		}
+	}
+
+	if len(parseErrors) > 0 {
+		return tasks.Result{
+			Status:      tasks.Failure,
+			Summary:     "Unable to parse the following Prometheus configuration files:\n" + strings.Join(parseErrors, "\n"),
+			URL:         "https://prometheus.io/docs/prometheus/latest/configuration/configuration/",
+			Payload:     configFiles,
+			FilesToCopy: filesToCopy,
+		}
+	}
+
+	if len(configFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Prometheus or nri-prometheus configuration files found.",
+		}
+	}
+
+	var paths []string
+	for _, configFile := range configFiles {
+		paths = append(paths, configFile.Path+" ("+configFile.Type+")")
+	}
+	return tasks.Result{
+		Status:      tasks.Success,
+		Summary:     "Found Prometheus configuration files:\n" + strings.Join(paths, "\n"),
+		Payload:     configFiles,
+		FilesToCopy: filesToCopy,
+	}
+}
+
+func getSearchPaths(goos string) []string {
+	if goos == "windows" {
+		return []string{
+			`C:\Program Files\New Relic\newrelic-infra\integrations.d\`,
+			`C:\Program Files\prometheus\`,
+		}
+	}
+	return []string{
+		"/etc/prometheus/",
+		"/etc/newrelic-infra/integrations.d/",
+		"/opt/prometheus/",
+	}
+}
+
+// parseConfigFile - tells prometheus.yml and nri-prometheus configs apart by their content, nri-prometheus can be
+// configured standalone or through the integrations.d format of the infrastructure agent
+func parseConfigFile(path string, content []byte) (PrometheusConfigFile, error) {
+	configFile := PrometheusConfigFile{Path: path}
+
+	var keys map[string]interface{}
+	if err := yaml.Unmarshal(content, &keys); err != nil {
+		return configFile, err
+	}
+
+	if _, ok := keys["integrations"]; ok {
+		var onHost onHostIntegrationsConfig
+		if err := yaml.Unmarshal(content, &onHost); err != nil {
+			return configFile, err
+		}
+		for _, integration := range onHost.Integrations {
+			if integration.Name != NriPrometheus {
+				continue
+			}
+			configFile.Type = NriPrometheus
+			return configFile, integration.Config.Decode(&configFile.NriPrometheus)
+		}
+		return configFile, errNotPrometheusConfig
+	}
+
+	_, hasRemoteWrite := keys["remote_write"]
+	_, hasScrapeConfigs := keys["scrape_configs"]
+	if hasRemoteWrite || hasScrapeConfigs {
+		configFile.Type = PrometheusServer
+		return configFile, yaml.Unmarshal(content, &configFile.Server)
+	}
+
+	_, hasTargets := keys["targets"]
+	_, hasClusterName := keys["cluster_name"]
+	if hasTargets || hasClusterName {
+		configFile.Type = NriPrometheus
+		return configFile, yaml.Unmarshal(content, &configFile.NriPrometheus)
+	}
+
+	return configFile, errNotPrometheusConfig
+}
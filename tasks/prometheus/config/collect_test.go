@@ -0,0 +1,81 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prometheus/Config/Collect", func() {
+	var p PrometheusConfigCollect
+	options := tasks.Options{Options: map[string]string{"YesToAll": "true"}}
+
+	Describe("Execute()", func() {
+		Context("When no configuration files are found", func() {
+			It("Should return a None result", func() {
+				p = PrometheusConfigCollect{
+					fileFinder: func([]string, []string) []string { return []string{} },
+					fileReader: os.ReadFile,
+				}
+				Expect(p.Execute(options, map[string]tasks.Result{}).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When prometheus.yml and an nri-prometheus config are found", func() {
+			It("Should return a Success result with both parsed files", func() {
+				p = PrometheusConfigCollect{
+					fileFinder: func([]string, []string) []string {
+						return []string{"fixtures/prometheus.yml", "fixtures/nri-prometheus-config.yml", "fixtures/alertmanager.yml"}
+					},
+					fileReader: os.ReadFile,
+				}
+				result := p.Execute(options, map[string]tasks.Result{})
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.FilesToCopy).To(HaveLen(2))
+
+				configFiles := result.Payload.([]PrometheusConfigFile)
+				Expect(configFiles).To(HaveLen(2))
+				Expect(configFiles[0].Type).To(Equal(PrometheusServer))
+				Expect(configFiles[0].Server.ScrapeConfigs[0].StaticConfigs[0].Targets).To(Equal([]string{"localhost:9100"}))
+				Expect(configFiles[1].Type).To(Equal(NriPrometheus))
+				Expect(configFiles[1].NriPrometheus.ClusterName).To(Equal("staging"))
+				Expect(configFiles[1].NriPrometheus.Targets[0].URLs).To(Equal([]string{"http://localhost:9100/metrics"}))
+			})
+		})
+
+		Context("When a configuration file cannot be read", func() {
+			It("Should return a Failure result", func() {
+				p = PrometheusConfigCollect{
+					fileFinder: func([]string, []string) []string { return []string{"/etc/prometheus/prometheus.yml"} },
+					fileReader: func(string) ([]byte, error) { return nil, errors.New("permission denied") },
+				}
+				result := p.Execute(options, map[string]tasks.Result{})
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("/etc/prometheus/prometheus.yml: permission denied"))
+			})
+		})
+	})
+
+	Describe("MarshalJSON()", func() {
+		It("Should redact remote_write credentials", func() {
+			remoteWrite := RemoteWriteConfig{
+				URL:           "https://metric-api.newrelic.com/prometheus/v1/write?X-License-Key=secret",
+				BearerToken:   "secret",
+				Authorization: AuthorizationConfig{Credentials: "secret"},
+			}
+			output, err := json.Marshal(remoteWrite)
+			Expect(err).To(BeNil())
+			Expect(string(output)).NotTo(ContainSubstring("secret"))
+		})
+
+		It("Should redact the nri-prometheus license key", func() {
+			output, err := json.Marshal(NriPrometheusConfig{LicenseKey: "secret"})
+			Expect(err).To(BeNil())
+			Expect(string(output)).NotTo(ContainSubstring("secret"))
+		})
+	})
+})
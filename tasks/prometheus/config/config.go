@@ -0,0 +1,22 @@
+package config
+
+import (
+	"os"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Prometheus/Config/*")
+
+	registrationFunc(PrometheusConfigCollect{
+		fileFinder: tasks.FindFiles,
+		fileReader: os.ReadFile,
+	}, true)
+	registrationFunc(PrometheusConfigRemoteWrite{
+		fileReader: os.ReadFile,
+	}, true)
+	registrationFunc(PrometheusConfigNriPrometheus{}, true)
+}
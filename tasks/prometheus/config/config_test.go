@@ -0,0 +1,24 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPrometheusConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Prometheus/Config/* test suite")
+}
+
+func TestRegisterWithCount(t *testing.T) {
+	registeredTasks := []tasks.Task{}
+	RegisterWith(func(task tasks.Task, runByDefault bool) {
+		registeredTasks = append(registeredTasks, task)
+	})
+	if len(registeredTasks) != 3 {
+		t.Errorf("RegisterWith() registered %d tasks, want 3", len(registeredTasks))
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// PrometheusConfigNriPrometheus - validates the settings of the nri-prometheus integration
+type PrometheusConfigNriPrometheus struct {
+}
+
+const nriPrometheusDocsURL = "https://docs.newrelic.com/docs/infrastructure/prometheus-integrations/install-configure-openmetrics/configure-prometheus-openmetrics-integrations/"
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PrometheusConfigNriPrometheus) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Prometheus/Config/NriPrometheus")
+}
+
+// Explain - Returns the help text for each individual task
+func (p PrometheusConfigNriPrometheus) Explain() string {
+	return "Validate nri-prometheus integration settings"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PrometheusConfigNriPrometheus) Dependencies() []string {
+	return []string{
+		"Prometheus/Config/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (p PrometheusConfigNriPrometheus) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	collected := upstream["Prometheus/Config/Collect"]
+	if collected.Status != tasks.Success && collected.Status != tasks.Failure {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Prometheus configuration files found. This task did not run.",
+		}
+	}
+	configFiles, ok := collected.Payload.([]PrometheusConfigFile)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var failures, warnings []string
+	var nriConfigs int
+	for _, configFile := range configFiles {
+		if configFile.Type != NriPrometheus {
+			continue
+		}
+		nriConfigs++
+		fileFailures, fileWarnings := validateNriPrometheus(configFile.NriPrometheus)
+		for _, failure := range fileFailures {
+			failures = append(failures, configFile.Path+": "+failure)
+		}
+		for _, warning := range fileWarnings {
+			warnings = append(warnings, configFile.Path+": "+warning)
+		}
+	}
+
+	if nriConfigs == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No nri-prometheus configuration files found.",
+		}
+	}
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Invalid nri-prometheus settings found:\n" + strings.Join(append(failures, warnings...), "\n"),
+			URL:     nriPrometheusDocsURL,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "Potential issues found in nri-prometheus settings:\n" + strings.Join(warnings, "\n"),
+			URL:     nriPrometheusDocsURL,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("Found %d valid nri-prometheus configuration files.", nriConfigs),
+	}
+}
+
+func validateNriPrometheus(config NriPrometheusConfig) (failures []string, warnings []string) {
+	if config.ClusterName == "" {
+		failures = append(failures, "cluster_name is required")
+	}
+	// license_key can be omitted when nri-prometheus runs under the infrastructure agent, which provides its own
+	if config.LicenseKey != "" && len(strings.TrimSpace(config.LicenseKey)) != 40 {
+		failures = append(failures, "license_key is not 40 characters long")
+	}
+
+	if len(config.Targets) == 0 && config.ScrapeEnabledLabel == "" {
+		warnings = append(warnings, "no targets configured, nri-prometheus will only scrape Kubernetes autodiscovered endpoints")
+	}
+	for _, target := range config.Targets {
+		if len(target.URLs) == 0 {
+			warnings = append(warnings, fmt.Sprintf("target %q has no urls", target.Description))
+		}
+		for _, targetURL := range target.URLs {
+			parsedURL, err := url.Parse(targetURL)
+			if err != nil || parsedURL.Host == "" {
+				failures = append(failures, fmt.Sprintf("target url %q is not a valid url, include the scheme as in http://localhost:9100/metrics", targetURL))
+			}
+		}
+	}
+	if config.InsecureSkipVerify {
+		warnings = append(warnings, "insecure_skip_verify is enabled, TLS certificates of targets are not validated")
+	}
+	return failures, warnings
+}
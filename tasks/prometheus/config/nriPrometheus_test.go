@@ -0,0 +1,65 @@
+package config
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prometheus/Config/NriPrometheus", func() {
+	var p PrometheusConfigNriPrometheus
+
+	upstreamFor := func(config NriPrometheusConfig) map[string]tasks.Result {
+		return map[string]tasks.Result{
+			"Prometheus/Config/Collect": {
+				Status:  tasks.Success,
+				Payload: []PrometheusConfigFile{{Path: "nri-prometheus-config.yml", Type: NriPrometheus, NriPrometheus: config}},
+			},
+		}
+	}
+
+	Describe("Execute()", func() {
+		Context("When only prometheus.yml was collected", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{
+					"Prometheus/Config/Collect": {Status: tasks.Success, Payload: []PrometheusConfigFile{{Type: PrometheusServer}}},
+				}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the configuration is valid", func() {
+			It("Should return a Success result", func() {
+				upstream := upstreamFor(NriPrometheusConfig{
+					ClusterName: "staging",
+					Targets:     []NriPrometheusTarget{{Description: "node", URLs: []string{"http://localhost:9100/metrics"}}},
+				})
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.Success))
+			})
+		})
+
+		Context("When cluster_name is missing and a target has no scheme", func() {
+			It("Should return a Failure result", func() {
+				upstream := upstreamFor(NriPrometheusConfig{
+					Targets: []NriPrometheusTarget{{Description: "node", URLs: []string{"localhost:9100"}}},
+				})
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("nri-prometheus-config.yml: cluster_name is required"))
+				Expect(result.Summary).To(ContainSubstring(`target url "localhost:9100" is not a valid url`))
+				Expect(result.URL).To(Equal(nriPrometheusDocsURL))
+			})
+		})
+
+		Context("When certificate validation is disabled", func() {
+			It("Should return a Warning result", func() {
+				upstream := upstreamFor(NriPrometheusConfig{
+					ClusterName:        "staging",
+					InsecureSkipVerify: true,
+					Targets:            []NriPrometheusTarget{{Description: "node", URLs: []string{"https://localhost:9100/metrics"}}},
+				})
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.Warning))
+			})
+		})
+	})
+})
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// PrometheusConfigRemoteWrite - validates the remote_write entries that send Prometheus data to New Relic
+type PrometheusConfigRemoteWrite struct {
+	fileReader func(string) ([]byte, error)
+}
+
+const (
+	remoteWritePath    = "/prometheus/v1/write"
+	remoteWriteDocsURL = "https://docs.newrelic.com/docs/infrastructure/prometheus-integrations/install-configure-remote-write/set-your-prometheus-remote-write-integration/"
+)
+
+// remoteWriteHosts - the metric API endpoint accepting remote_write data for each region
+var remoteWriteHosts = map[string]string{
+	"metric-api.newrelic.com":     "us",
+	"metric-api.eu.newrelic.com":  "eu",
+	"gov-metric-api.newrelic.com": "gov",
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PrometheusConfigRemoteWrite) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Prometheus/Config/RemoteWrite")
+}
+
+// Explain - Returns the help text for each individual task
+func (p PrometheusConfigRemoteWrite) Explain() string {
+	return "Validate Prometheus remote_write settings for sending data to New Relic"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PrometheusConfigRemoteWrite) Dependencies() []string {
+	return []string{
+		"Prometheus/Config/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (p PrometheusConfigRemoteWrite) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	collected := upstream["Prometheus/Config/Collect"]
+	if collected.Status != tasks.Success && collected.Status != tasks.Failure {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Prometheus configuration files found. This task did not run.",
+		}
+	}
+	configFiles, ok := collected.Payload.([]PrometheusConfigFile)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var failures, warnings []string
+	var newRelicWrites int
+	for _, configFile := range configFiles {
+		if configFile.Type != PrometheusServer {
+			continue
+		}
+		for _, remoteWrite := range configFile.Server.RemoteWrite {
+			if !isNewRelicEndpoint(remoteWrite.URL) {
+				continue
+			}
+			newRelicWrites++
+			fileFailures, fileWarnings := p.validateRemoteWrite(remoteWrite)
+			for _, failure := range fileFailures {
+				failures = append(failures, configFile.Path+": "+failure)
+			}
+			for _, warning := range fileWarnings {
+				warnings = append(warnings, configFile.Path+": "+warning)
+			}
+		}
+	}
+
+	if newRelicWrites == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No remote_write entries pointing to New Relic were found.",
+		}
+	}
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Invalid remote_write settings found:\n" + strings.Join(append(failures, warnings...), "\n"),
+			URL:     remoteWriteDocsURL,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "Potential issues found in remote_write settings:\n" + strings.Join(warnings, "\n"),
+			URL:     remoteWriteDocsURL,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("Found %d valid remote_write entries sending data to New Relic.", newRelicWrites),
+	}
+}
+
+// validateRemoteWrite - checks endpoint, credential and region of a remote_write entry pointing to New Relic
+func (p PrometheusConfigRemoteWrite) validateRemoteWrite(remoteWrite RemoteWriteConfig) (failures []string, warnings []string) {
+	parsedURL, err := url.Parse(remoteWrite.URL)
+	if err != nil {
+		return []string{"unable to parse remote_write url " + redactURL(remoteWrite.URL)}, nil
+	}
+	host := strings.ToLower(parsedURL.Hostname())
+	endpointRegion, ok := remoteWriteHosts[host]
+	if !ok {
+		failures = append(failures, host+" is not a New Relic remote_write endpoint, expected one of metric-api.newrelic.com, metric-api.eu.newrelic.com or gov-metric-api.newrelic.com")
+	}
+	if parsedURL.Scheme != "https" {
+		failures = append(failures, "remote_write url must use https")
+	}
+	if strings.TrimSuffix(parsedURL.Path, "/") != remoteWritePath {
+		failures = append(failures, fmt.Sprintf("remote_write url path is %q, expected %s", parsedURL.Path, remoteWritePath))
+	}
+	if parsedURL.Query().Get("prometheus_server") == "" {
+		warnings = append(warnings, "remote_write url has no prometheus_server parameter, data from multiple servers cannot be told apart")
+	}
+
+	credential, err := p.getCredential(remoteWrite, parsedURL)
+	if err != nil {
+		return append(failures, err.Error()), warnings
+	}
+	if credential == "" {
+		return append(failures, "no license key configured, set authorization.credentials, bearer_token or the X-License-Key parameter"), warnings
+	}
+
+	if ok && endpointRegion != "gov" {
+		keyRegion := "us"
+		if strings.HasPrefix(baseConfig.RegionFromLicenseKey(credential), "eu") {
+			keyRegion = "eu"
+		}
+		if keyRegion != endpointRegion {
+			failures = append(failures, fmt.Sprintf("license key belongs to the %s region but data is sent to %s", strings.ToUpper(keyRegion), host))
+		}
+	}
+	return failures, warnings
+}
+
+// getCredential - returns the license key used by a remote_write entry, in the precedence order of Prometheus
+func (p PrometheusConfigRemoteWrite) getCredential(remoteWrite RemoteWriteConfig, parsedURL *url.URL) (string, error) {
+	credentialFile := remoteWrite.Authorization.CredentialsFile
+	switch {
+	case remoteWrite.Authorization.Credentials != "":
+		return remoteWrite.Authorization.Credentials, nil
+	case remoteWrite.BearerToken != "":
+		return remoteWrite.BearerToken, nil
+	case remoteWrite.BearerTokenFile != "":
+		credentialFile = remoteWrite.BearerTokenFile
+	case credentialFile == "":
+		return parsedURL.Query().Get("X-License-Key"), nil
+	}
+
+	content, err := p.fileReader(credentialFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read credentials file %s: %s", credentialFile, err.Error())
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func isNewRelicEndpoint(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return strings.Contains(rawURL, "newrelic.com")
+	}
+	return strings.HasSuffix(strings.ToLower(parsedURL.Hostname()), "newrelic.com")
+}
+
+// redactURL - hides the X-License-Key parameter of remote_write urls
+func redactURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := parsedURL.Query()
+	if query.Get("X-License-Key") == "" {
+		return rawURL
+	}
+	query.Set("X-License-Key", redacted)
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String()
+}
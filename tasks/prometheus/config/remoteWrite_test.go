@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prometheus/Config/RemoteWrite", func() {
+	var p PrometheusConfigRemoteWrite
+
+	usKey := "0123456789abcdef0123456789abcdef0123NRAL"
+	euKey := "eu01xx6789abcdef0123456789abcdef0123NRAL"
+
+	upstreamFor := func(remoteWrites ...RemoteWriteConfig) map[string]tasks.Result {
+		return map[string]tasks.Result{
+			"Prometheus/Config/Collect": {
+				Status: tasks.Success,
+				Payload: []PrometheusConfigFile{{
+					Path:   "/etc/prometheus/prometheus.yml",
+					Type:   PrometheusServer,
+					Server: PrometheusServerConfig{RemoteWrite: remoteWrites},
+				}},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		p = PrometheusConfigRemoteWrite{
+			fileReader: func(path string) ([]byte, error) {
+				if path == "/etc/prometheus/nr-key" {
+					return []byte(euKey + "\n"), nil
+				}
+				return nil, errors.New("no such file or directory")
+			},
+		}
+	})
+
+	Describe("Execute()", func() {
+		Context("When no configuration files were collected", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{"Prometheus/Config/Collect": {Status: tasks.None}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the upstream payload has an unexpected type", func() {
+			It("Should return an Error result", func() {
+				upstream := map[string]tasks.Result{"Prometheus/Config/Collect": {Status: tasks.Success, Payload: "prometheus.yml"}}
+				Expect(p.Execute(tasks.Options{}, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Error,
+					Summary: tasks.AssertionErrorSummary,
+				}))
+			})
+		})
+
+		Context("When remote_write only targets other backends", func() {
+			It("Should return a None result", func() {
+				upstream := upstreamFor(RemoteWriteConfig{URL: "https://cortex.example.com/api/v1/push"})
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When remote_write is correctly configured", func() {
+			It("Should return a Success result", func() {
+				upstream := upstreamFor(RemoteWriteConfig{
+					URL:           "https://metric-api.newrelic.com/prometheus/v1/write?prometheus_server=prod",
+					Authorization: AuthorizationConfig{Credentials: usKey},
+				})
+				Expect(p.Execute(tasks.Options{}, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Success,
+					Summary: "Found 1 valid remote_write entries sending data to New Relic.",
+				}))
+			})
+		})
+
+		Context("When the prometheus_server parameter is missing", func() {
+			It("Should return a Warning result", func() {
+				upstream := upstreamFor(RemoteWriteConfig{
+					URL:         "https://metric-api.newrelic.com/prometheus/v1/write",
+					BearerToken: usKey,
+				})
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.URL).To(Equal(remoteWriteDocsURL))
+			})
+		})
+
+		Context("When the license key region does not match the endpoint", func() {
+			It("Should return a Failure result", func() {
+				upstream := upstreamFor(RemoteWriteConfig{
+					URL:             "https://metric-api.newrelic.com/prometheus/v1/write?prometheus_server=prod",
+					BearerTokenFile: "/etc/prometheus/nr-key",
+				})
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("license key belongs to the EU region but data is sent to metric-api.newrelic.com"))
+			})
+		})
+	})
+
+	Describe("validateRemoteWrite()", func() {
+		It("Should accept the license key as a query parameter", func() {
+			failures, warnings := p.validateRemoteWrite(RemoteWriteConfig{
+				URL: "https://metric-api.eu.newrelic.com/prometheus/v1/write?prometheus_server=prod&X-License-Key=" + euKey,
+			})
+			Expect(failures).To(BeEmpty())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should flag a wrong host, path and missing credential", func() {
+			failures, _ := p.validateRemoteWrite(RemoteWriteConfig{
+				URL: "http://metrics.newrelic.com/api/v1/write?prometheus_server=prod",
+			})
+			Expect(failures).To(Equal([]string{
+				"metrics.newrelic.com is not a New Relic remote_write endpoint, expected one of metric-api.newrelic.com, metric-api.eu.newrelic.com or gov-metric-api.newrelic.com",
+				"remote_write url must use https",
+				`remote_write url path is "/api/v1/write", expected /prometheus/v1/write`,
+				"no license key configured, set authorization.credentials, bearer_token or the X-License-Key parameter",
+			}))
+		})
+
+		It("Should flag an unreadable credentials file", func() {
+			failures, _ := p.validateRemoteWrite(RemoteWriteConfig{
+				URL:           "https://metric-api.newrelic.com/prometheus/v1/write?prometheus_server=prod",
+				Authorization: AuthorizationConfig{CredentialsFile: "/missing"},
+			})
+			Expect(failures).To(Equal([]string{"unable to read credentials file /missing: no such file or directory"}))
+		})
+	})
+})
@@ -0,0 +1,243 @@
+package targets
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// maxParseErrors - stop recording syntax errors after this many, a broken exporter usually repeats the same mistake
+const maxParseErrors = 10
+
+var (
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	metricTypes     = []string{"counter", "gauge", "histogram", "summary", "untyped", "unknown", "gaugehistogram", "stateset", "info"}
+)
+
+// Sample - a single line of the Prometheus text exposition format
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Exposition - summary of a scraped Prometheus text exposition
+type Exposition struct {
+	Samples          int
+	SeriesPerMetric  map[string]int
+	MaxLabels        int
+	MaxLabelsMetric  string
+	LongLabelNames   []string
+	LongLabelValues  []string
+	ParseErrors      []string
+	TruncatedErrors  bool
+	seriesSignatures map[string]bool
+}
+
+// parseExposition - reads the Prometheus text exposition format and counts series and labels per metric
+func parseExposition(reader io.Reader) (Exposition, error) {
+	exposition := Exposition{
+		SeriesPerMetric:  map[string]int{},
+		seriesSignatures: map[string]bool{},
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var err error
+		if strings.HasPrefix(line, "#") {
+			err = parseComment(line)
+		} else {
+			var sample Sample
+			sample, err = parseSample(line)
+			if err == nil {
+				exposition.addSample(sample)
+			}
+		}
+		if err != nil {
+			exposition.addParseError(fmt.Sprintf("line %d: %s", lineNumber, err.Error()))
+		}
+	}
+	return exposition, scanner.Err()
+}
+
+func (e *Exposition) addParseError(parseError string) {
+	if len(e.ParseErrors) >= maxParseErrors {
+		e.TruncatedErrors = true
+		return
+	}
+	e.ParseErrors = append(e.ParseErrors, parseError)
+}
+
+func (e *Exposition) addSample(sample Sample) {
+	e.Samples++
+
+	labelNames := make([]string, 0, len(sample.Labels))
+	for name, value := range sample.Labels {
+		labelNames = append(labelNames, name)
+		if len(name) > maxAttributeNameLength && !tasks.ContainsString(e.LongLabelNames, name) {
+			e.LongLabelNames = append(e.LongLabelNames, name)
+		}
+		if len(value) > maxAttributeValueLength && !tasks.ContainsString(e.LongLabelValues, sample.Name+"."+name) {
+			e.LongLabelValues = append(e.LongLabelValues, sample.Name+"."+name)
+		}
+	}
+	sort.Strings(labelNames)
+
+	signature := sample.Name
+	for _, name := range labelNames {
+		signature += "\xff" + name + "\xff" + sample.Labels[name]
+	}
+	if !e.seriesSignatures[signature] {
+		e.seriesSignatures[signature] = true
+		e.SeriesPerMetric[sample.Name]++
+	}
+
+	if len(sample.Labels) > e.MaxLabels {
+		e.MaxLabels = len(sample.Labels)
+		e.MaxLabelsMetric = sample.Name
+	}
+}
+
+// parseComment - validates HELP and TYPE lines, any other comment is ignored
+func parseComment(line string) error {
+	fields := strings.Fields(strings.TrimPrefix(line, "#"))
+	if len(fields) == 0 || (fields[0] != "HELP" && fields[0] != "TYPE") {
+		return nil
+	}
+	if len(fields) < 2 {
+		return fmt.Errorf("%s line without a metric name", fields[0])
+	}
+	if !metricNameRegex.MatchString(fields[1]) {
+		return fmt.Errorf("invalid metric name %q in %s line", fields[1], fields[0])
+	}
+	if fields[0] == "TYPE" {
+		if len(fields) != 3 {
+			return fmt.Errorf("TYPE line for %s must have exactly one type", fields[1])
+		}
+		if !tasks.ContainsString(metricTypes, strings.ToLower(fields[2])) {
+			return fmt.Errorf("unknown metric type %q for %s", fields[2], fields[1])
+		}
+	}
+	return nil
+}
+
+// parseSample - parses metric_name{label="value",...} value [timestamp]
+func parseSample(line string) (Sample, error) {
+	sample := Sample{Labels: map[string]string{}}
+
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd == -1 {
+		return sample, errors.New("sample without a value")
+	}
+	sample.Name = line[:nameEnd]
+	if !metricNameRegex.MatchString(sample.Name) {
+		return sample, fmt.Errorf("invalid metric name %q", sample.Name)
+	}
+
+	rest := line[nameEnd:]
+	if strings.HasPrefix(rest, "{") {
+		labels, remaining, err := parseLabels(rest[1:])
+		if err != nil {
+			return sample, fmt.Errorf("%s: %s", sample.Name, err.Error())
+		}
+		sample.Labels = labels
+		rest = remaining
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample, fmt.Errorf("%s: expected a value and an optional timestamp", sample.Name)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("%s: invalid value %q", sample.Name, fields[0])
+	}
+	sample.Value = value
+	if len(fields) == 2 {
+		if _, err := strconv.ParseFloat(fields[1], 64); err != nil {
+			return sample, fmt.Errorf("%s: invalid timestamp %q", sample.Name, fields[1])
+		}
+	}
+	return sample, nil
+}
+
+// parseLabels - parses the label set following the opening brace and returns what follows the closing brace
+func parseLabels(input string) (map[string]string, string, error) {
+	labels := map[string]string{}
+	position := 0
+	for {
+		for position < len(input) && (input[position] == ' ' || input[position] == ',') {
+			position++
+		}
+		if position >= len(input) {
+			return labels, "", errors.New("label set is not closed")
+		}
+		if input[position] == '}' {
+			return labels, input[position+1:], nil
+		}
+
+		equals := strings.IndexByte(input[position:], '=')
+		if equals == -1 {
+			return labels, "", errors.New("label without a value")
+		}
+		name := strings.TrimSpace(input[position : position+equals])
+		if !labelNameRegex.MatchString(name) {
+			return labels, "", fmt.Errorf("invalid label name %q", name)
+		}
+		if _, ok := labels[name]; ok {
+			return labels, "", fmt.Errorf("duplicate label %q", name)
+		}
+		position += equals + 1
+		if position >= len(input) || input[position] != '"' {
+			return labels, "", fmt.Errorf("value of label %q is not quoted", name)
+		}
+		position++
+
+		var value strings.Builder
+		closed := false
+		for position < len(input) {
+			char := input[position]
+			position++
+			if char == '"' {
+				closed = true
+				break
+			}
+			if char != '\\' {
+				value.WriteByte(char)
+				continue
+			}
+			if position >= len(input) {
+				break
+			}
+			switch input[position] {
+			case 'n':
+				value.WriteByte('\n')
+			case '\\', '"':
+				value.WriteByte(input[position])
+			default:
+				return labels, "", fmt.Errorf("invalid escape sequence in value of label %q", name)
+			}
+			position++
+		}
+		if !closed {
+			return labels, "", fmt.Errorf("value of label %q is not terminated", name)
+		}
+		labels[name] = value.String()
+	}
+}
@@ -0,0 +1,69 @@
+package targets
+
+import (
+	"math"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseExposition()", func() {
+	It("Should count series per metric and the largest label set", func() {
+		input := `# HELP http_requests_total Total requests.
+# TYPE http_requests_total counter
+http_requests_total{method="get",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="200"} 3
+http_requests_total{code="200",method="post"} 3
+# TYPE queue_depth gauge
+queue_depth 12
+# EOF
+`
+		exposition, err := parseExposition(strings.NewReader(input))
+		Expect(err).To(BeNil())
+		Expect(exposition.ParseErrors).To(BeEmpty())
+		Expect(exposition.Samples).To(Equal(4))
+		Expect(exposition.SeriesPerMetric).To(Equal(map[string]int{"http_requests_total": 2, "queue_depth": 1}))
+		Expect(exposition.MaxLabels).To(Equal(2))
+		Expect(exposition.MaxLabelsMetric).To(Equal("http_requests_total"))
+	})
+
+	It("Should report syntax errors with their line number", func() {
+		input := `# TYPE latency histogram
+latency_bucket{le="0.5"} 10
+latency_bucket{le="+Inf} 12
+2xx_responses 4
+# TYPE latency_count timer
+latency_count twelve
+`
+		exposition, err := parseExposition(strings.NewReader(input))
+		Expect(err).To(BeNil())
+		Expect(exposition.ParseErrors).To(Equal([]string{
+			`line 3: latency_bucket: value of label "le" is not terminated`,
+			`line 4: invalid metric name "2xx_responses"`,
+			`line 5: unknown metric type "timer" for latency_count`,
+			`line 6: latency_count: invalid value "twelve"`,
+		}))
+	})
+})
+
+var _ = Describe("parseSample()", func() {
+	It("Should parse escaped label values and special float values", func() {
+		sample, err := parseSample(`msg_total{path="C:\\temp",text="say \"hi\"\n"} -Inf`)
+		Expect(err).To(BeNil())
+		Expect(sample.Labels).To(Equal(map[string]string{"path": `C:\temp`, "text": "say \"hi\"\n"}))
+		Expect(math.IsInf(sample.Value, -1)).To(BeTrue())
+
+		sample, err = parseSample(`ratio{} NaN`)
+		Expect(err).To(BeNil())
+		Expect(math.IsNaN(sample.Value)).To(BeTrue())
+	})
+
+	It("Should reject duplicate and unquoted labels", func() {
+		_, err := parseSample(`up{job="a",job="b"} 1`)
+		Expect(err).To(MatchError(`up: duplicate label "job"`))
+
+		_, err = parseSample(`up{job=a} 1`)
+		Expect(err).To(MatchError(`up: value of label "job" is not quoted`))
+	})
+})
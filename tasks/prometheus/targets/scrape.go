@@ -0,0 +1,241 @@
+package targets
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	promConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/prometheus/config"
+)
+
+// PrometheusTargetsScrape - scrapes the configured targets and checks their output against New Relic limits
+type PrometheusTargetsScrape struct {
+	httpGetter tasks.HTTPRequestFunc
+}
+
+// New Relic Metric API limits applied to Prometheus data
+// https://docs.newrelic.com/docs/data-apis/ingest-apis/metric-api/metric-api-limits-restricted-attributes/
+const (
+	maxAttributesPerDataPoint = 100
+	maxAttributeNameLength    = 255
+	maxAttributeValueLength   = 4096
+	maxSeriesPerMetricName    = 100000
+)
+
+const (
+	maxScrapeTargets = 20
+	maxScrapeBytes   = 50 * 1024 * 1024
+	scrapeDocsURL    = "https://docs.newrelic.com/docs/infrastructure/prometheus-integrations/troubleshooting/"
+)
+
+// ScrapeResult - outcome of scraping a single target
+type ScrapeResult struct {
+	URL        string
+	Source     string
+	StatusCode int
+	Error      string
+	Exposition Exposition
+	Problems   []string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PrometheusTargetsScrape) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Prometheus/Targets/Scrape")
+}
+
+// Explain - Returns the help text for each individual task
+func (p PrometheusTargetsScrape) Explain() string {
+	return "Check Prometheus scrape targets are reachable and their metrics are within New Relic limits"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PrometheusTargetsScrape) Dependencies() []string {
+	return []string{
+		"Prometheus/Config/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (p PrometheusTargetsScrape) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	collected := upstream["Prometheus/Config/Collect"]
+	if collected.Status != tasks.Success && collected.Status != tasks.Failure {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Prometheus configuration files found. This task did not run.",
+		}
+	}
+	configFiles, ok := collected.Payload.([]promConfig.PrometheusConfigFile)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	targets, sources := getTargetURLs(configFiles)
+	if len(targets) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No static scrape targets found in the Prometheus configuration.",
+		}
+	}
+	var skipped int
+	if len(targets) > maxScrapeTargets {
+		skipped = len(targets) - maxScrapeTargets
+		targets = targets[:maxScrapeTargets]
+	}
+
+	var results []ScrapeResult
+	var failures, warnings []string
+	for _, target := range targets {
+		result := p.scrapeTarget(target)
+		result.Source = sources[target]
+		results = append(results, result)
+
+		if result.Error != "" {
+			failures = append(failures, target+": "+result.Error)
+			continue
+		}
+		if len(result.Exposition.ParseErrors) > 0 {
+			failures = append(failures, target+": invalid exposition format\n\t"+strings.Join(result.Exposition.ParseErrors, "\n\t"))
+		}
+		for _, problem := range result.Problems {
+			warnings = append(warnings, target+": "+problem)
+		}
+	}
+
+	summary := fmt.Sprintf("Scraped %d Prometheus targets.", len(targets))
+	if skipped > 0 {
+		summary += fmt.Sprintf(" %d more targets were not scraped.", skipped)
+	}
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: summary + " Issues found:\n" + strings.Join(append(failures, warnings...), "\n"),
+			URL:     scrapeDocsURL,
+			Payload: results,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: summary + " Metrics exceeding New Relic limits:\n" + strings.Join(warnings, "\n"),
+			URL:     scrapeDocsURL,
+			Payload: results,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: summary + " All targets are reachable and within New Relic limits.",
+		Payload: results,
+	}
+}
+
+// getTargetURLs - builds the scrape urls from prometheus.yml static_configs and nri-prometheus targets, mapped to the file defining them
+func getTargetURLs(configFiles []promConfig.PrometheusConfigFile) ([]string, map[string]string) {
+	var targets []string
+	sources := map[string]string{}
+	addTarget := func(target string, source string) {
+		if _, ok := sources[target]; ok {
+			return
+		}
+		sources[target] = source
+		targets = append(targets, target)
+	}
+
+	for _, configFile := range configFiles {
+		switch configFile.Type {
+		case promConfig.PrometheusServer:
+			for _, scrapeConfig := range configFile.Server.ScrapeConfigs {
+				scheme := scrapeConfig.Scheme
+				if scheme == "" {
+					scheme = "http"
+				}
+				metricsPath := scrapeConfig.MetricsPath
+				if metricsPath == "" {
+					metricsPath = "/metrics"
+				}
+				for _, staticConfig := range scrapeConfig.StaticConfigs {
+					for _, target := range staticConfig.Targets {
+						addTarget(scheme+"://"+target+metricsPath, configFile.Path)
+					}
+				}
+			}
+		case promConfig.NriPrometheus:
+			for _, target := range configFile.NriPrometheus.Targets {
+				for _, targetURL := range target.URLs {
+					if parsedURL, err := url.Parse(targetURL); err != nil || parsedURL.Host == "" {
+						continue
+					}
+					addTarget(targetURL, configFile.Path)
+				}
+			}
+		}
+	}
+	return targets, sources
+}
+
+func (p PrometheusTargetsScrape) scrapeTarget(target string) ScrapeResult {
+	result := ScrapeResult{URL: target}
+	wrapper := httpHelper.RequestWrapper{
+		Method:         "GET",
+		URL:            target,
+		Headers:        map[string]string{"Accept": "text/plain;version=0.0.4;q=1,*/*;q=0.1"},
+		TimeoutSeconds: 10,
+		BypassProxy:    true,
+	}
+	resp, err := p.httpGetter(wrapper)
+	if err != nil {
+		log.Debug("Error scraping", target, err)
+		result.Error = "unable to scrape target: " + err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode != 200 {
+		result.Error = fmt.Sprintf("target returned status code %d", resp.StatusCode)
+		return result
+	}
+
+	exposition, err := parseExposition(io.LimitReader(resp.Body, maxScrapeBytes))
+	if err != nil {
+		result.Error = "unable to read target response: " + err.Error()
+		return result
+	}
+	result.Exposition = exposition
+	result.Problems = checkLimits(exposition)
+	return result
+}
+
+// checkLimits - compares a scraped exposition against the New Relic Metric API limits
+func checkLimits(exposition Exposition) []string {
+	var problems []string
+	if exposition.MaxLabels > maxAttributesPerDataPoint {
+		problems = append(problems, fmt.Sprintf("%s has %d labels, New Relic accepts at most %d attributes per data point", exposition.MaxLabelsMetric, exposition.MaxLabels, maxAttributesPerDataPoint))
+	}
+
+	var metricNames []string
+	for metricName := range exposition.SeriesPerMetric {
+		metricNames = append(metricNames, metricName)
+	}
+	sort.Strings(metricNames)
+	for _, metricName := range metricNames {
+		if series := exposition.SeriesPerMetric[metricName]; series > maxSeriesPerMetricName {
+			problems = append(problems, fmt.Sprintf("%s has %d series, New Relic accepts at most %d unique series per metric name", metricName, series, maxSeriesPerMetricName))
+		}
+	}
+
+	for _, labelName := range exposition.LongLabelNames {
+		problems = append(problems, fmt.Sprintf("label name %s is longer than %d characters", labelName, maxAttributeNameLength))
+	}
+	for _, labelValue := range exposition.LongLabelValues {
+		problems = append(problems, fmt.Sprintf("value of label %s is longer than %d characters", labelValue, maxAttributeValueLength))
+	}
+	return problems
+}
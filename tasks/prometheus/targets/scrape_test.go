@@ -0,0 +1,134 @@
+package targets
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	promConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/prometheus/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prometheus/Targets/Scrape", func() {
+	var p PrometheusTargetsScrape
+	var exporter *httptest.Server
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "# TYPE up gauge\nup{instance=\"exporter\"} 1\n")
+		})
+		mux.HandleFunc("/wide", func(w http.ResponseWriter, r *http.Request) {
+			var labels []string
+			for i := 0; i <= maxAttributesPerDataPoint; i++ {
+				labels = append(labels, fmt.Sprintf("label_%d=\"%d\"", i, i))
+			}
+			fmt.Fprintf(w, "wide_metric{%s} 1\n", strings.Join(labels, ","))
+		})
+		mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "up{instance=exporter} 1\n")
+		})
+		exporter = httptest.NewServer(mux)
+		p = PrometheusTargetsScrape{httpGetter: tasks.HTTPRequester}
+	})
+
+	AfterEach(func() {
+		exporter.Close()
+	})
+
+	upstreamFor := func(urls ...string) map[string]tasks.Result {
+		return map[string]tasks.Result{
+			"Prometheus/Config/Collect": {
+				Status: tasks.Success,
+				Payload: []promConfig.PrometheusConfigFile{{
+					Path:          "nri-prometheus-config.yml",
+					Type:          promConfig.NriPrometheus,
+					NriPrometheus: promConfig.NriPrometheusConfig{Targets: []promConfig.NriPrometheusTarget{{URLs: urls}}},
+				}},
+			},
+		}
+	}
+
+	Describe("Execute()", func() {
+		Context("When no configuration files were collected", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{"Prometheus/Config/Collect": {Status: tasks.None}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the upstream payload has an unexpected type", func() {
+			It("Should return an Error result", func() {
+				upstream := map[string]tasks.Result{"Prometheus/Config/Collect": {Status: tasks.Success, Payload: "prometheus.yml"}}
+				Expect(p.Execute(tasks.Options{}, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Error,
+					Summary: tasks.AssertionErrorSummary,
+				}))
+			})
+		})
+
+		Context("When the exporter serves valid metrics", func() {
+			It("Should return a Success result", func() {
+				result := p.Execute(tasks.Options{}, upstreamFor(exporter.URL+"/metrics"))
+				Expect(result.Status).To(Equal(tasks.Success))
+				scrapes := result.Payload.([]ScrapeResult)
+				Expect(scrapes[0].StatusCode).To(Equal(200))
+				Expect(scrapes[0].Source).To(Equal("nri-prometheus-config.yml"))
+				Expect(scrapes[0].Exposition.SeriesPerMetric).To(Equal(map[string]int{"up": 1}))
+			})
+		})
+
+		Context("When a metric has more labels than New Relic accepts", func() {
+			It("Should return a Warning result", func() {
+				result := p.Execute(tasks.Options{}, upstreamFor(exporter.URL+"/wide"))
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("wide_metric has 101 labels, New Relic accepts at most 100 attributes per data point"))
+				Expect(result.URL).To(Equal(scrapeDocsURL))
+			})
+		})
+
+		Context("When a target is missing or serves invalid metrics", func() {
+			It("Should return a Failure result", func() {
+				result := p.Execute(tasks.Options{}, upstreamFor(exporter.URL+"/broken", exporter.URL+"/missing"))
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring(`/broken: invalid exposition format`))
+				Expect(result.Summary).To(ContainSubstring("/missing: target returned status code 404"))
+			})
+		})
+
+		Context("When a target cannot be reached", func() {
+			It("Should return a Failure result", func() {
+				p.httpGetter = func(httpHelper.RequestWrapper) (*http.Response, error) {
+					return nil, errors.New("connection refused")
+				}
+				result := p.Execute(tasks.Options{}, upstreamFor("http://localhost:9100/metrics"))
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("http://localhost:9100/metrics: unable to scrape target: connection refused"))
+			})
+		})
+	})
+
+	Describe("getTargetURLs()", func() {
+		It("Should apply the default scheme and metrics path to static targets", func() {
+			configFiles := []promConfig.PrometheusConfigFile{{
+				Path: "prometheus.yml",
+				Type: promConfig.PrometheusServer,
+				Server: promConfig.PrometheusServerConfig{ScrapeConfigs: []promConfig.ScrapeConfig{
+					{JobName: "node", StaticConfigs: []promConfig.StaticConfig{{Targets: []string{"localhost:9100"}}}},
+					{JobName: "app", Scheme: "https", MetricsPath: "/actuator/prometheus", StaticConfigs: []promConfig.StaticConfig{{Targets: []string{"app:8443", "localhost:9100"}}}},
+				}},
+			}}
+			targets, _ := getTargetURLs(configFiles)
+			Expect(targets).To(Equal([]string{
+				"http://localhost:9100/metrics",
+				"https://app:8443/actuator/prometheus",
+				"https://localhost:9100/actuator/prometheus",
+			}))
+		})
+	})
+})
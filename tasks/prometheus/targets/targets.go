@@ -0,0 +1,15 @@
+package targets
+
+import (
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Prometheus/Targets/*")
+
+	registrationFunc(PrometheusTargetsScrape{
+		httpGetter: tasks.HTTPRequester,
+	}, true)
+}
@@ -0,0 +1,24 @@
+package targets
+
+import (
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPrometheusTargets(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Prometheus/Targets/* test suite")
+}
+
+func TestRegisterWithCount(t *testing.T) {
+	registeredTasks := []tasks.Task{}
+	RegisterWith(func(task tasks.Task, runByDefault bool) {
+		registeredTasks = append(registeredTasks, task)
+	})
+	if len(registeredTasks) != 1 {
+		t.Errorf("RegisterWith() registered %d tasks, want 1", len(registeredTasks))
+	}
+}
@@ -2,15 +2,20 @@ package env
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/domain/repository"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
 // PythonEnvDependencies - This struct defines the project dependencies.
 type PythonEnvDependencies struct {
 	iPipEnvVersion repository.IPipEnvVersion
+	getwd          func() (string, error)
+	fileReader     func(string) ([]byte, error)
 }
 
 // PythonEnvDependenciesPayload - This is the payload.
@@ -38,6 +43,7 @@ func (t PythonEnvDependencies) Explain() string {
 func (t PythonEnvDependencies) Dependencies() []string {
 	return []string{
 		"Python/Config/Agent",
+		"Python/Env/Process",
 	}
 }
 
@@ -50,10 +56,81 @@ func (t PythonEnvDependencies) Execute(options tasks.Options, upstream map[strin
 			Status:  tasks.None,
 		}
 	}
+	// packages are read from the site-packages of running applications instead of the pip on PATH
+	if pythonProcesses, ok := upstream["Python/Env/Process"].Payload.([]PythonProcess); ok && upstream["Python/Env/Process"].Status == tasks.Success {
+		return getProcessDependencies(pythonProcesses)
+	}
 	result := t.getProjectDependencies()
+	if result.Status == tasks.Error {
+		// neither pip could be run, fall back to the versions pinned by the lockfiles of the application
+		if lockfileResult, found := t.getLockfileDependencies(); found {
+			return lockfileResult
+		}
+	}
 	return result
 }
 
+func (t PythonEnvDependencies) getLockfileDependencies() (tasks.Result, bool) {
+	dir, err := t.getwd()
+	if err != nil {
+		log.Debug("Unable to read the working directory:", err)
+		return tasks.Result{}, false
+	}
+	lockfiles := tasks.ReadLockfiles(dir, "python", t.fileReader)
+	var dependencies []string
+	var paths []string
+	var filesToCopy []tasks.FileCopyEnvelope
+	for _, lockfile := range lockfiles {
+		for _, dependency := range lockfile.Dependencies {
+			dependencies = append(dependencies, strings.ToLower(dependency.Name+"=="+dependency.Version))
+		}
+		paths = append(paths, lockfile.Path)
+		filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{Path: lockfile.Path})
+	}
+	if len(dependencies) == 0 {
+		return tasks.Result{}, false
+	}
+	return tasks.Result{
+		Status:      tasks.Success,
+		Summary:     "pip could not be run, collected the packages pinned in " + strings.Join(paths, ", "),
+		Payload:     removeDuplicates(dependencies),
+		FilesToCopy: filesToCopy,
+	}, true
+}
+
+func getProcessDependencies(pythonProcesses []PythonProcess) tasks.Result {
+	var dependencies []string
+	var packageList strings.Builder
+	for _, pythonProcess := range pythonProcesses {
+		fmt.Fprintf(&packageList, "# process %d: %s\n# %s\n", pythonProcess.Pid, pythonProcess.CmdLine, strings.Join(pythonProcess.SitePackages, ", "))
+		names := make([]string, 0, len(pythonProcess.Packages))
+		for name := range pythonProcess.Packages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			dependency := name + "==" + pythonProcess.Packages[name]
+			packageList.WriteString(dependency + "\n")
+			dependencies = append(dependencies, strings.ToLower(dependency))
+		}
+	}
+	if len(dependencies) == 0 {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "No installed packages found in the environments of the running Python applications.",
+		}
+	}
+
+	stream := make(chan string)
+	go tasks.StreamBlob(packageList.String(), stream)
+	return tasks.Result{
+		Status:      tasks.Success,
+		Summary:     fmt.Sprintf("Collected the installed packages of %d running Python application(s). See pythonPackages.txt for more info.", len(pythonProcesses)),
+		Payload:     removeDuplicates(dependencies),
+		FilesToCopy: []tasks.FileCopyEnvelope{{Path: "pythonPackages.txt", Stream: stream}},
+	}
+}
+
 func (t PythonEnvDependencies) getProjectDependencies() tasks.Result {
 	var errorsToReturn []string
 	var summariesToReturn []string
@@ -1,6 +1,7 @@
 package env
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -37,7 +38,7 @@ var _ = Describe("Python/Env/Dependencies", func() {
 
 	Describe("Dependencies()", func() {
 		It("Should return an expected slice of dependencies", func() {
-			expectedDependencies := []string{"Python/Config/Agent"}
+			expectedDependencies := []string{"Python/Config/Agent", "Python/Env/Process"}
 			Expect(p.Dependencies()).To(Equal(expectedDependencies))
 		})
 	})
@@ -191,3 +192,66 @@ func TestPythonEnvDependencies_getProjectDependencies(t *testing.T) {
 		})
 	}
 }
+
+var _ = Describe("PythonEnvDependencies with running Python applications", func() {
+	It("Should use the packages installed in the application environments", func() {
+		result := PythonEnvDependencies{}.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Python/Config/Agent": {Status: tasks.Success},
+			"Python/Env/Process": {
+				Status: tasks.Success,
+				Payload: []PythonProcess{
+					{Pid: 1, Packages: map[string]string{"newrelic": "9.1.0", "flask": "2.3.2"}},
+					{Pid: 2, Packages: map[string]string{"newrelic": "9.1.0", "celery": "5.3.1"}},
+				},
+			},
+		})
+		Expect(result.Status).To(Equal(tasks.Success))
+		Expect(result.Payload).To(Equal([]string{"flask==2.3.2", "newrelic==9.1.0", "celery==5.3.1"}))
+		Expect(result.FilesToCopy).To(HaveLen(1))
+		Expect(result.FilesToCopy[0].Path).To(Equal("pythonPackages.txt"))
+		var streamed string
+		for line := range result.FilesToCopy[0].Stream {
+			streamed += line
+		}
+		Expect(streamed).To(ContainSubstring("# process 2: \n"))
+		Expect(streamed).To(ContainSubstring("celery==5.3.1\n"))
+	})
+})
+
+var _ = Describe("PythonEnvDependencies without pip", func() {
+	var p PythonEnvDependencies
+
+	BeforeEach(func() {
+		mPipEnv := new(mocks.MPipVersionDeps)
+		mPipEnv.On("CheckPipVersion", mock.Anything).Return(tasks.Result{Status: tasks.Error, Summary: "FAILURE"})
+		p = PythonEnvDependencies{
+			iPipEnvVersion: mPipEnv,
+			getwd:          func() (string, error) { return "/app", nil },
+			fileReader: func(path string) ([]byte, error) {
+				if path == "/app/requirements.txt" {
+					return []byte("Flask==2.3.2\nnewrelic==9.1.0\ngunicorn\n"), nil
+				}
+				return nil, errors.New("no such file")
+			},
+		}
+	})
+
+	It("Should use the versions pinned by the application lockfiles", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Python/Config/Agent": {Status: tasks.Success},
+		})
+		Expect(result.Status).To(Equal(tasks.Success))
+		Expect(result.Summary).To(Equal("pip could not be run, collected the packages pinned in /app/requirements.txt"))
+		Expect(result.Payload).To(Equal([]string{"flask==2.3.2", "newrelic==9.1.0"}))
+		Expect(result.FilesToCopy).To(Equal([]tasks.FileCopyEnvelope{{Path: "/app/requirements.txt"}}))
+	})
+
+	It("Should return the pip errors when there is no lockfile", func() {
+		p.fileReader = func(string) ([]byte, error) { return nil, errors.New("no such file") }
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Python/Config/Agent": {Status: tasks.Success},
+		})
+		Expect(result.Status).To(Equal(tasks.Error))
+		Expect(result.Summary).To(Equal("FAILURE\nFAILURE"))
+	})
+})
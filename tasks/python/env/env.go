@@ -1,6 +1,10 @@
 package env
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks/python/repository"
@@ -11,10 +15,18 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Python/Env/*")
 	pythonEnv := repository.PythonEnv{CmdExec: tasks.CmdExecutor}
 	pipEnv := repository.PipEnv{}
+	registrationFunc(PythonEnvProcess{
+		processLister: getRunningProcesses,
+		fileReader:    os.ReadFile,
+		globber:       filepath.Glob,
+		runtimeOS:     runtime.GOOS,
+	}, true)
 	registrationFunc(PythonEnvVersion{
 		iPythonEnvVersion: pythonEnv},
 		true)
 	registrationFunc(PythonEnvDependencies{
 		iPipEnvVersion: pipEnv,
+		getwd:          os.Getwd,
+		fileReader:     os.ReadFile,
 	}, true)
 }
@@ -0,0 +1,386 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// PythonEnvProcess - finds the interpreters and packages used by running Python applications
+type PythonEnvProcess struct {
+	processLister func() ([]RunningProcess, error)
+	fileReader    func(string) ([]byte, error)
+	globber       func(string) ([]string, error)
+	runtimeOS     string
+}
+
+// RunningProcess - the process details needed to resolve the interpreter of an application
+type RunningProcess struct {
+	Pid     int32
+	Name    string
+	Exe     string
+	CmdLine []string
+	// Root is prepended to paths of the process, /proc/<pid>/root on Linux so container filesystems can be read
+	Root string
+}
+
+// PythonProcess - an application process and the Python environment it runs in
+type PythonProcess struct {
+	Pid     int32
+	CmdLine string
+	// Prefix is sys.prefix of the interpreter, the virtual environment directory when one is used
+	Prefix       string
+	EnvType      string
+	Version      string
+	SitePackages []string
+	// Packages has the normalized distribution names as keys and their versions as values
+	Packages map[string]string
+}
+
+// Python environment types
+const (
+	envTypeVenv   = "venv"
+	envTypeConda  = "conda"
+	envTypePyenv  = "pyenv"
+	envTypeSystem = "system"
+)
+
+var (
+	// the entry points that start instrumented applications
+	appLaunchers = []string{"newrelic-admin", "gunicorn", "uwsgi", "celery"}
+	// uwsgi takes the virtual environment as an option instead of running its python
+	uwsgiHomeOptions      = []string{"-H", "--home", "--virtualenv", "--venv", "--pyhome"}
+	pythonExecutableRegex = regexp.MustCompile(`^python(\d+(\.\d+)?)?(\.exe)?$`)
+	pythonLibDirRegex     = regexp.MustCompile(`python(\d+\.\d+)$`)
+	pythonDLLRegex        = regexp.MustCompile(`(?i)^python(\d)(\d+)\.dll$`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of this task.
+func (p PythonEnvProcess) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Python/Env/Process")
+}
+
+// Explain - Returns the help text for the Python/Env/Process task.
+func (p PythonEnvProcess) Explain() string {
+	return "Determine the Python environments and packages of running Python applications"
+}
+
+// Dependencies - Returns the dependencies for this task.
+func (p PythonEnvProcess) Dependencies() []string {
+	return []string{
+		"Python/Config/Agent",
+	}
+}
+
+// Execute - The core work within this task.
+func (p PythonEnvProcess) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Python/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Python Agent not installed. This task didn't run.",
+		}
+	}
+
+	runningProcesses, err := p.processLister()
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to list running processes: " + err.Error(),
+		}
+	}
+
+	var pythonProcesses []PythonProcess
+	var summaries []string
+	for _, runningProcess := range runningProcesses {
+		if !isPythonApp(runningProcess) {
+			continue
+		}
+		pythonProcess, ok := p.resolveEnvironment(runningProcess)
+		if !ok {
+			log.Debug("Unable to resolve the Python environment of process", runningProcess.Pid)
+			continue
+		}
+		pythonProcesses = append(pythonProcesses, pythonProcess)
+		summaries = append(summaries, fmt.Sprintf("Python %s (%s environment %s) with %d packages used by process %d: %s", pythonProcess.Version, pythonProcess.EnvType, pythonProcess.Prefix, len(pythonProcess.Packages), pythonProcess.Pid, pythonProcess.CmdLine))
+	}
+
+	if len(pythonProcesses) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No running Python applications found.",
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: pythonProcesses,
+	}
+}
+
+func isPythonApp(runningProcess RunningProcess) bool {
+	names := []string{runningProcess.Name}
+	for _, arg := range runningProcess.CmdLine {
+		names = append(names, filepath.Base(arg))
+	}
+	for _, name := range names {
+		name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+		// gunicorn and celery name their workers after the master, e.g. "gunicorn: worker [app]"
+		name = strings.TrimSuffix(name, ":")
+		if tasks.ContainsString(appLaunchers, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveEnvironment - finds sys.prefix from the interpreter or launcher paths of the command line, the interpreter
+// itself is not run since /proc/<pid>/exe resolves the symlinks that identify virtual environments
+func (p PythonEnvProcess) resolveEnvironment(runningProcess RunningProcess) (PythonProcess, bool) {
+	pythonProcess := PythonProcess{
+		Pid:     runningProcess.Pid,
+		CmdLine: strings.Join(runningProcess.CmdLine, " "),
+	}
+
+	binDirs := getCandidateBinDirs(runningProcess.CmdLine)
+	// the resolved executable only finds the base interpreter, so it is the last candidate
+	if runningProcess.Exe != "" && !tasks.ContainsString(binDirs, filepath.Dir(runningProcess.Exe)) {
+		binDirs = append(binDirs, filepath.Dir(runningProcess.Exe))
+	}
+	for _, binDir := range binDirs {
+		// Windows installs keep python.exe in the prefix itself, only venvs have a Scripts directory
+		prefix := binDir
+		if base := filepath.Base(binDir); base == "bin" || strings.EqualFold(base, "Scripts") {
+			prefix = filepath.Dir(binDir)
+		}
+		envType, version, ok := p.identifyPrefix(runningProcess.Root, prefix)
+		if !ok {
+			continue
+		}
+		pythonProcess.Prefix = prefix
+		pythonProcess.EnvType = envType
+		pythonProcess.Version = version
+		break
+	}
+	if pythonProcess.Prefix == "" {
+		return pythonProcess, false
+	}
+
+	pythonProcess.SitePackages = p.getSitePackages(runningProcess.Root, pythonProcess.Prefix, pythonProcess.Version)
+	pythonProcess.Packages = map[string]string{}
+	for _, sitePackages := range pythonProcess.SitePackages {
+		for name, version := range p.readInstalledPackages(filepath.Join(runningProcess.Root, sitePackages)) {
+			// the first site-packages on sys.path wins, like in Python
+			if _, found := pythonProcess.Packages[name]; !found {
+				pythonProcess.Packages[name] = version
+			}
+		}
+	}
+	return pythonProcess, true
+}
+
+// getCandidateBinDirs - the bin (or Scripts) directories of the interpreter, the launcher scripts and the uwsgi home
+func getCandidateBinDirs(cmdLine []string) []string {
+	var binDirs []string
+	addDir := func(dir string) {
+		if dir != "." && !tasks.ContainsString(binDirs, dir) {
+			binDirs = append(binDirs, dir)
+		}
+	}
+	for i, arg := range cmdLine {
+		if tasks.ContainsString(uwsgiHomeOptions, arg) && i+1 < len(cmdLine) {
+			addDir(filepath.Join(cmdLine[i+1], "bin"))
+			continue
+		}
+		if option, value, found := strings.Cut(arg, "="); found && tasks.ContainsString(uwsgiHomeOptions, option) {
+			addDir(filepath.Join(value, "bin"))
+			continue
+		}
+		name := strings.TrimSuffix(strings.ToLower(filepath.Base(arg)), ".exe")
+		if pythonExecutableRegex.MatchString(name) || tasks.ContainsString(appLaunchers, name) {
+			addDir(filepath.Dir(arg))
+		}
+	}
+	return binDirs
+}
+
+// identifyPrefix - returns the environment type and Python version of a candidate sys.prefix
+func (p PythonEnvProcess) identifyPrefix(root string, prefix string) (string, string, bool) {
+	if content, err := p.fileReader(filepath.Join(root, prefix, "pyvenv.cfg")); err == nil {
+		config := parsePyvenvConfig(content)
+		version := config["version"]
+		if version == "" {
+			version = config["version_info"]
+		}
+		if version == "" {
+			version = p.getLibVersion(root, prefix)
+		}
+		return envTypeVenv, trimVersion(version), true
+	}
+
+	version := p.getLibVersion(root, prefix)
+	if version == "" {
+		return "", "", false
+	}
+	if matches, _ := p.globber(filepath.Join(root, prefix, "conda-meta")); len(matches) > 0 {
+		return envTypeConda, version, true
+	}
+	if strings.Contains(filepath.ToSlash(prefix), "/.pyenv/versions/") {
+		return envTypePyenv, version, true
+	}
+	return envTypeSystem, version, true
+}
+
+// getLibVersion - reads the major and minor version from lib/pythonX.Y, Windows installs only have the pythonXY.dll
+func (p PythonEnvProcess) getLibVersion(root string, prefix string) string {
+	libDirs, _ := p.globber(filepath.Join(root, prefix, "lib", "python*"))
+	sort.Strings(libDirs)
+	for i := len(libDirs) - 1; i >= 0; i-- {
+		if match := pythonLibDirRegex.FindStringSubmatch(libDirs[i]); match != nil {
+			return match[1]
+		}
+	}
+	dlls, _ := p.globber(filepath.Join(root, prefix, "python3*.dll"))
+	for _, dll := range dlls {
+		if match := pythonDLLRegex.FindStringSubmatch(filepath.Base(dll)); match != nil {
+			return match[1] + "." + match[2]
+		}
+	}
+	return ""
+}
+
+func (p PythonEnvProcess) getSitePackages(root string, prefix string, version string) []string {
+	var sitePackages []string
+	addSitePackages := func(prefix string) {
+		pattern := filepath.Join(prefix, "lib", "python*", "site-packages")
+		if p.runtimeOS == "windows" {
+			pattern = filepath.Join(prefix, "Lib", "site-packages")
+		}
+		matches, _ := p.globber(filepath.Join(root, pattern))
+		sort.Strings(matches)
+		for _, match := range matches {
+			match = strings.TrimPrefix(match, root)
+			libVersion := pythonLibDirRegex.FindStringSubmatch(filepath.Dir(match))
+			// a prefix may hold the site-packages of several versions, only the running one is on sys.path
+			if libVersion != nil && version != "" && !strings.HasPrefix(version+".", libVersion[1]+".") {
+				continue
+			}
+			sitePackages = append(sitePackages, match)
+		}
+	}
+	addSitePackages(prefix)
+
+	// venvs created with --system-site-packages also see the packages of the interpreter they were created from
+	if content, err := p.fileReader(filepath.Join(root, prefix, "pyvenv.cfg")); err == nil {
+		config := parsePyvenvConfig(content)
+		if strings.EqualFold(config["include-system-site-packages"], "true") && config["home"] != "" {
+			addSitePackages(filepath.Dir(config["home"]))
+		}
+	}
+	return sitePackages
+}
+
+// parsePyvenvConfig - reads the key = value lines of pyvenv.cfg
+func parsePyvenvConfig(content []byte) map[string]string {
+	config := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if found {
+			config[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	return config
+}
+
+// trimVersion - version_info of virtualenv looks like 3.11.4.final.0
+func trimVersion(version string) string {
+	parts := strings.Split(version, ".")
+	for i, part := range parts {
+		if strings.Trim(part, "0123456789") != "" {
+			return strings.Join(parts[:i], ".")
+		}
+	}
+	return version
+}
+
+// readInstalledPackages - reads the Name and Version headers of the dist-info and egg-info metadata of a site-packages directory
+func (p PythonEnvProcess) readInstalledPackages(sitePackages string) map[string]string {
+	packages := map[string]string{}
+	metadataFiles, _ := p.globber(filepath.Join(sitePackages, "*.dist-info", "METADATA"))
+	eggInfoFiles, _ := p.globber(filepath.Join(sitePackages, "*.egg-info", "PKG-INFO"))
+	for _, metadataFile := range append(metadataFiles, eggInfoFiles...) {
+		content, err := p.fileReader(metadataFile)
+		if err != nil {
+			log.Debug("Unable to read", metadataFile, ":", err)
+			continue
+		}
+		name, version := parsePackageMetadata(content)
+		if name != "" && version != "" {
+			packages[tasks.NormalizePythonPackageName(name)] = version
+		}
+	}
+	return packages
+}
+
+// parsePackageMetadata - the metadata headers end at the first empty line, the description follows them
+func parsePackageMetadata(content []byte) (string, string) {
+	var name, version string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "name":
+			name = strings.TrimSpace(value)
+		case "version":
+			version = strings.TrimSpace(value)
+		}
+	}
+	return name, version
+}
+
+// getRunningProcesses - lists the running processes, reading their files through /proc/<pid>/root on Linux
+func getRunningProcesses() ([]RunningProcess, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	var runningProcesses []RunningProcess
+	for _, proc := range processes {
+		name, err := proc.Name()
+		if err != nil {
+			continue
+		}
+		cmdLine, err := proc.CmdlineSlice()
+		if err != nil {
+			continue
+		}
+		runningProcess := RunningProcess{Pid: proc.Pid, Name: name, CmdLine: cmdLine}
+		if exe, err := proc.Exe(); err == nil {
+			runningProcess.Exe = exe
+		}
+		// the root of processes owned by other users can only be read as root, their paths are read from the host
+		root := fmt.Sprintf("/proc/%d/root", proc.Pid)
+		if _, err := os.Stat(root + "/"); runtime.GOOS == "linux" && err == nil {
+			runningProcess.Root = root
+		}
+		runningProcesses = append(runningProcesses, runningProcess)
+	}
+	return runningProcesses, nil
+}
@@ -0,0 +1,122 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PythonEnvProcess", func() {
+	var p PythonEnvProcess
+	var runningProcesses []RunningProcess
+	agentInstalled := map[string]tasks.Result{
+		"Python/Config/Agent": {Status: tasks.Success},
+	}
+
+	BeforeEach(func() {
+		runningProcesses = nil
+		p = PythonEnvProcess{
+			processLister: func() ([]RunningProcess, error) { return runningProcesses, nil },
+			fileReader:    os.ReadFile,
+			globber:       filepath.Glob,
+			runtimeOS:     "linux",
+		}
+	})
+
+	Describe("Identifier()", func() {
+		It("Should return identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Name: "Process", Category: "Python", Subcategory: "Env"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		It("Should not run without the Python agent", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{"Python/Config/Agent": {Status: tasks.Failure}})
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		It("Should return an error when processes can't be listed", func() {
+			p.processLister = func() ([]RunningProcess, error) { return nil, errors.New("permission denied") }
+			result := p.Execute(tasks.Options{}, agentInstalled)
+			Expect(result.Status).To(Equal(tasks.Error))
+		})
+
+		It("Should return None when no Python applications are running", func() {
+			runningProcesses = []RunningProcess{{Pid: 1, Name: "python3", CmdLine: []string{"/usr/bin/python3", "script.py"}}}
+			result := p.Execute(tasks.Options{}, agentInstalled)
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		It("Should resolve the virtual environment of newrelic-admin from the interpreter path", func() {
+			runningProcesses = []RunningProcess{{
+				Pid:     10,
+				Name:    "python3.11",
+				Exe:     "/usr/local/bin/python3.11",
+				CmdLine: []string{"fixtures/venv/bin/python", "fixtures/venv/bin/newrelic-admin", "run-program", "flask", "run"},
+			}}
+			result := p.Execute(tasks.Options{}, agentInstalled)
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload).To(Equal([]PythonProcess{{
+				Pid:          10,
+				CmdLine:      "fixtures/venv/bin/python fixtures/venv/bin/newrelic-admin run-program flask run",
+				Prefix:       "fixtures/venv",
+				EnvType:      "venv",
+				Version:      "3.11.4",
+				SitePackages: []string{"fixtures/venv/lib/python3.11/site-packages"},
+				Packages: map[string]string{
+					"flask":            "2.3.2",
+					"flask-sqlalchemy": "3.0.5",
+					"legacy-lib":       "0.1",
+					"newrelic":         "9.1.0",
+				},
+			}}))
+		})
+
+		It("Should resolve conda environments from the celery launcher", func() {
+			runningProcesses = []RunningProcess{{
+				Pid:     11,
+				Name:    "celery",
+				CmdLine: []string{"fixtures/conda/envs/worker/bin/celery", "-A", "tasks", "worker"},
+			}}
+			result := p.Execute(tasks.Options{}, agentInstalled)
+			Expect(result.Status).To(Equal(tasks.Success))
+			pythonProcesses := result.Payload.([]PythonProcess)
+			Expect(pythonProcesses[0].EnvType).To(Equal("conda"))
+			Expect(pythonProcesses[0].Version).To(Equal("3.9"))
+			Expect(pythonProcesses[0].Packages).To(HaveKeyWithValue("celery", "5.3.1"))
+		})
+
+		It("Should read container environments through the process root and include system site-packages", func() {
+			runningProcesses = []RunningProcess{{
+				Pid:     12,
+				Name:    "uwsgi",
+				CmdLine: []string{"uwsgi", "--http", ":8000", "--home=/app/.venv", "--module", "app.wsgi"},
+				Root:    "fixtures/container",
+			}}
+			result := p.Execute(tasks.Options{}, agentInstalled)
+			Expect(result.Status).To(Equal(tasks.Success))
+			pythonProcesses := result.Payload.([]PythonProcess)
+			Expect(pythonProcesses[0].Prefix).To(Equal("/app/.venv"))
+			Expect(pythonProcesses[0].Version).To(Equal("3.12.1"))
+			Expect(pythonProcesses[0].SitePackages).To(Equal([]string{"/app/.venv/lib/python3.12/site-packages", "/usr/local/lib/python3.12/site-packages"}))
+			Expect(pythonProcesses[0].Packages).To(Equal(map[string]string{"django": "4.2", "newrelic": "9.5.0"}))
+		})
+
+		It("Should skip applications whose environment can't be found", func() {
+			runningProcesses = []RunningProcess{{Pid: 13, Name: "gunicorn", CmdLine: []string{"/missing/bin/gunicorn", "app:app"}}}
+			result := p.Execute(tasks.Options{}, agentInstalled)
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Describe("trimVersion()", func() {
+		It("Should drop the release level of version_info", func() {
+			Expect(trimVersion("3.11.4.final.0")).To(Equal("3.11.4"))
+			Expect(trimVersion("3.12.0")).To(Equal("3.12.0"))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package env
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/domain/repository"
@@ -27,6 +28,7 @@ func (p PythonEnvVersion) Explain() string {
 func (p PythonEnvVersion) Dependencies() []string {
 	return []string{
 		"Python/Config/Agent",
+		"Python/Env/Process",
 	}
 }
 
@@ -39,11 +41,41 @@ func (p PythonEnvVersion) Execute(options tasks.Options, upstream map[string]tas
 		result.Summary = "Python Agent not installed. This task didn't run."
 		return result
 	}
+	// the interpreters of running applications are preferred over whichever python is on PATH
+	if pythonProcesses, ok := upstream["Python/Env/Process"].Payload.([]PythonProcess); ok && upstream["Python/Env/Process"].Status == tasks.Success {
+		return getProcessVersions(pythonProcesses)
+	}
 	// pythonDeps := new(PythonDeps)
 	return p.RunPythonCommands()
 
 }
 
+func getProcessVersions(pythonProcesses []PythonProcess) tasks.Result {
+	var summaries []string
+	var versions []string
+	for _, pythonProcess := range pythonProcesses {
+		if pythonProcess.Version == "" {
+			continue
+		}
+		summaries = append(summaries, fmt.Sprintf("Python %s found in %s used by process %d.", pythonProcess.Version, pythonProcess.Prefix, pythonProcess.Pid))
+		if !tasks.ContainsString(versions, pythonProcess.Version) {
+			versions = append(versions, pythonProcess.Version)
+		}
+	}
+	if len(versions) == 0 {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to detect the Python version of the running Python applications.",
+			URL:     "https://docs.newrelic.com/docs/agents/python-agent/getting-started/compatibility-requirements-python-agent#basic",
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: versions,
+	}
+}
+
 func (p PythonEnvVersion) RunPythonCommands() tasks.Result {
 	var errorsToReturn []string
 	var successesToReturn []string
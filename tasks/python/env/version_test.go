@@ -48,7 +48,7 @@ var _ = Describe("PythonEnvVersion", func() {
 
 	Describe("Dependencies()", func() {
 		It("should return list of dependencies' ", func() {
-			Expect(p.Dependencies()).To(Equal([]string{"Python/Config/Agent"}))
+			Expect(p.Dependencies()).To(Equal([]string{"Python/Config/Agent", "Python/Env/Process"}))
 		})
 	})
 
@@ -191,3 +191,22 @@ func TestPythonEnvVersion_RunPythonCommands(t *testing.T) {
 		})
 	}
 }
+
+var _ = Describe("PythonEnvVersion with running Python applications", func() {
+	It("Should use the versions of the application interpreters", func() {
+		result := PythonEnvVersion{}.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Python/Config/Agent": {Status: tasks.Success},
+			"Python/Env/Process": {
+				Status: tasks.Success,
+				Payload: []PythonProcess{
+					{Pid: 1, Prefix: "/srv/web/venv", Version: "3.11.4"},
+					{Pid: 2, Prefix: "/srv/worker/venv", Version: "3.11.4"},
+					{Pid: 3, Prefix: "/opt/conda", Version: "3.9"},
+				},
+			},
+		})
+		Expect(result.Status).To(Equal(tasks.Success))
+		Expect(result.Payload).To(Equal([]string{"3.11.4", "3.9"}))
+		Expect(result.Summary).To(ContainSubstring("Python 3.9 found in /opt/conda used by process 3."))
+	})
+})
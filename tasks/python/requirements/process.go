@@ -0,0 +1,105 @@
+package requirements
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/python/env"
+)
+
+const pythonCompatibilityDocsURL = "https://docs.newrelic.com/docs/agents/python-agent/getting-started/compatibility-requirements-python-agent#basic"
+
+func describeProcess(pythonProcess env.PythonProcess) string {
+	return fmt.Sprintf("Process %d (%s)", pythonProcess.Pid, pythonProcess.Prefix)
+}
+
+// checkProcessPythonVersions - checks the Python version of each running application against the agent installed in its environment
+func checkProcessPythonVersions(pythonProcesses []env.PythonProcess) tasks.Result {
+	var failures, warnings, successes []string
+	for _, pythonProcess := range pythonProcesses {
+		label := describeProcess(pythonProcess)
+		agentVersion, hasAgent := pythonProcess.Packages["newrelic"]
+		requiredAgentVersion, isPythonVersionSupported := compatibilityVars.PythonVersionAgentSupportability[removePyVersionPatch(pythonProcess.Version)]
+		switch {
+		case !hasAgent:
+			failures = append(failures, fmt.Sprintf("%s: the New Relic Python agent is not installed in this environment.", label))
+		case !isPythonVersionSupported:
+			failures = append(failures, fmt.Sprintf("%s: Python %s is not supported by the Python Agent.", label, pythonProcess.Version))
+		default:
+			isAgentVersionCompatible, err := tasks.VersionIsCompatible(agentVersion, []string{requiredAgentVersion})
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: unable to parse the Python Agent version %s.", label, agentVersion))
+			} else if !isAgentVersionCompatible {
+				warnings = append(warnings, fmt.Sprintf("%s: Python %s is not supported by Python Agent %s, it requires Python Agent %s.", label, pythonProcess.Version, agentVersion, requiredAgentVersion))
+			} else {
+				successes = append(successes, fmt.Sprintf("%s: Python %s is supported by Python Agent %s.", label, pythonProcess.Version, agentVersion))
+			}
+		}
+	}
+
+	summary := strings.Join(append(append(failures, warnings...), successes...), "\n")
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: summary,
+			URL:     pythonCompatibilityDocsURL,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: summary,
+			URL:     pythonCompatibilityDocsURL,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: summary,
+	}
+}
+
+// checkProcessWebframeworks - lists the supported web frameworks installed in the environment of each running application
+func checkProcessWebframeworks(pythonProcesses []env.PythonProcess) tasks.Result {
+	var withoutFramework, summaries, compatibleFrameworks []string
+	for _, pythonProcess := range pythonProcesses {
+		var frameworks []string
+		for framework, requirements := range supportedVersions {
+			version, installed := pythonProcess.Packages[framework]
+			if !installed {
+				continue
+			}
+			isCompatible, err := tasks.VersionIsCompatible(version, requirements)
+			if err != nil {
+				log.Debugf("Encountered %s while attempting to parse the framework version.", err)
+			}
+			if isCompatible {
+				frameworks = append(frameworks, framework+"=="+version)
+			}
+		}
+		sort.Strings(frameworks)
+		if len(frameworks) == 0 {
+			withoutFramework = append(withoutFramework, describeProcess(pythonProcess)+": no compatible web framework found.")
+			continue
+		}
+		summaries = append(summaries, fmt.Sprintf("%s: found compatible web framework %s.", describeProcess(pythonProcess), strings.Join(frameworks, ", ")))
+		compatibleFrameworks = append(compatibleFrameworks, frameworks...)
+	}
+
+	if len(withoutFramework) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(append(withoutFramework, summaries...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/agents/python-agent/getting-started/instrumented-python-packages#web-frameworks, https://docs.newrelic.com/docs/agents/python-agent/supported-features/python-background-tasks",
+			Payload: compatibleFrameworks,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: compatibleFrameworks,
+	}
+}
@@ -0,0 +1,56 @@
+package requirements
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/python/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Python requirements of running Python applications", func() {
+	processes := func(pythonProcesses ...env.PythonProcess) map[string]tasks.Result {
+		return map[string]tasks.Result{
+			"Python/Env/Process": {Status: tasks.Success, Payload: pythonProcesses},
+		}
+	}
+	web := env.PythonProcess{Pid: 1, Prefix: "/srv/web/venv", Version: "3.11.4", Packages: map[string]string{"newrelic": "9.1.0", "flask": "2.3.2", "tornado": "5.1"}}
+	worker := env.PythonProcess{Pid: 2, Prefix: "/srv/worker/venv", Version: "3.11.2", Packages: map[string]string{"newrelic": "7.2.0.167", "celery": "5.3.1"}}
+	legacy := env.PythonProcess{Pid: 3, Prefix: "/usr", Version: "3.2.1", Packages: map[string]string{"newrelic": "9.1.0"}}
+	uninstrumented := env.PythonProcess{Pid: 4, Prefix: "/srv/admin/venv", Version: "3.11.4", Packages: map[string]string{"django": "4.2"}}
+
+	Describe("PythonRequirementsPythonVersion", func() {
+		It("Should succeed when every application runs a supported Python and agent", func() {
+			result := PythonRequirementsPythonVersion{}.Execute(tasks.Options{}, processes(web))
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("Process 1 (/srv/web/venv): Python 3.11.4 is supported by Python Agent 9.1.0."))
+		})
+
+		It("Should warn when the agent of an application is too old for its Python", func() {
+			result := PythonRequirementsPythonVersion{}.Execute(tasks.Options{}, processes(web, worker))
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("Process 2 (/srv/worker/venv): Python 3.11.2 is not supported by Python Agent 7.2.0.167, it requires Python Agent 8.3.0+."))
+		})
+
+		It("Should fail for unsupported Python versions and environments without the agent", func() {
+			result := PythonRequirementsPythonVersion{}.Execute(tasks.Options{}, processes(web, legacy, uninstrumented))
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("Process 3 (/usr): Python 3.2.1 is not supported by the Python Agent."))
+			Expect(result.Summary).To(ContainSubstring("Process 4 (/srv/admin/venv): the New Relic Python agent is not installed in this environment."))
+		})
+	})
+
+	Describe("PythonRequirementsWebframework", func() {
+		It("Should list the compatible frameworks of each application", func() {
+			result := PythonRequirementsWebframework{}.Execute(tasks.Options{}, processes(web, uninstrumented))
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload).To(Equal([]string{"flask==2.3.2", "django==4.2"}))
+			Expect(result.Summary).To(ContainSubstring("Process 1 (/srv/web/venv): found compatible web framework flask==2.3.2."))
+		})
+
+		It("Should warn about applications without a compatible framework", func() {
+			result := PythonRequirementsWebframework{}.Execute(tasks.Options{}, processes(web, worker))
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("Process 2 (/srv/worker/venv): no compatible web framework found."))
+		})
+	})
+})
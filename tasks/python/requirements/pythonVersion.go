@@ -8,6 +8,7 @@ import (
 
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/python/env"
 )
 
 //https://github.com/edmorley/newrelic-python-agent/blame/master/newrelic/setup.py#L100
@@ -36,11 +37,15 @@ func (t PythonRequirementsPythonVersion) Dependencies() []string {
 	return []string{
 		"Python/Env/Version",
 		"Python/Env/Dependencies",
+		"Python/Env/Process",
 	}
 }
 
 // Execute - The core work within this task
 func (t PythonRequirementsPythonVersion) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if pythonProcesses, ok := upstream["Python/Env/Process"].Payload.([]env.PythonProcess); ok && upstream["Python/Env/Process"].Status == tasks.Success {
+		return checkProcessPythonVersions(pythonProcesses)
+	}
 
 	if upstream["Python/Env/Version"].Status == tasks.Error {
 		return tasks.Result{
@@ -34,7 +34,7 @@ var _ = Describe("Python/Requirements/PythonVersion", func() {
 	Describe("Dependencies()", func() {
 		It("Should return an expected slice of dependencies", func() {
 			expectedDependencies := []string{"Python/Env/Version",
-				"Python/Env/Dependencies"}
+				"Python/Env/Dependencies", "Python/Env/Process"}
 			Expect(p.Dependencies()).To(Equal(expectedDependencies))
 		})
 	})
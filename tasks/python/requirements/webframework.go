@@ -6,6 +6,7 @@ import (
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/python/env"
 )
 
 var supportedVersions = map[string][]string{
@@ -44,11 +45,15 @@ func (t PythonRequirementsWebframework) Explain() string {
 func (t PythonRequirementsWebframework) Dependencies() []string {
 	return []string{
 		"Python/Env/Dependencies",
+		"Python/Env/Process",
 	}
 }
 
 // Execute - The core work within this task.
 func (t PythonRequirementsWebframework) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if pythonProcesses, ok := upstream["Python/Env/Process"].Payload.([]env.PythonProcess); ok && upstream["Python/Env/Process"].Status == tasks.Success {
+		return checkProcessWebframeworks(pythonProcesses)
+	}
 	if upstream["Python/Env/Dependencies"].Status != tasks.Info {
 		return tasks.Result{
 			Status:  tasks.None,
@@ -33,7 +33,7 @@ var _ = Describe("Python/Requirements/Webframework", func() {
 
 	Describe("Dependencies()", func() {
 		It("Should return an expected slice of dependencies", func() {
-			expectedDependencies := []string{"Python/Env/Dependencies"}
+			expectedDependencies := []string{"Python/Env/Dependencies", "Python/Env/Process"}
 			Expect(p.Dependencies()).To(Equal(expectedDependencies))
 		})
 	})
@@ -0,0 +1,272 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNothingToChange is returned by Plan when the action would not change anything, for example because the problem was already fixed
+var ErrNothingToChange = errors.New("nothing to change")
+
+// backupSuffix is appended, with a timestamp and a random suffix, to the name of every file a FileEdit backs up
+const backupSuffix = ".nrdiag-bak-"
+
+// Remediation - a fix a task offers for the problem it found. Remediations are only applied in -fix mode, after every task has run, and each action is confirmed with the user first
+type Remediation struct {
+	Description string
+	Actions     []RemediationAction
+}
+
+// RemediationAction - a single change made by a Remediation
+type RemediationAction interface {
+	// Plan describes the change without making it. File edits are described as a diff
+	Plan() (string, error)
+	// Apply makes the change and returns how to revert it
+	Apply() (Rollback, error)
+}
+
+// Rollback types
+const (
+	RollbackRestore = "restore" // copy Backup over Path
+	RollbackChmod   = "chmod"   // set the permissions of Path back to Mode
+	RollbackCommand = "command" // run Command
+	RollbackNone    = "none"    // the change cannot be reverted automatically
+)
+
+// Rollback - records how to revert an applied RemediationAction. Rollbacks are written to nrdiag-fix-rollback.json
+type Rollback struct {
+	Type    string   `json:"type"`
+	Path    string   `json:"path,omitempty"`
+	Backup  string   `json:"backup,omitempty"`
+	Mode    string   `json:"mode,omitempty"`
+	Command []string `json:"command,omitempty"`
+}
+
+// Revert - undoes the change recorded by the rollback
+func (r Rollback) Revert() error {
+	switch r.Type {
+	case RollbackRestore:
+		info, err := os.Stat(r.Backup)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(r.Backup)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(r.Path, content, info.Mode().Perm())
+	case RollbackChmod:
+		var mode os.FileMode
+		if _, err := fmt.Sscanf(r.Mode, "%o", &mode); err != nil {
+			return fmt.Errorf("invalid mode %q: %w", r.Mode, err)
+		}
+		return os.Chmod(r.Path, mode)
+	case RollbackCommand:
+		if len(r.Command) == 0 {
+			return errors.New("no command to run")
+		}
+		return runRemediationCommand(r.Command[0], r.Command[1:])
+	case RollbackNone:
+		return errors.New("this change cannot be reverted automatically")
+	}
+	return fmt.Errorf("unknown rollback type %q", r.Type)
+}
+
+// FileEdit - rewrites a file with the content returned by Edit after backing it up next to the original
+type FileEdit struct {
+	Path string
+	Edit func(content []byte) ([]byte, error)
+}
+
+// Plan - returns a diff of the edit
+func (f FileEdit) Plan() (string, error) {
+	original, edited, err := f.edit()
+	if err != nil {
+		return "", err
+	}
+	return DiffLines(f.Path, string(original), string(edited)), nil
+}
+
+// Apply - backs up the file and writes the edited content
+func (f FileEdit) Apply() (Rollback, error) {
+	original, edited, err := f.edit()
+	if err != nil {
+		return Rollback{}, err
+	}
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return Rollback{}, err
+	}
+	backup, err := f.backUp(original, info.Mode().Perm())
+	if err != nil {
+		return Rollback{}, fmt.Errorf("unable to back up %s: %w", f.Path, err)
+	}
+	if err := os.WriteFile(f.Path, edited, info.Mode().Perm()); err != nil {
+		return Rollback{}, err
+	}
+	return Rollback{Type: RollbackRestore, Path: f.Path, Backup: backup}, nil
+}
+
+// backUp - writes the original content to a new file next to f.Path. The name gets a random suffix after the timestamp so several edits of the same file in one run each keep their own backup
+func (f FileEdit) backUp(original []byte, perm os.FileMode) (string, error) {
+	backup, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+backupSuffix+time.Now().Format("20060102150405")+"-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = backup.Write(original)
+	if closeErr := backup.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(backup.Name(), perm)
+	}
+	if err != nil {
+		os.Remove(backup.Name())
+		return "", err
+	}
+	return backup.Name(), nil
+}
+
+func (f FileEdit) edit() ([]byte, []byte, error) {
+	original, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	edited, err := f.Edit(original)
+	if err != nil {
+		return nil, nil, err
+	}
+	if string(edited) == string(original) {
+		return nil, nil, ErrNothingToChange
+	}
+	return original, edited, nil
+}
+
+// Chmod - adds permission bits to a file or directory
+type Chmod struct {
+	Path string
+	Add  os.FileMode
+}
+
+// Plan - describes the permissions before and after the change
+func (c Chmod) Plan() (string, error) {
+	current, err := c.mode()
+	if err != nil {
+		return "", err
+	}
+	if current|c.Add == current {
+		return "", ErrNothingToChange
+	}
+	return fmt.Sprintf("chmod %s: %s -> %s", c.Path, current, current|c.Add), nil
+}
+
+// Apply - adds the permission bits
+func (c Chmod) Apply() (Rollback, error) {
+	current, err := c.mode()
+	if err != nil {
+		return Rollback{}, err
+	}
+	if err := os.Chmod(c.Path, current|c.Add); err != nil {
+		return Rollback{}, err
+	}
+	return Rollback{Type: RollbackChmod, Path: c.Path, Mode: fmt.Sprintf("%04o", current)}, nil
+}
+
+func (c Chmod) mode() (os.FileMode, error) {
+	info, err := os.Stat(c.Path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Mode().Perm(), nil
+}
+
+// Command - runs a command. Revert, when set, is the command that undoes it
+type Command struct {
+	Name   string
+	Args   []string
+	Revert []string
+}
+
+// Plan - describes the command to run
+func (c Command) Plan() (string, error) {
+	plan := "run: " + strings.Join(append([]string{c.Name}, c.Args...), " ")
+	if len(c.Revert) == 0 {
+		plan += " (cannot be reverted automatically)"
+	}
+	return plan, nil
+}
+
+// Apply - runs the command
+func (c Command) Apply() (Rollback, error) {
+	if err := runRemediationCommand(c.Name, c.Args); err != nil {
+		return Rollback{}, err
+	}
+	if len(c.Revert) == 0 {
+		return Rollback{Type: RollbackNone}, nil
+	}
+	return Rollback{Type: RollbackCommand, Command: c.Revert}, nil
+}
+
+func runRemediationCommand(name string, args []string) error {
+	output, err := CmdExecutor(name, args...)
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			return err
+		}
+		return fmt.Errorf("%w: %s", err, message)
+	}
+	return nil
+}
+
+// DiffLines - returns a unified style diff of two versions of a file. The changed region is shown as a single hunk with up to three lines of context
+func DiffLines(path, before, after string) string {
+	const context = 3
+	if strings.HasSuffix(before, "\n") && strings.HasSuffix(after, "\n") {
+		before, after = before[:len(before)-1], after[:len(after)-1]
+	}
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	start := prefix - context
+	if start < 0 {
+		start = 0
+	}
+	endA := len(a) - suffix + context
+	if endA > len(a) {
+		endA = len(a)
+	}
+	endB := len(b) - suffix + context
+	if endB > len(b) {
+		endB = len(b)
+	}
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", path, path)
+	fmt.Fprintf(&diff, "@@ -%d,%d +%d,%d @@\n", start+1, endA-start, start+1, endB-start)
+	for _, line := range a[start:prefix] {
+		diff.WriteString(" " + line + "\n")
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		diff.WriteString("-" + line + "\n")
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		diff.WriteString("+" + line + "\n")
+	}
+	for _, line := range a[len(a)-suffix : endA] {
+		diff.WriteString(" " + line + "\n")
+	}
+	return diff.String()
+}
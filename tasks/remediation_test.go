@@ -0,0 +1,175 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newrelic.yml")
+	original := "common: &default_settings\n  license_key: abc\n  log_level: info\n"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	edit := FileEdit{
+		Path: path,
+		Edit: func(content []byte) ([]byte, error) {
+			return []byte(strings.Replace(string(content), "log_level: info", "log_level: finest", 1)), nil
+		},
+	}
+
+	plan, err := edit.Plan()
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if !strings.Contains(plan, "-  log_level: info\n+  log_level: finest\n") {
+		t.Errorf("Plan() = %q, want a diff of the log_level line", plan)
+	}
+	if content, _ := os.ReadFile(path); string(content) != original {
+		t.Fatalf("Plan() changed the file")
+	}
+
+	rollback, err := edit.Apply()
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if content, _ := os.ReadFile(path); !strings.Contains(string(content), "log_level: finest") {
+		t.Errorf("Apply() wrote %q", content)
+	}
+	if backup, _ := os.ReadFile(rollback.Backup); string(backup) != original {
+		t.Errorf("backup %s = %q, want the original content", rollback.Backup, backup)
+	}
+	if rollback.Type != RollbackRestore || rollback.Path != path {
+		t.Errorf("Apply() rollback = %+v", rollback)
+	}
+
+	if _, err := edit.Plan(); !errors.Is(err, ErrNothingToChange) {
+		t.Errorf("Plan() after Apply() error = %v, want ErrNothingToChange", err)
+	}
+
+	if err := rollback.Revert(); err != nil {
+		t.Fatalf("Revert() error = %v", err)
+	}
+	if content := mustRead(t, path); string(content) != original {
+		t.Errorf("Revert() left %q, want the original content", content)
+	}
+}
+
+func TestFileEdit_sameFileTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newrelic.yml")
+	original := "common: &default_settings\n  app_name: My Application\n  log_level: info\n"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	replace := func(old, new string) FileEdit {
+		return FileEdit{Path: path, Edit: func(content []byte) ([]byte, error) {
+			return []byte(strings.Replace(string(content), old, new, 1)), nil
+		}}
+	}
+
+	// both edits run in the same second, as the AppName and LogLevel fixes do in one -fix run
+	first, err := replace("app_name: My Application", "app_name: checkout").Apply()
+	if err != nil {
+		t.Fatalf("first Apply() error = %v", err)
+	}
+	second, err := replace("log_level: info", "log_level: finest").Apply()
+	if err != nil {
+		t.Fatalf("second Apply() error = %v", err)
+	}
+	if first.Backup == second.Backup {
+		t.Fatalf("both edits were backed up to %s", first.Backup)
+	}
+	if backup := mustRead(t, first.Backup); string(backup) != original {
+		t.Errorf("first backup = %q, want the original content", backup)
+	}
+
+	for _, rollback := range []Rollback{second, first} {
+		if err := rollback.Revert(); err != nil {
+			t.Fatalf("Revert() error = %v", err)
+		}
+	}
+	if content := mustRead(t, path); string(content) != original {
+		t.Errorf("reverting both edits left %q, want the original content", content)
+	}
+}
+
+func TestFileEdit_nothingToChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newrelic.ini")
+	os.WriteFile(path, []byte("[newrelic]\n"), 0644)
+	edit := FileEdit{Path: path, Edit: func(content []byte) ([]byte, error) { return content, nil }}
+
+	if _, err := edit.Plan(); !errors.Is(err, ErrNothingToChange) {
+		t.Errorf("Plan() error = %v, want ErrNothingToChange", err)
+	}
+	if _, err := edit.Apply(); !errors.Is(err, ErrNothingToChange) {
+		t.Errorf("Apply() error = %v, want ErrNothingToChange", err)
+	}
+}
+
+func TestChmod(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not used on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "newrelic.jar")
+	os.WriteFile(path, []byte("jar"), 0600)
+	chmod := Chmod{Path: path, Add: 0040}
+
+	plan, err := chmod.Plan()
+	if err != nil || plan != "chmod "+path+": -rw------- -> -rw-r-----" {
+		t.Errorf("Plan() = %q, %v", plan, err)
+	}
+	rollback, err := chmod.Apply()
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("Apply() mode = %s", info.Mode().Perm())
+	}
+	if _, err := chmod.Plan(); !errors.Is(err, ErrNothingToChange) {
+		t.Errorf("Plan() after Apply() error = %v, want ErrNothingToChange", err)
+	}
+	if err := rollback.Revert(); err != nil {
+		t.Fatalf("Revert() error = %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Revert() mode = %s", info.Mode().Perm())
+	}
+}
+
+func TestCommand_Plan(t *testing.T) {
+	plan, _ := Command{Name: "service", Args: []string{"newrelic-daemon", "restart"}}.Plan()
+	if plan != "run: service newrelic-daemon restart (cannot be reverted automatically)" {
+		t.Errorf("Plan() = %q", plan)
+	}
+	plan, _ = Command{Name: "newrelic-infra-ctl", Revert: []string{"newrelic-infra-ctl", "-mode", "info"}}.Plan()
+	if plan != "run: newrelic-infra-ctl" {
+		t.Errorf("Plan() = %q", plan)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	after := "a\nb\nc\nd\nE\nf\ng\nh\n"
+	want := "--- f.yml\n+++ f.yml\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n"
+	if got := DiffLines("f.yml", before, after); got != want {
+		t.Errorf("DiffLines() =\n%s\nwant\n%s", got, want)
+	}
+
+	inserted := DiffLines("newrelic.ini", "[newrelic]\nlicense_key = abc\n", "[newrelic]\napp_name = web01\nlicense_key = abc\n")
+	if !strings.Contains(inserted, "@@ -1,2 +1,3 @@\n [newrelic]\n+app_name = web01\n license_key = abc\n") {
+		t.Errorf("DiffLines() = %q", inserted)
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
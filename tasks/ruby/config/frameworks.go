@@ -0,0 +1,322 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+)
+
+// RubyConfigFrameworks - checks the frameworks and servers resolved by Gemfile.lock against the Ruby agent resolved next to them
+type RubyConfigFrameworks struct {
+	fileReader func(string) ([]byte, error)
+}
+
+// RubyFramework - a framework or server resolved by a Gemfile.lock that also resolves newrelic_rpm
+type RubyFramework struct {
+	Name         string
+	Version      string
+	AgentVersion string
+	Lockfile     string
+}
+
+// the gems checked against compatibilityVars.RubyFrameworkAgentSupportability, railties is the Rails gem of apps that don't depend on rails itself
+var rubyFrameworkGems = map[string]string{
+	"rails":     "rails",
+	"railties":  "rails",
+	"puma":      "puma",
+	"unicorn":   "unicorn",
+	"passenger": "passenger",
+	"sidekiq":   "sidekiq",
+	"resque":    "resque",
+}
+
+// the frameworks whose processes run background jobs instead of serving requests
+var rubyJobFrameworks = []string{"sidekiq", "resque"}
+
+var (
+	pumaPreloadRegex     = regexp.MustCompile(`(?m)^\s*preload_app!`)
+	pumaWorkersRegex     = regexp.MustCompile(`(?m)^\s*workers[\s(]+([^\s)#]+)`)
+	pumaManualStartRegex = regexp.MustCompile(`NewRelic::Agent\.manual_start`)
+	pumaAfterForkRegex   = regexp.MustCompile(`NewRelic::Agent\.after_fork`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t RubyConfigFrameworks) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Ruby/Config/Frameworks")
+}
+
+// Explain - Returns the help text for each individual task
+func (t RubyConfigFrameworks) Explain() string {
+	return "Check the Rails, Puma, Unicorn, Passenger, Sidekiq and Resque versions of Gemfile.lock and their settings against the New Relic Ruby agent"
+}
+
+// Dependencies - Returns the dependencies for ech task.
+func (t RubyConfigFrameworks) Dependencies() []string {
+	return []string{
+		"Ruby/Config/Agent",
+		"Ruby/Config/Collect",
+		"Base/Env/CollectEnvVars",
+	}
+}
+
+// Execute - The core work within each task
+func (t RubyConfigFrameworks) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Ruby/Config/Collect"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Gemfile.lock was found. This task did not run",
+		}
+	}
+	gemfiles, ok := upstream["Ruby/Config/Collect"].Payload.([]string)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+	envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	// the payload of Ruby/Config/Agent is only the newrelic.yml files of the Ruby agent
+	configs, _ := upstream["Ruby/Config/Agent"].Payload.([]baseConfig.ValidateElement)
+
+	var frameworks []RubyFramework
+	var failures, warnings []string
+	for _, gemfile := range gemfiles {
+		if filepath.Base(gemfile) != "Gemfile.lock" {
+			continue
+		}
+		lockfileFrameworks := t.getFrameworks(gemfile)
+		if len(lockfileFrameworks) == 0 {
+			continue
+		}
+		frameworks = append(frameworks, lockfileFrameworks...)
+
+		lockfileFailures, lockfileWarnings := checkFrameworkSupport(lockfileFrameworks)
+		failures = append(failures, lockfileFailures...)
+		warnings = append(warnings, lockfileWarnings...)
+		if hasFramework(lockfileFrameworks, "puma") {
+			warnings = append(warnings, t.checkPumaConfig(filepath.Dir(gemfile))...)
+		}
+		dispatcherFailures, dispatcherWarnings := checkDispatcher(envVars["NEW_RELIC_DISPATCHER"], lockfileFrameworks)
+		failures = append(failures, dispatcherFailures...)
+		warnings = append(warnings, dispatcherWarnings...)
+		warnings = append(warnings, checkJobInstrumentation(configs, lockfileFrameworks)...)
+		if hasFramework(lockfileFrameworks, "rails") {
+			warnings = append(warnings, checkRailsEnvironment(configs, envVars)...)
+		}
+	}
+
+	if len(frameworks) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Gemfile.lock resolves newrelic_rpm together with a supported framework. This task did not run",
+		}
+	}
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(failures, warnings...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/ruby-agent/getting-started/ruby-agent-requirements-supported-frameworks/",
+			Payload: frameworks,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(warnings, "\n"),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/ruby-agent/configuration/ruby-agent-configuration/",
+			Payload: frameworks,
+		}
+	}
+	var names []string
+	for _, framework := range frameworks {
+		names = append(names, fmt.Sprintf("%s %s", framework.Name, framework.Version))
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: "The New Relic Ruby agent supports " + strings.Join(names, ", "),
+		Payload: frameworks,
+	}
+}
+
+// getFrameworks - the frameworks of a Gemfile.lock, only when it also resolves the agent
+func (t RubyConfigFrameworks) getFrameworks(path string) []RubyFramework {
+	content, err := t.fileReader(path)
+	if err != nil {
+		log.Debug("Unable to read", path, ":", err)
+		return nil
+	}
+	lockfile, err := tasks.ParseLockfile(path, content)
+	if err != nil {
+		log.Debug(err)
+		return nil
+	}
+	var agentVersion string
+	for _, gem := range lockfile.Dependencies {
+		if gem.Name == "newrelic_rpm" {
+			agentVersion = gem.Version
+		}
+	}
+	if agentVersion == "" {
+		log.Debug(path, "does not resolve newrelic_rpm")
+		return nil
+	}
+
+	var frameworks []RubyFramework
+	for _, gem := range lockfile.Dependencies {
+		name, ok := rubyFrameworkGems[gem.Name]
+		if !ok || (gem.Name == "railties" && hasFramework(frameworks, "rails")) {
+			continue
+		}
+		frameworks = append(frameworks, RubyFramework{Name: name, Version: gem.Version, AgentVersion: agentVersion, Lockfile: path})
+	}
+	return frameworks
+}
+
+func frameworkNames(frameworks []RubyFramework) []string {
+	var names []string
+	for _, framework := range frameworks {
+		names = append(names, framework.Name)
+	}
+	return names
+}
+
+func hasFramework(frameworks []RubyFramework, name string) bool {
+	return tasks.ContainsString(frameworkNames(frameworks), name)
+}
+
+// checkFrameworkSupport - the framework versions are looked up by major.minor first and then by major
+func checkFrameworkSupport(frameworks []RubyFramework) (failures []string, warnings []string) {
+	for _, framework := range frameworks {
+		supportedVersions := compatibilityVars.RubyFrameworkAgentSupportability[framework.Name]
+		version := tasks.LockfileVersionNumber(framework.Version)
+		parts := strings.Split(version, ".")
+		agentVersions, found := supportedVersions[strings.Join(parts[:min(2, len(parts))], ".")]
+		if !found {
+			agentVersions, found = supportedVersions[parts[0]]
+		}
+		if !found {
+			warnings = append(warnings, fmt.Sprintf("%s %s is not in the list of %s versions supported by the Ruby agent - %s", framework.Name, framework.Version, framework.Name, framework.Lockfile))
+			continue
+		}
+		isCompatible, err := tasks.VersionIsCompatible(tasks.LockfileVersionNumber(framework.AgentVersion), agentVersions)
+		if err != nil {
+			log.Debug("Unable to compare the Ruby agent version", framework.AgentVersion, ":", err)
+			continue
+		}
+		if !isCompatible {
+			failures = append(failures, fmt.Sprintf("newrelic_rpm %s does not support %s %s, it requires newrelic_rpm %s - %s", framework.AgentVersion, framework.Name, framework.Version, strings.Join(agentVersions, " or "), framework.Lockfile))
+		}
+	}
+	return
+}
+
+// checkPumaConfig - a preloaded Puma cluster forks its workers after the master started the agent, so a manually started agent must be restarted in each worker
+func (t RubyConfigFrameworks) checkPumaConfig(appDir string) []string {
+	path := filepath.Join(appDir, "config", "puma.rb")
+	content, err := t.fileReader(path)
+	if err != nil {
+		log.Debug("Unable to read", path, ":", err)
+		return nil
+	}
+	if !pumaPreloadRegex.Match(content) {
+		return nil
+	}
+	workers := pumaWorkersRegex.FindSubmatch(content)
+	if workers == nil || string(workers[1]) == "0" {
+		return nil
+	}
+	if pumaManualStartRegex.Match(content) && !pumaAfterForkRegex.Match(content) {
+		return []string{fmt.Sprintf("%s starts the agent with NewRelic::Agent.manual_start in a preloaded Puma cluster but never calls NewRelic::Agent.after_fork(force_reconnect: true) in on_worker_boot, the workers will not report data", path)}
+	}
+	return nil
+}
+
+// checkDispatcher - NEW_RELIC_DISPATCHER overrides the dispatcher detected by the agent in every process of the application
+func checkDispatcher(dispatcher string, frameworks []RubyFramework) (failures []string, warnings []string) {
+	if dispatcher == "" {
+		return
+	}
+	if !tasks.ContainsString(compatibilityVars.RubyAgentDispatchers, dispatcher) {
+		failures = append(failures, fmt.Sprintf("NEW_RELIC_DISPATCHER is set to %s, which is not a dispatcher of the Ruby agent: %s", dispatcher, strings.Join(compatibilityVars.RubyAgentDispatchers, ", ")))
+		return
+	}
+	if _, isFramework := compatibilityVars.RubyFrameworkAgentSupportability[dispatcher]; isFramework && !hasFramework(frameworks, dispatcher) {
+		warnings = append(warnings, fmt.Sprintf("NEW_RELIC_DISPATCHER is set to %s but %s does not resolve the %s gem", dispatcher, frameworks[0].Lockfile, dispatcher))
+	}
+	if tasks.ContainsString(rubyJobFrameworks, dispatcher) {
+		return
+	}
+	for _, job := range rubyJobFrameworks {
+		if hasFramework(frameworks, job) {
+			warnings = append(warnings, fmt.Sprintf("NEW_RELIC_DISPATCHER is set to %s, if it is also set for the %s processes they will report as %s web processes. Unset it for the %s processes, the agent detects %s on its own", dispatcher, job, dispatcher, job, job))
+		}
+	}
+	return
+}
+
+// checkJobInstrumentation - the disable_<framework> settings of newrelic.yml turn off the instrumentation of a job framework the application uses
+func checkJobInstrumentation(configs []baseConfig.ValidateElement, frameworks []RubyFramework) []string {
+	var warnings []string
+	for _, job := range rubyJobFrameworks {
+		if !hasFramework(frameworks, job) {
+			continue
+		}
+		for _, config := range configs {
+			// the settings of common are repeated in the environments that merge it
+			var settings []string
+			for _, setting := range config.ParsedResult.FindKey("disable_" + job) {
+				if setting.Value() == "true" {
+					settings = append(settings, setting.PathAndKey())
+				}
+			}
+			if len(settings) > 0 {
+				warnings = append(warnings, fmt.Sprintf("%s sets %s to true, the %s jobs of the application are not instrumented", config.Config.FilePath+config.Config.FileName, strings.Join(settings, ", "), job))
+			}
+		}
+	}
+	return warnings
+}
+
+// checkRailsEnvironment - the agent reads the newrelic.yml section named after the Rails environment, production when it is not set
+func checkRailsEnvironment(configs []baseConfig.ValidateElement, envVars map[string]string) []string {
+	environment := "production"
+	for _, envVar := range []string{"RAILS_ENV", "RACK_ENV"} {
+		if envVars[envVar] != "" {
+			environment = envVars[envVar]
+			break
+		}
+	}
+	var warnings []string
+	for _, config := range configs {
+		if filepath.Ext(config.Config.FileName) != ".yml" {
+			continue
+		}
+		var sections []string
+		for _, child := range config.ParsedResult.Children {
+			sections = append(sections, child.Key)
+		}
+		// a newrelic.yml without environment sections applies to every environment
+		if !hasEnvironmentSections(sections) || tasks.ContainsString(sections, environment) {
+			continue
+		}
+		sort.Strings(sections)
+		warnings = append(warnings, fmt.Sprintf("%s has no %s section for the %s Rails environment, only: %s", config.Config.FilePath+config.Config.FileName, environment, environment, strings.Join(sections, ", ")))
+	}
+	return warnings
+}
+
+func hasEnvironmentSections(sections []string) bool {
+	for _, section := range []string{"common", "development", "test", "staging", "production"} {
+		if tasks.ContainsString(sections, section) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ruby/Config/Frameworks", func() {
+	const lockfilePath = "../../fixtures/ruby/frameworks/Gemfile.lock"
+	var (
+		p        RubyConfigFrameworks
+		upstream map[string]tasks.Result
+		result   tasks.Result
+	)
+
+	parseConfig := func(path string) []baseConfig.ValidateElement {
+		content, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		parsed, err := baseConfig.ParseYaml(strings.NewReader(string(content)))
+		Expect(err).ToNot(HaveOccurred())
+		return []baseConfig.ValidateElement{{
+			Config:       baseConfig.ConfigElement{FileName: "newrelic.yml", FilePath: "/app/config/"},
+			ParsedResult: parsed,
+		}}
+	}
+
+	BeforeEach(func() {
+		p = RubyConfigFrameworks{fileReader: os.ReadFile}
+		upstream = map[string]tasks.Result{
+			"Ruby/Config/Collect":     {Status: tasks.Success, Payload: []string{lockfilePath}},
+			"Ruby/Config/Agent":       {Status: tasks.Success, Payload: []baseConfig.ValidateElement{}},
+			"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("When no Gemfile.lock was collected", func() {
+		BeforeEach(func() {
+			upstream["Ruby/Config/Collect"] = tasks.Result{Status: tasks.Warning}
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("When the Gemfile.lock does not resolve newrelic_rpm", func() {
+		BeforeEach(func() {
+			upstream["Ruby/Config/Collect"] = tasks.Result{Status: tasks.Success, Payload: []string{"../../fixtures/ruby/config/badgems_one_Gemfile"}}
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("When the agent is older than the frameworks it runs with", func() {
+		It("Should return a Failure with the frameworks that need a newer agent", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("newrelic_rpm 8.10.1 does not support puma 6.4.0, it requires newrelic_rpm 8.16.0+ - " + lockfilePath))
+			Expect(result.Summary).To(ContainSubstring("newrelic_rpm 8.10.1 does not support sidekiq 7.1.2, it requires newrelic_rpm 8.16.0+ - " + lockfilePath))
+			Expect(result.Summary).ToNot(ContainSubstring("does not support rails"))
+			Expect(result.Payload).To(Equal([]RubyFramework{
+				{Name: "puma", Version: "6.4.0", AgentVersion: "8.10.1", Lockfile: lockfilePath},
+				{Name: "rails", Version: "7.0.8", AgentVersion: "8.10.1", Lockfile: lockfilePath},
+				{Name: "sidekiq", Version: "7.1.2", AgentVersion: "8.10.1", Lockfile: lockfilePath},
+			}))
+		})
+		It("Should warn about the agent started manually in the preloaded Puma master", func() {
+			Expect(result.Summary).To(ContainSubstring("../../fixtures/ruby/frameworks/config/puma.rb starts the agent with NewRelic::Agent.manual_start in a preloaded Puma cluster"))
+		})
+	})
+
+	Context("When NEW_RELIC_DISPATCHER is not a dispatcher of the agent", func() {
+		BeforeEach(func() {
+			upstream["Base/Env/CollectEnvVars"] = tasks.Result{Status: tasks.Info, Payload: map[string]string{"NEW_RELIC_DISPATCHER": "nginx"}}
+		})
+		It("Should return a Failure", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("NEW_RELIC_DISPATCHER is set to nginx, which is not a dispatcher of the Ruby agent"))
+		})
+	})
+
+	Describe("checkDispatcher()", func() {
+		frameworks := []RubyFramework{{Name: "rails", Lockfile: lockfilePath}, {Name: "sidekiq", Lockfile: lockfilePath}}
+
+		It("Should not return findings when the variable is not set", func() {
+			failures, warnings := checkDispatcher("", frameworks)
+			Expect(failures).To(BeEmpty())
+			Expect(warnings).To(BeEmpty())
+		})
+		It("Should warn about a web dispatcher set next to a job framework", func() {
+			failures, warnings := checkDispatcher("unicorn", frameworks)
+			Expect(failures).To(BeEmpty())
+			Expect(warnings).To(Equal([]string{
+				"NEW_RELIC_DISPATCHER is set to unicorn but " + lockfilePath + " does not resolve the unicorn gem",
+				"NEW_RELIC_DISPATCHER is set to unicorn, if it is also set for the sidekiq processes they will report as unicorn web processes. Unset it for the sidekiq processes, the agent detects sidekiq on its own",
+			}))
+		})
+		It("Should accept the dispatcher of a job framework", func() {
+			failures, warnings := checkDispatcher("sidekiq", frameworks)
+			Expect(failures).To(BeEmpty())
+			Expect(warnings).To(BeEmpty())
+		})
+	})
+
+	Describe("newrelic.yml checks", func() {
+		var configs []baseConfig.ValidateElement
+		frameworks := []RubyFramework{{Name: "rails"}, {Name: "sidekiq"}}
+
+		BeforeEach(func() {
+			configs = parseConfig("../../fixtures/ruby/frameworks/newrelic.yml")
+		})
+
+		It("Should warn when the Sidekiq instrumentation is disabled", func() {
+			Expect(checkJobInstrumentation(configs, frameworks)).To(Equal([]string{
+				"/app/config/newrelic.yml sets /common/disable_sidekiq, /development/disable_sidekiq, /staging/disable_sidekiq to true, the sidekiq jobs of the application are not instrumented",
+			}))
+		})
+		It("Should warn when the section of the Rails environment is missing", func() {
+			Expect(checkRailsEnvironment(configs, map[string]string{})).To(Equal([]string{
+				"/app/config/newrelic.yml has no production section for the production Rails environment, only: common, development, staging",
+			}))
+		})
+		It("Should use the section of RAILS_ENV", func() {
+			Expect(checkRailsEnvironment(configs, map[string]string{"RAILS_ENV": "staging", "RACK_ENV": "production"})).To(BeEmpty())
+		})
+	})
+})
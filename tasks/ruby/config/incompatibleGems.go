@@ -2,19 +2,16 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
 )
 
-// List of incompatible gems: keys are displayed to customer in task Result
-var incompatibleGems = map[string]string{
-	"db-charmer":            "^[^# ]*(gem 'db-charmer').*",
-	"escape_utils":          "^[^# ]*(gem 'escape_utils').*",
-	"right_http_connection": "^[^# ]*(gem 'right_http_connection').*",
-	"ar-octopus":            "^[^# ]*(gem 'ar-octopus').*",
-}
-
 // RubyConfigIncompatibleGems - This task handles incompatible gems that are across the board incompatible regardless of Ruby Agent version and gem version
 type RubyConfigIncompatibleGems struct {
 }
@@ -22,6 +19,8 @@ type RubyConfigIncompatibleGems struct {
 type BadGemAndPath struct {
 	GemName     string
 	GemfilePath string
+	// Version is only known when the gem was resolved by a Gemfile.lock
+	Version string
 }
 
 // Identifier - This returns the Category, Subcategory and Name of each task
@@ -72,7 +71,16 @@ func (t RubyConfigIncompatibleGems) Execute(options tasks.Options, upstream map[
 
 func checkGems(gemfiles []string) (incompatibleGem []BadGemAndPath) {
 	for _, gemfile := range gemfiles {
-		for name, gemRegex := range incompatibleGems {
+		if filepath.Base(gemfile) == "Gemfile.lock" {
+			incompatibleGem = append(incompatibleGem, checkGemfileLock(gemfile)...)
+			continue
+		}
+		// the exact versions of a Gemfile with a lockfile next to it are checked in the lockfile instead
+		if tasks.ContainsString(gemfiles, gemfile+".lock") {
+			continue
+		}
+		for _, name := range incompatibleGemNames() {
+			gemRegex := fmt.Sprintf(`^[^# ]*(gem ['"]%s['"]).*`, regexp.QuoteMeta(name))
 			if tasks.FindStringInFile(gemRegex, gemfile) {
 				log.Debug("Incompatible gem", name, "was found")
 				incompatibleGem = append(incompatibleGem, BadGemAndPath{GemName: name, GemfilePath: gemfile})
@@ -82,6 +90,45 @@ func checkGems(gemfiles []string) (incompatibleGem []BadGemAndPath) {
 	return
 }
 
+// checkGemfileLock - checks the gems resolved by a Gemfile.lock, including the ones required by other gems
+func checkGemfileLock(path string) (incompatibleGem []BadGemAndPath) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Debug("Unable to read", path, ":", err)
+		return
+	}
+	lockfile, err := tasks.ParseLockfile(path, content)
+	if err != nil {
+		log.Debug(err)
+		return
+	}
+	for _, gem := range lockfile.Dependencies {
+		versions, ok := compatibilityVars.RubyIncompatibleGems[gem.Name]
+		if !ok {
+			continue
+		}
+		isIncompatible, err := tasks.VersionIsCompatible(tasks.LockfileVersionNumber(gem.Version), versions)
+		if err != nil {
+			log.Debug("Unable to compare the version of", gem.Name, ":", err)
+			isIncompatible = true
+		}
+		if isIncompatible {
+			log.Debug("Incompatible gem", gem.Name, gem.Version, "was found")
+			incompatibleGem = append(incompatibleGem, BadGemAndPath{GemName: gem.Name, GemfilePath: path, Version: gem.Version})
+		}
+	}
+	return
+}
+
+func incompatibleGemNames() []string {
+	names := make([]string, 0, len(compatibilityVars.RubyIncompatibleGems))
+	for name := range compatibilityVars.RubyIncompatibleGems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func displayResults(incompatibleGems []BadGemAndPath) (summary string) {
 
 	if len(incompatibleGems) == 0 {
@@ -89,6 +136,10 @@ func displayResults(incompatibleGems []BadGemAndPath) (summary string) {
 	}
 	summary = fmt.Sprintf("We detected %d Ruby gem(s) incompatible with the New Relic Ruby agent:", len(incompatibleGems))
 	for _, gem := range incompatibleGems {
+		if gem.Version != "" {
+			summary += fmt.Sprintf("\n%s (%s) - %s", gem.GemName, gem.Version, gem.GemfilePath)
+			continue
+		}
 		summary += fmt.Sprintf("\n%s - %s", gem.GemName, gem.GemfilePath)
 	}
 	return summary
@@ -43,6 +43,28 @@ var _ = Describe("Ruby/Config/IncompatibleGems", func() {
 
 			})
 		})
+		Context("When given a Gemfile with its Gemfile.lock", func() {
+			BeforeEach(func() {
+				gemFiles = []string{
+					"../../fixtures/ruby/config/lockfile/Gemfile",
+					"../../fixtures/ruby/config/lockfile/Gemfile.lock",
+				}
+			})
+			It("Should return the incompatible gems resolved by the lockfile with their versions", func() {
+				Expect(output).To(Equal([]BadGemAndPath{
+					{
+						GemName:     "ar-octopus",
+						GemfilePath: "../../fixtures/ruby/config/lockfile/Gemfile.lock",
+						Version:     "0.10.2",
+					},
+					{
+						GemName:     "escape_utils",
+						GemfilePath: "../../fixtures/ruby/config/lockfile/Gemfile.lock",
+						Version:     "1.3.0",
+					},
+				}))
+			})
+		})
 	})
 
 	Describe("displayResults()", func() {
@@ -84,6 +106,19 @@ var _ = Describe("Ruby/Config/IncompatibleGems", func() {
 			})
 		})
 
+		Context("When given an incompatible gem resolved by a Gemfile.lock", func() {
+			BeforeEach(func() {
+				incompatibleGems = []BadGemAndPath{{
+					GemName:     "escape_utils",
+					GemfilePath: "/app/Gemfile.lock",
+					Version:     "1.3.0",
+				}}
+			})
+			It("Should return expected summary including the resolved version", func() {
+				Expect(summary).To(Equal("We detected 1 Ruby gem(s) incompatible with the New Relic Ruby agent:\nescape_utils (1.3.0) - /app/Gemfile.lock"))
+			})
+		})
+
 		Context("When given multiple incompatible gems", func() {
 			format.TruncatedDiff = false
 			BeforeEach(func() {
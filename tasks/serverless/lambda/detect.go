@@ -0,0 +1,255 @@
+package lambda
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// ServerlessLambdaDetect - finds a Lambda function directory or deployment zip and parses its templates
+type ServerlessLambdaDetect struct {
+	fileReader func(string) ([]byte, error)
+}
+
+// maxPackageFiles - upper bound of package entries recorded, and of files walked in a function directory. Function packages with vendored
+// dependencies can be very large and we only need the handler modules
+const maxPackageFiles = 10000
+
+var (
+	templateFileRegex  = regexp.MustCompile(`^(template\.(ya?ml|json)|serverless\.ya?ml|.+\.template(\.json|\.ya?ml)?|cloudformation-template-(update|create)-stack\.json|.*(function|configuration).*\.json)$`)
+	skippedPackageDirs = []string{"node_modules", ".git", "site-packages", "vendor", "__pycache__"}
+	sensitiveEnvRegex  = regexp.MustCompile(`(?i)(KEY|SECRET|TOKEN|PASSWORD|PASSPHRASE)`)
+)
+
+// MarshalJSON - redacts environment values that may hold credentials before they reach nrdiag-output.json
+func (f LambdaFunction) MarshalJSON() ([]byte, error) {
+	type alias LambdaFunction
+	redacted := alias(copyFunction(f))
+	for key := range redacted.Environment {
+		if sensitiveEnvRegex.MatchString(key) {
+			redacted.Environment[key] = "_REDACTED_"
+		}
+	}
+	return json.Marshal(redacted)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p ServerlessLambdaDetect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Serverless/Lambda/Detect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p ServerlessLambdaDetect) Explain() string {
+	return "Detect AWS Lambda functions in a function directory or deployment zip"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p ServerlessLambdaDetect) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (p ServerlessLambdaDetect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	// Walking the working directory on every default run is too expensive when nrdiag runs from / or a home directory,
+	// so the function directory or zip has to be given explicitly
+	sourcePath := options.Options["path"]
+	if sourcePath == "" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Lambda function path provided. To diagnose a Lambda function run with '-o Serverless/Lambda/Detect.path=PATH-TO-FUNCTION-DIR-OR-ZIP'",
+		}
+	}
+
+	source, readErrors, err := p.inspectSource(sourcePath)
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the Lambda function at " + sourcePath + ": " + err.Error(),
+		}
+	}
+
+	if len(source.Templates) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No SAM, CloudFormation, serverless.yml or Lambda function configuration files found in " + sourcePath,
+		}
+	}
+
+	if len(source.Functions) == 0 {
+		if len(readErrors) == 0 {
+			return tasks.Result{
+				Status:  tasks.None,
+				Summary: "No Lambda functions are declared in the templates found in " + sourcePath,
+			}
+		}
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Unable to parse the Lambda deployment templates found in " + sourcePath + ":\n" + strings.Join(readErrors, "\n"),
+			URL:     "https://docs.newrelic.com/docs/serverless-function-monitoring/aws-lambda-monitoring/instrument-lambda-function/instrument-your-own/",
+			Payload: source,
+		}
+	}
+
+	var names []string
+	for _, function := range source.Functions {
+		names = append(names, function.Name)
+	}
+	summary := "Found Lambda function(s) " + strings.Join(names, ", ") + " in " + sourcePath
+	if len(readErrors) > 0 {
+		summary += "\nSome templates could not be parsed:\n" + strings.Join(readErrors, "\n")
+	}
+
+	return tasks.Result{
+		Status:   tasks.Info,
+		Summary:  summary,
+		Payload:  source,
+		Redacted: hasSensitiveEnvironment(source.Functions),
+	}
+}
+
+// hasSensitiveEnvironment - returns true if MarshalJSON redacts an environment value of any of the functions
+func hasSensitiveEnvironment(functions []LambdaFunction) bool {
+	for _, function := range functions {
+		for key := range function.Environment {
+			if sensitiveEnvRegex.MatchString(key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inspectSource - collects the package files and parses the templates of a directory or zip
+func (p ServerlessLambdaDetect) inspectSource(sourcePath string) (LambdaFunctionSource, []string, error) {
+	source := LambdaFunctionSource{Path: sourcePath}
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return source, nil, err
+	}
+
+	var contents map[string][]byte
+	if !info.IsDir() && strings.EqualFold(filepath.Ext(sourcePath), ".zip") {
+		source.IsArchive = true
+		source.Files, contents, err = readArchive(sourcePath)
+	} else if info.IsDir() {
+		source.Files, contents, err = p.readDirectory(sourcePath)
+	} else {
+		// A single template was provided
+		var content []byte
+		content, err = p.fileReader(sourcePath)
+		contents = map[string][]byte{filepath.Base(sourcePath): content}
+	}
+	if err != nil {
+		return source, nil, err
+	}
+
+	var readErrors []string
+	for _, name := range sortedKeys(contents) {
+		functions, parseErr := parseTemplate(name, contents[name])
+		if parseErr == errNotATemplate {
+			continue
+		}
+		source.Templates = append(source.Templates, name)
+		if parseErr != nil {
+			log.Debug("Error parsing Lambda template", name, parseErr)
+			readErrors = append(readErrors, name+": "+parseErr.Error())
+			continue
+		}
+		source.Functions = append(source.Functions, functions...)
+	}
+	return source, readErrors, nil
+}
+
+func (p ServerlessLambdaDetect) readDirectory(root string) ([]string, map[string][]byte, error) {
+	var files []string
+	contents := make(map[string][]byte)
+	walkErr := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			log.Debug("Error when walking Lambda function directory:", err)
+			return nil
+		}
+		if entry.IsDir() {
+			if path != root && tasks.ContainsString(skippedPackageDirs, entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(files) >= maxPackageFiles {
+			log.Debug("Stopped walking the Lambda function directory after", maxPackageFiles, "files")
+			return fs.SkipAll
+		}
+		relative, _ := filepath.Rel(root, path)
+		relative = filepath.ToSlash(relative)
+		files = append(files, relative)
+		if templateFileRegex.MatchString(entry.Name()) && isTemplateLocation(relative) {
+			content, readErr := p.fileReader(path)
+			if readErr != nil {
+				log.Debug("Error reading Lambda template", path, readErr)
+				return nil
+			}
+			contents[relative] = content
+		}
+		return nil
+	})
+	return files, contents, walkErr
+}
+
+func readArchive(path string) ([]string, map[string][]byte, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer reader.Close()
+
+	var files []string
+	contents := make(map[string][]byte)
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		if len(files) < maxPackageFiles {
+			files = append(files, entry.Name)
+		}
+		if !templateFileRegex.MatchString(filepath.Base(entry.Name)) || !isTemplateLocation(entry.Name) {
+			continue
+		}
+		entryReader, openErr := entry.Open()
+		if openErr != nil {
+			log.Debug("Error opening zip entry", entry.Name, openErr)
+			continue
+		}
+		content, readErr := io.ReadAll(entryReader)
+		entryReader.Close()
+		if readErr != nil {
+			log.Debug("Error reading zip entry", entry.Name, readErr)
+			continue
+		}
+		contents[entry.Name] = content
+	}
+	return files, contents, nil
+}
+
+// isTemplateLocation - templates live at the root of the project or in the build output of sam/serverless,
+// anything deeper is most likely a dependency's own file
+func isTemplateLocation(relative string) bool {
+	directory := filepath.ToSlash(filepath.Dir(relative))
+	return directory == "." || directory == ".aws-sam/build" || directory == ".serverless"
+}
+
+func sortedKeys(contents map[string][]byte) []string {
+	var keys []string
+	for key := range contents {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
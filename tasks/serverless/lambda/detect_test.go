@@ -0,0 +1,161 @@
+package lambda
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Serverless/Lambda/Detect", func() {
+	var p ServerlessLambdaDetect
+
+	BeforeEach(func() {
+		p = ServerlessLambdaDetect{
+			fileReader: os.ReadFile,
+		}
+	})
+
+	Describe("Identifier()", func() {
+		It("Should return the identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Serverless", Subcategory: "Lambda", Name: "Detect"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		Context("When the path option is not set", func() {
+			It("Should return a None result without reading the working directory", func() {
+				p.fileReader = func(string) ([]byte, error) {
+					Fail("no file should be read")
+					return nil, nil
+				}
+				result := p.Execute(tasks.Options{Options: map[string]string{}}, map[string]tasks.Result{})
+				Expect(result.Status).To(Equal(tasks.None))
+				Expect(result.Summary).To(ContainSubstring("Serverless/Lambda/Detect.path"))
+			})
+		})
+
+		Context("When the path option points to a SAM project", func() {
+			It("Should return the functions declared in the template with globals applied", func() {
+				result := p.Execute(tasks.Options{Options: map[string]string{"path": "fixtures/sam"}}, map[string]tasks.Result{})
+				Expect(result.Status).To(Equal(tasks.Info))
+
+				source, ok := result.Payload.(LambdaFunctionSource)
+				Expect(ok).To(BeTrue())
+				Expect(source.Templates).To(Equal([]string{"template.yaml"}))
+				Expect(source.Files).To(ContainElement("src/orders.py"))
+				Expect(source.Functions).To(HaveLen(2))
+
+				payload, err := json.Marshal(source)
+				Expect(err).To(BeNil())
+				Expect(string(payload)).NotTo(ContainSubstring("src/orders.py"))
+				Expect(result.Redacted).To(BeTrue())
+
+				orders := source.Functions[1]
+				Expect(orders.Name).To(Equal("OrdersFunction"))
+				Expect(orders.Runtime).To(Equal("python3.11"))
+				Expect(orders.Layers).To(Equal([]string{"!Sub arn:${AWS::Partition}:lambda:${AWS::Region}:451483290750:layer:NewRelicPython311:10"}))
+				Expect(orders.Environment).To(Equal(map[string]string{
+					"NEW_RELIC_ACCOUNT_ID":         "!Ref NRAccountId",
+					"NEW_RELIC_LICENSE_KEY_SECRET": "nr-license-key",
+					"NEW_RELIC_LAMBDA_HANDLER":     "orders.handler",
+				}))
+			})
+		})
+
+		Context("When the path option points to a serverless.yml project", func() {
+			It("Should apply provider defaults and the New Relic plugin settings", func() {
+				options := tasks.Options{Options: map[string]string{"path": "fixtures/serverless"}}
+				result := p.Execute(options, map[string]tasks.Result{})
+				Expect(result.Status).To(Equal(tasks.Info))
+
+				source := result.Payload.(LambdaFunctionSource)
+				Expect(source.Functions).To(HaveLen(2))
+				Expect(source.Functions[0].Name).To(Equal("cart"))
+				Expect(source.Functions[0].Runtime).To(Equal("nodejs20.x"))
+				Expect(source.Functions[0].ManagedByPlugin).To(BeTrue())
+				Expect(source.Functions[0].Environment["NEW_RELIC_ACCOUNT_ID"]).To(Equal("${env:NEW_RELIC_ACCOUNT_ID}"))
+				Expect(source.Functions[1].Runtime).To(Equal("nodejs14.x"))
+				Expect(result.Redacted).To(BeFalse())
+			})
+		})
+
+		Context("When the path option points to a deployment zip", func() {
+			It("Should read the package entries and templates from the archive", func() {
+				zipPath := filepath.Join(GinkgoT().TempDir(), "function.zip")
+				zipFile, err := os.Create(zipPath)
+				Expect(err).To(BeNil())
+				writer := zip.NewWriter(zipFile)
+				for _, name := range []string{"index.js", "function-configuration.json"} {
+					entry, err := writer.Create(name)
+					Expect(err).To(BeNil())
+					content := []byte("exports.handler = async () => {}")
+					if name == "function-configuration.json" {
+						content, _ = os.ReadFile("fixtures/configuration/function-configuration.json")
+					}
+					_, err = entry.Write(content)
+					Expect(err).To(BeNil())
+				}
+				Expect(writer.Close()).To(BeNil())
+				Expect(zipFile.Close()).To(BeNil())
+
+				options := tasks.Options{Options: map[string]string{"path": zipPath}}
+				result := p.Execute(options, map[string]tasks.Result{})
+				Expect(result.Status).To(Equal(tasks.Info))
+
+				source := result.Payload.(LambdaFunctionSource)
+				Expect(source.IsArchive).To(BeTrue())
+				Expect(source.Files).To(ConsistOf("index.js", "function-configuration.json"))
+				Expect(source.Functions).To(HaveLen(1))
+				Expect(source.Functions[0].Layers).To(HaveLen(2))
+			})
+		})
+
+		Context("When the path option points to a directory without templates", func() {
+			It("Should return a None result", func() {
+				result := p.Execute(tasks.Options{Options: map[string]string{"path": "fixtures/sam/src"}}, map[string]tasks.Result{})
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the directory holds more files than maxPackageFiles", func() {
+			It("Should stop walking once the limit is reached", func() {
+				dir := GinkgoT().TempDir()
+				for i := 0; i < maxPackageFiles+10; i++ {
+					Expect(os.WriteFile(filepath.Join(dir, fmt.Sprintf("module%05d.js", i)), nil, 0644)).To(Succeed())
+				}
+				files, _, err := p.readDirectory(dir)
+				Expect(err).To(BeNil())
+				Expect(files).To(HaveLen(maxPackageFiles))
+				Expect(files).NotTo(ContainElement(fmt.Sprintf("module%05d.js", maxPackageFiles)))
+			})
+		})
+
+		Context("When the path option does not exist", func() {
+			It("Should return an Error result", func() {
+				options := tasks.Options{Options: map[string]string{"path": "fixtures/missing.zip"}}
+				result := p.Execute(options, map[string]tasks.Result{})
+				Expect(result.Status).To(Equal(tasks.Error))
+			})
+		})
+	})
+
+	Describe("LambdaFunction.MarshalJSON()", func() {
+		It("Should redact credentials from the environment", func() {
+			function := LambdaFunction{Environment: map[string]string{
+				"NEW_RELIC_LICENSE_KEY": "0123456789abcdef0123456789abcdef01234567",
+				"NEW_RELIC_ACCOUNT_ID":  "12345",
+			}}
+			marshaled, err := json.Marshal(function)
+			Expect(err).To(BeNil())
+			Expect(string(marshaled)).NotTo(ContainSubstring("0123456789abcdef"))
+			Expect(string(marshaled)).To(ContainSubstring("12345"))
+			Expect(function.Environment["NEW_RELIC_LICENSE_KEY"]).To(Equal("0123456789abcdef0123456789abcdef01234567"))
+		})
+	})
+})
@@ -0,0 +1,154 @@
+package lambda
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// ServerlessLambdaEnvironment - validates the New Relic environment variables of each instrumented Lambda function
+type ServerlessLambdaEnvironment struct {
+}
+
+// EnvironmentFinding - a single misconfiguration found in a function's environment
+type EnvironmentFinding struct {
+	Function string
+	Variable string
+	Status   tasks.Status
+	Message  string
+}
+
+var accountIDRegex = regexp.MustCompile(`^[0-9]+$`)
+
+const environmentDocsURL = "https://docs.newrelic.com/docs/serverless-function-monitoring/aws-lambda-monitoring/instrument-lambda-function/env-variables-lambda/"
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p ServerlessLambdaEnvironment) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Serverless/Lambda/Environment")
+}
+
+// Explain - Returns the help text for each individual task
+func (p ServerlessLambdaEnvironment) Explain() string {
+	return "Validate New Relic account, license key and extension settings of AWS Lambda functions"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p ServerlessLambdaEnvironment) Dependencies() []string {
+	return []string{
+		"Serverless/Lambda/Detect",
+	}
+}
+
+// Execute - The core work within each task
+func (p ServerlessLambdaEnvironment) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Serverless/Lambda/Detect"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Lambda functions detected. This task did not run.",
+		}
+	}
+
+	source, ok := upstream["Serverless/Lambda/Detect"].Payload.(LambdaFunctionSource)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var instrumented []string
+	var findings []EnvironmentFinding
+	for _, function := range source.Functions {
+		if !isInstrumented(function) {
+			continue
+		}
+		instrumented = append(instrumented, function.Name)
+		findings = append(findings, validateEnvironment(function)...)
+	}
+
+	if len(instrumented) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Lambda functions instrumented with New Relic were found.",
+		}
+	}
+
+	if len(findings) == 0 {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "New Relic environment variables are correctly configured for: " + strings.Join(instrumented, ", "),
+		}
+	}
+
+	status := tasks.Warning
+	var lines []string
+	for _, finding := range findings {
+		if finding.Status == tasks.Failure {
+			status = tasks.Failure
+		}
+		lines = append(lines, finding.Function+": "+finding.Message)
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: "Issues were found in the New Relic Lambda environment variables:\n" + strings.Join(lines, "\n"),
+		URL:     environmentDocsURL,
+		Payload: findings,
+	}
+}
+
+// isInstrumented - a function is considered instrumented when it has a New Relic layer, is wrapped by the
+// serverless plugin or sets any NEW_RELIC_ variable
+func isInstrumented(function LambdaFunction) bool {
+	if function.ManagedByPlugin || len(getNewRelicLayers(function.Layers)) > 0 {
+		return true
+	}
+	for key := range function.Environment {
+		if strings.HasPrefix(key, "NEW_RELIC_") {
+			return true
+		}
+	}
+	return false
+}
+
+func validateEnvironment(function LambdaFunction) []EnvironmentFinding {
+	var findings []EnvironmentFinding
+	add := func(variable string, status tasks.Status, message string) {
+		findings = append(findings, EnvironmentFinding{Function: function.Name, Variable: variable, Status: status, Message: message})
+	}
+	env := function.Environment
+
+	accountID, hasAccountID := env["NEW_RELIC_ACCOUNT_ID"]
+	switch {
+	case !hasAccountID || accountID == "":
+		add("NEW_RELIC_ACCOUNT_ID", tasks.Failure, "NEW_RELIC_ACCOUNT_ID is not set. The agent will not send data without the account ID")
+	case !isUnresolved(accountID) && !accountIDRegex.MatchString(strings.TrimSpace(accountID)):
+		add("NEW_RELIC_ACCOUNT_ID", tasks.Failure, "NEW_RELIC_ACCOUNT_ID should be a numeric account ID, found: "+accountID)
+	}
+
+	extensionEnabled := !strings.EqualFold(env["NEW_RELIC_LAMBDA_EXTENSION_ENABLED"], "false")
+	_, hasLicenseKey := env["NEW_RELIC_LICENSE_KEY"]
+	_, hasLicenseKeySecret := env["NEW_RELIC_LICENSE_KEY_SECRET"]
+	if hasLicenseKey && !isUnresolved(env["NEW_RELIC_LICENSE_KEY"]) {
+		add("NEW_RELIC_LICENSE_KEY", tasks.Warning, "the license key is set in plain text in NEW_RELIC_LICENSE_KEY. Consider storing it in AWS Secrets Manager and referencing it with NEW_RELIC_LICENSE_KEY_SECRET")
+	}
+	if extensionEnabled && !hasLicenseKey && !hasLicenseKeySecret && !function.ManagedByPlugin {
+		add("NEW_RELIC_LICENSE_KEY_SECRET", tasks.Warning, "no license key reference found. The extension will look for the default NEW_RELIC_LICENSE_KEY secret in AWS Secrets Manager, make sure it exists and the function role can read it")
+	}
+
+	if !extensionEnabled {
+		add("NEW_RELIC_LAMBDA_EXTENSION_ENABLED", tasks.Warning, "the New Relic Lambda extension is disabled. Telemetry will only reach New Relic if CloudWatch log ingestion is configured for this function")
+	}
+	if strings.EqualFold(env["NEW_RELIC_EXTENSION_LOGS_ENABLED"], "false") {
+		add("NEW_RELIC_EXTENSION_LOGS_ENABLED", tasks.Warning, "extension logs are disabled. Set NEW_RELIC_EXTENSION_LOGS_ENABLED to true while troubleshooting")
+	}
+	if strings.EqualFold(env["NEW_RELIC_EXTENSION_SEND_FUNCTION_LOGS"], "true") && !extensionEnabled {
+		add("NEW_RELIC_EXTENSION_SEND_FUNCTION_LOGS", tasks.Warning, "NEW_RELIC_EXTENSION_SEND_FUNCTION_LOGS has no effect while the extension is disabled")
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Status > findings[j].Status
+	})
+	return findings
+}
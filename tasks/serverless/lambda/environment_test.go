@@ -0,0 +1,87 @@
+package lambda
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Serverless/Lambda/Environment", func() {
+	var p ServerlessLambdaEnvironment
+
+	layer := "arn:aws:lambda:us-east-1:451483290750:layer:NewRelicPython311:10"
+
+	Describe("Execute()", func() {
+		Context("When no function is instrumented", func() {
+			It("Should return a None result", func() {
+				source := LambdaFunctionSource{Functions: []LambdaFunction{{Name: "plain", Runtime: "python3.11", Environment: map[string]string{}}}}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the account ID and license key secret are configured", func() {
+			It("Should return a Success result", func() {
+				source := LambdaFunctionSource{Functions: []LambdaFunction{{
+					Name:        "orders",
+					Layers:      []string{layer},
+					Environment: map[string]string{"NEW_RELIC_ACCOUNT_ID": "12345", "NEW_RELIC_LICENSE_KEY_SECRET": "nr-license-key"},
+				}}}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				Expect(p.Execute(tasks.Options{}, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Success,
+					Summary: "New Relic environment variables are correctly configured for: orders",
+				}))
+			})
+		})
+
+		Context("When the account ID is missing", func() {
+			It("Should return a Failure result", func() {
+				source := LambdaFunctionSource{Functions: []LambdaFunction{{
+					Name:        "orders",
+					Layers:      []string{layer},
+					Environment: map[string]string{"NEW_RELIC_LICENSE_KEY_SECRET": "nr-license-key"},
+				}}}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("orders: NEW_RELIC_ACCOUNT_ID is not set"))
+				Expect(result.URL).To(Equal(environmentDocsURL))
+			})
+		})
+	})
+
+	Describe("validateEnvironment()", func() {
+		It("Should accept account IDs resolved at deploy time", func() {
+			function := LambdaFunction{Environment: map[string]string{"NEW_RELIC_ACCOUNT_ID": "!Ref NRAccountId", "NEW_RELIC_LICENSE_KEY_SECRET": "nr"}}
+			Expect(validateEnvironment(function)).To(BeEmpty())
+		})
+
+		It("Should flag a missing license key secret reference", func() {
+			function := LambdaFunction{Environment: map[string]string{"NEW_RELIC_ACCOUNT_ID": "12345"}}
+			findings := validateEnvironment(function)
+			Expect(findings).To(HaveLen(1))
+			Expect(findings[0].Variable).To(Equal("NEW_RELIC_LICENSE_KEY_SECRET"))
+		})
+
+		It("Should flag disabled extension logs", func() {
+			function := LambdaFunction{Environment: map[string]string{"NEW_RELIC_ACCOUNT_ID": "12345", "NEW_RELIC_LICENSE_KEY_SECRET": "nr", "NEW_RELIC_EXTENSION_LOGS_ENABLED": "false"}}
+			findings := validateEnvironment(function)
+			Expect(findings).To(HaveLen(1))
+			Expect(findings[0].Variable).To(Equal("NEW_RELIC_EXTENSION_LOGS_ENABLED"))
+		})
+
+		It("Should flag a non numeric account ID, a plain text license key and a disabled extension", func() {
+			function := LambdaFunction{Environment: map[string]string{
+				"NEW_RELIC_ACCOUNT_ID":               "my-account",
+				"NEW_RELIC_LICENSE_KEY":              "0123456789abcdef0123456789abcdef01234567",
+				"NEW_RELIC_LAMBDA_EXTENSION_ENABLED": "false",
+			}}
+			var variables []string
+			for _, finding := range validateEnvironment(function) {
+				variables = append(variables, finding.Variable)
+			}
+			Expect(variables).To(Equal([]string{"NEW_RELIC_ACCOUNT_ID", "NEW_RELIC_LICENSE_KEY", "NEW_RELIC_LAMBDA_EXTENSION_ENABLED"}))
+		})
+	})
+})
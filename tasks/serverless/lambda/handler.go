@@ -0,0 +1,221 @@
+package lambda
+
+import (
+	"path"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// ServerlessLambdaHandler - validates the New Relic handler wrapper configuration of each Lambda function
+type ServerlessLambdaHandler struct {
+}
+
+// HandlerFinding - the handler wrapper evaluation of a single Lambda function
+type HandlerFinding struct {
+	Function        string
+	Handler         string
+	OriginalHandler string
+	Status          tasks.Status
+	Message         string
+}
+
+// newRelicHandlerWrappers - handler values of the wrappers shipped in the New Relic layers, by runtime prefix.
+// Runtimes that are not listed here (dotnet, go, provided) are instrumented without a wrapper.
+var newRelicHandlerWrappers = map[string][]string{
+	"nodejs": {"newrelic-lambda-wrapper.handler", "/opt/nodejs/node_modules/newrelic-lambda-wrapper.handler", "/opt/nodejs/node_modules/newrelic-esm-lambda-wrapper/index.handler"},
+	"python": {"newrelic_lambda_wrapper.handler"},
+	"ruby":   {"newrelic_lambda_wrapper.handler"},
+	"java":   {"com.newrelic.java.HandlerWrapper::handleRequest", "com.newrelic.java.HandlerWrapper::handleStreamsRequest"},
+}
+
+// handlerModuleExtensions - source file extensions a handler module can resolve to, by runtime prefix
+var handlerModuleExtensions = map[string][]string{
+	"nodejs": {".js", ".mjs", ".cjs"},
+	"python": {".py"},
+	"ruby":   {".rb"},
+}
+
+const handlerDocsURL = "https://docs.newrelic.com/docs/serverless-function-monitoring/aws-lambda-monitoring/instrument-lambda-function/env-variables-lambda/"
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p ServerlessLambdaHandler) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Serverless/Lambda/Handler")
+}
+
+// Explain - Returns the help text for each individual task
+func (p ServerlessLambdaHandler) Explain() string {
+	return "Validate New Relic handler wrapper configuration of AWS Lambda functions"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p ServerlessLambdaHandler) Dependencies() []string {
+	return []string{
+		"Serverless/Lambda/Detect",
+	}
+}
+
+// Execute - The core work within each task
+func (p ServerlessLambdaHandler) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Serverless/Lambda/Detect"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Lambda functions detected. This task did not run.",
+		}
+	}
+
+	source, ok := upstream["Serverless/Lambda/Detect"].Payload.(LambdaFunctionSource)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var findings []HandlerFinding
+	for _, function := range source.Functions {
+		findings = append(findings, evaluateHandler(function, source))
+	}
+
+	return summarizeHandlerFindings(findings)
+}
+
+func evaluateHandler(function LambdaFunction, source LambdaFunctionSource) HandlerFinding {
+	finding := HandlerFinding{
+		Function:        function.Name,
+		Handler:         function.Handler,
+		OriginalHandler: function.Environment["NEW_RELIC_LAMBDA_HANDLER"],
+		Status:          tasks.Success,
+	}
+
+	if function.ManagedByPlugin {
+		finding.Message = "handler is wrapped at deploy time by the " + newRelicPlugin + " plugin"
+		return finding
+	}
+
+	runtimeFamily := getRuntimeFamily(function.Runtime)
+	wrappers, usesWrapper := newRelicHandlerWrappers[runtimeFamily]
+	if !usesWrapper {
+		finding.Status = tasks.None
+		finding.Message = "runtime " + function.Runtime + " is instrumented without a handler wrapper"
+		return finding
+	}
+
+	isWrapped := tasks.ContainsString(wrappers, function.Handler)
+	hasNewRelicLayer := len(getNewRelicLayers(function.Layers)) > 0
+
+	if !isWrapped {
+		if finding.OriginalHandler != "" {
+			finding.Status = tasks.Failure
+			finding.Message = "NEW_RELIC_LAMBDA_HANDLER is set but the function handler is " + function.Handler + " instead of the New Relic wrapper " + wrappers[0]
+			return finding
+		}
+		if hasNewRelicLayer {
+			finding.Status = tasks.Warning
+			finding.Message = "a New Relic layer is attached but the handler is not the New Relic wrapper " + wrappers[0] + ". The function is only instrumented if it wraps its handler manually"
+			return finding
+		}
+		finding.Status = tasks.None
+		finding.Message = "function is not instrumented with a New Relic layer"
+		return finding
+	}
+
+	if finding.OriginalHandler == "" {
+		finding.Status = tasks.Failure
+		finding.Message = "the handler is the New Relic wrapper but NEW_RELIC_LAMBDA_HANDLER is not set to the original handler"
+		return finding
+	}
+	if !hasNewRelicLayer {
+		finding.Status = tasks.Failure
+		finding.Message = "the handler is the New Relic wrapper but no New Relic layer is attached to provide it"
+		return finding
+	}
+	if isUnresolved(finding.OriginalHandler) {
+		finding.Message = "NEW_RELIC_LAMBDA_HANDLER is resolved at deploy time"
+		return finding
+	}
+
+	if module, found := findHandlerModule(finding.OriginalHandler, runtimeFamily, source); !found {
+		finding.Status = tasks.Failure
+		finding.Message = "NEW_RELIC_LAMBDA_HANDLER points to " + finding.OriginalHandler + " but " + module + " was not found in the function package"
+		return finding
+	}
+
+	finding.Message = "handler is wrapped and NEW_RELIC_LAMBDA_HANDLER is set to " + finding.OriginalHandler
+	return finding
+}
+
+// findHandlerModule - checks that the module of a handler such as "src/app.handler" exists in the package.
+// Returns the module that was looked for and true when it was found or could not be checked.
+func findHandlerModule(handler string, runtimeFamily string, source LambdaFunctionSource) (string, bool) {
+	extensions, ok := handlerModuleExtensions[runtimeFamily]
+	if !ok || len(source.Files) == 0 {
+		return "", true
+	}
+	lastDot := strings.LastIndex(handler, ".")
+	if lastDot <= 0 {
+		return handler, false
+	}
+	modulePath := handler[:lastDot]
+	// Python handlers can use dots as package separators: package.module.handler
+	candidates := []string{modulePath}
+	if runtimeFamily == "python" {
+		candidates = append(candidates, strings.ReplaceAll(modulePath, ".", "/"))
+	}
+
+	for _, candidate := range candidates {
+		for _, extension := range extensions {
+			fileName := path.Clean(candidate + extension)
+			for _, file := range source.Files {
+				// Templates can live one level above the code (CodeUri: src/)
+				if file == fileName || strings.HasSuffix(file, "/"+fileName) {
+					return fileName, true
+				}
+			}
+		}
+	}
+	return modulePath + extensions[0], false
+}
+
+func getRuntimeFamily(runtime string) string {
+	for _, family := range []string{"nodejs", "python", "ruby", "java", "dotnet", "go", "provided"} {
+		if strings.HasPrefix(runtime, family) {
+			return family
+		}
+	}
+	return runtime
+}
+
+func summarizeHandlerFindings(findings []HandlerFinding) tasks.Result {
+	status := tasks.None
+	var lines []string
+	for _, finding := range findings {
+		if finding.Status == tasks.None {
+			continue
+		}
+		if finding.Status > status {
+			status = finding.Status
+		}
+		lines = append(lines, finding.Function+": "+finding.Message)
+	}
+
+	if status == tasks.None {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Lambda functions using a New Relic handler wrapper were found.",
+			Payload: findings,
+		}
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Payload: findings,
+	}
+	if status == tasks.Success {
+		result.Summary = "New Relic handler wrappers are correctly configured:\n" + strings.Join(lines, "\n")
+		return result
+	}
+	result.Summary = "Issues were found with the New Relic handler wrapper configuration:\n" + strings.Join(lines, "\n")
+	result.URL = handlerDocsURL
+	return result
+}
@@ -0,0 +1,82 @@
+package lambda
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Serverless/Lambda/Handler", func() {
+	var p ServerlessLambdaHandler
+
+	pythonLayer := "arn:aws:lambda:us-east-1:451483290750:layer:NewRelicPython311:10"
+
+	Describe("Execute()", func() {
+		Context("When no functions were detected", func() {
+			It("Should return a None result", func() {
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.None}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the upstream payload has an unexpected type", func() {
+			It("Should return an Error result", func() {
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: "function"}}
+				Expect(p.Execute(tasks.Options{}, upstream)).To(Equal(tasks.Result{
+					Status:  tasks.Error,
+					Summary: tasks.AssertionErrorSummary,
+				}))
+			})
+		})
+
+		Context("When one wrapped function points to a missing module", func() {
+			It("Should return a Failure naming the missing module", func() {
+				source := LambdaFunctionSource{
+					Files: []string{"template.yaml", "src/orders.py"},
+					Functions: []LambdaFunction{
+						{Name: "Orders", Runtime: "python3.11", Handler: "newrelic_lambda_wrapper.handler", Layers: []string{pythonLayer}, Environment: map[string]string{"NEW_RELIC_LAMBDA_HANDLER": "orders.handler"}},
+						{Name: "Payments", Runtime: "python3.11", Handler: "newrelic_lambda_wrapper.handler", Layers: []string{pythonLayer}, Environment: map[string]string{"NEW_RELIC_LAMBDA_HANDLER": "payments.handler"}},
+					},
+				}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("Payments: NEW_RELIC_LAMBDA_HANDLER points to payments.handler but payments.py was not found"))
+				Expect(result.URL).To(Equal(handlerDocsURL))
+			})
+		})
+	})
+
+	Describe("evaluateHandler()", func() {
+		It("Should fail when the wrapper is used without NEW_RELIC_LAMBDA_HANDLER", func() {
+			function := LambdaFunction{Runtime: "nodejs20.x", Handler: "newrelic-lambda-wrapper.handler", Layers: []string{"arn:aws:lambda:us-east-1:451483290750:layer:NewRelicNodeJS20X:5"}, Environment: map[string]string{}}
+			Expect(evaluateHandler(function, LambdaFunctionSource{}).Status).To(Equal(tasks.Failure))
+		})
+
+		It("Should fail when NEW_RELIC_LAMBDA_HANDLER is set but the handler was not replaced", func() {
+			function := LambdaFunction{Runtime: "python3.11", Handler: "orders.handler", Layers: []string{pythonLayer}, Environment: map[string]string{"NEW_RELIC_LAMBDA_HANDLER": "orders.handler"}}
+			Expect(evaluateHandler(function, LambdaFunctionSource{}).Status).To(Equal(tasks.Failure))
+		})
+
+		It("Should warn when a New Relic layer is attached without the wrapper", func() {
+			function := LambdaFunction{Runtime: "python3.11", Handler: "orders.handler", Layers: []string{pythonLayer}, Environment: map[string]string{}}
+			Expect(evaluateHandler(function, LambdaFunctionSource{}).Status).To(Equal(tasks.Warning))
+		})
+
+		It("Should skip functions managed by the serverless plugin", func() {
+			function := LambdaFunction{Runtime: "nodejs20.x", Handler: "handlers/cart.handler", ManagedByPlugin: true}
+			Expect(evaluateHandler(function, LambdaFunctionSource{}).Status).To(Equal(tasks.Success))
+		})
+
+		It("Should resolve python package handlers and code under CodeUri", func() {
+			function := LambdaFunction{Runtime: "python3.12", Handler: "newrelic_lambda_wrapper.handler", Layers: []string{pythonLayer}, Environment: map[string]string{"NEW_RELIC_LAMBDA_HANDLER": "api.views.handler"}}
+			source := LambdaFunctionSource{Files: []string{"src/api/views.py"}}
+			Expect(evaluateHandler(function, source).Status).To(Equal(tasks.Success))
+		})
+
+		It("Should not evaluate runtimes that do not use a wrapper", func() {
+			function := LambdaFunction{Runtime: "dotnet8", Handler: "Orders::Orders.Function::Handler"}
+			Expect(evaluateHandler(function, LambdaFunctionSource{}).Status).To(Equal(tasks.None))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package lambda
+
+import (
+	"os"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Serverless/Lambda/*")
+
+	registrationFunc(ServerlessLambdaDetect{
+		fileReader: os.ReadFile,
+	}, true)
+	registrationFunc(ServerlessLambdaHandler{}, true)
+	registrationFunc(ServerlessLambdaEnvironment{}, true)
+	registrationFunc(ServerlessLambdaLayers{
+		layerRuntimes:      compatibilityVars.LambdaLayerRuntimes,
+		deprecatedRuntimes: compatibilityVars.LambdaDeprecatedRuntimes,
+	}, true)
+}
+
+// LambdaFunction - a Lambda function declared in a deployment template or function configuration
+type LambdaFunction struct {
+	Name          string
+	Source        string
+	Runtime       string
+	Handler       string
+	Architectures []string
+	Layers        []string
+	Environment   map[string]string
+	// ManagedByPlugin is true when the serverless-newrelic-lambda-layers plugin wraps the function at deploy time
+	ManagedByPlugin bool
+}
+
+// LambdaFunctionSource - the unpacked function directory or deployment zip inspected by Serverless/Lambda/Detect
+type LambdaFunctionSource struct {
+	Path      string
+	IsArchive bool
+	Templates []string
+	// Files are the package entries used to find handler modules, they are left out of nrdiag-output.json since a package can hold thousands of them
+	Files     []string `json:"-"`
+	Functions []LambdaFunction
+}
+
+// HasFile - returns true if the function package contains the given relative path
+func (s LambdaFunctionSource) HasFile(name string) bool {
+	return tasks.ContainsString(s.Files, name)
+}
@@ -0,0 +1,205 @@
+package lambda
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// ServerlessLambdaLayers - checks the New Relic layers attached to each function against the bundled compatibility table
+type ServerlessLambdaLayers struct {
+	layerRuntimes      map[string][]string
+	deprecatedRuntimes []string
+}
+
+// LambdaLayer - a parsed Lambda layer version ARN
+type LambdaLayer struct {
+	Arn     string
+	Region  string
+	Account string
+	Name    string
+	Version int
+	ARM64   bool
+}
+
+// LayerFinding - the layer compatibility evaluation of a single Lambda function
+type LayerFinding struct {
+	Function string
+	Runtime  string
+	Layers   []LambdaLayer
+	Status   tasks.Status
+	Messages []string
+}
+
+var layerArnRegex = regexp.MustCompile(`^arn:aws[a-zA-Z-]*:lambda:([^:]+):([^:]+):layer:([^:]+):([0-9]+)$`)
+
+const layersDocsURL = "https://layers.newrelic-external.com/"
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p ServerlessLambdaLayers) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Serverless/Lambda/Layers")
+}
+
+// Explain - Returns the help text for each individual task
+func (p ServerlessLambdaLayers) Explain() string {
+	return "Check New Relic Lambda layer compatibility with function runtimes and architectures"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p ServerlessLambdaLayers) Dependencies() []string {
+	return []string{
+		"Serverless/Lambda/Detect",
+	}
+}
+
+// Execute - The core work within each task
+func (p ServerlessLambdaLayers) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Serverless/Lambda/Detect"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Lambda functions detected. This task did not run.",
+		}
+	}
+
+	source, ok := upstream["Serverless/Lambda/Detect"].Payload.(LambdaFunctionSource)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var findings []LayerFinding
+	status := tasks.None
+	var lines []string
+	for _, function := range source.Functions {
+		layerArns := getNewRelicLayers(function.Layers)
+		if len(layerArns) == 0 {
+			continue
+		}
+		finding := p.evaluateLayers(function, layerArns)
+		findings = append(findings, finding)
+		if finding.Status > status {
+			status = finding.Status
+		}
+		for _, message := range finding.Messages {
+			lines = append(lines, function.Name+": "+message)
+		}
+	}
+
+	if len(findings) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic Lambda layers found in the detected functions.",
+		}
+	}
+
+	if status == tasks.Success {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "New Relic Lambda layers are compatible with the runtime and architecture of every function.",
+			Payload: findings,
+		}
+	}
+
+	return tasks.Result{
+		Status:  status,
+		Summary: "Issues were found with the New Relic Lambda layers:\n" + strings.Join(lines, "\n"),
+		URL:     layersDocsURL,
+		Payload: findings,
+	}
+}
+
+func (p ServerlessLambdaLayers) evaluateLayers(function LambdaFunction, layerArns []string) LayerFinding {
+	finding := LayerFinding{
+		Function: function.Name,
+		Runtime:  function.Runtime,
+		Status:   tasks.Success,
+	}
+	flag := func(status tasks.Status, message string) {
+		if status > finding.Status {
+			finding.Status = status
+		}
+		finding.Messages = append(finding.Messages, message)
+	}
+
+	isARM64Function := tasks.ContainsString(function.Architectures, "arm64")
+	runtimeKnown := function.Runtime != "" && !isUnresolved(function.Runtime)
+	runtimeDeprecated := runtimeKnown && tasks.ContainsString(p.deprecatedRuntimes, function.Runtime)
+
+	if runtimeDeprecated {
+		flag(tasks.Warning, "runtime "+function.Runtime+" is deprecated by AWS and no longer receives New Relic layer updates")
+	}
+
+	for _, arn := range layerArns {
+		layer, err := parseLayerArn(arn)
+		if err != nil {
+			if !isUnresolved(arn) {
+				flag(tasks.Warning, "unable to parse layer ARN "+arn)
+			}
+			continue
+		}
+		finding.Layers = append(finding.Layers, layer)
+
+		if layer.ARM64 != isARM64Function {
+			flag(tasks.Failure, "layer "+layer.Name+" is built for "+architectureName(layer.ARM64)+" but the function runs on "+architectureName(isARM64Function))
+		}
+
+		supportedRuntimes, known := p.layerRuntimes[layer.Name]
+		if !known {
+			// the retired layers of deprecated runtimes are already reported with the runtime
+			if !runtimeDeprecated {
+				flag(tasks.Warning, "layer "+layer.Name+" is not in the list of known New Relic layers")
+			}
+			continue
+		}
+		if runtimeKnown && !tasks.ContainsString(supportedRuntimes, "*") && !tasks.ContainsString(supportedRuntimes, function.Runtime) {
+			flag(tasks.Failure, "layer "+layer.Name+" supports "+strings.Join(supportedRuntimes, ", ")+" but the function runtime is "+function.Runtime)
+		}
+	}
+
+	if len(finding.Layers) > 1 {
+		flag(tasks.Warning, "more than one New Relic layer is attached. Each runtime layer already bundles the extension, attach only one")
+	}
+	return finding
+}
+
+// parseLayerArn - parses arn:aws:lambda:<region>:<account>:layer:<name>:<version>. !Sub templates are accepted.
+func parseLayerArn(arn string) (LambdaLayer, error) {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(arn, "!Sub "))
+	matches := layerArnRegex.FindStringSubmatch(trimmed)
+	if matches == nil {
+		return LambdaLayer{}, errInvalidLayerArn
+	}
+	version, _ := strconv.Atoi(matches[4])
+	name := matches[3]
+	isARM64 := strings.HasSuffix(name, "ARM64")
+	return LambdaLayer{
+		Arn:     trimmed,
+		Region:  matches[1],
+		Account: matches[2],
+		Name:    strings.TrimSuffix(name, "ARM64"),
+		Version: version,
+		ARM64:   isARM64,
+	}, nil
+}
+
+// getNewRelicLayers - returns the layers published by New Relic, identified by their NewRelic name prefix
+func getNewRelicLayers(layers []string) []string {
+	var newRelicLayers []string
+	for _, layer := range layers {
+		if strings.Contains(layer, ":layer:NewRelic") {
+			newRelicLayers = append(newRelicLayers, layer)
+		}
+	}
+	return newRelicLayers
+}
+
+func architectureName(isARM64 bool) string {
+	if isARM64 {
+		return "arm64"
+	}
+	return "x86_64"
+}
@@ -0,0 +1,118 @@
+package lambda
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Serverless/Lambda/Layers", func() {
+	var p ServerlessLambdaLayers
+
+	BeforeEach(func() {
+		p = ServerlessLambdaLayers{
+			layerRuntimes:      compatibilityVars.LambdaLayerRuntimes,
+			deprecatedRuntimes: compatibilityVars.LambdaDeprecatedRuntimes,
+		}
+	})
+
+	Describe("Execute()", func() {
+		Context("When the layers match the function runtime and architecture", func() {
+			It("Should return a Success result", func() {
+				source := LambdaFunctionSource{Functions: []LambdaFunction{{
+					Name:    "orders",
+					Runtime: "python3.11",
+					Layers:  []string{"!Sub arn:${AWS::Partition}:lambda:${AWS::Region}:451483290750:layer:NewRelicPython311:10"},
+				}}}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Success))
+			})
+		})
+
+		Context("When the function has no New Relic layers", func() {
+			It("Should return a None result", func() {
+				source := LambdaFunctionSource{Functions: []LambdaFunction{{Name: "orders", Runtime: "python3.11"}}}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the layer targets another runtime and architecture", func() {
+			It("Should return a Failure result", func() {
+				source := LambdaFunctionSource{Functions: []LambdaFunction{{
+					Name:          "inventory",
+					Runtime:       "nodejs18.x",
+					Architectures: []string{"x86_64"},
+					Layers: []string{
+						"arn:aws:lambda:eu-west-1:451483290750:layer:NewRelicNodeJS20XARM64:4",
+						"arn:aws:lambda:eu-west-1:451483290750:layer:NewRelicLambdaExtension:30",
+					},
+				}}}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("layer NewRelicNodeJS20X is built for arm64 but the function runs on x86_64"))
+				Expect(result.Summary).To(ContainSubstring("layer NewRelicNodeJS20X supports nodejs20.x but the function runtime is nodejs18.x"))
+				Expect(result.Summary).To(ContainSubstring("more than one New Relic layer is attached"))
+				Expect(result.URL).To(Equal(layersDocsURL))
+			})
+		})
+
+		Context("When the runtime is deprecated", func() {
+			It("Should return a Warning result", func() {
+				source := LambdaFunctionSource{Functions: []LambdaFunction{{
+					Name:    "legacy",
+					Runtime: "nodejs14.x",
+					Layers:  []string{"arn:aws:lambda:us-east-1:451483290750:layer:NewRelicLambdaExtension:30"},
+				}}}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				Expect(p.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.Warning))
+			})
+
+			It("Should only report the deprecated runtime for the layer New Relic published for it", func() {
+				source := LambdaFunctionSource{Functions: []LambdaFunction{{
+					Name:    "legacy",
+					Runtime: "nodejs16.x",
+					Layers:  []string{"arn:aws:lambda:us-east-1:451483290750:layer:NewRelicNodeJS16X:12"},
+				}}}
+				upstream := map[string]tasks.Result{"Serverless/Lambda/Detect": {Status: tasks.Info, Payload: source}}
+				result := p.Execute(tasks.Options{}, upstream)
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("runtime nodejs16.x is deprecated by AWS"))
+				Expect(result.Summary).ToNot(ContainSubstring("not in the list of known New Relic layers"))
+			})
+		})
+	})
+
+	Describe("compatibilityVars.LambdaLayerRuntimes", func() {
+		It("Should not list deprecated runtimes as supported", func() {
+			for layer, runtimes := range compatibilityVars.LambdaLayerRuntimes {
+				for _, runtime := range runtimes {
+					Expect(compatibilityVars.LambdaDeprecatedRuntimes).ToNot(ContainElement(runtime), "layer "+layer)
+				}
+			}
+		})
+	})
+
+	Describe("parseLayerArn()", func() {
+		It("Should parse region, account, name, version and architecture", func() {
+			layer, err := parseLayerArn("arn:aws:lambda:us-west-2:451483290750:layer:NewRelicPython312ARM64:7")
+			Expect(err).To(BeNil())
+			Expect(layer).To(Equal(LambdaLayer{
+				Arn:     "arn:aws:lambda:us-west-2:451483290750:layer:NewRelicPython312ARM64:7",
+				Region:  "us-west-2",
+				Account: "451483290750",
+				Name:    "NewRelicPython312",
+				Version: 7,
+				ARM64:   true,
+			}))
+		})
+
+		It("Should reject layers without a version", func() {
+			_, err := parseLayerArn("arn:aws:lambda:us-west-2:451483290750:layer:NewRelicPython312")
+			Expect(err).To(Equal(errInvalidLayerArn))
+		})
+	})
+})
@@ -0,0 +1,303 @@
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const newRelicPlugin = "serverless-newrelic-lambda-layers"
+
+var lambdaResourceTypes = []string{"AWS::Serverless::Function", "AWS::Lambda::Function"}
+
+var (
+	errNotATemplate    = errors.New("file does not declare any Lambda functions")
+	errInvalidLayerArn = errors.New("invalid Lambda layer ARN")
+)
+
+// parseTemplate - parses a SAM/CloudFormation template, a serverless.yml or the output of
+// 'aws lambda get-function-configuration' and returns the Lambda functions declared in it.
+// JSON is valid YAML so a single parser covers every format.
+func parseTemplate(name string, content []byte) ([]LambdaFunction, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	root, ok := nodeToValue(&document).(map[string]interface{})
+	if !ok {
+		return nil, errNotATemplate
+	}
+
+	var functions []LambdaFunction
+	switch {
+	case root["Resources"] != nil:
+		functions = parseCloudFormation(name, root)
+	case root["functions"] != nil:
+		functions = parseServerlessFramework(name, root)
+	case root["Configuration"] != nil:
+		configuration, _ := root["Configuration"].(map[string]interface{})
+		functions = parseFunctionConfiguration(name, configuration)
+	case root["FunctionName"] != nil:
+		functions = parseFunctionConfiguration(name, root)
+	}
+
+	if len(functions) == 0 {
+		return nil, errNotATemplate
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		return functions[i].Name < functions[j].Name
+	})
+	return functions, nil
+}
+
+func parseCloudFormation(name string, root map[string]interface{}) []LambdaFunction {
+	defaults := LambdaFunction{Environment: map[string]string{}}
+	if globals, ok := root["Globals"].(map[string]interface{}); ok {
+		if globalFunction, ok := globals["Function"].(map[string]interface{}); ok {
+			applyFunctionProperties(&defaults, globalFunction)
+		}
+	}
+
+	resources, _ := root["Resources"].(map[string]interface{})
+	var functions []LambdaFunction
+	for logicalID, rawResource := range resources {
+		resource, ok := rawResource.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resourceType := stringValue(resource["Type"])
+		isSAM := resourceType == lambdaResourceTypes[0]
+		if !isSAM && resourceType != lambdaResourceTypes[1] {
+			continue
+		}
+		function := LambdaFunction{Name: logicalID, Source: name, Environment: map[string]string{}}
+		// Globals only apply to AWS::Serverless::Function resources
+		if isSAM {
+			function = copyFunction(defaults)
+			function.Name = logicalID
+			function.Source = name
+		}
+		if properties, ok := resource["Properties"].(map[string]interface{}); ok {
+			applyFunctionProperties(&function, properties)
+		}
+		functions = append(functions, function)
+	}
+	return functions
+}
+
+func applyFunctionProperties(function *LambdaFunction, properties map[string]interface{}) {
+	if runtime := stringValue(properties["Runtime"]); runtime != "" {
+		function.Runtime = runtime
+	}
+	if handler := stringValue(properties["Handler"]); handler != "" {
+		function.Handler = handler
+	}
+	if architectures := stringSlice(properties["Architectures"]); len(architectures) > 0 {
+		function.Architectures = architectures
+	}
+	if layers := stringSlice(properties["Layers"]); len(layers) > 0 {
+		function.Layers = layers
+	}
+	if environment, ok := properties["Environment"].(map[string]interface{}); ok {
+		for key, value := range stringMap(environment["Variables"]) {
+			function.Environment[key] = value
+		}
+	}
+}
+
+func parseServerlessFramework(name string, root map[string]interface{}) []LambdaFunction {
+	defaults := LambdaFunction{Environment: map[string]string{}}
+	if provider, ok := root["provider"].(map[string]interface{}); ok {
+		applyServerlessProperties(&defaults, provider)
+	}
+
+	for _, plugin := range stringSlice(root["plugins"]) {
+		if plugin == newRelicPlugin {
+			defaults.ManagedByPlugin = true
+		}
+	}
+	if custom, ok := root["custom"].(map[string]interface{}); ok {
+		if newRelic, ok := custom["newRelic"].(map[string]interface{}); ok {
+			if accountID := stringValue(newRelic["accountId"]); accountID != "" {
+				if _, isSet := defaults.Environment["NEW_RELIC_ACCOUNT_ID"]; !isSet {
+					defaults.Environment["NEW_RELIC_ACCOUNT_ID"] = accountID
+				}
+			}
+			if stringValue(newRelic["enableExtension"]) == "false" {
+				defaults.Environment["NEW_RELIC_LAMBDA_EXTENSION_ENABLED"] = "false"
+			}
+		}
+	}
+
+	declared, _ := root["functions"].(map[string]interface{})
+	var functions []LambdaFunction
+	for functionName, rawFunction := range declared {
+		function := copyFunction(defaults)
+		function.Name = functionName
+		function.Source = name
+		if properties, ok := rawFunction.(map[string]interface{}); ok {
+			applyServerlessProperties(&function, properties)
+		}
+		functions = append(functions, function)
+	}
+	return functions
+}
+
+func applyServerlessProperties(function *LambdaFunction, properties map[string]interface{}) {
+	if runtime := stringValue(properties["runtime"]); runtime != "" {
+		function.Runtime = runtime
+	}
+	if handler := stringValue(properties["handler"]); handler != "" {
+		function.Handler = handler
+	}
+	if architecture := stringValue(properties["architecture"]); architecture != "" {
+		function.Architectures = []string{architecture}
+	}
+	if layers := stringSlice(properties["layers"]); len(layers) > 0 {
+		function.Layers = layers
+	}
+	for key, value := range stringMap(properties["environment"]) {
+		function.Environment[key] = value
+	}
+}
+
+func parseFunctionConfiguration(name string, configuration map[string]interface{}) []LambdaFunction {
+	if configuration == nil || stringValue(configuration["Runtime"]) == "" {
+		return nil
+	}
+	function := LambdaFunction{
+		Name:          stringValue(configuration["FunctionName"]),
+		Source:        name,
+		Runtime:       stringValue(configuration["Runtime"]),
+		Handler:       stringValue(configuration["Handler"]),
+		Architectures: stringSlice(configuration["Architectures"]),
+		Environment:   map[string]string{},
+	}
+	// get-function-configuration returns layers as objects: [{"Arn": "...", "CodeSize": 123}]
+	if layers, ok := configuration["Layers"].([]interface{}); ok {
+		for _, layer := range layers {
+			if layerObject, ok := layer.(map[string]interface{}); ok {
+				function.Layers = append(function.Layers, stringValue(layerObject["Arn"]))
+				continue
+			}
+			function.Layers = append(function.Layers, stringValue(layer))
+		}
+	}
+	if environment, ok := configuration["Environment"].(map[string]interface{}); ok {
+		function.Environment = stringMap(environment["Variables"])
+	}
+	return []LambdaFunction{function}
+}
+
+func copyFunction(function LambdaFunction) LambdaFunction {
+	copied := function
+	copied.Architectures = append([]string{}, function.Architectures...)
+	copied.Layers = append([]string{}, function.Layers...)
+	copied.Environment = map[string]string{}
+	for key, value := range function.Environment {
+		copied.Environment[key] = value
+	}
+	return copied
+}
+
+// nodeToValue - converts a yaml.Node into maps, slices and strings. CloudFormation short form
+// intrinsic functions (!Ref, !Sub, ...) are kept as "!Ref Value" so they can be told apart from literals.
+func nodeToValue(node *yaml.Node) interface{} {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return nodeToValue(node.Content[0])
+	case yaml.AliasNode:
+		return nodeToValue(node.Alias)
+	case yaml.MappingNode:
+		mapping := make(map[string]interface{})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			mapping[node.Content[i].Value] = nodeToValue(node.Content[i+1])
+		}
+		return tagged(node.Tag, mapping)
+	case yaml.SequenceNode:
+		sequence := []interface{}{}
+		for _, child := range node.Content {
+			sequence = append(sequence, nodeToValue(child))
+		}
+		return tagged(node.Tag, sequence)
+	default:
+		if isIntrinsicTag(node.Tag) {
+			return node.Tag + " " + node.Value
+		}
+		return node.Value
+	}
+}
+
+func isIntrinsicTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!")
+}
+
+func tagged(tag string, value interface{}) interface{} {
+	if isIntrinsicTag(tag) {
+		return map[string]interface{}{tag: value}
+	}
+	return value
+}
+
+// stringValue - flattens a template value into a string. Intrinsic functions in either the short
+// (!Ref X) or long ({"Ref": "X"}, {"Fn::Sub": "..."}) form are returned as "!Ref X"/"!Sub ...".
+func stringValue(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case map[string]interface{}:
+		for key, inner := range typed {
+			function := strings.TrimPrefix(key, "Fn::")
+			if !strings.HasPrefix(function, "!") {
+				function = "!" + function
+			}
+			return function + " " + stringValue(inner)
+		}
+		return ""
+	case []interface{}:
+		var parts []string
+		for _, inner := range typed {
+			parts = append(parts, stringValue(inner))
+		}
+		return strings.Join(parts, " ")
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+func stringSlice(value interface{}) []string {
+	var values []string
+	switch typed := value.(type) {
+	case []interface{}:
+		for _, inner := range typed {
+			values = append(values, stringValue(inner))
+		}
+	case string:
+		values = append(values, typed)
+	}
+	return values
+}
+
+func stringMap(value interface{}) map[string]string {
+	values := map[string]string{}
+	if typed, ok := value.(map[string]interface{}); ok {
+		for key, inner := range typed {
+			values[key] = stringValue(inner)
+		}
+	}
+	return values
+}
+
+// isUnresolved - returns true for values that are only known at deploy time: intrinsic functions,
+// serverless framework variables (${ssm:...}) and SAM parameter substitutions
+func isUnresolved(value string) bool {
+	return strings.HasPrefix(value, "!") || strings.Contains(value, "${")
+}
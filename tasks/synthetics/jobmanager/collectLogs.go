@@ -0,0 +1,94 @@
+package jobmanager
+
+import (
+	"fmt"
+	"regexp"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// SyntheticsJobManagerCollectLogs - collects the logs of the job managers and runtimes found
+type SyntheticsJobManagerCollectLogs struct {
+	executeCommand tasks.BufferedCommandExecFunc
+}
+
+// the private location key and the secrets of the variables can be printed by the job manager at startup
+var secretLogRegex = regexp.MustCompile(`NRSP-[0-9A-Za-z]+|((?:VSE_PASSPHRASE|HORDE_API_PROXY_PASSWORD|PRIVATE_LOCATION_KEY)\s*[=:]\s*)\S+`)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerCollectLogs) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/CollectLogs")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerCollectLogs) Explain() string {
+	return "Collect logs of found Synthetics Job Managers and their runtimes"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerCollectLogs) Dependencies() []string {
+	return []string{"Synthetics/JobManager/Detect"}
+}
+
+// Execute - The core work within each task
+func (p SyntheticsJobManagerCollectLogs) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Synthetics/JobManager/Detect"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics Job Manager detected to collect logs from",
+		}
+	}
+	detection, ok := upstream["Synthetics/JobManager/Detect"].Payload.(JobManagerDetection)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var fileCopyEnvelopes []tasks.FileCopyEnvelope
+	var errors []string
+	for _, container := range detection.Containers {
+		name, args := container.Platform, []string{"logs", container.ID}
+		if container.Platform == platformKubernetes {
+			name, args = "kubectl", []string{"logs", "-n", container.Namespace, container.ID, "--all-containers"}
+		}
+		// same as tasks.StreamContainerLogsById, the command is started before the file is copied
+		scanner, err := p.executeCommand(0, name, args...)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Error collecting logs from %s: %s", container.Name, err.Error()))
+			continue
+		}
+		stream := make(chan string)
+		go func() {
+			defer close(stream)
+			for scanner.Scan() {
+				stream <- secretLogRegex.ReplaceAllString(scanner.Text(), "${1}_REDACTED_") + "\n"
+			}
+		}()
+		log.Debugf("Collecting logs of %s %s\n", container.Platform, container.Name)
+		fileCopyEnvelopes = append(fileCopyEnvelopes, tasks.FileCopyEnvelope{
+			Path:       fmt.Sprintf("%s-%s.log", container.Platform, container.Name),
+			Stream:     stream,
+			Identifier: p.Identifier().String(),
+			Redacted:   true,
+		})
+	}
+
+	if len(errors) > 0 && len(fileCopyEnvelopes) == 0 {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: fmt.Sprint(errors),
+		}
+	}
+	summary := fmt.Sprintf("Logs from %d Synthetics Job Manager and runtime container(s) collected", len(fileCopyEnvelopes))
+	for _, err := range errors {
+		summary += "\n" + err
+	}
+	return tasks.Result{
+		Status:      tasks.Success,
+		Summary:     summary,
+		FilesToCopy: fileCopyEnvelopes,
+	}
+}
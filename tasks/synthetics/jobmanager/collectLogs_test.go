@@ -0,0 +1,59 @@
+package jobmanager
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Synthetics/JobManager/CollectLogs", func() {
+	var p SyntheticsJobManagerCollectLogs
+
+	Describe("Execute()", func() {
+		var (
+			commands []string
+			result   tasks.Result
+		)
+
+		BeforeEach(func() {
+			commands = nil
+			p.executeCommand = func(limit int64, name string, arg ...string) (*bufio.Scanner, error) {
+				commands = append(commands, strings.Join(append([]string{name}, arg...), " "))
+				logs := "2024-01-01 INFO Starting with key NRSP-us01ABCDEF0123456789\n2024-01-01 DEBUG VSE_PASSPHRASE=secret-passphrase\n2024-01-01 INFO Ready\n"
+				return bufio.NewScanner(strings.NewReader(logs)), nil
+			}
+			result = p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Synthetics/JobManager/Detect": {Status: tasks.Success, Payload: JobManagerDetection{
+					Containers: []JobManagerContainer{
+						{Platform: platformDocker, ID: "5a1f", Name: "sjm"},
+						{Platform: platformKubernetes, ID: "sjm-7b9c", Name: "sjm-7b9c", Namespace: "newrelic"},
+					},
+				}},
+			})
+		})
+
+		It("Should collect the logs of every container", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(commands).To(Equal([]string{
+				"docker logs 5a1f",
+				"kubectl logs -n newrelic sjm-7b9c --all-containers",
+			}))
+			Expect(result.FilesToCopy).To(HaveLen(2))
+			Expect(result.FilesToCopy[0].Path).To(Equal("docker-sjm.log"))
+		})
+
+		It("Should redact the secrets of the logs", func() {
+			var content string
+			for line := range result.FilesToCopy[0].Stream {
+				content += line
+			}
+			Expect(content).To(ContainSubstring("Starting with key _REDACTED_"))
+			Expect(content).To(ContainSubstring("VSE_PASSPHRASE=_REDACTED_"))
+			Expect(content).To(ContainSubstring("INFO Ready"))
+			Expect(content).ToNot(ContainSubstring("secret-passphrase"))
+		})
+	})
+})
@@ -0,0 +1,180 @@
+package jobmanager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/network"
+)
+
+// SyntheticsJobManagerConfigValidate - validates the settings of the Synthetics Job Managers found
+type SyntheticsJobManagerConfigValidate struct {
+}
+
+// JobManagerSettings - the settings of one job manager needed to reach horde
+type JobManagerSettings struct {
+	Container string
+	Platform  string
+	Region    string
+	Endpoint  string
+	// PrivateLocationKey is empty when the key is read from a Kubernetes secret
+	PrivateLocationKey string `json:"-"`
+}
+
+const dockerSocket = "/var/run/docker.sock"
+
+var locationKeyRegex = regexp.MustCompile(`^NRSP-(us|eu)01[0-9A-Za-z]+$`)
+
+// the variables the job manager needs to start the runtimes through the Podman API
+var podmanVariables = []string{"PODMAN_API_SERVICE_HOST", "PODMAN_API_SERVICE_PORT", "PODMAN_POD_NAME"}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerConfigValidate) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/ConfigValidate")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerConfigValidate) Explain() string {
+	return "Validate the private location key, container runtime access and runtime images of the Synthetics Job Manager"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerConfigValidate) Dependencies() []string {
+	return []string{"Synthetics/JobManager/Detect"}
+}
+
+// Execute - The core work within each task
+func (p SyntheticsJobManagerConfigValidate) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Synthetics/JobManager/Detect"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics Job Manager detected to validate",
+		}
+	}
+	detection, ok := upstream["Synthetics/JobManager/Detect"].Payload.(JobManagerDetection)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	var failures, warnings, infos []string
+	var settings []JobManagerSettings
+	for _, container := range detection.Containers {
+		if container.Runtime {
+			continue
+		}
+		name := fmt.Sprintf("%s container %s", container.Platform, container.Name)
+		if container.Platform == platformKubernetes {
+			name = fmt.Sprintf("pod %s/%s", container.Namespace, container.Name)
+		}
+		if state := strings.ToLower(container.State); state != "running" {
+			failures = append(failures, fmt.Sprintf("%s is %s", name, state))
+		}
+
+		containerSettings, failure := getSettings(container)
+		if failure != "" {
+			failures = append(failures, name+": "+failure)
+		} else {
+			settings = append(settings, containerSettings)
+		}
+
+		if container.HasVSEPassphrase {
+			infos = append(infos, name+": VSE_PASSPHRASE is set")
+		} else {
+			infos = append(infos, name+": VSE_PASSPHRASE is not set, it is only required when verified script execution is enabled for the private location")
+		}
+
+		switch container.Platform {
+		case platformDocker:
+			if !tasks.ContainsString(container.Mounts, dockerSocket) && container.Env["DOCKER_HOST"] == "" {
+				failures = append(failures, fmt.Sprintf("%s: %s is not mounted, the job manager can't start the runtimes. Add -v %s:%s:rw to docker run", name, dockerSocket, dockerSocket, dockerSocket))
+			}
+		case platformPodman:
+			var missing []string
+			for _, variable := range podmanVariables {
+				if container.Env[variable] == "" {
+					missing = append(missing, variable)
+				}
+			}
+			if len(missing) > 0 {
+				failures = append(failures, fmt.Sprintf("%s: %s must be set for the job manager to start the runtimes through the Podman API", name, strings.Join(missing, ", ")))
+			}
+		}
+		if warning := checkRuntimes(container, detection); warning != "" {
+			warnings = append(warnings, name+": "+warning)
+		}
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(append(failures, warnings...), infos...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/synthetics/synthetic-monitoring/private-locations/install-job-manager/",
+			Payload: settings,
+		}
+	}
+	if len(warnings) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: strings.Join(append(warnings, infos...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/synthetics/synthetic-monitoring/private-locations/install-job-manager/",
+			Payload: settings,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(append([]string{"The Synthetics Job Manager settings are valid"}, infos...), "\n"),
+		Payload: settings,
+	}
+}
+
+// getSettings - the region of a private location key follows its NRSP- prefix, HORDE_API_ENDPOINT overrides the endpoint of the region
+func getSettings(container JobManagerContainer) (JobManagerSettings, string) {
+	settings := JobManagerSettings{Container: container.Name, Platform: container.Platform}
+	key := container.PrivateLocationKey
+	_, fromSecret := container.Env["PRIVATE_LOCATION_KEY"]
+	switch {
+	case key == "" && fromSecret && container.Platform == platformKubernetes:
+		// the key of a secret can't be validated, horde is checked for every region
+	case key == "":
+		return settings, "PRIVATE_LOCATION_KEY is not set"
+	default:
+		match := locationKeyRegex.FindStringSubmatch(key)
+		if match == nil {
+			return settings, "PRIVATE_LOCATION_KEY is not a private location key, they start with NRSP-us01 or NRSP-eu01"
+		}
+		settings.Region = match[1]
+		settings.Endpoint = hordeEndpoint(match[1] + "01")
+		settings.PrivateLocationKey = key
+	}
+	if endpoint := container.Env["HORDE_API_ENDPOINT"]; endpoint != "" && endpoint != redactedValue {
+		settings.Endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+	return settings, ""
+}
+
+// hordeEndpoint - the horde endpoint of a region, from the Base/Network endpoint catalog
+func hordeEndpoint(region string) string {
+	endpoint, _ := network.CatalogEndpoint(region, "synthetics")
+	return "https://" + endpoint.Host
+}
+
+// checkRuntimes - the runtime images are pulled on Docker and Podman, the runtimes are pods of the namespace of the job manager on Kubernetes
+func checkRuntimes(container JobManagerContainer, detection JobManagerDetection) string {
+	if container.Platform != platformKubernetes {
+		if len(detection.RuntimeImages[container.Platform]) == 0 {
+			return fmt.Sprintf("no runtime image (%s) was pulled, check that the host can pull images from Docker Hub", strings.Join(runtimeImages, ", "))
+		}
+		return ""
+	}
+	for _, other := range detection.Containers {
+		if other.Runtime && other.Platform == platformKubernetes && other.Namespace == container.Namespace {
+			return ""
+		}
+	}
+	return fmt.Sprintf("no runtime pod runs in the %s namespace, check the events of the namespace with kubectl get events -n %s", container.Namespace, container.Namespace)
+}
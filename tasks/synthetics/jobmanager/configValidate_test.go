@@ -0,0 +1,113 @@
+package jobmanager
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Synthetics/JobManager/ConfigValidate", func() {
+	var p SyntheticsJobManagerConfigValidate
+
+	Describe("Execute()", func() {
+		var (
+			detection JobManagerDetection
+			result    tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Synthetics/JobManager/Detect": {Status: tasks.Success, Payload: detection},
+			})
+		})
+
+		Context("When a Docker job manager is configured", func() {
+			BeforeEach(func() {
+				detection = JobManagerDetection{
+					Containers: []JobManagerContainer{{
+						Platform:           platformDocker,
+						Name:               "sjm",
+						State:              "running",
+						Env:                map[string]string{},
+						Mounts:             []string{dockerSocket},
+						PrivateLocationKey: "NRSP-eu01ABCDEF0123456789",
+					}},
+					RuntimeImages: map[string][]string{platformDocker: {"newrelic/synthetics-ping-runtime:latest"}},
+				}
+			})
+			It("Should return a Success result with the endpoint of the region of the key", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				settings := result.Payload.([]JobManagerSettings)
+				Expect(settings).To(HaveLen(1))
+				Expect(settings[0].Region).To(Equal("eu"))
+				Expect(settings[0].Endpoint).To(Equal(hordeEndpoint("eu01")))
+				Expect(result.Summary).To(ContainSubstring("VSE_PASSPHRASE is not set"))
+			})
+		})
+
+		Context("When the key is invalid and the docker socket is not mounted", func() {
+			BeforeEach(func() {
+				detection = JobManagerDetection{
+					Containers: []JobManagerContainer{{
+						Platform:           platformDocker,
+						Name:               "sjm",
+						State:              "exited",
+						Env:                map[string]string{},
+						PrivateLocationKey: "not-a-key-0123456789",
+					}},
+					RuntimeImages: map[string][]string{},
+				}
+			})
+			It("Should return a Failure result without the key", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("docker container sjm is exited"))
+				Expect(result.Summary).To(ContainSubstring("PRIVATE_LOCATION_KEY is not a private location key"))
+				Expect(result.Summary).To(ContainSubstring("/var/run/docker.sock is not mounted"))
+				Expect(result.Summary).To(ContainSubstring("no runtime image"))
+				Expect(result.Summary).ToNot(ContainSubstring("not-a-key"))
+			})
+		})
+
+		Context("When a Podman job manager misses the Podman API variables", func() {
+			BeforeEach(func() {
+				detection = JobManagerDetection{
+					Containers: []JobManagerContainer{{
+						Platform:           platformPodman,
+						Name:               "sjm",
+						State:              "running",
+						Env:                map[string]string{"PODMAN_POD_NAME": "sjm-pod"},
+						PrivateLocationKey: "NRSP-us01ABCDEF0123456789",
+						HasVSEPassphrase:   true,
+					}},
+					RuntimeImages: map[string][]string{platformPodman: {"newrelic/synthetics-node-api-runtime:latest"}},
+				}
+			})
+			It("Should return a Failure result listing the missing variables", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("PODMAN_API_SERVICE_HOST, PODMAN_API_SERVICE_PORT must be set"))
+				Expect(result.Summary).To(ContainSubstring("VSE_PASSPHRASE is set"))
+			})
+		})
+
+		Context("When a Kubernetes job manager reads its key from a secret and no runtime pod runs", func() {
+			BeforeEach(func() {
+				detection = JobManagerDetection{
+					Containers: []JobManagerContainer{{
+						Platform:  platformKubernetes,
+						Name:      "sjm-7b9c",
+						Namespace: "newrelic",
+						State:     "Running",
+						Env:       map[string]string{"PRIVATE_LOCATION_KEY": "(valueFrom)"},
+					}},
+				}
+			})
+			It("Should return a Warning result with settings for every region", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("no runtime pod runs in the newrelic namespace"))
+				settings := result.Payload.([]JobManagerSettings)
+				Expect(settings).To(HaveLen(1))
+				Expect(settings[0].Endpoint).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -0,0 +1,331 @@
+package jobmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// SyntheticsJobManagerDetect - finds the Synthetics Job Manager and its runtimes on Docker, Podman and Kubernetes
+type SyntheticsJobManagerDetect struct {
+	executeCommand tasks.CmdExecFunc
+}
+
+// JobManagerContainer - a container or pod of the Synthetics Job Manager or of one of its runtimes
+type JobManagerContainer struct {
+	Platform  string
+	ID        string
+	Name      string
+	Namespace string
+	Image     string
+	Runtime   bool
+	State     string
+	// Env has the values of the variables that are not in SJMenvWhitelist redacted
+	Env    map[string]string
+	Mounts []string
+	// PrivateLocationKey is kept to validate it and to connect to horde, it is never written to the output
+	PrivateLocationKey string `json:"-"`
+	HasVSEPassphrase   bool
+}
+
+// HelmRelease - a Helm release of the synthetics-job-manager chart
+type HelmRelease struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Chart      string `json:"chart"`
+	AppVersion string `json:"app_version"`
+	Status     string `json:"status"`
+}
+
+// JobManagerDetection - everything found by Synthetics/JobManager/Detect
+type JobManagerDetection struct {
+	Containers    []JobManagerContainer
+	HelmReleases  []HelmRelease
+	RuntimeImages map[string][]string
+}
+
+const (
+	platformDocker     = "docker"
+	platformPodman     = "podman"
+	platformKubernetes = "kubernetes"
+	jobManagerImage    = "synthetics-job-manager"
+	redactedValue      = "_REDACTED_"
+)
+
+// the runtimes the job manager starts to run the monitors
+var runtimeImages = []string{"synthetics-ping-runtime", "synthetics-node-api-runtime", "synthetics-node-browser-runtime"}
+
+type kubernetesPods struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Containers []struct {
+				Image string `json:"image"`
+				Env   []struct {
+					Name      string          `json:"name"`
+					Value     string          `json:"value"`
+					ValueFrom json.RawMessage `json:"valueFrom"`
+				} `json:"env"`
+			} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			Phase string `json:"phase"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerDetect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/Detect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerDetect) Explain() string {
+	return "Detect the New Relic Synthetics Job Manager and its runtimes on Docker, Podman and Kubernetes"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerDetect) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (p SyntheticsJobManagerDetect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	detection := JobManagerDetection{RuntimeImages: map[string][]string{}}
+	var inspected []string
+	for _, platform := range []string{platformDocker, platformPodman} {
+		containers, inspectBlob := p.getContainers(platform)
+		detection.Containers = append(detection.Containers, containers...)
+		if inspectBlob != "" {
+			inspected = append(inspected, inspectBlob)
+		}
+		if images := p.getRuntimeImages(platform); len(images) > 0 {
+			detection.RuntimeImages[platform] = images
+		}
+	}
+	detection.Containers = append(detection.Containers, p.getPods()...)
+	detection.HelmReleases = p.getHelmReleases()
+
+	jobManagers := 0
+	for _, container := range detection.Containers {
+		if !container.Runtime {
+			jobManagers++
+		}
+	}
+	if jobManagers == 0 && len(detection.HelmReleases) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics Job Manager found on Docker, Podman or Kubernetes",
+		}
+	}
+
+	result := tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("Found %d Synthetics Job Manager container(s) and %d Helm release(s)", jobManagers, len(detection.HelmReleases)),
+		Payload: detection,
+	}
+	for i, blob := range inspected {
+		stream := make(chan string)
+		go tasks.StreamBlob(blob, stream)
+		result.FilesToCopy = append(result.FilesToCopy, tasks.FileCopyEnvelope{
+			Path:       fmt.Sprintf("inspected-SJM-%d.json", i),
+			Stream:     stream,
+			Identifier: p.Identifier().String(),
+			Redacted:   true,
+		})
+	}
+	return result
+}
+
+func isJobManagerImage(image string) (bool, bool) {
+	if strings.Contains(image, jobManagerImage) {
+		return true, false
+	}
+	for _, runtime := range runtimeImages {
+		if strings.Contains(image, runtime) {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// getContainers - returns the containers of the job manager and its runtimes with the redacted inspect output of docker or podman
+func (p SyntheticsJobManagerDetect) getContainers(platform string) ([]JobManagerContainer, string) {
+	output, err := p.executeCommand(platform, "ps", "-a", "--no-trunc", "--format", "{{.ID}} {{.Image}}")
+	if err != nil {
+		log.Debug("Unable to list the", platform, "containers:", err)
+		return nil, ""
+	}
+	images := map[string]string{}
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		id, image, found := strings.Cut(strings.TrimSpace(line), " ")
+		if !found {
+			continue
+		}
+		if matched, _ := isJobManagerImage(image); matched {
+			images[id] = image
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, ""
+	}
+
+	inspectOutput, err := p.executeCommand(platform, append([]string{"inspect"}, ids...)...)
+	if err != nil {
+		log.Debug("Unable to inspect the", platform, "containers:", err)
+		return nil, ""
+	}
+	var rawContainers []tasks.DockerContainer
+	if err := json.Unmarshal(inspectOutput, &rawContainers); err != nil {
+		log.Debug("Unable to parse the", platform, "inspect output:", err)
+		return nil, ""
+	}
+	redacted, err := tasks.RedactContainerEnv(inspectOutput, SJMenvWhitelist)
+	if err != nil {
+		log.Debug("Unable to redact the", platform, "inspect output:", err)
+		return nil, ""
+	}
+	var redactedContainers []tasks.DockerContainer
+	if err := json.Unmarshal(redacted, &redactedContainers); err != nil || len(redactedContainers) != len(rawContainers) {
+		log.Debug("Unable to parse the redacted", platform, "inspect output:", err)
+		return nil, ""
+	}
+
+	var containers []JobManagerContainer
+	for i, raw := range rawContainers {
+		rawEnv := envToMap(raw.Config.Env)
+		image := images[raw.Id]
+		_, runtime := isJobManagerImage(image)
+		container := JobManagerContainer{
+			Platform:           platform,
+			ID:                 raw.Id,
+			Name:               strings.TrimPrefix(raw.Name, "/"),
+			Image:              image,
+			Runtime:            runtime,
+			State:              raw.State.Status,
+			Env:                envToMap(redactedContainers[i].Config.Env),
+			PrivateLocationKey: rawEnv["PRIVATE_LOCATION_KEY"],
+			HasVSEPassphrase:   rawEnv["VSE_PASSPHRASE"] != "",
+		}
+		for _, mount := range raw.Mounts {
+			container.Mounts = append(container.Mounts, mount.Destination)
+		}
+		containers = append(containers, container)
+	}
+	return containers, string(redacted)
+}
+
+// getRuntimeImages - the job manager pulls the runtime images when it starts, monitors can't run without them
+func (p SyntheticsJobManagerDetect) getRuntimeImages(platform string) []string {
+	output, err := p.executeCommand(platform, "images", "--format", "{{.Repository}}:{{.Tag}}")
+	if err != nil {
+		return nil
+	}
+	var images []string
+	for _, image := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if matched, runtime := isJobManagerImage(image); matched && runtime {
+			images = append(images, image)
+		}
+	}
+	sort.Strings(images)
+	return images
+}
+
+// getPods - the pods of every namespace running the job manager or a runtime image
+func (p SyntheticsJobManagerDetect) getPods() []JobManagerContainer {
+	output, err := p.executeCommand("kubectl", "get", "pods", "--all-namespaces", "-o", "json")
+	if err != nil {
+		log.Debug("Unable to list the Kubernetes pods:", err)
+		return nil
+	}
+	var pods kubernetesPods
+	if err := json.Unmarshal(output, &pods); err != nil {
+		log.Debug("Unable to parse the Kubernetes pods:", err)
+		return nil
+	}
+	var containers []JobManagerContainer
+	for _, pod := range pods.Items {
+		for _, podContainer := range pod.Spec.Containers {
+			matched, runtime := isJobManagerImage(podContainer.Image)
+			if !matched {
+				continue
+			}
+			container := JobManagerContainer{
+				Platform:  platformKubernetes,
+				ID:        pod.Metadata.Name,
+				Name:      pod.Metadata.Name,
+				Namespace: pod.Metadata.Namespace,
+				Image:     podContainer.Image,
+				Runtime:   runtime,
+				State:     pod.Status.Phase,
+				Env:       map[string]string{},
+			}
+			for _, env := range podContainer.Env {
+				value := env.Value
+				if len(env.ValueFrom) > 0 {
+					// the chart reads the key and the passphrase from secrets, which are not collected
+					value = "(valueFrom)"
+				}
+				switch env.Name {
+				case "PRIVATE_LOCATION_KEY":
+					container.PrivateLocationKey = env.Value
+				case "VSE_PASSPHRASE":
+					container.HasVSEPassphrase = value != ""
+				}
+				if value != "(valueFrom)" && !isWhitelisted(env.Name) {
+					value = redactedValue
+				}
+				container.Env[env.Name] = value
+			}
+			containers = append(containers, container)
+		}
+	}
+	return containers
+}
+
+func (p SyntheticsJobManagerDetect) getHelmReleases() []HelmRelease {
+	output, err := p.executeCommand("helm", "list", "--all-namespaces", "-o", "json")
+	if err != nil {
+		log.Debug("Unable to list the Helm releases:", err)
+		return nil
+	}
+	var releases []HelmRelease
+	if err := json.Unmarshal(output, &releases); err != nil {
+		log.Debug("Unable to parse the Helm releases:", err)
+		return nil
+	}
+	var jobManagerReleases []HelmRelease
+	for _, release := range releases {
+		if strings.HasPrefix(release.Chart, jobManagerImage) {
+			jobManagerReleases = append(jobManagerReleases, release)
+		}
+	}
+	return jobManagerReleases
+}
+
+func envToMap(env []string) map[string]string {
+	envMap := map[string]string{}
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		envMap[name] = value
+	}
+	return envMap
+}
+
+func isWhitelisted(name string) bool {
+	for _, whitelisted := range SJMenvWhitelist {
+		if strings.EqualFold(whitelisted, name) {
+			return true
+		}
+	}
+	return false
+}
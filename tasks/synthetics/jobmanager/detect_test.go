@@ -0,0 +1,177 @@
+package jobmanager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSyntheticsJobManager(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Synthetics/JobManager/* test suite")
+}
+
+const dockerInspectFixture = `[
+	{
+		"Id": "5a1f",
+		"Name": "/sjm",
+		"State": {"Status": "running"},
+		"Mounts": [{"Destination": "/var/run/docker.sock"}],
+		"Config": {
+			"User": "",
+			"Env": [
+				"PRIVATE_LOCATION_KEY=NRSP-us01ABCDEF0123456789",
+				"VSE_PASSPHRASE=secret-passphrase",
+				"HORDE_API_PROXY_PASSWORD=proxy-password",
+				"LOG_LEVEL=INFO",
+				"ZZZ_CUSTOM=custom-value"
+			]
+		}
+	}
+]`
+
+const kubectlPodsFixture = `{
+	"items": [
+		{
+			"metadata": {"name": "sjm-7b9c", "namespace": "newrelic"},
+			"spec": {"containers": [{
+				"image": "newrelic/synthetics-job-manager:release-420",
+				"env": [
+					{"name": "PRIVATE_LOCATION_KEY", "valueFrom": {"secretKeyRef": {"name": "sjm", "key": "key"}}},
+					{"name": "LOG_LEVEL", "value": "DEBUG"},
+					{"name": "CUSTOM", "value": "custom-value"}
+				]
+			}]},
+			"status": {"phase": "Running"}
+		},
+		{
+			"metadata": {"name": "ping-runtime-1", "namespace": "newrelic"},
+			"spec": {"containers": [{"image": "newrelic/synthetics-ping-runtime:latest"}]},
+			"status": {"phase": "Running"}
+		},
+		{
+			"metadata": {"name": "web", "namespace": "default"},
+			"spec": {"containers": [{"image": "nginx:latest"}]},
+			"status": {"phase": "Running"}
+		}
+	]
+}`
+
+const helmListFixture = `[
+	{"name": "sjm", "namespace": "newrelic", "chart": "synthetics-job-manager-2.0.1", "app_version": "release-420", "status": "deployed"},
+	{"name": "ingress", "namespace": "default", "chart": "ingress-nginx-4.0.0", "app_version": "1.0.0", "status": "deployed"}
+]`
+
+func mockExecutor(outputs map[string]string) tasks.CmdExecFunc {
+	return func(name string, arg ...string) ([]byte, error) {
+		command := strings.Join(append([]string{name}, arg...), " ")
+		for prefix, output := range outputs {
+			if strings.HasPrefix(command, prefix) {
+				return []byte(output), nil
+			}
+		}
+		return nil, errors.New("executable file not found in $PATH")
+	}
+}
+
+var _ = Describe("Synthetics/JobManager/Detect", func() {
+	var p SyntheticsJobManagerDetect
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			expectedIdentifier := tasks.Identifier{
+				Category:    "Synthetics",
+				Subcategory: "JobManager",
+				Name:        "Detect",
+			}
+			Expect(p.Identifier()).To(Equal(expectedIdentifier))
+		})
+	})
+
+	Describe("Dependencies()", func() {
+		It("Should return no dependencies", func() {
+			Expect(p.Dependencies()).To(BeEmpty())
+		})
+	})
+
+	Describe("Execute()", func() {
+		var result tasks.Result
+
+		JustBeforeEach(func() {
+			result = p.Execute(tasks.Options{}, map[string]tasks.Result{})
+		})
+
+		Context("When no container platform is installed", func() {
+			BeforeEach(func() {
+				p.executeCommand = mockExecutor(map[string]string{})
+			})
+			It("Should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When a job manager runs on Docker", func() {
+			BeforeEach(func() {
+				p.executeCommand = mockExecutor(map[string]string{
+					"docker ps":      "5a1f newrelic/synthetics-job-manager:latest\n9e2d postgres:14",
+					"docker inspect": dockerInspectFixture,
+					"docker images":  "newrelic/synthetics-ping-runtime:latest\npostgres:14",
+				})
+			})
+			It("Should return the container with the secrets redacted", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				detection := result.Payload.(JobManagerDetection)
+				Expect(detection.Containers).To(HaveLen(1))
+				container := detection.Containers[0]
+				Expect(container.Name).To(Equal("sjm"))
+				Expect(container.Runtime).To(BeFalse())
+				Expect(container.PrivateLocationKey).To(Equal("NRSP-us01ABCDEF0123456789"))
+				Expect(container.HasVSEPassphrase).To(BeTrue())
+				Expect(container.Env["LOG_LEVEL"]).To(Equal("INFO"))
+				Expect(container.Env["PRIVATE_LOCATION_KEY"]).To(Equal(redactedValue))
+				Expect(container.Env["VSE_PASSPHRASE"]).To(Equal(redactedValue))
+				Expect(container.Env["HORDE_API_PROXY_PASSWORD"]).To(Equal(redactedValue))
+				Expect(container.Env["ZZZ_CUSTOM"]).To(Equal(redactedValue))
+				Expect(container.Mounts).To(ContainElement("/var/run/docker.sock"))
+				Expect(detection.RuntimeImages["docker"]).To(Equal([]string{"newrelic/synthetics-ping-runtime:latest"}))
+			})
+			It("Should copy the inspect output without the secrets", func() {
+				Expect(result.FilesToCopy).To(HaveLen(1))
+				var content string
+				for line := range result.FilesToCopy[0].Stream {
+					content += line
+				}
+				Expect(content).To(ContainSubstring("LOG_LEVEL=INFO"))
+				Expect(content).ToNot(ContainSubstring("NRSP-us01"))
+				Expect(content).ToNot(ContainSubstring("secret-passphrase"))
+				Expect(content).ToNot(ContainSubstring("proxy-password"))
+				Expect(content).ToNot(ContainSubstring("custom-value"))
+			})
+		})
+
+		Context("When a job manager runs on Kubernetes", func() {
+			BeforeEach(func() {
+				p.executeCommand = mockExecutor(map[string]string{
+					"kubectl get pods": kubectlPodsFixture,
+					"helm list":        helmListFixture,
+				})
+			})
+			It("Should return the job manager and runtime pods and the release of the chart", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				detection := result.Payload.(JobManagerDetection)
+				Expect(detection.Containers).To(HaveLen(2))
+				Expect(detection.Containers[0].Namespace).To(Equal("newrelic"))
+				Expect(detection.Containers[0].Env["PRIVATE_LOCATION_KEY"]).To(Equal("(valueFrom)"))
+				Expect(detection.Containers[0].Env["LOG_LEVEL"]).To(Equal("DEBUG"))
+				Expect(detection.Containers[0].Env["CUSTOM"]).To(Equal(redactedValue))
+				Expect(detection.Containers[1].Runtime).To(BeTrue())
+				Expect(detection.HelmReleases).To(HaveLen(1))
+				Expect(detection.HelmReleases[0].Name).To(Equal("sjm"))
+			})
+		})
+	})
+})
@@ -0,0 +1,33 @@
+package jobmanager
+
+// Expected ENV variables should be uppercase
+// Leave out expected ENV variables that have sensitive values:
+// "PRIVATE_LOCATION_KEY"
+// "VSE_PASSPHRASE"
+// "HORDE_API_PROXY_USERNAME"
+// "HORDE_API_PROXY_PASSWORD"
+// "USER_DEFINED_VARIABLES"
+var SJMenvWhitelist = []string{
+	//Variables default to the SJM and runtime images
+	"PATH",
+	"HOME",
+	"HOSTNAME",
+	"LANG",
+	"JAVA_HOME",
+	"JAVA_VERSION",
+	"NODE_VERSION",
+	//User Configurable variables below
+	"LOG_LEVEL",
+	"HORDE_API_ENDPOINT",
+	"HORDE_API_PROXY_HOST",
+	"HORDE_API_PROXY_PORT",
+	"HORDE_API_PROXY_ACCEPT_SELF_SIGNED_CERT",
+	"CHECK_TIMEOUT",
+	"HEAVYWEIGHT_WORKERS",
+	"DESIRED_RUNTIMES",
+	"DOCKER_HOST",
+	"DOCKER_API_VERSION",
+	"PODMAN_API_SERVICE_HOST",
+	"PODMAN_API_SERVICE_PORT",
+	"PODMAN_POD_NAME",
+}
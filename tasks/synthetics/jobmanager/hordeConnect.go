@@ -0,0 +1,131 @@
+package jobmanager
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/network"
+)
+
+// SyntheticsJobManagerHordeConnect - checks the connection of the job managers to the horde endpoint of their region
+type SyntheticsJobManagerHordeConnect struct {
+	httpGetter tasks.HTTPRequestFunc
+}
+
+// HordeConnection - the result of one request to horde
+type HordeConnection struct {
+	Endpoint   string
+	WithKey    bool
+	StatusCode int
+	Error      string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerHordeConnect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/HordeConnect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerHordeConnect) Explain() string {
+	return "Check network connection of the Synthetics Job Manager to the New Relic Synthetics horde endpoint of its region"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerHordeConnect) Dependencies() []string {
+	return []string{
+		"Synthetics/JobManager/ConfigValidate",
+		"Base/Config/ProxyDetect",
+	}
+}
+
+// Execute - The core work within each task
+func (p SyntheticsJobManagerHordeConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	settings, ok := upstream["Synthetics/JobManager/ConfigValidate"].Payload.([]JobManagerSettings)
+	if !ok || len(settings) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics Job Manager settings to connect to horde with",
+		}
+	}
+
+	// a key read from a secret is unknown, so every region is checked without it
+	keysByEndpoint := map[string]string{}
+	for _, setting := range settings {
+		if setting.Endpoint == "" {
+			for _, region := range network.CatalogRegions() {
+				endpoint := hordeEndpoint(region)
+				if _, found := keysByEndpoint[endpoint]; !found {
+					keysByEndpoint[endpoint] = ""
+				}
+			}
+			continue
+		}
+		keysByEndpoint[setting.Endpoint] = setting.PrivateLocationKey
+	}
+	endpoints := make([]string, 0, len(keysByEndpoint))
+	for endpoint := range keysByEndpoint {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	var connections []HordeConnection
+	var failures, successes []string
+	for _, endpoint := range endpoints {
+		connection := p.connect(endpoint, keysByEndpoint[endpoint])
+		connections = append(connections, connection)
+		switch {
+		case connection.Error != "":
+			failures = append(failures, fmt.Sprintf("Unable to connect to %s: %s", endpoint, connection.Error))
+		case connection.StatusCode == http.StatusOK:
+			successes = append(successes, fmt.Sprintf("Successful connection to %s with the private location key", endpoint))
+		case connection.StatusCode == http.StatusUnauthorized || connection.StatusCode == http.StatusForbidden:
+			if connection.WithKey {
+				failures = append(failures, fmt.Sprintf("%s rejected the private location key with %d, confirm the key of the private location in the Synthetics UI", endpoint, connection.StatusCode))
+				continue
+			}
+			successes = append(successes, fmt.Sprintf("%s is reachable, the private location key was not checked because it is read from a Kubernetes secret", endpoint))
+		default:
+			failures = append(failures, fmt.Sprintf("Expected a 200 response from %s, received: %d", endpoint, connection.StatusCode))
+		}
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: strings.Join(append(failures, successes...), "\n"),
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#synthetics-private",
+			Payload: connections,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(successes, "\n"),
+		Payload: connections,
+	}
+}
+
+func (p SyntheticsJobManagerHordeConnect) connect(endpoint string, key string) HordeConnection {
+	connection := HordeConnection{Endpoint: endpoint, WithKey: key != ""}
+	headers := map[string]string{}
+	if key != "" {
+		headers["X-API-Key"] = key
+	}
+	log.Debug("Attempting connection to:", endpoint)
+	resp, err := p.httpGetter(httpHelper.RequestWrapper{
+		Method:  "GET",
+		URL:     endpoint + "/api/v1.0/config",
+		Headers: headers,
+	})
+	if err != nil {
+		connection.Error = err.Error()
+		return connection
+	}
+	defer resp.Body.Close()
+	connection.StatusCode = resp.StatusCode
+	return connection
+}
@@ -0,0 +1,92 @@
+package jobmanager
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Synthetics/JobManager/HordeConnect", func() {
+	var (
+		p        SyntheticsJobManagerHordeConnect
+		settings []JobManagerSettings
+		requests []httpHelper.RequestWrapper
+		statuses map[string]int
+		result   tasks.Result
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		p.httpGetter = func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+			requests = append(requests, wrapper)
+			for endpoint, status := range statuses {
+				if strings.HasPrefix(wrapper.URL, endpoint) {
+					return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+				}
+			}
+			return nil, errors.New("dial tcp: i/o timeout")
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Synthetics/JobManager/ConfigValidate": {Status: tasks.Success, Payload: settings},
+		})
+	})
+
+	Context("When there are no settings", func() {
+		BeforeEach(func() {
+			settings = nil
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("When horde accepts the key", func() {
+		BeforeEach(func() {
+			settings = []JobManagerSettings{{Endpoint: hordeEndpoint("us01"), PrivateLocationKey: "NRSP-us01ABC"}}
+			statuses = map[string]int{hordeEndpoint("us01"): 200}
+		})
+		It("Should send the key and return a Success result", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL).To(Equal(hordeEndpoint("us01") + "/api/v1.0/config"))
+			Expect(requests[0].Headers["X-API-Key"]).To(Equal("NRSP-us01ABC"))
+		})
+	})
+
+	Context("When horde rejects the key", func() {
+		BeforeEach(func() {
+			settings = []JobManagerSettings{{Endpoint: hordeEndpoint("eu01"), PrivateLocationKey: "NRSP-eu01ABC"}}
+			statuses = map[string]int{hordeEndpoint("eu01"): 401}
+		})
+		It("Should return a Failure result without the key", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("rejected the private location key"))
+			Expect(result.Summary).ToNot(ContainSubstring("NRSP-eu01ABC"))
+		})
+	})
+
+	Context("When the key is read from a secret and one region is unreachable", func() {
+		BeforeEach(func() {
+			settings = []JobManagerSettings{{}}
+			statuses = map[string]int{hordeEndpoint("us01"): 401}
+		})
+		It("Should check every region without a key", func() {
+			Expect(requests).To(HaveLen(2))
+			for _, request := range requests {
+				Expect(request.Headers).ToNot(HaveKey("X-API-Key"))
+			}
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring(hordeEndpoint("us01") + " is reachable"))
+			Expect(result.Summary).To(ContainSubstring("Unable to connect to " + hordeEndpoint("eu01")))
+		})
+	})
+})
@@ -0,0 +1,17 @@
+package jobmanager
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Synthetics/JobManager/*")
+
+	registrationFunc(SyntheticsJobManagerDetect{executeCommand: tasks.CmdExecutor}, false)
+	registrationFunc(SyntheticsJobManagerConfigValidate{}, false)
+	registrationFunc(SyntheticsJobManagerHordeConnect{httpGetter: httpHelper.MakeHTTPRequest}, false)
+	registrationFunc(SyntheticsJobManagerCollectLogs{executeCommand: tasks.BufferedCommandExec}, false)
+}
@@ -113,6 +113,7 @@ func (p SyntheticsMinionDetectCPM) Execute(options tasks.Options, upstream map[s
 			Path:       "inspected-CPMs.json",
 			Stream:     stream,
 			Identifier: p.Identifier().String(),
+			Redacted:   true,
 		},
 	}
 
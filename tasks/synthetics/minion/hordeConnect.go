@@ -8,6 +8,7 @@ import (
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/network"
 )
 
 // HTTPResponse is struct of parsed http response data used to evaluate horde connection
@@ -16,6 +17,9 @@ type HTTPResponse struct {
 	ResponseBody string
 }
 
+// legacy private location keys carry no region, the minions connect to the US horde
+var hordeEndpoint, _ = network.CatalogEndpoint("us01", "synthetics")
+
 type SyntheticsMinionHordeConnect struct { // This defines the task itself and should be named according to the standard CategorySubcategoryTaskname in camelcase
 }
 
@@ -61,12 +65,12 @@ func (p SyntheticsMinionHordeConnect) Execute(options tasks.Options, upstream ma
 	case 200:
 		if err != nil {
 			result.Status = tasks.Warning
-			result.Summary = "Successful connection to synthetics-horde.nr-data.net with current settings, but unable to parse response body"
+			result.Summary = "Successful connection to " + hordeEndpoint.Host + " with current settings, but unable to parse response body"
 			result.URL = "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#synthetics-private"
 			return result
 		}
 		result.Status = tasks.Success
-		result.Summary = "Successful connection to synthetics-horde.nr-data.net with current settings"
+		result.Summary = "Successful connection to " + hordeEndpoint.Host + " with current settings"
 
 	case 403:
 		if upstream["Synthetics/Minion/ConfigValidate"].Status != tasks.Success {
@@ -75,12 +79,12 @@ func (p SyntheticsMinionHordeConnect) Execute(options tasks.Options, upstream ma
 			result.URL = "https://docs.newrelic.com/docs/synthetics/new-relic-synthetics/private-locations/install-configure-private-minions#configure"
 		} else {
 			result.Status = tasks.Failure
-			result.Summary = "Connection to synthetics-horde.nr-data.net returned 403 Forbidden. Confirm location key is correct (\"" + settings.Key + "\") at: http://<MINION_IP_ADDRESS>/setup"
+			result.Summary = "Connection to " + hordeEndpoint.Host + " returned 403 Forbidden. Confirm location key is correct (\"" + settings.Key + "\") at: http://<MINION_IP_ADDRESS>/setup"
 			result.URL = "https://docs.newrelic.com/docs/synthetics/new-relic-synthetics/private-locations/install-configure-private-minions#configure"
 		}
 	case -7:
 		result.Status = tasks.Failure
-		result.Summary = "Unable to complete request to synthetics-horde.nr-data.net: " + err.Error()
+		result.Summary = "Unable to complete request to " + hordeEndpoint.Host + ": " + err.Error()
 		result.URL = "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#synthetics-private"
 	default:
 		result.Status = tasks.Failure
@@ -91,18 +95,18 @@ func (p SyntheticsMinionHordeConnect) Execute(options tasks.Options, upstream ma
 	return result
 }
 
-// hordeRequest - Performs GET request to https://synthetics-horde.nr-data.net/api/v1.0/config using provided private location key
+// hordeRequest - Performs GET request to the /api/v1.0/config path of horde using provided private location key
 func hordeRequest(privateLocationKey string) (HTTPResponse, error) {
 	var httpResponse HTTPResponse
 
 	headers := make(map[string]string)
 	headers["X-API-Key"] = privateLocationKey
 
-	log.Debug("Attempting connection to: synthetics-horde.nr-data.net (HTTPS) using location key: " + privateLocationKey)
+	log.Debug("Attempting connection to: " + hordeEndpoint.Host + " (HTTPS) using location key: " + privateLocationKey)
 
 	wrapper := httpHelper.RequestWrapper{
 		Method:  "GET",
-		URL:     "https://synthetics-horde.nr-data.net/api/v1.0/config",
+		URL:     "https://" + hordeEndpoint.Host + "/api/v1.0/config",
 		Headers: headers,
 	}
 
@@ -694,7 +694,8 @@ func GetProcessEnvVars(pid int32) (envVars EnvironmentVariables, retErr error) {
 		envVarString := string(environFile)
 		lines := strings.Split(envVarString, "\x00")
 		for _, line := range lines {
-			split := strings.Split(line, "=")
+			// values such as NODE_OPTIONS=--require=newrelic contain = themselves
+			split := strings.SplitN(line, "=", 2)
 			if len(split) > 1 {
 				name := split[0]
 				val := split[1]
@@ -743,22 +744,27 @@ type JavaProcArgs struct {
 
 // GetJavaProcArgs returns a slice of JavaProcArgs struct with two fields: ProcID(int32) and Args([]string)
 func GetJavaProcArgs() []JavaProcArgs {
-	javaProcs, err := FindProcessByName("java")
+	return GetProcArgs("java")
+}
+
+// GetProcArgs returns the process id and cmdline arguments of each running process with the given name
+func GetProcArgs(name string) []JavaProcArgs {
+	procs, err := FindProcessByName(name)
 	if err != nil {
-		log.Debug("We encountered an error while detecting all running Java processes: " + err.Error())
+		log.Debug("We encountered an error while detecting all running " + name + " processes: " + err.Error())
 	}
-	if javaProcs == nil {
+	if procs == nil {
 		return []JavaProcArgs{}
 	}
-	javaProcArgs := []JavaProcArgs{}
-	for _, proc := range javaProcs {
+	procArgs := []JavaProcArgs{}
+	for _, proc := range procs {
 		cmdLineArgsSlice, err := GetCmdLineArgs(proc)
 		if err != nil {
 			log.Debug("Error getting command line options while running GetCmdLineArgs(proc)")
 		}
-		javaProcArgs = append(javaProcArgs, JavaProcArgs{ProcID: proc.Pid, Args: cmdLineArgsSlice})
+		procArgs = append(procArgs, JavaProcArgs{ProcID: proc.Pid, Args: cmdLineArgsSlice})
 	}
-	return javaProcArgs
+	return procArgs
 }
 
 // ProcIDSysProps has a running java process id and its associated system properties organized as map of key system property and the value of the system property
@@ -189,8 +189,8 @@ func RedactContainerEnv(containers []byte, whitelist []string) ([]byte, error) {
 			searchIndex := sort.SearchStrings(whitelist, envVarNameUpper)
 			//SearchStrings will return index of where search value should be inserted in ordered list if not found
 			//So we check if the index value matches search value to validate if present
-			//And that index is not len of whitelist
-			if (searchIndex != len(whitelist)) && (!strings.EqualFold(whitelist[searchIndex], envVarNameUpper)) {
+			//Or that index is len of whitelist, when the name sorts after every whitelisted name
+			if (searchIndex == len(whitelist)) || (!strings.EqualFold(whitelist[searchIndex], envVarNameUpper)) {
 				//if searched value not found we redact the value and reconstruct the string
 				env[i] = fmt.Sprintf(`%s=_REDACTED_`, envVarPair[0])
 			}
@@ -0,0 +1,409 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// LockfileDependency - a package with the exact version resolved by a lockfile
+type LockfileDependency struct {
+	Name    string
+	Version string
+	// Direct is set when the lockfile records the package as declared by the application instead of pulled in by another package
+	Direct bool
+}
+
+// Lockfile - the normalized dependency inventory of a package manager lockfile
+type Lockfile struct {
+	Path         string
+	Ecosystem    string
+	Dependencies []LockfileDependency
+}
+
+type lockfileParser struct {
+	ecosystem string
+	parse     func([]byte) ([]LockfileDependency, error)
+}
+
+// lockfileParsers - the supported lockfiles by file name
+var lockfileParsers = map[string]lockfileParser{
+	"package-lock.json":   {"node", parsePackageLock},
+	"npm-shrinkwrap.json": {"node", parsePackageLock},
+	"yarn.lock":           {"node", parseYarnLock},
+	"pnpm-lock.yaml":      {"node", parsePnpmLock},
+	"Gemfile.lock":        {"ruby", parseGemfileLock},
+	"poetry.lock":         {"python", parsePoetryLock},
+	"Pipfile.lock":        {"python", parsePipfileLock},
+	"requirements.txt":    {"python", parseRequirementsTxt},
+}
+
+var errUnknownLockfile = errors.New("not a supported lockfile")
+
+// ParseLockfile - parses the content of a lockfile, the format is chosen by the base name of the path
+func ParseLockfile(path string, content []byte) (Lockfile, error) {
+	parser, ok := lockfileParsers[filepath.Base(path)]
+	if !ok {
+		return Lockfile{}, errUnknownLockfile
+	}
+	dependencies, err := parser.parse(content)
+	if err != nil {
+		return Lockfile{}, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	sort.Slice(dependencies, func(i, j int) bool {
+		if dependencies[i].Name != dependencies[j].Name {
+			return dependencies[i].Name < dependencies[j].Name
+		}
+		return dependencies[i].Version < dependencies[j].Version
+	})
+	return Lockfile{Path: path, Ecosystem: parser.ecosystem, Dependencies: dependencies}, nil
+}
+
+// ReadLockfiles - reads and parses the lockfiles of an ecosystem ("node", "ruby" or "python") found in a directory
+func ReadLockfiles(dir string, ecosystem string, fileReader func(string) ([]byte, error)) []Lockfile {
+	var names []string
+	for name, parser := range lockfileParsers {
+		if parser.ecosystem == ecosystem {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var lockfiles []Lockfile
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		content, err := fileReader(path)
+		if err != nil {
+			continue
+		}
+		lockfile, err := ParseLockfile(path, content)
+		if err != nil {
+			log.Debug(err)
+			continue
+		}
+		lockfiles = append(lockfiles, lockfile)
+	}
+	return lockfiles
+}
+
+// LockfileVersionNumber - the numeric part of a resolved version, so "7.0.0-beta.1" and "4.2rc1" can be compared with VersionIsCompatible
+func LockfileVersionNumber(version string) string {
+	return lockfileVersionRegex.FindString(version)
+}
+
+var lockfileVersionRegex = regexp.MustCompile(`^\d+(\.\d+)*`)
+
+// parsePackageLock - package-lock.json v1 lists packages in nested "dependencies", v2 and v3 in "packages" keyed by their node_modules path
+func parsePackageLock(content []byte) ([]LockfileDependency, error) {
+	type v1Dependency struct {
+		Version      string                     `json:"version"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	var lock struct {
+		Packages map[string]struct {
+			Name            string            `json:"name"`
+			Version         string            `json:"version"`
+			Link            bool              `json:"link"`
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		} `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	var dependencies []LockfileDependency
+	if len(lock.Packages) > 0 {
+		root := lock.Packages[""]
+		for path, pkg := range lock.Packages {
+			index := strings.LastIndex(path, "node_modules/")
+			if index < 0 || pkg.Link {
+				continue
+			}
+			name := path[index+len("node_modules/"):]
+			if pkg.Name != "" {
+				name = pkg.Name
+			}
+			// only the packages installed at the top of the tree can be the declared ones
+			_, declared := root.Dependencies[name]
+			_, declaredDev := root.DevDependencies[name]
+			direct := (declared || declaredDev) && path == "node_modules/"+name
+			dependencies = append(dependencies, LockfileDependency{Name: name, Version: pkg.Version, Direct: direct})
+		}
+		return dependencies, nil
+	}
+
+	var walk func(map[string]json.RawMessage) error
+	walk = func(nested map[string]json.RawMessage) error {
+		for name, raw := range nested {
+			var dependency v1Dependency
+			if err := json.Unmarshal(raw, &dependency); err != nil {
+				return err
+			}
+			dependencies = append(dependencies, LockfileDependency{Name: name, Version: dependency.Version})
+			if err := walk(dependency.Dependencies); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(lock.Dependencies); err != nil {
+		return nil, err
+	}
+	return dependencies, nil
+}
+
+// parseYarnLock - classic yarn.lock entries have a `version "x"` line, berry entries a `version: x` line
+func parseYarnLock(content []byte) ([]LockfileDependency, error) {
+	var dependencies []LockfileDependency
+	var name string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			name = yarnEntryName(strings.TrimSuffix(line, ":"))
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if name == "" || !strings.HasPrefix(trimmed, "version") || strings.HasPrefix(line, "    ") {
+			continue
+		}
+		version := strings.Trim(strings.TrimSpace(strings.TrimLeft(trimmed[len("version"):], ":")), `"`)
+		dependencies = append(dependencies, LockfileDependency{Name: name, Version: version})
+		name = ""
+	}
+	return dependencies, scanner.Err()
+}
+
+// yarnEntryName - returns the package of an entry key like `"@babel/core@^7.0.0", "@babel/core@^7.1.0"` or `"express@npm:^4.17.1"`
+func yarnEntryName(key string) string {
+	spec := strings.Trim(strings.TrimSpace(strings.Split(key, ",")[0]), `"`)
+	// the name ends at the first @ that is not the scope, berry ranges may reference other packages: "string-width-cjs@npm:string-width@^4.2.0"
+	index := strings.Index(strings.TrimPrefix(spec, "@"), "@")
+	if index < 0 {
+		return ""
+	}
+	if strings.HasPrefix(spec, "@") {
+		index++
+	}
+	// berry also lists the workspaces themselves
+	if strings.HasPrefix(spec[index+1:], "workspace:") {
+		return ""
+	}
+	return spec[:index]
+}
+
+// parsePnpmLock - pnpm-lock.yaml keys its packages as "/name/version" up to v5, "/name@version" in v6 and "name@version" from v9
+func parsePnpmLock(content []byte) ([]LockfileDependency, error) {
+	var lock struct {
+		LockfileVersion interface{}             `yaml:"lockfileVersion"`
+		Packages        map[string]interface{}  `yaml:"packages"`
+		Importers       map[string]pnpmImporter `yaml:"importers"`
+		// single project lockfiles before v9 declare the dependencies at the top level
+		Root pnpmImporter `yaml:",inline"`
+	}
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	declared := lock.Root.names()
+	if root, ok := lock.Importers["."]; ok {
+		declared = append(declared, root.names()...)
+	}
+	legacy := strings.HasPrefix(fmt.Sprint(lock.LockfileVersion), "5")
+
+	var dependencies []LockfileDependency
+	for key := range lock.Packages {
+		key = strings.TrimPrefix(key, "/")
+		// peer dependency suffixes: "react-dom@18.2.0(react@18.2.0)" or "react-dom/18.2.0_react@18.2.0"
+		if index := strings.Index(key, "("); index > 0 {
+			key = key[:index]
+		}
+		var name, version string
+		if legacy {
+			index := strings.LastIndex(key, "/")
+			if index <= 0 {
+				continue
+			}
+			name, version = key[:index], strings.Split(key[index+1:], "_")[0]
+		} else {
+			index := strings.LastIndex(key, "@")
+			if index <= 0 {
+				continue
+			}
+			name, version = key[:index], key[index+1:]
+		}
+		dependencies = append(dependencies, LockfileDependency{Name: name, Version: version, Direct: ContainsString(declared, name)})
+	}
+	return dependencies, nil
+}
+
+type pnpmImporter struct {
+	Dependencies         map[string]interface{} `yaml:"dependencies"`
+	DevDependencies      map[string]interface{} `yaml:"devDependencies"`
+	OptionalDependencies map[string]interface{} `yaml:"optionalDependencies"`
+}
+
+func (i pnpmImporter) names() []string {
+	var names []string
+	for _, group := range []map[string]interface{}{i.Dependencies, i.DevDependencies, i.OptionalDependencies} {
+		for name := range group {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseGemfileLock - the gems resolved in the specs of the GEM, GIT and PATH sections, the DEPENDENCIES section lists the ones of the Gemfile
+func parseGemfileLock(content []byte) ([]LockfileDependency, error) {
+	var dependencies []LockfileDependency
+	var declared []string
+	var section string
+	specRegex := regexp.MustCompile(`^    ([^\s(]+) \(([^)]+)\)$`)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			section = line
+			continue
+		}
+		switch section {
+		case "GEM", "GIT", "PATH":
+			match := specRegex.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			// platform specific gems append the platform: nokogiri (1.15.4-x86_64-linux)
+			version := strings.SplitN(match[2], "-", 2)[0]
+			dependencies = append(dependencies, LockfileDependency{Name: match[1], Version: version})
+		case "DEPENDENCIES":
+			fields := strings.Fields(line)
+			if len(fields) > 0 && strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "   ") {
+				declared = append(declared, strings.TrimSuffix(fields[0], "!"))
+			}
+		}
+	}
+	for i := range dependencies {
+		dependencies[i].Direct = ContainsString(declared, dependencies[i].Name)
+	}
+	return dependencies, scanner.Err()
+}
+
+// parsePoetryLock - reads the name and version keys of the [[package]] tables of the TOML file
+func parsePoetryLock(content []byte) ([]LockfileDependency, error) {
+	var dependencies []LockfileDependency
+	inPackage := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inPackage = line == "[[package]]"
+			if inPackage {
+				dependencies = append(dependencies, LockfileDependency{})
+			}
+			continue
+		}
+		if !inPackage {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.TrimSpace(key) {
+		case "name":
+			dependencies[len(dependencies)-1].Name = NormalizePythonPackageName(value)
+		case "version":
+			dependencies[len(dependencies)-1].Version = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, dependency := range dependencies {
+		if dependency.Name == "" || dependency.Version == "" {
+			return nil, errors.New("[[package]] table without a name or version")
+		}
+	}
+	return dependencies, nil
+}
+
+// parsePipfileLock - the default and develop groups map package names to their "==x" pins
+func parsePipfileLock(content []byte) ([]LockfileDependency, error) {
+	var lock map[string]json.RawMessage
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+	var dependencies []LockfileDependency
+	for _, group := range []string{"default", "develop"} {
+		raw, ok := lock[group]
+		if !ok {
+			continue
+		}
+		var packages map[string]struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(raw, &packages); err != nil {
+			return nil, err
+		}
+		for name, pkg := range packages {
+			// packages installed from VCS or paths have no version
+			if pkg.Version == "" {
+				continue
+			}
+			dependencies = append(dependencies, LockfileDependency{Name: NormalizePythonPackageName(name), Version: strings.TrimLeft(pkg.Version, "=")})
+		}
+	}
+	return dependencies, nil
+}
+
+var requirementRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*===?\s*([^\s,;]+)`)
+
+// parseRequirementsTxt - only pinned requirements (name==version) resolve to an exact version
+func parseRequirementsTxt(content []byte) ([]LockfileDependency, error) {
+	var dependencies []LockfileDependency
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = strings.TrimSpace(line[:index])
+		}
+		// options such as -r, -e and the --hash continuation lines of pip-compile
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		match := requirementRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		dependencies = append(dependencies, LockfileDependency{Name: NormalizePythonPackageName(match[1]), Version: match[3], Direct: true})
+	}
+	return dependencies, scanner.Err()
+}
+
+var pythonNameSeparatorRegex = regexp.MustCompile(`[-_.]+`)
+
+// NormalizePythonPackageName - PEP 503 normalization, so Flask_SQLAlchemy and flask-sqlalchemy are the same package
+func NormalizePythonPackageName(name string) string {
+	return pythonNameSeparatorRegex.ReplaceAllString(strings.ToLower(name), "-")
+}
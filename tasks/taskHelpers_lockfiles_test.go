@@ -0,0 +1,283 @@
+package tasks
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseLockfile()", func() {
+	parse := func(path string, content string) []LockfileDependency {
+		lockfile, err := ParseLockfile(path, []byte(content))
+		Expect(err).ToNot(HaveOccurred())
+		return lockfile.Dependencies
+	}
+
+	It("Should reject files that are not lockfiles", func() {
+		_, err := ParseLockfile("/app/package.json", []byte("{}"))
+		Expect(err).To(Equal(errUnknownLockfile))
+	})
+
+	It("Should wrap the parse errors with the path", func() {
+		_, err := ParseLockfile("/app/package-lock.json", []byte("{"))
+		Expect(err).To(MatchError(ContainSubstring("unable to parse /app/package-lock.json")))
+	})
+
+	Context("With a package-lock.json", func() {
+		It("Should read the packages of a v2 or v3 lockfile", func() {
+			Expect(parse("/app/package-lock.json", `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "dependencies": {"express": "^4.18.2"}, "devDependencies": {"@babel/core": "^7.0.0"}},
+    "node_modules/express": {"version": "4.18.2"},
+    "node_modules/@babel/core": {"version": "7.23.0", "dev": true},
+    "node_modules/debug": {"version": "2.6.9"},
+    "node_modules/express/node_modules/debug": {"version": "4.3.4"},
+    "node_modules/local": {"resolved": "packages/local", "link": true}
+  }
+}`)).To(Equal([]LockfileDependency{
+				{Name: "@babel/core", Version: "7.23.0", Direct: true},
+				{Name: "debug", Version: "2.6.9"},
+				{Name: "debug", Version: "4.3.4"},
+				{Name: "express", Version: "4.18.2", Direct: true},
+			}))
+		})
+
+		It("Should walk the nested dependencies of a v1 lockfile", func() {
+			Expect(parse("/app/package-lock.json", `{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "express": {"version": "4.16.4", "dependencies": {"debug": {"version": "2.6.9"}}},
+    "mongoose": {"version": "5.4.0"}
+  }
+}`)).To(Equal([]LockfileDependency{
+				{Name: "debug", Version: "2.6.9"},
+				{Name: "express", Version: "4.16.4"},
+				{Name: "mongoose", Version: "5.4.0"},
+			}))
+		})
+	})
+
+	Context("With a yarn.lock", func() {
+		It("Should read a classic lockfile", func() {
+			Expect(parse("/app/yarn.lock", `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/core@^7.0.0", "@babel/core@^7.1.0":
+  version "7.4.0"
+  resolved "https://registry.yarnpkg.com/@babel/core/-/core-7.4.0.tgz"
+  dependencies:
+    version-range "^1.0.0"
+
+express@^4.16.4:
+  version "4.16.4"
+`)).To(Equal([]LockfileDependency{
+				{Name: "@babel/core", Version: "7.4.0"},
+				{Name: "express", Version: "4.16.4"},
+			}))
+		})
+
+		It("Should read a berry lockfile and skip the workspaces", func() {
+			Expect(parse("/app/yarn.lock", `__metadata:
+  version: 8
+  cacheKey: 10c0
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+
+"express@npm:^4.18.2":
+  version: 4.18.2
+  resolution: "express@npm:4.18.2"
+
+"string-width-cjs@npm:string-width@^4.2.0":
+  version: 4.2.3
+  resolution: "string-width@npm:4.2.3"
+`)).To(Equal([]LockfileDependency{
+				{Name: "express", Version: "4.18.2"},
+				{Name: "string-width-cjs", Version: "4.2.3"},
+			}))
+		})
+	})
+
+	Context("With a pnpm-lock.yaml", func() {
+		It("Should read the package keys of a v5 lockfile", func() {
+			Expect(parse("/app/pnpm-lock.yaml", `lockfileVersion: 5.4
+
+specifiers:
+  react-dom: ^18.2.0
+
+dependencies:
+  react-dom: 18.2.0_react@18.2.0
+
+packages:
+
+  /@babel/runtime/7.21.0:
+    resolution: {integrity: sha512-abc}
+    dev: false
+
+  /react-dom/18.2.0_react@18.2.0:
+    resolution: {integrity: sha512-def}
+    dev: false
+`)).To(Equal([]LockfileDependency{
+				{Name: "@babel/runtime", Version: "7.21.0"},
+				{Name: "react-dom", Version: "18.2.0", Direct: true},
+			}))
+		})
+
+		It("Should read the package keys of a v9 lockfile", func() {
+			Expect(parse("/app/pnpm-lock.yaml", `lockfileVersion: '9.0'
+
+importers:
+
+  .:
+    dependencies:
+      express:
+        specifier: ^4.18.2
+        version: 4.18.2
+
+packages:
+
+  express@4.18.2:
+    resolution: {integrity: sha512-abc}
+
+  '@types/node@20.8.0':
+    resolution: {integrity: sha512-def}
+
+  react-dom@18.2.0(react@18.2.0):
+    resolution: {integrity: sha512-ghi}
+`)).To(Equal([]LockfileDependency{
+				{Name: "@types/node", Version: "20.8.0"},
+				{Name: "express", Version: "4.18.2", Direct: true},
+				{Name: "react-dom", Version: "18.2.0"},
+			}))
+		})
+	})
+
+	Context("With a Gemfile.lock", func() {
+		It("Should read the resolved specs and the declared gems", func() {
+			Expect(parse("/app/Gemfile.lock", `GIT
+  remote: https://github.com/thiagopradi/octopus.git
+  revision: 0f1f0a0
+  specs:
+    ar-octopus (0.10.2)
+      activerecord (>= 3.2.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    activerecord (7.0.4)
+      activesupport (= 7.0.4)
+    activesupport (7.0.4)
+    nokogiri (1.15.4-x86_64-linux)
+      racc (~> 1.4)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  activerecord (~> 7.0)
+  ar-octopus!
+
+BUNDLED WITH
+   2.4.10
+`)).To(Equal([]LockfileDependency{
+				{Name: "activerecord", Version: "7.0.4", Direct: true},
+				{Name: "activesupport", Version: "7.0.4"},
+				{Name: "ar-octopus", Version: "0.10.2", Direct: true},
+				{Name: "nokogiri", Version: "1.15.4"},
+			}))
+		})
+	})
+
+	Context("With Python lockfiles", func() {
+		It("Should read the [[package]] tables of a poetry.lock", func() {
+			Expect(parse("/app/poetry.lock", `# This file is automatically @generated by Poetry and should not be changed by hand.
+
+[[package]]
+name = "Django"
+version = "4.2.1"
+description = "A high-level Python web framework."
+optional = false
+python-versions = ">=3.8"
+
+[package.dependencies]
+asgiref = ">=3.6.0,<4"
+
+[[package]]
+name = "zope.interface"
+version = "6.0"
+
+[metadata]
+lock-version = "2.0"
+`)).To(Equal([]LockfileDependency{
+				{Name: "django", Version: "4.2.1"},
+				{Name: "zope-interface", Version: "6.0"},
+			}))
+		})
+
+		It("Should reject a poetry.lock package without a version", func() {
+			_, err := ParseLockfile("/app/poetry.lock", []byte("[[package]]\nname = \"django\"\n"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should read the default and develop groups of a Pipfile.lock", func() {
+			Expect(parse("/app/Pipfile.lock", `{
+  "_meta": {"hash": {"sha256": "abc"}},
+  "default": {"Flask": {"version": "==2.3.2"}, "mylib": {"git": "https://github.com/example/mylib.git"}},
+  "develop": {"pytest": {"version": "==7.4.0"}}
+}`)).To(Equal([]LockfileDependency{
+				{Name: "flask", Version: "2.3.2"},
+				{Name: "pytest", Version: "7.4.0"},
+			}))
+		})
+
+		It("Should only read the pinned requirements of a requirements.txt", func() {
+			Expect(parse("/app/requirements.txt", `# generated by pip-compile
+-r base.txt
+-e git+https://github.com/example/mylib.git#egg=mylib
+celery[redis]==5.3.1 \
+    --hash=sha256:abc
+gunicorn>=20
+newrelic==9.0.0 ; python_version >= "3.7"  # the agent
+Flask_SQLAlchemy===3.0.5
+`)).To(Equal([]LockfileDependency{
+				{Name: "celery", Version: "5.3.1", Direct: true},
+				{Name: "flask-sqlalchemy", Version: "3.0.5", Direct: true},
+				{Name: "newrelic", Version: "9.0.0", Direct: true},
+			}))
+		})
+	})
+})
+
+var _ = Describe("ReadLockfiles()", func() {
+	It("Should parse the lockfiles of the ecosystem found in the directory", func() {
+		files := map[string]string{
+			"/app/Gemfile.lock":      "GEM\n  specs:\n    rails (7.0.4)\n",
+			"/app/yarn.lock":         "express@^4.16.4:\n  version \"4.16.4\"\n",
+			"/app/package-lock.json": "{",
+		}
+		lockfiles := ReadLockfiles("/app", "node", func(path string) ([]byte, error) {
+			content, ok := files[path]
+			if !ok {
+				return nil, errors.New("no such file")
+			}
+			return []byte(content), nil
+		})
+		Expect(lockfiles).To(Equal([]Lockfile{{
+			Path:         "/app/yarn.lock",
+			Ecosystem:    "node",
+			Dependencies: []LockfileDependency{{Name: "express", Version: "4.16.4"}},
+		}}))
+	})
+})
+
+var _ = Describe("LockfileVersionNumber()", func() {
+	It("Should drop the pre-release and local parts of a version", func() {
+		Expect(LockfileVersionNumber("7.0.0-beta.1")).To(Equal("7.0.0"))
+		Expect(LockfileVersionNumber("4.2rc1")).To(Equal("4.2"))
+		Expect(LockfileVersionNumber("latest")).To(Equal(""))
+	})
+})
@@ -15,6 +15,10 @@ type Result struct {
 	URL         string             // a URL pointing to documentation about this task; "required" on Warning or Failure, desireable on any status
 	FilesToCopy []FileCopyEnvelope // List of files identified by the task to be included in zip file
 	Payload     interface{}        // task defined list of returned data. This is what is used by downstream tasks so data format agreements are between tasks
+	// Remediations are the fixes the task offers for the problem it found. They are only applied in -fix mode and are not part of the output json
+	Remediations []Remediation `json:"-"`
+	// Redacted is set when secrets were removed from the payload. It marks nrdiag-output.json as redacted in the zip file manifest
+	Redacted bool `json:"-"`
 }
 
 // Status statusEnum listing of valid values for status